@@ -0,0 +1,12 @@
+// Command trader runs strategy bots, backtests and tooling as subcommands
+package main
+
+import (
+	"os"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:]))
+}
@@ -0,0 +1,205 @@
+// Momentum is an example strategy plugin.
+//
+// Build it with the same toolchain as the trader binary:
+//
+//	go build -buildmode=plugin -o plugins/momentum.so ./examples/plugins/momentum
+//
+// and run it with a config containing
+//
+//	"plugins": {"dir": "plugins"},
+//	"strategy": {"custom": {"type": "momentum", "config": {"symbol": "BTCUSDT", "amount": 50, "threshold": 0.01}}}
+//
+// An optional "id" tells instances apart, as for the built-in strategies.
+//
+// An optional "exit" object scales out with the shared exit manager, e.g.
+//
+//	"exit": {"targets": [{"profit": 0.03, "fraction": 0.5}, {"profit": 0.06, "fraction": 0.5}], "stop_loss": 0.02, "breakeven_after": 1}
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Register is looked up by the plugin loader
+func Register(registry *strategy.Registry) error {
+	return registry.Register("momentum", newMomentum)
+}
+
+// momentum buys a fixed quote amount after the price rises by threshold since the last tick
+type momentum struct {
+	strategy.Identity
+	symbol    string
+	amount    float64
+	threshold float64
+	exit      *strategy.ExitManager // nil without an exit config
+
+	exchange types.ExchangeClient
+	logger   *logger.Logger
+
+	mu        sync.Mutex
+	lastPrice float64
+	metrics   types.StrategyMetrics
+}
+
+func newMomentum(config map[string]interface{}, exchange types.ExchangeClient, log *logger.Logger) (strategy.Strategy, error) {
+	m := &momentum{Identity: strategy.IdentityFromConfig("momentum", config), symbol: "BTCUSDT", amount: 50, threshold: 0.01, exchange: exchange, logger: log}
+	if v, ok := config["symbol"].(string); ok {
+		m.symbol = v
+	}
+	if v, ok := config["amount"].(float64); ok {
+		m.amount = v
+	}
+	if v, ok := config["threshold"].(float64); ok {
+		m.threshold = v
+	}
+	exit, err := strategy.ParseExitConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if exit != nil {
+		if err := strategy.ValidateExit(*exit); err != nil {
+			return nil, err
+		}
+		m.exit = strategy.NewExitManager(*exit)
+	}
+	return m, m.ValidateConfig()
+}
+
+func (m *momentum) Execute(ctx context.Context, market types.MarketData) error {
+	if m.exit != nil {
+		if exited, err := m.executeExit(ctx, market); err != nil || exited {
+			m.mu.Lock()
+			m.lastPrice = market.Price
+			m.mu.Unlock()
+			return err
+		}
+	}
+
+	signal := m.GetSignal(market)
+
+	m.mu.Lock()
+	m.lastPrice = market.Price
+	m.mu.Unlock()
+
+	if signal.Type != types.SignalTypeBuy {
+		return nil
+	}
+
+	order := types.Order{
+		Symbol:    m.symbol,
+		Side:      types.OrderSideBuy,
+		Type:      types.OrderTypeMarket,
+		Quantity:  signal.Quantity,
+		Price:     market.Price,
+		Timestamp: market.Timestamp,
+	}
+	if err := m.exchange.PlaceOrder(ctx, order); err != nil {
+		return fmt.Errorf("failed to place momentum order: %w", err)
+	}
+
+	m.mu.Lock()
+	m.metrics.TotalTrades++
+	m.metrics.TotalVolume += m.amount
+	m.metrics.LastUpdate = time.Now()
+	if m.exit != nil {
+		m.exit.OnBuy(order.Quantity, market.Price)
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("Momentum buy %.8f %s @ %.2f", order.Quantity, m.symbol, market.Price)
+	return nil
+}
+
+// executeExit sells the part of the position due for exit, reporting whether it sold
+func (m *momentum) executeExit(ctx context.Context, market types.MarketData) (bool, error) {
+	m.mu.Lock()
+	exit, ok := m.exit.Check(market.Price)
+	_, entry := m.exit.Position()
+	m.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	order := types.Order{
+		Symbol:    m.symbol,
+		Side:      types.OrderSideSell,
+		Type:      types.OrderTypeMarket,
+		Quantity:  exit.Quantity,
+		Price:     market.Price,
+		Timestamp: market.Timestamp,
+	}
+	if err := m.exchange.PlaceOrder(ctx, order); err != nil {
+		return false, fmt.Errorf("failed to place momentum exit: %w", err)
+	}
+
+	realized := (market.Price - entry) * exit.Quantity
+	m.mu.Lock()
+	m.exit.Filled(exit)
+	m.metrics.TotalTrades++
+	m.metrics.TotalVolume += exit.Quantity * market.Price
+	if realized >= 0 {
+		m.metrics.WinningTrades++
+		m.metrics.TotalProfit += realized
+	} else {
+		m.metrics.LosingTrades++
+		m.metrics.TotalLoss += -realized
+	}
+	m.metrics.LastUpdate = time.Now()
+	m.mu.Unlock()
+
+	m.logger.Info("Momentum %s exit %.8f %s @ %.2f pnl=%.2f", exit.Reason, exit.Quantity, m.symbol, market.Price, realized)
+	return true, nil
+}
+
+func (m *momentum) GetSignal(market types.MarketData) types.Signal {
+	m.mu.Lock()
+	last := m.lastPrice
+	m.mu.Unlock()
+
+	signal := types.Signal{Type: types.SignalTypeHold, Symbol: m.symbol, Price: market.Price, Timestamp: market.Timestamp}
+	if last > 0 && market.Price > 0 && market.Price/last-1 >= m.threshold {
+		signal.Type = types.SignalTypeBuy
+		signal.Quantity = m.amount / market.Price
+		signal.Strength = market.Price/last - 1
+	}
+	return signal
+}
+
+func (m *momentum) ValidateConfig() error {
+	if m.symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if m.amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if m.threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	return nil
+}
+
+func (m *momentum) GetMetrics() types.StrategyMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.metrics
+}
+
+func (m *momentum) Shutdown(ctx context.Context) error {
+	m.logger.Info("Momentum strategy stopped")
+	return nil
+}
+
+// RequiredHistory returns nil: momentum compares each price with the last tick
+func (m *momentum) RequiredHistory() []strategy.HistoryRequirement {
+	return nil
+}
+
+// main is unused; the package is built with -buildmode=plugin
+func main() {}
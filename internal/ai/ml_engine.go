@@ -76,10 +76,15 @@ type OptimizedStrategy struct {
 
 type MachineLearningModel struct {
 	weights map[string]float64
+	fitted  *RegimeModel // classifies instead of the rules when set
 }
 
 // Predict predicts market regime based on features
 func (mlm *MachineLearningModel) Predict(features map[string]float64) RegimeType {
+	if mlm.fitted != nil {
+		return mlm.fitted.Predict(features)
+	}
+
 	// Simple rule-based prediction - can be enhanced with ML models
 	rsi, hasRSI := features["rsi"]
 	trend, hasTrend := features["trend"]
@@ -201,8 +206,11 @@ type RegimeDetector struct {
 
 // ClassifyMarket automatically classifies market conditions
 func (rd *RegimeDetector) ClassifyMarket(market types.MarketData) RegimeType {
-	features := rd.extractFeatures(market)
+	return rd.Classify(rd.extractFeatures(market))
+}
 
+// Classify returns the regime of already extracted features
+func (rd *RegimeDetector) Classify(features map[string]float64) RegimeType {
 	return rd.mlModel.Predict(features)
 }
 
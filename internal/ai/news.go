@@ -0,0 +1,148 @@
+package ai
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// coinNames are the names news use for common base assets besides the ticker
+var coinNames = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"SOL":  "solana",
+	"XRP":  "ripple",
+	"ADA":  "cardano",
+	"DOGE": "dogecoin",
+	"DOT":  "polkadot",
+	"AVAX": "avalanche",
+}
+
+// NewNewsSource reads RSS and Atom feeds
+func NewNewsSource(feeds ...NewsFeed) *NewsSource {
+	return &NewsSource{feeds: feeds}
+}
+
+// FetchData returns the title and summary of feed items published within
+// timeframe that mention symbol's base asset. Undated items are kept. It
+// fails only when every feed does.
+func (s *NewsSource) FetchData(ctx context.Context, symbol string, timeframe time.Duration) ([]string, error) {
+	keywords := symbolKeywords(symbol)
+	since := time.Now().Add(-timeframe)
+
+	var texts []string
+	var errs []error
+	for _, feed := range s.feeds {
+		items, err := readNewsFeed(ctx, feed.URL)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, item := range items {
+			if at, ok := item.published(); ok && at.Before(since) {
+				continue
+			}
+			text := item.text()
+			if mentions(text, keywords) {
+				texts = append(texts, text)
+			}
+		}
+	}
+	if len(errs) > 0 && len(errs) == len(s.feeds) {
+		return nil, errors.Join(errs...)
+	}
+	return texts, nil
+}
+
+// symbolKeywords are the lower-case words that mark news about symbol's base asset
+func symbolKeywords(symbol string) []string {
+	base, _, ok := types.SplitSymbol(symbol)
+	if !ok {
+		base = symbol
+	}
+	keywords := []string{strings.ToLower(base)}
+	if name, ok := coinNames[base]; ok {
+		keywords = append(keywords, name)
+	}
+	return keywords
+}
+
+// mentions reports whether text contains one of keywords as a word
+func mentions(text string, keywords []string) bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if slices.Contains(keywords, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// newsDocument is an RSS 2.0 or Atom feed
+type newsDocument struct {
+	Items   []newsItem `xml:"channel>item"`
+	Entries []newsItem `xml:"entry"`
+}
+
+// newsItem is an RSS item or Atom entry
+type newsItem struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Summary     string `xml:"summary"`
+	PubDate     string `xml:"pubDate"`
+	Published   string `xml:"published"`
+	Updated     string `xml:"updated"`
+}
+
+func (i newsItem) text() string {
+	return strings.TrimSpace(strings.Join([]string{i.Title, i.Description, i.Summary}, " "))
+}
+
+// published returns the item's date, false when it has none that parses
+func (i newsItem) published() (time.Time, bool) {
+	for _, value := range []string{i.PubDate, i.Published, i.Updated} {
+		for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339} {
+			if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// readNewsFeed fetches and parses the items of an RSS or Atom feed
+func readNewsFeed(ctx context.Context, url string) ([]newsItem, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create feed request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch news feed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("news feed %s returned status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read news feed: %w", err)
+	}
+
+	var doc newsDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse news feed %s: %w", url, err)
+	}
+	return append(doc.Items, doc.Entries...), nil
+}
@@ -0,0 +1,362 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// regimeNames are the regimes in RegimeType order
+var regimeNames = []string{"trending_up", "trending_down", "range_bound", "high_volatility", "low_volatility"}
+
+// String returns the regime's name, e.g. "trending_up"
+func (r RegimeType) String() string {
+	if r < 0 || int(r) >= len(regimeNames) {
+		return fmt.Sprintf("regime(%d)", int(r))
+	}
+	return regimeNames[r]
+}
+
+// MarshalText encodes the regime by name
+func (r RegimeType) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a regime name
+func (r *RegimeType) UnmarshalText(text []byte) error {
+	for i, name := range regimeNames {
+		if name == string(text) {
+			*r = RegimeType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown regime %q", text)
+}
+
+// modelFeatures are the features a fitted model classifies on. sma_20 is a
+// price level, which does not carry over between symbols or years.
+var modelFeatures = []string{"rsi", "trend", "volatility"}
+
+// minFeatureCandles is how many candles extractFeatures needs
+const minFeatureCandles = 20
+
+// LabelOptions sets how candles are auto-labeled by what followed them;
+// zero fields take defaults
+type LabelOptions struct {
+	Lookback        int     `json:"lookback"`          // candles features are computed over (default 50)
+	Horizon         int     `json:"horizon"`           // candles ahead the label looks at (default 24)
+	Trend           float64 `json:"trend"`             // forward return marking a trend (default 0.03)
+	HighVolQuantile float64 `json:"high_vol_quantile"` // forward volatility quantile above which a range is high volatility (default 0.8)
+	LowVolQuantile  float64 `json:"low_vol_quantile"`  // and below which it is low volatility (default 0.2)
+}
+
+func (o LabelOptions) withDefaults() LabelOptions {
+	if o.Lookback < minFeatureCandles {
+		o.Lookback = 50
+	}
+	if o.Horizon <= 0 {
+		o.Horizon = 24
+	}
+	if o.Trend <= 0 {
+		o.Trend = 0.03
+	}
+	if o.HighVolQuantile <= 0 || o.HighVolQuantile >= 1 {
+		o.HighVolQuantile = 0.8
+	}
+	if o.LowVolQuantile <= 0 || o.LowVolQuantile >= o.HighVolQuantile {
+		o.LowVolQuantile = 0.2
+	}
+	return o
+}
+
+// LabeledSample is the features at a candle and the regime that followed it
+type LabeledSample struct {
+	Time     time.Time          `json:"time"`
+	Features map[string]float64 `json:"features"`
+	Regime   RegimeType         `json:"regime"`
+}
+
+// LabelCandles labels every candle with enough history and future. The
+// return over the next Horizon candles marks a trend up or down beyond
+// Trend; otherwise the standard deviation of the returns over that horizon,
+// ranked against the whole series, marks high or low volatility, and the
+// rest are range bound. Candles must be chronological.
+func LabelCandles(candles []types.Candle, opts LabelOptions) []LabeledSample {
+	opts = opts.withDefaults()
+	first, last := opts.Lookback-1, len(candles)-1-opts.Horizon
+	if first > last {
+		return nil
+	}
+
+	vols := make([]float64, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		vols = append(vols, forwardVolatility(candles[i:i+opts.Horizon+1]))
+	}
+	sorted := append([]float64(nil), vols...)
+	sort.Float64s(sorted)
+	high, low := quantile(sorted, opts.HighVolQuantile), quantile(sorted, opts.LowVolQuantile)
+
+	detector := &RegimeDetector{}
+	samples := make([]LabeledSample, 0, len(vols))
+	for i := first; i <= last; i++ {
+		ret := candles[i+opts.Horizon].Close/candles[i].Close - 1
+		vol := vols[i-first]
+		regime := RangeBound
+		switch {
+		case ret > opts.Trend:
+			regime = TrendingUp
+		case ret < -opts.Trend:
+			regime = TrendingDown
+		case vol > high:
+			regime = HighVolatility
+		case vol < low:
+			regime = LowVolatility
+		}
+		features := detector.extractFeatures(types.MarketData{Candles: candles[i-opts.Lookback+1 : i+1]})
+		samples = append(samples, LabeledSample{Time: candles[i].Timestamp, Features: features, Regime: regime})
+	}
+	return samples
+}
+
+// forwardVolatility is the standard deviation of the candle-to-candle returns
+func forwardVolatility(candles []types.Candle) float64 {
+	var sum, sumSq float64
+	n := float64(len(candles) - 1)
+	for i := 1; i < len(candles); i++ {
+		r := candles[i].Close/candles[i-1].Close - 1
+		sum += r
+		sumSq += r * r
+	}
+	if n <= 0 {
+		return 0
+	}
+	mean := sum / n
+	return math.Sqrt(math.Max(sumSq/n-mean*mean, 0))
+}
+
+// quantile returns the q quantile of sorted values
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+// RegimeModel is a Gaussian naive Bayes classifier of market regimes fitted
+// on labeled samples. A RegimeDetector with a model classifies with it
+// instead of the built-in rules.
+type RegimeModel struct {
+	Features []string      `json:"features"`
+	Classes  []RegimeClass `json:"classes"`
+	Labeling LabelOptions  `json:"labeling"`
+	Samples  int           `json:"samples"`
+	Trained  time.Time     `json:"trained"`
+}
+
+// RegimeClass is one regime's prior and per-feature normal distribution
+type RegimeClass struct {
+	Regime   RegimeType `json:"regime"`
+	Prior    float64    `json:"prior"`
+	Mean     []float64  `json:"mean"`
+	Variance []float64  `json:"variance"`
+}
+
+// FitRegimeModel fits a model on samples. Regimes without samples are left
+// out and never predicted.
+func FitRegimeModel(samples []LabeledSample, labeling LabelOptions) (*RegimeModel, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no labeled samples to fit")
+	}
+	k := len(modelFeatures)
+	byRegime := make(map[RegimeType][][]float64)
+	for _, s := range samples {
+		byRegime[s.Regime] = append(byRegime[s.Regime], featureVector(s.Features))
+	}
+
+	// A variance floor keeps constant features from dominating the likelihood
+	overall := make([]float64, k)
+	for j := range overall {
+		_, overall[j] = meanVariance(samples, j)
+	}
+
+	model := &RegimeModel{Features: modelFeatures, Labeling: labeling.withDefaults(), Samples: len(samples), Trained: time.Now().UTC()}
+	for regime := TrendingUp; regime <= LowVolatility; regime++ {
+		vectors := byRegime[regime]
+		if len(vectors) == 0 {
+			continue
+		}
+		class := RegimeClass{Regime: regime, Prior: float64(len(vectors)) / float64(len(samples)), Mean: make([]float64, k), Variance: make([]float64, k)}
+		for _, v := range vectors {
+			for j := range v {
+				class.Mean[j] += v[j]
+			}
+		}
+		for j := range class.Mean {
+			class.Mean[j] /= float64(len(vectors))
+		}
+		for _, v := range vectors {
+			for j := range v {
+				d := v[j] - class.Mean[j]
+				class.Variance[j] += d * d
+			}
+		}
+		for j := range class.Variance {
+			class.Variance[j] = class.Variance[j]/float64(len(vectors)) + 1e-9*overall[j] + 1e-18
+		}
+		model.Classes = append(model.Classes, class)
+	}
+	return model, nil
+}
+
+// featureVector orders features as modelFeatures; missing ones are 0
+func featureVector(features map[string]float64) []float64 {
+	v := make([]float64, len(modelFeatures))
+	for j, name := range modelFeatures {
+		v[j] = features[name]
+	}
+	return v
+}
+
+func meanVariance(samples []LabeledSample, j int) (float64, float64) {
+	var sum, sumSq float64
+	for _, s := range samples {
+		x := s.Features[modelFeatures[j]]
+		sum += x
+		sumSq += x * x
+	}
+	n := float64(len(samples))
+	mean := sum / n
+	return mean, math.Max(sumSq/n-mean*mean, 0)
+}
+
+// Predict returns the most likely regime for features; RangeBound when the
+// features are incomplete, as with the rules
+func (m *RegimeModel) Predict(features map[string]float64) RegimeType {
+	for _, name := range m.Features {
+		if _, ok := features[name]; !ok {
+			return RangeBound
+		}
+	}
+	v := featureVector(features)
+	best, bestLog := RangeBound, math.Inf(-1)
+	for _, class := range m.Classes {
+		logP := math.Log(class.Prior)
+		for j, x := range v {
+			d := x - class.Mean[j]
+			logP -= 0.5*math.Log(2*math.Pi*class.Variance[j]) + d*d/(2*class.Variance[j])
+		}
+		if logP > bestLog {
+			best, bestLog = class.Regime, logP
+		}
+	}
+	return best
+}
+
+// Save writes the model as JSON
+func (m *RegimeModel) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode regime model: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write regime model: %w", err)
+	}
+	return nil
+}
+
+// LoadRegimeModel reads a model written by Save
+func LoadRegimeModel(path string) (*RegimeModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read regime model: %w", err)
+	}
+	var m RegimeModel
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode regime model %s: %w", path, err)
+	}
+	if len(m.Classes) == 0 {
+		return nil, fmt.Errorf("regime model %s has no classes", path)
+	}
+	for _, class := range m.Classes {
+		if len(class.Mean) != len(m.Features) || len(class.Variance) != len(m.Features) {
+			return nil, fmt.Errorf("regime model %s: %s does not match its %d features", path, class.Regime, len(m.Features))
+		}
+	}
+	return &m, nil
+}
+
+// NewRegimeDetector creates a detector classifying with model, or with the
+// built-in rules when model is nil
+func NewRegimeDetector(model *RegimeModel) *RegimeDetector {
+	return &RegimeDetector{mlModel: &MachineLearningModel{fitted: model}}
+}
+
+// LoadRegimeDetector creates a detector from a model file written by
+// trader regime -model-out
+func LoadRegimeDetector(path string) (*RegimeDetector, error) {
+	model, err := LoadRegimeModel(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewRegimeDetector(model), nil
+}
+
+// ConfusionMatrix counts actual regimes (rows) against predicted ones
+// (columns), in RegimeType order
+type ConfusionMatrix struct {
+	Labels   []string     `json:"labels"`
+	Counts   [][]int      `json:"counts"`
+	Accuracy float64      `json:"accuracy"`
+	Classes  []ClassScore `json:"classes"`
+}
+
+// ClassScore is how well one regime was recognized
+type ClassScore struct {
+	Regime    string  `json:"regime"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	Support   int     `json:"support"` // samples labeled with the regime
+}
+
+// EvaluateRegimes classifies each sample's features with predict and
+// tallies the results against the labels
+func EvaluateRegimes(samples []LabeledSample, predict func(map[string]float64) RegimeType) ConfusionMatrix {
+	n := len(regimeNames)
+	cm := ConfusionMatrix{Labels: regimeNames, Counts: make([][]int, n)}
+	for i := range cm.Counts {
+		cm.Counts[i] = make([]int, n)
+	}
+	correct := 0
+	for _, s := range samples {
+		predicted := predict(s.Features)
+		cm.Counts[s.Regime][predicted]++
+		if predicted == s.Regime {
+			correct++
+		}
+	}
+	if len(samples) > 0 {
+		cm.Accuracy = float64(correct) / float64(len(samples))
+	}
+
+	for i := 0; i < n; i++ {
+		score := ClassScore{Regime: regimeNames[i]}
+		predicted := 0
+		for j := 0; j < n; j++ {
+			score.Support += cm.Counts[i][j]
+			predicted += cm.Counts[j][i]
+		}
+		if predicted > 0 {
+			score.Precision = float64(cm.Counts[i][i]) / float64(predicted)
+		}
+		if score.Support > 0 {
+			score.Recall = float64(cm.Counts[i][i]) / float64(score.Support)
+		}
+		cm.Classes = append(cm.Classes, score)
+	}
+	return cm
+}
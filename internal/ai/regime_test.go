@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// regimeCandles rises, then moves sideways in a tight range, then falls
+func regimeCandles() []types.Candle {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []types.Candle
+	price := 100.0
+	for i := 0; i < 600; i++ {
+		switch {
+		case i < 200:
+			price *= 1.004
+		case i < 400:
+			price *= 1 + 0.001*math.Sin(float64(i))
+		default:
+			price *= 0.996
+		}
+		candles = append(candles, types.Candle{Open: price, High: price, Low: price, Close: price, Timestamp: start.Add(time.Duration(i) * time.Hour)})
+	}
+	return candles
+}
+
+func TestRegimeModel_FitEvaluateSave(t *testing.T) {
+	samples := LabelCandles(regimeCandles(), LabelOptions{})
+	if len(samples) != 600-49-24 {
+		t.Fatalf("samples = %d, want one per candle with 50 behind and 24 ahead", len(samples))
+	}
+	if first, last := samples[0].Regime, samples[len(samples)-1].Regime; first != TrendingUp || last != TrendingDown {
+		t.Fatalf("first and last labels = %s, %s; want trending_up and trending_down", first, last)
+	}
+
+	model, err := FitRegimeModel(samples, LabelOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := EvaluateRegimes(samples, model.Predict)
+	if cm.Accuracy < 0.8 {
+		t.Errorf("accuracy = %.2f on the training samples, want at least 0.8: %+v", cm.Accuracy, cm.Counts)
+	}
+	if up := cm.Classes[TrendingUp]; up.Support == 0 || up.Recall < 0.8 {
+		t.Errorf("trending_up score = %+v", up)
+	}
+
+	path := filepath.Join(t.TempDir(), "regime.json")
+	if err := model.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	detector, err := LoadRegimeDetector(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	market := types.MarketData{Candles: regimeCandles()[100:150]}
+	if got := detector.ClassifyMarket(market); got != TrendingUp {
+		t.Errorf("loaded detector classified a rally as %s", got)
+	}
+}
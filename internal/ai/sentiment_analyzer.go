@@ -2,6 +2,9 @@ package ai
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/nlp"
@@ -23,6 +26,17 @@ type AggregatedSentiment struct {
 	Confidence float64   `json:"confidence"`
 	Timestamp  time.Time `json:"timestamp"`
 	Sources    int       `json:"sources"`
+
+	// BySource breaks the score down by named source
+	BySource map[string]SourceSentiment `json:"by_source,omitempty"`
+}
+
+// SourceSentiment is one source's share of an aggregated score
+type SourceSentiment struct {
+	Sentiment  float64 `json:"sentiment"`
+	Confidence float64 `json:"confidence"`
+	Weight     float64 `json:"weight"`
+	Texts      int     `json:"texts"`
 }
 
 // Aggregate combines sentiment data from multiple sources
@@ -38,6 +52,7 @@ func (sa *SentimentAggregator) Aggregate(sentiments []SentimentData) *Aggregated
 	totalSentiment := 0.0
 	totalConfidence := 0.0
 	totalWeight := 0.0
+	bySource := make(map[string]SourceSentiment)
 
 	for _, sentiment := range sentiments {
 		weight := sa.weights[sentiment.Source]
@@ -48,11 +63,28 @@ func (sa *SentimentAggregator) Aggregate(sentiments []SentimentData) *Aggregated
 		totalSentiment += sentiment.Sentiment * weight
 		totalConfidence += sentiment.Confidence * weight
 		totalWeight += weight
+
+		if sentiment.Source != "" {
+			source := bySource[sentiment.Source]
+			source.Sentiment += sentiment.Sentiment
+			source.Confidence += sentiment.Confidence
+			source.Weight = weight
+			source.Texts++
+			bySource[sentiment.Source] = source
+		}
 	}
 
 	if totalWeight == 0 {
 		totalWeight = float64(len(sentiments))
 	}
+	for name, source := range bySource {
+		source.Sentiment /= float64(source.Texts)
+		source.Confidence /= float64(source.Texts)
+		bySource[name] = source
+	}
+	if len(bySource) == 0 {
+		bySource = nil
+	}
 
 	return &AggregatedSentiment{
 		Symbol:     sentiments[0].Symbol,
@@ -60,6 +92,7 @@ func (sa *SentimentAggregator) Aggregate(sentiments []SentimentData) *Aggregated
 		Confidence: totalConfidence / totalWeight,
 		Timestamp:  time.Now(),
 		Sources:    len(sentiments),
+		BySource:   bySource,
 	}
 }
 
@@ -72,41 +105,82 @@ type SentimentData struct {
 	Volume     int       `json:"mention_volume"`
 }
 
-// AnalyzeMarketSentiment processes multiple data sources concurrently
+// NewSentimentAnalyzer scores the texts of sources, keyed by name, with the
+// default dictionaries; weights scale each source's say (default 1)
+func NewSentimentAnalyzer(sources map[string]DataSource, weights map[string]float64) *SentimentAnalyzer {
+	processor := nlp.NewProcessor()
+	processor.InitializeDefaultDictionaries()
+	if weights == nil {
+		weights = make(map[string]float64)
+	}
+	return &SentimentAnalyzer{
+		nlpProcessor: processor,
+		dataSources:  sources,
+		aggregator:   &SentimentAggregator{weights: weights},
+	}
+}
+
+// Score scores one text from source
+func (sa *SentimentAnalyzer) Score(source, symbol, text string, at time.Time) SentimentData {
+	processed := sa.nlpProcessor.ProcessText(text)
+	return SentimentData{
+		Source:     source,
+		Symbol:     symbol,
+		Sentiment:  processed.Score,
+		Confidence: processed.Confidence,
+		Timestamp:  at,
+		Volume:     processed.MentionCount,
+	}
+}
+
+// Aggregate combines scored texts with the analyzer's source weights
+func (sa *SentimentAnalyzer) Aggregate(symbol string, at time.Time, sentiments []SentimentData) *AggregatedSentiment {
+	aggregated := sa.aggregator.Aggregate(sentiments)
+	aggregated.Symbol, aggregated.Timestamp = symbol, at
+	return aggregated
+}
+
+// AnalyzeMarketSentiment processes multiple data sources concurrently. It
+// fails only when every source does.
 func (sa *SentimentAnalyzer) AnalyzeMarketSentiment(
 	ctx context.Context,
 	symbol string,
 	timeframe time.Duration,
 ) (*AggregatedSentiment, error) {
 
-	var sentiments []SentimentData
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		sentiments []SentimentData
+		errs       []error
+	)
+	now := time.Now()
 
 	// Process multiple sources in parallel
 	for sourceName, source := range sa.dataSources {
+		wg.Add(1)
 		go func(name string, src DataSource) {
+			defer wg.Done()
 			data, err := src.FetchData(ctx, symbol, timeframe)
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
 				return
 			}
 
 			// Process each text item
 			for _, text := range data {
-				processed := sa.nlpProcessor.ProcessText(text)
-				sentiment := SentimentData{
-					Source:     name,
-					Symbol:     symbol,
-					Sentiment:  processed.Score,
-					Confidence: processed.Confidence,
-					Timestamp:  time.Now(),
-					Volume:     processed.MentionCount,
-				}
-
-				sentiments = append(sentiments, sentiment)
+				sentiments = append(sentiments, sa.Score(name, symbol, text, now))
 			}
 		}(sourceName, source)
 	}
+	wg.Wait()
 
-	return sa.aggregator.Aggregate(sentiments), nil
+	if len(errs) > 0 && len(errs) == len(sa.dataSources) {
+		return nil, fmt.Errorf("no sentiment source answered: %w", errors.Join(errs...))
+	}
+	return sa.Aggregate(symbol, now, sentiments), nil
 }
 
 type DataSource interface {
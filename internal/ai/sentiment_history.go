@@ -0,0 +1,370 @@
+package ai
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// SentimentConfig configures recording sentiment from news feeds (disabled without feeds)
+type SentimentConfig struct {
+	Feeds   []string           `json:"feeds"`   // RSS or Atom URLs
+	Weights map[string]float64 `json:"weights"` // by feed URL (default 1)
+	Symbols []string           `json:"symbols"` // recorded symbols (default the traded symbol)
+
+	// Interval is how often sentiment is recorded and the span of news each
+	// recording scores (default 15m)
+	Interval time.Duration `json:"interval"`
+
+	// MomentumWindow is the span sentiment momentum is measured over (default 24h)
+	MomentumWindow time.Duration `json:"momentum_window"`
+
+	Alerts SentimentAlerts `json:"alerts"`
+}
+
+// SentimentAlerts are the scores beyond which sentiment is extreme; 0
+// disables a side
+type SentimentAlerts struct {
+	High float64 `json:"high"` // in (0, 1]
+	Low  float64 `json:"low"`  // in [-1, 0)
+}
+
+// Sentiment extremes reported by SentimentAlerts.Extreme
+const (
+	SentimentHigh = "high"
+	SentimentLow  = "low"
+)
+
+// Extreme returns SentimentHigh or SentimentLow when score reaches a
+// threshold, "" otherwise
+func (a SentimentAlerts) Extreme(score float64) string {
+	switch {
+	case a.High > 0 && score >= a.High:
+		return SentimentHigh
+	case a.Low < 0 && score <= a.Low:
+		return SentimentLow
+	}
+	return ""
+}
+
+// UnmarshalJSON implements custom parsing for durations ("15m", "24h")
+func (c *SentimentConfig) UnmarshalJSON(data []byte) error {
+	type Alias SentimentConfig
+	aux := &struct {
+		Interval       string `json:"interval"`
+		MomentumWindow string `json:"momentum_window"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"interval", aux.Interval, &c.Interval},
+		{"momentum_window", aux.MomentumWindow, &c.MomentumWindow},
+	} {
+		if field.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(field.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s format: %w", field.name, err)
+		}
+		*field.dst = duration
+	}
+
+	return nil
+}
+
+// Enabled reports whether any feed is configured
+func (c SentimentConfig) Enabled() bool {
+	return len(c.Feeds) > 0
+}
+
+// Validate checks the config
+func (c SentimentConfig) Validate() error {
+	if c.Interval < 0 || c.MomentumWindow < 0 {
+		return fmt.Errorf("sentiment durations must not be negative")
+	}
+	if c.Alerts.High < 0 || c.Alerts.High > 1 {
+		return fmt.Errorf("alerts.high must be within (0, 1]")
+	}
+	if c.Alerts.Low > 0 || c.Alerts.Low < -1 {
+		return fmt.Errorf("alerts.low must be within [-1, 0)")
+	}
+	for feed, weight := range c.Weights {
+		if weight < 0 {
+			return fmt.Errorf("weight of %s must not be negative", feed)
+		}
+	}
+	return nil
+}
+
+// WithDefaults fills in the default interval and momentum window
+func (c SentimentConfig) WithDefaults() SentimentConfig {
+	if c.Interval <= 0 {
+		c.Interval = 15 * time.Minute
+	}
+	if c.MomentumWindow <= 0 {
+		c.MomentumWindow = 24 * time.Hour
+	}
+	return c
+}
+
+// NewFeedAnalyzer creates an analyzer over the config's feeds, each a source
+// named by its URL
+func (c SentimentConfig) NewFeedAnalyzer() *SentimentAnalyzer {
+	sources := make(map[string]DataSource, len(c.Feeds))
+	for _, feed := range c.Feeds {
+		sources[feed] = NewNewsSource(NewsFeed{URL: feed, Category: "news"})
+	}
+	return NewSentimentAnalyzer(sources, c.Weights)
+}
+
+// SentimentHistory is recorded sentiment per symbol, oldest first
+type SentimentHistory struct {
+	mu     sync.RWMutex
+	points map[string][]AggregatedSentiment
+}
+
+// NewSentimentHistory creates a history holding points
+func NewSentimentHistory(points ...AggregatedSentiment) *SentimentHistory {
+	h := &SentimentHistory{points: make(map[string][]AggregatedSentiment)}
+	h.Add(points...)
+	return h
+}
+
+// Add records points in time order; a point replaces one of its symbol at
+// the same time
+func (h *SentimentHistory) Add(points ...AggregatedSentiment) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, p := range points {
+		series := h.points[p.Symbol]
+		i := sort.Search(len(series), func(i int) bool { return !series[i].Timestamp.Before(p.Timestamp) })
+		if i < len(series) && series[i].Timestamp.Equal(p.Timestamp) {
+			series[i] = p
+			continue
+		}
+		h.points[p.Symbol] = slices.Insert(series, i, p)
+	}
+}
+
+// Symbols returns the symbols with recorded sentiment, sorted
+func (h *SentimentHistory) Symbols() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	symbols := make([]string, 0, len(h.points))
+	for symbol := range h.points {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// At returns symbol's last point recorded by t
+func (h *SentimentHistory) At(symbol string, t time.Time) (AggregatedSentiment, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.at(symbol, t)
+}
+
+func (h *SentimentHistory) at(symbol string, t time.Time) (AggregatedSentiment, bool) {
+	series := h.points[symbol]
+	i := sort.Search(len(series), func(i int) bool { return series[i].Timestamp.After(t) })
+	if i == 0 {
+		return AggregatedSentiment{}, false
+	}
+	return series[i-1], true
+}
+
+// Series returns symbol's points within [from, to]; zero bounds are open
+func (h *SentimentHistory) Series(symbol string, from, to time.Time) []AggregatedSentiment {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var points []AggregatedSentiment
+	for _, p := range h.points[symbol] {
+		if (!from.IsZero() && p.Timestamp.Before(from)) || (!to.IsZero() && p.Timestamp.After(to)) {
+			continue
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// Reading returns symbol's sentiment as of t with its momentum over window,
+// nil when nothing was recorded by t. Momentum is 0 until the history
+// reaches back window.
+func (h *SentimentHistory) Reading(symbol string, t time.Time, window time.Duration) *types.Sentiment {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	latest, ok := h.at(symbol, t)
+	if !ok {
+		return nil
+	}
+	reading := &types.Sentiment{Score: latest.Sentiment, Confidence: latest.Confidence, Timestamp: latest.Timestamp}
+	if earlier, ok := h.at(symbol, t.Add(-window)); ok && window > 0 {
+		reading.Momentum = latest.Sentiment - earlier.Sentiment
+	}
+	return reading
+}
+
+// SentimentTrend is how a symbol's sentiment moved over a window
+type SentimentTrend struct {
+	Window    string                `json:"window"`
+	Momentum  float64               `json:"momentum"`  // latest score minus the score a window earlier
+	Direction string                `json:"direction"` // rising, falling or flat
+	Points    []AggregatedSentiment `json:"points"`    // recorded within the window
+}
+
+// Trend returns symbol's sentiment trend over the window ending at t
+func (h *SentimentHistory) Trend(symbol string, t time.Time, window time.Duration) SentimentTrend {
+	trend := SentimentTrend{Window: window.String(), Direction: "flat"}
+	if reading := h.Reading(symbol, t, window); reading != nil {
+		trend.Momentum = reading.Momentum
+	}
+	switch {
+	case trend.Momentum > 0:
+		trend.Direction = "rising"
+	case trend.Momentum < 0:
+		trend.Direction = "falling"
+	}
+	trend.Points = h.Series(symbol, t.Add(-window), t)
+	return trend
+}
+
+// SentimentRecord is a historical text, or an already computed score, to
+// backfill sentiment from
+type SentimentRecord struct {
+	Time       time.Time
+	Symbol     string
+	Source     string
+	Text       string
+	Score      float64
+	Confidence float64
+	Scored     bool // Score is given, Text is not scored
+}
+
+// ReadSentimentCSV reads backfill records from CSV with a header naming the
+// columns: timestamp (RFC3339 or unix seconds), symbol, and text or score,
+// optionally source and confidence
+func ReadSentimentCSV(r io.Reader) ([]SentimentRecord, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sentiment header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasText := columns["text"]
+	_, hasScore := columns["score"]
+	for _, required := range []string{"timestamp", "symbol"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("sentiment CSV has no %s column", required)
+		}
+	}
+	if !hasText && !hasScore {
+		return nil, fmt.Errorf("sentiment CSV needs a text or score column")
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var records []SentimentRecord
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sentiment line %d: %w", line, err)
+		}
+		at, err := parseSentimentTime(field(row, "timestamp"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		record := SentimentRecord{Time: at, Symbol: strings.ToUpper(field(row, "symbol")), Source: field(row, "source"), Text: field(row, "text")}
+		if record.Symbol == "" {
+			return nil, fmt.Errorf("line %d: symbol is required", line)
+		}
+		if score := field(row, "score"); hasScore && score != "" {
+			if record.Score, err = strconv.ParseFloat(score, 64); err != nil || record.Score < -1 || record.Score > 1 {
+				return nil, fmt.Errorf("line %d: score must be a number within [-1, 1]", line)
+			}
+			record.Scored, record.Confidence = true, 1
+			if confidence := field(row, "confidence"); confidence != "" {
+				if record.Confidence, err = strconv.ParseFloat(confidence, 64); err != nil {
+					return nil, fmt.Errorf("line %d: invalid confidence: %w", line, err)
+				}
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+func parseSentimentTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// Backfill aggregates records into one point per symbol and interval. A
+// point is stamped with the end of its interval, so a reading at a time only
+// reflects texts from before it.
+func (sa *SentimentAnalyzer) Backfill(records []SentimentRecord, interval time.Duration) []AggregatedSentiment {
+	type bucket struct {
+		symbol string
+		end    time.Time
+	}
+	buckets := make(map[bucket][]SentimentData)
+	for _, r := range records {
+		data := SentimentData{Source: r.Source, Symbol: r.Symbol, Sentiment: r.Score, Confidence: r.Confidence, Timestamp: r.Time}
+		if !r.Scored {
+			data = sa.Score(r.Source, r.Symbol, r.Text, r.Time)
+		}
+		b := bucket{symbol: r.Symbol, end: r.Time.Truncate(interval).Add(interval)}
+		buckets[b] = append(buckets[b], data)
+	}
+
+	points := make([]AggregatedSentiment, 0, len(buckets))
+	for b, sentiments := range buckets {
+		points = append(points, *sa.Aggregate(b.symbol, b.end, sentiments))
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if !points[i].Timestamp.Equal(points[j].Timestamp) {
+			return points[i].Timestamp.Before(points[j].Timestamp)
+		}
+		return points[i].Symbol < points[j].Symbol
+	})
+	return points
+}
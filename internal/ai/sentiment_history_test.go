@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSentimentAnalyzer_Backfill(t *testing.T) {
+	records, err := ReadSentimentCSV(strings.NewReader(`timestamp,symbol,source,text,score
+2024-01-01T00:10:00Z,btcusdt,news,Bitcoin rally as ETF inflows surge,
+2024-01-01T00:40:00Z,BTCUSDT,social,,-0.5
+1704070800,BTCUSDT,news,,-0.8
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0].Symbol != "BTCUSDT" || records[0].Scored || !records[1].Scored {
+		t.Fatalf("records = %+v", records)
+	}
+
+	points := NewSentimentAnalyzer(nil, nil).Backfill(records, time.Hour)
+	if len(points) != 2 {
+		t.Fatalf("Backfill() = %d points, want 2", len(points))
+	}
+	first, second := points[0], points[1]
+	if !first.Timestamp.Equal(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)) || first.Sources != 2 {
+		t.Errorf("first point = %+v, want two sources stamped at the end of its hour", first)
+	}
+	if social, ok := first.BySource["social"]; !ok || social.Sentiment != -0.5 || len(first.BySource) != 2 {
+		t.Errorf("first point by source = %+v, want news and social", first.BySource)
+	}
+	if second.Sentiment != -0.8 {
+		t.Errorf("second point sentiment = %v, want -0.8", second.Sentiment)
+	}
+
+	history := NewSentimentHistory(points...)
+	if r := history.Reading("BTCUSDT", time.Date(2024, 1, 1, 0, 59, 0, 0, time.UTC), time.Hour); r != nil {
+		t.Errorf("Reading() before the first point = %+v, want nil", r)
+	}
+	r := history.Reading("BTCUSDT", time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC), time.Hour)
+	if r == nil || r.Score != -0.8 || r.Momentum != -0.8-first.Sentiment {
+		t.Errorf("Reading() = %+v, want the second point with momentum from the first", r)
+	}
+
+	trend := history.Trend("BTCUSDT", time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC), time.Hour)
+	if trend.Direction != "falling" || len(trend.Points) != 1 {
+		t.Errorf("Trend() = %+v, want falling with the second point", trend)
+	}
+	alerts := SentimentAlerts{High: 0.7, Low: -0.7}
+	if alerts.Extreme(-0.8) != SentimentLow || alerts.Extreme(0.7) != SentimentHigh || alerts.Extreme(0.5) != "" {
+		t.Error("Extreme() does not match the thresholds")
+	}
+
+	for name, csv := range map[string]string{
+		"no symbol":   "timestamp,score\n2024-01-01T00:00:00Z,0.1\n",
+		"bad score":   "timestamp,symbol,score\n2024-01-01T00:00:00Z,BTCUSDT,2\n",
+		"bad time":    "timestamp,symbol,score\nyesterday,BTCUSDT,0.1\n",
+		"no contents": "timestamp,symbol\n2024-01-01T00:00:00Z,BTCUSDT\n",
+	} {
+		if _, err := ReadSentimentCSV(strings.NewReader(csv)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"math"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Capacity limits
+const (
+	LimitBook      = "book"       // slippage of one order reaches the edge
+	LimitBookDepth = "book_depth" // orders outgrow the visible book first
+	LimitVolume    = "volume"     // daily orders reach the participation cap
+)
+
+// CapacityInput describes how a strategy trades one symbol at its
+// configured capital. Orders are assumed to grow in proportion to capital.
+type CapacityInput struct {
+	Strategy     string
+	Symbol       string
+	Capital      float64 // quote capital the strategy is configured with
+	OrderSize    float64 // quote notional of one order at that capital
+	OrdersPerDay float64 // 0 when unknown: the volume limit is skipped
+
+	EdgeBps          float64 // expected return per order before costs, in basis points
+	MaxParticipation float64 // largest share of daily volume to trade, e.g. 0.01
+}
+
+// CapacityReport is the capital at which a strategy's trading costs would
+// consume its expected returns
+type CapacityReport struct {
+	Strategy    string  `json:"strategy"`
+	Symbol      string  `json:"symbol"`
+	Capital     float64 `json:"capital"`
+	OrderSize   float64 `json:"order_size"`
+	DailyVolume float64 `json:"daily_volume"` // quote volume over the last day
+	SlippageBps float64 `json:"slippage_bps"` // of one order at the configured size, against the mid
+
+	BookCapacity   float64 `json:"book_capacity"`             // capital at which one order's slippage equals the edge
+	VolumeCapacity float64 `json:"volume_capacity,omitempty"` // capital at which daily orders reach the participation cap
+	Capacity       float64 `json:"capacity"`                  // the smaller of the two
+	Limit          string  `json:"limit"`                     // LimitBook, LimitBookDepth or LimitVolume
+	Utilization    float64 `json:"utilization"`               // capital over capacity
+}
+
+// BookSlippage returns the cost in basis points of a market order of
+// notional quote against the book's mid price: the worse of a buy walking
+// the asks and a sell walking the bids. ok is false when the book is too
+// shallow to fill it.
+func BookSlippage(book *types.OrderBook, notional float64) (float64, bool) {
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return 0, false
+	}
+	mid := (book.Bids[0].Price + book.Asks[0].Price) / 2
+	buy, buyOK := walkBook(book.Asks, notional)
+	sell, sellOK := walkBook(book.Bids, notional)
+	if !buyOK || !sellOK {
+		return 0, false
+	}
+	return math.Max(buy-mid, mid-sell) / mid * 10000, true
+}
+
+// walkBook returns the average price filling notional quote across levels
+func walkBook(levels []types.OrderBookEntry, notional float64) (float64, bool) {
+	if notional <= 0 {
+		return levels[0].Price, true
+	}
+	var filled, quantity float64
+	for _, level := range levels {
+		take := math.Min(level.Price*level.Amount, notional-filled)
+		filled += take
+		quantity += take / level.Price
+		if filled >= notional {
+			return filled / quantity, true
+		}
+	}
+	return 0, false
+}
+
+// bookDepth returns the quote notional both sides of the book can fill
+func bookDepth(book *types.OrderBook) float64 {
+	side := func(levels []types.OrderBookEntry) float64 {
+		var total float64
+		for _, level := range levels {
+			total += level.Price * level.Amount
+		}
+		return total
+	}
+	return math.Min(side(book.Bids), side(book.Asks))
+}
+
+// EstimateCapacity estimates the capital in.Strategy can trade in.Symbol
+// with before costs outweigh its edge, from a snapshot of the book and the
+// symbol's daily quote volume
+func EstimateCapacity(in CapacityInput, book *types.OrderBook, dailyVolume float64) CapacityReport {
+	report := CapacityReport{Strategy: in.Strategy, Symbol: in.Symbol, Capital: in.Capital, OrderSize: in.OrderSize, DailyVolume: dailyVolume}
+	if in.Capital <= 0 || in.OrderSize <= 0 || book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return report
+	}
+	report.SlippageBps, _ = BookSlippage(book, in.OrderSize)
+
+	// Slippage grows with order size, so bisect on it up to the book's depth
+	depth := bookDepth(book)
+	report.Limit = LimitBookDepth
+	low, high := 0.0, depth
+	if slippage, _ := BookSlippage(book, depth); slippage >= in.EdgeBps {
+		report.Limit = LimitBook
+		for iter := 0; iter < 60; iter++ {
+			mid := (low + high) / 2
+			if slippage, ok := BookSlippage(book, mid); ok && slippage < in.EdgeBps {
+				low = mid
+			} else {
+				high = mid
+			}
+		}
+		high = low
+	}
+	report.BookCapacity = high / in.OrderSize * in.Capital
+	report.Capacity = report.BookCapacity
+
+	if in.OrdersPerDay > 0 && in.MaxParticipation > 0 && dailyVolume > 0 {
+		report.VolumeCapacity = in.MaxParticipation * dailyVolume / (in.OrdersPerDay * in.OrderSize) * in.Capital
+		if report.VolumeCapacity < report.Capacity {
+			report.Capacity, report.Limit = report.VolumeCapacity, LimitVolume
+		}
+	}
+	if report.Capacity > 0 {
+		report.Utilization = in.Capital / report.Capacity
+	}
+	return report
+}
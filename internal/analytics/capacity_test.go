@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestEstimateCapacity(t *testing.T) {
+	book := &types.OrderBook{
+		Symbol: "BTCUSDT",
+		Bids:   []types.OrderBookEntry{{Price: 99.9, Amount: 10}, {Price: 99, Amount: 10}},
+		Asks:   []types.OrderBookEntry{{Price: 100.1, Amount: 10}, {Price: 101, Amount: 10}},
+	}
+	in := CapacityInput{Strategy: "dca", Symbol: "BTCUSDT", Capital: 5000, OrderSize: 500, EdgeBps: 25}
+
+	report := EstimateCapacity(in, book, 0)
+	if math.Abs(report.SlippageBps-10) > 1e-9 {
+		t.Errorf("slippage of a 500 order = %.4f bps, want the 10 bps half spread", report.SlippageBps)
+	}
+	if report.Limit != LimitBook {
+		t.Fatalf("limit = %s, want %s", report.Limit, LimitBook)
+	}
+	// At capacity one order costs the whole edge
+	order := report.BookCapacity / in.Capital * in.OrderSize
+	if slippage, ok := BookSlippage(book, order); !ok || math.Abs(slippage-25) > 1e-6 {
+		t.Errorf("slippage at capacity = %.6f bps, want 25", slippage)
+	}
+	if math.Abs(report.Utilization-in.Capital/report.Capacity) > 1e-12 || report.Utilization >= 1 {
+		t.Errorf("utilization = %v", report.Utilization)
+	}
+
+	// One order a day may trade 1% of 100000: 1000, twice the configured size
+	in.OrdersPerDay, in.MaxParticipation = 1, 0.01
+	report = EstimateCapacity(in, book, 100000)
+	if report.Limit != LimitVolume || math.Abs(report.Capacity-10000) > 1e-9 {
+		t.Errorf("capacity = %.2f (%s), want 10000 limited by volume", report.Capacity, report.Limit)
+	}
+
+	// An edge the whole book cannot eat is bounded by its depth
+	in.EdgeBps, in.OrdersPerDay = 500, 0
+	report = EstimateCapacity(in, book, 0)
+	if report.Limit != LimitBookDepth || math.Abs(report.Capacity-1989*10) > 1e-6 {
+		t.Errorf("capacity = %.2f (%s), want the 1989 deep bids at 10x", report.Capacity, report.Limit)
+	}
+	if _, ok := BookSlippage(book, 5000); ok {
+		t.Error("Expected an order beyond the book to be unfillable")
+	}
+}
@@ -0,0 +1,217 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// tradingDaysPerYear annualizes daily statistics; crypto trades every day
+const tradingDaysPerYear = 365
+
+// EquityPoint is one snapshot of account equity
+type EquityPoint struct {
+	Time      time.Time `json:"time"`
+	Equity    float64   `json:"equity"`
+	Benchmark float64   `json:"benchmark,omitempty"` // price of the benchmark asset, in the same currency
+}
+
+// DailyReturn is a UTC day's closing equity and its return over the previous close
+type DailyReturn struct {
+	Date   time.Time `json:"date"`
+	Equity float64   `json:"equity"`
+	Return float64   `json:"return"`
+}
+
+// DailyReturns closes each UTC day at its last snapshot. The first day has
+// no return; days without snapshots are skipped, so a return after a gap
+// spans the whole gap.
+func DailyReturns(points []EquityPoint) []DailyReturn {
+	points = sortedPoints(points)
+
+	var days []DailyReturn
+	for _, p := range points {
+		if p.Equity <= 0 {
+			continue
+		}
+		date := p.Time.UTC().Truncate(24 * time.Hour)
+		if n := len(days); n > 0 && days[n-1].Date.Equal(date) {
+			days[n-1].Equity = p.Equity
+			continue
+		}
+		days = append(days, DailyReturn{Date: date, Equity: p.Equity})
+	}
+	for i := 1; i < len(days); i++ {
+		days[i].Return = days[i].Equity/days[i-1].Equity - 1
+	}
+	return days
+}
+
+// EquityStats summarizes an equity curve; ratios are fractions, not percent
+type EquityStats struct {
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	Snapshots        int       `json:"snapshots"`
+	Days             int       `json:"days"`
+	StartEquity      float64   `json:"start_equity"`
+	EndEquity        float64   `json:"end_equity"`
+	TotalReturn      float64   `json:"total_return"`
+	AnnualizedReturn float64   `json:"annualized_return"`
+	Volatility       float64   `json:"volatility"`    // annualized standard deviation of daily returns
+	SharpeRatio      float64   `json:"sharpe_ratio"`  // annualized, zero risk-free rate
+	SortinoRatio     float64   `json:"sortino_ratio"` // annualized mean over downside deviation
+	CalmarRatio      float64   `json:"calmar_ratio"`  // annualized return over max drawdown
+	MaxDrawdown      float64   `json:"max_drawdown"`  // over every snapshot, not only daily closes
+	CurrentDrawdown  float64   `json:"current_drawdown"`
+	VaR95            float64   `json:"var_95"`  // daily loss exceeded on 5% of days
+	CVaR95           float64   `json:"cvar_95"` // mean daily loss beyond VaR95
+}
+
+// ComputeEquityStats derives return and risk statistics from equity snapshots
+func ComputeEquityStats(points []EquityPoint) EquityStats {
+	points = sortedPoints(points)
+
+	var stats EquityStats
+	var peak float64
+	for _, p := range points {
+		if p.Equity <= 0 {
+			continue
+		}
+		if stats.Snapshots == 0 {
+			stats.Start, stats.StartEquity = p.Time, p.Equity
+		}
+		stats.Snapshots++
+		stats.End, stats.EndEquity = p.Time, p.Equity
+
+		peak = math.Max(peak, p.Equity)
+		stats.CurrentDrawdown = 1 - p.Equity/peak
+		stats.MaxDrawdown = math.Max(stats.MaxDrawdown, stats.CurrentDrawdown)
+	}
+	if stats.Snapshots == 0 {
+		return stats
+	}
+
+	stats.TotalReturn = stats.EndEquity/stats.StartEquity - 1
+	if years := stats.End.Sub(stats.Start).Hours() / 24 / tradingDaysPerYear; years >= 1.0/tradingDaysPerYear {
+		stats.AnnualizedReturn = math.Pow(stats.EndEquity/stats.StartEquity, 1/years) - 1
+	}
+	if stats.MaxDrawdown > 0 {
+		stats.CalmarRatio = stats.AnnualizedReturn / stats.MaxDrawdown
+	}
+
+	days := DailyReturns(points)
+	stats.Days = len(days)
+	if len(days) < 3 {
+		return stats
+	}
+	returns := make([]float64, 0, len(days)-1)
+	for _, d := range days[1:] {
+		returns = append(returns, d.Return)
+	}
+
+	mean, std := meanStd(returns)
+	stats.Volatility = std * math.Sqrt(tradingDaysPerYear)
+	if std > 0 {
+		stats.SharpeRatio = mean / std * math.Sqrt(tradingDaysPerYear)
+	}
+	if downside := downsideDeviation(returns); downside > 0 {
+		stats.SortinoRatio = mean / downside * math.Sqrt(tradingDaysPerYear)
+	}
+	stats.VaR95, stats.CVaR95 = historicalVaR(returns, 0.05)
+	return stats
+}
+
+// Metrics returns the stats as strategy metrics for performance reports
+func (s EquityStats) Metrics() *StrategyMetrics {
+	return &StrategyMetrics{
+		TotalReturn:      s.TotalReturn,
+		AnnualizedReturn: s.AnnualizedReturn,
+		SharpeRatio:      s.SharpeRatio,
+		SortinoRatio:     s.SortinoRatio,
+		CalmarRatio:      s.CalmarRatio,
+		MaxDrawdown:      s.MaxDrawdown,
+		VaR95:            s.VaR95,
+		CVaR95:           s.CVaR95,
+		Volatility:       s.Volatility,
+	}
+}
+
+// sortedPoints returns points ordered by time without modifying the input
+func sortedPoints(points []EquityPoint) []EquityPoint {
+	sorted := append([]EquityPoint(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	return sorted
+}
+
+// meanStd returns the mean and sample standard deviation
+func meanStd(values []float64) (float64, float64) {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var ss float64
+	for _, v := range values {
+		ss += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(values)-1))
+}
+
+// downsideDeviation returns the root mean square of the negative returns,
+// counting the others as zero
+func downsideDeviation(returns []float64) float64 {
+	var ss float64
+	for _, r := range returns {
+		if r < 0 {
+			ss += r * r
+		}
+	}
+	return math.Sqrt(ss / float64(len(returns)))
+}
+
+// BenchmarkStats measures an equity curve in units of a benchmark asset
+type BenchmarkStats struct {
+	Asset string `json:"asset"`
+	// Stats of the equity converted into the asset; a positive total return
+	// means the account grew faster than holding the asset would have
+	Stats      EquityStats `json:"stats"`
+	HoldReturn float64     `json:"hold_return"` // of holding the asset over the same snapshots
+}
+
+// ComputeBenchmarkStats converts snapshots with a benchmark price into the
+// benchmark asset and computes their statistics; snapshots recorded without a
+// price are left out
+func ComputeBenchmarkStats(asset string, points []EquityPoint) BenchmarkStats {
+	var converted []EquityPoint
+	var first, last EquityPoint
+	for _, p := range sortedPoints(points) {
+		if p.Benchmark <= 0 {
+			continue
+		}
+		if first.Benchmark == 0 {
+			first = p
+		}
+		last = p
+		converted = append(converted, EquityPoint{Time: p.Time, Equity: p.Equity / p.Benchmark})
+	}
+	stats := BenchmarkStats{Asset: asset, Stats: ComputeEquityStats(converted)}
+	if first.Benchmark > 0 {
+		stats.HoldReturn = last.Benchmark/first.Benchmark - 1
+	}
+	return stats
+}
+
+// historicalVaR returns the loss at the alpha quantile of returns and the
+// mean loss at or beyond it, both as positive fractions
+func historicalVaR(returns []float64, alpha float64) (float64, float64) {
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+
+	n := max(1, int(math.Ceil(alpha*float64(len(sorted)))))
+	var tail float64
+	for _, r := range sorted[:n] {
+		tail += r
+	}
+	return math.Max(0, -sorted[n-1]), math.Max(0, -tail/float64(n))
+}
@@ -0,0 +1,123 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestDailyReturns(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := []EquityPoint{
+		{Time: day.Add(26 * time.Hour), Equity: 1050},
+		{Time: day.Add(time.Hour), Equity: 900},
+		{Time: day.Add(23 * time.Hour), Equity: 1000}, // closes day one
+		{Time: day.Add(47 * time.Hour), Equity: 1100}, // closes day two
+	}
+
+	days := DailyReturns(points)
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+	if days[0].Equity != 1000 || days[0].Return != 0 {
+		t.Errorf("day one = %+v, want close 1000 without a return", days[0])
+	}
+	if days[1].Equity != 1100 || math.Abs(days[1].Return-0.1) > 1e-12 {
+		t.Errorf("day two = %+v, want close 1100 and a 10%% return", days[1])
+	}
+}
+
+func TestComputeEquityStats(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var points []EquityPoint
+	for i, equity := range []float64{1000, 1020, 1000, 1040, 1030, 1080} {
+		points = append(points, EquityPoint{Time: day.Add(time.Duration(i)*24*time.Hour + 12*time.Hour), Equity: equity})
+	}
+	// An intraday dip deepens drawdown without changing daily closes
+	points = append(points, EquityPoint{Time: day.Add(49 * time.Hour), Equity: 969})
+
+	stats := ComputeEquityStats(points)
+	if stats.Days != 6 || stats.Snapshots != 7 {
+		t.Errorf("days, snapshots = %d, %d; want 6, 7", stats.Days, stats.Snapshots)
+	}
+	if math.Abs(stats.TotalReturn-0.08) > 1e-12 {
+		t.Errorf("TotalReturn = %v, want 0.08", stats.TotalReturn)
+	}
+	if math.Abs(stats.MaxDrawdown-0.05) > 1e-12 {
+		t.Errorf("MaxDrawdown = %v, want 0.05 from the intraday dip", stats.MaxDrawdown)
+	}
+	if stats.CurrentDrawdown != 0 {
+		t.Errorf("CurrentDrawdown = %v at a new high, want 0", stats.CurrentDrawdown)
+	}
+	if stats.SharpeRatio <= 0 || stats.Volatility <= 0 {
+		t.Errorf("Sharpe %v, volatility %v; want both positive", stats.SharpeRatio, stats.Volatility)
+	}
+	// Only the two down days count against Sortino
+	if stats.SortinoRatio <= stats.SharpeRatio {
+		t.Errorf("Sortino %v, want above Sharpe %v", stats.SortinoRatio, stats.SharpeRatio)
+	}
+	if math.Abs(stats.CalmarRatio-stats.AnnualizedReturn/0.05) > 1e-9 {
+		t.Errorf("Calmar = %v, want annualized return over drawdown", stats.CalmarRatio)
+	}
+	// The worst daily return is 1020 -> 1000
+	if math.Abs(stats.VaR95-(1-1000.0/1020)) > 1e-12 {
+		t.Errorf("VaR95 = %v, want the worst day's loss", stats.VaR95)
+	}
+	if m := stats.Metrics(); m.SharpeRatio != stats.SharpeRatio || m.MaxDrawdown != stats.MaxDrawdown {
+		t.Errorf("Metrics() = %+v does not carry the stats", m)
+	}
+
+	if empty := ComputeEquityStats(nil); empty.Snapshots != 0 || empty.SharpeRatio != 0 {
+		t.Errorf("ComputeEquityStats(nil) = %+v, want zero", empty)
+	}
+}
+
+func TestRollingPerformance(t *testing.T) {
+	day := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rolling := NewRollingPerformance(5 * 24 * time.Hour)
+	// An early crash leaves the window once enough days follow it
+	for i, equity := range []float64{1000, 500, 520, 540, 530, 560, 580, 600, 590, 620} {
+		rolling.Add(EquityPoint{Time: day.Add(time.Duration(i) * 24 * time.Hour), Equity: equity})
+	}
+
+	stats := rolling.Stats()
+	if stats.Snapshots != 6 || stats.StartEquity != 530 {
+		t.Fatalf("window holds %d snapshots from %v, want 6 from 530", stats.Snapshots, stats.StartEquity)
+	}
+	if math.Abs(stats.MaxDrawdown-(1-590.0/600)) > 1e-12 {
+		t.Errorf("MaxDrawdown = %v, want the 600 -> 590 dip", stats.MaxDrawdown)
+	}
+
+	var m types.StrategyMetrics
+	rolling.Apply(&m)
+	if m.SharpeRatio != stats.SharpeRatio || m.SortinoRatio != stats.SortinoRatio || m.CalmarRatio != stats.CalmarRatio ||
+		math.Abs(m.MaxDrawdown-stats.MaxDrawdown*100) > 1e-12 {
+		t.Errorf("Apply = %+v, want the window's stats with drawdown in percent", m)
+	}
+	if m.SharpeRatio <= 0 || m.SortinoRatio <= 0 || m.CalmarRatio <= 0 {
+		t.Errorf("ratios %+v, want positive for a rising window", m)
+	}
+}
+
+func TestComputeBenchmarkStats(t *testing.T) {
+	day := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Equity doubles while BTC triples, so the account lost BTC terms
+	points := []EquityPoint{
+		{Time: day, Equity: 1000, Benchmark: 20000},
+		{Time: day.Add(24 * time.Hour), Equity: 1500},
+		{Time: day.Add(48 * time.Hour), Equity: 2000, Benchmark: 60000},
+	}
+
+	stats := ComputeBenchmarkStats("BTC", points)
+	if stats.Asset != "BTC" || stats.Stats.Snapshots != 2 {
+		t.Fatalf("stats = %+v, want the two priced BTC snapshots", stats)
+	}
+	if math.Abs(stats.HoldReturn-2) > 1e-12 {
+		t.Errorf("HoldReturn = %v, want 2", stats.HoldReturn)
+	}
+	if want := (2000.0/60000)/(1000.0/20000) - 1; math.Abs(stats.Stats.TotalReturn-want) > 1e-12 {
+		t.Errorf("TotalReturn = %v, want %v", stats.Stats.TotalReturn, want)
+	}
+}
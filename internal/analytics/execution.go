@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Execution is one filled order with the price the strategy expected when it
+// placed the order and the commission paid on the fill
+type Execution struct {
+	Time          time.Time `json:"time"`
+	Bot           string    `json:"bot,omitempty"`
+	Strategy      string    `json:"strategy,omitempty"`
+	StrategyID    string    `json:"strategy_id,omitempty"`
+	Exchange      string    `json:"exchange,omitempty"`
+	Account       string    `json:"account,omitempty"` // empty for the main account
+	Symbol        string    `json:"symbol"`
+	Side          string    `json:"side"` // BUY or SELL
+	Type          string    `json:"type"`
+	OrderID       string    `json:"order_id,omitempty"`
+	Quantity      float64   `json:"quantity"`
+	ExpectedPrice float64   `json:"expected_price"`
+	FilledPrice   float64   `json:"filled_price"`
+	Fee           float64   `json:"fee"` // in the quote currency
+}
+
+// Notional is the filled value in the quote currency
+func (e Execution) Notional() float64 { return e.Quantity * e.FilledPrice }
+
+// SlippageBps is the fill's deviation from the expected price in basis
+// points, positive when it cost money: a buy filled above or a sell filled
+// below the expected price
+func (e Execution) SlippageBps() float64 {
+	if e.ExpectedPrice <= 0 {
+		return 0
+	}
+	bps := (e.FilledPrice - e.ExpectedPrice) / e.ExpectedPrice * 10000
+	if e.Side == "SELL" {
+		bps = -bps
+	}
+	return bps
+}
+
+// SlippageCost is the slippage in the quote currency, positive when it cost money
+func (e Execution) SlippageCost() float64 {
+	cost := (e.FilledPrice - e.ExpectedPrice) * e.Quantity
+	if e.Side == "SELL" {
+		cost = -cost
+	}
+	return cost
+}
+
+// SlippageStats summarizes the executions in one group
+type SlippageStats struct {
+	Count        int     `json:"count"`
+	Notional     float64 `json:"notional"`
+	Fees         float64 `json:"fees"`
+	FeeBps       float64 `json:"fee_bps"` // fees relative to notional
+	SlippageCost float64 `json:"slippage_cost"`
+	MeanBps      float64 `json:"mean_bps"` // notional-weighted
+	MedianBps    float64 `json:"median_bps"`
+	P95Bps       float64 `json:"p95_bps"`
+	WorstBps     float64 `json:"worst_bps"`
+}
+
+// SlippageReport breaks executions down by strategy, exchange and time of day
+type SlippageReport struct {
+	Overall    SlippageStats            `json:"overall"`
+	ByStrategy map[string]SlippageStats `json:"by_strategy"`
+	ByExchange map[string]SlippageStats `json:"by_exchange"`
+	ByHour     map[int]SlippageStats    `json:"by_hour"` // UTC hour of the fill
+}
+
+// SummarizeSlippage computes slippage and commission statistics for executions
+func SummarizeSlippage(executions []Execution) SlippageReport {
+	byStrategy := make(map[string][]Execution)
+	byExchange := make(map[string][]Execution)
+	byHour := make(map[int][]Execution)
+	for _, e := range executions {
+		byStrategy[e.Strategy] = append(byStrategy[e.Strategy], e)
+		byExchange[e.Exchange] = append(byExchange[e.Exchange], e)
+		hour := e.Time.UTC().Hour()
+		byHour[hour] = append(byHour[hour], e)
+	}
+
+	report := SlippageReport{
+		Overall:    slippageStats(executions),
+		ByStrategy: make(map[string]SlippageStats, len(byStrategy)),
+		ByExchange: make(map[string]SlippageStats, len(byExchange)),
+		ByHour:     make(map[int]SlippageStats, len(byHour)),
+	}
+	for k, group := range byStrategy {
+		report.ByStrategy[k] = slippageStats(group)
+	}
+	for k, group := range byExchange {
+		report.ByExchange[k] = slippageStats(group)
+	}
+	for k, group := range byHour {
+		report.ByHour[k] = slippageStats(group)
+	}
+	return report
+}
+
+func slippageStats(executions []Execution) SlippageStats {
+	stats := SlippageStats{Count: len(executions)}
+	if len(executions) == 0 {
+		return stats
+	}
+
+	bps := make([]float64, 0, len(executions))
+	stats.WorstBps = math.Inf(-1)
+	for _, e := range executions {
+		stats.Notional += e.Notional()
+		stats.Fees += e.Fee
+		stats.SlippageCost += e.SlippageCost()
+		b := e.SlippageBps()
+		bps = append(bps, b)
+		stats.WorstBps = math.Max(stats.WorstBps, b)
+	}
+	if stats.Notional > 0 {
+		stats.FeeBps = stats.Fees / stats.Notional * 10000
+		stats.MeanBps = stats.SlippageCost / stats.Notional * 10000
+	}
+
+	sort.Float64s(bps)
+	stats.MedianBps = percentile(bps, 0.5)
+	stats.P95Bps = percentile(bps, 0.95)
+	return stats
+}
+
+// percentile interpolates the p-th quantile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
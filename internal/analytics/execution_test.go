@@ -0,0 +1,60 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExecution_SlippageSign(t *testing.T) {
+	buy := Execution{Side: "BUY", Quantity: 2, ExpectedPrice: 100, FilledPrice: 100.5}
+	if got := buy.SlippageBps(); math.Abs(got-50) > 1e-9 {
+		t.Errorf("buy slippage = %v bps, want 50", got)
+	}
+	if got := buy.SlippageCost(); math.Abs(got-1) > 1e-9 {
+		t.Errorf("buy slippage cost = %v, want 1", got)
+	}
+
+	// A sell filled above the expected price is price improvement
+	sell := Execution{Side: "SELL", Quantity: 1, ExpectedPrice: 100, FilledPrice: 100.2}
+	if got := sell.SlippageBps(); math.Abs(got+20) > 1e-9 {
+		t.Errorf("sell slippage = %v bps, want -20", got)
+	}
+}
+
+func TestSummarizeSlippage(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2024, 3, 1, hour, 15, 0, 0, time.UTC) }
+	executions := []Execution{
+		{Time: at(9), Strategy: "dca", Exchange: "binance", Side: "BUY", Quantity: 1, ExpectedPrice: 100, FilledPrice: 100.1, Fee: 0.1},
+		{Time: at(9), Strategy: "dca", Exchange: "binance", Side: "BUY", Quantity: 1, ExpectedPrice: 100, FilledPrice: 100.3, Fee: 0.1},
+		{Time: at(14), Strategy: "grid", Exchange: "binance", Side: "SELL", Quantity: 1, ExpectedPrice: 100, FilledPrice: 99.8, Fee: 0.1},
+	}
+
+	report := SummarizeSlippage(executions)
+	if report.Overall.Count != 3 {
+		t.Fatalf("overall count = %d, want 3", report.Overall.Count)
+	}
+	if math.Abs(report.Overall.SlippageCost-0.6) > 1e-9 {
+		t.Errorf("slippage cost = %v, want 0.6", report.Overall.SlippageCost)
+	}
+	if math.Abs(report.Overall.Fees-0.3) > 1e-9 {
+		t.Errorf("fees = %v, want 0.3", report.Overall.Fees)
+	}
+	if got := report.Overall.MedianBps; math.Abs(got-20) > 1e-6 {
+		t.Errorf("median = %v bps, want 20", got)
+	}
+	if got := report.Overall.WorstBps; math.Abs(got-30) > 1e-6 {
+		t.Errorf("worst = %v bps, want 30", got)
+	}
+
+	dca := report.ByStrategy["dca"]
+	if dca.Count != 2 || math.Abs(dca.MeanBps-20) > 0.1 {
+		t.Errorf("dca stats = %+v, want 2 fills at ~20 bps", dca)
+	}
+	if report.ByHour[9].Count != 2 || report.ByHour[14].Count != 1 {
+		t.Errorf("by hour = %+v", report.ByHour)
+	}
+	if report.ByExchange["binance"].Count != 3 {
+		t.Errorf("by exchange = %+v", report.ByExchange)
+	}
+}
@@ -0,0 +1,181 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// DefaultMarketWindow is how many candle returns volatility and correlation
+// are computed over
+const DefaultMarketWindow = 30
+
+// MarketConfig configures the market analytics served to dashboards
+type MarketConfig struct {
+	// Symbols are analyzed and correlated pairwise (default: the bot's symbol)
+	Symbols []string `json:"symbols"`
+
+	// Interval is the market data timeframe analyzed (default: the first one)
+	Interval string `json:"interval"`
+
+	// Window is the number of candle returns analyzed (default 30)
+	Window int `json:"window"`
+
+	// RegimeModel is a model file from trader regime -model-out classifying
+	// the regime (default: the built-in rules)
+	RegimeModel string `json:"regime_model"`
+}
+
+// Validate checks the window
+func (c MarketConfig) Validate() error {
+	if c.Window < 0 {
+		return fmt.Errorf("window must not be negative")
+	}
+	if c.Window == 1 {
+		return fmt.Errorf("window needs at least 2 returns")
+	}
+	return nil
+}
+
+// MarketAnalytics is the rolling volatility, correlation and regime of a set
+// of symbols on one timeframe
+type MarketAnalytics struct {
+	Interval     string            `json:"interval"`
+	Window       int               `json:"window"`
+	Time         time.Time         `json:"time"`
+	Symbols      []SymbolAnalytics `json:"symbols"`
+	Correlations []Correlation     `json:"correlations"`
+}
+
+// SymbolAnalytics is the state of one symbol's market
+type SymbolAnalytics struct {
+	Symbol     string    `json:"symbol"`
+	Price      float64   `json:"price"`
+	Volatility float64   `json:"volatility"` // annualized realized volatility of log returns
+	Regime     string    `json:"regime,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"` // of the last candle
+}
+
+// Correlation is the correlation of two symbols' log returns over the
+// candles both have
+type Correlation struct {
+	A           string  `json:"a"`
+	B           string  `json:"b"`
+	Correlation float64 `json:"correlation"`
+	Samples     int     `json:"samples"` // returns correlated
+}
+
+// AnalyzeMarket computes the analytics of each symbol's candles, oldest
+// first, over the last window returns. classify names the regime of a
+// symbol's candles; symbols come out sorted.
+func AnalyzeMarket(candles map[string][]types.Candle, interval string, window int, classify func([]types.Candle) string) MarketAnalytics {
+	if window <= 0 {
+		window = DefaultMarketWindow
+	}
+	ma := MarketAnalytics{Interval: interval, Window: window, Symbols: []SymbolAnalytics{}, Correlations: []Correlation{}}
+	symbols := make([]string, 0, len(candles))
+	for symbol := range candles {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		series := candles[symbol]
+		if len(series) == 0 {
+			continue
+		}
+		last := series[len(series)-1]
+		sa := SymbolAnalytics{Symbol: symbol, Price: last.Close, Volatility: RealizedVolatility(series, window), UpdatedAt: last.Timestamp}
+		if classify != nil {
+			sa.Regime = classify(series)
+		}
+		if last.Timestamp.After(ma.Time) {
+			ma.Time = last.Timestamp
+		}
+		ma.Symbols = append(ma.Symbols, sa)
+	}
+
+	for i := range symbols {
+		for j := i + 1; j < len(symbols); j++ {
+			corr, n := ReturnCorrelation(candles[symbols[i]], candles[symbols[j]], window)
+			ma.Correlations = append(ma.Correlations, Correlation{A: symbols[i], B: symbols[j], Correlation: corr, Samples: n})
+		}
+	}
+	return ma
+}
+
+// RealizedVolatility annualizes the standard deviation of the last window
+// log returns of candles, oldest first; the bar length is the median
+// spacing of the candles
+func RealizedVolatility(candles []types.Candle, window int) float64 {
+	returns := logReturns(candles)
+	if len(returns) > window {
+		returns = returns[len(returns)-window:]
+	}
+	bar := barLength(candles)
+	if len(returns) < 2 || bar <= 0 {
+		return 0
+	}
+	_, sd := meanStd(returns)
+	return sd * math.Sqrt(float64(365*24*time.Hour)/float64(bar))
+}
+
+// ReturnCorrelation is the Pearson correlation of the log returns of a and b
+// between the candles both have, over the last window of them
+func ReturnCorrelation(a, b []types.Candle, window int) (float64, int) {
+	closes := make(map[time.Time]float64, len(b))
+	for _, c := range b {
+		closes[c.Timestamp] = c.Close
+	}
+	var common, other []types.Candle
+	for _, c := range a {
+		if price, ok := closes[c.Timestamp]; ok {
+			common = append(common, c)
+			other = append(other, types.Candle{Timestamp: c.Timestamp, Close: price})
+		}
+	}
+	x, y := logReturns(common), logReturns(other)
+	if len(x) > window {
+		x, y = x[len(x)-window:], y[len(y)-window:]
+	}
+	if len(x) < 2 {
+		return 0, len(x)
+	}
+	mx, sx := meanStd(x)
+	my, sy := meanStd(y)
+	if sx == 0 || sy == 0 {
+		return 0, len(x)
+	}
+	cov := 0.0
+	for i := range x {
+		cov += (x[i] - mx) * (y[i] - my)
+	}
+	return cov / float64(len(x)-1) / (sx * sy), len(x)
+}
+
+// logReturns are the log returns between consecutive candles with prices
+func logReturns(candles []types.Candle) []float64 {
+	var returns []float64
+	for i := 1; i < len(candles); i++ {
+		if candles[i-1].Close > 0 && candles[i].Close > 0 {
+			returns = append(returns, math.Log(candles[i].Close/candles[i-1].Close))
+		}
+	}
+	return returns
+}
+
+// barLength is the median spacing of candles
+func barLength(candles []types.Candle) time.Duration {
+	if len(candles) < 2 {
+		return 0
+	}
+	gaps := make([]time.Duration, 0, len(candles)-1)
+	for i := 1; i < len(candles); i++ {
+		gaps = append(gaps, candles[i].Timestamp.Sub(candles[i-1].Timestamp))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestAnalyzeMarket(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Daily candles alternating +1% and -1% log returns; ETH moves with BTC
+	// and SOL against it
+	series := func(sign float64, days int) []types.Candle {
+		candles := make([]types.Candle, days)
+		price := 100.0
+		for i := range candles {
+			if i > 0 {
+				step := 0.01
+				if i%2 == 0 {
+					step = -0.01
+				}
+				price *= math.Exp(sign * step)
+			}
+			candles[i] = types.Candle{Timestamp: start.Add(time.Duration(i) * 24 * time.Hour), Close: price}
+		}
+		return candles
+	}
+	candles := map[string][]types.Candle{
+		"SOLUSDT": series(-1, 40),
+		"BTCUSDT": series(1, 40),
+		"ETHUSDT": series(2, 21)[1:], // fewer candles, starting a day later
+	}
+	ma := AnalyzeMarket(candles, "1d", 10, func([]types.Candle) string { return "range_bound" })
+
+	if len(ma.Symbols) != 3 || ma.Symbols[0].Symbol != "BTCUSDT" || ma.Symbols[0].Regime != "range_bound" {
+		t.Fatalf("symbols = %+v, want BTC, ETH and SOL with their regime", ma.Symbols)
+	}
+	// Sample deviation of ten alternating ±1% returns, over 365 days
+	want := 0.01 * math.Sqrt(10.0/9) * math.Sqrt(365)
+	if got := ma.Symbols[0].Volatility; math.Abs(got-want) > 1e-9 {
+		t.Errorf("BTC volatility = %.6f, want %.6f", got, want)
+	}
+	if got := ma.Symbols[1].Volatility; math.Abs(got-2*want) > 1e-9 {
+		t.Errorf("ETH volatility = %.6f, want %.6f", got, 2*want)
+	}
+
+	wantCorr := map[[2]string]float64{
+		{"BTCUSDT", "ETHUSDT"}: 1,
+		{"BTCUSDT", "SOLUSDT"}: -1,
+		{"ETHUSDT", "SOLUSDT"}: -1,
+	}
+	if len(ma.Correlations) != 3 {
+		t.Fatalf("correlations = %+v, want 3 pairs", ma.Correlations)
+	}
+	for _, c := range ma.Correlations {
+		if math.Abs(c.Correlation-wantCorr[[2]string{c.A, c.B}]) > 1e-9 || c.Samples != 10 {
+			t.Errorf("%s/%s correlation = %.4f over %d returns", c.A, c.B, c.Correlation, c.Samples)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package analytics
+
+import "math"
+
+// PairedTest is a paired t-test of two return series observed over the same
+// periods, such as two strategy variants trading the same market
+type PairedTest struct {
+	N           int     `json:"n"`         // paired observations
+	MeanDiff    float64 `json:"mean_diff"` // mean of b minus a
+	StdDiff     float64 `json:"std_diff"`  // sample standard deviation of the differences
+	T           float64 `json:"t"`
+	PValue      float64 `json:"p_value"` // two-sided
+	Alpha       float64 `json:"alpha"`
+	Significant bool    `json:"significant"` // p-value below alpha
+}
+
+// PairedTTest tests whether b's returns differ from a's on average. Pairs
+// beyond the shorter series are ignored. Fewer than two pairs, or identical
+// differences, leave the p-value at 1 unless the mean difference is nonzero.
+func PairedTTest(a, b []float64, alpha float64) PairedTest {
+	n := min(len(a), len(b))
+	test := PairedTest{N: n, PValue: 1, Alpha: alpha}
+	if n < 2 {
+		return test
+	}
+	diffs := make([]float64, n)
+	for i := range diffs {
+		diffs[i] = b[i] - a[i]
+	}
+	test.MeanDiff, test.StdDiff = meanStd(diffs)
+	switch {
+	case test.StdDiff > 0:
+		test.T = test.MeanDiff / (test.StdDiff / math.Sqrt(float64(n)))
+		test.PValue = studentTTwoSided(test.T, float64(n-1))
+	case test.MeanDiff != 0:
+		// Every pair differs by the same amount
+		test.T, test.PValue = math.Copysign(math.Inf(1), test.MeanDiff), 0
+	}
+	test.Significant = test.PValue < alpha
+	return test
+}
+
+// studentTTwoSided is P(|T| >= |t|) for Student's t with df degrees of freedom
+func studentTTwoSided(t, df float64) float64 {
+	return regularizedBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// regularizedBeta is the regularized incomplete beta function I_x(a, b),
+// evaluated by its continued fraction
+func regularizedBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaFraction(x, a, b) / a
+	}
+	return 1 - front*betaFraction(1-x, b, a)/b
+}
+
+// betaFraction evaluates the incomplete beta continued fraction by the
+// modified Lentz method
+func betaFraction(x, a, b float64) float64 {
+	const tiny = 1e-300
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= 200; m++ {
+		fm := float64(m)
+		for _, num := range []float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			d = 1 + num*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + num/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			h *= d * c
+		}
+		if math.Abs(d*c-1) < 1e-12 {
+			break
+		}
+	}
+	return h
+}
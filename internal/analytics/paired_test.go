@@ -0,0 +1,37 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPairedTTest(t *testing.T) {
+	a := []float64{0.010, -0.004, 0.006, 0.002, -0.001, 0.008, 0.003, -0.002, 0.005, 0.001}
+	b := make([]float64, len(a))
+	noise := []float64{0.001, -0.0005, 0.0008, -0.0002, 0.0004, -0.0007, 0.0003, 0.0006, -0.0004, 0.0002}
+	for i := range a {
+		b[i] = a[i] + 0.002 + noise[i]
+	}
+
+	test := PairedTTest(a, b, 0.05)
+	if test.N != 10 || math.Abs(test.MeanDiff-0.00215) > 1e-9 {
+		t.Fatalf("test = %+v, want 10 pairs differing by 0.00215 on average", test)
+	}
+	if !test.Significant || test.PValue > 0.001 || test.T <= 0 {
+		t.Errorf("consistent outperformance: %+v, want significant", test)
+	}
+
+	// Differences that change sign at random are not significant
+	test = PairedTTest(a, noise, 0.05)
+	if test.Significant || test.PValue < 0.05 {
+		t.Errorf("noise: %+v, want not significant", test)
+	}
+
+	// Textbook value: t = 2 with 10 degrees of freedom
+	if p := studentTTwoSided(2, 10); math.Abs(p-0.0734) > 1e-4 {
+		t.Errorf("P(|T| >= 2), df 10 = %.4f, want 0.0734", p)
+	}
+	if test := PairedTTest(a[:1], b[:1], 0.05); test.PValue != 1 || test.Significant {
+		t.Errorf("one pair: %+v, want p-value 1", test)
+	}
+}
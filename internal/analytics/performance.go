@@ -48,6 +48,8 @@ type StrategyMetrics struct {
 	TotalReturn      float64 `json:"total_return"`
 	AnnualizedReturn float64 `json:"annualized_return"`
 	SharpeRatio      float64 `json:"sharpe_ratio"`
+	SortinoRatio     float64 `json:"sortino_ratio"`
+	CalmarRatio      float64 `json:"calmar_ratio"`
 	MaxDrawdown      float64 `json:"max_drawdown"`
 	WinRate          float64 `json:"win_rate"`
 	ProfitFactor     float64 `json:"profit_factor"`
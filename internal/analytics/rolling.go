@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// RollingPerformance keeps equity statistics over a trailing window of
+// snapshots up to date as snapshots arrive
+type RollingPerformance struct {
+	window time.Duration
+
+	mu     sync.RWMutex
+	points []EquityPoint
+	stats  EquityStats
+}
+
+// NewRollingPerformance creates a calculator over the last window of
+// snapshots; zero keeps every snapshot
+func NewRollingPerformance(window time.Duration) *RollingPerformance {
+	return &RollingPerformance{window: window}
+}
+
+// Add records snapshots, drops those that left the window and recomputes
+// the statistics
+func (r *RollingPerformance) Add(points ...EquityPoint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.points = sortedPoints(append(r.points, points...))
+	if n := len(r.points); n > 0 && r.window > 0 {
+		cutoff := r.points[n-1].Time.Add(-r.window)
+		first := 0
+		for first < n && r.points[first].Time.Before(cutoff) {
+			first++
+		}
+		r.points = append(r.points[:0], r.points[first:]...)
+	}
+	r.stats = ComputeEquityStats(r.points)
+}
+
+// Stats returns the statistics of the current window
+func (r *RollingPerformance) Stats() EquityStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.stats
+}
+
+// Apply fills the risk-adjusted fields of strategy metrics from the window.
+// Drawdown is reported in percent like the win rate.
+func (r *RollingPerformance) Apply(m *types.StrategyMetrics) {
+	stats := r.Stats()
+	m.SharpeRatio = stats.SharpeRatio
+	m.SortinoRatio = stats.SortinoRatio
+	m.CalmarRatio = stats.CalmarRatio
+	m.MaxDrawdown = stats.MaxDrawdown * 100
+}
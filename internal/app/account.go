@@ -0,0 +1,89 @@
+package app
+
+import (
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/ratelimit"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Account is one exchange account or sub-account the process trades. Each
+// has its own order client, request budget and portfolio manager, so
+// balances, positions and PnL are kept apart from other accounts'.
+type Account struct {
+	name       string
+	subAccount string
+	exchange   types.ExchangeClient // strategy orders
+	portfolio  *portfolio.Manager
+	budget     *ratelimit.Budget // nil without a rate limit
+	paper      *PaperExchange
+	journal    *journalClient // nil without a state dir
+}
+
+// Name returns the account name; the main account is config.MainAccount
+func (a *Account) Name() string {
+	return a.name
+}
+
+// Exchange returns the client strategies bound to the account place orders with
+func (a *Account) Exchange() types.ExchangeClient {
+	return a.exchange
+}
+
+// Portfolio returns the account's portfolio manager
+func (a *Account) Portfolio() *portfolio.Manager {
+	return a.portfolio
+}
+
+// RateBudget returns the account's request budget, or nil when unlimited
+func (a *Account) RateBudget() *ratelimit.Budget {
+	return a.budget
+}
+
+// Status reports the account's balance, equity and portfolio metrics
+func (a *Account) Status() map[string]interface{} {
+	status := map[string]interface{}{
+		"name":      a.name,
+		"equity":    a.portfolio.Equity(),
+		"portfolio": a.portfolio.GetMetrics(),
+		"positions": a.portfolio.GetPositionSummary(),
+	}
+	if a.subAccount != "" {
+		status["sub_account"] = a.subAccount
+	}
+	if balance := a.portfolio.Balance(); balance != nil {
+		status["balance"] = balance
+	}
+	if a.budget != nil {
+		status["rate_limit"] = a.budget.Status()
+	}
+	return status
+}
+
+// newSubAccount wires an account from cfg.Exchange.Accounts. Orders pass the
+// same journal, deleverager, guard and calendar as the main account's but a
+// budget of the account's own.
+func newSubAccount(c *Container, cfg config.AccountConfig, wrap func(types.ExchangeClient) types.ExchangeClient) *Account {
+	// Paper exchange for demonstration (use a client with the account's keys in production)
+	account := &Account{name: cfg.Name, subAccount: cfg.SubAccount, paper: NewPaperExchange(c.logger, 500)}
+	var client types.ExchangeClient = account.paper
+	if c.chaos != nil {
+		client = c.chaos.Client(client)
+	}
+	if c.stateStore != nil {
+		account.journal = newJournalClient(client, c.stateStore, c.logger)
+		account.journal.account = cfg.Name
+		client = account.journal
+	}
+	client = wrap(client)
+
+	portfolioClient := client
+	if cfg.RateLimit.Enabled() {
+		account.budget = ratelimit.NewBudget(cfg.RateLimit)
+		portfolioClient = account.budget.Client("portfolio", client)
+		client = account.budget.Client("strategy", client)
+	}
+	account.exchange = client
+	account.portfolio = portfolio.NewManager(portfolioClient, c.logger)
+	return account
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/ratelimit"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestContainer_SubAccounts(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{Name: "test", ReportingCurrency: "USD", StateDir: t.TempDir()},
+		Logging: config.LoggingConfig{Level: "error"},
+		Exchange: config.ExchangeConfig{Accounts: []config.AccountConfig{
+			{Name: "hedge", SubAccount: "hedge-sub", RateLimit: ratelimit.Config{Limit: ratelimit.Limit{RequestsPerSecond: 10}}},
+		}},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(c.Accounts()); got != 2 {
+		t.Fatalf("got %d accounts, want 2", got)
+	}
+	main, ok := c.Account("")
+	if !ok || main.Name() != config.MainAccount || main.Portfolio() != c.PortfolioManager() {
+		t.Fatalf("Account(\"\") = %v, %v; want the main account", main, ok)
+	}
+	hedge, ok := c.Account("hedge")
+	if !ok || hedge.RateBudget() == nil || hedge.Portfolio() == c.PortfolioManager() {
+		t.Fatalf("hedge account must have its own budget and portfolio")
+	}
+	if _, ok := c.Account("other"); ok {
+		t.Error("unknown account found")
+	}
+
+	// Combo sub-strategies bind to accounts by name
+	combo := func(account string) types.ComboConfig {
+		return types.ComboConfig{Enabled: true, Strategies: []types.StrategyConfig{{
+			Type:    "dca",
+			Account: account,
+			Config: map[string]interface{}{
+				"symbol": "BTCUSDT", "investment_amount": 100.0, "interval": "1h", "max_investments": 5.0, "enabled": true,
+			},
+		}}}
+	}
+	if _, err := c.StrategyFactory().CreateCombo(combo("hedge"), c.Exchange()); err != nil {
+		t.Fatalf("combo bound to hedge: %v", err)
+	}
+	if _, err := c.StrategyFactory().CreateCombo(combo("other"), c.Exchange()); err == nil {
+		t.Error("expected an error for an unknown account")
+	}
+
+	// Orders are journaled under the account they were placed on
+	hedge.journal.setBot("bot")
+	order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.01, Price: 45000,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "h1"}}
+	if err := hedge.Exchange().PlaceOrder(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+	submissions, err := readSubmissions(c.StateStore(), "bot", "BTCUSDT", c.Logger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != 1 || submissions[0].account != "hedge" {
+		t.Fatalf("submissions = %+v, want one on hedge", submissions)
+	}
+
+	dca := strategy.NewDCAStrategy(types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 5, Enabled: true}, c.Exchange(), c.Logger())
+	rec := httptest.NewRecorder()
+	newRouter(c, dca, &probeState{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/accounts", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /accounts = %d, want 200", rec.Code)
+	}
+	var accounts []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &accounts); err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 || accounts[1]["name"] != "hedge" || accounts[1]["sub_account"] != "hedge-sub" || accounts[1]["rate_limit"] == nil {
+		t.Errorf("GET /accounts = %v", accounts)
+	}
+}
+
+func TestContainer_SubAccountDeleverage(t *testing.T) {
+	cfg := &config.Config{
+		App:       config.AppConfig{Name: "test", ReportingCurrency: "USD"},
+		Logging:   config.LoggingConfig{Level: "error"},
+		Exchange:  config.ExchangeConfig{Accounts: []config.AccountConfig{{Name: "hedge"}}},
+		Portfolio: config.PortfolioConfig{Deleverage: risk.DeleverageConfig{Levels: []risk.DeleverageLevel{{Drawdown: 0.2, Scale: 0}}}},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hedge, _ := c.Account("hedge")
+
+	// Drawdown on the main account halts the sub-account's buys too
+	c.Deleverager().Update(1000)
+	c.Deleverager().Update(700)
+	buy := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.01, Price: 45000}
+	if err := hedge.Exchange().PlaceOrder(context.Background(), buy); !errors.Is(err, risk.ErrDeleveraged) {
+		t.Fatalf("sub-account buy error = %v, want ErrDeleveraged", err)
+	}
+	sell := buy
+	sell.Side = types.OrderSideSell
+	if err := hedge.Exchange().PlaceOrder(context.Background(), sell); err != nil {
+		t.Fatalf("sub-account sell: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/compliance"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+)
+
+// auditName is the state dir journal of compliance reviews
+const auditName = "audit"
+
+// auditLog records compliance reviews in the state dir's audit journal and
+// hands them to the report manager, when there are, and logs the violations
+func auditLog(store *StateStore, reports *compliance.ReportManager, log *logger.Logger) func(compliance.AuditRecord) {
+	return func(record compliance.AuditRecord) {
+		for _, violation := range record.Violations() {
+			if record.Blocked {
+				log.Warn("Compliance blocked %s %s: %s", record.Side, record.Symbol, violation.Detail)
+			} else {
+				log.Warn("Compliance flagged %s %s: %s", record.Side, record.Symbol, violation.Detail)
+			}
+		}
+		if store != nil {
+			if err := store.Append(auditName, record); err != nil {
+				log.Error("Failed to record compliance review: %v", err)
+			}
+		}
+		if reports != nil {
+			if err := reports.Observe(record); err != nil {
+				log.Error("Failed to queue compliance report: %v", err)
+			}
+		}
+	}
+}
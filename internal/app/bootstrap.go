@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+)
+
+// LoadConfig reads configuration from a JSON file, or from environment when path is empty.
+// STATE_DIR overrides the file setting so containers can point it at a mounted volume,
+// and API_TOKEN keeps the order API secret out of config files.
+func LoadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		return config.LoadFromEnv(), nil
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if dir := os.Getenv("STATE_DIR"); dir != "" {
+		cfg.App.StateDir = dir
+	}
+	if token := os.Getenv("API_TOKEN"); token != "" {
+		cfg.App.APIToken = token
+	}
+	if url := os.Getenv("COLLECTOR_URL"); url != "" {
+		cfg.App.Collector.URL = url
+	}
+	if instance := os.Getenv("INSTANCE_NAME"); instance != "" {
+		cfg.App.Collector.Instance = instance
+	}
+	if token := os.Getenv("COLLECTOR_TOKEN"); token != "" {
+		cfg.App.Collector.Token = token
+	}
+	return cfg, nil
+}
+
+// NewLogger creates a logger from logging configuration
+func NewLogger(cfg config.LoggingConfig) (*logger.Logger, error) {
+	level := ParseLogLevel(cfg.Level)
+
+	log := logger.New(level)
+	if cfg.File != "" {
+		rotation := logger.Rotation{MaxSize: int64(cfg.MaxSizeMB) << 20, MaxAge: cfg.MaxAge, MaxBackups: cfg.MaxBackups}
+		var err error
+		if log, err = logger.NewWithFile(level, cfg.File, rotation); err != nil {
+			return nil, fmt.Errorf("failed to create logger: %w", err)
+		}
+	}
+	for component, name := range cfg.Components {
+		log.SetComponentLevel(component, ParseLogLevel(name))
+	}
+	return log, nil
+}
+
+// ParseLogLevel maps a level name to a logger level (info by default)
+func ParseLogLevel(level string) logger.Level {
+	switch level {
+	case "debug":
+		return logger.LevelDebug
+	case "warn":
+		return logger.LevelWarn
+	case "error":
+		return logger.LevelError
+	default:
+		return logger.LevelInfo
+	}
+}
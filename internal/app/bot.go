@@ -0,0 +1,448 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/maintenance"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/marketdata"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/fleet"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// BotSpec describes a strategy bot run by RunBot
+type BotSpec struct {
+	ID           string        // strategy instance id naming state files, journal entries and metrics, e.g. "dca"
+	Name         string        // display name, e.g. "DCA Bot"
+	Icon         string        // shown in the startup log line
+	Symbol       string        // symbol fed to the trading loop
+	LoopInterval time.Duration // how often market data is fetched
+	PriceSwing   float64       // paper exchange price oscillation
+
+	// Build creates the strategy from the container
+	Build func(c *Container) (strategy.Strategy, error)
+
+	// Shadow is a candidate run in shadow mode or A/B tested against the
+	// strategy; nil runs none
+	Shadow *ShadowSpec
+}
+
+// BotSnapshot is the strategy state persisted to the state dir
+type BotSnapshot struct {
+	Bot       string                `json:"bot"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	Metrics   types.StrategyMetrics `json:"metrics"`
+	Status    *types.StrategyStatus `json:"status,omitempty"`
+}
+
+// probeState backs the /ready endpoint
+type probeState struct {
+	ready    atomic.Bool // strategy running and not draining
+	watchdog *Watchdog   // trading loop heartbeats; nil skips the stall check
+}
+
+// RunBot starts a strategy bot and blocks until SIGINT/SIGTERM.
+// On shutdown it stops taking new work, lets the in-flight iteration finish,
+// then shuts the strategy down before closing the HTTP server.
+func RunBot(c *Container, spec BotSpec) error {
+	return RunBotContext(context.Background(), c, spec)
+}
+
+// RunBotContext runs a strategy bot like RunBot, also shutting it down
+// gracefully when parent is done
+func RunBotContext(parent context.Context, c *Container, spec BotSpec) error {
+	cfg, log := c.Config(), c.Logger()
+
+	log.Info("%s %s starting...", spec.Icon, spec.Name)
+	log.Info("Version: %s", cfg.App.Version)
+	log.Info("Exchange: %s", cfg.Exchange.Name)
+	if spec.Symbol != "" {
+		log.Info("Symbol: %s", spec.Symbol)
+	}
+	if store := c.StateStore(); store != nil {
+		log.Info("State dir: %s", store.Dir())
+	}
+	if cfg.MigratedFrom > 0 {
+		log.Warn("Config is schema v%d, upgraded to v%d in memory; run trader config migrate to update the file", cfg.MigratedFrom, config.SchemaVersion)
+	}
+
+	// Create context with cancellation
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	// A second instance of the bot would trade on the same state and account
+	if store := c.StateStore(); store != nil {
+		lock, err := AcquireStateLock(store, spec.ID, spec.Symbol)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Release(); err != nil {
+				log.Error("Failed to release state lock: %v", err)
+			}
+		}()
+		from, changes, err := MigrateState(store, false)
+		if err != nil {
+			return err
+		}
+		if from < StateSchemaVersion {
+			log.Info("Migrated state dir from schema v%d to v%d", from, StateSchemaVersion)
+			for _, change := range changes {
+				log.Info("  %s", change)
+			}
+		}
+		if cfg.App.ExchangeLockCheck && spec.Symbol != "" {
+			if err := checkForeignOrders(ctx, store, c.Exchange(), spec.Symbol); err != nil {
+				return err
+			}
+		}
+	}
+
+	if spec.PriceSwing > 0 {
+		for _, account := range c.Accounts() {
+			account.paper.SetPriceSwing(spec.PriceSwing)
+		}
+	}
+	exchange := c.Exchange()
+
+	strat, err := spec.Build(c)
+	if err != nil {
+		return fmt.Errorf("failed to create strategy: %w", err)
+	}
+
+	// Validate strategy config
+	if err := strat.ValidateConfig(); err != nil {
+		return fmt.Errorf("strategy config validation error: %w", err)
+	}
+
+	// Fail fast on symbols the exchange would never fill
+	if !cfg.App.SkipPreflight {
+		if err := preflight(ctx, cfg.Exchange.Name, botMarkets(c, strat, spec.Symbol)); err != nil {
+			return err
+		}
+	}
+
+	// Resume where the previous run left off, then journal under this bot
+	if err := recoverStrategy(ctx, c.StateStore(), exchange, spec.ID, spec.Symbol, strat, log); err != nil {
+		return err
+	}
+	// Take over inventory already held rather than buying it again
+	if seedable, ok := strat.(strategy.Seedable); ok {
+		if err := seedable.Seed(ctx); err != nil {
+			return fmt.Errorf("failed to seed strategy: %w", err)
+		}
+	}
+	for _, account := range c.Accounts() {
+		if account.journal != nil {
+			account.journal.setBot(spec.ID)
+		}
+	}
+
+	// The candidate trades the same ticks, on paper or on its share of the
+	// capital, journaled under its own id
+	var shadow *shadowRun
+	loopStrat := strat
+	if spec.Shadow != nil {
+		if shadow, err = startShadow(ctx, c, spec); err != nil {
+			return err
+		}
+		c.shadow = shadow
+		loopStrat = &variantA{Strategy: strat, run: shadow}
+		if shadow.allocator != nil {
+			log.Info("A/B test: %s trades %.0f%% of %.2f, report after %d days", shadow.id, shadow.state.Allocation*100, shadow.state.Capital, shadow.state.Days)
+		} else {
+			log.Info("Shadow: %s, report after %d days", shadow.id, shadow.state.Days)
+		}
+	}
+
+	// Seed positions with coins held before the bot started
+	if cfg.Portfolio.Import.Enabled {
+		imported, err := c.PortfolioManager().ImportHoldings(ctx, cfg.Portfolio.Import)
+		if err != nil {
+			return fmt.Errorf("failed to import holdings: %w", err)
+		}
+		log.Info("Imported %d existing holding(s)", len(imported))
+	}
+
+	// The watchdog reports the trading loop stalled after five missed ticks
+	interval := spec.LoopInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	stallThreshold := cfg.App.StallThreshold
+	if stallThreshold <= 0 {
+		stallThreshold = 5 * interval
+	}
+	probes := &probeState{watchdog: NewWatchdog(stallThreshold, log)}
+	c.Metrics().AddCollector(botCollector(c, spec, strat, probes.watchdog))
+
+	// Start HTTP server for monitoring (optional); it outlives the trading loop
+	// so probes keep answering while the bot drains
+	serverCtx, stopServer := context.WithCancel(context.Background())
+	defer stopServer()
+	serverDone := make(chan struct{})
+	if cfg.App.Port > 0 {
+		go func() {
+			startHTTPServer(serverCtx, c, strat, probes)
+			close(serverDone)
+		}()
+	} else {
+		close(serverDone)
+	}
+
+	// Start portfolio auto-refresh and exchange status polling
+	for _, account := range c.Accounts() {
+		go account.portfolio.StartAutoRefresh(ctx, 30*time.Second)
+	}
+	go c.Maintenance().Run(ctx)
+	if cfg.Portfolio.Dust.Convert {
+		go c.PortfolioManager().RunDustConversion(ctx, cfg.Portfolio.Dust)
+	}
+	if cfg.Portfolio.Rebalance.Enabled() && cfg.Portfolio.Rebalance.Interval > 0 {
+		go c.PortfolioManager().RunRebalance(ctx, c.rebalanceClient(), cfg.Portfolio.Rebalance)
+	}
+	if deleverager := c.Deleverager(); deleverager != nil {
+		go deleverager.Run(ctx, 30*time.Second, c.PortfolioManager().Equity)
+	}
+	if cal := c.Calendar(); cal != nil {
+		go cal.Run(ctx)
+	}
+	if sentiment := c.Sentiment(); sentiment != nil {
+		symbols := cfg.Sentiment.Symbols
+		if len(symbols) == 0 {
+			symbols = []string{spec.Symbol}
+		}
+		go sentiment.Run(ctx, symbols)
+	}
+	if reports := c.ComplianceReports(); reports != nil {
+		go reports.Run(ctx, func(err error) { log.Error("Failed to queue compliance summary: %v", err) })
+	}
+	if recorder := c.EquityRecorder(); recorder != nil {
+		snapshotInterval := cfg.Portfolio.SnapshotInterval
+		if snapshotInterval <= 0 {
+			snapshotInterval = time.Hour
+		}
+		go recorder.Run(ctx, snapshotInterval)
+	}
+	if executions := c.Executions(); executions != nil {
+		go executions.Run(ctx, 5*time.Minute, spec.ID, spec.Symbol)
+		if shadow != nil && shadow.allocator != nil {
+			go executions.Run(ctx, 5*time.Minute, shadow.id, spec.Symbol)
+		}
+	}
+
+	// Push metrics to the fleet collector
+	if cfg.App.Collector.Enabled() {
+		reporter, err := newFleetReporter(c, spec, strat)
+		if err != nil {
+			return err
+		}
+		log.Info("Reporting to collector %s as %s", cfg.App.Collector.URL, reporter.Instance())
+		go reporter.Run(ctx)
+	}
+
+	// Preload the candles indicator strategies need for their first decision
+	preloadHistory(ctx, strat, exchange, log)
+	if shadow != nil {
+		preloadHistory(ctx, shadow.strat, exchange, log)
+	}
+
+	// Start trading loop
+	go probes.watchdog.Run(ctx, time.Minute)
+	saveState := func() {
+		if err := saveSnapshot(c.StateStore(), spec.ID, strat); err != nil {
+			log.Error("Failed to save state: %v", err)
+		}
+	}
+	afterTick := func(market types.MarketData) {
+		if shadow != nil {
+			shadow.tick(context.WithoutCancel(ctx), market)
+		}
+		saveState()
+	}
+	loopDone := make(chan struct{})
+	go func() {
+		runTradingLoop(ctx, loopStrat, exchange, c.Maintenance(), c.MarketData(), c.Prices(), c.Sentiment(), probes.watchdog, log, spec.ID, spec.Symbol, interval, afterTick)
+		close(loopDone)
+	}()
+
+	// Handle OS signals for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	probes.ready.Store(true)
+	log.Info("%s started and running", spec.Name)
+
+	// Wait for termination signal
+	select {
+	case <-sigChan:
+		log.Info("Termination signal received, stopping bot...")
+	case <-parent.Done():
+		log.Info("Run ended, stopping bot...")
+	}
+	probes.ready.Store(false)
+	cancel()
+
+	// Graceful shutdown
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+
+	select {
+	case <-loopDone:
+	case <-shutdownCtx.Done():
+		log.Warn("Trading loop did not drain before shutdown timeout")
+	}
+
+	if err := strat.Shutdown(shutdownCtx); err != nil {
+		log.Error("Error stopping strategy: %v", err)
+	}
+	saveState()
+	if shadow != nil {
+		shadow.shutdown(shutdownCtx)
+	}
+
+	stopServer()
+	<-serverDone
+
+	log.Info("%s stopped", spec.Name)
+	return nil
+}
+
+// newFleetReporter reports the strategy metrics and portfolio equity.
+// The config hash covers the strategy section, so instances of a group
+// drift apart when any strategy parameter differs.
+func newFleetReporter(c *Container, spec BotSpec, strat strategy.Strategy) (*fleet.Reporter, error) {
+	cfg := c.Config()
+	hash, err := fleet.HashConfig(cfg.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	source := func() fleet.Report {
+		metrics := c.StrategyMetrics(strat)
+		return fleet.Report{
+			Bot:        spec.ID,
+			Symbol:     spec.Symbol,
+			Version:    cfg.App.Version,
+			ConfigHash: hash,
+			Equity:     c.PortfolioManager().GetPortfolio().TotalValue,
+			PnL:        metrics.TotalProfit - metrics.TotalLoss,
+			Metrics:    metrics,
+		}
+	}
+	return fleet.NewReporter(cfg.App.Collector, spec.ID, source, c.Logger()), nil
+}
+
+// runTradingLoop feeds market data to the strategy every interval.
+// A started iteration runs to completion even if ctx is canceled meanwhile.
+// Ticks are skipped while the monitor reports the exchange unavailable.
+// With a market data provider, each snapshot carries higher-timeframe context;
+// with a price source, its Price is the reference price instead of the last.
+// Successful fetches and executions are reported to the watchdog as loop id.
+// afterTick gets the market data of every executed tick.
+func runTradingLoop(ctx context.Context, strategy strategy.Strategy, exchange types.ExchangeClient, monitor *maintenance.Monitor, provider *marketdata.Provider, prices *marketdata.PriceSource, sentiment *SentimentRecorder, watchdog *Watchdog, log *logger.Logger, id, symbol string, interval time.Duration, afterTick func(types.MarketData)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	watchdog.Start(id, time.Now())
+
+	log.Info("Trading loop started for %s", symbol)
+	paused := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Trading loop stopped")
+			return
+		case <-ticker.C:
+			if available, reason := monitor.Available(); !available {
+				if !paused {
+					log.Warn("Trading paused: %s", reason)
+					paused = true
+				}
+				watchdog.Idle(id, time.Now())
+				continue
+			}
+			if paused {
+				log.Info("Exchange available again, trading resumed")
+				paused = false
+			}
+
+			execCtx := context.WithoutCancel(ctx)
+
+			// Fetch market data
+			marketData, err := getMarketData(execCtx, exchange, symbol)
+			monitor.Report(err)
+			if err != nil {
+				log.Error("Failed to fetch market data: %v", err)
+				continue
+			}
+			watchdog.Beat(id, stageMarketData, time.Now())
+			if prices != nil {
+				// Signals and level crossings stay on the last price rather
+				// than skipping the tick
+				if err := prices.Apply(execCtx, &marketData); err != nil {
+					log.Warn("Falling back to the last price: %v", err)
+				}
+			}
+			if provider != nil {
+				// Stale or partial context is still passed on; strategies
+				// check for the timeframes they need
+				marketData.Context, err = provider.Context(execCtx, symbol)
+				if err != nil {
+					log.Warn("Failed to refresh market context: %v", err)
+				}
+			}
+			if sentiment != nil {
+				marketData.Sentiment = sentiment.Reading(symbol, time.Now())
+			}
+
+			// Execute strategy
+			if err := strategy.Execute(execCtx, marketData); err != nil {
+				log.Error("Strategy execution error: %v", err)
+			} else {
+				watchdog.Beat(id, stageExecute, time.Now())
+			}
+
+			log.Debug("Strategy metrics: %+v", strategy.GetMetrics())
+			afterTick(marketData)
+		}
+	}
+}
+
+// getMarketData fetches market data
+func getMarketData(ctx context.Context, exchange types.ExchangeClient, symbol string) (types.MarketData, error) {
+	ticker, err := exchange.GetTicker(ctx, symbol)
+	if err != nil {
+		return types.MarketData{}, err
+	}
+
+	return types.MarketData{
+		Symbol:    symbol,
+		Price:     ticker.Price,
+		Volume:    ticker.Volume,
+		Timestamp: ticker.Timestamp,
+		Ticker:    ticker,
+	}, nil
+}
+
+// saveSnapshot writes strategy metrics and status to <id>-state.json
+func saveSnapshot(store *StateStore, id string, strat strategy.Strategy) error {
+	if store == nil {
+		return nil
+	}
+
+	snapshot := BotSnapshot{Bot: id, UpdatedAt: time.Now(), Metrics: strat.GetMetrics()}
+	if reporter, ok := strat.(strategy.StatusReporter); ok {
+		status := reporter.GetStatus()
+		snapshot.Status = &status
+	}
+	return store.Save(id+"-state", snapshot)
+}
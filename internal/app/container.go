@@ -1,46 +1,459 @@
 package app
 
 import (
+	"context"
+	"path/filepath"
+
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/calendar"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/compliance"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange"
-	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/chaos"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/maintenance"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/marketdata"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/ratelimit"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/metrics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/plugins"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
+// Container holds the dependencies shared by all bots
 type Container struct {
 	config           *config.Config
 	logger           *logger.Logger
 	exchangeClients  map[string]exchange.Client
+	exchange         types.ExchangeClient
+	paperExchange    *PaperExchange
+	maintenance      *maintenance.Monitor
+	stateStore       *StateStore
+	journal          *journalClient
+	equity           *EquityRecorder
+	executions       *ExecutionLog
+	deleverager      *risk.Deleverager
+	calendar         *calendar.Calendar
+	restrictions     *risk.Restrictions
+	compliance       *compliance.Monitor
+	reports          *compliance.ReportManager
+	guard            *risk.Guard
+	chaos            *chaos.Injector
+	rateBudget       *ratelimit.Budget
+	marketData       *marketdata.Provider
+	prices           *marketdata.PriceSource
+	marketAnalyzer   *marketAnalyzer
+	sentiment        *SentimentRecorder
 	strategyFactory  *strategy.Factory
 	portfolioManager *portfolio.Manager
 	riskManager      *risk.Manager
 	metricsCollector *analytics.MetricsCollector
+	metrics          *metrics.Registry
+	accounts         []*Account // main account first
+	shadow           *shadowRun // set by RunBot when a candidate runs in shadow mode
 }
 
+// NewContainer wires logger, exchange, strategy factory and portfolio from config
 func NewContainer(cfg *config.Config) (*Container, error) {
-	log := logger.New(logger.LevelInfo)
+	log, err := NewLogger(cfg.Logging)
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeName := cfg.Exchange.Name
+	if exchangeName == "" {
+		exchangeName = "binance"
+	}
+
+	// Paper exchange for demonstration (use real client in production)
+	paper := NewPaperExchange(log, 500)
+	var client types.ExchangeClient = paper
+
+	// Rehearse exchange faults against the paper exchange
+	var injector *chaos.Injector
+	if cfg.Exchange.Chaos.Enabled() {
+		injector = chaos.NewInjector(cfg.Exchange.Chaos)
+		client = injector.Client(client)
+	}
+
+	// Journal orders into the state dir when one is configured
+	var stateStore *StateStore
+	var journal *journalClient
+	if cfg.App.StateDir != "" {
+		stateStore, err = NewStateStore(cfg.App.StateDir)
+		if err != nil {
+			return nil, err
+		}
+		journal = newJournalClient(client, stateStore, log)
+		client = journal
+	}
+
+	// Size every strategy's buys by portfolio drawdown
+	var deleverager *risk.Deleverager
+	if cfg.Portfolio.Deleverage.Enabled() {
+		deleverager = risk.NewDeleverager(cfg.Portfolio.Deleverage)
+		client = deleverager.Client(client)
+	}
+
+	// Reject new entries around scheduled events
+	var cal *calendar.Calendar
+	if cfg.Calendar.Enabled() {
+		cal = calendar.NewCalendar(cfg.Calendar, log)
+		client = cal.Client(client)
+	}
+
+	// Keep buys off blacklisted symbols and out of their trading hours; the
+	// rules can be edited through the API, so this layer is always there
+	restrictions, err := risk.NewRestrictions(cfg.Restrictions)
+	if err != nil {
+		return nil, err
+	}
+	client = restrictions.Client(client)
+
+	// Review every order against the compliance rules and audit the results;
+	// reports on them are queued next to the journal
+	var monitor *compliance.Monitor
+	var reports *compliance.ReportManager
+	if cfg.Compliance.Enabled() {
+		if stateStore != nil {
+			reports, err = compliance.NewReportManager(filepath.Join(stateStore.Dir(), "compliance"), cfg.Compliance.Reports)
+			if err != nil {
+				return nil, err
+			}
+		}
+		monitor = compliance.NewMonitor(cfg.Compliance, auditLog(stateStore, reports, log))
+		client = monitor.Client(client)
+	}
 
-	exchangeClients := make(map[string]exchange.Client)
-	// Initialize default exchange client (mock for now)
-	mockClient := &mock.MockClient{}
-	exchangeClients["binance"] = mockClient
+	// Stop all orders when the bot itself misbehaves
+	var guard *risk.Guard
+	if cfg.Guard.Enabled() {
+		guard = risk.NewGuard(cfg.Guard)
+		client = guard.Client(client)
+	}
 
-	riskManager := &risk.Manager{}
-	portfolioManager := portfolio.NewManager(mockClient, log)
+	// Strategy orders, portfolio refreshes, status polls and higher-timeframe
+	// candles share one request budget, each as its own consumer
+	var rateBudget *ratelimit.Budget
+	portfolioClient, maintenanceClient, marketDataClient := client, client, client
+	if cfg.Exchange.RateLimit.Enabled() {
+		rateBudget = ratelimit.NewBudget(cfg.Exchange.RateLimit)
+		portfolioClient = rateBudget.Client("portfolio", client)
+		maintenanceClient = rateBudget.Client("maintenance", client)
+		marketDataClient = rateBudget.Client("market_data", client)
+		client = rateBudget.Client("strategy", client)
+	}
 
-	metricsCollector := &analytics.MetricsCollector{}
+	var marketData *marketdata.Provider
+	if cfg.Exchange.MarketData.Enabled() {
+		marketData, err = marketdata.NewProvider(marketDataClient, cfg.Exchange.MarketData)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var prices *marketdata.PriceSource
+	if source := cfg.Exchange.MarketData.Price.Source; source != "" && source != marketdata.PriceLast {
+		prices = marketdata.NewPriceSource(marketDataClient, cfg.Exchange.MarketData.Price)
+	}
+	var analyzer *marketAnalyzer
+	if marketData != nil {
+		analyzer, err = newMarketAnalyzer(marketData, cfg.MarketAnalytics, cfg.Exchange.MarketData.Timeframes[0].Interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+	exchangeClients := map[string]exchange.Client{exchangeName: client}
 
-	return &Container{
+	// Register plugin strategies before any strategy is built
+	strategyFactory := strategy.NewFactory(log)
+	if err := plugins.LoadAll(cfg.Plugins, strategyFactory.Registry(), log); err != nil {
+		return nil, err
+	}
+
+	portfolioManager := portfolio.NewManager(portfolioClient, log)
+	valuator := portfolio.NewValuator(portfolioClient, log, cfg.App.ReportingCurrency)
+	portfolioManager.SetValuator(valuator)
+	portfolioManager.SetNetting(cfg.Portfolio.Netting)
+
+	// Keep the equity curve and fill attribution next to the order journal
+	var equity *EquityRecorder
+	var executions *ExecutionLog
+	if stateStore != nil {
+		equity, err = NewEquityRecorder(stateStore, portfolioManager.Equity, log)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Portfolio.PerformanceWindow > 0 {
+			equity.SetPerformanceWindow(cfg.Portfolio.PerformanceWindow)
+		}
+		if asset := cfg.Portfolio.Benchmark; asset != "" {
+			equity.SetBenchmark(asset, func() (float64, error) {
+				return valuator.Rate(context.Background(), asset)
+			})
+		}
+		executions, err = NewExecutionLog(stateStore, portfolioClient, exchangeName, log)
+		if err != nil {
+			return nil, err
+		}
+		trackPositions(executions, portfolioManager)
+	}
+	var sentiment *SentimentRecorder
+	if cfg.Sentiment.Enabled() {
+		if sentiment, err = NewSentimentRecorder(cfg.Sentiment, stateStore, log); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Container{
 		config:           cfg,
 		logger:           log,
 		exchangeClients:  exchangeClients,
-		strategyFactory:  strategy.NewFactory(log),
+		exchange:         client,
+		paperExchange:    paper,
+		maintenance:      maintenance.NewMonitor(maintenanceClient, cfg.Exchange.Maintenance, log),
+		stateStore:       stateStore,
+		journal:          journal,
+		equity:           equity,
+		executions:       executions,
+		deleverager:      deleverager,
+		calendar:         cal,
+		restrictions:     restrictions,
+		compliance:       monitor,
+		reports:          reports,
+		guard:            guard,
+		chaos:            injector,
+		rateBudget:       rateBudget,
+		marketData:       marketData,
+		prices:           prices,
+		marketAnalyzer:   analyzer,
+		sentiment:        sentiment,
+		strategyFactory:  strategyFactory,
 		portfolioManager: portfolioManager,
-		riskManager:      riskManager,
-		metricsCollector: metricsCollector,
-	}, nil
+		riskManager:      risk.NewManager(),
+		metricsCollector: &analytics.MetricsCollector{},
+		metrics:          metrics.NewStandardRegistry(),
+	}
+
+	// Sub-accounts get their own clients, budgets and portfolios; strategies
+	// reach them by name through the factory
+	c.accounts = []*Account{{name: config.MainAccount, exchange: client, portfolio: portfolioManager, budget: rateBudget, paper: paper, journal: journal}}
+	wrap := func(client types.ExchangeClient) types.ExchangeClient {
+		if deleverager != nil {
+			client = deleverager.Client(client)
+		}
+		if cal != nil {
+			client = cal.Client(client)
+		}
+		client = restrictions.Client(client)
+		if monitor != nil {
+			client = monitor.Client(client)
+		}
+		if guard != nil {
+			client = guard.Client(client)
+		}
+		return client
+	}
+	for _, accountCfg := range cfg.Exchange.Accounts {
+		account := newSubAccount(c, accountCfg, wrap)
+		c.accounts = append(c.accounts, account)
+		if executions != nil {
+			executions.AddAccount(account.name, account.exchange)
+		}
+	}
+	clients := make(map[string]types.ExchangeClient, len(c.accounts))
+	for _, account := range c.accounts {
+		clients[account.name] = account.exchange
+	}
+	strategyFactory.SetAccounts(clients)
+	strategyFactory.SetVenueQuoter(func(venues map[string]types.ExchangeClient) strategy.VenueQuoter {
+		clients := make(map[string]exchange.Client, len(venues))
+		for name, client := range venues {
+			clients[name] = client
+		}
+		return exchange.NewUnifiedClientFromClients(clients, log)
+	})
+	return c, nil
+}
+
+// Config returns the loaded configuration
+func (c *Container) Config() *config.Config {
+	return c.config
+}
+
+// Logger returns the application logger
+func (c *Container) Logger() *logger.Logger {
+	return c.logger
+}
+
+// Exchange returns the default exchange client
+func (c *Container) Exchange() types.ExchangeClient {
+	return c.exchange
+}
+
+// Maintenance returns the exchange availability monitor
+func (c *Container) Maintenance() *maintenance.Monitor {
+	return c.maintenance
+}
+
+// ExchangeClient returns the exchange client registered under name
+func (c *Container) ExchangeClient(name string) (exchange.Client, bool) {
+	client, ok := c.exchangeClients[name]
+	return client, ok
+}
+
+// StrategyFactory returns the strategy factory
+func (c *Container) StrategyFactory() *strategy.Factory {
+	return c.strategyFactory
+}
+
+// PortfolioManager returns the portfolio manager
+func (c *Container) PortfolioManager() *portfolio.Manager {
+	return c.portfolioManager
+}
+
+// StateStore returns the persistent state store, or nil when STATE_DIR is unset
+func (c *Container) StateStore() *StateStore {
+	return c.stateStore
+}
+
+// EquityRecorder returns the persisted equity curve, or nil when STATE_DIR is unset
+func (c *Container) EquityRecorder() *EquityRecorder {
+	return c.equity
+}
+
+// Sentiment returns the sentiment recorder, or nil without sentiment feeds
+func (c *Container) Sentiment() *SentimentRecorder {
+	return c.sentiment
+}
+
+// StrategyMetrics returns strat's metrics with the risk ratios and drawdown
+// of the account's equity curve, which strategies do not compute themselves
+func (c *Container) StrategyMetrics(strat strategy.Strategy) types.StrategyMetrics {
+	m := strat.GetMetrics()
+	if c.equity != nil {
+		c.equity.ApplyPerformance(&m)
+	}
+	return m
+}
+
+// Accounts returns the exchange accounts, the main account first
+func (c *Container) Accounts() []*Account {
+	return c.accounts
+}
+
+// Account returns the named account; "" is the main account
+func (c *Container) Account(name string) (*Account, bool) {
+	if name == "" {
+		name = config.MainAccount
+	}
+	for _, account := range c.accounts {
+		if account.name == name {
+			return account, true
+		}
+	}
+	return nil, false
+}
+
+// Executions returns the fill attribution log, or nil when STATE_DIR is unset
+func (c *Container) Executions() *ExecutionLog {
+	return c.executions
+}
+
+// Deleverager returns the drawdown deleverager, or nil when not configured
+func (c *Container) Deleverager() *risk.Deleverager {
+	return c.deleverager
+}
+
+// Calendar returns the event blackout calendar, or nil when not configured
+func (c *Container) Calendar() *calendar.Calendar {
+	return c.calendar
+}
+
+// Chaos returns the paper exchange's fault injector, or nil when disabled
+func (c *Container) Chaos() *chaos.Injector {
+	return c.chaos
+}
+
+// Restrictions returns the symbol blacklists and trading hours
+func (c *Container) Restrictions() *risk.Restrictions {
+	return c.restrictions
+}
+
+// Compliance returns the order compliance monitor, or nil when disabled
+func (c *Container) Compliance() *compliance.Monitor {
+	return c.compliance
+}
+
+// ComplianceReports returns the compliance report queue, or nil without
+// compliance checks or a state dir
+func (c *Container) ComplianceReports() *compliance.ReportManager {
+	return c.reports
+}
+
+// Guard returns the order anomaly circuit breaker, or nil when disabled
+func (c *Container) Guard() *risk.Guard {
+	return c.guard
+}
+
+// RateBudget returns the shared exchange request budget, or nil when not configured
+func (c *Container) RateBudget() *ratelimit.Budget {
+	return c.rateBudget
+}
+
+// MarketData returns the higher-timeframe data provider, or nil when not configured
+func (c *Container) MarketData() *marketdata.Provider {
+	return c.marketData
+}
+
+// Prices returns the reference price source, or nil when strategies trade on
+// the last price
+func (c *Container) Prices() *marketdata.PriceSource {
+	return c.prices
+}
+
+// Metrics returns the Prometheus metrics registry
+func (c *Container) Metrics() *metrics.Registry {
+	return c.metrics
+}
+
+// RiskManager returns the risk manager
+func (c *Container) RiskManager() *risk.Manager {
+	return c.riskManager
+}
+
+// orderClient journals orders under bot on the main account, below the same
+// risk controls and request budget as the live strategy's orders. It needs
+// a state dir.
+func (c *Container) orderClient(bot string) types.ExchangeClient {
+	journal := newJournalClient(c.journal.ExchangeClient, c.stateStore, c.logger)
+	journal.setBot(bot)
+	var client types.ExchangeClient = journal
+	if c.deleverager != nil {
+		client = c.deleverager.Client(client)
+	}
+	if c.calendar != nil {
+		client = c.calendar.Client(client)
+	}
+	client = c.restrictions.Client(client)
+	if c.compliance != nil {
+		client = c.compliance.Client(client)
+	}
+	if c.guard != nil {
+		client = c.guard.Client(client)
+	}
+	if c.rateBudget != nil {
+		client = c.rateBudget.Client("strategy", client)
+	}
+	return client
+}
+
+// rebalanceClient places portfolio rebalance orders: journaled under
+// "rebalance" with a state dir, else on the live client
+func (c *Container) rebalanceClient() types.ExchangeClient {
+	if c.stateStore == nil {
+		return c.Exchange()
+	}
+	return c.orderClient("rebalance")
 }
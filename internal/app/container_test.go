@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/chaos"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestContainer_Chaos(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{Name: "test", ReportingCurrency: "USD", StateDir: t.TempDir()},
+		Logging: config.LoggingConfig{Level: "error"},
+		Exchange: config.ExchangeConfig{
+			Chaos:    chaos.Config{RejectRate: 1, Seed: 1},
+			Accounts: []config.AccountConfig{{Name: "hedge"}},
+		},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Chaos() == nil {
+		t.Fatal("expected a fault injector")
+	}
+
+	// Faults hit every account below the journal, which records the rejections
+	for _, account := range c.Accounts() {
+		if account.journal != nil {
+			account.journal.setBot("bot")
+		}
+		order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.01, Price: 45000}
+		if err := account.Exchange().PlaceOrder(context.Background(), order); !errors.Is(err, chaos.ErrRejected) {
+			t.Errorf("%s: PlaceOrder() error = %v, want a chaos rejection", account.Name(), err)
+		}
+	}
+	submissions, err := readSubmissions(c.StateStore(), "bot", "BTCUSDT", c.Logger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != 2 || !submissions[0].failed || !submissions[1].failed {
+		t.Errorf("journaled %d submissions, want 2 failed", len(submissions))
+	}
+	if injected := c.Chaos().Status()["injected"].(map[string]int); injected["rejected_orders"] != 2 {
+		t.Errorf("injected = %v, want 2 rejected orders", injected)
+	}
+}
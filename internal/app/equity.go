@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// equityJournal is the journal equity snapshots are appended to
+const equityJournal = "equity"
+
+// defaultPerformanceWindow is the span live risk ratios are computed over
+const defaultPerformanceWindow = 90 * 24 * time.Hour
+
+// EquityRecorder snapshots account equity into the state store, so the
+// equity curve survives restarts and spans the account's whole history
+type EquityRecorder struct {
+	store  *StateStore
+	equity func() float64
+	logger *logger.Logger
+
+	benchmark      string
+	benchmarkPrice func() (float64, error)
+
+	mu          sync.RWMutex
+	points      []analytics.EquityPoint
+	performance *analytics.RollingPerformance
+}
+
+// NewEquityRecorder loads snapshots recorded by earlier runs
+func NewEquityRecorder(store *StateStore, equity func() float64, log *logger.Logger) (*EquityRecorder, error) {
+	points, err := ReadEquity(store)
+	if err != nil {
+		return nil, err
+	}
+	r := &EquityRecorder{store: store, equity: equity, logger: log, points: points}
+	r.SetPerformanceWindow(defaultPerformanceWindow)
+	return r, nil
+}
+
+// ReadEquity returns the equity snapshots recorded in store, oldest first
+func ReadEquity(store *StateStore) ([]analytics.EquityPoint, error) {
+	var points []analytics.EquityPoint
+	err := store.Scan(equityJournal, func(line []byte) error {
+		var point analytics.EquityPoint
+		if err := json.Unmarshal(line, &point); err != nil {
+			return fmt.Errorf("failed to decode equity snapshot: %w", err)
+		}
+		points = append(points, point)
+		return nil
+	})
+	return points, err
+}
+
+// SetPerformanceWindow recomputes the rolling risk ratios over window
+func (r *EquityRecorder) SetPerformanceWindow(window time.Duration) {
+	performance := analytics.NewRollingPerformance(window)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	performance.Add(r.points...)
+	r.performance = performance
+}
+
+// ApplyPerformance fills m's Sharpe, Sortino and Calmar ratios and max
+// drawdown from the rolling window of the equity curve
+func (r *EquityRecorder) ApplyPerformance(m *types.StrategyMetrics) {
+	r.mu.RLock()
+	performance := r.performance
+	r.mu.RUnlock()
+	performance.Apply(m)
+}
+
+// SetBenchmark records the price of asset with every snapshot. A snapshot
+// whose price lookup fails is recorded without it.
+func (r *EquityRecorder) SetBenchmark(asset string, price func() (float64, error)) {
+	r.benchmark, r.benchmarkPrice = asset, price
+}
+
+// Benchmark returns the benchmark asset, or "" when none is set
+func (r *EquityRecorder) Benchmark() string {
+	return r.benchmark
+}
+
+// Record appends the current equity; it is skipped until equity is known
+func (r *EquityRecorder) Record(now time.Time) error {
+	equity := r.equity()
+	if equity <= 0 {
+		return nil
+	}
+
+	point := analytics.EquityPoint{Time: now.UTC(), Equity: equity}
+	if r.benchmarkPrice != nil {
+		price, err := r.benchmarkPrice()
+		if err != nil {
+			r.logger.Warn("Failed to price benchmark %s: %v", r.benchmark, err)
+		} else {
+			point.Benchmark = price
+		}
+	}
+	if err := r.store.Append(equityJournal, point); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.points = append(r.points, point)
+	r.performance.Add(point)
+	r.mu.Unlock()
+	return nil
+}
+
+// Run records a snapshot every interval until ctx is done
+func (r *EquityRecorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := r.Record(now); err != nil {
+				r.logger.Error("Failed to record equity snapshot: %v", err)
+			}
+		}
+	}
+}
+
+// History returns snapshots within [from, to]; zero bounds are open
+func (r *EquityRecorder) History(from, to time.Time) []analytics.EquityPoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var points []analytics.EquityPoint
+	for _, p := range r.points {
+		if (!from.IsZero() && p.Time.Before(from)) || (!to.IsZero() && p.Time.After(to)) {
+			continue
+		}
+		points = append(points, p)
+	}
+	return points
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ExecutionLog attributes slippage and commission to journaled orders. It
+// matches submissions against the exchange's filled orders and journals the
+// expected price, fill price and fee of each, so execution statistics span
+// restarts and can calibrate backtest slippage.
+type ExecutionLog struct {
+	store    *StateStore
+	exchange types.ExchangeClient
+	name     string // exchange name executions are labeled with
+	logger   *logger.Logger
+	accounts map[string]types.ExchangeClient // sub-account clients by name
+	shadow   bool                            // attributes a shadow strategy's hypothetical fills
+	onFill   func(analytics.Execution)       // called with every real fill attributed
+
+	mu         sync.RWMutex
+	executions []analytics.Execution
+}
+
+// NewExecutionLog loads executions journaled by earlier runs
+func NewExecutionLog(store *StateStore, exchange types.ExchangeClient, name string, log *logger.Logger) (*ExecutionLog, error) {
+	l := &ExecutionLog{store: store, exchange: exchange, name: name, logger: log}
+	executions, err := ReadExecutions(store)
+	if err != nil {
+		return nil, err
+	}
+	l.executions = executions
+	return l, nil
+}
+
+// AddAccount attributes fills of orders journaled for a sub-account through
+// that account's client
+func (l *ExecutionLog) AddAccount(name string, exchange types.ExchangeClient) {
+	if l.accounts == nil {
+		l.accounts = make(map[string]types.ExchangeClient)
+	}
+	l.accounts[name] = exchange
+}
+
+// OnFill calls fn with every real fill attributed from now on
+func (l *ExecutionLog) OnFill(fn func(analytics.Execution)) {
+	l.onFill = fn
+}
+
+// trackPositions keeps the strategies' positions in m up to date with l's
+// fills, starting with those journaled by earlier runs
+func trackPositions(l *ExecutionLog, m *portfolio.Manager) {
+	apply := func(e analytics.Execution) {
+		m.ApplyFill(e.StrategyID, e.Symbol, types.OrderSide(e.Side), e.Quantity, e.FilledPrice)
+	}
+	for _, e := range l.History(time.Time{}, time.Time{}) {
+		apply(e)
+	}
+	l.OnFill(apply)
+}
+
+// ReadExecutions returns the executions journaled in store, without the
+// hypothetical fills of shadow strategies. Undecodable lines are skipped as
+// they are during recovery.
+func ReadExecutions(store *StateStore) ([]analytics.Execution, error) {
+	var executions []analytics.Execution
+	err := store.Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if json.Unmarshal(line, &entry) != nil || entry.Action != journalFill || entry.Execution == nil || entry.Shadow {
+			return nil
+		}
+		executions = append(executions, *entry.Execution)
+		return nil
+	})
+	return executions, err
+}
+
+// Attribute journals the execution of bot's orders for symbol that filled
+// since the last call and returns how many were added. Orders without an
+// expected price or missing from the exchange's history are left for later.
+func (l *ExecutionLog) Attribute(ctx context.Context, bot, symbol string) (int, error) {
+	submissions, err := readSubmissions(l.store, bot, symbol, l.logger)
+	if err != nil {
+		return 0, err
+	}
+	pending := make(map[string]map[string]*journaledOrder) // by account, then client order id
+	for _, s := range submissions {
+		if !s.filled && !s.failed && s.order.Price > 0 {
+			if pending[s.account] == nil {
+				pending[s.account] = make(map[string]*journaledOrder)
+			}
+			pending[s.account][s.order.ExchangeOrder.ClientOrderID] = s
+		}
+	}
+
+	var added int
+	for account, orders := range pending {
+		exchange := l.exchange
+		if account != "" {
+			if exchange = l.accounts[account]; exchange == nil {
+				l.logger.Warn("Cannot attribute %d fill(s) of unknown account %s", len(orders), account)
+				continue
+			}
+		}
+		n, err := l.attribute(ctx, exchange, account, bot, symbol, orders)
+		added += n
+		if err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+// attribute journals the fills of one account's pending orders
+func (l *ExecutionLog) attribute(ctx context.Context, exchange types.ExchangeClient, account, bot, symbol string, pending map[string]*journaledOrder) (int, error) {
+	history, err := exchange.GetFilledOrders(ctx, symbol)
+	if errors.Is(err, types.ErrNotSupported) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get order history: %w", err)
+	}
+
+	// Exchanges that report no commission are charged their fee schedule
+	var fees *types.TradingFees
+	if f, err := exchange.GetTradingFees(ctx, symbol); err == nil {
+		fees = f
+	} else if !errors.Is(err, types.ErrNotSupported) {
+		l.logger.Warn("Fee schedule unavailable, attributing fills without fees: %v", err)
+	}
+
+	var added int
+	for _, actual := range history {
+		if actual.ExchangeOrder == nil || actual.FilledAmount <= 0 || actual.FilledPrice <= 0 {
+			continue
+		}
+		s, ok := pending[actual.ExchangeOrder.ClientOrderID]
+		if !ok {
+			continue
+		}
+		delete(pending, actual.ExchangeOrder.ClientOrderID)
+
+		execution := newExecution(bot, l.name, s.order, actual, fees)
+		execution.Account = account
+		entry := JournalEntry{
+			Time:          time.Now(),
+			Bot:           bot,
+			Account:       account,
+			StrategyID:    execution.StrategyID,
+			Action:        journalFill,
+			OrderID:       actual.ID,
+			ClientOrderID: actual.ExchangeOrder.ClientOrderID,
+			Shadow:        l.shadow,
+			Execution:     &execution,
+		}
+		if err := l.store.Append(journalName, entry); err != nil {
+			return added, fmt.Errorf("failed to journal fill: %w", err)
+		}
+		added++
+		if l.shadow {
+			continue
+		}
+		l.mu.Lock()
+		l.executions = append(l.executions, execution)
+		l.mu.Unlock()
+		if l.onFill != nil {
+			l.onFill(execution)
+		}
+	}
+	return added, nil
+}
+
+// newExecution combines a journaled submission with the exchange's fill
+func newExecution(bot, exchange string, submitted, actual types.Order, fees *types.TradingFees) analytics.Execution {
+	execution := analytics.Execution{
+		Time:          actual.Timestamp.UTC(),
+		Bot:           bot,
+		Exchange:      exchange,
+		Symbol:        submitted.Symbol,
+		Side:          string(submitted.Side),
+		Type:          string(submitted.Type),
+		OrderID:       actual.ID,
+		Quantity:      actual.FilledAmount,
+		ExpectedPrice: submitted.Price,
+		FilledPrice:   actual.FilledPrice,
+	}
+	if actual.Timestamp.IsZero() {
+		execution.Time = time.Now().UTC()
+	}
+	if submitted.Decision != nil {
+		execution.Strategy = submitted.Decision.Strategy
+		execution.StrategyID = submitted.Decision.StrategyID
+	}
+	if fees != nil {
+		rate := fees.TakerFee
+		if submitted.Type == types.OrderTypeLimit {
+			rate = fees.MakerFee
+		}
+		execution.Fee = execution.Notional() * rate
+	}
+	return execution
+}
+
+// Run attributes bot's fills every interval until ctx is done
+func (l *ExecutionLog) Run(ctx context.Context, interval time.Duration, bot, symbol string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.Attribute(ctx, bot, symbol); err != nil {
+				l.logger.Error("Failed to attribute fills: %v", err)
+			}
+		}
+	}
+}
+
+// History returns executions within [from, to]; zero bounds are open
+func (l *ExecutionLog) History(from, to time.Time) []analytics.Execution {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var executions []analytics.Execution
+	for _, e := range l.executions {
+		if (!from.IsZero() && e.Time.Before(from)) || (!to.IsZero() && e.Time.After(to)) {
+			continue
+		}
+		executions = append(executions, e)
+	}
+	return executions
+}
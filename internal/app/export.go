@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// exportTimeLayout is the time format of exports, which spreadsheets parse as
+// a date; the zone is named in the column header instead
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+// table is an export: a header row and rows of string or float64 cells
+type table struct {
+	header []string
+	rows   [][]interface{}
+}
+
+// tradesTable lists fills oldest first with times in loc
+func tradesTable(history []analytics.Execution, loc *time.Location) table {
+	t := table{header: []string{
+		"Time (" + loc.String() + ")", "Bot", "Strategy", "Exchange", "Account", "Symbol", "Side", "Type", "Order ID",
+		"Quantity", "Expected Price", "Filled Price", "Notional", "Fee", "Slippage (bps)",
+	}}
+	for _, e := range history {
+		t.rows = append(t.rows, []interface{}{
+			e.Time.In(loc).Format(exportTimeLayout), e.Bot, e.Strategy, e.Exchange, e.Account, e.Symbol, e.Side, e.Type, e.OrderID,
+			e.Quantity, e.ExpectedPrice, e.FilledPrice, e.Notional(), e.Fee, e.SlippageBps(),
+		})
+	}
+	return t
+}
+
+// positionsTable lists positions by symbol with update times in loc
+func positionsTable(positions []*types.Position, loc *time.Location) table {
+	t := table{header: []string{
+		"Symbol", "Quantity", "Avg Price", "Current Price", "Value", "Unrealized PnL", "Realized PnL", "Updated (" + loc.String() + ")",
+	}}
+	for _, p := range positions {
+		updated := ""
+		if !p.Timestamp.IsZero() {
+			updated = p.Timestamp.In(loc).Format(exportTimeLayout)
+		}
+		t.rows = append(t.rows, []interface{}{
+			p.Symbol, p.Quantity, p.AvgPrice, p.CurrentPrice, p.Quantity * p.CurrentPrice, p.UnrealizedPnL, p.RealizedPnL, updated,
+		})
+	}
+	return t
+}
+
+// sortedPositions returns the manager's positions ordered by symbol
+func sortedPositions(m *portfolio.Manager) []*types.Position {
+	all := m.GetAllPositions()
+	positions := make([]*types.Position, 0, len(all))
+	for _, p := range all {
+		positions = append(positions, p)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	return positions
+}
+
+// formatCell writes a cell as text, numbers without an exponent
+func formatCell(cell interface{}) string {
+	if v, ok := cell.(float64); ok {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(cell)
+}
+
+// exportLocation reads the ?tz= IANA zone of exported times, UTC by default
+func exportLocation(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tz: %w", err)
+	}
+	return loc, nil
+}
+
+// writeExport sends t as a <name>-<date> attachment in the ?format= of the
+// request: csv (default) or xlsx
+func writeExport(w http.ResponseWriter, r *http.Request, name string, t table) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		writeError(w, http.StatusBadRequest, "format must be csv or xlsx")
+		return
+	}
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102"), format)
+
+	if format == "xlsx" {
+		var buf bytes.Buffer
+		if err := writeXLSX(&buf, name, t); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		_, _ = w.Write(buf.Bytes())
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	out := csv.NewWriter(w)
+	_ = out.Write(t.header)
+	record := make([]string, len(t.header))
+	for _, row := range t.rows {
+		for i, cell := range row {
+			record[i] = formatCell(cell)
+		}
+		_ = out.Write(record)
+	}
+	out.Flush()
+}
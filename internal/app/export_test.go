@@ -0,0 +1,89 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestTradesTable_TimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	fill := analytics.Execution{
+		Time: time.Date(2024, 7, 1, 22, 30, 0, 0, time.UTC), Symbol: "BTCUSDT", Side: "BUY", Type: "MARKET",
+		Quantity: 0.5, ExpectedPrice: 100, FilledPrice: 101, Fee: 0.05,
+	}
+	got := tradesTable([]analytics.Execution{fill}, berlin)
+	if got.header[0] != "Time (Europe/Berlin)" {
+		t.Errorf("header = %q, want the zone named", got.header[0])
+	}
+	// Summer time: UTC+2 rolls the fill into the next day
+	if got.rows[0][0] != "2024-07-02 00:30:00" || got.rows[0][12] != 50.5 || got.rows[0][14] != 100.0 {
+		t.Errorf("row = %v, want the Berlin time, notional 50.5 and 100 bps slippage", got.rows[0])
+	}
+}
+
+func TestRouter_PortfolioExport(t *testing.T) {
+	c := newTestContainer(t, "")
+	order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Status: types.OrderStatusFilled, FilledAmount: 0.25, FilledPrice: 40000}
+	if err := c.PortfolioManager().UpdatePosition(order); err != nil {
+		t.Fatal(err)
+	}
+	router := newRouter(c, nil, &probeState{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/portfolio/export", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("csv export = %d %s: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), `filename="positions-`) {
+		t.Errorf("Content-Disposition = %q, want a positions attachment", rec.Header().Get("Content-Disposition"))
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0][7] != "Updated (UTC)" || records[1][0] != "BTCUSDT" || records[1][1] != "0.25" || records[1][2] != "40000" {
+		t.Errorf("csv = %v, want a header and the BTC position", records)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/portfolio/export?format=xlsx", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("xlsx export = %d: %s", rec.Code, rec.Body)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("xlsx is not a zip: %v", err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, _ := f.Open()
+			raw, _ := io.ReadAll(r)
+			sheet = string(raw)
+		}
+	}
+	if !strings.Contains(sheet, "<t xml:space=\"preserve\">BTCUSDT</t>") || !strings.Contains(sheet, "<v>0.25</v>") {
+		t.Errorf("sheet = %s, want the symbol as text and the quantity as a number", sheet)
+	}
+
+	for _, path := range []string{"/portfolio/export?format=pdf", "/portfolio/export?tz=Mars/Olympus", "/trades/export"} {
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code < 400 || !strings.Contains(rec.Body.String(), `"code"`) {
+			t.Errorf("%s = %d: %s, want an error", path, rec.Code, rec.Body)
+		}
+	}
+}
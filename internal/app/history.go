@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// preloadHistory fetches the candles strat requires and hands them to it if
+// it loads history. Progress is logged per requirement. History that cannot
+// be fetched is not fatal: the strategy fetches it again when it executes,
+// holding until enough bars have closed.
+func preloadHistory(ctx context.Context, strat strategy.Strategy, exchange types.ExchangeClient, log *logger.Logger) {
+	reqs := strat.RequiredHistory()
+	if len(reqs) == 0 {
+		return
+	}
+	loader, _ := strat.(strategy.HistoryLoader)
+	log.Info("Preloading history for %s: %d series", strat.ID(), len(reqs))
+	for i, req := range reqs {
+		client := req.Exchange
+		if client == nil {
+			client = exchange
+		}
+		candles, err := client.GetCandles(ctx, req.Symbol, req.Interval, req.Candles)
+		if err != nil {
+			log.Warn("History %d/%d: %s %s unavailable: %v", i+1, len(reqs), req.Symbol, req.Interval, err)
+			continue
+		}
+		sort.SliceStable(candles, func(a, b int) bool { return candles[a].Timestamp.Before(candles[b].Timestamp) })
+		if len(candles) < req.Candles {
+			log.Warn("History %d/%d: only %d of %d %s %s candles available, trading waits for the rest", i+1, len(reqs), len(candles), req.Candles, req.Symbol, req.Interval)
+		} else {
+			log.Info("History %d/%d: loaded %d %s %s candles", i+1, len(reqs), len(candles), req.Symbol, req.Interval)
+		}
+		if loader != nil {
+			loader.LoadHistory(req, candles)
+		}
+	}
+}
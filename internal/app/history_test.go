@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// historyStrategy requires reqs and records the history it is given
+type historyStrategy struct {
+	strategy.Strategy
+	reqs   []strategy.HistoryRequirement
+	loaded map[string][]types.Candle
+}
+
+func (s *historyStrategy) ID() string { return "history" }
+
+func (s *historyStrategy) RequiredHistory() []strategy.HistoryRequirement { return s.reqs }
+
+func (s *historyStrategy) LoadHistory(req strategy.HistoryRequirement, candles []types.Candle) {
+	s.loaded[req.Symbol+" "+req.Interval] = candles
+}
+
+// noCandles fails every candle request
+type noCandles struct{ *mock.MockClient }
+
+func (noCandles) GetCandles(ctx context.Context, symbol, interval string, limit int) ([]types.Candle, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestPreloadHistory(t *testing.T) {
+	strat := &historyStrategy{
+		reqs: []strategy.HistoryRequirement{
+			{Symbol: "BTCUSDT", Interval: "1h", Candles: 50},
+			{Symbol: "ETHUSDT", Interval: "4h", Candles: 20, Exchange: noCandles{mock.NewMockClient()}},
+		},
+		loaded: make(map[string][]types.Candle),
+	}
+	preloadHistory(context.Background(), strat, mock.NewMockClient(), logger.New(logger.LevelError))
+
+	candles := strat.loaded["BTCUSDT 1h"]
+	if len(candles) != 50 {
+		t.Fatalf("loaded %d BTCUSDT candles, want 50 from the bot's exchange", len(candles))
+	}
+	for i := 1; i < len(candles); i++ {
+		if candles[i].Timestamp.Before(candles[i-1].Timestamp) {
+			t.Fatal("preloaded candles are not oldest first")
+		}
+	}
+	if _, ok := strat.loaded["ETHUSDT 4h"]; ok {
+		t.Error("history that failed to load was passed to the strategy")
+	}
+}
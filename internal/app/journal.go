@@ -0,0 +1,320 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// JournalEntry is one order action recorded in the order journal
+type JournalEntry struct {
+	Time          time.Time    `json:"time"`
+	Bot           string       `json:"bot,omitempty"`
+	Account       string       `json:"account,omitempty"`     // empty for the main account
+	StrategyID    string       `json:"strategy_id,omitempty"` // strategy instance placing the order
+	Action        string       `json:"action"`                // submit, place, cancel, fill
+	Order         *types.Order `json:"order,omitempty"`
+	OrderID       string       `json:"order_id,omitempty"`
+	ClientOrderID string       `json:"client_order_id,omitempty"`
+	Error         string       `json:"error,omitempty"`
+	Shadow        bool         `json:"shadow,omitempty"` // hypothetical order of a shadow strategy, never sent
+
+	Execution *analytics.Execution `json:"execution,omitempty"` // fill entries only
+}
+
+// Journal actions
+const (
+	journalSubmit = "submit" // written before the order is sent
+	journalPlace  = "place"  // outcome of the submission
+	journalCancel = "cancel"
+	journalFill   = "fill" // fill price and fee, matched from exchange history
+)
+
+// journalName is the order journal file name within the state dir
+const journalName = "orders"
+
+// journalClient records order placement and cancellation in the state store.
+// Orders are written ahead of submission under a client order id, so an order
+// in flight during a crash can be matched against exchange history on restart.
+//
+// Orders carrying a strategy decision are deduplicated on the decision, not on
+// the caller's client order id: a decision retried after a crash interrupted
+// its submission is not resent if the exchange has the interrupted order, and
+// lookups of the retry's client order id answer with that order. Orders
+// without a client order id get a deterministic one: the decision plus how
+// often the exchange has taken it before.
+type journalClient struct {
+	types.ExchangeClient
+	store  *StateStore
+	logger *logger.Logger
+
+	account string // tags entries of a sub-account's orders
+	shadow  bool   // tags entries of a shadow strategy's orders
+
+	mu         sync.Mutex
+	bot        string
+	loaded     bool              // journal read for bot
+	placed     map[string]int    // orders the exchange took, by decision key
+	unanswered map[string]string // decision keys of submissions the exchange never answered, by client order id
+	aliases    map[string]string // client order ids of interrupted orders, by the id of their retry
+	seq        atomic.Int64
+}
+
+func newJournalClient(exchange types.ExchangeClient, store *StateStore, log *logger.Logger) *journalClient {
+	return &journalClient{ExchangeClient: exchange, store: store, logger: log}
+}
+
+// setBot tags subsequent entries with the bot placing the orders
+func (j *journalClient) setBot(bot string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.bot = bot
+	j.loaded = false
+}
+
+func (j *journalClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	key := decisionKey(order)
+	if order.ExchangeOrder == nil || order.ExchangeOrder.ClientOrderID == "" {
+		exchangeOrder := types.ExchangeOrder{}
+		if order.ExchangeOrder != nil {
+			exchangeOrder = *order.ExchangeOrder
+		}
+		id, err := j.clientOrderID(order, key)
+		if err != nil {
+			return err
+		}
+		exchangeOrder.ClientOrderID = id
+		order.ExchangeOrder = &exchangeOrder
+	}
+	clientOrderID, strategyID := order.ExchangeOrder.ClientOrderID, decisionStrategyID(order)
+
+	// An interrupted submission of the same decision may have reached the exchange
+	interrupted, err := j.interrupted(clientOrderID, key)
+	if err != nil {
+		return err
+	}
+	if interrupted != "" {
+		existing, found, err := findExchangeOrder(ctx, j.ExchangeClient, order.Symbol, interrupted)
+		if err != nil {
+			return fmt.Errorf("failed to check for order %s placed before a restart: %w", interrupted, err)
+		}
+		switch {
+		case found:
+			j.logger.Warn("Order %s was placed before a restart, not resending it", interrupted)
+			j.record(JournalEntry{Time: time.Now(), StrategyID: strategyID, Action: journalPlace, OrderID: existing.ID, ClientOrderID: interrupted}, nil)
+			j.answered(interrupted, key, nil)
+			if interrupted != clientOrderID {
+				j.mu.Lock()
+				j.aliases[clientOrderID] = interrupted
+				j.mu.Unlock()
+			}
+			return nil
+		case interrupted != clientOrderID:
+			// The retry goes out under its own id; the interrupted one never will
+			j.record(JournalEntry{Time: time.Now(), StrategyID: strategyID, Action: journalPlace, ClientOrderID: interrupted}, errNotPlaced)
+			j.answered(interrupted, key, errNotPlaced)
+		}
+	}
+
+	// A submission that cannot be journaled is not sent
+	if err := j.append(JournalEntry{Time: time.Now(), StrategyID: strategyID, Action: journalSubmit, Order: &order, ClientOrderID: clientOrderID}); err != nil {
+		return fmt.Errorf("failed to journal order: %w", err)
+	}
+	j.mu.Lock()
+	j.unanswered[clientOrderID] = key
+	j.mu.Unlock()
+
+	err = j.ExchangeClient.PlaceOrder(ctx, order)
+	j.record(JournalEntry{Time: time.Now(), StrategyID: strategyID, Action: journalPlace, ClientOrderID: clientOrderID}, err)
+	j.answered(clientOrderID, key, err)
+	return err
+}
+
+// clientOrderID names an order without a client order id: deterministically
+// from its decision key, or uniquely without one
+func (j *journalClient) clientOrderID(order types.Order, key string) (string, error) {
+	if key == "" {
+		return types.NewClientOrderID(decisionStrategyID(order), "j", j.seq.Add(1)), nil
+	}
+	if err := j.load(); err != nil {
+		return "", err
+	}
+	j.mu.Lock()
+	n := j.placed[key]
+	bot := j.bot
+	j.mu.Unlock()
+
+	h := fnv.New32a()
+	h.Write([]byte(bot + "|" + j.account + "|" + key))
+	id := "j" + strconv.FormatUint(uint64(h.Sum32()), 36) + "-" + strconv.Itoa(n)
+	return types.PrefixClientOrderID(decisionStrategyID(order), id), nil
+}
+
+// errNotPlaced answers an interrupted submission the exchange never got
+var errNotPlaced = errors.New("not placed before a restart")
+
+// interrupted returns the client order id of a submission that was journaled
+// but never answered by the exchange: of clientOrderID itself, or else of
+// the same decision. It returns "" without one.
+func (j *journalClient) interrupted(clientOrderID, key string) (string, error) {
+	if err := j.load(); err != nil {
+		return "", err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.unanswered[clientOrderID]; ok {
+		return clientOrderID, nil
+	}
+	if key == "" {
+		return "", nil
+	}
+	for id, k := range j.unanswered {
+		if k == key {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+// GetOrderByClientID looks a retried order up under the id of the
+// interrupted order that stood in for it
+func (j *journalClient) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	j.mu.Lock()
+	if id, ok := j.aliases[clientOrderID]; ok {
+		clientOrderID = id
+	}
+	j.mu.Unlock()
+	return j.ExchangeClient.GetOrderByClientID(ctx, symbol, clientOrderID)
+}
+
+// answered books the exchange's answer to a submission
+func (j *journalClient) answered(clientOrderID, key string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.unanswered, clientOrderID)
+	if err == nil && key != "" {
+		j.placed[key]++
+	}
+}
+
+// load reads the bot's submissions and their answers from the journal once
+func (j *journalClient) load() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.loaded {
+		return nil
+	}
+
+	placed, unanswered := make(map[string]int), make(map[string]string)
+	err := j.store.Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if json.Unmarshal(line, &entry) != nil || entry.Bot != j.bot || entry.Account != j.account || entry.ClientOrderID == "" {
+			return nil
+		}
+		switch entry.Action {
+		case journalSubmit:
+			if entry.Order != nil {
+				unanswered[entry.ClientOrderID] = decisionKey(*entry.Order)
+			}
+		case journalPlace:
+			key, ok := unanswered[entry.ClientOrderID]
+			if !ok {
+				return nil
+			}
+			delete(unanswered, entry.ClientOrderID)
+			if entry.Error == "" && key != "" {
+				placed[key]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read order journal: %w", err)
+	}
+	j.placed, j.unanswered, j.loaded = placed, unanswered, true
+	if j.aliases == nil {
+		j.aliases = make(map[string]string)
+	}
+	return nil
+}
+
+// decisionKey identifies the strategy decision behind an order; "" for
+// orders without one
+func decisionKey(order types.Order) string {
+	d := order.Decision
+	if d == nil || d.StrategyID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%g|%s|%d", d.StrategyID, order.Symbol, order.Side, d.Strategy, d.Action, d.Level, d.Exit, d.Target)
+}
+
+// findExchangeOrder looks an order up by client order id, or among the
+// exchange's open and filled orders of symbol where it cannot
+func findExchangeOrder(ctx context.Context, client types.ExchangeClient, symbol, clientOrderID string) (types.Order, bool, error) {
+	order, err := client.GetOrderByClientID(ctx, symbol, clientOrderID)
+	switch {
+	case err == nil:
+		return *order, true, nil
+	case errors.Is(err, types.ErrOrderNotFound):
+		return types.Order{}, false, nil
+	case !errors.Is(err, types.ErrNotSupported):
+		return types.Order{}, false, err
+	}
+	for _, list := range []func(context.Context, string) ([]types.Order, error){client.GetActiveOrders, client.GetFilledOrders} {
+		orders, err := list(ctx, symbol)
+		if errors.Is(err, types.ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			return types.Order{}, false, err
+		}
+		for _, order := range orders {
+			if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID == clientOrderID {
+				return order, true, nil
+			}
+		}
+	}
+	return types.Order{}, false, nil
+}
+
+func (j *journalClient) CancelOrder(ctx context.Context, orderID string) error {
+	err := j.ExchangeClient.CancelOrder(ctx, orderID)
+	j.record(JournalEntry{Time: time.Now(), Action: journalCancel, OrderID: orderID}, err)
+	return err
+}
+
+// decisionStrategyID returns the id of the strategy that placed order, ""
+// if unknown
+func decisionStrategyID(order types.Order) string {
+	if order.Decision == nil {
+		return ""
+	}
+	return order.Decision.StrategyID
+}
+
+func (j *journalClient) record(entry JournalEntry, err error) {
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := j.append(entry); err != nil {
+		j.logger.Error("Failed to journal order: %v", err)
+	}
+}
+
+func (j *journalClient) append(entry JournalEntry) error {
+	j.mu.Lock()
+	entry.Bot = j.bot
+	j.mu.Unlock()
+	entry.Account, entry.Shadow = j.account, j.shadow
+	return j.store.Append(journalName, entry)
+}
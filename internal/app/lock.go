@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrLocked reports that another instance runs the same bot
+var ErrLocked = errors.New("another instance is running")
+
+// heldLocks are the lock files this process holds. A lock naming this
+// process's PID but missing here was left by an earlier process that had the
+// same PID, as a restarted container's PID 1 does.
+var (
+	heldMu    sync.Mutex
+	heldLocks = make(map[string]bool)
+)
+
+// lockOwner is written to a lock file to identify the instance holding it
+type lockOwner struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Bot     string    `json:"bot"`
+	Symbol  string    `json:"symbol,omitempty"`
+	Started time.Time `json:"started"`
+}
+
+// StateLock marks a bot's state in the state dir as in use by this process
+type StateLock struct {
+	path  string
+	owner lockOwner
+}
+
+// lockName names the lock of a bot trading symbol; bots keep their journal
+// and snapshots apart by these two
+func lockName(bot, symbol string) string {
+	if symbol == "" {
+		return bot + ".lock"
+	}
+	return bot + "-" + symbol + ".lock"
+}
+
+// AcquireStateLock locks bot's state for symbol in store. It fails with
+// ErrLocked while another live process holds the lock; a lock left behind by
+// a process that died on this host, including an earlier one with this
+// process's PID, is taken over. Locks held from another host cannot be
+// checked and must be removed by hand.
+func AcquireStateLock(store *StateStore, bot, symbol string) (*StateLock, error) {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	host, _ := os.Hostname()
+	lock := &StateLock{
+		path:  filepath.Join(store.Dir(), lockName(bot, symbol)),
+		owner: lockOwner{PID: os.Getpid(), Host: host, Bot: bot, Symbol: symbol, Started: time.Now()},
+	}
+	data, err := json.Marshal(lock.owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lock: %w", err)
+	}
+
+	// A stale lock is removed once and creation retried
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(lock.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = f.Write(data)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(lock.path)
+				return nil, fmt.Errorf("failed to write lock: %w", err)
+			}
+			heldLocks[lock.path] = true
+			return lock, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock: %w", err)
+		}
+
+		holder, err := readLockOwner(lock.path)
+		if err != nil {
+			return nil, err
+		}
+		stale := holder.PID == 0 || (holder.Host == host && !lockHolderAlive(holder.PID, lock.path))
+		if attempt > 0 || !stale {
+			return nil, fmt.Errorf("%w: %s held by pid %d on %s since %s; stop it or remove %s",
+				ErrLocked, bot, holder.PID, holder.Host, holder.Started.Format(time.RFC3339), lock.path)
+		}
+		if err := os.Remove(lock.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale lock: %w", err)
+		}
+	}
+}
+
+// Path returns the lock file
+func (l *StateLock) Path() string {
+	return l.path
+}
+
+// Release removes the lock unless another process has taken it over
+func (l *StateLock) Release() error {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	delete(heldLocks, l.path)
+	holder, err := readLockOwner(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if holder.PID != l.owner.PID || holder.Host != l.owner.Host {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock: %w", err)
+	}
+	return nil
+}
+
+func readLockOwner(path string) (lockOwner, error) {
+	var owner lockOwner
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return owner, fmt.Errorf("failed to read lock: %w", err)
+	}
+	// A lock interrupted while being written has no owner yet and is
+	// treated as held by a dead process
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &owner); err != nil {
+			return owner, fmt.Errorf("failed to decode lock %s: %w", path, err)
+		}
+	}
+	return owner, nil
+}
+
+// lockHolderAlive reports whether the process with pid on this host still
+// holds the lock at path
+func lockHolderAlive(pid int, path string) bool {
+	if pid == os.Getpid() {
+		return heldLocks[path]
+	}
+	return processAlive(pid)
+}
+
+// processAlive reports whether a process with pid runs on this host
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// checkForeignOrders fails with ErrLocked when client has open symbol orders
+// tagged by a bot that are missing from the journal in store: another
+// instance, running against another state dir, is trading the account.
+func checkForeignOrders(ctx context.Context, store *StateStore, client types.ExchangeClient, symbol string) error {
+	open, err := client.GetActiveOrders(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	journaled := make(map[string]bool)
+	err = store.Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if json.Unmarshal(line, &entry) == nil && entry.ClientOrderID != "" {
+			journaled[entry.ClientOrderID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var foreign []string
+	for _, order := range open {
+		if order.ExchangeOrder == nil || !botOrderID(order.ExchangeOrder.ClientOrderID) {
+			continue
+		}
+		if !journaled[order.ExchangeOrder.ClientOrderID] {
+			foreign = append(foreign, order.ExchangeOrder.ClientOrderID)
+		}
+	}
+	if len(foreign) > 0 {
+		return fmt.Errorf("%w: %d open %s order(s) placed by a bot outside this state dir (%s)",
+			ErrLocked, len(foreign), symbol, strings.Join(foreign, ", "))
+	}
+	return nil
+}
+
+// botOrderID reports whether a client order id was generated by a strategy
+// ("s...") or the order journal ("j..."), with or without a strategy id prefix
+func botOrderID(clientOrderID string) bool {
+	_, id := types.SplitClientOrderID(clientOrderID)
+	return len(id) > 1 && (id[0] == 's' || id[0] == 'j') && strings.Contains(id, "-")
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestStateLock(t *testing.T) {
+	store, err := NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquireStateLock(store, "grid", "BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireStateLock(store, "grid", "BTCUSDT"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second lock error = %v, want ErrLocked", err)
+	}
+	// Other bots and symbols keep their own state
+	other, err := AcquireStateLock(store, "grid", "ETHUSDT")
+	if err != nil {
+		t.Fatalf("lock for another symbol: %v", err)
+	}
+	_ = other.Release()
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(lock.Path()); !os.IsNotExist(err) {
+		t.Fatalf("lock file left after release: %v", err)
+	}
+
+	// A lock left by a process that is gone is taken over
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(lockOwner{PID: 1 << 30, Host: host, Bot: "grid", Started: time.Now()})
+	if err := os.WriteFile(lock.Path(), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = AcquireStateLock(store, "grid", "BTCUSDT")
+	if err != nil {
+		t.Fatalf("stale lock not taken over: %v", err)
+	}
+	_ = lock.Release()
+
+	// So is one left by an earlier process with this PID, as a restarted
+	// container's PID 1 leaves it
+	data, _ = json.Marshal(lockOwner{PID: os.Getpid(), Host: host, Bot: "grid", Started: time.Now().Add(-time.Hour)})
+	if err := os.WriteFile(lock.Path(), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = AcquireStateLock(store, "grid", "BTCUSDT")
+	if err != nil {
+		t.Fatalf("lock left by the same pid not taken over: %v", err)
+	}
+	if _, err := AcquireStateLock(store, "grid", "BTCUSDT"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("lock taken over twice, error = %v, want ErrLocked", err)
+	}
+	_ = lock.Release()
+
+	// Locks from other hosts cannot be checked
+	data, _ = json.Marshal(lockOwner{PID: 1 << 30, Host: host + "-other", Bot: "grid", Started: time.Now()})
+	if err := os.WriteFile(lock.Path(), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireStateLock(store, "grid", "BTCUSDT"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("lock from another host error = %v, want ErrLocked", err)
+	}
+}
+
+// openOrdersExchange reports fixed open orders
+type openOrdersExchange struct {
+	*PaperExchange
+	open []types.Order
+}
+
+func (o *openOrdersExchange) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return o.open, nil
+}
+
+func TestCheckForeignOrders(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(journalName, JournalEntry{Time: time.Now(), Bot: "grid", Action: journalSubmit, ClientOrderID: "s1-1"}); err != nil {
+		t.Fatal(err)
+	}
+	open := func(id string) types.Order {
+		return types.Order{Symbol: "BTCUSDT", Status: types.OrderStatusNew, ExchangeOrder: &types.ExchangeOrder{ClientOrderID: id}}
+	}
+	client := &openOrdersExchange{PaperExchange: newTestContainer(t, "").paperExchange}
+
+	// Open orders of this state dir and manual orders do not block a start
+	client.open = []types.Order{open("s1-1"), open("manual")}
+	if err := checkForeignOrders(ctx, store, client, "BTCUSDT"); err != nil {
+		t.Fatalf("own orders blocked the start: %v", err)
+	}
+
+	// A bot order missing from the journal belongs to another instance
+	client.open = append(client.open, open("s2-1"))
+	if err := checkForeignOrders(ctx, store, client, "BTCUSDT"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("foreign order error = %v, want ErrLocked", err)
+	}
+
+	// Ids prefixed with a strategy id are bot orders too
+	client.open = []types.Order{open("dca-eth.j2-1")}
+	if err := checkForeignOrders(ctx, store, client, "BTCUSDT"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("prefixed foreign order error = %v, want ErrLocked", err)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/marketdata"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// marketAnalyzer computes market analytics from the market data provider's
+// candle cache
+type marketAnalyzer struct {
+	provider *marketdata.Provider
+	cfg      analytics.MarketConfig
+	detector *ai.RegimeDetector
+}
+
+// newMarketAnalyzer analyzes interval of provider's timeframes by default,
+// classifying regimes with cfg's model or the built-in rules
+func newMarketAnalyzer(provider *marketdata.Provider, cfg analytics.MarketConfig, interval string) (*marketAnalyzer, error) {
+	if cfg.Interval == "" {
+		cfg.Interval = interval
+	}
+	detector := ai.NewRegimeDetector(nil)
+	if cfg.RegimeModel != "" {
+		var err error
+		if detector, err = ai.LoadRegimeDetector(cfg.RegimeModel); err != nil {
+			return nil, fmt.Errorf("market analytics: %w", err)
+		}
+	}
+	return &marketAnalyzer{provider: provider, cfg: cfg, detector: detector}, nil
+}
+
+// Analyze returns the analytics of symbols. Symbols whose candles cannot be
+// had are left out of them and named in the error.
+func (m *marketAnalyzer) Analyze(ctx context.Context, symbols []string) (analytics.MarketAnalytics, error) {
+	candles := make(map[string][]types.Candle, len(symbols))
+	var errs []error
+	for _, symbol := range symbols {
+		mc, err := m.provider.Context(ctx, symbol)
+		if tf := mc.Timeframe(m.cfg.Interval); tf != nil && len(tf.Candles) > 0 {
+			candles[symbol] = tf.Candles
+			continue
+		}
+		if err == nil {
+			err = fmt.Errorf("%s %s: no candles", symbol, m.cfg.Interval)
+		}
+		errs = append(errs, err)
+	}
+	classify := func(series []types.Candle) string {
+		return m.detector.ClassifyMarket(types.MarketData{Candles: series}).String()
+	}
+	return analytics.AnalyzeMarket(candles, m.cfg.Interval, m.cfg.Window, classify), errors.Join(errs...)
+}
+
+// splitSymbols parses a comma-separated symbol list
+func splitSymbols(s string) []string {
+	var symbols []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.ToUpper(strings.TrimSpace(part)); part != "" {
+			symbols = append(symbols, part)
+		}
+	}
+	return symbols
+}
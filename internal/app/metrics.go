@@ -0,0 +1,151 @@
+package app
+
+import (
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/ratelimit"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/metrics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// botCollector exports a bot's strategy, portfolio, risk and exchange state
+// to the container's registry on every scrape
+func botCollector(c *Container, spec BotSpec, strat strategy.Strategy, watchdog *Watchdog) func(*metrics.Registry) {
+	cfg, log := c.Config(), c.Logger()
+	bot, exchange := cfg.App.Name, cfg.Exchange.Name
+	if exchange == "" {
+		exchange = "binance"
+	}
+
+	return func(reg *metrics.Registry) {
+		set := func(name string, value float64, labels ...string) {
+			if err := reg.Set(name, value, labels...); err != nil {
+				log.Error("Failed to export metric: %v", err)
+			}
+		}
+
+		m := c.StrategyMetrics(strat)
+		labels := []string{bot, exchange, spec.ID, spec.Symbol}
+		set(metrics.StrategyTrades, float64(m.TotalTrades), labels...)
+		set(metrics.StrategyWinningTrades, float64(m.WinningTrades), labels...)
+		set(metrics.StrategyPnL, m.TotalProfit-m.TotalLoss, labels...)
+		set(metrics.StrategyWinRate, m.WinRate, labels...)
+		set(metrics.StrategyVolume, m.TotalVolume, labels...)
+		set(metrics.StrategyDrawdown, m.MaxDrawdown, labels...)
+		set(metrics.StrategySharpe, m.SharpeRatio, labels...)
+		set(metrics.StrategySortino, m.SortinoRatio, labels...)
+		set(metrics.StrategyCalmar, m.CalmarRatio, labels...)
+		for _, loop := range watchdog.Status(time.Now()) {
+			if loop.Loop == spec.ID {
+				set(metrics.StrategyLoopStalled, boolValue(loop.Stalled), labels...)
+				set(metrics.StrategyHeartbeatAge, loop.Age, labels...)
+			}
+		}
+
+		portfolio := c.PortfolioManager()
+		snapshot := portfolio.GetPortfolio()
+		set(metrics.PortfolioEquity, portfolio.Equity(), bot, exchange)
+		set(metrics.PortfolioValue, snapshot.TotalValue, bot, exchange)
+		set(metrics.PortfolioUnrealized, snapshot.NetProfit, bot, exchange)
+		set(metrics.PortfolioPositions, float64(len(portfolio.GetAllPositions())), bot, exchange)
+		for _, account := range c.Accounts() {
+			set(metrics.AccountEquity, account.portfolio.Equity(), bot, exchange, account.name)
+			set(metrics.AccountUnrealized, account.portfolio.GetPortfolio().NetProfit, bot, exchange, account.name)
+		}
+
+		if executions := c.Executions(); executions != nil {
+			var fills []analytics.Execution
+			for _, e := range executions.History(time.Time{}, time.Time{}) {
+				if e.Bot == spec.ID && e.Symbol == spec.Symbol {
+					fills = append(fills, e)
+				}
+			}
+			stats := analytics.SummarizeSlippage(fills).Overall
+			set(metrics.ExecutionSlippage, stats.MeanBps, labels...)
+			set(metrics.ExecutionFees, stats.Fees, labels...)
+		}
+		if reporter, ok := strat.(strategy.StatusReporter); ok {
+			status := reporter.GetStatus()
+			if status.DCA != nil && status.DCA.Execution != nil {
+				set(metrics.ExecutionMaker, status.DCA.Execution.MakerRatio, labels...)
+			}
+			set(metrics.StrategyDataMissing, boolValue(dataUnavailable(status)), labels...)
+		}
+
+		if sentiment := c.Sentiment(); sentiment != nil {
+			for _, symbol := range sentiment.History().Symbols() {
+				report, ok := sentiment.Report(symbol, time.Now(), 0)
+				if !ok {
+					continue
+				}
+				set(metrics.SentimentScore, report.Sentiment, bot, exchange, symbol)
+				set(metrics.SentimentExtreme, extremeValue(report.Extreme), bot, exchange, symbol)
+			}
+		}
+
+		available, _ := c.Maintenance().Available()
+		set(metrics.ExchangeAvailable, boolValue(available), bot, exchange)
+
+		if deleverager := c.Deleverager(); deleverager != nil {
+			status := deleverager.Status()
+			set(metrics.DeleverageDrawdown, status["drawdown"].(float64), bot, exchange)
+			set(metrics.DeleverageScale, status["scale"].(float64), bot, exchange)
+			set(metrics.OrdersRejected, float64(status["rejected_orders"].(int)), bot, exchange, "deleverage")
+		}
+		if cal := c.Calendar(); cal != nil {
+			status := cal.Status()
+			set(metrics.CalendarBlackout, float64(len(status["active"].([]map[string]interface{}))), bot, exchange)
+			set(metrics.OrdersRejected, float64(status["rejected_orders"].(int)), bot, exchange, "calendar")
+		}
+		if guard := c.Guard(); guard != nil {
+			status := guard.Status()
+			set(metrics.GuardTripped, boolValue(status["tripped"].(bool)), bot, exchange)
+			set(metrics.GuardTrips, float64(status["trips"].(int)), bot, exchange)
+			set(metrics.OrdersRejected, float64(status["rejected_orders"].(int)), bot, exchange, "guard")
+		}
+		if budget := c.RateBudget(); budget != nil {
+			status := budget.Status()
+			set(metrics.RateLimitSaturation, status.Saturation, bot, exchange)
+			for _, priority := range []ratelimit.Priority{ratelimit.PriorityHigh, ratelimit.PriorityNormal, ratelimit.PriorityLow} {
+				set(metrics.RateLimitQueued, float64(status.Queued[priority.String()]), bot, exchange, priority.String())
+			}
+		}
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// extremeValue is 1 for high sentiment, -1 for low and 0 otherwise
+func extremeValue(extreme string) float64 {
+	switch extreme {
+	case ai.SentimentHigh:
+		return 1
+	case ai.SentimentLow:
+		return -1
+	}
+	return 0
+}
+
+// dataUnavailable reports whether the strategy, or any sub-strategy of a
+// combo, holds for lack of candles
+func dataUnavailable(status types.StrategyStatus) bool {
+	if status.Data != nil && status.Data.State == types.DataUnavailable {
+		return true
+	}
+	if status.Combo != nil {
+		for _, sub := range status.Combo.Strategies {
+			if dataUnavailable(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Observer reads a running bot's state without taking part in it: it only
+// reads the state dir and, when an API address is set, issues GET requests.
+// Operators can watch a bot that exposes no HTTP port this way.
+type Observer struct {
+	store  *StateStore
+	bot    string
+	api    string
+	client *http.Client
+}
+
+// ObservedOrder is a journaled order with its outcome so far
+type ObservedOrder struct {
+	Time        time.Time `json:"time"`
+	Account     string    `json:"account,omitempty"`
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Type        string    `json:"type"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"`
+	Status      string    `json:"status"` // pending, placed, failed or filled
+	FilledPrice float64   `json:"filled_price,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Observation is one look at a bot
+type Observation struct {
+	Bot       string                  `json:"bot"`
+	UpdatedAt time.Time               `json:"updated_at"` // of the state snapshot
+	Metrics   types.StrategyMetrics   `json:"metrics"`
+	Status    *types.StrategyStatus   `json:"status,omitempty"`
+	Equity    []analytics.EquityPoint `json:"equity,omitempty"`
+	Orders    []ObservedOrder         `json:"orders,omitempty"` // newest last
+
+	// Live is the API's GET /metrics response; APIError is set instead
+	// when the API was asked but did not answer
+	Live     map[string]interface{} `json:"live,omitempty"`
+	APIError string                 `json:"api_error,omitempty"`
+}
+
+// NewObserver watches bot's state in dir. An empty bot selects the only bot
+// with a snapshot in dir. Unlike NewStateStore it never creates dir.
+func NewObserver(dir, bot string) (*Observer, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("state dir %s: %w", dir, err)
+	}
+	if bot == "" {
+		matches, err := filepath.Glob(filepath.Join(dir, "*-state.json"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) != 1 {
+			return nil, fmt.Errorf("found %d bot snapshot(s) in %s, select one with a bot id", len(matches), dir)
+		}
+		bot = strings.TrimSuffix(filepath.Base(matches[0]), "-state.json")
+	}
+	return &Observer{store: &StateStore{dir: dir}, bot: bot, client: &http.Client{Timeout: 5 * time.Second}}, nil
+}
+
+// Bot returns the observed bot id
+func (o *Observer) Bot() string {
+	return o.bot
+}
+
+// SetAPI additionally reads live state from the bot's HTTP API at url
+func (o *Observer) SetAPI(url string) {
+	o.api = strings.TrimRight(url, "/")
+}
+
+// Observe reads the bot's snapshot, the last equity points and the last
+// orders it journaled, at most limit of each
+func (o *Observer) Observe(ctx context.Context, limit int) (*Observation, error) {
+	var snapshot BotSnapshot
+	found, err := o.store.Load(o.bot+"-state", &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no snapshot of bot %s in %s", o.bot, o.store.Dir())
+	}
+	obs := &Observation{Bot: o.bot, UpdatedAt: snapshot.UpdatedAt, Metrics: snapshot.Metrics, Status: snapshot.Status}
+
+	err = o.store.Scan(equityJournal, func(line []byte) error {
+		var point analytics.EquityPoint
+		if json.Unmarshal(line, &point) == nil {
+			obs.Equity = append(obs.Equity, point)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(obs.Equity) > limit {
+		obs.Equity = obs.Equity[len(obs.Equity)-limit:]
+	}
+
+	if obs.Orders, err = o.orders(limit); err != nil {
+		return nil, err
+	}
+
+	if o.api != "" {
+		if err := o.get(ctx, "/metrics", &obs.Live); err != nil {
+			obs.APIError = err.Error()
+		}
+	}
+	return obs, nil
+}
+
+// orders replays the journal into the bot's last limit orders. Lines being
+// written while the journal is read are skipped.
+func (o *Observer) orders(limit int) ([]ObservedOrder, error) {
+	var orders []*ObservedOrder
+	byID := make(map[string]*ObservedOrder)
+	err := o.store.Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if json.Unmarshal(line, &entry) != nil || entry.Bot != o.bot || entry.ClientOrderID == "" {
+			return nil
+		}
+		switch entry.Action {
+		case journalSubmit:
+			if entry.Order == nil {
+				return nil
+			}
+			order := &ObservedOrder{
+				Time:     entry.Time,
+				Account:  entry.Account,
+				Symbol:   entry.Order.Symbol,
+				Side:     string(entry.Order.Side),
+				Type:     string(entry.Order.Type),
+				Quantity: entry.Order.Quantity,
+				Price:    entry.Order.Price,
+				Status:   "pending",
+			}
+			orders = append(orders, order)
+			byID[entry.ClientOrderID] = order
+		case journalPlace:
+			if order, ok := byID[entry.ClientOrderID]; ok {
+				order.Status, order.Error = "placed", entry.Error
+				if entry.Error != "" {
+					order.Status = "failed"
+				}
+			}
+		case journalFill:
+			if order, ok := byID[entry.ClientOrderID]; ok && entry.Execution != nil {
+				order.Status, order.FilledPrice = "filled", entry.Execution.FilledPrice
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(orders) > limit {
+		orders = orders[len(orders)-limit:]
+	}
+	result := make([]ObservedOrder, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, *order)
+	}
+	return result, nil
+}
+
+// get decodes the JSON response to a GET of path on the bot's API
+func (o *Observer) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.api+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,233 @@
+package app
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ManualOrderRequest is the body of POST /orders
+type ManualOrderRequest struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`            // BUY or SELL
+	Type     string  `json:"type,omitempty"`  // MARKET (default) or LIMIT
+	Quantity float64 `json:"quantity"`        // base asset quantity
+	Price    float64 `json:"price,omitempty"` // required for LIMIT; MARKET defaults to the last price
+}
+
+// order validates the request and builds the order it describes
+func (req ManualOrderRequest) order() (types.Order, error) {
+	order := types.Order{
+		Symbol:    strings.ToUpper(req.Symbol),
+		Side:      types.OrderSide(strings.ToUpper(req.Side)),
+		Type:      types.OrderType(strings.ToUpper(req.Type)),
+		Quantity:  req.Quantity,
+		Price:     req.Price,
+		Status:    types.OrderStatusNew,
+		Timestamp: time.Now(),
+	}
+	if order.Type == "" {
+		order.Type = types.OrderTypeMarket
+	}
+
+	switch {
+	case order.Symbol == "":
+		return order, fmt.Errorf("symbol is required")
+	case order.Side != types.OrderSideBuy && order.Side != types.OrderSideSell:
+		return order, fmt.Errorf("side must be BUY or SELL")
+	case order.Type != types.OrderTypeMarket && order.Type != types.OrderTypeLimit:
+		return order, fmt.Errorf("type must be MARKET or LIMIT")
+	case order.Quantity <= 0:
+		return order, fmt.Errorf("quantity must be positive")
+	case order.Price < 0 || (order.Type == types.OrderTypeLimit && order.Price == 0):
+		return order, fmt.Errorf("limit orders need a positive price")
+	}
+
+	action := types.DecisionBuy
+	if order.Side == types.OrderSideSell {
+		action = types.DecisionSell
+	}
+	order.Decision = &types.Decision{Strategy: "manual", Action: action}
+	return order, nil
+}
+
+// registerOrderRoutes adds the operator order endpoints. Manual orders take
+// the same path as strategy orders: maintenance pause, risk manager, the
+// running strategy's throttle and the order journal. The endpoints require
+// app.api_token and are disabled without one.
+func registerOrderRoutes(mux *http.ServeMux, c *Container, strat strategy.Strategy) {
+	token := c.Config().App.APIToken
+
+	mux.HandleFunc("GET /orders", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+		if symbol == "" {
+			writeError(w, http.StatusBadRequest, "symbol query parameter is required")
+			return
+		}
+		orders, err := c.Exchange().GetActiveOrders(r.Context(), symbol)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, orders)
+	}))
+
+	// One page of the exchange's filled orders; pass next back as cursor
+	// for the following page
+	mux.HandleFunc("GET /orders/history", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		query := types.OrderHistoryQuery{Symbol: strings.ToUpper(r.URL.Query().Get("symbol")), Cursor: r.URL.Query().Get("cursor")}
+		if query.Symbol == "" {
+			writeError(w, http.StatusBadRequest, "symbol query parameter is required")
+			return
+		}
+		var err error
+		if query.Start, query.End, err = parseTimeRange(r); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if v := r.URL.Query().Get("limit"); v != "" {
+			if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 0 {
+				writeError(w, http.StatusBadRequest, "limit must be a non-negative number")
+				return
+			}
+		}
+		page, err := c.Exchange().GetOrderHistory(r.Context(), query)
+		switch {
+		case errors.Is(err, types.ErrNotSupported):
+			writeError(w, http.StatusNotImplemented, err.Error())
+		case err != nil:
+			writeError(w, http.StatusBadGateway, err.Error())
+		default:
+			writeJSON(w, http.StatusOK, map[string]interface{}{"orders": page.Orders, "next": page.Next})
+		}
+	}))
+
+	mux.HandleFunc("POST /orders", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		var req ManualOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		order, err := req.order()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if available, reason := c.Maintenance().Available(); !available {
+			writeError(w, http.StatusServiceUnavailable, "trading paused: "+reason)
+			return
+		}
+
+		ctx := r.Context()
+		if order.Price == 0 {
+			ticker, err := c.Exchange().GetTicker(ctx, order.Symbol)
+			if err != nil {
+				writeError(w, http.StatusBadGateway, "failed to price order: "+err.Error())
+				return
+			}
+			order.Price = ticker.Price
+		}
+
+		if err := c.RiskManager().ValidateOrder(order, c.PortfolioManager().GetPortfolio()); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "risk check failed: "+err.Error())
+			return
+		}
+
+		exchange := c.Exchange()
+		type throttled interface{ Throttle() *risk.Throttle }
+		if t, ok := strat.(throttled); ok && t.Throttle() != nil {
+			exchange = t.Throttle().Client(exchange)
+		}
+
+		if err := exchange.PlaceOrder(ctx, order); err != nil {
+			status := http.StatusBadGateway
+			switch {
+			case errors.Is(err, risk.ErrThrottled):
+				status = http.StatusTooManyRequests
+			case errors.Is(err, risk.ErrCircuitOpen):
+				status = http.StatusServiceUnavailable
+			case errors.Is(err, risk.ErrRestricted):
+				status = http.StatusForbidden
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+
+		c.Logger().Info("Manual order placed: %s %s %.8f @ %.2f", order.Side, order.Symbol, order.Quantity, order.Price)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"status":   "placed",
+			"symbol":   order.Symbol,
+			"side":     order.Side,
+			"type":     order.Type,
+			"quantity": order.Quantity,
+			"price":    order.Price,
+		})
+	}))
+
+	// Symbol blacklists and trading hours, replaced as a whole
+	mux.HandleFunc("GET /restrictions", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.Restrictions().Config())
+	})
+	mux.HandleFunc("PUT /restrictions", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		var cfg risk.RestrictionsConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := c.Restrictions().Update(cfg); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Logger().Info("Symbol restrictions updated by operator")
+		writeJSON(w, http.StatusOK, c.Restrictions().Config())
+	}))
+
+	mux.HandleFunc("POST /guard/reset", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		guard := c.Guard()
+		if guard == nil {
+			writeError(w, http.StatusNotFound, "order guard is not enabled")
+			return
+		}
+		if tripped, reason := guard.Tripped(); tripped {
+			c.Logger().Warn("Order guard reset by operator (tripped: %s)", reason)
+		}
+		guard.Reset()
+		writeJSON(w, http.StatusOK, guard.Status())
+	}))
+
+	mux.HandleFunc("DELETE /orders/{id}", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if err := c.Exchange().CancelOrder(r.Context(), id); err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		c.Logger().Info("Manual cancel of order %s", id)
+		writeJSON(w, http.StatusOK, map[string]string{"status": "canceled", "order_id": id})
+	}))
+}
+
+// authorized requires "Authorization: Bearer <token>"; an empty token disables the handler
+func authorized(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeError(w, http.StatusForbidden, "order API disabled: set app.api_token")
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
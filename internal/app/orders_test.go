@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestRouter_ManualOrders(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestContainer(t, dir)
+	c.config.App.APIToken = "secret"
+
+	dca := strategy.NewDCAStrategy(types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 5, Enabled: true}, c.Exchange(), c.Logger())
+	dca.SetThrottle(risk.NewThrottle(types.ThrottleConfig{MaxPerHour: 1}))
+	router := newRouter(c, dca, &probeState{})
+
+	do := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	buy := `{"symbol": "btcusdt", "side": "buy", "quantity": 0.001}`
+	if code := do(http.MethodPost, "/orders", "", buy); code != http.StatusUnauthorized {
+		t.Errorf("POST /orders without token = %d, want 401", code)
+	}
+	if code := do(http.MethodPost, "/orders", "secret", `{"symbol": "BTCUSDT", "side": "HOLD", "quantity": 1}`); code != http.StatusBadRequest {
+		t.Errorf("POST /orders with bad side = %d, want 400", code)
+	}
+	if code := do(http.MethodPost, "/orders", "secret", buy); code != http.StatusCreated {
+		t.Fatalf("POST /orders = %d, want 201", code)
+	}
+	// The strategy's throttle applies to manual orders too
+	if code := do(http.MethodPost, "/orders", "secret", buy); code != http.StatusTooManyRequests {
+		t.Errorf("second POST /orders = %d, want 429", code)
+	}
+	if code := do(http.MethodDelete, "/orders/42", "secret", ""); code != http.StatusOK {
+		t.Errorf("DELETE /orders/42 = %d, want 200", code)
+	}
+
+	// Manual orders are journaled with their decision
+	var submitted *types.Order
+	err := c.StateStore().Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		if entry.Action == journalSubmit {
+			submitted = entry.Order
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submitted == nil || submitted.Decision == nil || submitted.Decision.Strategy != "manual" || submitted.Price <= 0 {
+		t.Fatalf("Unexpected journaled order %+v", submitted)
+	}
+
+	// Without a configured token the endpoints are disabled
+	c.config.App.APIToken = ""
+	router = newRouter(c, dca, &probeState{})
+	if code := do(http.MethodPost, "/orders", "secret", buy); code != http.StatusForbidden {
+		t.Errorf("POST /orders without api_token = %d, want 403", code)
+	}
+}
+
+func TestRouter_GuardTripsAndResets(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{Name: "test", ReportingCurrency: "USD", APIToken: "secret"},
+		Logging: config.LoggingConfig{Level: "error"},
+		Guard:   risk.GuardConfig{MaxOrdersPerMinute: 1},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dca := strategy.NewDCAStrategy(types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 5, Enabled: true}, c.Exchange(), c.Logger())
+	router := newRouter(c, dca, &probeState{})
+
+	do := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	buy := `{"symbol": "BTCUSDT", "side": "BUY", "quantity": 0.001}`
+	if code := do(http.MethodPost, "/orders", buy); code != http.StatusCreated {
+		t.Fatalf("POST /orders = %d, want 201", code)
+	}
+	if code := do(http.MethodPost, "/orders", buy); code != http.StatusServiceUnavailable {
+		t.Fatalf("second POST /orders within a minute = %d, want 503", code)
+	}
+	if tripped, _ := c.Guard().Tripped(); !tripped {
+		t.Fatal("expected the guard to trip")
+	}
+
+	if code := do(http.MethodPost, "/guard/reset", ""); code != http.StatusOK {
+		t.Fatalf("POST /guard/reset = %d, want 200", code)
+	}
+	if code := do(http.MethodPost, "/orders", buy); code != http.StatusCreated {
+		t.Errorf("POST /orders after reset = %d, want 201", code)
+	}
+}
+
+func TestRouter_Restrictions(t *testing.T) {
+	cfg := &config.Config{
+		App:          config.AppConfig{Name: "test", ReportingCurrency: "USD", APIToken: "secret"},
+		Logging:      config.LoggingConfig{Level: "error"},
+		Restrictions: risk.RestrictionsConfig{SymbolRules: risk.SymbolRules{Blacklist: []string{"BTCUSDT"}}},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dca := strategy.NewDCAStrategy(types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 5, Enabled: true}, c.Exchange(), c.Logger())
+	router := newRouter(c, dca, &probeState{})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	buy := `{"symbol": "BTCUSDT", "side": "BUY", "quantity": 0.001}`
+	if rec := do(http.MethodPost, "/orders", buy); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST /orders of a blacklisted symbol = %d, want 403", rec.Code)
+	}
+
+	if rec := do(http.MethodPut, "/v1/restrictions", `{"hours": [{"start": "9am", "end": "17:00"}]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT /restrictions with invalid hours = %d, want 400", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/v1/restrictions", `{"blacklist": ["ETHUSDT"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT /restrictions = %d: %s", rec.Code, rec.Body)
+	}
+	rec := do(http.MethodGet, "/restrictions", "")
+	var got risk.RestrictionsConfig
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || len(got.Blacklist) != 1 || got.Blacklist[0] != "ETHUSDT" {
+		t.Fatalf("GET /restrictions = %s (%v)", rec.Body, err)
+	}
+	if rec := do(http.MethodPost, "/orders", buy); rec.Code != http.StatusCreated {
+		t.Errorf("POST /orders after the blacklist changed = %d, want 201", rec.Code)
+	}
+}
+
+func TestRouter_OperatorPause(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{Name: "test", ReportingCurrency: "USD", APIToken: "secret"},
+		Logging: config.LoggingConfig{Level: "error"},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newRouter(c, nil, &probeState{})
+
+	do := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do(http.MethodPost, "/trading/pause?reason=ssh", ""); code != http.StatusOK {
+		t.Fatalf("POST /trading/pause = %d, want 200", code)
+	}
+	if available, reason := c.Maintenance().Available(); available || !strings.Contains(reason, "ssh") {
+		t.Errorf("available = %v (%s), want paused by the operator", available, reason)
+	}
+	buy := `{"symbol": "BTCUSDT", "side": "BUY", "quantity": 0.001}`
+	if code := do(http.MethodPost, "/orders", buy); code != http.StatusServiceUnavailable {
+		t.Errorf("POST /orders while paused = %d, want 503", code)
+	}
+	if code := do(http.MethodPost, "/trading/resume", ""); code != http.StatusOK {
+		t.Fatalf("POST /trading/resume = %d, want 200", code)
+	}
+	if code := do(http.MethodGet, "/ticker?symbol=btcusdt", ""); code != http.StatusOK {
+		t.Errorf("GET /ticker = %d, want 200", code)
+	}
+	if code := do(http.MethodPost, "/orders", buy); code != http.StatusCreated {
+		t.Errorf("POST /orders after resume = %d, want 201", code)
+	}
+}
+
+func TestRouter_OrderHistory(t *testing.T) {
+	c := newTestContainer(t, t.TempDir())
+	c.config.App.APIToken = "secret"
+	router := newRouter(c, nil, &probeState{})
+	for i := 0; i < 3; i++ {
+		order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.001}
+		if err := c.Exchange().PlaceOrder(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(path string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	if code, _ := get("/orders/history"); code != http.StatusBadRequest {
+		t.Errorf("GET /orders/history without symbol = %d, want 400", code)
+	}
+	code, page := get("/orders/history?symbol=BTCUSDT&limit=2")
+	if code != http.StatusOK || len(page["orders"].([]interface{})) != 2 || page["next"] == "" {
+		t.Fatalf("first page = %d %v, want 2 orders and a cursor", code, page)
+	}
+	code, page = get("/orders/history?symbol=BTCUSDT&limit=2&cursor=" + page["next"].(string))
+	if code != http.StatusOK || len(page["orders"].([]interface{})) != 1 || page["next"] != "" {
+		t.Fatalf("last page = %d %v, want the third order", code, page)
+	}
+}
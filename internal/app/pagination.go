@@ -0,0 +1,110 @@
+package app
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// List endpoints return at most ?limit= items, defaultPageLimit by default,
+// continuing from ?cursor=, the next of the previous page. Cursors are keys,
+// the time and id of the last item served, as with the exchanges' order
+// history cursors: items added to or dropped from a list between requests
+// do not shift the pages that follow.
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// page is the window of a list a request asked for
+type page struct {
+	limit  int
+	cursor *pageKey // last item of the previous page; nil on the first
+}
+
+// pageKey orders list items by time, then id
+type pageKey struct {
+	time time.Time
+	id   string
+}
+
+func (k pageKey) less(other pageKey) bool {
+	if !k.time.Equal(other.time) {
+		return k.time.Before(other.time)
+	}
+	return k.id < other.id
+}
+
+// String encodes the key as a cursor; keys of lists ordered by id alone
+// leave the time out
+func (k pageKey) String() string {
+	nanos := ""
+	if !k.time.IsZero() {
+		nanos = strconv.FormatInt(k.time.UnixNano(), 10)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(nanos + ":" + k.id))
+}
+
+// parsePage reads the limit and cursor query parameters
+func parsePage(r *http.Request) (page, error) {
+	p := page{limit: defaultPageLimit}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return p, fmt.Errorf("limit must be a positive number")
+		}
+		p.limit = min(n, maxPageLimit)
+	}
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(v)
+		nanos, id, ok := strings.Cut(string(raw), ":")
+		if err != nil || !ok {
+			return p, fmt.Errorf("invalid cursor %q", v)
+		}
+		p.cursor = &pageKey{id: id}
+		if nanos != "" {
+			n, err := strconv.ParseInt(nanos, 10, 64)
+			if err != nil {
+				return p, fmt.Errorf("invalid cursor %q", v)
+			}
+			p.cursor.time = time.Unix(0, n)
+		}
+	}
+	return p, nil
+}
+
+// pageOf sorts items by key and returns the page after the cursor and the
+// cursor of the following page, "" after the last
+func pageOf[T any](p page, items []T, key func(T) pageKey) ([]T, string) {
+	sort.SliceStable(items, func(i, j int) bool { return key(items[i]).less(key(items[j])) })
+	from := 0
+	if p.cursor != nil {
+		from = sort.Search(len(items), func(i int) bool { return p.cursor.less(key(items[i])) })
+	}
+	to := min(from+p.limit, len(items))
+	next := ""
+	if to < len(items) {
+		next = key(items[to-1]).String()
+	}
+	return items[from:to], next
+}
+
+// pageBackOf is pageOf for lists served newest first: it returns the page
+// before the cursor, oldest first, and the cursor of the page before it
+func pageBackOf[T any](p page, items []T, key func(T) pageKey) ([]T, string) {
+	sort.SliceStable(items, func(i, j int) bool { return key(items[i]).less(key(items[j])) })
+	to := len(items)
+	if p.cursor != nil {
+		to = sort.Search(len(items), func(i int) bool { return !key(items[i]).less(*p.cursor) })
+	}
+	from := max(to-p.limit, 0)
+	next := ""
+	if from > 0 {
+		next = key(items[from]).String()
+	}
+	return items[from:to], next
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// paperHistory is how many filled orders the paper exchange remembers, as
+// many as Binance returns from its order history
+const paperHistory = 1000
+
+// PaperExchange is the demo exchange used by the bots instead of a live venue
+type PaperExchange struct {
+	logger    *logger.Logger
+	basePrice float64
+	swing     float64 // price oscillates within basePrice ± swing
+
+	mu     sync.Mutex
+	nextID int
+	orders []types.Order // filled orders, oldest first
+}
+
+// NewPaperExchange creates a paper exchange oscillating around 45000
+func NewPaperExchange(log *logger.Logger, swing float64) *PaperExchange {
+	return &PaperExchange{
+		logger:    log,
+		basePrice: 45000.0,
+		swing:     swing,
+	}
+}
+
+// SetPriceSwing adjusts the simulated price oscillation
+func (p *PaperExchange) SetPriceSwing(swing float64) {
+	p.swing = swing
+}
+
+func (p *PaperExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	p.logger.Info("Paper: order placed %s %s %.8f @ %.2f", order.Side, order.Symbol, order.Quantity, order.Price)
+
+	// Simulate successful execution
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	order.ID = fmt.Sprintf("paper-%d", p.nextID)
+	order.Status = types.OrderStatusFilled
+	order.FilledAmount = order.Quantity
+	order.FilledPrice = order.Price
+	order.Timestamp = time.Now()
+
+	p.orders = append(p.orders, order)
+	if len(p.orders) > paperHistory {
+		p.orders = p.orders[len(p.orders)-paperHistory:]
+	}
+	return nil
+}
+
+func (p *PaperExchange) CancelOrder(ctx context.Context, orderID string) error {
+	p.logger.Info("Paper: order canceled %s", orderID)
+	return nil
+}
+
+func (p *PaperExchange) GetOrder(ctx context.Context, orderID string) (*types.Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, order := range p.orders {
+		if order.ID == orderID {
+			return &order, nil
+		}
+	}
+	return nil, fmt.Errorf("paper: unknown order %s", orderID)
+}
+
+func (p *PaperExchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return types.OrderByClientID(p.orders, clientOrderID)
+}
+
+func (p *PaperExchange) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return nil, nil
+}
+
+// GetFilledOrders returns the last filled orders for symbol, oldest first
+func (p *PaperExchange) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var filled []types.Order
+	for _, order := range p.orders {
+		if order.Symbol == symbol {
+			filled = append(filled, order)
+		}
+	}
+	return filled, nil
+}
+
+// GetOrderHistory pages the kept order history
+func (p *PaperExchange) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return types.PageOrders(p.orders, query)
+}
+
+func (p *PaperExchange) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	// Simple oscillation within ± swing
+	price := p.basePrice
+	if p.swing > 0 {
+		period := int64(2 * p.swing)
+		price += float64(time.Now().Unix()%period) - p.swing
+	}
+
+	return &types.Ticker{
+		Symbol:    symbol,
+		Price:     price,
+		Bid:       price - 0.1,
+		Ask:       price + 0.1,
+		Volume:    1000.0,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+func (p *PaperExchange) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	return types.FetchTickers(ctx, symbols, p.GetTicker)
+}
+
+func (p *PaperExchange) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *PaperExchange) GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *PaperExchange) GetBalance(ctx context.Context) (*types.Balance, error) {
+	return &types.Balance{
+		Asset:     "USDT",
+		Free:      10000.0,
+		Locked:    0.0,
+		Total:     10000.0,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// GetBalances returns the paper USDT balance; the paper exchange holds no coins
+func (p *PaperExchange) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	balance, err := p.GetBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []types.Balance{*balance}, nil
+}
+
+func (p *PaperExchange) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
+	return &types.TradingFees{
+		Symbol:    symbol,
+		MakerFee:  0.001,
+		TakerFee:  0.001,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// GetFundingRate is not supported: the paper exchange is spot only
+func (p *PaperExchange) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	return nil, types.ErrNotSupported
+}
+
+// GetSymbolRules is not supported: the paper exchange fills any size
+func (p *PaperExchange) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	return nil, types.ErrNotSupported
+}
+
+// ConvertDust is not supported: the paper exchange leaves no dust
+func (p *PaperExchange) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	return nil, types.ErrNotSupported
+}
+
+// GetBorrowRates is not supported: the paper exchange has no margin
+func (p *PaperExchange) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	return nil, types.ErrNotSupported
+}
+
+// GetSavings is not supported: the paper balance is fixed
+func (p *PaperExchange) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	return nil, types.ErrNotSupported
+}
+
+// SubscribeSavings is not supported: the paper balance is fixed
+func (p *PaperExchange) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+// RedeemSavings is not supported: the paper balance is fixed
+func (p *PaperExchange) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+// GetSystemStatus always reports normal operation
+func (p *PaperExchange) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	return &types.SystemStatus{State: types.SystemNormal, Timestamp: time.Now()}, nil
+}
+
+func (p *PaperExchange) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (p *PaperExchange) Close() error {
+	return nil
+}
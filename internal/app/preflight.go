@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// botMarkets returns the markets strat trades, or symbol on the main
+// exchange for strategies that do not list theirs
+func botMarkets(c *Container, strat strategy.Strategy, symbol string) []strategy.Market {
+	if lister, ok := strat.(strategy.MarketLister); ok {
+		return lister.Markets()
+	}
+	if symbol == "" {
+		return nil
+	}
+	return []strategy.Market{{Symbol: symbol, Exchange: c.Exchange()}}
+}
+
+// preflight checks that every market is listed and open for trading and
+// that its current price is inside the strategy's price range. A misspelled
+// symbol or a grid placed away from the market would otherwise run without
+// ever placing an order. All problems are reported together.
+func preflight(ctx context.Context, exchange string, markets []strategy.Market) error {
+	var problems []string
+	for _, m := range markets {
+		rules, err := m.Exchange.GetSymbolRules(ctx, m.Symbol)
+		switch {
+		case errors.Is(err, types.ErrUnknownSymbol):
+			problems = append(problems, fmt.Sprintf("%s is not listed on %s: check the spelling and quote asset, e.g. BTCUSDT", m.Symbol, exchange))
+			continue
+		case errors.Is(err, types.ErrNotSupported):
+			// No published rules; the ticker below still proves the symbol exists
+		case err != nil:
+			return fmt.Errorf("preflight: failed to get %s rules: %w", m.Symbol, err)
+		case rules.Halted:
+			problems = append(problems, fmt.Sprintf("%s is listed on %s but not open for trading", m.Symbol, exchange))
+			continue
+		}
+
+		ticker, err := m.Exchange.GetTicker(ctx, m.Symbol)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s has no price on %s: %v", m.Symbol, exchange, err))
+			continue
+		}
+		if m.Upper > 0 && (ticker.Price < m.Lower || ticker.Price > m.Upper) {
+			problems = append(problems, fmt.Sprintf("%s price %.8g is outside the range %.8g-%.8g: move lower_price/upper_price around the market",
+				m.Symbol, ticker.Price, m.Lower, m.Upper))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("preflight failed (set app.skip_preflight to start anyway):\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// listingClient lists BTCUSDT, halts ETHUSDT and knows nothing else
+type listingClient struct {
+	*mock.MockClient
+}
+
+func (c listingClient) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	switch symbol {
+	case "BTCUSDT":
+		return &types.SymbolRules{Symbol: symbol}, nil
+	case "ETHUSDT":
+		return &types.SymbolRules{Symbol: symbol, Halted: true}, nil
+	}
+	return nil, types.ErrUnknownSymbol
+}
+
+func TestPreflight(t *testing.T) {
+	client := listingClient{mock.NewMockClient()} // BTC at 45000
+	ctx := context.Background()
+
+	ok := []strategy.Market{
+		{Symbol: "BTCUSDT", Exchange: client},
+		{Symbol: "BTCUSDT", Exchange: client, Lower: 40000, Upper: 50000},
+	}
+	if err := preflight(ctx, "binance", ok); err != nil {
+		t.Fatalf("preflight() error = %v, want none", err)
+	}
+
+	err := preflight(ctx, "binance", []strategy.Market{
+		{Symbol: "BTCUSDTT", Exchange: client},
+		{Symbol: "ETHUSDT", Exchange: client},
+		{Symbol: "BTCUSDT", Exchange: client, Lower: 60000, Upper: 70000},
+	})
+	if err == nil {
+		t.Fatal("preflight() passed unknown, halted and out-of-range markets")
+	}
+	for _, want := range []string{"BTCUSDTT is not listed on binance", "ETHUSDT is listed on binance but not open", "outside the range 60000-70000"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("preflight() error = %v, want %q", err, want)
+		}
+	}
+
+	// Exchanges without symbol rules are checked by their ticker alone
+	if err := preflight(ctx, "mock", []strategy.Market{{Symbol: "BTCUSDT", Exchange: mock.NewMockClient()}}); err != nil {
+		t.Errorf("preflight() without rules error = %v", err)
+	}
+	if err := preflight(ctx, "binance", []strategy.Market{{Symbol: "BTCUSDT", Exchange: failingRules{client}}}); err == nil || errors.Is(err, types.ErrUnknownSymbol) {
+		t.Errorf("preflight() with an unreachable exchange error = %v, want the request error", err)
+	}
+}
+
+type failingRules struct{ listingClient }
+
+func (failingRules) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	return nil, errors.New("connection refused")
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// journaledOrder is a submission read back from the order journal
+type journaledOrder struct {
+	order    types.Order
+	account  string // sub-account the order was placed on; empty for the main account
+	answered bool   // a place entry followed the submission
+	failed   bool   // the exchange rejected the submission
+	filled   bool   // a fill entry attributed the order's execution
+}
+
+// recoverStrategy replays bot's order journal into a Recoverable strategy.
+// Each submission is reconciled against the exchange's complete order history: orders
+// found there use the reported fill, acknowledged market orders missing from
+// history count as filled as journaled, and submissions interrupted by a
+// crash or rejected by the exchange are dropped. A dropped interrupted
+// submission is checked for again when its decision is retried.
+func recoverStrategy(ctx context.Context, store *StateStore, client types.ExchangeClient, bot, symbol string, strat strategy.Strategy, log *logger.Logger) error {
+	recoverable, ok := strat.(strategy.Recoverable)
+	if store == nil || !ok {
+		return nil
+	}
+
+	submissions, err := readSubmissions(store, bot, symbol, log)
+	if err != nil || len(submissions) == 0 {
+		return err
+	}
+
+	history, err := types.FilledOrders(ctx, client, types.OrderHistoryQuery{Symbol: symbol})
+	if err != nil && !errors.Is(err, types.ErrNotSupported) {
+		return fmt.Errorf("failed to get order history: %w", err)
+	}
+	filled := make(map[string]types.Order, len(history))
+	for _, order := range history {
+		if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID != "" {
+			filled[order.ExchangeOrder.ClientOrderID] = order
+		}
+	}
+
+	fills := make([]types.Order, 0, len(submissions))
+	var interrupted int
+	for _, s := range submissions {
+		order := s.order
+		if actual, ok := filled[order.ExchangeOrder.ClientOrderID]; ok {
+			// The exchange took an order interrupted by a crash; answering it
+			// keeps its client order id from being given to the next order
+			if !s.answered {
+				entry := JournalEntry{Time: time.Now(), Bot: bot, Account: s.account, StrategyID: decisionStrategyID(order),
+					Action: journalPlace, OrderID: actual.ID, ClientOrderID: order.ExchangeOrder.ClientOrderID}
+				if err := store.Append(journalName, entry); err != nil {
+					return fmt.Errorf("failed to journal recovered order: %w", err)
+				}
+			}
+			order.ID = actual.ID
+			order.Status = types.OrderStatusFilled
+			order.FilledAmount = actual.FilledAmount
+			order.FilledPrice = actual.FilledPrice
+			if !actual.Timestamp.IsZero() {
+				order.Timestamp = actual.Timestamp
+			}
+			fills = append(fills, order)
+			continue
+		}
+
+		switch {
+		case !s.answered:
+			interrupted++
+		case !s.failed && order.Type == types.OrderTypeMarket:
+			fills = append(fills, order)
+		}
+	}
+	if interrupted > 0 {
+		log.Warn("Recovery: %d order(s) interrupted before the exchange answered were not filled", interrupted)
+	}
+
+	log.Info("Recovering %s from %d journaled fill(s)", bot, len(fills))
+	if err := recoverable.Recover(fills); err != nil {
+		return fmt.Errorf("failed to recover strategy state: %w", err)
+	}
+	return nil
+}
+
+// readSubmissions returns bot's journaled submissions for symbol in order.
+// Undecodable lines, e.g. one torn by a crash mid-write, are skipped.
+func readSubmissions(store *StateStore, bot, symbol string, log *logger.Logger) ([]*journaledOrder, error) {
+	var submissions []*journaledOrder
+	byID := make(map[string]*journaledOrder)
+
+	err := store.Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			log.Warn("Recovery: skipping unreadable journal line: %v", err)
+			return nil
+		}
+		if entry.Bot != bot || entry.ClientOrderID == "" {
+			return nil
+		}
+
+		switch entry.Action {
+		case journalSubmit:
+			if entry.Order == nil || entry.Order.Symbol != symbol {
+				return nil
+			}
+			s := &journaledOrder{order: *entry.Order, account: entry.Account}
+			submissions = append(submissions, s)
+			byID[entry.ClientOrderID] = s
+		case journalPlace:
+			if s, ok := byID[entry.ClientOrderID]; ok {
+				s.answered = true
+				s.failed = entry.Error != ""
+			}
+		case journalFill:
+			if s, ok := byID[entry.ClientOrderID]; ok {
+				s.filled = true
+			}
+		}
+		return nil
+	})
+	return submissions, err
+}
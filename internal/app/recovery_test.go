@@ -0,0 +1,317 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// historyExchange is a paper exchange with scripted order history and rejections
+type historyExchange struct {
+	*PaperExchange
+	history []types.Order
+	reject  bool
+}
+
+func (h *historyExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	if h.reject {
+		return errors.New("exchange unavailable")
+	}
+	return h.PaperExchange.PlaceOrder(ctx, order)
+}
+
+func (h *historyExchange) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return h.history, nil
+}
+
+func (h *historyExchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	if order, err := types.OrderByClientID(h.history, clientOrderID); err == nil {
+		return order, nil
+	}
+	return h.PaperExchange.GetOrderByClientID(ctx, symbol, clientOrderID)
+}
+
+func (h *historyExchange) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	return types.PageOrders(h.history, query)
+}
+
+var recoveryGrid = types.GridConfig{
+	Symbol:             "BTCUSDT",
+	LowerPrice:         100,
+	UpperPrice:         120,
+	GridLevels:         3,
+	InvestmentPerLevel: 100,
+	Enabled:            true,
+}
+
+func newRecoveryGrid(t *testing.T, exchange types.ExchangeClient) *strategy.GridStrategy {
+	t.Helper()
+	grid, err := strategy.NewGridStrategy(recoveryGrid, exchange, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return grid
+}
+
+func TestRecoverGridFromJournal(t *testing.T) {
+	log := logger.New(logger.LevelError)
+	store, err := NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	exchange := &historyExchange{PaperExchange: NewPaperExchange(log, 0)}
+	journal := newJournalClient(exchange, store, log)
+	journal.setBot("grid")
+
+	// First run: buy levels 110 and 120, sell 110 on the way up, then crash
+	grid := newRecoveryGrid(t, journal)
+	for _, price := range []float64{109, 121} {
+		if err := grid.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: price}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := grid.GetStatus().Grid.LevelsHeld
+
+	// An order rejected by the exchange and one cut off by the crash are not fills
+	exchange.reject = true
+	_ = journal.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 1, Price: 100,
+		Decision: &types.Decision{Strategy: "grid", Action: types.DecisionBuy, Level: 100}})
+	interrupted := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 1, Price: 100,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "in-flight"},
+		Decision:      &types.Decision{Strategy: "grid", Action: types.DecisionBuy, Level: 100}}
+	if err := store.Append(journalName, JournalEntry{Time: time.Now(), Bot: "grid", Action: journalSubmit, Order: &interrupted, ClientOrderID: "in-flight"}); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := newRecoveryGrid(t, exchange)
+	if err := recoverStrategy(ctx, store, exchange, "grid", "BTCUSDT", restarted, log); err != nil {
+		t.Fatalf("recoverStrategy: %v", err)
+	}
+	if got := restarted.GetStatus().Grid.LevelsHeld; got != before || got != 1 {
+		t.Fatalf("levels_held after recovery = %v, want %v", got, before)
+	}
+	if got, want := restarted.GetMetrics().TotalTrades, grid.GetMetrics().TotalTrades; got != want {
+		t.Fatalf("recovered %d trades, want %d", got, want)
+	}
+
+	// The in-flight order did fill on the exchange: history wins over the journal
+	exchange.history = []types.Order{{
+		Symbol: "BTCUSDT", Side: types.OrderSideBuy, Status: types.OrderStatusFilled, FilledAmount: 1, FilledPrice: 99,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "in-flight"},
+	}}
+	restarted = newRecoveryGrid(t, exchange)
+	if err := recoverStrategy(ctx, store, exchange, "grid", "BTCUSDT", restarted, log); err != nil {
+		t.Fatalf("recoverStrategy: %v", err)
+	}
+	if got := restarted.GetStatus().Grid.LevelsHeld; got != 2 {
+		t.Fatalf("levels_held with the in-flight fill = %v, want 2", got)
+	}
+}
+
+func TestRecoverDCASchedule(t *testing.T) {
+	log := logger.New(logger.LevelError)
+	store, err := NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	exchange := &historyExchange{PaperExchange: NewPaperExchange(log, 0)}
+	journal := newJournalClient(exchange, store, log)
+	journal.setBot("dca")
+
+	cfg := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 10, Enabled: true}
+	dca := strategy.NewDCAStrategy(cfg, journal, log)
+	if err := dca.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 45000, Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Orders journaled by another bot are not replayed
+	journal.setBot("grid")
+	_ = journal.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 1, Price: 45000,
+		Decision: &types.Decision{Strategy: "dca", Action: types.DecisionBuy}})
+
+	restarted := strategy.NewDCAStrategy(cfg, exchange, log)
+	if err := recoverStrategy(ctx, store, exchange, "dca", "BTCUSDT", restarted, log); err != nil {
+		t.Fatalf("recoverStrategy: %v", err)
+	}
+	if got := restarted.GetStatus().DCA.Orders; got != 1 {
+		t.Fatalf("buy_count = %v, want 1", got)
+	}
+	if signal := restarted.GetSignal(types.MarketData{Symbol: "BTCUSDT", Price: 45000, Timestamp: time.Now()}); signal.Type != types.SignalTypeHold {
+		t.Fatalf("restarted DCA should wait for the interval, got %s", signal.Type)
+	}
+}
+
+func TestExecutionLog_AttributesFills(t *testing.T) {
+	log := logger.New(logger.LevelError)
+	store, err := NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	exchange := &historyExchange{PaperExchange: NewPaperExchange(log, 0)}
+	journal := newJournalClient(exchange, store, log)
+	journal.setBot("dca")
+
+	order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.5, Price: 40000,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "c1"},
+		Decision:      &types.Decision{Strategy: "dca", StrategyID: "dca-btc", Action: types.DecisionBuy}}
+	if err := journal.PlaceOrder(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	executions, err := NewExecutionLog(store, exchange, "binance", log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Not in the exchange's history yet
+	if n, err := executions.Attribute(ctx, "dca", "BTCUSDT"); err != nil || n != 0 {
+		t.Fatalf("Attribute before the fill = %d, %v; want 0", n, err)
+	}
+
+	filledAt := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+	exchange.history = []types.Order{{ID: "42", Symbol: "BTCUSDT", Side: types.OrderSideBuy, Status: types.OrderStatusFilled,
+		FilledAmount: 0.5, FilledPrice: 40020, Timestamp: filledAt, ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "c1"}}}
+	for i, want := range []int{1, 0} { // the second pass finds it attributed
+		if n, err := executions.Attribute(ctx, "dca", "BTCUSDT"); err != nil || n != want {
+			t.Fatalf("Attribute pass %d = %d, %v; want %d", i, n, err, want)
+		}
+	}
+
+	// Fills survive a restart
+	reloaded, err := NewExecutionLog(store, exchange, "binance", log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := reloaded.History(time.Time{}, time.Time{})
+	if len(history) != 1 {
+		t.Fatalf("got %d executions, want 1", len(history))
+	}
+	e := history[0]
+	if e.Strategy != "dca" || e.StrategyID != "dca-btc" || e.Exchange != "binance" || e.OrderID != "42" || !e.Time.Equal(filledAt) {
+		t.Errorf("execution = %+v", e)
+	}
+	if got := e.SlippageBps(); got < 4.99 || got > 5.01 {
+		t.Errorf("slippage = %v bps, want 5", got)
+	}
+	if got, want := e.Fee, 0.5*40020*0.001; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("fee = %v, want %v", got, want)
+	}
+
+	// The journaled fill does not change recovery
+	restarted := strategy.NewDCAStrategy(types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 10, Enabled: true}, exchange, log)
+	if err := recoverStrategy(ctx, store, exchange, "dca", "BTCUSDT", restarted, log); err != nil {
+		t.Fatal(err)
+	}
+	if got := restarted.GetStatus().DCA.Orders; got != 1 {
+		t.Fatalf("buy_count = %v, want 1", got)
+	}
+}
+
+func TestJournal_ResendsNoInterruptedOrder(t *testing.T) {
+	log := logger.New(logger.LevelError)
+	store, err := NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	exchange := &historyExchange{PaperExchange: NewPaperExchange(log, 0)}
+	buy := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.01, Price: 40000,
+		Decision: &types.Decision{Strategy: "dca", StrategyID: "dca", Action: types.DecisionBuy}}
+
+	// A crash after journaling the submission, before the exchange answered
+	crashed := newJournalClient(exchange, store, log)
+	crashed.setBot("dca")
+	id, err := crashed.clientOrderID(buy, decisionKey(buy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitted := buy
+	submitted.ExchangeOrder = &types.ExchangeOrder{ClientOrderID: id}
+	if err := crashed.append(JournalEntry{Time: time.Now(), Action: journalSubmit, Order: &submitted, ClientOrderID: id}); err != nil {
+		t.Fatal(err)
+	}
+
+	// After the restart the exchange reports the order; the retried buy has
+	// its id and is not sent again
+	exchange.history = []types.Order{{ID: "7", Symbol: "BTCUSDT", Status: types.OrderStatusFilled, FilledAmount: 0.01, FilledPrice: 40000,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: id}}}
+	restarted := newJournalClient(exchange, store, log)
+	restarted.setBot("dca")
+	if err := restarted.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(exchange.orders); n != 0 {
+		t.Fatalf("exchange got %d orders, want the interrupted one not resent", n)
+	}
+
+	// The next buy gets a new id and is sent
+	if err := restarted.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(exchange.orders); n != 1 || exchange.orders[0].ExchangeOrder.ClientOrderID == id {
+		t.Fatalf("exchange orders = %+v, want one new order", exchange.orders)
+	}
+
+	// An interrupted submission the exchange never got is sent under its id
+	exit := buy
+	exit.Side, exit.Decision = types.OrderSideSell, &types.Decision{Strategy: "dca", StrategyID: "dca", Action: types.DecisionExit}
+	exitID, _ := restarted.clientOrderID(exit, decisionKey(exit))
+	exit.ExchangeOrder = &types.ExchangeOrder{ClientOrderID: exitID}
+	if err := restarted.append(JournalEntry{Time: time.Now(), Action: journalSubmit, Order: &exit, ClientOrderID: exitID}); err != nil {
+		t.Fatal(err)
+	}
+	exit.ExchangeOrder = nil
+	again := newJournalClient(exchange, store, log)
+	again.setBot("dca")
+	if err := again.PlaceOrder(ctx, exit); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(exchange.orders); n != 2 || exchange.orders[1].ExchangeOrder.ClientOrderID != exitID {
+		t.Fatalf("exchange orders = %+v, want the exit sent as %s", exchange.orders, exitID)
+	}
+}
+
+func TestJournal_GridRetryAfterRestart(t *testing.T) {
+	log := logger.New(logger.LevelError)
+	store, err := NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	exchange := &historyExchange{PaperExchange: NewPaperExchange(log, 0)}
+
+	// A crash after journaling the grid's buy at 110 under the strategy's
+	// own client order id, before the exchange answered
+	buy := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 100.0 / 110, Price: 110,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "grid.g-1-1"},
+		Decision:      &types.Decision{Strategy: "grid", StrategyID: "grid", Action: types.DecisionBuy, Level: 110}}
+	if err := store.Append(journalName, JournalEntry{Time: time.Now(), Bot: "grid", Action: journalSubmit, Order: &buy, ClientOrderID: "grid.g-1-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The exchange did take it, after recovery read the history; the
+	// restarted grid retries the decision under a new id
+	exchange.history = []types.Order{{ID: "7", Symbol: "BTCUSDT", Side: types.OrderSideBuy, Status: types.OrderStatusFilled, Quantity: buy.Quantity,
+		FilledAmount: buy.Quantity, FilledPrice: 109, ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "grid.g-1-1"}}}
+	journal := newJournalClient(exchange, store, log)
+	journal.setBot("grid")
+	grid := newRecoveryGrid(t, journal)
+	if err := grid.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 109}); err != nil {
+		t.Fatal(err)
+	}
+	for _, order := range exchange.orders {
+		if order.Decision != nil && order.Decision.Level == 110 && order.Side == types.OrderSideBuy {
+			t.Fatalf("buy at 110 placed again as %s", order.ExchangeOrder.ClientOrderID)
+		}
+	}
+	if held := grid.GetStatus().Grid.LevelsHeld; held < 1 {
+		t.Fatalf("levels_held = %v, want the interrupted buy booked", held)
+	}
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+)
+
+// StateSchemaVersion is the state dir layout this build reads and writes,
+// recorded in schema.json. Dirs without it are version 1.
+const StateSchemaVersion = 2
+
+// schemaName is the state dir's schema file
+const schemaName = "schema"
+
+type stateSchema struct {
+	Version int `json:"version"`
+}
+
+// stateMigrations upgrade a state dir to version To from the one before;
+// with dryRun they only describe what they would change
+var stateMigrations = []struct {
+	To    int
+	Apply func(store *StateStore, dryRun bool) ([]string, error)
+}{
+	{To: 2, Apply: attributeJournal},
+}
+
+// MigrateState upgrades a state dir to StateSchemaVersion and returns the
+// version it was in and the changes made, or with dryRun the changes it
+// would make. A dir written by a newer build fails with config.ErrNewerSchema.
+func MigrateState(store *StateStore, dryRun bool) (int, []string, error) {
+	schema := stateSchema{Version: 1}
+	if _, err := store.Load(schemaName, &schema); err != nil {
+		return 0, nil, err
+	}
+	from := schema.Version
+	if from > StateSchemaVersion {
+		return from, nil, fmt.Errorf("state dir %s %w: version %d, supported %d", store.Dir(), config.ErrNewerSchema, from, StateSchemaVersion)
+	}
+	if from == StateSchemaVersion {
+		return from, nil, nil
+	}
+
+	var changes []string
+	for _, m := range stateMigrations {
+		if m.To <= from {
+			continue
+		}
+		applied, err := m.Apply(store, dryRun)
+		if err != nil {
+			return from, changes, fmt.Errorf("state migration to v%d: %w", m.To, err)
+		}
+		for _, change := range applied {
+			changes = append(changes, fmt.Sprintf("v%d: %s", m.To, change))
+		}
+	}
+	if dryRun {
+		return from, changes, nil
+	}
+	return from, changes, store.Save(schemaName, stateSchema{Version: StateSchemaVersion})
+}
+
+// attributeJournal assigns order journal entries written before entries
+// named their bot to the bot whose snapshot the dir holds. Recovery,
+// statements and slippage fits select entries by bot and skipped them.
+func attributeJournal(store *StateStore, dryRun bool) ([]string, error) {
+	var unattributed int
+	err := store.Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if json.Unmarshal(line, &entry) == nil && entry.Bot == "" {
+			unattributed++
+		}
+		return nil
+	})
+	if err != nil || unattributed == 0 {
+		return nil, err
+	}
+
+	bots, err := snapshotBots(store)
+	if err != nil {
+		return nil, err
+	}
+	if len(bots) != 1 {
+		return []string{fmt.Sprintf("left %d journal record(s) without a bot: the dir holds snapshots of %d bots (%s)",
+			unattributed, len(bots), strings.Join(bots, ", "))}, nil
+	}
+	change := fmt.Sprintf("attributed %d journal record(s) without a bot to %s", unattributed, bots[0])
+	if dryRun {
+		return []string{change}, nil
+	}
+
+	err = store.Rewrite(journalName, func(line []byte) ([]byte, error) {
+		var entry map[string]interface{}
+		if json.Unmarshal(line, &entry) != nil {
+			return line, nil // kept as is; readers skip it too
+		}
+		if bot, _ := entry["bot"].(string); bot != "" {
+			return line, nil
+		}
+		entry["bot"] = bots[0]
+		return json.Marshal(entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []string{change}, nil
+}
+
+// snapshotBots returns the ids of the bots with a snapshot in the dir
+func snapshotBots(store *StateStore) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(store.Dir(), "*-state.json"))
+	if err != nil {
+		return nil, err
+	}
+	bots := make([]string, 0, len(paths))
+	for _, path := range paths {
+		bots = append(bots, strings.TrimSuffix(filepath.Base(path), "-state.json"))
+	}
+	sort.Strings(bots)
+	return bots, nil
+}
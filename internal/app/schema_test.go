@@ -0,0 +1,43 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+)
+
+func TestMigrateState(t *testing.T) {
+	store, err := NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bot := range []string{"dca", "grid"} {
+		if err := store.Save(bot+"-state", BotSnapshot{Bot: bot}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Append(journalName, JournalEntry{Action: journalPlace, OrderID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unattributed entries are left alone when the dir holds several bots
+	from, changes, err := MigrateState(store, false)
+	if err != nil || from != 1 {
+		t.Fatalf("MigrateState() = v%d, %v", from, err)
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0], "left 1 journal record(s) without a bot") {
+		t.Errorf("changes = %v", changes)
+	}
+	if from, changes, err := MigrateState(store, false); err != nil || from != StateSchemaVersion || len(changes) != 0 {
+		t.Errorf("second MigrateState() = v%d, %v, %v", from, changes, err)
+	}
+
+	if err := store.Save(schemaName, stateSchema{Version: StateSchemaVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := MigrateState(store, false); !errors.Is(err, config.ErrNewerSchema) {
+		t.Errorf("MigrateState() of a newer dir error = %v, want ErrNewerSchema", err)
+	}
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// sentimentJournal is the journal sentiment points are appended to
+const sentimentJournal = "sentiment"
+
+// SentimentRecorder scores the configured feeds for the traded symbols and
+// keeps the scores over time, in the state store when there is one, so
+// strategies see sentiment momentum rather than a single snapshot
+type SentimentRecorder struct {
+	cfg      ai.SentimentConfig
+	store    *StateStore // nil keeps the history in memory only
+	analyzer *ai.SentimentAnalyzer
+	history  *ai.SentimentHistory
+	logger   *logger.Logger
+
+	mu      sync.Mutex
+	extreme map[string]string // symbol -> ai.SentimentHigh or ai.SentimentLow while alerted
+}
+
+// SentimentReport is the latest sentiment of a symbol as served by the API
+type SentimentReport struct {
+	ai.AggregatedSentiment
+	Trend   ai.SentimentTrend  `json:"trend"`
+	Extreme string             `json:"extreme,omitempty"` // high or low beyond the alert thresholds
+	Alerts  ai.SentimentAlerts `json:"alerts"`
+}
+
+// NewSentimentRecorder loads points recorded or backfilled by earlier runs
+func NewSentimentRecorder(cfg ai.SentimentConfig, store *StateStore, log *logger.Logger) (*SentimentRecorder, error) {
+	var points []ai.AggregatedSentiment
+	if store != nil {
+		var err error
+		if points, err = ReadSentiment(store); err != nil {
+			return nil, err
+		}
+	}
+	return &SentimentRecorder{
+		cfg:      cfg.WithDefaults(),
+		store:    store,
+		analyzer: cfg.NewFeedAnalyzer(),
+		history:  ai.NewSentimentHistory(points...),
+		logger:   log,
+		extreme:  make(map[string]string),
+	}, nil
+}
+
+// ReadSentiment returns the sentiment points recorded in store, in journal order
+func ReadSentiment(store *StateStore) ([]ai.AggregatedSentiment, error) {
+	var points []ai.AggregatedSentiment
+	err := store.Scan(sentimentJournal, func(line []byte) error {
+		var point ai.AggregatedSentiment
+		if err := json.Unmarshal(line, &point); err != nil {
+			return fmt.Errorf("failed to decode sentiment point: %w", err)
+		}
+		points = append(points, point)
+		return nil
+	})
+	return points, err
+}
+
+// AppendSentiment journals points in store, e.g. from a backfill
+func AppendSentiment(store *StateStore, points []ai.AggregatedSentiment) error {
+	for _, point := range points {
+		if err := store.Append(sentimentJournal, point); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// History returns the recorded sentiment
+func (r *SentimentRecorder) History() *ai.SentimentHistory {
+	return r.history
+}
+
+// Reading returns symbol's latest sentiment and its momentum, nil before
+// the first recording
+func (r *SentimentRecorder) Reading(symbol string, now time.Time) *types.Sentiment {
+	return r.history.Reading(symbol, now, r.cfg.MomentumWindow)
+}
+
+// Report returns symbol's latest sentiment with its trend over window (the
+// momentum window when 0), false before the first recording
+func (r *SentimentRecorder) Report(symbol string, now time.Time, window time.Duration) (*SentimentReport, bool) {
+	latest, ok := r.history.At(symbol, now)
+	if !ok {
+		return nil, false
+	}
+	if window <= 0 {
+		window = r.cfg.MomentumWindow
+	}
+	return &SentimentReport{
+		AggregatedSentiment: latest,
+		Trend:               r.history.Trend(symbol, now, window),
+		Extreme:             r.cfg.Alerts.Extreme(latest.Sentiment),
+		Alerts:              r.cfg.Alerts,
+	}, true
+}
+
+// Record scores the news of the last interval for symbol. Intervals without
+// news about the symbol are not recorded.
+func (r *SentimentRecorder) Record(ctx context.Context, symbol string) error {
+	point, err := r.analyzer.AnalyzeMarketSentiment(ctx, symbol, r.cfg.Interval)
+	if err != nil {
+		return err
+	}
+	if point.Sources == 0 {
+		return nil
+	}
+	point.Timestamp = point.Timestamp.UTC()
+	if r.store != nil {
+		if err := r.store.Append(sentimentJournal, point); err != nil {
+			return err
+		}
+	}
+	r.history.Add(*point)
+	r.alert(*point)
+	return nil
+}
+
+// alert logs when symbol's sentiment turns extreme, and again when it returns
+func (r *SentimentRecorder) alert(point ai.AggregatedSentiment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	extreme := r.cfg.Alerts.Extreme(point.Sentiment)
+	switch previous := r.extreme[point.Symbol]; {
+	case extreme == previous:
+	case extreme != "":
+		r.logger.Warn("Sentiment alert: %s sentiment %.2f is extremely %s (thresholds %.2f/%.2f)",
+			point.Symbol, point.Sentiment, extreme, r.cfg.Alerts.Low, r.cfg.Alerts.High)
+		r.extreme[point.Symbol] = extreme
+	default:
+		r.logger.Info("Sentiment alert cleared: %s sentiment back to %.2f", point.Symbol, point.Sentiment)
+		delete(r.extreme, point.Symbol)
+	}
+}
+
+// Run records the sentiment of symbols now and every interval until ctx is done
+func (r *SentimentRecorder) Run(ctx context.Context, symbols []string) {
+	record := func() {
+		for _, symbol := range symbols {
+			if err := r.Record(ctx, symbol); err != nil {
+				r.logger.Warn("Failed to record %s sentiment: %v", symbol, err)
+			}
+		}
+	}
+	record()
+
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			record()
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+)
+
+func TestRouter_Sentiment(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<rss><channel>
+<item><title>Bitcoin rally: BTC surges to record high on strong gains</title><pubDate>%s</pubDate></item>
+<item><title>Ethereum upgrade delayed</title><pubDate>%s</pubDate></item>
+</channel></rss>`, time.Now().Format(time.RFC1123Z), time.Now().Format(time.RFC1123Z))
+	}))
+	defer feed.Close()
+
+	cfg := &config.Config{
+		App:       config.AppConfig{Name: "test", ReportingCurrency: "USD", StateDir: t.TempDir()},
+		Logging:   config.LoggingConfig{Level: "error"},
+		Sentiment: ai.SentimentConfig{Feeds: []string{feed.URL}, Alerts: ai.SentimentAlerts{High: 0.1, Low: -0.1}},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newRouter(c, nil, &probeState{})
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/sentiment/BTCUSDT"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /sentiment before recording = %d, want 404", rec.Code)
+	}
+	if err := c.Sentiment().Record(context.Background(), "BTCUSDT"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := get("/v1/sentiment/btcusdt?window=1h")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /sentiment = %d: %s", rec.Code, rec.Body.String())
+	}
+	var report SentimentReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Symbol != "BTCUSDT" || report.Sentiment <= 0.1 || report.Sources != 1 {
+		t.Errorf("report = %+v, want the bullish BTC headline only", report.AggregatedSentiment)
+	}
+	if source, ok := report.BySource[feed.URL]; !ok || source.Texts != 1 {
+		t.Errorf("by_source = %+v, want the feed", report.BySource)
+	}
+	if report.Extreme != ai.SentimentHigh || report.Trend.Window != "1h0m0s" || len(report.Trend.Points) != 1 {
+		t.Errorf("extreme = %q, trend = %+v", report.Extreme, report.Trend)
+	}
+
+	// The point is journaled for the next run
+	if points, err := ReadSentiment(c.StateStore()); err != nil || len(points) != 1 {
+		t.Errorf("ReadSentiment() = %d points, %v", len(points), err)
+	}
+	if rec := get("/sentiment/BTCUSDT?window=soon"); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /sentiment with a bad window = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,684 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/compliance"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// startHTTPServer runs the HTTP server for monitoring
+func startHTTPServer(ctx context.Context, c *Container, strategy strategy.Strategy, probes *probeState) {
+	cfg, log := c.Config(), c.Logger()
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.App.Port),
+		Handler: loggingMiddleware(log, newRouter(c, strategy, probes)),
+	}
+
+	go func() {
+		log.Info("HTTP server listening on port %d", cfg.App.Port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error("HTTP server error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(shutdownCtx)
+	log.Info("HTTP server stopped")
+}
+
+// strategyTraceable names strategy.Traceable where newRouter's strategy
+// parameter shadows the package
+type strategyTraceable = strategy.Traceable
+
+// strategyStatusReporter names strategy.StatusReporter for the same reason
+type strategyStatusReporter = strategy.StatusReporter
+
+// traceKey pages decision trace entries by time and sequence number
+func traceKey(e strategy.TraceEntry) pageKey {
+	return pageKey{time: e.Time, id: fmt.Sprintf("%020d", e.Seq)}
+}
+
+// newRouter serves the monitoring endpoints shared by all bots under /v1.
+// The unversioned paths remain as aliases for existing clients.
+func newRouter(c *Container, strategy strategy.Strategy, probes *probeState) *http.ServeMux {
+	mux := http.NewServeMux()
+	router := http.NewServeMux()
+	router.Handle("/v1/", http.StripPrefix("/v1", mux))
+	router.Handle("/", mux)
+	portfolio := c.PortfolioManager()
+
+	// Health: degraded while a trading loop has stalled
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		if stalled := probes.watchdog.Stalled(time.Now()); len(stalled) > 0 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "degraded", "stalled": stalled})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	mux.HandleFunc("GET /health/loops", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, probes.watchdog.Status(time.Now()))
+	})
+
+	// Liveness: the process is up and serving HTTP
+	mux.HandleFunc("GET /live", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "alive"})
+	})
+
+	// Readiness: the strategy is running and the exchange is reachable
+	mux.HandleFunc("GET /ready", func(w http.ResponseWriter, r *http.Request) {
+		if !probes.ready.Load() {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready", "reason": "strategy not running"})
+			return
+		}
+		pingCtx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+		if err := c.Exchange().Ping(pingCtx); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready", "reason": "exchange unreachable: " + err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	})
+
+	// Exchange availability: maintenance windows, system status and failure backoff
+	mux.HandleFunc("GET /exchange/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.Maintenance().Status())
+	})
+
+	mux.HandleFunc("GET /ticker", func(w http.ResponseWriter, r *http.Request) {
+		symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+		if symbol == "" {
+			writeError(w, http.StatusBadRequest, "symbol query parameter is required")
+			return
+		}
+		ticker, err := c.Exchange().GetTicker(r.Context(), symbol)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, ticker)
+	})
+
+	// Rolling volatility, correlation and regime from the market data candles;
+	// ?symbols= overrides the configured symbols, which default to the bot's
+	mux.HandleFunc("GET /analytics/market", func(w http.ResponseWriter, r *http.Request) {
+		if c.marketAnalyzer == nil {
+			writeError(w, http.StatusNotFound, "market analytics need exchange.market_data timeframes")
+			return
+		}
+		symbols := splitSymbols(r.URL.Query().Get("symbols"))
+		if len(symbols) == 0 {
+			symbols = c.Config().MarketAnalytics.Symbols
+		}
+		if len(symbols) == 0 {
+			for _, market := range botMarkets(c, strategy, "") {
+				if !slices.Contains(symbols, market.Symbol) {
+					symbols = append(symbols, market.Symbol)
+				}
+			}
+		}
+		if len(symbols) == 0 {
+			writeError(w, http.StatusBadRequest, "symbols query parameter is required")
+			return
+		}
+		result, err := c.marketAnalyzer.Analyze(r.Context(), symbols)
+		if err != nil {
+			if len(result.Symbols) == 0 {
+				writeError(w, http.StatusBadGateway, err.Error())
+				return
+			}
+			c.Logger().Warn("Market analytics incomplete: %v", err)
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+
+	// Latest news sentiment with its per-source breakdown and trend; ?window=
+	// overrides the momentum window the trend is measured over
+	mux.HandleFunc("GET /sentiment/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		sentiment := c.Sentiment()
+		if sentiment == nil {
+			writeError(w, http.StatusNotFound, "sentiment needs sentiment.feeds")
+			return
+		}
+		var window time.Duration
+		if raw := r.URL.Query().Get("window"); raw != "" {
+			var err error
+			if window, err = time.ParseDuration(raw); err != nil || window <= 0 {
+				writeError(w, http.StatusBadRequest, "window must be a positive duration")
+				return
+			}
+		}
+		symbol := strings.ToUpper(r.PathValue("symbol"))
+		report, ok := sentiment.Report(symbol, time.Now(), window)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("no sentiment recorded for %s", symbol))
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+
+	mux.HandleFunc("GET /portfolio", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, portfolio.GetPortfolio())
+	})
+
+	// Open positions by symbol
+	mux.HandleFunc("GET /positions", func(w http.ResponseWriter, r *http.Request) {
+		pg, err := parsePage(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		positions, next := pageOf(pg, sortedPositions(portfolio), func(p *types.Position) pageKey { return pageKey{id: p.Symbol} })
+		writeJSON(w, http.StatusOK, map[string]interface{}{"positions": positions, "next": next})
+	})
+
+	// The portfolio re-priced at ?to= (default now) from journaled fills and
+	// historical candles, and the PnL since ?from=
+	mux.HandleFunc("GET /portfolio/statement", func(w http.ResponseWriter, r *http.Request) {
+		executions, recorder := c.Executions(), c.EquityRecorder()
+		if executions == nil || recorder == nil {
+			writeError(w, http.StatusNotFound, "statements need a state dir")
+			return
+		}
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if to.IsZero() {
+			to = time.Now()
+		}
+		if !from.IsZero() && !from.Before(to) {
+			writeError(w, http.StatusBadRequest, "from must be before to")
+			return
+		}
+		statement, err := RepricePeriod(r.Context(), executions.History(time.Time{}, time.Time{}),
+			recorder.History(time.Time{}, time.Time{}), from, to, historicalPrices(c.Exchange()))
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, statement)
+	})
+
+	// Gross and net exposure of each strategy's positions, and the positions
+	// aggregated per symbol under portfolio.netting
+	mux.HandleFunc("GET /portfolio/exposure", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, portfolio.Exposure())
+	})
+
+	// Spreadsheet exports: ?format=csv (default) or xlsx, times in the ?tz= zone
+	mux.HandleFunc("GET /portfolio/export", func(w http.ResponseWriter, r *http.Request) {
+		loc, err := exportLocation(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeExport(w, r, "positions", positionsTable(sortedPositions(portfolio), loc))
+	})
+
+	mux.HandleFunc("GET /trades/export", func(w http.ResponseWriter, r *http.Request) {
+		executions := c.Executions()
+		if executions == nil {
+			writeError(w, http.StatusNotFound, "fill attribution needs a state dir")
+			return
+		}
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		loc, err := exportLocation(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeExport(w, r, "trades", tradesTable(executions.History(from, to), loc))
+	})
+
+	mux.HandleFunc("GET /portfolio/valuation", func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		latest, _ := portfolio.GetValuation()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"latest":  latest,
+			"history": portfolio.GetValuationHistory(from, to),
+		})
+	})
+
+	// Persisted equity curve with daily returns and the statistics derived from them
+	mux.HandleFunc("GET /portfolio/equity", func(w http.ResponseWriter, r *http.Request) {
+		recorder := c.EquityRecorder()
+		if recorder == nil {
+			writeError(w, http.StatusNotFound, "equity snapshots need a state dir")
+			return
+		}
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		points := recorder.History(from, to)
+		response := map[string]interface{}{
+			"snapshots": points,
+			"daily":     analytics.DailyReturns(points),
+			"stats":     analytics.ComputeEquityStats(points),
+		}
+		if asset := recorder.Benchmark(); asset != "" {
+			response["benchmark"] = analytics.ComputeBenchmarkStats(asset, points)
+		}
+		writeJSON(w, http.StatusOK, response)
+	})
+
+	// The trades a rebalance to portfolio.rebalance targets would place now
+	mux.HandleFunc("GET /portfolio/rebalance", func(w http.ResponseWriter, r *http.Request) {
+		cfg := c.Config().Portfolio.Rebalance
+		if !cfg.Enabled() {
+			writeError(w, http.StatusNotFound, "no rebalance targets configured")
+			return
+		}
+		plan, err := portfolio.PlanRebalance(r.Context(), cfg)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, plan)
+	})
+
+	// Journaled fills with slippage and commission broken down by strategy,
+	// exchange and hour of day
+	mux.HandleFunc("GET /accounts", func(w http.ResponseWriter, r *http.Request) {
+		accounts := make([]map[string]interface{}, 0, len(c.Accounts()))
+		for _, account := range c.Accounts() {
+			accounts = append(accounts, account.Status())
+		}
+		writeJSON(w, http.StatusOK, accounts)
+	})
+
+	mux.HandleFunc("GET /executions", func(w http.ResponseWriter, r *http.Request) {
+		executions := c.Executions()
+		if executions == nil {
+			writeError(w, http.StatusNotFound, "fill attribution needs a state dir")
+			return
+		}
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		pg, err := parsePage(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Slippage covers the whole time range, not just the page
+		history := executions.History(from, to)
+		list, next := pageOf(pg, slices.Clone(history), func(e analytics.Execution) pageKey {
+			return pageKey{time: e.Time, id: e.OrderID + "|" + e.Symbol + "|" + e.Side}
+		})
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"executions": list,
+			"next":       next,
+			"slippage":   analytics.SummarizeSlippage(history),
+		})
+	})
+
+	mux.HandleFunc("GET /strategy/status", func(w http.ResponseWriter, r *http.Request) {
+		reporter, ok := strategy.(strategyStatusReporter)
+		if !ok {
+			writeError(w, http.StatusNotFound, "strategy does not report status")
+			return
+		}
+		writeJSON(w, http.StatusOK, reporter.GetStatus())
+	})
+
+	// Decision trace: the rule that triggered or blocked each Execute run's
+	// orders. Pages run from the newest entries back, each oldest first.
+	mux.HandleFunc("GET /strategy/trace", func(w http.ResponseWriter, r *http.Request) {
+		traceable, ok := strategy.(strategyTraceable)
+		if !ok {
+			writeError(w, http.StatusNotFound, "strategy does not record decisions")
+			return
+		}
+		pg, err := parsePage(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		entries, next := pageBackOf(pg, traceable.Trace(), traceKey)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"entries": entries,
+			"next":    next,
+		})
+	})
+
+	// Shadow or A/B comparison so far: the candidate's fills against the
+	// live ones since the test started, with the paired return test
+	mux.HandleFunc("GET /shadow", func(w http.ResponseWriter, r *http.Request) {
+		if c.shadow == nil {
+			writeError(w, http.StatusNotFound, "no strategy runs in shadow mode")
+			return
+		}
+		report, err := c.shadow.report()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+
+	mux.HandleFunc("POST /strategy/config", func(w http.ResponseWriter, r *http.Request) {
+		// Try to update DCA config if supported
+		type dcaConfigUpdater interface {
+			UpdateConfig(cfg types.DCAConfig) error
+		}
+		if up, ok := strategy.(dcaConfigUpdater); ok {
+			var partial map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&partial); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			// Current config; fetch via type assert if supported
+			type dcaConfigGetter interface{ GetConfig() types.DCAConfig }
+			if getter, ok := strategy.(dcaConfigGetter); ok {
+				current := getter.GetConfig()
+				// Apply partial fields
+				if v, ok := partial["investment_amount"].(float64); ok {
+					current.InvestmentAmount = v
+				}
+				if v, ok := partial["max_investments"].(float64); ok {
+					current.MaxInvestments = int(v)
+				}
+				if v, ok := partial["price_threshold"].(float64); ok {
+					current.PriceThreshold = v
+				}
+				if v, ok := partial["stop_loss"].(float64); ok {
+					current.StopLoss = v
+				}
+				if v, ok := partial["take_profit"].(float64); ok {
+					current.TakeProfit = v
+				}
+				if v, ok := partial["enabled"].(bool); ok {
+					current.Enabled = v
+				}
+				if v, ok := partial["interval"].(string); ok {
+					if d, err := time.ParseDuration(v); err == nil {
+						current.Interval = d
+					}
+				}
+				if err := up.UpdateConfig(current); err != nil {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+				return
+			}
+		}
+		writeError(w, http.StatusBadRequest, "strategy does not support config updates")
+	})
+
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		metrics := map[string]interface{}{
+			"strategy":  c.StrategyMetrics(strategy),
+			"portfolio": portfolio.GetMetrics(),
+		}
+		if deleverager := c.Deleverager(); deleverager != nil {
+			metrics["deleverage"] = deleverager.Status()
+		}
+		if cal := c.Calendar(); cal != nil {
+			metrics["calendar"] = cal.Status()
+		}
+		metrics["restrictions"] = c.Restrictions().Status()
+		if monitor := c.Compliance(); monitor != nil {
+			metrics["compliance"] = monitor.Status()
+		}
+		if reports := c.ComplianceReports(); reports != nil {
+			metrics["compliance_reports"] = reports.Status()
+		}
+		if guard := c.Guard(); guard != nil {
+			metrics["guard"] = guard.Status()
+		}
+		if budget := c.RateBudget(); budget != nil {
+			metrics["rate_limit"] = budget.Status()
+		}
+		if injector := c.Chaos(); injector != nil {
+			metrics["chaos"] = injector.Status()
+		}
+		writeJSON(w, http.StatusOK, metrics)
+	})
+
+	// Prometheus exposition of the registered metrics; names and labels are
+	// stable so generated dashboards keep working
+	mux.Handle("GET /metrics/prometheus", c.Metrics().Handler())
+
+	// Log levels: the default and the per-component overrides, which the
+	// operator can change at runtime
+	mux.HandleFunc("GET /logging", func(w http.ResponseWriter, r *http.Request) {
+		level, overrides := c.Logger().Levels()
+		components := make(map[string]string, len(overrides))
+		for component, l := range overrides {
+			components[component] = l.String()
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"level": level.String(), "components": components})
+	})
+
+	token := c.Config().App.APIToken
+
+	// Ending an A/B test: promote needs B significantly better unless
+	// ?force=true; rollback stops B
+	decide := func(decision func(r *http.Request) (*ShadowReport, error)) http.HandlerFunc {
+		return authorized(token, func(w http.ResponseWriter, r *http.Request) {
+			if c.shadow == nil {
+				writeError(w, http.StatusNotFound, "no strategy runs in shadow mode")
+				return
+			}
+			report, err := decision(r)
+			switch {
+			case errors.Is(err, errShadowDecided), errors.Is(err, errNotSignificant), errors.Is(err, errNotABTest):
+				writeError(w, http.StatusConflict, err.Error())
+			case err != nil:
+				writeError(w, http.StatusInternalServerError, err.Error())
+			default:
+				writeJSON(w, http.StatusOK, report)
+			}
+		})
+	}
+	mux.HandleFunc("POST /shadow/promote", decide(func(r *http.Request) (*ShadowReport, error) {
+		return c.shadow.promote(r.URL.Query().Get("force") == "true")
+	}))
+	mux.HandleFunc("POST /shadow/rollback", decide(func(r *http.Request) (*ShadowReport, error) {
+		return c.shadow.rollback(context.WithoutCancel(r.Context()))
+	}))
+
+	// Operator pause: the trading loop skips ticks and manual orders are
+	// refused until resumed, as during exchange maintenance
+	mux.HandleFunc("POST /trading/pause", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		reason := r.URL.Query().Get("reason")
+		c.Maintenance().Pause(reason)
+		c.Logger().Warn("Trading paused by operator: %s", reason)
+		writeJSON(w, http.StatusOK, c.Maintenance().Status())
+	}))
+	mux.HandleFunc("POST /trading/resume", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		c.Maintenance().Resume()
+		c.Logger().Info("Trading resumed by operator")
+		writeJSON(w, http.StatusOK, c.Maintenance().Status())
+	}))
+
+	// Rebalance now rather than waiting for the schedule
+	mux.HandleFunc("POST /portfolio/rebalance", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		cfg := c.Config().Portfolio.Rebalance
+		if !cfg.Enabled() {
+			writeError(w, http.StatusNotFound, "no rebalance targets configured")
+			return
+		}
+		if available, reason := c.Maintenance().Available(); !available {
+			writeError(w, http.StatusServiceUnavailable, "trading paused: "+reason)
+			return
+		}
+		ctx := context.WithoutCancel(r.Context())
+		plan, err := portfolio.PlanRebalance(ctx, cfg)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		placed, err := portfolio.ExecuteRebalance(ctx, c.rebalanceClient(), plan, cfg)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("%v (%d order(s) placed)", err, placed))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"placed": placed, "plan": plan})
+	}))
+
+	// Compliance reports awaiting filing; the operator files them with the
+	// regulator and records the reference
+	withReports := func(next func(w http.ResponseWriter, r *http.Request, reports *compliance.ReportManager)) http.HandlerFunc {
+		return authorized(token, func(w http.ResponseWriter, r *http.Request) {
+			reports := c.ComplianceReports()
+			if reports == nil {
+				writeError(w, http.StatusNotFound, "compliance reports need compliance checks and a state dir")
+				return
+			}
+			next(w, r, reports)
+		})
+	}
+	mux.HandleFunc("GET /compliance/reports", withReports(func(w http.ResponseWriter, r *http.Request, reports *compliance.ReportManager) {
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "":
+			status = compliance.ReportPending
+		case "all":
+			status = ""
+		case compliance.ReportPending, compliance.ReportFiled:
+		default:
+			writeError(w, http.StatusBadRequest, "status must be pending, filed or all")
+			return
+		}
+		pg, err := parsePage(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		list, next := pageOf(pg, reports.Reports(status), func(r compliance.Report) pageKey { return pageKey{time: r.Created, id: r.ID} })
+		writeJSON(w, http.StatusOK, map[string]interface{}{"reports": list, "next": next})
+	}))
+	mux.HandleFunc("GET /compliance/reports/{id}", withReports(func(w http.ResponseWriter, r *http.Request, reports *compliance.ReportManager) {
+		_, path, err := reports.Open(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeFile(w, r, path)
+	}))
+	mux.HandleFunc("POST /compliance/reports/{id}/filed", withReports(func(w http.ResponseWriter, r *http.Request, reports *compliance.ReportManager) {
+		var req struct {
+			Reference string `json:"reference"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		report, err := reports.MarkFiled(r.PathValue("id"), req.Reference)
+		switch {
+		case errors.Is(err, compliance.ErrReportNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, compliance.ErrReportFiled):
+			writeError(w, http.StatusConflict, err.Error())
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		default:
+			c.Logger().Info("Compliance report %s filed as %q", report.ID, report.Reference)
+			writeJSON(w, http.StatusOK, report)
+		}
+	}))
+
+	mux.HandleFunc("PUT /logging/{component}", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch req.Level {
+		case "debug", "info", "warn", "error":
+		default:
+			writeError(w, http.StatusBadRequest, "level must be debug, info, warn or error")
+			return
+		}
+		component := r.PathValue("component")
+		c.Logger().SetComponentLevel(component, ParseLogLevel(req.Level))
+		c.Logger().Info("Log level of %s set to %s", component, req.Level)
+		writeJSON(w, http.StatusOK, map[string]string{"component": component, "level": req.Level})
+	}))
+
+	mux.HandleFunc("DELETE /logging/{component}", authorized(token, func(w http.ResponseWriter, r *http.Request) {
+		component := r.PathValue("component")
+		c.Logger().ClearComponentLevel(component)
+		writeJSON(w, http.StatusOK, map[string]string{"component": component, "status": "cleared"})
+	}))
+
+	registerOrderRoutes(mux, c, strategy)
+
+	return router
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// APIError is the body of every error response
+type APIError struct {
+	Error string `json:"error"`
+	Code  string `json:"code"` // snake_case HTTP status, e.g. bad_request
+}
+
+// writeError writes msg in the error envelope
+func writeError(w http.ResponseWriter, status int, msg string) {
+	code := strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	writeJSON(w, status, APIError{Error: msg, Code: code})
+}
+
+func loggingMiddleware(log *logger.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Info("%s %s %s", r.Method, r.URL.Path, time.Since(start))
+	})
+}
+
+// parseTimeRange reads optional RFC3339 from and to query parameters
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	var bounds [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		bounds[i] = t
+	}
+	return bounds[0], bounds[1], nil
+}
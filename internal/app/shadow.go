@@ -0,0 +1,788 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// defaultShadowDays is how long a test runs before its report is written
+const defaultShadowDays = 7
+
+// ShadowSpec describes the candidate strategy, variant B, a bot runs in
+// shadow mode or A/B tests against its live strategy
+type ShadowSpec struct {
+	ID         string        // state and journal id of the candidate, distinct from the bot's
+	Days       int           // days until the comparison report is written (default 7)
+	Allocation float64       // share of the quote balance B trades live with; 0 runs shadow mode
+	Interval   time.Duration // period of the paired returns (default 24h)
+	Alpha      float64       // significance level of the paired t-test (default 0.05)
+
+	// Build creates the candidate on the shadow exchange client
+	Build func(c *Container, exchange types.ExchangeClient) (strategy.Strategy, error)
+}
+
+// shadowExchange fills a shadow strategy's orders against the live quotes
+// without sending them. Market data, balances and fees come from the live
+// client. Market orders fill at the last ask or bid; limit orders fill at
+// their price once the quote crosses it.
+type shadowExchange struct {
+	types.ExchangeClient
+
+	mu      sync.Mutex
+	nextID  int
+	fills   int // orders filled by this run
+	quotes  map[string]types.Ticker
+	resting []types.Order // open limit orders, oldest first
+	closed  []types.Order // filled and canceled orders, oldest first
+}
+
+func newShadowExchange(live types.ExchangeClient) *shadowExchange {
+	return &shadowExchange{ExchangeClient: live, quotes: make(map[string]types.Ticker)}
+}
+
+// quote records the market's ticker and fills the resting limits it crosses
+func (s *shadowExchange) quote(market types.MarketData) {
+	ticker := types.Ticker{Symbol: market.Symbol, Price: market.Price, Timestamp: market.Timestamp}
+	if market.Ticker != nil {
+		ticker = *market.Ticker
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[market.Symbol] = ticker
+
+	resting := s.resting[:0]
+	for _, order := range s.resting {
+		if order.Symbol == market.Symbol && crosses(order, ticker) {
+			s.fillLocked(order, order.Price)
+			continue
+		}
+		resting = append(resting, order)
+	}
+	s.resting = resting
+}
+
+// crosses reports whether a limit order is marketable at the ticker
+func crosses(order types.Order, ticker types.Ticker) bool {
+	if order.Side == types.OrderSideBuy {
+		ask := ticker.Ask
+		if ask <= 0 {
+			ask = ticker.Price
+		}
+		return ask > 0 && ask <= order.Price
+	}
+	bid := ticker.Bid
+	if bid <= 0 {
+		bid = ticker.Price
+	}
+	return bid > 0 && bid >= order.Price
+}
+
+func (s *shadowExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	order.ID = fmt.Sprintf("shadow-%d", s.nextID)
+	order.Timestamp = time.Now()
+
+	ticker, quoted := s.quotes[order.Symbol]
+	switch {
+	case order.Type != types.OrderTypeLimit:
+		price := order.Price
+		if quoted {
+			if order.Side == types.OrderSideBuy && ticker.Ask > 0 {
+				price = ticker.Ask
+			} else if order.Side == types.OrderSideSell && ticker.Bid > 0 {
+				price = ticker.Bid
+			}
+		}
+		s.fillLocked(order, price)
+	case quoted && crosses(order, ticker):
+		s.fillLocked(order, order.Price)
+	default:
+		order.Status = types.OrderStatusNew
+		s.resting = append(s.resting, order)
+	}
+	return nil
+}
+
+// fillLocked closes order as filled in full at price
+func (s *shadowExchange) fillLocked(order types.Order, price float64) {
+	order.Status, order.Timestamp = types.OrderStatusFilled, time.Now()
+	order.FilledAmount, order.FilledPrice = order.Quantity, price
+	s.fills++
+	s.closeLocked(order)
+}
+
+// filled returns how many orders this run filled
+func (s *shadowExchange) filled() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fills
+}
+
+func (s *shadowExchange) closeLocked(order types.Order) {
+	s.closed = append(s.closed, order)
+	if len(s.closed) > paperHistory {
+		s.closed = s.closed[len(s.closed)-paperHistory:]
+	}
+}
+
+func (s *shadowExchange) CancelOrder(ctx context.Context, orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, order := range s.resting {
+		if order.ID == orderID {
+			s.resting = append(s.resting[:i], s.resting[i+1:]...)
+			order.Status = types.OrderStatusCanceled
+			s.closeLocked(order)
+			return nil
+		}
+	}
+	return fmt.Errorf("shadow: no open order %s", orderID)
+}
+
+func (s *shadowExchange) GetOrder(ctx context.Context, orderID string) (*types.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, orders := range [][]types.Order{s.resting, s.closed} {
+		for _, order := range orders {
+			if order.ID == orderID {
+				return &order, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("shadow: unknown order %s", orderID)
+}
+
+// GetOrderByClientID looks the order up among the shadow's, never the live
+// account's
+func (s *shadowExchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if order, err := types.OrderByClientID(s.resting, clientOrderID); err == nil {
+		return order, nil
+	}
+	return types.OrderByClientID(s.closed, clientOrderID)
+}
+
+func (s *shadowExchange) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ordersOf(s.resting, symbol), nil
+}
+
+// GetFilledOrders returns the kept filled and canceled orders for symbol,
+// oldest first
+func (s *shadowExchange) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ordersOf(s.closed, symbol), nil
+}
+
+// GetOrderHistory pages the kept order history
+func (s *shadowExchange) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return types.PageOrders(s.closed, query)
+}
+
+// ConvertDust is not simulated; the live account's dust is not the shadow's
+func (s *shadowExchange) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	return nil, types.ErrNotSupported
+}
+
+// GetSavings is not simulated; the live account's savings are not the shadow's
+func (s *shadowExchange) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	return nil, types.ErrNotSupported
+}
+
+// SubscribeSavings is not simulated and never reaches the live account
+func (s *shadowExchange) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+// RedeemSavings is not simulated and never reaches the live account
+func (s *shadowExchange) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+// Close leaves the live client open
+func (s *shadowExchange) Close() error {
+	return nil
+}
+
+// restore puts fills journaled by earlier runs back into the order history,
+// so the candidate recovers them, and numbers new orders after them
+func (s *shadowExchange) restore(store *StateStore, bot string) error {
+	var submitted int
+	var fills []types.Order
+	err := store.Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if json.Unmarshal(line, &entry) != nil || entry.Bot != bot || !entry.Shadow {
+			return nil
+		}
+		switch {
+		case entry.Action == journalSubmit:
+			submitted++
+		case entry.Action == journalFill && entry.Execution != nil:
+			e := entry.Execution
+			fills = append(fills, types.Order{
+				ID:            entry.OrderID,
+				Symbol:        e.Symbol,
+				Side:          types.OrderSide(e.Side),
+				Type:          types.OrderType(e.Type),
+				Quantity:      e.Quantity,
+				Price:         e.ExpectedPrice,
+				Status:        types.OrderStatusFilled,
+				FilledAmount:  e.Quantity,
+				FilledPrice:   e.FilledPrice,
+				Timestamp:     e.Time,
+				ExchangeOrder: &types.ExchangeOrder{ClientOrderID: entry.ClientOrderID},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read shadow fills: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID = submitted
+	for _, order := range fills {
+		s.closeLocked(order)
+	}
+	return nil
+}
+
+func ordersOf(orders []types.Order, symbol string) []types.Order {
+	var matched []types.Order
+	for _, order := range orders {
+		if order.Symbol == symbol {
+			matched = append(matched, order)
+		}
+	}
+	return matched
+}
+
+// Decisions ending an A/B test
+const (
+	shadowPromoted   = "promoted"    // B trades the whole quote balance, A stopped
+	shadowRolledBack = "rolled_back" // B stopped
+)
+
+// Reasons a promotion or rollback is refused
+var (
+	errShadowDecided  = errors.New("shadow test already decided")
+	errNotSignificant = errors.New("variant B is not significantly better")
+	errNotABTest      = errors.New("promotion needs an A/B test with an allocation")
+)
+
+// Defaults of the paired return test
+const (
+	defaultShadowInterval = 24 * time.Hour
+	defaultShadowAlpha    = 0.05
+)
+
+// shadowState is a shadow's progress, saved as <id>-shadow.json
+type shadowState struct {
+	Bot      string    `json:"bot"` // live bot the shadow is compared with
+	Symbol   string    `json:"symbol"`
+	Started  time.Time `json:"started"`
+	Days     int       `json:"days"`
+	Reported time.Time `json:"reported,omitempty"`
+
+	Allocation float64       `json:"allocation,omitempty"` // B's share of the capital; 0 in shadow mode
+	Capital    float64       `json:"capital,omitempty"`    // quote balance when the test started
+	Interval   time.Duration `json:"interval"`
+	Alpha      float64       `json:"alpha"`
+	Samples    []pnlSample   `json:"samples,omitempty"` // PnL of A and B every interval, from the start
+	Decision   string        `json:"decision,omitempty"`
+	Decided    time.Time     `json:"decided,omitempty"`
+}
+
+// pnlSample is the PnL of both variants since the start at one time
+type pnlSample struct {
+	Time    time.Time `json:"time"`
+	Live    float64   `json:"live"`
+	Variant float64   `json:"variant"`
+}
+
+// capital returns the quote capital of variant A and B. In shadow mode
+// both trade the whole balance.
+func (s shadowState) capital() (float64, float64) {
+	if s.Allocation <= 0 {
+		return s.Capital, s.Capital
+	}
+	return s.Capital * (1 - s.Allocation), s.Capital * s.Allocation
+}
+
+// pairedReturns returns each interval's PnL change of A and B over their
+// capital
+func (s shadowState) pairedReturns() ([]float64, []float64) {
+	capitalA, capitalB := s.capital()
+	ret := func(change, capital float64) float64 {
+		if capital > 0 {
+			return change / capital
+		}
+		return change
+	}
+	var a, b []float64
+	for i := 1; i < len(s.Samples); i++ {
+		a = append(a, ret(s.Samples[i].Live-s.Samples[i-1].Live, capitalA))
+		b = append(b, ret(s.Samples[i].Variant-s.Samples[i-1].Variant, capitalB))
+	}
+	return a, b
+}
+
+// shadowRun is a candidate strategy, variant B, fed the live bot's market
+// data. In shadow mode it trades a shadowExchange; in an A/B test it trades
+// live within a capital account.
+type shadowRun struct {
+	id         string
+	strat      strategy.Strategy
+	exchange   *shadowExchange             // nil in an A/B test
+	executions *ExecutionLog               // attributes shadow fills; nil in an A/B test
+	allocator  *portfolio.CapitalAllocator // B's capital account; nil in shadow mode
+	store      *StateStore
+	logger     *logger.Logger
+
+	attributed int // exchange fills journaled so far
+
+	mu    sync.Mutex
+	state shadowState
+	mark  float64 // last market price
+}
+
+// startShadow builds the bot's candidate and recovers it from the journal.
+// The test starts with the first run and spans restarts; changing the
+// allocation starts a new one.
+func startShadow(ctx context.Context, c *Container, spec BotSpec) (*shadowRun, error) {
+	store, log := c.StateStore(), c.Logger()
+	shadow := spec.Shadow
+	if store == nil {
+		return nil, fmt.Errorf("shadow mode needs a state dir")
+	}
+	if shadow.ID == "" || shadow.ID == spec.ID {
+		return nil, fmt.Errorf("shadow id must be set and differ from the bot's")
+	}
+
+	state, err := loadShadowState(ctx, c, spec)
+	if err != nil {
+		return nil, err
+	}
+	run := &shadowRun{id: shadow.ID, store: store, logger: log, state: state}
+
+	var client types.ExchangeClient
+	if state.Allocation > 0 {
+		// B's live orders are checked against and booked to its share
+		run.allocator = portfolio.NewCapitalAllocator(log)
+		_, capital := state.capital()
+		if state.Decision == shadowPromoted {
+			capital = state.Capital
+		}
+		if err := run.allocator.Allocate(shadow.ID, capital); err != nil {
+			return nil, fmt.Errorf("shadow allocation: %w", err)
+		}
+		submissions, err := readSubmissions(store, shadow.ID, spec.Symbol, log)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range submissions {
+			if s.answered && !s.failed {
+				run.allocator.Reserve(shadow.ID, s.order)
+			}
+		}
+		orders := c.orderClient(shadow.ID)
+		if err := run.allocator.Reconcile(ctx, shadow.ID, orders); err != nil {
+			log.Warn("Shadow %s capital account not reconciled: %v", shadow.ID, err)
+		}
+		client = run.allocator.Client(shadow.ID, orders)
+	} else {
+		run.exchange = newShadowExchange(c.Exchange())
+		if err := run.exchange.restore(store, shadow.ID); err != nil {
+			return nil, err
+		}
+		run.executions = &ExecutionLog{store: store, exchange: run.exchange, name: "shadow", logger: log, shadow: true}
+		journal := newJournalClient(run.exchange, store, log)
+		journal.shadow = true
+		journal.setBot(shadow.ID)
+
+		// The candidate is sized and blacked out as the live strategy is
+		client = journal
+		if deleverager := c.Deleverager(); deleverager != nil {
+			client = deleverager.Client(client)
+		}
+		if cal := c.Calendar(); cal != nil {
+			client = cal.Client(client)
+		}
+	}
+
+	run.strat, err = shadow.Build(c, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shadow strategy: %w", err)
+	}
+	if err := run.strat.ValidateConfig(); err != nil {
+		return nil, fmt.Errorf("shadow strategy config validation error: %w", err)
+	}
+	history := client
+	if run.exchange != nil {
+		history = run.exchange
+	}
+	if err := recoverStrategy(ctx, store, history, shadow.ID, spec.Symbol, run.strat, log); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// loadShadowState resumes the saved test of the same bot, symbol and
+// allocation, or starts a new one with the quote balance as its capital
+func loadShadowState(ctx context.Context, c *Container, spec BotSpec) (shadowState, error) {
+	store, shadow := c.StateStore(), spec.Shadow
+	state := shadowState{
+		Bot:        spec.ID,
+		Symbol:     spec.Symbol,
+		Started:    time.Now().UTC(),
+		Days:       shadow.Days,
+		Allocation: shadow.Allocation,
+		Interval:   shadow.Interval,
+		Alpha:      shadow.Alpha,
+	}
+	if state.Days <= 0 {
+		state.Days = defaultShadowDays
+	}
+	if state.Interval <= 0 {
+		state.Interval = defaultShadowInterval
+	}
+	if state.Alpha <= 0 {
+		state.Alpha = defaultShadowAlpha
+	}
+
+	var saved shadowState
+	found, err := store.Load(shadow.ID+"-shadow", &saved)
+	if err != nil {
+		return state, err
+	}
+	if found && saved.Bot == spec.ID && saved.Symbol == spec.Symbol && saved.Allocation == state.Allocation {
+		state.Started, state.Reported, state.Capital = saved.Started, saved.Reported, saved.Capital
+		state.Samples, state.Decision, state.Decided = saved.Samples, saved.Decision, saved.Decided
+	} else {
+		balance, err := c.Exchange().GetBalance(ctx)
+		switch {
+		case err == nil:
+			state.Capital = balance.Free
+		case state.Allocation > 0:
+			return state, fmt.Errorf("failed to get the quote balance to allocate: %w", err)
+		default:
+			c.Logger().Warn("Shadow %s returns are not relative to capital: %v", shadow.ID, err)
+		}
+		if state.Allocation > 0 && state.Capital <= 0 {
+			return state, fmt.Errorf("no quote balance to allocate to shadow %s", shadow.ID)
+		}
+		state.Samples = []pnlSample{{Time: state.Started}}
+	}
+	return state, store.Save(shadow.ID+"-shadow", state)
+}
+
+// tick executes the candidate on the market data the live strategy got,
+// samples both variants' PnL every interval and writes the report once the
+// test period is over. A rolled back candidate no longer trades.
+func (r *shadowRun) tick(ctx context.Context, market types.MarketData) {
+	r.mu.Lock()
+	r.mark = market.Price
+	decision := r.state.Decision
+	r.mu.Unlock()
+	if decision == shadowRolledBack {
+		return
+	}
+
+	if r.exchange != nil {
+		r.exchange.quote(market)
+	}
+	if err := r.strat.Execute(ctx, market); err != nil {
+		r.logger.Warn("Shadow %s execution error: %v", r.id, err)
+	}
+	if r.exchange != nil {
+		if filled := r.exchange.filled(); filled > r.attributed {
+			if _, err := r.executions.Attribute(ctx, r.id, market.Symbol); err != nil {
+				r.logger.Warn("Failed to attribute shadow %s fills: %v", r.id, err)
+			} else {
+				r.attributed = filled
+			}
+		}
+	}
+	if err := saveSnapshot(r.store, r.id, r.strat); err != nil {
+		r.logger.Error("Failed to save shadow state: %v", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.sampleLocked(time.Now().UTC()); err != nil {
+		r.logger.Error("Failed to sample shadow %s PnL: %v", r.id, err)
+	}
+	if !r.state.Reported.IsZero() || time.Since(r.state.Started) < time.Duration(r.state.Days)*24*time.Hour {
+		return
+	}
+	report, err := r.saveReportLocked()
+	if err != nil {
+		r.logger.Error("Failed to save shadow report: %v", err)
+		return
+	}
+	r.logger.Info("Shadow %s after %.1f days: PnL %.2f vs live %.2f (%+.2f), %d vs %d fill(s), p-value %.3f; report saved as %s-report.json",
+		r.id, report.Days, report.Variant.PnL, report.Live.PnL, report.PnLDelta, report.Variant.Fills, report.Live.Fills, report.Test.PValue, r.id)
+}
+
+// sampleLocked records both variants' PnL once an interval has passed
+// since the last sample
+func (r *shadowRun) sampleLocked(now time.Time) error {
+	if n := len(r.state.Samples); n > 0 && now.Sub(r.state.Samples[n-1].Time) < r.state.Interval {
+		return nil
+	}
+	report, err := shadowReport(r.store, r.id, r.state, r.mark)
+	if err != nil {
+		return err
+	}
+	r.state.Samples = append(r.state.Samples, pnlSample{Time: now, Live: report.Live.PnL, Variant: report.Variant.PnL})
+	return r.store.Save(r.id+"-shadow", r.state)
+}
+
+// saveReportLocked writes the comparison so far as <id>-report.json
+func (r *shadowRun) saveReportLocked() (*ShadowReport, error) {
+	report, err := shadowReport(r.store, r.id, r.state, r.mark)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.Save(r.id+"-report", report); err != nil {
+		return nil, err
+	}
+	r.state.Reported = report.To
+	return report, r.store.Save(r.id+"-shadow", r.state)
+}
+
+// report compares the variants so far
+func (r *shadowRun) report() (*ShadowReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return shadowReport(r.store, r.id, r.state, r.mark)
+}
+
+// promoted reports whether B replaced A
+func (r *shadowRun) promoted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.Decision == shadowPromoted
+}
+
+// promote ends an A/B test in B's favor: B's capital account grows to the
+// whole capital and A stops trading. Unless forced, B's paired returns must
+// be significantly better than A's.
+func (r *shadowRun) promote(force bool) (*ShadowReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.allocator == nil {
+		return nil, errNotABTest
+	}
+	if r.state.Decision != "" {
+		return nil, fmt.Errorf("%w: %s", errShadowDecided, r.state.Decision)
+	}
+	report, err := shadowReport(r.store, r.id, r.state, r.mark)
+	if err != nil {
+		return nil, err
+	}
+	if !force && !(report.Test.Significant && report.Test.MeanDiff > 0) {
+		return nil, fmt.Errorf("%w: p-value %.3f over %d interval(s), mean return difference %+.5f",
+			errNotSignificant, report.Test.PValue, report.Test.N, report.Test.MeanDiff)
+	}
+	if err := r.allocator.Resize(r.id, r.state.Capital); err != nil {
+		return nil, err
+	}
+	r.logger.Info("Shadow %s promoted: variant B trades %.2f, variant A stopped", r.id, r.state.Capital)
+	return r.decideLocked(shadowPromoted)
+}
+
+// rollback ends the test in A's favor: B is shut down and stops trading.
+// Positions B holds stay on the account.
+func (r *shadowRun) rollback(ctx context.Context) (*ShadowReport, error) {
+	r.mu.Lock()
+	if r.state.Decision != "" {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", errShadowDecided, r.state.Decision)
+	}
+	report, err := r.decideLocked(shadowRolledBack)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Info("Shadow %s rolled back", r.id)
+	r.shutdown(ctx)
+	return report, nil
+}
+
+// decideLocked records the decision and saves the final report
+func (r *shadowRun) decideLocked(decision string) (*ShadowReport, error) {
+	r.state.Decision, r.state.Decided = decision, time.Now().UTC()
+	return r.saveReportLocked()
+}
+
+// shutdown stops the candidate and saves its last snapshot
+func (r *shadowRun) shutdown(ctx context.Context) {
+	if err := r.strat.Shutdown(ctx); err != nil {
+		r.logger.Error("Error stopping shadow strategy: %v", err)
+	}
+	if err := saveSnapshot(r.store, r.id, r.strat); err != nil {
+		r.logger.Error("Failed to save shadow state: %v", err)
+	}
+}
+
+// variantA is the live strategy as the trading loop runs it: it stops
+// executing once the candidate is promoted
+type variantA struct {
+	strategy.Strategy
+	run *shadowRun
+}
+
+func (v *variantA) Execute(ctx context.Context, market types.MarketData) error {
+	if v.run.promoted() {
+		return nil
+	}
+	return v.Strategy.Execute(ctx, market)
+}
+
+// ShadowReport compares variant B's fills, hypothetical in shadow mode,
+// with the live variant A's over the same period
+type ShadowReport struct {
+	Symbol     string    `json:"symbol"`
+	From       time.Time `json:"from"` // test start
+	To         time.Time `json:"to"`
+	Days       float64   `json:"days"`
+	Mark       float64   `json:"mark"`                 // price open positions are valued at
+	Allocation float64   `json:"allocation,omitempty"` // B's share of the capital in an A/B test
+	Live       ShadowLeg `json:"live"`
+	Variant    ShadowLeg `json:"variant"`
+	PnLDelta   float64   `json:"pnl_delta"` // B minus A PnL
+
+	// Test compares the variants' returns over each interval since the start
+	Test     analytics.PairedTest `json:"test"`
+	Decision string               `json:"decision,omitempty"` // promoted or rolled_back
+	Decided  time.Time            `json:"decided,omitempty"`
+}
+
+// ShadowLeg is one variant of a shadow report
+type ShadowLeg struct {
+	Bot      string  `json:"bot"`
+	Capital  float64 `json:"capital,omitempty"` // quote capital returns are relative to
+	Orders   int     `json:"orders"`            // journaled submissions
+	Fills    int     `json:"fills"`
+	Bought   float64 `json:"bought"` // base quantity
+	Spent    float64 `json:"spent"`  // quote
+	Sold     float64 `json:"sold"`
+	Proceeds float64 `json:"proceeds"`
+	Fees     float64 `json:"fees"`
+	Position float64 `json:"position"` // bought minus sold within the period
+	PnL      float64 `json:"pnl"`      // proceeds and position value, less spent and fees
+	Return   float64 `json:"return"`   // PnL over capital
+
+	// Metrics are the strategy's own, from its last saved snapshot
+	Metrics *types.StrategyMetrics `json:"metrics,omitempty"`
+}
+
+// ReadShadowReport compares shadow id with its live bot from the order
+// journal and snapshots in store. Both legs count the fills of the bot's
+// symbol since the test started; open positions are valued at mark, or
+// without one at the last fill price.
+func ReadShadowReport(store *StateStore, id string, mark float64) (*ShadowReport, error) {
+	var state shadowState
+	found, err := store.Load(id+"-shadow", &state)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no shadow %s in %s", id, store.Dir())
+	}
+	return shadowReport(store, id, state, mark)
+}
+
+func shadowReport(store *StateStore, id string, state shadowState, mark float64) (*ShadowReport, error) {
+	capitalA, capitalB := state.capital()
+	report := &ShadowReport{
+		Symbol:     state.Symbol,
+		From:       state.Started,
+		To:         time.Now().UTC(),
+		Allocation: state.Allocation,
+		Live:       ShadowLeg{Bot: state.Bot, Capital: capitalA},
+		Variant:    ShadowLeg{Bot: id, Capital: capitalB},
+		Decision:   state.Decision,
+		Decided:    state.Decided,
+	}
+	var lastFill time.Time
+	err := store.Scan(journalName, func(line []byte) error {
+		var entry JournalEntry
+		if json.Unmarshal(line, &entry) != nil || entry.Time.Before(state.Started) {
+			return nil
+		}
+		var leg *ShadowLeg
+		switch entry.Bot {
+		case state.Bot:
+			leg = &report.Live
+		case id:
+			leg = &report.Variant
+		default:
+			return nil
+		}
+		switch {
+		case entry.Action == journalSubmit && entry.Order != nil && entry.Order.Symbol == state.Symbol:
+			leg.Orders++
+		case entry.Action == journalFill && entry.Execution != nil && entry.Execution.Symbol == state.Symbol:
+			e := entry.Execution
+			leg.Fills++
+			leg.Fees += e.Fee
+			if e.Side == string(types.OrderSideBuy) {
+				leg.Bought += e.Quantity
+				leg.Spent += e.Notional()
+			} else {
+				leg.Sold += e.Quantity
+				leg.Proceeds += e.Notional()
+			}
+			if !e.Time.Before(lastFill) && mark <= 0 {
+				lastFill, report.Mark = e.Time, e.FilledPrice
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if mark > 0 {
+		report.Mark = mark
+	}
+
+	for _, leg := range []*ShadowLeg{&report.Live, &report.Variant} {
+		leg.Position = leg.Bought - leg.Sold
+		leg.PnL = leg.Proceeds + leg.Position*report.Mark - leg.Spent - leg.Fees
+		if leg.Capital > 0 {
+			leg.Return = leg.PnL / leg.Capital
+		}
+		var snapshot BotSnapshot
+		found, err := store.Load(leg.Bot+"-state", &snapshot)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			leg.Metrics = &snapshot.Metrics
+		}
+	}
+	report.Days = report.To.Sub(report.From).Hours() / 24
+	report.PnLDelta = report.Variant.PnL - report.Live.PnL
+	a, b := state.pairedReturns()
+	report.Test = analytics.PairedTTest(a, b, state.Alpha)
+	return report, nil
+}
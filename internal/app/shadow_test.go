@@ -0,0 +1,210 @@
+package app
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestShadow_JournalsWithoutSending(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{Name: "test", ReportingCurrency: "USD", StateDir: t.TempDir()},
+		Logging: config.LoggingConfig{Level: "error"},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidate := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 200, Interval: time.Hour, MaxInvestments: 5, Enabled: true}
+	spec := BotSpec{ID: "dca", Symbol: "BTCUSDT", Shadow: &ShadowSpec{
+		ID: "dca-shadow",
+		Build: func(c *Container, exchange types.ExchangeClient) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateDCA(candidate, exchange)
+		},
+	}}
+	ctx := context.Background()
+	shadow, err := startShadow(ctx, c, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticker := &types.Ticker{Symbol: "BTCUSDT", Price: 40000, Bid: 39999, Ask: 40001, Timestamp: time.Now()}
+	shadow.tick(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 40000, Timestamp: ticker.Timestamp, Ticker: ticker})
+
+	// The paper exchange behind the live client never saw the order
+	if sent, _ := c.paperExchange.GetFilledOrders(ctx, "BTCUSDT"); len(sent) != 0 {
+		t.Fatalf("shadow order reached the exchange: %+v", sent)
+	}
+	executions, err := ReadExecutions(c.StateStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 0 {
+		t.Errorf("shadow fills count as live executions: %+v", executions)
+	}
+
+	// A live fill over the same period
+	live := analytics.Execution{Time: time.Now().UTC(), Bot: "dca", Symbol: "BTCUSDT", Side: "BUY", Type: "MARKET", Quantity: 0.0025, ExpectedPrice: 40000, FilledPrice: 40000}
+	if err := c.StateStore().Append(journalName, JournalEntry{Time: time.Now(), Bot: "dca", Action: journalFill, ClientOrderID: "live-1", Execution: &live}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ReadShadowReport(c.StateStore(), "dca-shadow", 42000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Variant.Orders != 1 || report.Variant.Fills != 1 || report.Live.Fills != 1 {
+		t.Fatalf("report counted shadow %d orders %d fills, live %d fills; want 1, 1, 1", report.Variant.Orders, report.Variant.Fills, report.Live.Fills)
+	}
+	if want := 200.0 / 40000; math.Abs(report.Variant.Bought-want) > 1e-9 || report.Variant.Spent <= 200 {
+		t.Errorf("shadow bought %.8f for %.2f, want %.8f at the ask", report.Variant.Bought, report.Variant.Spent, want)
+	}
+	if want := 0.0025*42000 - 100; math.Abs(report.Live.PnL-want) > 1e-9 {
+		t.Errorf("live PnL = %.4f, want %.4f", report.Live.PnL, want)
+	}
+	if math.Abs(report.PnLDelta-(report.Variant.PnL-report.Live.PnL)) > 1e-9 || report.Variant.Metrics == nil {
+		t.Errorf("report = %+v", report)
+	}
+
+	// A restart keeps the shadow period and recovers the candidate's buy
+	restarted, err := startShadow(ctx, c, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restarted.state.Started.Equal(shadow.state.Started) {
+		t.Errorf("restart moved the shadow start from %v to %v", shadow.state.Started, restarted.state.Started)
+	}
+	if got := restarted.strat.GetMetrics().TotalVolume; got <= 0 {
+		t.Errorf("recovered candidate volume = %v, want its journaled buy", got)
+	}
+}
+
+func TestShadowExchange_RestingLimit(t *testing.T) {
+	ctx := context.Background()
+	s := newShadowExchange(NewPaperExchange(nil, 0))
+	quote := func(bid, ask float64) {
+		s.quote(types.MarketData{Symbol: "BTCUSDT", Price: (bid + ask) / 2, Ticker: &types.Ticker{Symbol: "BTCUSDT", Bid: bid, Ask: ask}})
+	}
+	quote(100, 101)
+
+	order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeLimit, Quantity: 1, Price: 100}
+	if err := s.PlaceOrder(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := s.GetActiveOrders(ctx, "BTCUSDT"); len(active) != 1 {
+		t.Fatalf("limit at the bid should rest, %d open", len(active))
+	}
+
+	quote(99, 100)
+	filled, _ := s.GetFilledOrders(ctx, "BTCUSDT")
+	if len(filled) != 1 || filled[0].Status != types.OrderStatusFilled || filled[0].FilledPrice != 100 {
+		t.Fatalf("filled = %+v, want the limit filled at 100", filled)
+	}
+	if active, _ := s.GetActiveOrders(ctx, "BTCUSDT"); len(active) != 0 {
+		t.Errorf("%d orders still open", len(active))
+	}
+}
+
+func TestShadow_ABTestPromote(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{Name: "test", ReportingCurrency: "USD", StateDir: t.TempDir(), APIToken: "secret"},
+		Logging: config.LoggingConfig{Level: "error"},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dca := func(amount float64) types.DCAConfig {
+		return types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: amount, Interval: time.Hour, MaxInvestments: 5, Enabled: true}
+	}
+	spec := BotSpec{ID: "dca", Symbol: "BTCUSDT", Shadow: &ShadowSpec{
+		ID:         "dca-b",
+		Allocation: 0.1,
+		Build: func(c *Container, exchange types.ExchangeClient) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateDCA(dca(200), exchange)
+		},
+	}}
+	ctx := context.Background()
+	shadow, err := startShadow(ctx, c, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.shadow = shadow
+
+	// B trades live, journaled under its own id, within 10% of the paper balance
+	shadow.tick(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 40000, Timestamp: time.Now()})
+	sent, _ := c.paperExchange.GetFilledOrders(ctx, "BTCUSDT")
+	if len(sent) != 1 {
+		t.Fatalf("B placed %d live orders, want 1", len(sent))
+	}
+	submissions, err := readSubmissions(c.StateStore(), "dca-b", "BTCUSDT", c.Logger())
+	if err != nil || len(submissions) != 1 {
+		t.Fatalf("journaled %d submissions of B (%v), want 1", len(submissions), err)
+	}
+	if account, _ := shadow.allocator.Account("dca-b"); account.Allocated != 1000 || math.Abs(account.Available-800) > 1e-6 {
+		t.Errorf("B's capital account = %+v, want 800 of 1000 available", account)
+	}
+
+	// One interval later both variants' PnL is sampled
+	shadow.mu.Lock()
+	err = shadow.sampleLocked(time.Now().Add(25 * time.Hour))
+	samples := len(shadow.state.Samples)
+	shadow.mu.Unlock()
+	if err != nil || samples != 2 {
+		t.Fatalf("%d samples (%v), want the start and one interval", samples, err)
+	}
+
+	router := newRouter(c, nil, &probeState{})
+	post := func(path string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := post("/shadow/promote"); code != http.StatusConflict {
+		t.Fatalf("promote without a significant difference = %d, want 409", code)
+	}
+	if code := post("/v1/shadow/promote?force=true"); code != http.StatusOK {
+		t.Fatalf("forced promote = %d, want 200", code)
+	}
+	if account, _ := shadow.allocator.Account("dca-b"); account.Allocated != 10000 {
+		t.Errorf("promoted B's allocation = %v, want the whole 10000", account.Allocated)
+	}
+	if code := post("/shadow/rollback"); code != http.StatusConflict {
+		t.Errorf("rollback after promotion = %d, want 409", code)
+	}
+
+	// A no longer trades
+	live, err := c.StrategyFactory().CreateDCA(dca(100), c.Exchange())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &variantA{Strategy: live, run: shadow}
+	if err := a.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 40000, Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if sent, _ := c.paperExchange.GetFilledOrders(ctx, "BTCUSDT"); len(sent) != 1 {
+		t.Errorf("A placed orders after B was promoted: %d live orders", len(sent))
+	}
+
+	// The decision survives a restart
+	restarted, err := startShadow(ctx, c, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restarted.promoted() {
+		t.Error("restart forgot the promotion")
+	}
+	if account, _ := restarted.allocator.Account("dca-b"); account.Allocated != 10000 || math.Abs(account.Available-9800) > 1e-6 {
+		t.Errorf("restarted B's capital account = %+v, want 9800 of 10000 available", account)
+	}
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// StateStore persists JSON snapshots and append-only journals under a directory
+type StateStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStateStore creates the state directory if needed
+func NewStateStore(dir string) (*StateStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create state dir: %w", err)
+	}
+	return &StateStore{dir: dir}, nil
+}
+
+// Dir returns the state directory
+func (s *StateStore) Dir() string {
+	return s.dir
+}
+
+// Save atomically writes v as <name>.json
+func (s *StateStore) Save(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s state: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.dir, name+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s state: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s state: %w", name, err)
+	}
+	return nil
+}
+
+// Load reads <name>.json into v; it reports false when no snapshot exists
+func (s *StateStore) Load(name string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s state: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to decode %s state: %w", name, err)
+	}
+	return true, nil
+}
+
+// Append writes v as one JSON line to <name>.jsonl
+func (s *StateStore) Append(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s entry: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(s.dir, name+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s journal: %w", name, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append to %s journal: %w", name, err)
+	}
+	return f.Sync()
+}
+
+// Scan calls fn for every line of <name>.jsonl; a missing journal has no lines
+func (s *StateStore) Scan(name string, fn func(line []byte) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(filepath.Join(s.dir, name+".jsonl"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s journal: %w", name, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s journal: %w", name, err)
+	}
+	return nil
+}
+
+// Rewrite atomically replaces every line of <name>.jsonl with what fn
+// returns for it; a missing journal is left missing
+func (s *StateStore) Rewrite(name string, fn func(line []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.dir, name+".jsonl")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s journal: %w", name, err)
+	}
+
+	var out []byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		rewritten, err := fn(line)
+		if err != nil {
+			return err
+		}
+		out = append(append(out, rewritten...), '\n')
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o644); err != nil {
+		return fmt.Errorf("failed to rewrite %s journal: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to rewrite %s journal: %w", name, err)
+	}
+	return nil
+}
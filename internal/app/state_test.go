@@ -0,0 +1,357 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func newTestContainer(t *testing.T, stateDir string) *Container {
+	t.Helper()
+	cfg := &config.Config{
+		App:     config.AppConfig{Name: "test", ReportingCurrency: "USD", StateDir: stateDir},
+		Logging: config.LoggingConfig{Level: "error"},
+	}
+	c, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+	return c
+}
+
+func TestStateStore_SaveLoad(t *testing.T) {
+	store, err := NewStateStore(filepath.Join(t.TempDir(), "state"))
+	if err != nil {
+		t.Fatalf("NewStateStore() error = %v", err)
+	}
+
+	var got map[string]int
+	if ok, err := store.Load("missing", &got); ok || err != nil {
+		t.Fatalf("Load(missing) = %v, %v; want false, nil", ok, err)
+	}
+
+	if err := store.Save("bot", map[string]int{"trades": 3}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if ok, err := store.Load("bot", &got); !ok || err != nil {
+		t.Fatalf("Load(bot) = %v, %v", ok, err)
+	}
+	if got["trades"] != 3 {
+		t.Errorf("Expected trades 3, got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(store.Dir(), "bot.json.tmp")); !os.IsNotExist(err) {
+		t.Error("Expected temporary file to be renamed away")
+	}
+}
+
+func TestContainer_JournalsOrders(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestContainer(t, dir)
+	ctx := context.Background()
+
+	order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.01, Price: 45000,
+		Decision: &types.Decision{Strategy: "dca", StrategyID: "dca-btc", Action: types.DecisionBuy}}
+	if err := c.Exchange().PlaceOrder(ctx, order); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if err := c.Exchange().CancelOrder(ctx, "42"); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, journalName+".jsonl"))
+	if err != nil {
+		t.Fatalf("Expected order journal: %v", err)
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Invalid journal line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 journal entries, got %d", len(entries))
+	}
+	// The order is written ahead of submission, then its outcome
+	if entries[0].Action != "submit" || entries[0].Order == nil || entries[0].Order.Quantity != 0.01 || entries[0].StrategyID != "dca-btc" {
+		t.Errorf("Unexpected submit entry: %+v", entries[0])
+	}
+	// The client order id names the strategy placing it
+	if strategyID, _ := types.SplitClientOrderID(entries[0].ClientOrderID); strategyID != "dca-btc" {
+		t.Errorf("Unexpected submit entry: %+v", entries[0])
+	}
+	if entries[1].Action != "place" || entries[1].ClientOrderID != entries[0].ClientOrderID || entries[1].Error != "" {
+		t.Errorf("Unexpected place entry: %+v", entries[1])
+	}
+	if entries[2].Action != "cancel" || entries[2].OrderID != "42" {
+		t.Errorf("Unexpected cancel entry: %+v", entries[2])
+	}
+}
+
+func TestRouter_Probes(t *testing.T) {
+	c := newTestContainer(t, "")
+	probes := &probeState{}
+	router := newRouter(c, nil, probes)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if code := get("/live"); code != http.StatusOK {
+		t.Errorf("/live = %d, want 200", code)
+	}
+	if code := get("/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("/ready before start = %d, want 503", code)
+	}
+
+	probes.ready.Store(true)
+	if code := get("/ready"); code != http.StatusOK {
+		t.Errorf("/ready when running = %d, want 200", code)
+	}
+
+	// Draining bots stay alive but stop reporting ready
+	probes.ready.Store(false)
+	if code := get("/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("/ready while draining = %d, want 503", code)
+	}
+	if code := get("/live"); code != http.StatusOK {
+		t.Errorf("/live while draining = %d, want 200", code)
+	}
+
+	// A stalled trading loop degrades /health
+	if code := get("/health"); code != http.StatusOK {
+		t.Errorf("/health without a watchdog = %d, want 200", code)
+	}
+	probes.watchdog = NewWatchdog(time.Minute, c.Logger())
+	probes.watchdog.Start("dca", time.Now().Add(-2*time.Minute))
+	if code := get("/health"); code != http.StatusServiceUnavailable {
+		t.Errorf("/health with a stalled loop = %d, want 503", code)
+	}
+	probes.watchdog.Beat("dca", stageMarketData, time.Now())
+	probes.watchdog.Beat("dca", stageExecute, time.Now())
+	if code := get("/health"); code != http.StatusOK {
+		t.Errorf("/health after a heartbeat = %d, want 200", code)
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	watchdog := NewWatchdog(5*time.Minute, newTestContainer(t, "").Logger())
+	watchdog.Start("dca", start)
+	watchdog.Start("grid", start)
+
+	// The grid loop keeps fetching market data, but its strategy keeps failing
+	for i := 1; i <= 10; i++ {
+		now := start.Add(time.Duration(i) * time.Minute)
+		watchdog.Beat("grid", stageMarketData, now)
+		if i <= 3 {
+			watchdog.Beat("dca", stageMarketData, now)
+			watchdog.Beat("dca", stageExecute, now)
+		} else {
+			// Skipped ticks during maintenance keep the dca loop alive
+			watchdog.Idle("dca", now)
+		}
+	}
+
+	now := start.Add(10 * time.Minute)
+	stalled := watchdog.Stalled(now)
+	if len(stalled) != 1 || stalled[0].Loop != "grid" || stalled[0].Stage != stageExecute || stalled[0].Age != 600 {
+		t.Fatalf("Stalled() = %+v, want grid stalled in execute for 600s", stalled)
+	}
+	if status := watchdog.Status(now); len(status) != 2 || status[0].Loop != "dca" || status[0].Stalled {
+		t.Errorf("Status() = %+v, want a healthy dca loop first", status)
+	}
+
+	alerted := make(map[string]bool)
+	watchdog.check(now, alerted)
+	if !alerted["grid"] || alerted["dca"] {
+		t.Errorf("alerted = %v after a check, want grid only", alerted)
+	}
+	watchdog.Beat("grid", stageExecute, now)
+	watchdog.check(now, alerted)
+	if len(alerted) != 0 {
+		t.Errorf("alerted = %v after recovery, want none", alerted)
+	}
+}
+
+func TestRouter_StrategyTrace(t *testing.T) {
+	c := newTestContainer(t, "")
+	dca := strategy.NewDCAStrategy(types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 5, Enabled: true}, c.Exchange(), c.Logger())
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{0, time.Minute} {
+		if err := dca.Execute(context.Background(), types.MarketData{Symbol: "BTCUSDT", Price: 40000, Timestamp: start.Add(offset)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	newRouter(c, dca, &probeState{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/strategy/trace?limit=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/strategy/trace = %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Entries []strategy.TraceEntry `json:"entries"`
+		Next    string                `json:"next"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Entries) != 1 || body.Entries[0].Run != 2 || body.Entries[0].Rule != strategy.RuleInterval {
+		t.Fatalf("entries = %+v, want the second run blocked by the interval", body.Entries)
+	}
+
+	// The next page goes back to the first run
+	rec = httptest.NewRecorder()
+	newRouter(c, dca, &probeState{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/strategy/trace?limit=1&cursor="+body.Next, nil))
+	body.Entries = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Entries) != 1 || body.Entries[0].Run != 1 || body.Next != "" {
+		t.Fatalf("second page = %+v, next %q; want the first run and no more pages", body.Entries, body.Next)
+	}
+}
+
+func TestRouter_V1(t *testing.T) {
+	c := newTestContainer(t, "")
+	for _, symbol := range []string{"SOLUSDT", "BTCUSDT", "ETHUSDT"} {
+		order := types.Order{Symbol: symbol, Side: types.OrderSideBuy, Status: types.OrderStatusFilled, FilledAmount: 1, FilledPrice: 100}
+		if err := c.PortfolioManager().UpdatePosition(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := newRouter(c, nil, &probeState{})
+
+	var symbols []string
+	for cursor, pages := "", 0; pages == 0 || cursor != ""; pages++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/positions?limit=2&cursor="+cursor, nil))
+		var body struct {
+			Positions []types.Position `json:"positions"`
+			Next      string           `json:"next"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("/v1/positions = %d: %s", rec.Code, rec.Body)
+		}
+		for _, p := range body.Positions {
+			symbols = append(symbols, p.Symbol)
+		}
+		cursor = body.Next
+
+		// A position opened before the cursor does not shift the next page
+		if pages == 0 {
+			order := types.Order{Symbol: "ADAUSDT", Side: types.OrderSideBuy, Status: types.OrderStatusFilled, FilledAmount: 1, FilledPrice: 1}
+			if err := c.PortfolioManager().UpdatePosition(order); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if strings.Join(symbols, ",") != "BTCUSDT,ETHUSDT,SOLUSDT" {
+		t.Errorf("paged positions = %v, want all three by symbol, once each", symbols)
+	}
+
+	// Unversioned paths still answer, and errors share one envelope
+	for _, path := range []string{"/positions?limit=0", "/v1/positions?cursor=x", "/v1/executions"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body APIError
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == "" || body.Code == "" {
+			t.Errorf("%s = %d: %s, want an error envelope", path, rec.Code, rec.Body)
+		}
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/executions", nil))
+	if !strings.Contains(rec.Body.String(), `"code":"not_found"`) {
+		t.Errorf("/v1/executions without a state dir = %s, want code not_found", rec.Body)
+	}
+}
+
+func TestEquityRecorder_Persists(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStateStore(dir)
+	if err != nil {
+		t.Fatalf("NewStateStore() error = %v", err)
+	}
+	equity := 0.0
+	recorder, err := NewEquityRecorder(store, func() float64 { return equity }, nil)
+	if err != nil {
+		t.Fatalf("NewEquityRecorder() error = %v", err)
+	}
+	recorder.SetBenchmark("BTC", func() (float64, error) { return 50000, nil })
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := recorder.Record(day); err != nil {
+		t.Fatal(err)
+	}
+	for i, value := range []float64{1000, 1010, 990} {
+		equity = value
+		if err := recorder.Record(day.Add(time.Duration(i+1) * 24 * time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Snapshots are skipped until equity is known and reloaded after a restart
+	reloaded, err := NewEquityRecorder(store, func() float64 { return equity }, nil)
+	if err != nil {
+		t.Fatalf("NewEquityRecorder() error = %v", err)
+	}
+	if got := reloaded.History(time.Time{}, time.Time{}); len(got) != 3 || got[2].Equity != 990 || got[2].Benchmark != 50000 {
+		t.Fatalf("History() = %+v, want the three recorded snapshots", got)
+	}
+	if got := reloaded.History(day.Add(36*time.Hour), time.Time{}); len(got) != 2 {
+		t.Errorf("History(from) = %d snapshots, want 2", len(got))
+	}
+
+	// Live metrics take their drawdown from the reloaded curve
+	var m types.StrategyMetrics
+	reloaded.ApplyPerformance(&m)
+	if want := (1 - 990.0/1010) * 100; math.Abs(m.MaxDrawdown-want) > 1e-9 {
+		t.Errorf("MaxDrawdown = %v, want %v", m.MaxDrawdown, want)
+	}
+	reloaded.SetPerformanceWindow(12 * time.Hour)
+	m = types.StrategyMetrics{}
+	reloaded.ApplyPerformance(&m)
+	if m.MaxDrawdown != 0 {
+		t.Errorf("MaxDrawdown = %v over a window of one snapshot, want 0", m.MaxDrawdown)
+	}
+}
+
+func TestRouter_PrometheusMetrics(t *testing.T) {
+	c := newTestContainer(t, "")
+	dca := strategy.NewDCAStrategy(types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 5, Enabled: true}, c.Exchange(), c.Logger())
+	c.Metrics().AddCollector(botCollector(c, BotSpec{ID: "dca", Symbol: "BTCUSDT"}, dca, nil))
+	router := newRouter(c, dca, &probeState{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/prometheus", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/metrics/prometheus = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`trader_strategy_trades_total{bot="test",exchange="binance",strategy="dca",symbol="BTCUSDT"} 0`,
+		`trader_exchange_available{bot="test",exchange="binance"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %s in\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// PriceAt returns the price of symbol at a past time
+type PriceAt func(ctx context.Context, symbol string, at time.Time) (float64, error)
+
+// Statement is the portfolio re-priced as of a past time: the positions the
+// journaled fills add up to by then, valued at historical prices
+type Statement struct {
+	At            time.Time              `json:"at"`
+	Positions     []StatementPosition    `json:"positions"`
+	Value         float64                `json:"value"` // of the positions, in the quote currency
+	Cost          float64                `json:"cost"`
+	RealizedPnL   float64                `json:"realized_pnl"`
+	UnrealizedPnL float64                `json:"unrealized_pnl"`
+	Fees          float64                `json:"fees"`
+	Fills         int                    `json:"fills"`
+	Equity        *analytics.EquityPoint `json:"recorded_equity,omitempty"` // last snapshot by At, cash included
+}
+
+// StatementPosition is one symbol's position in a statement
+type StatementPosition struct {
+	Symbol        string  `json:"symbol"`
+	Quantity      float64 `json:"quantity"`
+	AvgPrice      float64 `json:"avg_price"`
+	Price         float64 `json:"price"`
+	Value         float64 `json:"value"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+}
+
+// PeriodStatement compares the portfolio at the start and end of a period,
+// e.g. a month for a statement or a year for a tax estimate
+type PeriodStatement struct {
+	Opening     *Statement `json:"opening,omitempty"` // absent when the period starts with the journal
+	Closing     Statement  `json:"closing"`
+	RealizedPnL float64    `json:"realized_pnl"`
+	Fees        float64    `json:"fees"`
+	PnL         float64    `json:"pnl"` // realized and unrealized change, net of fees
+	Fills       int        `json:"fills"`
+}
+
+// Reprice builds the statement of the executions filled by at, pricing the
+// open positions with price. equity, oldest first, supplies the recorded
+// snapshot.
+func Reprice(ctx context.Context, executions []analytics.Execution, equity []analytics.EquityPoint, at time.Time, price PriceAt) (Statement, error) {
+	st := Statement{At: at, Positions: []StatementPosition{}}
+	positions := make(map[string]*portfolio.StrategyPosition)
+	for _, e := range executions {
+		if e.Time.After(at) {
+			continue
+		}
+		p := positions[e.Symbol]
+		if p == nil {
+			p = &portfolio.StrategyPosition{Symbol: e.Symbol}
+			positions[e.Symbol] = p
+		}
+		p.Apply(types.OrderSide(e.Side), e.Quantity, e.FilledPrice)
+		st.Fees += e.Fee
+		st.Fills++
+	}
+
+	symbols := make([]string, 0, len(positions))
+	for symbol := range positions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	var errs []error
+	for _, symbol := range symbols {
+		p := positions[symbol]
+		sp := StatementPosition{Symbol: symbol, Quantity: p.Quantity, AvgPrice: p.AvgPrice, RealizedPnL: p.RealizedPnL}
+		st.RealizedPnL += p.RealizedPnL
+		if p.Quantity != 0 {
+			var err error
+			if sp.Price, err = price(ctx, symbol, at); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", symbol, err))
+			}
+			sp.Value = p.Quantity * sp.Price
+			sp.UnrealizedPnL = (sp.Price - p.AvgPrice) * p.Quantity
+			st.Value += sp.Value
+			st.Cost += p.Quantity * p.AvgPrice
+			st.UnrealizedPnL += sp.UnrealizedPnL
+		}
+		st.Positions = append(st.Positions, sp)
+	}
+
+	for i := len(equity) - 1; i >= 0; i-- {
+		if !equity[i].Time.After(at) {
+			point := equity[i]
+			st.Equity = &point
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return st, fmt.Errorf("failed to price positions at %s: %w", at.Format(time.RFC3339), errors.Join(errs...))
+	}
+	return st, nil
+}
+
+// RepricePeriod builds the statements at from and to and the PnL between
+// them; a zero from starts with the journal
+func RepricePeriod(ctx context.Context, executions []analytics.Execution, equity []analytics.EquityPoint, from, to time.Time, price PriceAt) (*PeriodStatement, error) {
+	if !from.IsZero() && !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	closing, err := Reprice(ctx, executions, equity, to, price)
+	if err != nil {
+		return nil, err
+	}
+	ps := &PeriodStatement{Closing: closing}
+	opening := Statement{}
+	if !from.IsZero() {
+		if opening, err = Reprice(ctx, executions, equity, from, price); err != nil {
+			return nil, err
+		}
+		ps.Opening = &opening
+	}
+	ps.RealizedPnL = closing.RealizedPnL - opening.RealizedPnL
+	ps.Fees = closing.Fees - opening.Fees
+	ps.Fills = closing.Fills - opening.Fills
+	ps.PnL = ps.RealizedPnL + closing.UnrealizedPnL - opening.UnrealizedPnL - ps.Fees
+	return ps, nil
+}
+
+// candleRanger is implemented by clients that page candles by time
+type candleRanger interface {
+	GetCandlesRange(ctx context.Context, symbol string, interval string, start, end time.Time) ([]types.Candle, error)
+}
+
+// historicalPrices prices symbols at past times by the close of the last
+// candle ending by then: hourly within the last 1000 hours, daily before.
+// Clients without time ranges serve the most recent 1000 candles.
+func historicalPrices(exchange types.ExchangeClient) PriceAt {
+	return func(ctx context.Context, symbol string, at time.Time) (float64, error) {
+		interval, bar := "1h", time.Hour
+		if time.Since(at) > 1000*time.Hour {
+			interval, bar = "1d", 24*time.Hour
+		}
+		var candles []types.Candle
+		var err error
+		if ranger, ok := exchange.(candleRanger); ok {
+			candles, err = ranger.GetCandlesRange(ctx, symbol, interval, at.Add(-2*bar), at)
+		} else {
+			candles, err = exchange.GetCandles(ctx, symbol, interval, 1000)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get candles: %w", err)
+		}
+		return closeAt(candles, bar, at)
+	}
+}
+
+// closeAt is the close of the last of candles, oldest first, ending by at
+func closeAt(candles []types.Candle, bar time.Duration, at time.Time) (float64, error) {
+	for i := len(candles) - 1; i >= 0; i-- {
+		if !candles[i].Timestamp.Add(bar).After(at) {
+			return candles[i].Close, nil
+		}
+	}
+	return 0, fmt.Errorf("no candle ends by %s", at.Format(time.RFC3339))
+}
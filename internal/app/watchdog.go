@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+)
+
+// Trading loop stages the watchdog expects to succeed every tick
+const (
+	stageMarketData = "market_data"
+	stageExecute    = "execute"
+)
+
+// LoopStatus is the heartbeat of one trading loop
+type LoopStatus struct {
+	Loop           string    `json:"loop"`
+	Started        time.Time `json:"started"`
+	LastMarketData time.Time `json:"last_market_data"` // last successful fetch, zero before the first
+	LastExecute    time.Time `json:"last_execute"`     // last Execute without an error
+	Stalled        bool      `json:"stalled"`
+	Stage          string    `json:"stage,omitempty"` // the stage that stalled
+	Age            float64   `json:"age_seconds"`     // since the oldest stage last succeeded
+}
+
+// Watchdog tracks the last successful market data fetch and strategy
+// execution of each trading loop and reports loops where either has not
+// succeeded for longer than the threshold. A loop that hangs, or keeps
+// failing without crashing, otherwise looks healthy from outside.
+type Watchdog struct {
+	threshold time.Duration
+	logger    *logger.Logger
+
+	mu    sync.Mutex
+	loops map[string]*LoopStatus
+}
+
+// NewWatchdog creates a watchdog that considers a loop stalled after threshold
+func NewWatchdog(threshold time.Duration, log *logger.Logger) *Watchdog {
+	return &Watchdog{threshold: threshold, logger: log, loops: make(map[string]*LoopStatus)}
+}
+
+// Start begins tracking loop; its stages count from now
+func (w *Watchdog) Start(loop string, now time.Time) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.loops[loop] = &LoopStatus{Loop: loop, Started: now}
+}
+
+// Beat records a successful stage of loop
+func (w *Watchdog) Beat(loop, stage string, now time.Time) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status, ok := w.loops[loop]
+	if !ok {
+		status = &LoopStatus{Loop: loop, Started: now}
+		w.loops[loop] = status
+	}
+	switch stage {
+	case stageMarketData:
+		status.LastMarketData = now
+	case stageExecute:
+		status.LastExecute = now
+	}
+}
+
+// Idle records a tick loop skipped on purpose, e.g. during exchange
+// maintenance; the loop is alive, so every stage counts as successful
+func (w *Watchdog) Idle(loop string, now time.Time) {
+	w.Beat(loop, stageMarketData, now)
+	w.Beat(loop, stageExecute, now)
+}
+
+// Status returns every loop's heartbeat as of now, sorted by loop
+func (w *Watchdog) Status(now time.Time) []LoopStatus {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	statuses := make([]LoopStatus, 0, len(w.loops))
+	for _, status := range w.loops {
+		statuses = append(statuses, w.evaluate(*status, now))
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Loop < statuses[j].Loop })
+	return statuses
+}
+
+// Stalled returns the loops stalled as of now
+func (w *Watchdog) Stalled(now time.Time) []LoopStatus {
+	var stalled []LoopStatus
+	for _, status := range w.Status(now) {
+		if status.Stalled {
+			stalled = append(stalled, status)
+		}
+	}
+	return stalled
+}
+
+// evaluate fills the age and stall of status; a stage that never succeeded
+// counts from the loop's start
+func (w *Watchdog) evaluate(status LoopStatus, now time.Time) LoopStatus {
+	oldest, stage := status.LastMarketData, stageMarketData
+	if status.LastExecute.Before(oldest) {
+		oldest, stage = status.LastExecute, stageExecute
+	}
+	if oldest.IsZero() {
+		oldest = status.Started
+	}
+	age := now.Sub(oldest)
+	status.Age = age.Seconds()
+	status.Stalled = w.threshold > 0 && age > w.threshold
+	if status.Stalled {
+		status.Stage = stage
+	}
+	return status
+}
+
+// Run checks the loops every interval until ctx is done, logging an alert
+// when a loop stalls and again when it recovers
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	alerted := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.check(now, alerted)
+		}
+	}
+}
+
+// check logs loops that stalled or recovered since the last check
+func (w *Watchdog) check(now time.Time, alerted map[string]bool) {
+	for _, status := range w.Status(now) {
+		switch {
+		case status.Stalled && !alerted[status.Loop]:
+			w.logger.Error("Watchdog: %s loop stalled, no successful %s for %s (threshold %s)",
+				status.Loop, status.Stage, time.Duration(status.Age*float64(time.Second)).Round(time.Second), w.threshold)
+			alerted[status.Loop] = true
+		case !status.Stalled && alerted[status.Loop]:
+			w.logger.Info("Watchdog: %s loop recovered", status.Loop)
+			delete(alerted, status.Loop)
+		}
+	}
+}
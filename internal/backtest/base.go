@@ -0,0 +1,65 @@
+package backtest
+
+import (
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// BaseMetrics is a backtest's performance measured in units of the traded
+// base asset instead of the quote currency. A positive total return means
+// the strategy ended with more of the asset than buying it at the start
+// would have, i.e. it beat holding.
+type BaseMetrics struct {
+	Asset            string  `json:"asset"`
+	TotalReturn      float64 `json:"total_return"`      // %
+	AnnualizedReturn float64 `json:"annualized_return"` // %
+	MaxDrawdown      float64 `json:"max_drawdown"`      // %
+	SharpeRatio      float64 `json:"sharpe_ratio"`
+	HoldReturn       float64 `json:"hold_return"` // % return of holding the asset, in the quote currency
+}
+
+// SetBaseMetrics also measures DCA, grid and strategy backtests in the base
+// asset of their symbol
+func (e *Engine) SetBaseMetrics(on bool) { e.baseMetrics = on }
+
+// baseAsset names the asset of symbol equity is converted into
+func baseAsset(symbol string) string {
+	if base, _, ok := types.SplitSymbol(symbol); ok {
+		return base
+	}
+	return symbol
+}
+
+// withBase adds base asset metrics to m when enabled. equity holds one value
+// per candle of window.
+func (e *Engine) withBase(m PerformanceMetrics, symbol string, equity []float64, window []Candle, period time.Duration) PerformanceMetrics {
+	if !e.baseMetrics || len(equity) == 0 || len(equity) != len(window) {
+		return m
+	}
+	units := make([]float64, len(equity))
+	for i, c := range window {
+		if c.Close <= 0 {
+			return m
+		}
+		units[i] = equity[i] / c.Close
+	}
+	m.Base = baseMetrics(computePerformance(units, period, 0, 0, 0), symbol, window[0].Close, window[len(window)-1].Close)
+	return m
+}
+
+// baseMetrics takes the return and risk figures of performance computed on
+// base asset units
+func baseMetrics(p PerformanceMetrics, symbol string, firstClose, lastClose float64) *BaseMetrics {
+	base := &BaseMetrics{
+		Asset:            baseAsset(symbol),
+		TotalReturn:      p.TotalReturn,
+		AnnualizedReturn: p.AnnualizedReturn,
+		MaxDrawdown:      p.MaxDrawdown,
+		SharpeRatio:      p.SharpeRatio,
+	}
+	if firstClose > 0 {
+		base.HoldReturn = (lastClose/firstClose - 1) * 100
+	}
+	return base
+}
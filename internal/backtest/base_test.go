@@ -0,0 +1,51 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestBaseMetrics(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []Candle
+	for i := 0; i < 10; i++ {
+		candles = append(candles, Candle{Time: day.Add(time.Duration(i) * 24 * time.Hour), Close: 100 + 10*float64(i)})
+	}
+	start, end := candles[0].Time, candles[len(candles)-1].Time
+	cfg := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 100}
+
+	eng := NewEngine(0)
+	if m := eng.BacktestDCA("BTCUSDT", candles, start, end, cfg, 1000); m.Base != nil {
+		t.Fatalf("base metrics without SetBaseMetrics: %+v", m.Base)
+	}
+
+	eng.SetBaseMetrics(true)
+	m := eng.BacktestDCA("BTCUSDT", candles, start, end, cfg, 1000)
+	if m.Base == nil || m.Base.Asset != "BTC" {
+		t.Fatalf("Base = %+v, want BTC metrics", m.Base)
+	}
+	// Buying 100 a day into a rally ends with less BTC than buying it all on day one
+	if math.Abs(m.Base.HoldReturn-90) > 1e-9 || m.Base.TotalReturn >= 0 || m.TotalReturn <= 0 {
+		t.Errorf("USD return %.2f%%, BTC return %.2f%%, hold %.2f%%; want a USD gain that lags holding", m.TotalReturn, m.Base.TotalReturn, m.Base.HoldReturn)
+	}
+	// Fully invested by the last buy, the BTC balance no longer changes
+	want := 0.0
+	for _, c := range candles {
+		want += 100 / c.Close
+	}
+	if got := (1 + m.Base.TotalReturn/100) * 1000 / 100; math.Abs(got-want) > 1e-9 {
+		t.Errorf("ending BTC = %.8f, want %.8f", got, want)
+	}
+
+	// Streamed runs report the same figures
+	cmp, err := eng.StreamCompare(&sliceIterator{candles: candles}, start, end, 1000, cfg, types.GridConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp.DCAResults.Base == nil || math.Abs(cmp.DCAResults.Base.TotalReturn-m.Base.TotalReturn) > 1e-9 {
+		t.Errorf("streamed Base = %+v, want %+v", cmp.DCAResults.Base, m.Base)
+	}
+}
@@ -0,0 +1,72 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// benchCandles is a year of hourly sideways candles around 40000
+func benchCandles(b *testing.B) []Candle {
+	b.Helper()
+	candles, err := GenerateSynthetic(ScenarioConfig(SIDEWAYS_MARKET, 24*365, 42))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return candles
+}
+
+func benchConfigs(candles []Candle) (types.DCAConfig, types.GridConfig) {
+	price := candles[0].Close
+	return types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 365, Enabled: true},
+		types.GridConfig{Symbol: "BTCUSDT", LowerPrice: price * 0.8, UpperPrice: price * 1.2, GridLevels: 40, InvestmentPerLevel: 100, Enabled: true}
+}
+
+func BenchmarkEngine_BacktestDCA(b *testing.B) {
+	candles := benchCandles(b)
+	dca, _ := benchConfigs(candles)
+	eng := NewEngine(0.001)
+	start, end := candles[0].Time, candles[len(candles)-1].Time
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		eng.BacktestDCA("BTCUSDT", candles, start, end, dca, 100000)
+	}
+}
+
+func BenchmarkEngine_BacktestGrid(b *testing.B) {
+	candles := benchCandles(b)
+	_, grid := benchConfigs(candles)
+	eng := NewEngine(0.001)
+	start, end := candles[0].Time, candles[len(candles)-1].Time
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		eng.BacktestGrid("BTCUSDT", candles, start, end, grid, 100000)
+	}
+}
+
+func BenchmarkEngine_BacktestBreakout(b *testing.B) {
+	candles := benchCandles(b)
+	cfg := types.BreakoutConfig{Symbol: "BTCUSDT", InvestmentAmount: 1000, Interval: "1h", ATRMultiplier: 3, Enabled: true}
+	eng := NewEngine(0.001)
+	start, end := candles[0].Time, candles[len(candles)-1].Time
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := eng.BacktestBreakout("BTCUSDT", candles, start, end, cfg, 100000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEngine_CompareStrategies(b *testing.B) {
+	candles := benchCandles(b)
+	dca, grid := benchConfigs(candles)
+	eng := NewEngine(0.001)
+	start, end := candles[0].Time, candles[len(candles)-1].Time
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := eng.CompareStrategies("BTCUSDT", candles, start, end, 100000, dca, grid); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package backtest
+
+import (
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// BacktestBreakout replays candles through the live breakout strategy. The
+// channel periods count the backtest's bars whatever the configured interval.
+func (e *Engine) BacktestBreakout(symbol string, candles []Candle, start, end time.Time, cfg types.BreakoutConfig, initialBalance float64) (PerformanceMetrics, error) {
+	if cfg.Symbol == "" {
+		cfg.Symbol = symbol
+	}
+	return e.BacktestStrategy(symbol, candles, start, end, BreakoutBuilder(cfg), initialBalance)
+}
+
+// BreakoutRunner adapts BacktestBreakout to a StrategyRunner
+func (e *Engine) BreakoutRunner(symbol string, cfg types.BreakoutConfig) StrategyRunner {
+	return func(candles []Candle, start, end time.Time, initialBalance float64) (PerformanceMetrics, error) {
+		return e.BacktestBreakout(symbol, candles, start, end, cfg, initialBalance)
+	}
+}
+
+// BreakoutBuilder builds the live breakout strategy on a simulated exchange.
+// It always trades, and a throttle counts orders in candle time.
+func BreakoutBuilder(cfg types.BreakoutConfig) StrategyBuilder {
+	return func(exchange types.ExchangeClient) (strategy.Strategy, error) {
+		// Enabled pauses a live bot; a backtest always trades
+		cfg.Enabled = true
+		built, err := strategy.NewFactory(logger.New(logger.LevelError)).CreateBreakout(cfg, exchange)
+		if err != nil {
+			return nil, err
+		}
+		breakout, ok := built.(*strategy.BreakoutStrategy)
+		sim, simulated := exchange.(*simExchange)
+		if ok && simulated && breakout.Throttle() != nil {
+			breakout.Throttle().SetClock(func() time.Time { return sim.current().Time })
+		}
+		return built, nil
+	}
+}
@@ -11,6 +11,10 @@ type StrategyComparison struct {
 	GridResults PerformanceMetrics `json:"grid_results"`
 	Period      time.Duration      `json:"backtest_period"`
 	MarketType  MarketCondition    `json:"market_condition"`
+	Regimes     *RegimeComparison  `json:"regimes,omitempty"`
+
+	// BreakoutResults is a channel breakout over the same candles, when requested
+	BreakoutResults *PerformanceMetrics `json:"breakout_results,omitempty"`
 }
 
 type PerformanceMetrics struct {
@@ -22,6 +26,11 @@ type PerformanceMetrics struct {
 	WinRate          float64 `json:"win_rate"`          // %
 	TotalFees        float64 `json:"total_fees"`        // USD
 	VolatilityImpact float64 `json:"volatility_impact"` // %
+
+	// SavingsInterest is what idle cash earned, for DCA with savings only (USD)
+	SavingsInterest float64 `json:"savings_interest,omitempty"`
+
+	Base *BaseMetrics `json:"base,omitempty"` // in the base asset, with SetBaseMetrics
 }
 
 type MarketCondition string
@@ -38,21 +47,33 @@ func (e *Engine) CompareStrategies(symbol string, candles []Candle, startDate, e
 	marketCondition := analyzeMarketCondition(candles, startDate, endDate)
 	dca := e.BacktestDCA(symbol, candles, startDate, endDate, dcaCfg, initialBalance)
 	grid := e.BacktestGrid(symbol, candles, startDate, endDate, gridCfg, initialBalance)
-	return &StrategyComparison{DCAResults: dca, GridResults: grid, Period: endDate.Sub(startDate), MarketType: marketCondition}, nil
+
+	// Per-regime breakdown over detected bull/bear/sideways segments
+	regimes, err := e.CompareAcrossRegimes(candlesBetween(candles, startDate, endDate), initialBalance, map[string]StrategyRunner{
+		"dca":  e.DCARunner(symbol, dcaCfg),
+		"grid": e.GridRunner(symbol, gridCfg),
+	}, DefaultSegmentOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &StrategyComparison{DCAResults: dca, GridResults: grid, Period: endDate.Sub(startDate), MarketType: marketCondition, Regimes: regimes}, nil
 }
 
 func analyzeMarketCondition(candles []Candle, start, end time.Time) MarketCondition {
 	// Simple heuristic: last vs first close price
 	var first, last float64
-	for _, c := range candles {
-		if c.Time.Before(start) || c.Time.After(end) {
-			continue
-		}
+	for _, c := range candlesBetween(candles, start, end) {
 		if first == 0 {
 			first = c.Close
 		}
 		last = c.Close
 	}
+	return marketConditionFromPrices(first, last)
+}
+
+// marketConditionFromPrices classifies the move from first to last close
+func marketConditionFromPrices(first, last float64) MarketCondition {
 	if first == 0 {
 		return SIDEWAYS_MARKET
 	}
@@ -0,0 +1,64 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Dataset is a time-sorted candle series prepared once and shared across runs.
+// Slicing by date is a binary search, so sweeps don't rescan every candle.
+type Dataset struct {
+	candles []Candle
+}
+
+// NewDataset returns a dataset over candles, sorting a copy if they are out of order
+func NewDataset(candles []Candle) (*Dataset, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("dataset has no candles")
+	}
+	if !candlesSorted(candles) {
+		sorted := make([]Candle, len(candles))
+		copy(sorted, candles)
+		sortCandles(sorted)
+		candles = sorted
+	}
+	return &Dataset{candles: candles}, nil
+}
+
+// LoadDataset reads a .csv or .parquet file into a dataset
+func (e *Engine) LoadDataset(path string) (*Dataset, error) {
+	candles, err := e.LoadCandles(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewDataset(candles)
+}
+
+// Candles returns all candles; callers must not modify them
+func (d *Dataset) Candles() []Candle {
+	return d.candles
+}
+
+// Len returns the number of candles
+func (d *Dataset) Len() int {
+	return len(d.candles)
+}
+
+// Range returns the times of the first and last candle
+func (d *Dataset) Range() (time.Time, time.Time) {
+	return d.candles[0].Time, d.candles[len(d.candles)-1].Time
+}
+
+// Between returns the candles within [start, end] without copying
+func (d *Dataset) Between(start, end time.Time) []Candle {
+	return candlesBetween(d.candles, start, end)
+}
+
+func candlesSorted(candles []Candle) bool {
+	return sort.SliceIsSorted(candles, func(i, j int) bool { return candles[i].Time.Before(candles[j].Time) })
+}
+
+func sortCandles(candles []Candle) {
+	sort.SliceStable(candles, func(i, j int) bool { return candles[i].Time.Before(candles[j].Time) })
+}
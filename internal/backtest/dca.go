@@ -1,42 +1,195 @@
 package backtest
 
 import (
-    "time"
+	"math"
+	"time"
 
-    "github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
 func (e *Engine) BacktestDCA(symbol string, candles []Candle, start, end time.Time, cfg types.DCAConfig, initialBalance float64) PerformanceMetrics {
-    cash := initialBalance
-    qty := 0.0
-    totalFees := 0.0
-    trades := 0
-    wins := 0
-
-    nextBuy := start
-    var equity []float64
-    for _, c := range candles {
-        if c.Time.Before(start) || c.Time.After(end) { continue }
-        price := c.Close
-        if !nextBuy.After(c.Time) && trades < cfg.MaxInvestments && cfg.InvestmentAmount > 0 && cash > 0 {
-            invest := cfg.InvestmentAmount
-            if invest > cash { invest = cash }
-            fee := invest * e.feeRate
-            totalFees += fee
-            qty += (invest - fee) / price
-            cash -= invest
-            trades++
-            nextBuy = nextBuy.Add(cfg.Interval)
-        }
-        equity = append(equity, cash+qty*price)
-    }
-    if len(equity) == 0 { return PerformanceMetrics{} }
-    // wins proxy: last price above average buy -> count as win
-    if qty > 0 {
-        avg := (initialBalance - cash - totalFees) / qty
-        if candles[len(candles)-1].Close > avg { wins = trades }
-    }
-    return computePerformance(equity, end.Sub(start), trades, wins, totalFees)
+	metrics, _ := e.DCASeries(symbol, candles, start, end, cfg, initialBalance)
+	return metrics
 }
 
+// DCASeries is BacktestDCA that also returns the per-bar equity returns
+func (e *Engine) DCASeries(symbol string, candles []Candle, start, end time.Time, cfg types.DCAConfig, initialBalance float64) (PerformanceMetrics, []float64) {
+	return e.dcaSeries(symbol, candles, start, end, e.newDCASim(start, cfg, initialBalance))
+}
+
+// dcaSeries runs sim over the candles between start and end
+func (e *Engine) dcaSeries(symbol string, candles []Candle, start, end time.Time, sim *dcaSim) (PerformanceMetrics, []float64) {
+	var equity []float64
+	window := candlesBetween(candles, start, end)
+	for _, c := range window {
+		equity = append(equity, sim.step(c))
+	}
+	if len(equity) == 0 {
+		return PerformanceMetrics{}, nil
+	}
+	metrics := computePerformance(equity, end.Sub(start), sim.trades, sim.wins(candles[len(candles)-1].Close), sim.totalFees)
+	metrics.SavingsInterest = sim.interest
+	return e.withBase(metrics, symbol, equity, window, end.Sub(start)), ReturnsFromEquity(equity)
+}
+
+// dcaSim is the DCA backtest state advanced one candle at a time
+type dcaSim struct {
+	fees       feeSchedule // maker for chased limit fills, taker otherwise
+	cfg        types.DCAConfig
+	exec       ExecutionModel
+	working    *dcaBuy // buy still executing over the next candles
+	wallet     *wallet
+	qty        float64
+	spent      float64 // cash invested, fees included
+	totalFees  float64
+	trades     int
+	nextBuy    time.Time
+	shortfalls int       // scheduled buys cut short or held back for lack of cash
+	shortAt    time.Time // the scheduled buy last counted as a shortfall
+	volTgt     *strategy.VolatilityTarget
+	slippage   *SlippageModel
+	maker      float64 // quote filled by resting limit orders
+	taker      float64 // quote filled at market
+	interest   float64 // credited to cash parked in savings
+	lastTime   time.Time
+
+	overlay *overlaySim // options written on top, when comparing overlays
+}
+
+// dcaBuy is a limit chase or TWAP buy spread over several candles
+type dcaBuy struct {
+	remaining float64 // quote left to spend
+	limit     float64 // chased limit price
+	candles   int     // candles worked so far
+	slices    int     // TWAP slices left
+}
+
+func (e *Engine) newDCASim(start time.Time, cfg types.DCAConfig, initialBalance float64) *dcaSim {
+	s := &dcaSim{fees: e.fees(), slippage: e.slippage, cfg: cfg, exec: executionModel(cfg.Execution), wallet: &wallet{cash: initialBalance}, nextBuy: start}
+	if cfg.VolTarget != nil {
+		s.volTgt = strategy.NewVolatilityTarget(*cfg.VolTarget)
+	}
+	return s
+}
 
+// step works an unfinished buy, buys if the interval elapsed and returns
+// equity at the candle close
+func (s *dcaSim) step(c Candle) float64 {
+	price := c.Close
+	if s.volTgt != nil {
+		s.volTgt.Observe(c.High, c.Low, c.Close)
+	}
+	s.credit(c)
+	if s.working != nil {
+		s.work(c)
+	}
+	if s.working == nil && !s.nextBuy.After(c.Time) && s.trades < s.cfg.MaxInvestments && s.cfg.InvestmentAmount > 0 {
+		invest := s.cfg.InvestmentAmount
+		if s.volTgt != nil {
+			scale, _ := s.volTgt.Scale()
+			invest *= scale
+		}
+		if invest > s.wallet.cash && !s.shortAt.Equal(s.nextBuy) {
+			s.shortfalls++
+			s.shortAt = s.nextBuy
+		}
+		if s.wallet.cash > 0 {
+			invest = math.Min(invest, s.wallet.cash)
+			s.trades++
+			s.nextBuy = s.nextBuy.Add(s.cfg.Interval)
+			switch s.exec.Style {
+			case ExecutionLimitChase:
+				s.working = &dcaBuy{remaining: invest, limit: price}
+			case ExecutionTWAP:
+				s.working = &dcaBuy{remaining: invest, slices: s.exec.slices()}
+			default:
+				s.fill(invest, s.slippage.Fill(price, true, c.Time), types.OrderTypeMarket)
+			}
+		}
+	}
+	if s.overlay != nil {
+		return s.overlay.step(s, c)
+	}
+	return s.wallet.cash + s.qty*price
+}
+
+// credit pays the savings rate since the previous candle on the budget still
+// to invest, less the next buy once it is within Lead of being due, as the
+// live strategy keeps it parked
+func (s *dcaSim) credit(c Candle) {
+	savings := s.cfg.Savings
+	if savings == nil || savings.Rate <= 0 {
+		return
+	}
+	if !s.lastTime.IsZero() && c.Time.After(s.lastTime) {
+		remaining := s.cfg.InvestmentAmount * float64(max(s.cfg.MaxInvestments-s.trades, 0))
+		if remaining > 0 && !s.nextBuy.After(c.Time.Add(savings.Lead)) {
+			remaining -= s.cfg.InvestmentAmount
+		}
+		parked := math.Max(math.Min(s.wallet.cash, remaining), 0)
+		interest := parked * savings.Rate * c.Time.Sub(s.lastTime).Hours() / (365 * 24)
+		s.wallet.cash += interest
+		s.interest += interest
+	}
+	s.lastTime = c.Time
+}
+
+// work advances the unfinished buy by candle c. A chased limit at the bid
+// fills when the candle trades below it and is re-pegged to a higher close;
+// after ChaseTicks candles the rest is bought at market. A TWAP buy spends
+// an equal slice at each candle's average price.
+func (s *dcaSim) work(c Candle) {
+	b := s.working
+	b.candles++
+	switch s.exec.Style {
+	case ExecutionLimitChase:
+		switch {
+		case c.Low < b.limit:
+			s.fill(b.remaining, b.limit, types.OrderTypeLimit)
+		case b.candles >= s.exec.chaseTicks():
+			s.fill(b.remaining, s.slippage.Fill(c.Close, true, c.Time), types.OrderTypeMarket)
+		default:
+			b.limit = math.Max(b.limit, c.Close)
+			return
+		}
+	case ExecutionTWAP:
+		slice := b.remaining / float64(b.slices)
+		s.fill(slice, s.slippage.Fill((c.Open+c.High+c.Low+c.Close)/4, true, c.Time), types.OrderTypeMarket)
+		b.remaining -= slice
+		if b.slices--; b.slices > 0 {
+			return
+		}
+	}
+	s.working = nil
+}
+
+// fill spends amount of cash, fees included, on a buy at price; a shared
+// wallet may have less left than the buy was sized for
+func (s *dcaSim) fill(amount, price float64, orderType types.OrderType) {
+	amount = math.Min(amount, s.wallet.cash)
+	if amount <= 0 {
+		return
+	}
+	fee := amount * s.fees.rate(orderType)
+	s.totalFees += fee
+	s.qty += (amount - fee) / price
+	s.wallet.cash -= amount
+	s.spent += amount
+	if orderType == types.OrderTypeLimit {
+		s.maker += amount
+	} else {
+		s.taker += amount
+	}
+}
+
+// wins proxy: last price above average buy -> count as win
+func (s *dcaSim) wins(lastClose float64) int {
+	if s.qty > 0 {
+		avg := (s.spent - s.totalFees) / s.qty
+		if lastClose > avg {
+			return s.trades
+		}
+	}
+	return 0
+}
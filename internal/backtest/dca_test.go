@@ -0,0 +1,37 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestEngine_DCASavingsInterest(t *testing.T) {
+	// Flat prices: whatever the savings earn is the difference in return
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]Candle, 24*10)
+	for i := range candles {
+		candles[i] = Candle{Time: start.Add(time.Duration(i) * time.Hour), Open: 100, High: 100, Low: 100, Close: 100, Volume: 1}
+	}
+	end := candles[len(candles)-1].Time
+	cfg := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 10, Enabled: true}
+
+	eng := NewEngine(0)
+	idle := eng.BacktestDCA("BTCUSDT", candles, start, end, cfg, 1000)
+	cfg.Savings = &types.SavingsConfig{Lead: time.Hour, Rate: 0.05}
+	parked := eng.BacktestDCA("BTCUSDT", candles, start, end, cfg, 1000)
+
+	if idle.SavingsInterest != 0 {
+		t.Errorf("interest without savings = %v", idle.SavingsInterest)
+	}
+	// The budget not yet invested, 900 down to 0, earns 5% a year for ~9.5 days
+	want := 0.05 / 365 * (900 + 800 + 700 + 600 + 500 + 400 + 300 + 200 + 100)
+	if math.Abs(parked.SavingsInterest-want) > want*0.1 {
+		t.Errorf("savings interest = %.4f, want about %.4f", parked.SavingsInterest, want)
+	}
+	if parked.TotalReturn <= idle.TotalReturn {
+		t.Errorf("return with savings %.6f should beat %.6f", parked.TotalReturn, idle.TotalReturn)
+	}
+}
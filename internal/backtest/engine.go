@@ -1,12 +1,11 @@
 package backtest
 
 import (
-    "encoding/csv"
     "fmt"
-    "io"
     "os"
-    "strconv"
     "time"
+
+    "github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
 )
 
 type Candle struct {
@@ -19,35 +18,25 @@ type Candle struct {
 }
 
 type Engine struct {
-    feeRate float64 // taker fee rate e.g. 0.001
+    feeRate  float64 // taker fee rate e.g. 0.001
+    makerFee float64 // limit fill fee rate, the taker rate unless set
+    slippage *SlippageModel // market fill cost; nil fills at the close
+    baseMetrics bool // also measure performance in the base asset
+    sentiment *ai.SentimentHistory // attached to strategy market data; nil for none
+    sentimentWindow time.Duration // sentiment momentum span
 }
 
-func NewEngine(feeRate float64) *Engine { return &Engine{ feeRate: feeRate } }
+func NewEngine(feeRate float64) *Engine { return &Engine{ feeRate: feeRate, makerFee: feeRate } }
 
 func (e *Engine) LoadCSV(path string) ([]Candle, error) {
     f, err := os.Open(path)
     if err != nil { return nil, err }
-    defer f.Close()
-    r := csv.NewReader(f)
-    r.FieldsPerRecord = -1
-    var out []Candle
     // expect header: timestamp,open,high,low,close,volume
-    _, _ = r.Read()
-    for {
-        rec, err := r.Read()
-        if err == io.EOF { break }
-        if err != nil { return nil, err }
-        if len(rec) < 6 { continue }
-        ts, _ := time.Parse(time.RFC3339, rec[0])
-        open, _ := strconv.ParseFloat(rec[1], 64)
-        high, _ := strconv.ParseFloat(rec[2], 64)
-        low, _ := strconv.ParseFloat(rec[3], 64)
-        closeP, _ := strconv.ParseFloat(rec[4], 64)
-        vol, _ := strconv.ParseFloat(rec[5], 64)
-        out = append(out, Candle{ Time: ts, Open: open, High: high, Low: low, Close: closeP, Volume: vol })
-    }
+    out, err := CollectCandles(NewCSVIterator(f))
+    f.Close()
+    if err != nil { return nil, err }
     if len(out) == 0 { return nil, fmt.Errorf("no candles loaded") }
+    // date slicing relies on chronological order
+    if !candlesSorted(out) { sortCandles(out) }
     return out, nil
 }
-
-
@@ -0,0 +1,123 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Execution styles a DCA backtest can simulate. Market and limit chase are
+// the styles live DCA buys support; TWAP is simulated for comparison.
+const (
+	ExecutionMarket     = types.ExecutionMarket
+	ExecutionLimitChase = types.ExecutionLimitChase
+	ExecutionTWAP       = "twap"
+)
+
+// Defaults of the multi-candle styles
+const (
+	defaultChaseTicks = 5
+	defaultTWAPSlices = 4
+)
+
+// ExecutionModel is how simulated DCA buys fill within the candles. Fills
+// are priced from each candle's OHLC: market buys at the close, a chased
+// limit at its price once a low trades through it, TWAP slices at the
+// candle's average price. Market and TWAP fills pay slippage and the taker
+// fee, limit fills the maker fee.
+type ExecutionModel struct {
+	Style      string `json:"style"`                 // ExecutionMarket (default), ExecutionLimitChase or ExecutionTWAP
+	ChaseTicks int    `json:"chase_ticks,omitempty"` // candles a limit is chased before buying at market (default 5)
+	Slices     int    `json:"slices,omitempty"`      // candles a TWAP buy is spread over (default 4)
+}
+
+// DefaultExecutionModels compares market, limit chase and TWAP buys
+func DefaultExecutionModels() []ExecutionModel {
+	return []ExecutionModel{{Style: ExecutionMarket}, {Style: ExecutionLimitChase}, {Style: ExecutionTWAP}}
+}
+
+// Validate checks the style and counts
+func (m ExecutionModel) Validate() error {
+	switch m.Style {
+	case "", ExecutionMarket, ExecutionLimitChase, ExecutionTWAP:
+	default:
+		return fmt.Errorf("execution style must be %s, %s or %s, got %q", ExecutionMarket, ExecutionLimitChase, ExecutionTWAP, m.Style)
+	}
+	if m.ChaseTicks < 0 || m.Slices < 0 {
+		return fmt.Errorf("execution chase ticks and slices must not be negative")
+	}
+	return nil
+}
+
+func (m ExecutionModel) chaseTicks() int {
+	if m.ChaseTicks > 0 {
+		return m.ChaseTicks
+	}
+	return defaultChaseTicks
+}
+
+func (m ExecutionModel) slices() int {
+	if m.Slices > 0 {
+		return m.Slices
+	}
+	return defaultTWAPSlices
+}
+
+// executionModel simulates a live DCA execution config. A chase timeout
+// has no candle equivalent; ChaseTicks counts candles.
+func executionModel(config *types.ExecutionConfig) ExecutionModel {
+	if config == nil {
+		return ExecutionModel{Style: ExecutionMarket}
+	}
+	return ExecutionModel{Style: config.Style, ChaseTicks: config.ChaseTicks}
+}
+
+// ExecutionComparison is a DCA backtest run once per execution style
+type ExecutionComparison struct {
+	Period  time.Duration     `json:"backtest_period"`
+	Results []ExecutionResult `json:"results"`
+}
+
+// ExecutionResult is the DCA backtest of one execution style
+type ExecutionResult struct {
+	Execution   ExecutionModel     `json:"execution"`
+	Metrics     PerformanceMetrics `json:"metrics"`
+	AvgCost     float64            `json:"avg_cost"`     // quote spent per unit bought, fees included
+	MakerRatio  float64            `json:"maker_ratio"`  // share of the quote filled as maker
+	ReturnDelta float64            `json:"return_delta"` // total return minus the first style's, in points
+}
+
+// CompareExecution backtests the DCA config once per execution model, on
+// the same candles and balance, so the difference in net return is down to
+// how buys were filled. Without models it compares DefaultExecutionModels.
+func (e *Engine) CompareExecution(symbol string, candles []Candle, start, end time.Time, cfg types.DCAConfig, initialBalance float64, models []ExecutionModel) (*ExecutionComparison, error) {
+	if len(models) == 0 {
+		models = DefaultExecutionModels()
+	}
+	cmp := &ExecutionComparison{Period: end.Sub(start)}
+	for _, model := range models {
+		if err := model.Validate(); err != nil {
+			return nil, err
+		}
+		if model.Style == "" {
+			model.Style = ExecutionMarket
+		}
+		sim := e.newDCASim(start, cfg, initialBalance)
+		sim.exec = model
+		metrics, _ := e.dcaSeries(symbol, candles, start, end, sim)
+
+		result := ExecutionResult{Execution: model, Metrics: metrics}
+		if sim.qty > 0 {
+			result.AvgCost = sim.spent / sim.qty
+		}
+		if filled := sim.maker + sim.taker; filled > 0 {
+			result.MakerRatio = sim.maker / filled
+		}
+		if len(cmp.Results) > 0 {
+			result.ReturnDelta = metrics.TotalReturn - cmp.Results[0].Metrics.TotalReturn
+		}
+		cmp.Results = append(cmp.Results, result)
+	}
+	return cmp, nil
+}
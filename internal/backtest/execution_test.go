@@ -0,0 +1,57 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestEngine_CompareExecution(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := func(ohlc ...[4]float64) []Candle {
+		candles := make([]Candle, len(ohlc))
+		for i, p := range ohlc {
+			candles[i] = Candle{Time: start.Add(time.Duration(i) * time.Hour), Open: p[0], High: p[1], Low: p[2], Close: p[3]}
+		}
+		return candles
+	}
+	cfg := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 1, Enabled: true}
+	models := []ExecutionModel{{Style: ExecutionMarket}, {Style: ExecutionLimitChase, ChaseTicks: 2}, {Style: ExecutionTWAP, Slices: 2}}
+	eng := NewEngine(0.001)
+	eng.SetFees(0, 0.001)
+
+	// The bid at the 100 close is traded through on the next bar
+	candles := bars([4]float64{100, 101, 99, 100}, [4]float64{100, 102, 99.5, 101}, [4]float64{101, 103, 100, 102})
+	cmp, err := eng.CompareExecution("BTCUSDT", candles, start, candles[len(candles)-1].Time, cfg, 1000, models)
+	if err != nil {
+		t.Fatal(err)
+	}
+	market, chase, twap := cmp.Results[0], cmp.Results[1], cmp.Results[2]
+	if math.Abs(market.AvgCost-100/0.999) > 1e-9 || market.MakerRatio != 0 {
+		t.Errorf("market = %+v, want a taker buy at the close", market)
+	}
+	if chase.AvgCost != 100 || chase.MakerRatio != 1 || chase.Metrics.TotalFees != 0 || chase.ReturnDelta <= 0 {
+		t.Errorf("chase = %+v, want a fee-free maker fill at 100 beating market", chase)
+	}
+	// Two slices at the bar averages of 100.625 and 101.5
+	wantTWAP := 100 / (49.95/100.625 + 49.95/101.5)
+	if math.Abs(twap.AvgCost-wantTWAP) > 1e-9 || twap.Metrics.TradeCount != 1 {
+		t.Errorf("twap = %+v, want avg cost %.4f from one buy", twap, wantTWAP)
+	}
+
+	// In a rally the bid is chased up and bought at market after two bars
+	candles = bars([4]float64{100, 101, 99, 100}, [4]float64{100, 102, 100, 102}, [4]float64{102, 105, 102, 104}, [4]float64{104, 105, 103, 104})
+	cmp, err = eng.CompareExecution("BTCUSDT", candles, start, candles[len(candles)-1].Time, cfg, 1000, models)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chase := cmp.Results[1]; math.Abs(chase.AvgCost-104/0.999) > 1e-9 || chase.MakerRatio != 0 || chase.ReturnDelta >= 0 {
+		t.Errorf("chase in a rally = %+v, want a market buy at 104 trailing market", chase)
+	}
+
+	if _, err := eng.CompareExecution("BTCUSDT", candles, start, start, cfg, 1000, []ExecutionModel{{Style: "vwap"}}); err == nil {
+		t.Error("expected an error for an unknown style")
+	}
+}
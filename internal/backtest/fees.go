@@ -0,0 +1,41 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// feeSchedule holds the rates simulated fills pay, e.g. 0.001 for 0.1%
+type feeSchedule struct {
+	maker, taker float64
+}
+
+// rate returns the rate an order pays. Limit orders are filled at their
+// price as resting orders and pay the maker rate; market orders take
+// liquidity. Live fills without a reported commission are charged the same
+// way.
+func (f feeSchedule) rate(orderType types.OrderType) float64 {
+	if orderType == types.OrderTypeLimit {
+		return f.maker
+	}
+	return f.taker
+}
+
+func (e *Engine) fees() feeSchedule { return feeSchedule{maker: e.makerFee, taker: e.feeRate} }
+
+// SetFees sets the maker and taker fee rates
+func (e *Engine) SetFees(maker, taker float64) {
+	e.makerFee, e.feeRate = maker, taker
+}
+
+// LoadFees sets the fee rates from an exchange's schedule for symbol
+func (e *Engine) LoadFees(ctx context.Context, client types.ExchangeClient, symbol string) error {
+	fees, err := client.GetTradingFees(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get %s trading fees: %w", symbol, err)
+	}
+	e.SetFees(fees.MakerFee, fees.TakerFee)
+	return nil
+}
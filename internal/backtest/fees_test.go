@@ -0,0 +1,41 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestSimExchange_MakerTakerFees(t *testing.T) {
+	ctx := context.Background()
+	eng := NewEngine(0.002)
+	eng.SetFees(0.0005, 0.002)
+	sim := newSimExchange("BTCUSDT", nil, eng.fees(), 1000)
+	sim.push(Candle{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Close: 100}, gridHistory)
+
+	// A resting limit order pays the maker rate, a market order the taker rate
+	if err := sim.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeLimit, Quantity: 1, Price: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sim.totalFees-0.05) > 1e-12 {
+		t.Fatalf("limit fill fees = %v, want 0.05", sim.totalFees)
+	}
+	if err := sim.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sim.totalFees-0.25) > 1e-12 {
+		t.Fatalf("fees after the market fill = %v, want 0.25", sim.totalFees)
+	}
+
+	// Fee schedules load from any exchange client
+	loaded := NewEngine(0)
+	if err := loaded.LoadFees(ctx, sim, "BTCUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.fees() != eng.fees() {
+		t.Fatalf("loaded fees = %+v, want %+v", loaded.fees(), eng.fees())
+	}
+}
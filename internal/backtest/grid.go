@@ -1,65 +1,94 @@
 package backtest
 
 import (
-    "sort"
-    "time"
+	"context"
+	"fmt"
+	"time"
 
-    "github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
+// BacktestGrid replays candles through the live grid strategy. Invalid
+// configs yield empty metrics; GridRunner reports why.
 func (e *Engine) BacktestGrid(symbol string, candles []Candle, start, end time.Time, cfg types.GridConfig, initialBalance float64) PerformanceMetrics {
-    if cfg.GridLevels < 2 { return PerformanceMetrics{} }
-    step := (cfg.UpperPrice - cfg.LowerPrice) / float64(cfg.GridLevels-1)
-    levels := make([]float64, cfg.GridLevels)
-    for i := 0; i < cfg.GridLevels; i++ { levels[i] = cfg.LowerPrice + float64(i)*step }
-    sort.Float64s(levels)
+	metrics, _ := e.GridSeries(symbol, candles, start, end, cfg, initialBalance)
+	return metrics
+}
+
+// GridSeries is BacktestGrid that also returns the per-bar equity returns
+func (e *Engine) GridSeries(symbol string, candles []Candle, start, end time.Time, cfg types.GridConfig, initialBalance float64) (PerformanceMetrics, []float64) {
+	metrics, returns, _ := e.gridSeries(symbol, candles, start, end, cfg, initialBalance)
+	return metrics, returns
+}
 
-    type pos struct{ qty, avg float64 }
-    positions := make(map[int]pos)
+func (e *Engine) gridSeries(symbol string, candles []Candle, start, end time.Time, cfg types.GridConfig, initialBalance float64) (PerformanceMetrics, []float64, error) {
+	sim, err := e.newGridSim(symbol, cfg, initialBalance)
+	if err != nil {
+		return PerformanceMetrics{}, nil, err
+	}
+	var equity []float64
+	window := candlesBetween(candles, start, end)
+	for _, c := range window {
+		equity = append(equity, sim.step(c))
+	}
+
+	metrics := computePerformance(equity, end.Sub(start), sim.trades(), sim.wins(), sim.exchange.totalFees)
+	return e.withBase(metrics, symbol, equity, window, end.Sub(start)), ReturnsFromEquity(equity), nil
+}
 
-    cash := initialBalance
-    totalFees := 0.0
-    trades := 0
-    wins := 0
-    var equity []float64
+// gridHistory is how many candles the simulated exchange keeps for the
+// strategy's GetCandles calls
+const gridHistory = 500
 
-    for _, c := range candles {
-        if c.Time.Before(start) || c.Time.After(end) { continue }
-        p := c.Close
-        // buy
-        for i, level := range levels {
-            if p <= level {
-                if positions[i].qty == 0 && cash >= cfg.InvestmentPerLevel {
-                    fee := cfg.InvestmentPerLevel * e.feeRate
-                    qty := (cfg.InvestmentPerLevel - fee) / p
-                    positions[i] = pos{ qty: qty, avg: p }
-                    cash -= cfg.InvestmentPerLevel
-                    totalFees += fee
-                    trades++
-                }
-            }
-        }
-        // sell
-        for i := 0; i < len(levels)-1; i++ {
-            next := levels[i+1]
-            if positions[i].qty > 0 && p >= next {
-                qty := positions[i].qty
-                proceeds := qty * p
-                fee := proceeds * e.feeRate
-                cash += proceeds - fee
-                if p >= positions[i].avg { wins++ }
-                totalFees += fee
-                positions[i] = pos{}
-                trades++
-            }
-        }
-        // equity
-        invQty := 0.0
-        for _, ps := range positions { invQty += ps.qty }
-        equity = append(equity, cash+invQty*p)
-    }
+// gridSim drives the live GridStrategy one candle at a time against a
+// simulated exchange, so every live grid feature (exit ladders, throttles,
+// signal filters) backtests exactly as it trades
+type gridSim struct {
+	engine   *Engine
+	exchange *simExchange
+	grid     *strategy.GridStrategy
+}
+
+func (e *Engine) newGridSim(symbol string, cfg types.GridConfig, initialBalance float64) (*gridSim, error) {
+	if cfg.Symbol == "" {
+		cfg.Symbol = symbol
+	}
+	// Enabled pauses a live bot; a backtest always trades
+	cfg.Enabled = true
+
+	sim := newSimExchange(cfg.Symbol, nil, e.fees(), initialBalance)
+	sim.slippage = e.slippage
+	built, err := strategy.NewFactory(logger.New(logger.LevelError)).CreateGrid(cfg, sim)
+	if err != nil {
+		return nil, err
+	}
+	grid, ok := built.(*strategy.GridStrategy)
+	if !ok {
+		return nil, fmt.Errorf("grid factory returned %T", built)
+	}
+	// Throttles count orders in candle time, not wall-clock time
+	if throttle := grid.Throttle(); throttle != nil {
+		throttle.SetClock(func() time.Time { return sim.current().Time })
+	}
+	return &gridSim{engine: e, exchange: sim, grid: grid}, nil
+}
 
-    return computePerformance(equity, end.Sub(start), trades, wins, totalFees)
+// step executes the grid on the candle close and returns equity. Orders the
+// simulated exchange rejects, e.g. for lack of cash, are skipped as a live
+// bot would retry them on the next tick.
+func (s *gridSim) step(c Candle) float64 {
+	s.exchange.push(c, gridHistory)
+	ctx := context.Background()
+	ticker, _ := s.exchange.GetTicker(ctx, s.exchange.symbol)
+	_ = s.grid.Execute(ctx, s.engine.marketData(s.exchange.symbol, c, ticker))
+	return s.exchange.equity()
 }
 
+// trades counts fills on the simulated exchange
+func (s *gridSim) trades() int { return s.exchange.trades }
 
+// wins counts sells that closed a level's position at a profit, as the live
+// strategy reports them
+func (s *gridSim) wins() int { return s.grid.GetMetrics().WinningTrades }
@@ -0,0 +1,46 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestBacktestGridMatchesLiveStrategy(t *testing.T) {
+	candles, err := GenerateSynthetic(ScenarioConfig(SIDEWAYS_MARKET, 1500, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end := candles[0].Time, candles[len(candles)-1].Time
+	price := candles[0].Close
+	cfg := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: price * 0.9, UpperPrice: price * 1.1, GridLevels: 30, InvestmentPerLevel: 100, Enabled: true}
+	eng := NewEngine(0.001)
+
+	grid := eng.BacktestGrid("BTCUSDT", candles, start, end, cfg, 10000)
+	if grid.TradeCount == 0 {
+		t.Fatal("expected grid trades")
+	}
+
+	// The same orders as the live strategy on a simulated exchange
+	live, err := eng.BacktestStrategy("BTCUSDT", candles, start, end, func(exchange types.ExchangeClient) (strategy.Strategy, error) {
+		return strategy.NewFactory(logger.New(logger.LevelError)).CreateGrid(cfg, exchange)
+	}, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grid.TradeCount != live.TradeCount || grid.TotalFees != live.TotalFees || grid.TotalReturn != live.TotalReturn {
+		t.Errorf("BacktestGrid = %+v, live strategy = %+v", grid, live)
+	}
+
+	// Live-only features take effect: a throttle in candle time caps orders
+	throttled := cfg
+	throttled.Throttle = &types.ThrottleConfig{MaxPerDay: 1}
+	days := int(end.Sub(start)/(24*time.Hour)) + 1
+	got := eng.BacktestGrid("BTCUSDT", candles, start, end, throttled, 10000)
+	if got.TradeCount >= grid.TradeCount || got.TradeCount > 2*days {
+		t.Errorf("throttled grid traded %d times over %d days, unthrottled %d", got.TradeCount, days, grid.TradeCount)
+	}
+}
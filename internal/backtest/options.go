@@ -0,0 +1,299 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Option overlays a DCA backtest can write on top of its accumulation
+const (
+	OverlayCoveredCall    = "covered_call"     // calls on the holdings
+	OverlayCashSecuredPut = "cash_secured_put" // puts on cash the DCA does not need before expiry
+)
+
+// Defaults of an overlay
+const (
+	defaultOverlayStrike    = 0.1
+	defaultOverlayTenor     = 7 * 24 * time.Hour
+	defaultOverlayVolWindow = 30
+)
+
+// OptionOverlay is a short option position rolled over the backtest: when
+// one expires the next is written at the close. Its premium is a fixed share
+// of spot, or the Black-Scholes price at Volatility, or at the realized
+// volatility of the last VolWindow closes. Options are cash-settled at
+// expiry against the close: an assigned call sells the holdings it covers at
+// the strike, an assigned put buys at the strike with the cash set aside.
+// Open options are marked at their intrinsic value.
+type OptionOverlay struct {
+	Style      string        `json:"style"`                // OverlayCoveredCall or OverlayCashSecuredPut
+	Strike     float64       `json:"strike"`               // distance from spot: calls above, puts below (default 0.1)
+	Tenor      time.Duration `json:"tenor"`                // time to expiry (default 7 days)
+	Premium    float64       `json:"premium,omitempty"`    // premium as a share of spot, priced from volatility when zero
+	Volatility float64       `json:"volatility,omitempty"` // annualized, realized over VolWindow candles when zero
+	VolWindow  int           `json:"vol_window,omitempty"` // candles of realized volatility (default 30)
+	Coverage   float64       `json:"coverage,omitempty"`   // share of the holdings or spare cash written on (default 1)
+}
+
+// Validate checks the style and parameters
+func (o OptionOverlay) Validate() error {
+	switch o.Style {
+	case OverlayCoveredCall, OverlayCashSecuredPut:
+	default:
+		return fmt.Errorf("overlay style must be %s or %s, got %q", OverlayCoveredCall, OverlayCashSecuredPut, o.Style)
+	}
+	if o.Strike < 0 || o.Tenor < 0 || o.Premium < 0 || o.Volatility < 0 || o.VolWindow < 0 || o.Coverage < 0 || o.Coverage > 1 {
+		return fmt.Errorf("overlay parameters must not be negative and coverage at most 1")
+	}
+	if o.Style == OverlayCashSecuredPut && o.Strike >= 1 {
+		return fmt.Errorf("put strike must be less than 100%% below spot")
+	}
+	return nil
+}
+
+// withDefaults fills in the unset parameters
+func (o OptionOverlay) withDefaults() OptionOverlay {
+	if o.Strike == 0 {
+		o.Strike = defaultOverlayStrike
+	}
+	if o.Tenor == 0 {
+		o.Tenor = defaultOverlayTenor
+	}
+	if o.VolWindow == 0 {
+		o.VolWindow = defaultOverlayVolWindow
+	}
+	if o.Coverage == 0 {
+		o.Coverage = 1
+	}
+	return o
+}
+
+// OverlayComparison is a DCA backtest run plain and once per option overlay
+type OverlayComparison struct {
+	Period   time.Duration      `json:"backtest_period"`
+	Baseline PerformanceMetrics `json:"baseline"` // without an overlay
+	Results  []OverlayResult    `json:"results"`
+}
+
+// OverlayResult is the DCA backtest with one overlay
+type OverlayResult struct {
+	Overlay       OptionOverlay      `json:"overlay"`
+	Metrics       PerformanceMetrics `json:"metrics"`
+	Written       int                `json:"written"`        // options written
+	Assigned      int                `json:"assigned"`       // options that expired in the money
+	Premiums      float64            `json:"premiums"`       // quote collected
+	ReturnDelta   float64            `json:"return_delta"`   // total return minus the baseline's, in points
+	DrawdownDelta float64            `json:"drawdown_delta"` // max drawdown minus the baseline's, in points
+}
+
+// CompareOverlays backtests the DCA config plain and with each overlay, on
+// the same candles and balance, so the differences in return and drawdown
+// are down to the options
+func (e *Engine) CompareOverlays(symbol string, candles []Candle, start, end time.Time, cfg types.DCAConfig, initialBalance float64, overlays []OptionOverlay) (*OverlayComparison, error) {
+	if len(overlays) == 0 {
+		return nil, fmt.Errorf("no overlays to compare")
+	}
+	cmp := &OverlayComparison{Period: end.Sub(start)}
+	cmp.Baseline, _ = e.dcaSeries(symbol, candles, start, end, e.newDCASim(start, cfg, initialBalance))
+	for _, overlay := range overlays {
+		if err := overlay.Validate(); err != nil {
+			return nil, err
+		}
+		overlay = overlay.withDefaults()
+		sim := e.newDCASim(start, cfg, initialBalance)
+		sim.overlay = &overlaySim{overlay: overlay}
+		metrics, _ := e.dcaSeries(symbol, candles, start, end, sim)
+		cmp.Results = append(cmp.Results, OverlayResult{
+			Overlay:       overlay,
+			Metrics:       metrics,
+			Written:       sim.overlay.written,
+			Assigned:      sim.overlay.assigned,
+			Premiums:      sim.overlay.premiums,
+			ReturnDelta:   metrics.TotalReturn - cmp.Baseline.TotalReturn,
+			DrawdownDelta: metrics.MaxDrawdown - cmp.Baseline.MaxDrawdown,
+		})
+	}
+	return cmp, nil
+}
+
+// overlaySim is the option position of a DCA backtest
+type overlaySim struct {
+	overlay  OptionOverlay
+	closes   []float64 // the last VolWindow+1, for realized volatility
+	bar      time.Duration
+	lastTime time.Time
+	open     *shortOption
+	written  int
+	assigned int
+	premiums float64
+}
+
+// shortOption is a written option awaiting expiry
+type shortOption struct {
+	strike    float64
+	contracts float64 // units of the base asset
+	expiry    time.Time
+}
+
+// step settles an expired option and writes the next one after the DCA
+// stepped through c, and returns the equity net of the open option
+func (o *overlaySim) step(s *dcaSim, c Candle) float64 {
+	if !o.lastTime.IsZero() && c.Time.After(o.lastTime) {
+		o.bar = c.Time.Sub(o.lastTime)
+	}
+	o.lastTime = c.Time
+	o.closes = append(o.closes, c.Close)
+	if len(o.closes) > o.overlay.VolWindow+1 {
+		o.closes = o.closes[1:]
+	}
+
+	if o.open != nil && !c.Time.Before(o.open.expiry) {
+		o.settle(s, c.Close)
+	}
+	if o.open == nil {
+		o.write(s, c)
+	}
+	return s.wallet.cash + s.qty*c.Close - o.liability(c.Close)
+}
+
+// settle expires the open option against price
+func (o *overlaySim) settle(s *dcaSim, price float64) {
+	opt := o.open
+	o.open = nil
+	switch {
+	case o.overlay.Style == OverlayCoveredCall && price > opt.strike:
+		contracts := math.Min(opt.contracts, s.qty)
+		if s.qty > 0 {
+			s.spent *= 1 - contracts/s.qty // the cost of the units called away
+		}
+		s.qty -= contracts
+		s.wallet.cash += contracts * opt.strike
+		o.assigned++
+	case o.overlay.Style == OverlayCashSecuredPut && price < opt.strike:
+		cost := math.Min(opt.contracts*opt.strike, s.wallet.cash)
+		s.qty += cost / opt.strike
+		s.spent += cost
+		s.wallet.cash -= cost
+		o.assigned++
+	}
+}
+
+// write sells the next option at the close of c
+func (o *overlaySim) write(s *dcaSim, c Candle) {
+	spot := c.Close
+	if spot <= 0 {
+		return
+	}
+	expiry := c.Time.Add(o.overlay.Tenor)
+	opt := &shortOption{expiry: expiry}
+	if o.overlay.Style == OverlayCoveredCall {
+		opt.strike = spot * (1 + o.overlay.Strike)
+		opt.contracts = s.qty * o.overlay.Coverage
+	} else {
+		opt.strike = spot * (1 - o.overlay.Strike)
+		opt.contracts = math.Max(s.wallet.cash-o.reserve(s, expiry), 0) * o.overlay.Coverage / opt.strike
+	}
+	if opt.contracts*spot < 1e-8 {
+		return
+	}
+	rate, ok := o.premium(spot, opt.strike)
+	if !ok {
+		return
+	}
+	premium := opt.contracts * spot * rate
+	s.wallet.cash += premium
+	o.premiums += premium
+	o.written++
+	o.open = opt
+}
+
+// reserve is the cash the DCA buys due by expiry will spend
+func (o *overlaySim) reserve(s *dcaSim, expiry time.Time) float64 {
+	reserve := 0.0
+	if s.working != nil {
+		reserve += s.working.remaining
+	}
+	remaining := s.cfg.MaxInvestments - s.trades
+	if s.cfg.Interval <= 0 {
+		return reserve + s.cfg.InvestmentAmount*float64(max(remaining, 0))
+	}
+	for at := s.nextBuy; remaining > 0 && !at.After(expiry); at = at.Add(s.cfg.Interval) {
+		reserve += s.cfg.InvestmentAmount
+		remaining--
+	}
+	return reserve
+}
+
+// premium returns the premium of an option struck at strike as a share of
+// spot, or false while there is too little history to price it
+func (o *overlaySim) premium(spot, strike float64) (float64, bool) {
+	if o.overlay.Premium > 0 {
+		return o.overlay.Premium, true
+	}
+	vol := o.overlay.Volatility
+	if vol == 0 {
+		var ok bool
+		if vol, ok = o.realizedVol(); !ok {
+			return 0, false
+		}
+	}
+	years := o.overlay.Tenor.Hours() / (24 * 365)
+	return blackScholes(o.overlay.Style == OverlayCoveredCall, spot, strike, years, vol) / spot, true
+}
+
+// realizedVol annualizes the volatility of the log returns of the closes
+func (o *overlaySim) realizedVol() (float64, bool) {
+	if len(o.closes) < o.overlay.VolWindow+1 || o.bar <= 0 {
+		return 0, false
+	}
+	returns := make([]float64, 0, len(o.closes)-1)
+	for i := 1; i < len(o.closes); i++ {
+		if o.closes[i-1] <= 0 || o.closes[i] <= 0 {
+			return 0, false
+		}
+		returns = append(returns, math.Log(o.closes[i]/o.closes[i-1]))
+	}
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns))
+	barsPerYear := float64(365*24*time.Hour) / float64(o.bar)
+	return math.Sqrt(variance * barsPerYear), true
+}
+
+// liability is the intrinsic value of the open option at price
+func (o *overlaySim) liability(price float64) float64 {
+	if o.open == nil {
+		return 0
+	}
+	if o.overlay.Style == OverlayCoveredCall {
+		return math.Max(price-o.open.strike, 0) * o.open.contracts
+	}
+	return math.Max(o.open.strike-price, 0) * o.open.contracts
+}
+
+// blackScholes prices a European option without interest or dividends
+func blackScholes(call bool, spot, strike, years, vol float64) float64 {
+	if years <= 0 || vol <= 0 {
+		if call {
+			return math.Max(spot-strike, 0)
+		}
+		return math.Max(strike-spot, 0)
+	}
+	sd := vol * math.Sqrt(years)
+	d1 := (math.Log(spot/strike) + sd*sd/2) / sd
+	d2 := d1 - sd
+	if call {
+		return spot*normCDF(d1) - strike*normCDF(d2)
+	}
+	return strike*normCDF(-d2) - spot*normCDF(-d1)
+}
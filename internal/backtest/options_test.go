@@ -0,0 +1,78 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestEngine_CompareOverlays(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	hourly := func(price func(i int) float64) []Candle {
+		candles := make([]Candle, 24*10)
+		for i := range candles {
+			p := price(i)
+			candles[i] = Candle{Time: start.Add(time.Duration(i) * time.Hour), Open: p, High: p, Low: p, Close: p, Volume: 1}
+		}
+		return candles
+	}
+	cfg := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 5, Enabled: true}
+	overlays := []OptionOverlay{
+		{Style: OverlayCoveredCall, Strike: 0.1, Tenor: 24 * time.Hour, Premium: 0.01},
+		{Style: OverlayCashSecuredPut, Strike: 0.1, Tenor: 24 * time.Hour, Premium: 0.01},
+	}
+	eng := NewEngine(0)
+
+	// Flat prices: every option expires worthless and the premiums are profit
+	candles := hourly(func(int) float64 { return 100 })
+	cmp, err := eng.CompareOverlays("BTCUSDT", candles, start, candles[len(candles)-1].Time, cfg, 1000, overlays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls, puts := cmp.Results[0], cmp.Results[1]
+	// One call a day on the 1 to 5 BTC bought so far, 5 on the last
+	if calls.Written != 10 || calls.Assigned != 0 || math.Abs(calls.Premiums-(1+2+3+4+5*6)) > 1e-9 {
+		t.Errorf("calls = %+v, want 10 written for 40 in premiums", calls)
+	}
+	if calls.ReturnDelta <= 0 || puts.ReturnDelta <= 0 || puts.Assigned != 0 {
+		t.Errorf("flat market: calls %+v, puts %+v, want both ahead of plain DCA", calls, puts)
+	}
+
+	// A rally calls the holdings away: the overlay gives up upside
+	candles = hourly(func(i int) float64 { return 100 * math.Pow(1.005, float64(i)) })
+	cmp, err = eng.CompareOverlays("BTCUSDT", candles, start, candles[len(candles)-1].Time, cfg, 1000, overlays[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := cmp.Results[0]; calls.Assigned == 0 || calls.ReturnDelta >= 0 {
+		t.Errorf("rally: calls = %+v, want assignments trailing plain DCA", calls)
+	}
+
+	// A crash puts coins on the spare cash: deeper drawdown than plain DCA
+	candles = hourly(func(i int) float64 { return 100 * math.Pow(0.995, float64(i)) })
+	cmp, err = eng.CompareOverlays("BTCUSDT", candles, start, candles[len(candles)-1].Time, cfg, 1000, overlays[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if puts := cmp.Results[0]; puts.Assigned == 0 || puts.DrawdownDelta <= 0 {
+		t.Errorf("crash: puts = %+v, want assignments deepening the drawdown", puts)
+	}
+
+	if _, err := eng.CompareOverlays("BTCUSDT", candles, start, start, cfg, 1000, []OptionOverlay{{Style: "straddle"}}); err == nil {
+		t.Error("expected an error for an unknown style")
+	}
+}
+
+func TestBlackScholes(t *testing.T) {
+	// At the money, one year, 20% volatility
+	if call := blackScholes(true, 100, 100, 1, 0.2); math.Abs(call-7.9656) > 1e-3 {
+		t.Errorf("call = %.4f, want 7.9656", call)
+	}
+	// Put-call parity without interest
+	call, put := blackScholes(true, 100, 110, 0.5, 0.6), blackScholes(false, 100, 110, 0.5, 0.6)
+	if math.Abs(call-put-(100-110)) > 1e-9 {
+		t.Errorf("call %.4f - put %.4f != spot - strike", call, put)
+	}
+}
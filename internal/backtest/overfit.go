@@ -0,0 +1,317 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Overfitting diagnostics for parameter searches. Picking the best of many
+// backtests inflates its Sharpe ratio; these estimate how much of the
+// winner's edge is selection luck.
+
+// ReturnsFromEquity converts an equity curve to per-bar simple returns.
+// A bar after zero equity counts as a zero return so series stay aligned.
+func ReturnsFromEquity(equity []float64) []float64 {
+	if len(equity) < 2 {
+		return nil
+	}
+	returns := make([]float64, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1] != 0 {
+			returns[i-1] = equity[i]/equity[i-1] - 1
+		}
+	}
+	return returns
+}
+
+// DeflatedSharpe is the deflated Sharpe ratio of the selected trial
+type DeflatedSharpe struct {
+	Sharpe       float64 `json:"sharpe"`       // per-bar Sharpe of the selected trial
+	Threshold    float64 `json:"threshold"`    // expected best per-bar Sharpe of Trials unskilled trials
+	Probability  float64 `json:"probability"`  // P(true Sharpe > 0) after deflation; below 0.95 is not significant
+	Trials       int     `json:"trials"`       // number of trials searched
+	Observations int     `json:"observations"` // bars in the selected series
+	Skew         float64 `json:"skew"`
+	Kurtosis     float64 `json:"kurtosis"` // non-excess; 3 for normal returns
+}
+
+// ComputeDeflatedSharpe implements Bailey and López de Prado (2014) for
+// trial returns[selected]: its Sharpe is tested against the maximum Sharpe
+// expected from len(returns) trials with no skill, given the variance of
+// the trial Sharpes, and adjusted for the skew and kurtosis of its returns.
+func ComputeDeflatedSharpe(returns [][]float64, selected int) DeflatedSharpe {
+	trialSharpes := make([]float64, len(returns))
+	for i, r := range returns {
+		if mean, sd := meanStd(r); sd > 0 {
+			trialSharpes[i] = mean / sd
+		}
+	}
+
+	mean, sd := meanStd(returns[selected])
+	d := DeflatedSharpe{Trials: len(returns), Observations: len(returns[selected])}
+	if sd == 0 || d.Observations < 3 {
+		return d
+	}
+	d.Sharpe = mean / sd
+	for _, r := range returns[selected] {
+		z := (r - mean) / sd
+		d.Skew += z * z * z
+		d.Kurtosis += z * z * z * z
+	}
+	d.Skew /= float64(d.Observations)
+	d.Kurtosis /= float64(d.Observations)
+
+	if n := float64(len(trialSharpes)); n >= 2 {
+		_, sdSharpe := meanStd(trialSharpes)
+		const eulerGamma = 0.5772156649015329
+		d.Threshold = sdSharpe * ((1-eulerGamma)*normQuantile(1-1/n) + eulerGamma*normQuantile(1-1/(n*math.E)))
+	}
+
+	variance := 1 - d.Skew*d.Sharpe + (d.Kurtosis-1)/4*d.Sharpe*d.Sharpe
+	if variance <= 0 {
+		variance = 1e-12
+	}
+	d.Probability = normCDF((d.Sharpe - d.Threshold) * math.Sqrt(float64(d.Observations-1)) / math.Sqrt(variance))
+	return d
+}
+
+// PBO is the probability of backtest overfitting estimated with CSCV
+type PBO struct {
+	Probability  float64 `json:"probability"`  // share of splits where the in-sample best is below the out-of-sample median
+	Combinations int     `json:"combinations"` // in-sample/out-of-sample splits evaluated
+	Blocks       int     `json:"blocks"`
+	MeanLogit    float64 `json:"mean_logit"` // mean log-odds of the best trial's out-of-sample rank; negative means overfit
+}
+
+// ComputePBO implements combinatorially symmetric cross-validation (Bailey
+// et al. 2015). The bars of returns (one equal-length series per trial) are
+// cut into blocks; every choice of half the blocks is an in-sample set and
+// the rest out-of-sample. For each split the in-sample Sharpe winner is
+// ranked out-of-sample; PBO is how often it lands in the bottom half.
+func ComputePBO(returns [][]float64, blocks int) (PBO, error) {
+	if blocks < 2 || blocks%2 != 0 {
+		return PBO{}, fmt.Errorf("blocks must be even and at least 2, got %d", blocks)
+	}
+	if len(returns) < 2 {
+		return PBO{}, fmt.Errorf("need at least 2 trials, got %d", len(returns))
+	}
+	bars := len(returns[0])
+	for i, r := range returns {
+		if len(r) != bars {
+			return PBO{}, fmt.Errorf("trial %d has %d bars, want %d", i, len(r), bars)
+		}
+	}
+	if bars < blocks*2 {
+		return PBO{}, fmt.Errorf("need at least %d bars for %d blocks, got %d", blocks*2, blocks, bars)
+	}
+
+	// Per block and trial sums, so each split combines blocks in O(blocks)
+	type moments struct{ n, sum, sumSq float64 }
+	stats := make([][]moments, blocks)
+	for b := range stats {
+		lo, hi := b*bars/blocks, (b+1)*bars/blocks
+		stats[b] = make([]moments, len(returns))
+		for t, series := range returns {
+			m := &stats[b][t]
+			for _, r := range series[lo:hi] {
+				m.n++
+				m.sum += r
+				m.sumSq += r * r
+			}
+		}
+	}
+	sharpe := func(m moments) float64 {
+		mean := m.sum / m.n
+		variance := m.sumSq/m.n - mean*mean
+		if variance <= 0 {
+			return 0
+		}
+		return mean / math.Sqrt(variance)
+	}
+
+	result := PBO{Blocks: blocks}
+	inSample := make([]bool, blocks)
+	isPerf := make([]float64, len(returns))
+	oosPerf := make([]float64, len(returns))
+	var overfit int
+	var logits float64
+	var visit func(next, chosen int)
+	visit = func(next, chosen int) {
+		if chosen == blocks/2 {
+			for t := range returns {
+				var is, oos moments
+				for b := 0; b < blocks; b++ {
+					m, dst := stats[b][t], &oos
+					if inSample[b] {
+						dst = &is
+					}
+					dst.n += m.n
+					dst.sum += m.sum
+					dst.sumSq += m.sumSq
+				}
+				isPerf[t], oosPerf[t] = sharpe(is), sharpe(oos)
+			}
+			best := 0
+			for t := range isPerf {
+				if isPerf[t] > isPerf[best] {
+					best = t
+				}
+			}
+			rank := 1
+			for t := range oosPerf {
+				if oosPerf[t] < oosPerf[best] {
+					rank++
+				}
+			}
+			omega := float64(rank) / float64(len(returns)+1)
+			logit := math.Log(omega / (1 - omega))
+			if logit <= 0 {
+				overfit++
+			}
+			logits += logit
+			result.Combinations++
+			return
+		}
+		for b := next; b <= blocks-(blocks/2-chosen); b++ {
+			inSample[b] = true
+			visit(b+1, chosen+1)
+			inSample[b] = false
+		}
+	}
+	visit(0, 0)
+
+	result.Probability = float64(overfit) / float64(result.Combinations)
+	result.MeanLogit = logits / float64(result.Combinations)
+	return result, nil
+}
+
+// Heatmap is the score surface over two parameters
+type Heatmap struct {
+	X      string       `json:"x"`
+	Y      string       `json:"y"`
+	XTicks []float64    `json:"x_ticks"` // distinct values, or bin centers for continuous searches
+	YTicks []float64    `json:"y_ticks"`
+	Cells  [][]*float64 `json:"cells"` // [y][x] mean score; nil where nothing was evaluated
+
+	// Best is the top cell's score and NeighborMean the mean of its evaluated
+	// neighbours. A robust optimum sits on a plateau where the two are close;
+	// an isolated spike suggests a curve fit.
+	Best         float64 `json:"best"`
+	NeighborMean float64 `json:"neighbor_mean"`
+	Stability    float64 `json:"stability"` // NeighborMean / Best when Best > 0
+}
+
+// StabilityHeatmap bins trials by two parameters and averages their scores.
+// Parameters with at most bins distinct values keep one cell per value.
+func StabilityHeatmap(x, y string, points []map[string]float64, scores []float64, bins int) Heatmap {
+	h := Heatmap{X: x, Y: y}
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	for i, p := range points {
+		xs[i], ys[i] = p[x], p[y]
+	}
+	var xIndex, yIndex func(float64) int
+	h.XTicks, xIndex = axis(xs, bins)
+	h.YTicks, yIndex = axis(ys, bins)
+
+	sums := make([][]float64, len(h.YTicks))
+	counts := make([][]int, len(h.YTicks))
+	for i := range sums {
+		sums[i] = make([]float64, len(h.XTicks))
+		counts[i] = make([]int, len(h.XTicks))
+	}
+	for i := range points {
+		if math.IsNaN(scores[i]) || math.IsInf(scores[i], 0) {
+			continue
+		}
+		row, col := yIndex(ys[i]), xIndex(xs[i])
+		sums[row][col] += scores[i]
+		counts[row][col]++
+	}
+
+	h.Cells = make([][]*float64, len(h.YTicks))
+	bestRow, bestCol := -1, -1
+	for row := range sums {
+		h.Cells[row] = make([]*float64, len(h.XTicks))
+		for col := range sums[row] {
+			if counts[row][col] == 0 {
+				continue
+			}
+			mean := sums[row][col] / float64(counts[row][col])
+			h.Cells[row][col] = &mean
+			if bestRow < 0 || mean > h.Best {
+				h.Best, bestRow, bestCol = mean, row, col
+			}
+		}
+	}
+	if bestRow < 0 {
+		return h
+	}
+
+	var neighbors []float64
+	for row := bestRow - 1; row <= bestRow+1; row++ {
+		for col := bestCol - 1; col <= bestCol+1; col++ {
+			if (row == bestRow && col == bestCol) || row < 0 || col < 0 || row >= len(h.Cells) || col >= len(h.Cells[row]) {
+				continue
+			}
+			if cell := h.Cells[row][col]; cell != nil {
+				neighbors = append(neighbors, *cell)
+			}
+		}
+	}
+	if len(neighbors) > 0 {
+		h.NeighborMean, _ = meanStd(neighbors)
+		if h.Best > 0 {
+			h.Stability = h.NeighborMean / h.Best
+		}
+	}
+	return h
+}
+
+// axis returns the ticks for values and a function mapping a value to its tick
+func axis(values []float64, bins int) ([]float64, func(float64) int) {
+	distinct := append([]float64(nil), values...)
+	sort.Float64s(distinct)
+	ticks := distinct[:0]
+	for i, v := range distinct {
+		if i == 0 || v != distinct[i-1] {
+			ticks = append(ticks, v)
+		}
+	}
+	if len(ticks) <= bins || bins < 1 {
+		return ticks, func(v float64) int { return sort.SearchFloat64s(ticks, v) }
+	}
+
+	lo, hi := ticks[0], ticks[len(ticks)-1]
+	width := (hi - lo) / float64(bins)
+	centers := make([]float64, bins)
+	for i := range centers {
+		centers[i] = lo + width*(float64(i)+0.5)
+	}
+	return centers, func(v float64) int { return min(int((v-lo)/width), bins-1) }
+}
+
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
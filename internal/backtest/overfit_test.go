@@ -0,0 +1,100 @@
+package backtest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// noiseTrials returns n series of zero-mean returns plus, when edge > 0,
+// one last trial with a consistent positive drift
+func noiseTrials(rng *rand.Rand, n, bars int, edge float64) [][]float64 {
+	trials := make([][]float64, n)
+	for t := range trials {
+		trials[t] = make([]float64, bars)
+		for i := range trials[t] {
+			trials[t][i] = rng.NormFloat64() * 0.01
+			if edge > 0 && t == n-1 {
+				trials[t][i] += edge
+			}
+		}
+	}
+	return trials
+}
+
+func TestComputePBO(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	// The in-sample winner among pure noise is no better than a coin flip out-of-sample
+	noise, err := ComputePBO(noiseTrials(rng, 30, 800, 0), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if noise.Combinations != 70 || noise.Probability < 0.25 || noise.Probability > 0.75 {
+		t.Errorf("Noise PBO = %+v, want about 0.5 over 70 splits", noise)
+	}
+
+	// A real edge wins in every split
+	edge, err := ComputePBO(noiseTrials(rng, 30, 800, 0.005), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edge.Probability > 0.05 || edge.MeanLogit <= 0 {
+		t.Errorf("Edge PBO = %+v, want near 0", edge)
+	}
+
+	if _, err := ComputePBO(noiseTrials(rng, 3, 800, 0), 5); err == nil {
+		t.Error("Expected odd block count to be rejected")
+	}
+	if _, err := ComputePBO([][]float64{make([]float64, 10), make([]float64, 9)}, 2); err == nil {
+		t.Error("Expected unequal series to be rejected")
+	}
+}
+
+func TestComputeDeflatedSharpe(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+
+	// The luckiest of 200 noise trials looks good but does not survive deflation
+	noise := noiseTrials(rng, 200, 500, 0)
+	best, bestSharpe := 0, math.Inf(-1)
+	for i, r := range noise {
+		if mean, sd := meanStd(r); mean/sd > bestSharpe {
+			best, bestSharpe = i, mean/sd
+		}
+	}
+	lucky := ComputeDeflatedSharpe(noise, best)
+	if lucky.Trials != 200 || lucky.Threshold <= 0 || lucky.Probability > 0.95 {
+		t.Errorf("Lucky trial deflated Sharpe = %+v, want insignificant", lucky)
+	}
+
+	edge := noiseTrials(rng, 200, 500, 0.004)
+	if d := ComputeDeflatedSharpe(edge, 199); d.Probability < 0.99 {
+		t.Errorf("Edge deflated Sharpe = %+v, want significant", d)
+	}
+}
+
+func TestStabilityHeatmap(t *testing.T) {
+	var points []map[string]float64
+	var scores []float64
+	for _, levels := range []float64{5, 10, 20} {
+		for _, band := range []float64{0.1, 0.2} {
+			points = append(points, map[string]float64{"levels": levels, "band": band})
+			scores = append(scores, levels/10+band)
+		}
+	}
+
+	h := StabilityHeatmap("levels", "band", points, scores, 8)
+	if len(h.XTicks) != 3 || len(h.YTicks) != 2 || *h.Cells[1][2] != 2.2 {
+		t.Fatalf("Unexpected heatmap %+v", h)
+	}
+	// Neighbours of the (20, 0.2) optimum: (10, 0.2), (10, 0.1), (20, 0.1)
+	if h.Best != 2.2 || math.Abs(h.NeighborMean-(1.2+1.1+2.1)/3) > 1e-9 || h.Stability <= 0 {
+		t.Errorf("Unexpected stability best=%v neighbors=%v", h.Best, h.NeighborMean)
+	}
+
+	// Continuous values are binned
+	binned := StabilityHeatmap("levels", "band", points, scores, 2)
+	if len(binned.XTicks) != 2 || binned.Cells[0][0] == nil {
+		t.Errorf("Expected 2 bins, got %+v", binned)
+	}
+}
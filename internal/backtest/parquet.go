@@ -0,0 +1,146 @@
+package backtest
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetBatch is the number of rows decoded per read, bounding memory use
+const parquetBatch = 8192
+
+// parquetCandle is the on-disk candle schema
+type parquetCandle struct {
+	Timestamp int64   `parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Open      float64 `parquet:"name=open, type=DOUBLE"`
+	High      float64 `parquet:"name=high, type=DOUBLE"`
+	Low       float64 `parquet:"name=low, type=DOUBLE"`
+	Close     float64 `parquet:"name=close, type=DOUBLE"`
+	Volume    float64 `parquet:"name=volume, type=DOUBLE"`
+}
+
+// ParquetIterator streams candles from a Parquet file in row batches
+type ParquetIterator struct {
+	file    *localFile
+	pr      *reader.ParquetReader
+	rows    int64
+	read    int64
+	batch   []parquetCandle
+	pos     int
+	current Candle
+	err     error
+}
+
+// OpenParquet opens a Parquet file with timestamp (ms), open, high, low, close and volume columns
+func OpenParquet(path string) (*ParquetIterator, error) {
+	f, err := openLocalFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := reader.NewParquetReader(f, new(parquetCandle), 1)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read parquet %s: %w", path, err)
+	}
+	return &ParquetIterator{file: f, pr: pr, rows: pr.GetNumRows()}, nil
+}
+
+func (it *ParquetIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.pos >= len(it.batch) {
+		if it.read >= it.rows {
+			return false
+		}
+		n := it.rows - it.read
+		if n > parquetBatch {
+			n = parquetBatch
+		}
+		it.batch = make([]parquetCandle, n)
+		if err := it.pr.Read(&it.batch); err != nil {
+			it.err = fmt.Errorf("failed to read parquet rows: %w", err)
+			return false
+		}
+		it.read += n
+		it.pos = 0
+		if len(it.batch) == 0 {
+			return false
+		}
+	}
+
+	row := it.batch[it.pos]
+	it.pos++
+	it.current = Candle{
+		Time:   time.UnixMilli(row.Timestamp).UTC(),
+		Open:   row.Open,
+		High:   row.High,
+		Low:    row.Low,
+		Close:  row.Close,
+		Volume: row.Volume,
+	}
+	return true
+}
+
+func (it *ParquetIterator) Candle() Candle { return it.current }
+
+func (it *ParquetIterator) Err() error { return it.err }
+
+func (it *ParquetIterator) Close() error {
+	it.pr.ReadStop()
+	return it.file.Close()
+}
+
+// WriteParquet writes candles to a Parquet file readable by OpenParquet
+func WriteParquet(path string, candles []Candle) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	pw, err := writer.NewParquetWriterFromWriter(f, new(parquetCandle), 1)
+	if err != nil {
+		return fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	for _, c := range candles {
+		row := parquetCandle{Timestamp: c.Time.UnixMilli(), Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume}
+		if err := pw.Write(row); err != nil {
+			return fmt.Errorf("failed to write parquet row: %w", err)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return f.Close()
+}
+
+// localFile adapts *os.File to source.ParquetFile; the reader reopens
+// the file once per column chunk
+type localFile struct {
+	*os.File
+	path string
+}
+
+func openLocalFile(path string) (*localFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &localFile{File: f, path: path}, nil
+}
+
+func (f *localFile) Open(name string) (source.ParquetFile, error) {
+	if name == "" {
+		name = f.path
+	}
+	return openLocalFile(name)
+}
+
+func (f *localFile) Create(name string) (source.ParquetFile, error) {
+	return nil, fmt.Errorf("parquet source %s is read-only", f.path)
+}
@@ -0,0 +1,253 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// PortfolioLeg is one strategy trading one symbol in a portfolio backtest.
+// Exactly one of DCA, Grid and Build is set.
+type PortfolioLeg struct {
+	Name    string // defaults to the symbol
+	Symbol  string
+	Candles []Candle // chronological; legs may use different bar times
+	DCA     *types.DCAConfig
+	Grid    *types.GridConfig
+	Build   StrategyBuilder
+}
+
+// LegResult is one leg's part in a portfolio backtest
+type LegResult struct {
+	Name           string  `json:"name"`
+	Symbol         string  `json:"symbol"`
+	TradeCount     int     `json:"trade_count"`
+	WinRate        float64 `json:"win_rate"` // %
+	TotalFees      float64 `json:"total_fees"`
+	PnL            float64 `json:"pnl"`             // realized and unrealized, after fees
+	MaxExposure    float64 `json:"max_exposure"`    // largest position value held
+	CashShortfalls int     `json:"cash_shortfalls"` // buys cut or refused because the shared cash ran out
+}
+
+// PortfolioResult is a portfolio backtest: the shared account's performance,
+// each leg's contribution and how the legs moved together
+type PortfolioResult struct {
+	Portfolio PerformanceMetrics `json:"portfolio"`
+	Legs      []LegResult        `json:"legs"`
+	MinCash   float64            `json:"min_cash"` // lowest idle cash; near zero means legs competed for capital
+	// Correlation of the legs' per-bar PnL changes, in leg order
+	Correlation [][]float64 `json:"correlation"`
+}
+
+// portfolioLeg is a leg's simulation state during a portfolio backtest
+type portfolioLeg struct {
+	PortfolioLeg
+	candles []Candle // within the backtest window
+	next    int
+
+	dca      *dcaSim
+	grid     *gridSim
+	exchange *simExchange // of grid and built legs
+	step     func(Candle)
+
+	close       float64 // last close seen
+	pnl         []float64
+	maxExposure float64
+}
+
+// BacktestPortfolio runs every leg against one simulated cash balance of
+// initialBalance. Legs advance together in time order; legs with a bar at the
+// same time trade in the order given, so earlier legs get first call on cash.
+// Buys the remaining cash cannot cover are cut or refused as on a live
+// account, which is how capital contention shows up in the results.
+func (e *Engine) BacktestPortfolio(legs []PortfolioLeg, start, end time.Time, initialBalance float64) (*PortfolioResult, error) {
+	if len(legs) == 0 {
+		return nil, fmt.Errorf("portfolio has no legs")
+	}
+	shared := &wallet{cash: initialBalance}
+	sims := make([]*portfolioLeg, 0, len(legs))
+	for _, leg := range legs {
+		if leg.Name == "" {
+			leg.Name = leg.Symbol
+		}
+		sim, err := e.newPortfolioLeg(leg, start, shared)
+		if err != nil {
+			return nil, fmt.Errorf("leg %s: %w", leg.Name, err)
+		}
+		sim.candles = candlesBetween(leg.Candles, start, end)
+		sims = append(sims, sim)
+	}
+
+	result := &PortfolioResult{MinCash: initialBalance}
+	var equity []float64
+	for {
+		// The next bar time of any leg
+		var now time.Time
+		for _, sim := range sims {
+			if sim.next < len(sim.candles) && (now.IsZero() || sim.candles[sim.next].Time.Before(now)) {
+				now = sim.candles[sim.next].Time
+			}
+		}
+		if now.IsZero() {
+			break
+		}
+
+		value := 0.0
+		for _, sim := range sims {
+			if sim.next < len(sim.candles) && sim.candles[sim.next].Time.Equal(now) {
+				c := sim.candles[sim.next]
+				sim.step(c)
+				sim.close = c.Close
+				sim.next++
+			}
+			exposure := sim.quantity() * sim.close
+			sim.maxExposure = math.Max(sim.maxExposure, exposure)
+			sim.pnl = append(sim.pnl, exposure-sim.spent())
+			value += exposure
+		}
+		result.MinCash = math.Min(result.MinCash, shared.cash)
+		equity = append(equity, shared.cash+value)
+	}
+	if len(equity) == 0 {
+		return nil, fmt.Errorf("no candles between %s and %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	var trades, wins int
+	var fees float64
+	changes := make([][]float64, len(sims))
+	for i, sim := range sims {
+		leg, legWins := sim.result()
+		trades += leg.TradeCount
+		wins += legWins
+		fees += leg.TotalFees
+		result.Legs = append(result.Legs, leg)
+		for j := 1; j < len(sim.pnl); j++ {
+			changes[i] = append(changes[i], sim.pnl[j]-sim.pnl[j-1])
+		}
+	}
+	result.Portfolio = computePerformance(equity, end.Sub(start), trades, wins, fees)
+	result.Correlation = correlationMatrix(changes)
+	return result, nil
+}
+
+// newPortfolioLeg builds the leg's simulation on the shared wallet
+func (e *Engine) newPortfolioLeg(leg PortfolioLeg, start time.Time, shared *wallet) (*portfolioLeg, error) {
+	sim := &portfolioLeg{PortfolioLeg: leg}
+	switch {
+	case leg.DCA != nil && leg.Grid == nil && leg.Build == nil:
+		sim.dca = e.newDCASim(start, *leg.DCA, 0)
+		sim.dca.wallet = shared
+		sim.step = func(c Candle) { sim.dca.step(c) }
+	case leg.Grid != nil && leg.DCA == nil && leg.Build == nil:
+		grid, err := e.newGridSim(leg.Symbol, *leg.Grid, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grid config: %w", err)
+		}
+		grid.exchange.wallet = shared
+		sim.grid, sim.exchange = grid, grid.exchange
+		sim.step = func(c Candle) { grid.step(c) }
+	case leg.Build != nil && leg.DCA == nil && leg.Grid == nil:
+		exchange := newSimExchange(leg.Symbol, nil, e.fees(), 0)
+		exchange.slippage = e.slippage
+		exchange.wallet = shared
+		strat, err := leg.Build(exchange)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build strategy: %w", err)
+		}
+		sim.exchange = exchange
+		sim.step = func(c Candle) {
+			exchange.push(c, gridHistory)
+			ctx := context.Background()
+			ticker, _ := exchange.GetTicker(ctx, leg.Symbol)
+			// Execution errors (e.g. insufficient balance) are part of the simulation
+			_ = strat.Execute(ctx, e.marketData(leg.Symbol, c, ticker))
+		}
+	default:
+		return nil, fmt.Errorf("set exactly one of dca, grid and build")
+	}
+	return sim, nil
+}
+
+func (l *portfolioLeg) quantity() float64 {
+	if l.dca != nil {
+		return l.dca.qty
+	}
+	return l.exchange.qty
+}
+
+// spent is the cash the leg has taken from the wallet, net of sales
+func (l *portfolioLeg) spent() float64 {
+	if l.dca != nil {
+		return l.dca.spent
+	}
+	return l.exchange.spent
+}
+
+// result summarizes the leg and returns its winning trades
+func (l *portfolioLeg) result() (LegResult, int) {
+	leg := LegResult{Name: l.Name, Symbol: l.Symbol, MaxExposure: l.maxExposure}
+	var wins int
+	switch {
+	case l.dca != nil:
+		leg.TradeCount, wins, leg.TotalFees, leg.CashShortfalls = l.dca.trades, l.dca.wins(l.close), l.dca.totalFees, l.dca.shortfalls
+	case l.grid != nil:
+		leg.TradeCount, wins, leg.TotalFees, leg.CashShortfalls = l.grid.trades(), l.grid.wins(), l.exchange.totalFees, l.exchange.rejected
+	default:
+		leg.TradeCount, wins, leg.TotalFees, leg.CashShortfalls = l.exchange.trades, l.exchange.wins, l.exchange.totalFees, l.exchange.rejected
+	}
+	if leg.TradeCount > 0 {
+		leg.WinRate = float64(wins) / float64(leg.TradeCount) * 100
+	}
+	if n := len(l.pnl); n > 0 {
+		leg.PnL = l.pnl[n-1]
+	}
+	return leg, wins
+}
+
+// correlationMatrix returns the pairwise Pearson correlation of series,
+// compared over their common length; a flat series correlates 0
+func correlationMatrix(series [][]float64) [][]float64 {
+	out := make([][]float64, len(series))
+	for i := range series {
+		out[i] = make([]float64, len(series))
+		for j := range series {
+			if i == j {
+				out[i][j] = 1
+				continue
+			}
+			out[i][j] = correlation(series[i], series[j])
+		}
+	}
+	return out
+}
+
+func correlation(a, b []float64) float64 {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	if n < 2 {
+		return 0
+	}
+	var meanA, meanB float64
+	for k := 0; k < n; k++ {
+		meanA += a[k]
+		meanB += b[k]
+	}
+	meanA /= float64(n)
+	meanB /= float64(n)
+	var cov, varA, varB float64
+	for k := 0; k < n; k++ {
+		da, db := a[k]-meanA, b[k]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
@@ -0,0 +1,64 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestBacktestPortfolio_SharedCash(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var btc, eth []Candle
+	for i, price := range []float64{100, 110, 120, 130, 140} {
+		c := Candle{Time: day.Add(time.Duration(i) * 24 * time.Hour), Close: price}
+		btc = append(btc, c)
+		// ETH bars start a day later and move with BTC
+		if i > 0 {
+			c.Close = price / 10
+			eth = append(eth, c)
+		}
+	}
+	dca := types.DCAConfig{InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 10}
+	start, end := btc[0].Time, btc[len(btc)-1].Time
+	eng := NewEngine(0)
+
+	result, err := eng.BacktestPortfolio([]PortfolioLeg{
+		{Symbol: "BTCUSDT", Candles: btc, DCA: &dca},
+		{Symbol: "ETHUSDT", Candles: eth, DCA: &dca},
+	}, start, end, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// BTC buys on days 0 and 1, ETH on day 1; then both run out of cash
+	btcLeg, ethLeg := result.Legs[0], result.Legs[1]
+	if btcLeg.TradeCount != 2 || ethLeg.TradeCount != 1 || btcLeg.CashShortfalls != 1 || ethLeg.CashShortfalls != 1 {
+		t.Fatalf("legs = %+v, want 2 and 1 buys with a shortfall each", result.Legs)
+	}
+	if result.MinCash != 0 || result.Portfolio.TradeCount != 3 {
+		t.Errorf("min cash %.2f, trades %d; want 0 and 3", result.MinCash, result.Portfolio.TradeCount)
+	}
+	btcPnL := 100.0/100*140 + 100.0/110*140 - 200
+	ethPnL := 100.0/11*14 - 100
+	if math.Abs(btcLeg.PnL-btcPnL) > 1e-9 || math.Abs(ethLeg.PnL-ethPnL) > 1e-9 {
+		t.Errorf("PnL = %.4f, %.4f; want %.4f, %.4f", btcLeg.PnL, ethLeg.PnL, btcPnL, ethPnL)
+	}
+	if want := (btcPnL + ethPnL) / 300 * 100; math.Abs(result.Portfolio.TotalReturn-want) > 1e-9 {
+		t.Errorf("portfolio return = %.4f%%, want %.4f%%", result.Portfolio.TotalReturn, want)
+	}
+	if c := result.Correlation[0][1]; c <= 0.9 || c != result.Correlation[1][0] {
+		t.Errorf("correlation = %v, want the legs to move together", result.Correlation)
+	}
+
+	// Alone, the BTC leg has the cash for every buy
+	alone := eng.BacktestDCA("BTCUSDT", btc, start, end, dca, 300)
+	if alone.TradeCount != 3 {
+		t.Errorf("standalone BTC DCA made %d buys, want 3", alone.TradeCount)
+	}
+
+	if _, err := eng.BacktestPortfolio([]PortfolioLeg{{Symbol: "BTCUSDT", Candles: btc}}, start, end, 300); err == nil {
+		t.Error("expected an error for a leg without a strategy")
+	}
+}
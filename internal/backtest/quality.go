@@ -0,0 +1,297 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Data quality issue kinds
+const (
+	IssueGap        = "gap"
+	IssueDuplicate  = "duplicate"
+	IssueOutOfOrder = "out_of_order"
+	IssueInvalid    = "invalid"     // non-positive prices or OHLC outside the high/low range
+	IssueZeroVolume = "zero_volume" // bars with no trades, often an exchange outage
+	IssueOutlier    = "outlier"     // close-to-close move far outside recent volatility
+)
+
+// QualityOptions configures candle checks; zero fields take defaults
+type QualityOptions struct {
+	Interval         time.Duration // expected bar spacing; inferred when zero
+	OutlierThreshold float64       // robust z-score of a log return that counts as an outlier (default 12)
+	OutlierWindow    int           // returns the robust volatility is measured over (default 50)
+	MaxIssues        int           // issues kept in the report; counts stay complete (default 100)
+}
+
+func (o QualityOptions) withDefaults() QualityOptions {
+	if o.OutlierThreshold <= 0 {
+		o.OutlierThreshold = 12
+	}
+	if o.OutlierWindow <= 0 {
+		o.OutlierWindow = 50
+	}
+	if o.MaxIssues <= 0 {
+		o.MaxIssues = 100
+	}
+	return o
+}
+
+// Issue is one data quality finding
+type Issue struct {
+	Kind   string    `json:"kind"`
+	Time   time.Time `json:"time"`
+	Detail string    `json:"detail"`
+}
+
+// Gap is a run of missing bars between Start and End, inclusive
+type Gap struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Missing int       `json:"missing"`
+}
+
+// QualityReport summarizes the checks over a candle series
+type QualityReport struct {
+	Candles  int            `json:"candles"`
+	Interval time.Duration  `json:"interval"`
+	First    time.Time      `json:"first"`
+	Last     time.Time      `json:"last"`
+	Counts   map[string]int `json:"counts"`
+	Gaps     []Gap          `json:"gaps,omitempty"`
+	Issues   []Issue        `json:"issues,omitempty"` // the first MaxIssues findings
+}
+
+// OK reports whether no issue was found
+func (r *QualityReport) OK() bool {
+	return len(r.Counts) == 0
+}
+
+// Missing returns the number of bars missing across all gaps
+func (r *QualityReport) Missing() int {
+	missing := 0
+	for _, gap := range r.Gaps {
+		missing += gap.Missing
+	}
+	return missing
+}
+
+// Summary is a one-line description such as "2 gaps (14 missing bars), 1 duplicate"
+func (r *QualityReport) Summary() string {
+	if r.OK() {
+		return "no issues"
+	}
+	kinds := make([]string, 0, len(r.Counts))
+	for kind := range r.Counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		part := fmt.Sprintf("%d %s", r.Counts[kind], kind)
+		if kind == IssueGap {
+			part += fmt.Sprintf(" (%d missing bars)", r.Missing())
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// QualityChecker inspects candles one at a time, so large files can be
+// checked while streaming. Without a configured interval the spacing of the
+// first two distinct bars is taken as the interval.
+type QualityChecker struct {
+	opts    QualityOptions
+	report  QualityReport
+	prev    Candle
+	started bool
+	returns []float64 // recent log returns for the outlier check
+	ref     float64   // last trusted close
+	suspect bool      // the previous close was flagged as an outlier
+}
+
+// NewQualityChecker creates a checker for opts
+func NewQualityChecker(opts QualityOptions) *QualityChecker {
+	opts = opts.withDefaults()
+	return &QualityChecker{
+		opts:   opts,
+		report: QualityReport{Interval: opts.Interval, Counts: make(map[string]int)},
+	}
+}
+
+// Add checks the next candle against the previous one
+func (q *QualityChecker) Add(c Candle) {
+	q.report.Candles++
+	if c.Open <= 0 || c.High <= 0 || c.Low <= 0 || c.Close <= 0 || c.High < c.Low ||
+		math.Max(c.Open, c.Close) > c.High || math.Min(c.Open, c.Close) < c.Low {
+		q.add(IssueInvalid, c.Time, fmt.Sprintf("O %.8g H %.8g L %.8g C %.8g", c.Open, c.High, c.Low, c.Close))
+	}
+	if c.Volume == 0 {
+		q.add(IssueZeroVolume, c.Time, "no volume")
+	}
+
+	if !q.started {
+		q.started = true
+		q.report.First, q.report.Last = c.Time, c.Time
+		q.prev, q.ref = c, c.Close
+		return
+	}
+
+	spacing := c.Time.Sub(q.prev.Time)
+	switch {
+	case spacing == 0:
+		q.add(IssueDuplicate, c.Time, "repeated timestamp")
+		return
+	case spacing < 0:
+		q.add(IssueOutOfOrder, c.Time, fmt.Sprintf("after %s", q.prev.Time.Format(time.RFC3339)))
+		return
+	}
+
+	if q.report.Interval == 0 {
+		q.report.Interval = spacing
+	}
+	if interval := q.report.Interval; spacing > interval+interval/2 {
+		gap := Gap{
+			Start:   q.prev.Time.Add(interval),
+			End:     c.Time.Add(-interval),
+			Missing: int(math.Round(float64(spacing)/float64(interval))) - 1,
+		}
+		q.report.Gaps = append(q.report.Gaps, gap)
+		q.add(IssueGap, gap.Start, fmt.Sprintf("%d bars missing until %s", gap.Missing, c.Time.Format(time.RFC3339)))
+	}
+
+	// Moves are measured from the last trusted close, so a single bad print
+	// is flagged once rather than again when price returns. A second outlier
+	// in a row means the level really shifted.
+	if q.ref > 0 && c.Close > 0 {
+		r := math.Log(c.Close / q.ref)
+		z, ok := q.robustZ(r)
+		switch {
+		case ok && math.Abs(z) > q.opts.OutlierThreshold && !q.suspect:
+			q.add(IssueOutlier, c.Time, fmt.Sprintf("close %.8g after %.8g (robust z %.1f)", c.Close, q.ref, z))
+			q.suspect = true
+		case ok && math.Abs(z) > q.opts.OutlierThreshold:
+			q.ref, q.suspect = c.Close, false
+		default:
+			q.returns = append(q.returns, r)
+			if len(q.returns) > q.opts.OutlierWindow {
+				q.returns = q.returns[1:]
+			}
+			q.ref, q.suspect = c.Close, false
+		}
+	} else if c.Close > 0 {
+		q.ref = c.Close
+	}
+
+	q.report.Last = c.Time
+	q.prev = c
+}
+
+// robustZ scores r against the median and MAD of recent returns
+func (q *QualityChecker) robustZ(r float64) (float64, bool) {
+	if len(q.returns) < 10 {
+		return 0, false
+	}
+	sorted := append([]float64(nil), q.returns...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	for i, v := range sorted {
+		sorted[i] = math.Abs(v - median)
+	}
+	sort.Float64s(sorted)
+	mad := sorted[len(sorted)/2] * 1.4826
+	if mad == 0 {
+		return 0, false
+	}
+	return (r - median) / mad, true
+}
+
+func (q *QualityChecker) add(kind string, t time.Time, detail string) {
+	q.report.Counts[kind]++
+	if len(q.report.Issues) < q.opts.MaxIssues {
+		q.report.Issues = append(q.report.Issues, Issue{Kind: kind, Time: t, Detail: detail})
+	}
+}
+
+// Report returns the findings so far
+func (q *QualityChecker) Report() QualityReport {
+	return q.report
+}
+
+// CheckCandles checks a candle series. Without a configured interval the
+// median spacing is used, which is robust to gaps early in the series.
+func CheckCandles(candles []Candle, opts QualityOptions) QualityReport {
+	if opts.Interval == 0 {
+		opts.Interval = medianSpacing(candles)
+	}
+	checker := NewQualityChecker(opts)
+	for _, c := range candles {
+		checker.Add(c)
+	}
+	return checker.Report()
+}
+
+// CheckIterator checks every candle of it and closes it
+func CheckIterator(it CandleIterator, opts QualityOptions) (QualityReport, error) {
+	defer it.Close()
+
+	checker := NewQualityChecker(opts)
+	for it.Next() {
+		checker.Add(it.Candle())
+	}
+	return checker.Report(), it.Err()
+}
+
+// medianSpacing returns the median positive spacing between consecutive candles
+func medianSpacing(candles []Candle) time.Duration {
+	spacings := make([]time.Duration, 0, len(candles))
+	for i := 1; i < len(candles); i++ {
+		if d := candles[i].Time.Sub(candles[i-1].Time); d > 0 {
+			spacings = append(spacings, d)
+		}
+	}
+	if len(spacings) == 0 {
+		return 0
+	}
+	sort.Slice(spacings, func(i, j int) bool { return spacings[i] < spacings[j] })
+	return spacings[len(spacings)/2]
+}
+
+// RepairGaps fills each gap with candles from fetch, which should return the
+// bars within [start, end]. The result is sorted with one candle per
+// timestamp, keeping the first original candle of a repeated timestamp and
+// never replacing an original with a fetched one. It returns the number of
+// bars added.
+func RepairGaps(ctx context.Context, candles []Candle, gaps []Gap, fetch func(ctx context.Context, start, end time.Time) ([]Candle, error)) ([]Candle, int, error) {
+	seen := make(map[int64]bool, len(candles))
+	repaired := make([]Candle, 0, len(candles))
+	for _, c := range candles {
+		if !seen[c.Time.UnixNano()] {
+			seen[c.Time.UnixNano()] = true
+			repaired = append(repaired, c)
+		}
+	}
+
+	added := 0
+	for _, gap := range gaps {
+		fetched, err := fetch(ctx, gap.Start, gap.End)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to fetch gap at %s: %w", gap.Start.Format(time.RFC3339), err)
+		}
+		for _, c := range fetched {
+			if c.Time.Before(gap.Start) || c.Time.After(gap.End) || seen[c.Time.UnixNano()] {
+				continue
+			}
+			seen[c.Time.UnixNano()] = true
+			repaired = append(repaired, c)
+			added++
+		}
+	}
+
+	sortCandles(repaired)
+	return repaired, added, nil
+}
@@ -0,0 +1,58 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCheckCandles(t *testing.T) {
+	candles, err := GenerateSynthetic(ScenarioConfig(SIDEWAYS_MARKET, 200, 3))
+	if err != nil {
+		t.Fatalf("GenerateSynthetic() error = %v", err)
+	}
+	if report := CheckCandles(candles, QualityOptions{}); !report.OK() || report.Interval != time.Hour {
+		t.Fatalf("Expected clean hourly data, got %s every %s: %+v", report.Summary(), report.Interval, report.Issues)
+	}
+
+	// Drop bars 50-54, repeat bar 100, zero the volume of bar 120 and spike bar 150
+	broken := append([]Candle(nil), candles[:50]...)
+	broken = append(broken, candles[55:101]...)
+	broken = append(broken, candles[100:]...)
+	for i := range broken {
+		switch broken[i].Time {
+		case candles[120].Time:
+			broken[i].Volume = 0
+		case candles[150].Time:
+			broken[i].Close *= 3
+			broken[i].High = broken[i].Close
+		}
+	}
+
+	report := CheckCandles(broken, QualityOptions{})
+	want := map[string]int{IssueGap: 1, IssueDuplicate: 1, IssueZeroVolume: 1, IssueOutlier: 1}
+	for kind, n := range want {
+		if report.Counts[kind] != n {
+			t.Errorf("Expected %d %s, got %d (%s)", n, kind, report.Counts[kind], report.Summary())
+		}
+	}
+	if len(report.Gaps) != 1 || report.Gaps[0].Missing != 5 || !report.Gaps[0].Start.Equal(candles[50].Time) || !report.Gaps[0].End.Equal(candles[54].Time) {
+		t.Errorf("Unexpected gaps %+v", report.Gaps)
+	}
+
+	fetched := 0
+	repaired, added, err := RepairGaps(context.Background(), broken, report.Gaps, func(ctx context.Context, start, end time.Time) ([]Candle, error) {
+		fetched++
+		// Exchanges return whole pages; bars outside the gap must be ignored
+		return candles[45:60], nil
+	})
+	if err != nil {
+		t.Fatalf("RepairGaps() error = %v", err)
+	}
+	if fetched != 1 || added != 5 || len(repaired) != len(candles) {
+		t.Errorf("Expected 5 bars added to restore %d candles, got %d added and %d candles", len(candles), added, len(repaired))
+	}
+	if after := CheckCandles(repaired, QualityOptions{}); after.Counts[IssueGap] != 0 || after.Counts[IssueDuplicate] != 0 {
+		t.Errorf("Expected no gaps or duplicates after repair, got %s", after.Summary())
+	}
+}
@@ -0,0 +1,259 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// MarketSegment is a contiguous slice of history with a single market condition
+type MarketSegment struct {
+	Condition   MarketCondition `json:"condition"`
+	Start       time.Time       `json:"start"`
+	End         time.Time       `json:"end"`
+	Bars        int             `json:"bars"`
+	PriceChange float64         `json:"price_change"` // %
+}
+
+// SegmentOptions controls regime segmentation
+type SegmentOptions struct {
+	Window    int     `json:"window"`    // bars used for the trailing return
+	Threshold float64 `json:"threshold"` // trailing return separating trends from sideways
+	MinBars   int     `json:"min_bars"`  // shorter segments are merged into neighbours
+}
+
+// DefaultSegmentOptions suits hourly candles
+func DefaultSegmentOptions() SegmentOptions {
+	return SegmentOptions{Window: 72, Threshold: 0.05, MinBars: 48}
+}
+
+// SegmentResult holds per-strategy metrics for one segment
+type SegmentResult struct {
+	Segment MarketSegment                 `json:"segment"`
+	Results map[string]PerformanceMetrics `json:"results"`
+}
+
+// RegimeSummary aggregates strategy performance over all segments of a condition
+type RegimeSummary struct {
+	Condition      MarketCondition    `json:"condition"`
+	Segments       int                `json:"segments"`
+	Bars           int                `json:"bars"`
+	AvgReturn      map[string]float64 `json:"avg_return"`       // %
+	AvgMaxDrawdown map[string]float64 `json:"avg_max_drawdown"` // %
+	BestCount      map[string]int     `json:"best_count"`       // segments where the strategy had the best return
+}
+
+// RegimeComparison is the regime-comparison table across strategies
+type RegimeComparison struct {
+	Segments []SegmentResult `json:"segments"`
+	Regimes  []RegimeSummary `json:"regimes"`
+}
+
+// StrategyRunner backtests one strategy over candles within [start, end]
+type StrategyRunner func(candles []Candle, start, end time.Time, initialBalance float64) (PerformanceMetrics, error)
+
+// StrategyBuilder creates a fresh strategy bound to the given exchange
+type StrategyBuilder func(exchange types.ExchangeClient) (strategy.Strategy, error)
+
+// DCARunner adapts BacktestDCA to a StrategyRunner
+func (e *Engine) DCARunner(symbol string, cfg types.DCAConfig) StrategyRunner {
+	return func(candles []Candle, start, end time.Time, initialBalance float64) (PerformanceMetrics, error) {
+		return e.BacktestDCA(symbol, candles, start, end, cfg, initialBalance), nil
+	}
+}
+
+// GridRunner adapts BacktestGrid to a StrategyRunner
+func (e *Engine) GridRunner(symbol string, cfg types.GridConfig) StrategyRunner {
+	return func(candles []Candle, start, end time.Time, initialBalance float64) (PerformanceMetrics, error) {
+		metrics, _, err := e.gridSeries(symbol, candles, start, end, cfg, initialBalance)
+		return metrics, err
+	}
+}
+
+// Runner adapts any Strategy to a StrategyRunner using a simulated exchange
+func (e *Engine) Runner(symbol string, build StrategyBuilder) StrategyRunner {
+	return func(candles []Candle, start, end time.Time, initialBalance float64) (PerformanceMetrics, error) {
+		return e.BacktestStrategy(symbol, candles, start, end, build, initialBalance)
+	}
+}
+
+// BacktestStrategy replays candles through a live Strategy implementation
+func (e *Engine) BacktestStrategy(symbol string, candles []Candle, start, end time.Time, build StrategyBuilder, initialBalance float64) (PerformanceMetrics, error) {
+	sim := newSimExchange(symbol, candles, e.fees(), initialBalance)
+	sim.slippage = e.slippage
+	strat, err := build(sim)
+	if err != nil {
+		return PerformanceMetrics{}, fmt.Errorf("failed to build strategy: %w", err)
+	}
+
+	ctx := context.Background()
+	var equity []float64
+	var window []Candle
+	for i, c := range candles {
+		if c.Time.Before(start) || c.Time.After(end) {
+			continue
+		}
+		window = append(window, c)
+		sim.advance(i)
+		ticker, _ := sim.GetTicker(ctx, symbol)
+		// Execution errors (e.g. insufficient balance) are part of the simulation
+		_ = strat.Execute(ctx, e.marketData(symbol, c, ticker))
+		equity = append(equity, sim.equity())
+	}
+	_ = strat.Shutdown(ctx)
+
+	metrics := computePerformance(equity, end.Sub(start), sim.trades, sim.wins, sim.totalFees)
+	return e.withBase(metrics, symbol, equity, window, end.Sub(start)), nil
+}
+
+// DetectSegments slices candles into bull/bear/sideways segments using a trailing return
+func DetectSegments(candles []Candle, opts SegmentOptions) []MarketSegment {
+	if len(candles) == 0 {
+		return nil
+	}
+	if opts.Window <= 0 {
+		opts.Window = 1
+	}
+
+	labels := make([]MarketCondition, len(candles))
+	for i := range candles {
+		from := i - opts.Window
+		if from < 0 {
+			from = 0
+		}
+		labels[i] = SIDEWAYS_MARKET
+		if candles[from].Close <= 0 {
+			continue
+		}
+		chg := candles[i].Close/candles[from].Close - 1
+		switch {
+		case chg > opts.Threshold:
+			labels[i] = BULL_MARKET
+		case chg < -opts.Threshold:
+			labels[i] = BEAR_MARKET
+		}
+	}
+
+	// Group consecutive labels into index ranges
+	type span struct {
+		cond     MarketCondition
+		from, to int
+	}
+	var spans []span
+	for i, l := range labels {
+		if len(spans) > 0 && spans[len(spans)-1].cond == l {
+			spans[len(spans)-1].to = i
+			continue
+		}
+		spans = append(spans, span{cond: l, from: i, to: i})
+	}
+
+	// Merge short spans into the previous one (or the next for the first span)
+	var merged []span
+	for _, s := range spans {
+		if len(merged) > 0 && (s.to-s.from+1 < opts.MinBars || merged[len(merged)-1].cond == s.cond) {
+			merged[len(merged)-1].to = s.to
+			continue
+		}
+		merged = append(merged, s)
+	}
+	if len(merged) > 1 && merged[0].to-merged[0].from+1 < opts.MinBars {
+		merged[1].from = merged[0].from
+		merged = merged[1:]
+	}
+
+	segments := make([]MarketSegment, 0, len(merged))
+	for _, s := range merged {
+		first, last := candles[s.from], candles[s.to]
+		change := 0.0
+		if first.Open > 0 {
+			change = (last.Close/first.Open - 1) * 100
+		}
+		segments = append(segments, MarketSegment{
+			Condition:   s.cond,
+			Start:       first.Time,
+			End:         last.Time,
+			Bars:        s.to - s.from + 1,
+			PriceChange: change,
+		})
+	}
+	return segments
+}
+
+// CompareAcrossRegimes runs every strategy on each detected segment and aggregates per regime
+func (e *Engine) CompareAcrossRegimes(candles []Candle, initialBalance float64, runners map[string]StrategyRunner, opts SegmentOptions) (*RegimeComparison, error) {
+	if len(runners) == 0 {
+		return nil, fmt.Errorf("at least one strategy runner is required")
+	}
+
+	names := make([]string, 0, len(runners))
+	for name := range runners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	comparison := &RegimeComparison{}
+	summaries := make(map[MarketCondition]*RegimeSummary)
+
+	for _, seg := range DetectSegments(candles, opts) {
+		window := candlesBetween(candles, seg.Start, seg.End)
+		result := SegmentResult{Segment: seg, Results: make(map[string]PerformanceMetrics)}
+		for _, name := range names {
+			metrics, err := runners[name](window, seg.Start, seg.End, initialBalance)
+			if err != nil {
+				return nil, fmt.Errorf("%s failed on %s segment: %w", name, seg.Condition, err)
+			}
+			result.Results[name] = metrics
+		}
+		comparison.Segments = append(comparison.Segments, result)
+
+		summary, ok := summaries[seg.Condition]
+		if !ok {
+			summary = &RegimeSummary{
+				Condition:      seg.Condition,
+				AvgReturn:      make(map[string]float64),
+				AvgMaxDrawdown: make(map[string]float64),
+				BestCount:      make(map[string]int),
+			}
+			summaries[seg.Condition] = summary
+		}
+		summary.Segments++
+		summary.Bars += seg.Bars
+
+		best := ""
+		for _, name := range names {
+			m := result.Results[name]
+			summary.AvgReturn[name] += m.TotalReturn
+			summary.AvgMaxDrawdown[name] += m.MaxDrawdown
+			if best == "" || m.TotalReturn > result.Results[best].TotalReturn {
+				best = name
+			}
+		}
+		summary.BestCount[best]++
+	}
+
+	for _, cond := range marketConditions {
+		summary, ok := summaries[cond]
+		if !ok {
+			continue
+		}
+		for _, name := range names {
+			summary.AvgReturn[name] /= float64(summary.Segments)
+			summary.AvgMaxDrawdown[name] /= float64(summary.Segments)
+		}
+		comparison.Regimes = append(comparison.Regimes, *summary)
+	}
+
+	return comparison, nil
+}
+
+// candlesBetween returns the candles within [start, end]
+func candlesBetween(candles []Candle, start, end time.Time) []Candle {
+	from := sort.Search(len(candles), func(i int) bool { return !candles[i].Time.Before(start) })
+	to := sort.Search(len(candles), func(i int) bool { return candles[i].Time.After(end) })
+	return candles[from:to]
+}
@@ -0,0 +1,86 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// trendCandles builds hourly candles moving by step per bar for each leg
+func trendCandles(start float64, legs ...[2]float64) []Candle {
+	var out []Candle
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	price := start
+	for _, leg := range legs {
+		for i := 0; i < int(leg[0]); i++ {
+			next := price * (1 + leg[1])
+			out = append(out, Candle{Time: ts, Open: price, High: next, Low: price, Close: next, Volume: 1})
+			price = next
+			ts = ts.Add(time.Hour)
+		}
+	}
+	return out
+}
+
+func TestDetectSegments(t *testing.T) {
+	candles := trendCandles(100, [2]float64{200, 0.002}, [2]float64{200, -0.002})
+	segments := DetectSegments(candles, SegmentOptions{Window: 24, Threshold: 0.03, MinBars: 24})
+
+	if len(segments) < 2 {
+		t.Fatalf("Expected at least 2 segments, got %d", len(segments))
+	}
+	if segments[0].Condition != BULL_MARKET {
+		t.Errorf("Expected first segment bull, got %s", segments[0].Condition)
+	}
+	if segments[len(segments)-1].Condition != BEAR_MARKET {
+		t.Errorf("Expected last segment bear, got %s", segments[len(segments)-1].Condition)
+	}
+
+	total := 0
+	for _, s := range segments {
+		total += s.Bars
+	}
+	if total != len(candles) {
+		t.Errorf("Expected segments to cover %d bars, got %d", len(candles), total)
+	}
+}
+
+func TestCompareAcrossRegimes_AnyStrategy(t *testing.T) {
+	candles := trendCandles(45000, [2]float64{150, -0.001}, [2]float64{150, 0.001})
+	eng := NewEngine(0.001)
+	gridCfg := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: 38000, UpperPrice: 46000, GridLevels: 9, InvestmentPerLevel: 100, Enabled: true}
+	log := logger.New(logger.LevelError)
+
+	runners := map[string]StrategyRunner{
+		"grid": eng.GridRunner("BTCUSDT", gridCfg),
+		"grid_live": eng.Runner("BTCUSDT", func(exchange types.ExchangeClient) (strategy.Strategy, error) {
+			return strategy.NewGridStrategy(gridCfg, exchange, log)
+		}),
+	}
+
+	cmp, err := eng.CompareAcrossRegimes(candles, 10000, runners, SegmentOptions{Window: 24, Threshold: 0.02, MinBars: 24})
+	if err != nil {
+		t.Fatalf("CompareAcrossRegimes() error = %v", err)
+	}
+	if len(cmp.Segments) == 0 || len(cmp.Regimes) == 0 {
+		t.Fatal("Expected segments and regime summaries")
+	}
+	for _, seg := range cmp.Segments {
+		if _, ok := seg.Results["grid_live"]; !ok {
+			t.Fatalf("Expected live strategy results for segment %+v", seg.Segment)
+		}
+	}
+
+	live, err := eng.BacktestStrategy("BTCUSDT", candles, candles[0].Time, candles[len(candles)-1].Time, func(exchange types.ExchangeClient) (strategy.Strategy, error) {
+		return strategy.NewGridStrategy(gridCfg, exchange, log)
+	}, 10000)
+	if err != nil {
+		t.Fatalf("BacktestStrategy() error = %v", err)
+	}
+	if live.TradeCount == 0 {
+		t.Error("Expected the live grid strategy to trade on simulated candles")
+	}
+}
@@ -0,0 +1,24 @@
+package backtest
+
+import (
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// SetSentiment passes recorded sentiment, with its momentum over window, to
+// the strategies of grid, portfolio and regime backtests. Each candle sees
+// the last point recorded by its close time.
+func (e *Engine) SetSentiment(history *ai.SentimentHistory, window time.Duration) {
+	e.sentiment, e.sentimentWindow = history, window
+}
+
+// marketData is what a strategy sees of candle c
+func (e *Engine) marketData(symbol string, c Candle, ticker *types.Ticker) types.MarketData {
+	market := types.MarketData{Symbol: symbol, Price: c.Close, Volume: c.Volume, Timestamp: c.Time, Ticker: ticker}
+	if e.sentiment != nil {
+		market.Sentiment = e.sentiment.Reading(symbol, c.Time, e.sentimentWindow)
+	}
+	return market
+}
@@ -0,0 +1,227 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// simExchange fills strategy orders against historical candles
+type simExchange struct {
+	symbol   string
+	fees     feeSchedule
+	slippage *SlippageModel
+
+	candles []Candle
+	index   int
+
+	wallet    *wallet
+	qty       float64
+	spent     float64 // cash paid for buys and fees net of sale proceeds
+	rejected  int     // buys refused for lack of cash
+	totalFees float64
+	trades    int
+	wins      int
+	avgCost   float64
+	orders    []types.Order
+	byClient  map[string]int // index into orders by client order id
+	nextID    int
+}
+
+func newSimExchange(symbol string, candles []Candle, fees feeSchedule, initialBalance float64) *simExchange {
+	return &simExchange{symbol: symbol, candles: candles, fees: fees, wallet: &wallet{cash: initialBalance}}
+}
+
+// wallet is the quote cash of a backtest, shared by the strategies of a
+// portfolio backtest
+type wallet struct {
+	cash float64
+}
+
+// advance moves the simulated clock to candle i
+func (s *simExchange) advance(i int) { s.index = i }
+
+// push appends c and makes it the current candle for streamed replays,
+// keeping at least keep candles of history
+func (s *simExchange) push(c Candle, keep int) {
+	if len(s.candles) >= 2*keep {
+		s.candles = append(s.candles[:0], s.candles[len(s.candles)-keep+1:]...)
+	}
+	s.candles = append(s.candles, c)
+	s.index = len(s.candles) - 1
+}
+
+func (s *simExchange) current() Candle { return s.candles[s.index] }
+
+func (s *simExchange) equity() float64 { return s.wallet.cash + s.qty*s.current().Close }
+
+func (s *simExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	price := s.slippage.Fill(s.current().Close, order.Side == types.OrderSideBuy, s.current().Time)
+	if order.Type == types.OrderTypeLimit && order.Price > 0 {
+		price = order.Price
+	}
+	notional := order.Quantity * price
+	fee := notional * s.fees.rate(order.Type)
+
+	switch order.Side {
+	case types.OrderSideBuy:
+		if notional+fee > s.wallet.cash+1e-9 {
+			s.rejected++
+			return fmt.Errorf("insufficient balance: need %.2f, have %.2f", notional+fee, s.wallet.cash)
+		}
+		if s.qty+order.Quantity > 0 {
+			s.avgCost = (s.avgCost*s.qty + notional) / (s.qty + order.Quantity)
+		}
+		s.wallet.cash -= notional + fee
+		s.spent += notional + fee
+		s.qty += order.Quantity
+	case types.OrderSideSell:
+		if order.Quantity > s.qty+1e-12 {
+			return fmt.Errorf("insufficient position: need %.8f, have %.8f", order.Quantity, s.qty)
+		}
+		if price >= s.avgCost {
+			s.wins++
+		}
+		s.wallet.cash += notional - fee
+		s.spent -= notional - fee
+		s.qty -= order.Quantity
+	default:
+		return fmt.Errorf("unsupported order side: %s", order.Side)
+	}
+
+	s.nextID++
+	order.ID = fmt.Sprintf("sim_%d", s.nextID)
+	order.Status = types.OrderStatusFilled
+	order.FilledAmount = order.Quantity
+	order.FilledPrice = price
+	order.Timestamp = s.current().Time
+	if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID != "" {
+		if s.byClient == nil {
+			s.byClient = make(map[string]int)
+		}
+		s.byClient[order.ExchangeOrder.ClientOrderID] = len(s.orders)
+	}
+	s.orders = append(s.orders, order)
+	s.totalFees += fee
+	s.trades++
+	return nil
+}
+
+func (s *simExchange) CancelOrder(ctx context.Context, orderID string) error { return nil }
+
+func (s *simExchange) GetOrder(ctx context.Context, orderID string) (*types.Order, error) {
+	for i := range s.orders {
+		if s.orders[i].ID == orderID {
+			o := s.orders[i]
+			return &o, nil
+		}
+	}
+	return nil, fmt.Errorf("order not found: %s", orderID)
+}
+
+func (s *simExchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	i, ok := s.byClient[clientOrderID]
+	if !ok {
+		return nil, fmt.Errorf("%w: client id %s", types.ErrOrderNotFound, clientOrderID)
+	}
+	o := s.orders[i]
+	return &o, nil
+}
+
+func (s *simExchange) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return nil, nil
+}
+
+func (s *simExchange) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return append([]types.Order(nil), s.orders...), nil
+}
+
+func (s *simExchange) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	return types.PageOrders(s.orders, query)
+}
+
+func (s *simExchange) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	c := s.current()
+	return &types.Ticker{Symbol: symbol, Price: c.Close, Bid: c.Close, Ask: c.Close, Volume: c.Volume, Timestamp: c.Time}, nil
+}
+
+func (s *simExchange) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	return types.FetchTickers(ctx, symbols, s.GetTicker)
+}
+
+func (s *simExchange) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	c := s.current()
+	return &types.OrderBook{
+		Symbol: symbol,
+		Bids:   []types.OrderBookEntry{{Price: c.Close, Amount: c.Volume}},
+		Asks:   []types.OrderBookEntry{{Price: c.Close, Amount: c.Volume}},
+	}, nil
+}
+
+func (s *simExchange) GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error) {
+	from := s.index + 1 - limit
+	if from < 0 {
+		from = 0
+	}
+	out := make([]types.Candle, 0, s.index+1-from)
+	for _, c := range s.candles[from : s.index+1] {
+		out = append(out, toTypesCandle(symbol, c))
+	}
+	return out, nil
+}
+
+func (s *simExchange) GetBalance(ctx context.Context) (*types.Balance, error) {
+	return &types.Balance{Asset: "USDT", Free: s.wallet.cash, Total: s.wallet.cash, Timestamp: s.current().Time}, nil
+}
+
+func (s *simExchange) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	balance, _ := s.GetBalance(ctx)
+	return []types.Balance{*balance}, nil
+}
+
+func (s *simExchange) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
+	return &types.TradingFees{Symbol: symbol, MakerFee: s.fees.maker, TakerFee: s.fees.taker, Timestamp: time.Now()}, nil
+}
+
+func (s *simExchange) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (s *simExchange) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (s *simExchange) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (s *simExchange) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (s *simExchange) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (s *simExchange) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+func (s *simExchange) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+func (s *simExchange) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	return &types.SystemStatus{State: types.SystemNormal, Timestamp: s.current().Time}, nil
+}
+
+func (s *simExchange) Ping(ctx context.Context) error { return nil }
+
+func (s *simExchange) Close() error { return nil }
+
+// toTypesCandle converts a backtest candle into the shared candle type
+func toTypesCandle(symbol string, c Candle) types.Candle {
+	return types.Candle{Symbol: symbol, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume, Timestamp: c.Time}
+}
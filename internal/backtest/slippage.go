@@ -0,0 +1,66 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SlippageModel moves simulated market fills away from the candle close by a
+// cost in basis points, optionally varying by UTC hour of day. Models are
+// typically calibrated from live fills (see trader slippage).
+type SlippageModel struct {
+	Bps    float64         `json:"bps"`              // cost when no hourly value applies
+	Hourly map[int]float64 `json:"hourly,omitempty"` // cost by UTC hour, 0-23
+}
+
+// Validate checks the hours are in range
+func (m SlippageModel) Validate() error {
+	for hour := range m.Hourly {
+		if hour < 0 || hour > 23 {
+			return fmt.Errorf("slippage hour %d out of range 0-23", hour)
+		}
+	}
+	return nil
+}
+
+// BpsAt returns the cost in basis points for a fill at t
+func (m *SlippageModel) BpsAt(t time.Time) float64 {
+	if m == nil {
+		return 0
+	}
+	if bps, ok := m.Hourly[t.UTC().Hour()]; ok {
+		return bps
+	}
+	return m.Bps
+}
+
+// Fill returns the price a market order fills at given the reference price:
+// buys pay the cost above it, sells receive it below
+func (m *SlippageModel) Fill(price float64, buy bool, t time.Time) float64 {
+	bps := m.BpsAt(t)
+	if !buy {
+		bps = -bps
+	}
+	return price * (1 + bps/10000)
+}
+
+// LoadSlippageModel reads a JSON slippage model
+func LoadSlippageModel(path string) (*SlippageModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slippage model: %w", err)
+	}
+	var m SlippageModel
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode slippage model %s: %w", path, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("slippage model %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// SetSlippage prices market fills with m; nil fills at the candle close
+func (e *Engine) SetSlippage(m *SlippageModel) { e.slippage = m }
@@ -0,0 +1,55 @@
+package backtest
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestSlippageModel_Fill(t *testing.T) {
+	m := &SlippageModel{Bps: 10, Hourly: map[int]float64{14: 50}}
+	morning := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	afternoon := time.Date(2024, 1, 1, 14, 30, 0, 0, time.UTC)
+
+	if got := m.Fill(100, true, morning); math.Abs(got-100.1) > 1e-9 {
+		t.Errorf("buy fill = %v, want 100.1", got)
+	}
+	if got := m.Fill(100, false, afternoon); math.Abs(got-99.5) > 1e-9 {
+		t.Errorf("sell fill at 14h = %v, want 99.5", got)
+	}
+	var none *SlippageModel
+	if got := none.Fill(100, true, morning); got != 100 {
+		t.Errorf("nil model fill = %v, want 100", got)
+	}
+}
+
+func TestLoadSlippageModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := os.WriteFile(path, []byte(`{"bps": 3, "hourly": {"24": 5}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSlippageModel(path); err == nil {
+		t.Fatal("expected an error for hour 24")
+	}
+}
+
+func TestEngine_SlippageCostsReturn(t *testing.T) {
+	candles, err := GenerateSynthetic(ScenarioConfig(SIDEWAYS_MARKET, 24*30, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end := candles[0].Time, candles[len(candles)-1].Time
+	cfg := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 30, Enabled: true}
+
+	eng := NewEngine(0.001)
+	base := eng.BacktestDCA("BTCUSDT", candles, start, end, cfg, 10000)
+	eng.SetSlippage(&SlippageModel{Bps: 50})
+	slipped := eng.BacktestDCA("BTCUSDT", candles, start, end, cfg, 10000)
+	if slipped.TotalReturn >= base.TotalReturn {
+		t.Errorf("return with slippage %.4f should be below %.4f", slipped.TotalReturn, base.TotalReturn)
+	}
+}
@@ -0,0 +1,348 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// CandleIterator streams candles from a source in chronological order
+type CandleIterator interface {
+	// Next advances to the next candle, returning false at the end or on error
+	Next() bool
+	// Candle returns the current candle
+	Candle() Candle
+	// Err returns the first error encountered
+	Err() error
+	Close() error
+}
+
+// OpenCandles opens a .csv or .parquet candle file for streaming
+func OpenCandles(path string) (CandleIterator, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet":
+		return OpenParquet(path)
+	case ".csv", "":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		it := NewCSVIterator(f)
+		it.closer = f
+		return it, nil
+	default:
+		return nil, fmt.Errorf("unsupported candle file %q (want .csv or .parquet)", path)
+	}
+}
+
+// CollectCandles drains an iterator into a slice and closes it
+func CollectCandles(it CandleIterator) ([]Candle, error) {
+	defer it.Close()
+
+	var out []Candle
+	for it.Next() {
+		out = append(out, it.Candle())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CSVIterator reads timestamp,open,high,low,close,volume rows one at a time.
+// Like LoadCSV it skips the header and short rows.
+type CSVIterator struct {
+	r       *csv.Reader
+	closer  io.Closer
+	current Candle
+	header  bool
+	err     error
+}
+
+// NewCSVIterator streams candles from r
+func NewCSVIterator(r io.Reader) *CSVIterator {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	return &CSVIterator{r: cr}
+}
+
+func (it *CSVIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.header {
+		it.header = true
+		if _, err := it.r.Read(); err != nil {
+			if err != io.EOF {
+				it.err = err
+			}
+			return false
+		}
+	}
+	for {
+		rec, err := it.r.Read()
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if len(rec) < 6 {
+			continue
+		}
+		ts, _ := time.Parse(time.RFC3339, rec[0])
+		open, _ := strconv.ParseFloat(rec[1], 64)
+		high, _ := strconv.ParseFloat(rec[2], 64)
+		low, _ := strconv.ParseFloat(rec[3], 64)
+		closeP, _ := strconv.ParseFloat(rec[4], 64)
+		vol, _ := strconv.ParseFloat(rec[5], 64)
+		it.current = Candle{Time: ts, Open: open, High: high, Low: low, Close: closeP, Volume: vol}
+		return true
+	}
+}
+
+func (it *CSVIterator) Candle() Candle { return it.current }
+
+func (it *CSVIterator) Err() error { return it.err }
+
+func (it *CSVIterator) Close() error {
+	if it.closer != nil {
+		return it.closer.Close()
+	}
+	return nil
+}
+
+// downsampler aggregates candles into fixed, UTC-aligned periods
+type downsampler struct {
+	src     CandleIterator
+	period  time.Duration
+	current Candle
+	pending Candle
+	has     bool
+}
+
+// Downsample aggregates candles from src into period-long bars on the fly
+// (e.g. 1m -> 1h). Bars start at period boundaries; a partial last bar is kept.
+func Downsample(src CandleIterator, period time.Duration) CandleIterator {
+	if period <= 0 {
+		return src
+	}
+	return &downsampler{src: src, period: period}
+}
+
+func (d *downsampler) Next() bool {
+	for d.src.Next() {
+		c := d.src.Candle()
+		bucket := c.Time.Truncate(d.period)
+		if !d.has {
+			d.pending = Candle{Time: bucket, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume}
+			d.has = true
+			continue
+		}
+		if !bucket.Equal(d.pending.Time) {
+			d.current = d.pending
+			d.pending = Candle{Time: bucket, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume}
+			return true
+		}
+		d.pending.High = math.Max(d.pending.High, c.High)
+		d.pending.Low = math.Min(d.pending.Low, c.Low)
+		d.pending.Close = c.Close
+		d.pending.Volume += c.Volume
+	}
+	if d.has && d.src.Err() == nil {
+		d.current = d.pending
+		d.has = false
+		return true
+	}
+	return false
+}
+
+func (d *downsampler) Candle() Candle { return d.current }
+
+func (d *downsampler) Err() error { return d.src.Err() }
+
+func (d *downsampler) Close() error { return d.src.Close() }
+
+// equityTracker computes PerformanceMetrics from equity points in O(1) memory.
+// Return mean/variance use Welford's method, so results match
+// computePerformance up to floating point rounding.
+type equityTracker struct {
+	n           int
+	first, last float64
+	peak, maxDD float64
+	count       int
+	mean, m2    float64
+}
+
+func (t *equityTracker) add(v float64) {
+	if t.n == 0 {
+		t.first, t.peak = v, v
+	} else if t.last != 0 {
+		r := v/t.last - 1
+		t.count++
+		delta := r - t.mean
+		t.mean += delta / float64(t.count)
+		t.m2 += delta * (r - t.mean)
+	}
+	t.n++
+	t.last = v
+	if v > t.peak {
+		t.peak = v
+	}
+	if dd := (t.peak - v) / t.peak; dd > t.maxDD {
+		t.maxDD = dd
+	}
+}
+
+func (t *equityTracker) performance(period time.Duration, trades, wins int, totalFees float64) PerformanceMetrics {
+	if t.n == 0 {
+		return PerformanceMetrics{}
+	}
+	totalReturn := (t.last/t.first - 1.0) * 100.0
+
+	years := period.Hours() / (24 * 365)
+	annualized := 0.0
+	if years > 0 {
+		annualized = (math.Pow(t.last/t.first, 1/years) - 1) * 100.0
+	}
+
+	sd := 0.0
+	if t.count > 0 {
+		sd = math.Sqrt(t.m2 / float64(t.count))
+	}
+	sharpe := 0.0
+	if t.n >= 2 && sd != 0 {
+		sharpe = t.mean / sd
+	}
+	winRate := 0.0
+	if trades > 0 {
+		winRate = float64(wins) / float64(trades) * 100.0
+	}
+
+	return PerformanceMetrics{
+		TotalReturn:      totalReturn,
+		AnnualizedReturn: annualized,
+		MaxDrawdown:      t.maxDD * 100.0,
+		SharpeRatio:      sharpe,
+		TradeCount:       trades,
+		WinRate:          winRate,
+		TotalFees:        totalFees,
+		VolatilityImpact: sd * 100.0,
+	}
+}
+
+// StreamCompare runs the DCA and grid backtests in a single pass over it,
+// keeping O(1) candles in memory. Unlike CompareStrategies it has no
+// per-regime breakdown, which needs the whole series.
+func (e *Engine) StreamCompare(it CandleIterator, start, end time.Time, initialBalance float64, dcaCfg types.DCAConfig, gridCfg types.GridConfig) (*StrategyComparison, error) {
+	dca := e.newDCASim(start, dcaCfg, initialBalance)
+	var grid *gridSim
+	if gridCfg.GridLevels >= 2 {
+		sim, err := e.newGridSim(gridCfg.Symbol, gridCfg, initialBalance)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grid config: %w", err)
+		}
+		grid = sim
+	}
+
+	var (
+		dcaEquity, gridEquity equityTracker
+		dcaBase, gridBase     equityTracker // in units of the base asset
+		first, last           float64
+		lastClose             float64
+	)
+	err := streamWindow(it, start, end, func(c Candle) {
+		if first == 0 {
+			first = c.Close
+		}
+		last = c.Close
+		equity := dca.step(c)
+		dcaEquity.add(equity)
+		dcaBase.add(equity / c.Close)
+		if grid != nil {
+			equity = grid.step(c)
+			gridEquity.add(equity)
+			gridBase.add(equity / c.Close)
+		}
+	}, func(c Candle) { lastClose = c.Close })
+	if err != nil {
+		return nil, err
+	}
+
+	period := end.Sub(start)
+	cmp := &StrategyComparison{Period: period, MarketType: marketConditionFromPrices(first, last)}
+	if dcaEquity.n > 0 {
+		cmp.DCAResults = dcaEquity.performance(period, dca.trades, dca.wins(lastClose), dca.totalFees)
+		if e.baseMetrics {
+			cmp.DCAResults.Base = baseMetrics(dcaBase.performance(period, 0, 0, 0), dcaCfg.Symbol, first, last)
+		}
+	}
+	if grid != nil {
+		cmp.GridResults = gridEquity.performance(period, grid.trades(), grid.wins(), grid.exchange.totalFees)
+		if e.baseMetrics {
+			cmp.GridResults.Base = baseMetrics(gridBase.performance(period, 0, 0, 0), gridCfg.Symbol, first, last)
+		}
+	}
+	return cmp, nil
+}
+
+// streamWindow calls fn for candles within [start, end] and every for all of them,
+// then closes the iterator. Input must be chronological.
+func streamWindow(it CandleIterator, start, end time.Time, fn func(Candle), every func(Candle)) error {
+	defer it.Close()
+
+	var prev time.Time
+	for it.Next() {
+		c := it.Candle()
+		if c.Time.Before(prev) {
+			return fmt.Errorf("candles out of order at %s", c.Time.Format(time.RFC3339))
+		}
+		prev = c.Time
+		if every != nil {
+			every(c)
+		}
+		if c.Time.Before(start) || c.Time.After(end) {
+			continue
+		}
+		fn(c)
+	}
+	return it.Err()
+}
+
+// LoadCandles reads a .csv or .parquet file, optionally downsampled to
+// resample-long bars while streaming so only the aggregated bars are kept
+func (e *Engine) LoadCandles(path string, resample time.Duration) ([]Candle, error) {
+	it, err := OpenCandles(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := CollectCandles(Downsample(it, resample))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no candles loaded")
+	}
+	if !candlesSorted(out) {
+		sortCandles(out)
+	}
+	return out, nil
+}
+
+// WriteCandles writes candles as Parquet or CSV depending on the file extension
+func WriteCandles(path string, candles []Candle) error {
+	if strings.EqualFold(filepath.Ext(path), ".parquet") {
+		return WriteParquet(path, candles)
+	}
+	return WriteCSV(path, candles)
+}
@@ -0,0 +1,157 @@
+package backtest
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// sliceIterator streams an in-memory slice
+type sliceIterator struct {
+	candles []Candle
+	pos     int
+}
+
+func (it *sliceIterator) Next() bool     { it.pos++; return it.pos <= len(it.candles) }
+func (it *sliceIterator) Candle() Candle { return it.candles[it.pos-1] }
+func (it *sliceIterator) Err() error     { return nil }
+func (it *sliceIterator) Close() error   { return nil }
+
+func TestParquetRoundTrip(t *testing.T) {
+	// More rows than one read batch
+	candles, err := GenerateSynthetic(ScenarioConfig(HIGH_VOLATILITY, parquetBatch+500, 9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "candles.parquet")
+	if err := WriteCandles(path, candles); err != nil {
+		t.Fatalf("WriteCandles: %v", err)
+	}
+
+	it, err := OpenCandles(path)
+	if err != nil {
+		t.Fatalf("OpenCandles: %v", err)
+	}
+	got, err := CollectCandles(it)
+	if err != nil {
+		t.Fatalf("CollectCandles: %v", err)
+	}
+	if len(got) != len(candles) {
+		t.Fatalf("read %d candles, want %d", len(got), len(candles))
+	}
+	for i := range candles {
+		want := candles[i]
+		want.Time = want.Time.UTC().Truncate(time.Millisecond)
+		if !reflect.DeepEqual(got[i], want) {
+			t.Fatalf("candle %d = %+v, want %+v", i, got[i], want)
+		}
+	}
+}
+
+func TestCSVIterator(t *testing.T) {
+	data := "timestamp,open,high,low,close,volume\n" +
+		"2024-01-01T00:00:00Z,1,2,0.5,1.5,10\n" +
+		"short,row\n" +
+		"2024-01-01T00:01:00Z,1.5,3,1,2,5\n"
+
+	got, err := CollectCandles(NewCSVIterator(strings.NewReader(data)))
+	if err != nil {
+		t.Fatalf("CollectCandles: %v", err)
+	}
+	if len(got) != 2 || got[1].High != 3 || got[1].Volume != 5 {
+		t.Fatalf("unexpected candles %+v", got)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var minutes []Candle
+	for i := 0; i < 150; i++ {
+		price := 100 + float64(i)
+		minutes = append(minutes, Candle{Time: base.Add(time.Duration(i) * time.Minute), Open: price, High: price + 1, Low: price - 1, Close: price + 0.5, Volume: 1})
+	}
+
+	hours, err := CollectCandles(Downsample(&sliceIterator{candles: minutes}, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Candle{
+		{Time: base, Open: 100, High: 160, Low: 99, Close: 159.5, Volume: 60},
+		{Time: base.Add(time.Hour), Open: 160, High: 220, Low: 159, Close: 219.5, Volume: 60},
+		{Time: base.Add(2 * time.Hour), Open: 220, High: 250, Low: 219, Close: 249.5, Volume: 30},
+	}
+	if !reflect.DeepEqual(hours, want) {
+		t.Fatalf("Downsample = %+v, want %+v", hours, want)
+	}
+}
+
+func TestStreamCompareMatchesInMemory(t *testing.T) {
+	candles, err := GenerateSynthetic(ScenarioConfig(SIDEWAYS_MARKET, 2000, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "candles.parquet")
+	if err := WriteParquet(path, candles); err != nil {
+		t.Fatal(err)
+	}
+
+	start, end := candles[100].Time, candles[1800].Time
+	price := candles[100].Close
+	dcaCfg := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 24 * time.Hour, MaxInvestments: 50, Enabled: true}
+	gridCfg := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: price * 0.85, UpperPrice: price * 1.15, GridLevels: 12, InvestmentPerLevel: 100, Enabled: true}
+	eng := NewEngine(0.001)
+
+	it, err := OpenCandles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := eng.StreamCompare(it, start, end, 10000, dcaCfg, gridCfg)
+	if err != nil {
+		t.Fatalf("StreamCompare: %v", err)
+	}
+
+	dca := eng.BacktestDCA("BTCUSDT", candles, start, end, dcaCfg, 10000)
+	grid := eng.BacktestGrid("BTCUSDT", candles, start, end, gridCfg, 10000)
+	assertMetricsClose(t, "dca", got.DCAResults, dca)
+	assertMetricsClose(t, "grid", got.GridResults, grid)
+	if got.MarketType != analyzeMarketCondition(candles, start, end) {
+		t.Errorf("market type = %s", got.MarketType)
+	}
+}
+
+func TestStreamCompareRejectsUnsortedInput(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	it := &sliceIterator{candles: []Candle{{Time: base.Add(time.Hour), Close: 1}, {Time: base, Close: 1}}}
+
+	_, err := NewEngine(0).StreamCompare(it, base, base.Add(time.Hour), 1000, types.DCAConfig{}, types.GridConfig{})
+	if err == nil {
+		t.Fatalf("expected out-of-order error")
+	}
+}
+
+func assertMetricsClose(t *testing.T, name string, got, want PerformanceMetrics) {
+	t.Helper()
+
+	if got.TradeCount != want.TradeCount {
+		t.Errorf("%s trades = %d, want %d", name, got.TradeCount, want.TradeCount)
+	}
+	pairs := map[string][2]float64{
+		"total_return":      {got.TotalReturn, want.TotalReturn},
+		"annualized_return": {got.AnnualizedReturn, want.AnnualizedReturn},
+		"max_drawdown":      {got.MaxDrawdown, want.MaxDrawdown},
+		"sharpe_ratio":      {got.SharpeRatio, want.SharpeRatio},
+		"win_rate":          {got.WinRate, want.WinRate},
+		"total_fees":        {got.TotalFees, want.TotalFees},
+		"volatility_impact": {got.VolatilityImpact, want.VolatilityImpact},
+	}
+	for field, v := range pairs {
+		if math.Abs(v[0]-v[1]) > 1e-9*math.Max(1, math.Abs(v[1])) {
+			t.Errorf("%s %s = %v, want %v", name, field, v[0], v[1])
+		}
+	}
+}
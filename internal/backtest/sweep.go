@@ -0,0 +1,82 @@
+package backtest
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// SweepJob is one parameter combination evaluated by RunSweep
+type SweepJob struct {
+	Params map[string]interface{}
+	Run    func() PerformanceMetrics
+}
+
+// SweepResult is the outcome of a SweepJob
+type SweepResult struct {
+	Params  map[string]interface{}
+	Metrics PerformanceMetrics
+}
+
+// SweepOptions configures RunSweep
+type SweepOptions struct {
+	Workers  int                   // defaults to runtime.NumCPU()
+	Progress func(done, total int) // called after each job, never concurrently
+}
+
+// RunSweep evaluates jobs on a worker pool and returns results in job order.
+// Jobs must not share mutable state; the Engine and a Dataset are safe to share.
+func RunSweep(ctx context.Context, jobs []SweepJob, opts SweepOptions) ([]SweepResult, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	results := make([]SweepResult, len(jobs))
+	indexes := make(chan int)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = SweepResult{Params: jobs[i].Params, Metrics: jobs[i].Run()}
+
+				mu.Lock()
+				done++
+				if opts.Progress != nil {
+					opts.Progress(done, len(jobs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i := range jobs {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,110 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestDatasetBetween(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := []Candle{
+		{Time: base.Add(2 * time.Hour), Close: 3},
+		{Time: base, Close: 1},
+		{Time: base.Add(time.Hour), Close: 2},
+		{Time: base.Add(3 * time.Hour), Close: 4},
+	}
+
+	ds, err := NewDataset(candles)
+	if err != nil {
+		t.Fatalf("NewDataset: %v", err)
+	}
+	if candles[0].Close != 3 {
+		t.Fatalf("NewDataset reordered the caller's slice")
+	}
+	if first, last := ds.Range(); !first.Equal(base) || !last.Equal(base.Add(3*time.Hour)) {
+		t.Fatalf("Range = %v..%v", first, last)
+	}
+
+	window := ds.Between(base.Add(time.Hour), base.Add(2*time.Hour))
+	if len(window) != 2 || window[0].Close != 2 || window[1].Close != 3 {
+		t.Fatalf("Between = %+v", window)
+	}
+	if got := ds.Between(base.Add(10*time.Hour), base.Add(20*time.Hour)); len(got) != 0 {
+		t.Fatalf("expected empty window, got %d candles", len(got))
+	}
+
+	if _, err := NewDataset(nil); err == nil {
+		t.Fatalf("expected error for empty dataset")
+	}
+}
+
+func TestRunSweepMatchesSequential(t *testing.T) {
+	candles, err := GenerateSynthetic(ScenarioConfig(SIDEWAYS_MARKET, 500, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := NewDataset(candles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end := ds.Range()
+	eng := NewEngine(0.001)
+
+	var jobs []SweepJob
+	var want []PerformanceMetrics
+	for _, levels := range []int{4, 8, 16, 32} {
+		for _, band := range []float64{0.05, 0.1, 0.2} {
+			cfg := types.GridConfig{
+				Symbol:             "BTCUSDT",
+				LowerPrice:         candles[0].Close * (1 - band),
+				UpperPrice:         candles[0].Close * (1 + band),
+				GridLevels:         levels,
+				InvestmentPerLevel: 100,
+				Enabled:            true,
+			}
+			want = append(want, eng.BacktestGrid("BTCUSDT", candles, start, end, cfg, 10000))
+			jobs = append(jobs, SweepJob{
+				Params: map[string]interface{}{"levels": levels, "band": band},
+				Run:    func() PerformanceMetrics { return eng.BacktestGrid("BTCUSDT", ds.Candles(), start, end, cfg, 10000) },
+			})
+		}
+	}
+
+	var calls []int
+	results, err := RunSweep(context.Background(), jobs, SweepOptions{
+		Workers:  4,
+		Progress: func(done, total int) { calls = append(calls, done) },
+	})
+	if err != nil {
+		t.Fatalf("RunSweep: %v", err)
+	}
+
+	got := make([]PerformanceMetrics, len(results))
+	for i, r := range results {
+		got[i] = r.Metrics
+		if !reflect.DeepEqual(r.Params, jobs[i].Params) {
+			t.Fatalf("result %d params = %v, want %v", i, r.Params, jobs[i].Params)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parallel results differ from sequential runs")
+	}
+	if len(calls) != len(jobs) || calls[len(calls)-1] != len(jobs) {
+		t.Fatalf("progress calls = %v", calls)
+	}
+}
+
+func TestRunSweepCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	jobs := []SweepJob{{Run: func() PerformanceMetrics { return PerformanceMetrics{} }}}
+	if _, err := RunSweep(ctx, jobs, SweepOptions{Workers: 1}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunSweep = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,65 @@
+package backtest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateSynthetic(t *testing.T) {
+	configs := map[string]SyntheticConfig{
+		"bull":     ScenarioConfig(BULL_MARKET, 500, 1),
+		"bear":     ScenarioConfig(BEAR_MARKET, 500, 1),
+		"sideways": ScenarioConfig(SIDEWAYS_MARKET, 500, 1),
+		"high_vol": ScenarioConfig(HIGH_VOLATILITY, 500, 1),
+	}
+	regime := ScenarioConfig(BULL_MARKET, 500, 1)
+	regime.Model = ModelRegimeSwitching
+	regime.SwitchProbability = 0.02
+	regime.Regimes = []RegimeSpec{{Name: "up", Drift: 2, Volatility: 0.5}, {Name: "down", Drift: -2, Volatility: 0.8}}
+	configs["regime"] = regime
+
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			first, err := GenerateSynthetic(cfg)
+			if err != nil {
+				t.Fatalf("GenerateSynthetic() error = %v", err)
+			}
+			second, _ := GenerateSynthetic(cfg)
+
+			if len(first) != cfg.Bars {
+				t.Fatalf("Expected %d bars, got %d", cfg.Bars, len(first))
+			}
+			for i, c := range first {
+				if c != second[i] {
+					t.Fatalf("Expected deterministic output for same seed at bar %d", i)
+				}
+				if c.High < c.Open || c.High < c.Close || c.Low > c.Open || c.Low > c.Close || c.Low <= 0 {
+					t.Fatalf("Invalid OHLC at bar %d: %+v", i, c)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSynthetic_InvalidConfig(t *testing.T) {
+	cfg := ScenarioConfig(BULL_MARKET, 100, 1)
+	cfg.Model = ModelRegimeSwitching
+	if _, err := GenerateSynthetic(cfg); err == nil {
+		t.Error("Expected error for regime switching without regimes")
+	}
+
+	cfg = ScenarioConfig(BULL_MARKET, 0, 1)
+	if _, err := GenerateSynthetic(cfg); err == nil {
+		t.Error("Expected error for zero bars")
+	}
+}
+
+func TestParseMarketCondition(t *testing.T) {
+	if condition, err := ParseMarketCondition("high_vol"); err != nil || condition != HIGH_VOLATILITY {
+		t.Errorf("ParseMarketCondition(high_vol) = %q, %v", condition, err)
+	}
+	_, err := ParseMarketCondition("sidways")
+	if err == nil || !strings.Contains(err.Error(), "bull, bear, sideways, high_vol") {
+		t.Errorf("ParseMarketCondition(sidways) error = %v, want the valid names", err)
+	}
+}
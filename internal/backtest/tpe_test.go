@@ -0,0 +1,74 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRunTPEConvergesOnOptimum(t *testing.T) {
+	space := []Param{
+		{Name: "levels", Min: 2, Max: 60, Integer: true},
+		{Name: "band", Min: 0.01, Max: 1, Log: true},
+	}
+	// Return peaks at 23 levels and a 0.15 band
+	build := func(x map[string]float64) SweepJob {
+		return SweepJob{
+			Params: map[string]interface{}{"levels": x["levels"], "band": x["band"]},
+			Run: func() PerformanceMetrics {
+				d := (x["levels"] - 23) / 10
+				b := math.Log(x["band"] / 0.15)
+				return PerformanceMetrics{TotalReturn: 10 - d*d - b*b}
+			},
+		}
+	}
+	score := func(m PerformanceMetrics) float64 { return m.TotalReturn }
+	run := func() []SweepResult {
+		results, err := RunTPE(context.Background(), space, build, score, TPEOptions{Trials: 60, Seed: 7, Workers: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+
+	results := run()
+	if len(results) != 60 {
+		t.Fatalf("Expected 60 trials, got %d", len(results))
+	}
+	best := func(rs []SweepResult) SweepResult {
+		b := rs[0]
+		for _, r := range rs {
+			if r.Metrics.TotalReturn > b.Metrics.TotalReturn {
+				b = r
+			}
+		}
+		return b
+	}
+	random, modeled := best(results[:10]), best(results)
+	if modeled.Metrics.TotalReturn < 9.9 || modeled.Metrics.TotalReturn <= random.Metrics.TotalReturn {
+		t.Errorf("TPE best %v (random phase %v) at %v", modeled.Metrics.TotalReturn, random.Metrics.TotalReturn, modeled.Params)
+	}
+	for _, r := range results {
+		if levels := r.Params["levels"].(float64); levels != math.Round(levels) || levels < 2 || levels > 60 {
+			t.Fatalf("Suggestion outside the space: %v", r.Params)
+		}
+	}
+
+	// A fixed seed reproduces the search
+	if again := run(); !reflect.DeepEqual(again, results) {
+		t.Error("Expected identical trials for the same seed")
+	}
+}
+
+func TestValidateSpace(t *testing.T) {
+	for _, space := range [][]Param{
+		nil,
+		{{Name: "x", Min: 2, Max: 1}},
+		{{Name: "x", Min: 0, Max: 1, Log: true}},
+	} {
+		if err := ValidateSpace(space); err == nil {
+			t.Errorf("Expected %+v to be rejected", space)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Package calendar pauses new entries around scheduled market-moving events
+// such as FOMC decisions, CPI releases and large token unlocks. Events come
+// from the config and from an optional JSON or ICS feed that is reloaded
+// periodically; each event blacks out buys from Before its start until After
+// its end.
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrBlackout is returned for buys rejected during a blackout window
+var ErrBlackout = errors.New("new entries paused for calendar event")
+
+// Event is a scheduled market-moving event
+type Event struct {
+	Name     string    `json:"name"`
+	Category string    `json:"category,omitempty"` // e.g. fomc, cpi, unlock
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end,omitempty"`     // defaults to Start
+	Symbols  []string  `json:"symbols,omitempty"` // affected symbols; empty means all
+}
+
+// Affects reports whether the event pauses trading in symbol
+func (e Event) Affects(symbol string) bool {
+	return len(e.Symbols) == 0 || slices.Contains(e.Symbols, symbol)
+}
+
+// Config configures a Calendar (disabled without events or a feed)
+type Config struct {
+	Events  []Event       `json:"events"`
+	Feed    string        `json:"feed"`    // path or http(s) URL of a JSON or ICS feed
+	Refresh time.Duration `json:"refresh"` // feed reload period (default 6h)
+	Before  time.Duration `json:"before"`  // blackout lead before an event (default 30m)
+	After   time.Duration `json:"after"`   // blackout tail after an event ends (default 1h)
+}
+
+// UnmarshalJSON implements custom parsing for durations ("30m", "6h")
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type Alias Config
+	aux := &struct {
+		Refresh string `json:"refresh"`
+		Before  string `json:"before"`
+		After   string `json:"after"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"refresh", aux.Refresh, &c.Refresh},
+		{"before", aux.Before, &c.Before},
+		{"after", aux.After, &c.After},
+	} {
+		if field.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(field.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s format: %w", field.name, err)
+		}
+		*field.dst = duration
+	}
+
+	return nil
+}
+
+// Enabled reports whether any event source is configured
+func (c Config) Enabled() bool {
+	return len(c.Events) > 0 || c.Feed != ""
+}
+
+// Validate checks the config
+func (c Config) Validate() error {
+	if c.Refresh < 0 || c.Before < 0 || c.After < 0 {
+		return fmt.Errorf("calendar durations must not be negative")
+	}
+	for i, event := range c.Events {
+		if err := event.validate(); err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (e Event) validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if e.Start.IsZero() {
+		return fmt.Errorf("%s: start is required", e.Name)
+	}
+	if !e.End.IsZero() && e.End.Before(e.Start) {
+		return fmt.Errorf("%s: end is before start", e.Name)
+	}
+	return nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.Refresh <= 0 {
+		c.Refresh = 6 * time.Hour
+	}
+	if c.Before <= 0 {
+		c.Before = 30 * time.Minute
+	}
+	if c.After <= 0 {
+		c.After = time.Hour
+	}
+	return c
+}
+
+// Calendar tracks configured and feed events and rejects buys while one of
+// them is blacking out the order's symbol. Sells pass so positions can still
+// be closed.
+type Calendar struct {
+	cfg    Config
+	logger *logger.Logger
+
+	mu       sync.Mutex
+	feed     []Event
+	loaded   time.Time
+	feedErr  error
+	rejected int
+	now      func() time.Time
+}
+
+// NewCalendar creates a calendar for cfg
+func NewCalendar(cfg Config, log *logger.Logger) *Calendar {
+	return &Calendar{cfg: cfg.withDefaults(), logger: log, now: time.Now}
+}
+
+// window returns the blackout period of event
+func (c *Calendar) window(event Event) (time.Time, time.Time) {
+	end := event.End
+	if end.IsZero() {
+		end = event.Start
+	}
+	return event.Start.Add(-c.cfg.Before), end.Add(c.cfg.After)
+}
+
+// events returns configured and feed events sorted by start; c.mu must be held
+func (c *Calendar) events() []Event {
+	events := make([]Event, 0, len(c.cfg.Events)+len(c.feed))
+	events = append(events, c.cfg.Events...)
+	events = append(events, c.feed...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events
+}
+
+// Blackout returns the event blacking out symbol now, if any
+func (c *Calendar) Blackout(symbol string) (Event, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.blackout(symbol)
+}
+
+func (c *Calendar) blackout(symbol string) (Event, bool) {
+	now := c.now()
+	for _, event := range c.events() {
+		start, end := c.window(event)
+		if event.Affects(symbol) && !now.Before(start) && now.Before(end) {
+			return event, true
+		}
+	}
+	return Event{}, false
+}
+
+// Upcoming returns up to limit events whose blackout has not ended yet
+func (c *Calendar) Upcoming(limit int) []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.upcoming(limit)
+}
+
+func (c *Calendar) upcoming(limit int) []Event {
+	now := c.now()
+	var upcoming []Event
+	for _, event := range c.events() {
+		if len(upcoming) == limit {
+			break
+		}
+		if _, end := c.window(event); now.Before(end) {
+			upcoming = append(upcoming, event)
+		}
+	}
+	return upcoming
+}
+
+// Load reads the feed, replacing previously loaded feed events. A failed
+// load keeps the previous events.
+func (c *Calendar) Load(ctx context.Context) error {
+	if c.cfg.Feed == "" {
+		return nil
+	}
+
+	events, err := readFeed(ctx, c.cfg.Feed)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.feedErr = err
+	if err != nil {
+		return err
+	}
+	c.feed = events
+	c.loaded = c.now()
+	return nil
+}
+
+// Run loads the feed now and every Refresh until ctx is done
+func (c *Calendar) Run(ctx context.Context) {
+	if c.cfg.Feed == "" {
+		return
+	}
+
+	load := func() {
+		if err := c.Load(ctx); err != nil && c.logger != nil {
+			c.logger.Warn("Failed to load event calendar: %v", err)
+		}
+	}
+	load()
+
+	ticker := time.NewTicker(c.cfg.Refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			load()
+		}
+	}
+}
+
+// Status reports active blackouts with their end and the next events
+func (c *Calendar) Status() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	active := []map[string]interface{}{}
+	for _, event := range c.events() {
+		if start, end := c.window(event); !now.Before(start) && now.Before(end) {
+			active = append(active, map[string]interface{}{"event": event, "until": end})
+		}
+	}
+	status := map[string]interface{}{
+		"active":          active,
+		"upcoming":        c.upcoming(10),
+		"rejected_orders": c.rejected,
+	}
+	if c.cfg.Feed != "" {
+		status["feed_events"] = len(c.feed)
+		status["last_load"] = c.loaded
+		if c.feedErr != nil {
+			status["feed_error"] = c.feedErr.Error()
+		}
+	}
+	return status
+}
+
+// readFeed loads events from a file or URL, as ICS when the content is a
+// VCALENDAR and as JSON otherwise
+func readFeed(ctx context.Context, feed string) ([]Event, error) {
+	var data []byte
+	if strings.HasPrefix(feed, "http://") || strings.HasPrefix(feed, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create feed request: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch calendar feed: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("calendar feed returned status %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to read calendar feed: %w", err)
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(feed); err != nil {
+			return nil, fmt.Errorf("failed to read calendar feed: %w", err)
+		}
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "BEGIN:VCALENDAR") {
+		return ParseICS(string(data))
+	}
+	return ParseJSON(data)
+}
+
+// ParseJSON parses a list of events, bare or as {"events": [...]}
+func ParseJSON(data []byte) ([]Event, error) {
+	var events []Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		var wrapped struct {
+			Events []Event `json:"events"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to decode calendar feed: %w", err)
+		}
+		events = wrapped.Events
+	}
+	for i, event := range events {
+		if err := event.validate(); err != nil {
+			return nil, fmt.Errorf("feed event %d: %w", i, err)
+		}
+	}
+	return events, nil
+}
+
+// Client wraps an exchange client so buys are rejected during blackouts
+func (c *Calendar) Client(exchange types.ExchangeClient) types.ExchangeClient {
+	return &blackoutClient{ExchangeClient: exchange, calendar: c}
+}
+
+// blackoutClient applies a Calendar on PlaceOrder
+type blackoutClient struct {
+	types.ExchangeClient
+	calendar *Calendar
+}
+
+// PlaceOrder rejects buys of a symbol in a blackout window
+func (b *blackoutClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	if order.Side == types.OrderSideBuy {
+		c := b.calendar
+		c.mu.Lock()
+		event, active := c.blackout(order.Symbol)
+		if active {
+			c.rejected++
+		}
+		c.mu.Unlock()
+		if active {
+			return fmt.Errorf("%w: %s at %s", ErrBlackout, event.Name, event.Start.UTC().Format(time.RFC3339))
+		}
+	}
+	return b.ExchangeClient.PlaceOrder(ctx, order)
+}
@@ -0,0 +1,179 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var fomc = time.Date(2024, 3, 20, 18, 0, 0, 0, time.UTC)
+
+// orderClient records placed orders
+type orderClient struct {
+	types.ExchangeClient
+	placed []types.Order
+}
+
+func (c *orderClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	c.placed = append(c.placed, order)
+	return nil
+}
+
+func newCalendar(cfg Config, now *time.Time) *Calendar {
+	c := NewCalendar(cfg, nil)
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestCalendarBlackout(t *testing.T) {
+	now := fomc.Add(-time.Hour)
+	c := newCalendar(Config{
+		Events: []Event{
+			{Name: "FOMC", Category: "fomc", Start: fomc},
+			{Name: "ARB unlock", Category: "unlock", Start: fomc.Add(48 * time.Hour), Symbols: []string{"ARBUSDT"}},
+		},
+		Before: 30 * time.Minute,
+		After:  time.Hour,
+	}, &now)
+
+	for _, tt := range []struct {
+		at     time.Time
+		symbol string
+		want   bool
+	}{
+		{fomc.Add(-31 * time.Minute), "BTCUSDT", false},
+		{fomc.Add(-30 * time.Minute), "BTCUSDT", true},
+		{fomc.Add(59 * time.Minute), "ETHUSDT", true},
+		{fomc.Add(time.Hour), "BTCUSDT", false},
+		{fomc.Add(48 * time.Hour), "BTCUSDT", false},
+		{fomc.Add(48 * time.Hour), "ARBUSDT", true},
+	} {
+		now = tt.at
+		if _, got := c.Blackout(tt.symbol); got != tt.want {
+			t.Errorf("Blackout(%s) at %s = %v, want %v", tt.symbol, tt.at.Format(time.RFC3339), got, tt.want)
+		}
+	}
+
+	now = fomc.Add(30 * time.Minute)
+	upcoming := c.Upcoming(10)
+	if len(upcoming) != 2 || upcoming[0].Name != "FOMC" {
+		t.Errorf("Upcoming() = %+v, want the ongoing FOMC then the unlock", upcoming)
+	}
+	status := c.Status()
+	if active := status["active"].([]map[string]interface{}); len(active) != 1 {
+		t.Errorf("active = %v, want the FOMC blackout", active)
+	}
+}
+
+func TestCalendarClient(t *testing.T) {
+	now := fomc
+	c := newCalendar(Config{Events: []Event{{Name: "CPI", Start: fomc}}}, &now)
+	inner := &orderClient{}
+	client := c.Client(inner)
+	ctx := context.Background()
+
+	err := client.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1})
+	if !errors.Is(err, ErrBlackout) {
+		t.Fatalf("buy during blackout: err = %v, want ErrBlackout", err)
+	}
+	if err := client.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: types.OrderSideSell, Quantity: 1}); err != nil {
+		t.Fatalf("sell during blackout: %v", err)
+	}
+
+	now = fomc.Add(2 * time.Hour)
+	if err := client.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1}); err != nil {
+		t.Fatalf("buy after blackout: %v", err)
+	}
+	if len(inner.placed) != 2 {
+		t.Errorf("placed %d orders, want the sell and the later buy", len(inner.placed))
+	}
+	if got := c.Status()["rejected_orders"]; got != 1 {
+		t.Errorf("rejected_orders = %v, want 1", got)
+	}
+}
+
+func TestCalendarLoadFeed(t *testing.T) {
+	dir := t.TempDir()
+	jsonFeed := filepath.Join(dir, "events.json")
+	if err := os.WriteFile(jsonFeed, []byte(`{"events": [{"name": "CPI", "category": "cpi", "start": "2024-03-12T12:30:00Z"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 12, 12, 0, 0, 0, time.UTC)
+	c := newCalendar(Config{Feed: jsonFeed}, &now)
+	if err := c.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if event, ok := c.Blackout("BTCUSDT"); !ok || event.Category != "cpi" {
+		t.Errorf("Blackout() = %+v, %v; want the CPI release from the feed", event, ok)
+	}
+
+	// A broken feed keeps the events already loaded
+	if err := os.WriteFile(jsonFeed, []byte(`not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(context.Background()); err == nil {
+		t.Fatal("expected decode error")
+	}
+	if _, ok := c.Blackout("BTCUSDT"); !ok {
+		t.Error("feed events dropped after a failed load")
+	}
+	if _, ok := c.Status()["feed_error"]; !ok {
+		t.Error("feed error missing from status")
+	}
+}
+
+func TestParseICS(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:FOMC Rate Decision\\, March\r\n" +
+		"CATEGORIES:FOMC,Macro\r\n" +
+		"DTSTART;TZID=America/New_York:20240320T140000\r\n" +
+		"DTEND:20240320T183000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Token unlock of a long\r\n" +
+		" ly folded name\r\n" +
+		"DTSTART;VALUE=DATE:20240322\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := ParseICS(ics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if e := events[0]; e.Name != "FOMC Rate Decision, March" || e.Category != "fomc" || !e.Start.Equal(fomc) ||
+		!e.End.Equal(fomc.Add(30*time.Minute)) {
+		t.Errorf("event 0 = %+v", e)
+	}
+	if e := events[1]; e.Name != "Token unlock of a longly folded name" || !e.Start.Equal(time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("event 1 = %+v", e)
+	}
+}
+
+func TestConfigUnmarshalAndValidate(t *testing.T) {
+	var cfg Config
+	data := `{"events": [{"name": "FOMC", "start": "2024-03-20T18:00:00Z"}], "before": "1h", "after": "2h"}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Before != time.Hour || cfg.After != 2*time.Hour || !cfg.Enabled() {
+		t.Errorf("config = %+v", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := Config{Events: []Event{{Name: "CPI", Start: fomc, End: fomc.Add(-time.Hour)}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("expected error for an event ending before it starts")
+	}
+}
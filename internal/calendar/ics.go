@@ -0,0 +1,103 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseICS parses the VEVENTs of an iCalendar feed. SUMMARY names the event,
+// CATEGORIES sets its category, and DTSTART/DTEND may be UTC, TZID-local or
+// all-day dates. Events without a start are skipped.
+func ParseICS(data string) ([]Event, error) {
+	var (
+		events  []Event
+		current *Event
+	)
+	for i, line := range unfoldICS(data) {
+		name, params, value, ok := splitICSLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+		case name == "END" && value == "VEVENT":
+			if current != nil && !current.Start.IsZero() {
+				if current.Name == "" {
+					current.Name = "event"
+				}
+				events = append(events, *current)
+			}
+			current = nil
+		case current == nil:
+		case name == "SUMMARY":
+			current.Name = unescapeICS(value)
+		case name == "CATEGORIES":
+			current.Category = strings.ToLower(unescapeICS(strings.Split(value, ",")[0]))
+		case name == "DTSTART", name == "DTEND":
+			t, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", i+1, name, err)
+			}
+			if name == "DTSTART" {
+				current.Start = t
+			} else {
+				current.End = t
+			}
+		}
+	}
+	return events, nil
+}
+
+// unfoldICS splits data into content lines, joining folded continuations
+func unfoldICS(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitICSLine splits "NAME;PARAM=V:value" into its parts
+func splitICSLine(line string) (string, map[string]string, string, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, strings.TrimSpace(value), true
+}
+
+// parseICSTime parses a DATE or DATE-TIME value; floating times are taken as UTC
+func parseICSTime(params map[string]string, value string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		return time.ParseInLocation("20060102", value, time.UTC)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.ParseInLocation("20060102T150405Z", value, time.UTC)
+	}
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %s", tzid)
+		}
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+// unescapeICS reverses iCalendar text escaping
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/experiments"
+)
+
+var backtestCommand = &Command{
+	Name:    "backtest",
+	Summary: "Backtest DCA vs Grid on CSV or synthetic data (JSON output)",
+	Run:     runBacktest,
+}
+
+func runBacktest(args []string) error {
+	fs := newFlagSet("backtest")
+	data := addDataFlags(fs)
+	strategies := addStrategyFlags(fs)
+	stream := fs.Bool("stream", false, "Stream -data in one pass with bounded memory (needs -start and -end; no regime breakdown)")
+	portfolio := fs.String("portfolio", "", "JSON file of strategy legs, each with its own symbol and data, to backtest on one shared -initial balance")
+	execution := fs.String("execution", "", "Compare DCA execution styles instead of DCA vs Grid: comma-separated market, limit_chase, twap")
+	chaseTicks := fs.Int("chase-ticks", 5, "Candles -execution limit_chase bids before buying at market")
+	twapSlices := fs.Int("twap-slices", 4, "Candles -execution twap spreads each buy over")
+	overlay := fs.String("overlay", "", "Compare DCA with option overlays instead of DCA vs Grid: comma-separated covered_call, cash_secured_put")
+	overlayStrike := fs.Float64("overlay-strike", 0.1, "Distance of -overlay strikes from spot, above for calls and below for puts")
+	overlayTenor := fs.Duration("overlay-tenor", 7*24*time.Hour, "Time to expiry of each -overlay option")
+	overlayPremium := fs.Float64("overlay-premium", 0, "-overlay premium as a share of spot (0 prices it from -overlay-vol)")
+	overlayVol := fs.Float64("overlay-vol", 0, "Annualized volatility pricing -overlay premiums (0 uses the realized volatility of the last 30 candles)")
+	record := addExperimentsFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *execution != "" {
+		if *portfolio != "" || *stream {
+			return usageError(fs, "-execution cannot be combined with -portfolio or -stream")
+		}
+		if err := data.validate(fs); err != nil {
+			return err
+		}
+		var models []backtest.ExecutionModel
+		for _, style := range splitList(*execution) {
+			models = append(models, backtest.ExecutionModel{Style: style, ChaseTicks: *chaseTicks, Slices: *twapSlices})
+		}
+		cmp, err := compareExecution(data, strategies, models)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cmp)
+	}
+	if *overlay != "" {
+		if *execution != "" || *portfolio != "" || *stream {
+			return usageError(fs, "-overlay cannot be combined with -execution, -portfolio or -stream")
+		}
+		if err := data.validate(fs); err != nil {
+			return err
+		}
+		var overlays []backtest.OptionOverlay
+		for _, style := range splitList(*overlay) {
+			overlays = append(overlays, backtest.OptionOverlay{Style: style, Strike: *overlayStrike, Tenor: *overlayTenor, Premium: *overlayPremium, Volatility: *overlayVol})
+		}
+		cmp, err := compareOverlays(data, strategies, overlays)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cmp)
+	}
+	if *portfolio != "" {
+		result, err := backtestPortfolio(data, *portfolio)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	if err := data.validate(fs); err != nil {
+		return err
+	}
+
+	var (
+		cmp    *backtest.StrategyComparison
+		window runWindow
+		err    error
+	)
+	if *stream {
+		if *strategies.breakout {
+			return usageError(fs, "-breakout cannot be combined with -stream")
+		}
+		if *data.data == "" || *data.start == "" || *data.end == "" {
+			return usageError(fs, "-stream needs -data, -start and -end")
+		}
+		cmp, window, err = streamCompare(data, strategies)
+	} else {
+		cmp, window, err = compare(data, strategies)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *record != "" {
+		dcaCfg, _ := strategies.dcaConfig(*data.symbol)
+		runs := []experiments.Run{
+			{Strategy: "dca", Params: dcaParams(dcaCfg), Metrics: cmp.DCAResults},
+			{Strategy: "grid", Params: gridParams(strategies.gridConfig(*data.symbol)), Metrics: cmp.GridResults},
+		}
+		if err := recordRuns(*record, "backtest", window, data, runs); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cmp)
+}
+
+// compareExecution backtests the DCA flags once per execution model
+func compareExecution(d *dataFlags, s *strategyFlags, models []backtest.ExecutionModel) (*backtest.ExecutionComparison, error) {
+	dcaCfg, err := s.dcaConfig(*d.symbol)
+	if err != nil {
+		return nil, err
+	}
+	eng, err := d.engine()
+	if err != nil {
+		return nil, err
+	}
+	candles, startT, endT, err := d.load(eng)
+	if err != nil {
+		return nil, err
+	}
+	return eng.CompareExecution(*d.symbol, candles, startT, endT, dcaCfg, *d.initial, models)
+}
+
+// compareOverlays backtests the DCA flags plain and with each option overlay
+func compareOverlays(d *dataFlags, s *strategyFlags, overlays []backtest.OptionOverlay) (*backtest.OverlayComparison, error) {
+	dcaCfg, err := s.dcaConfig(*d.symbol)
+	if err != nil {
+		return nil, err
+	}
+	eng, err := d.engine()
+	if err != nil {
+		return nil, err
+	}
+	candles, startT, endT, err := d.load(eng)
+	if err != nil {
+		return nil, err
+	}
+	return eng.CompareOverlays(*d.symbol, candles, startT, endT, dcaCfg, *d.initial, overlays)
+}
+
+// streamCompare runs the DCA vs Grid comparison while streaming -data,
+// hashing the bars as they pass
+func streamCompare(d *dataFlags, s *strategyFlags) (*backtest.StrategyComparison, runWindow, error) {
+	dcaCfg, err := s.dcaConfig(*d.symbol)
+	if err != nil {
+		return nil, runWindow{}, err
+	}
+	startT, err := time.Parse(time.RFC3339, *d.start)
+	if err != nil {
+		return nil, runWindow{}, fmt.Errorf("invalid -start: %w", err)
+	}
+	endT, err := time.Parse(time.RFC3339, *d.end)
+	if err != nil {
+		return nil, runWindow{}, fmt.Errorf("invalid -end: %w", err)
+	}
+
+	it, err := backtest.OpenCandles(*d.data)
+	if err != nil {
+		return nil, runWindow{}, err
+	}
+	eng, err := d.engine()
+	if err != nil {
+		return nil, runWindow{}, err
+	}
+	hasher := experiments.NewDatasetHasher(startT, endT)
+	cmp, err := eng.StreamCompare(experiments.NewHashingIterator(backtest.Downsample(it, *d.resample), hasher), startT, endT, *d.initial, dcaCfg, s.gridConfig(*d.symbol))
+	return cmp, runWindow{start: startT, end: endT, dataset: hasher.Sum()}, err
+}
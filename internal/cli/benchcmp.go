@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var benchcmpCommand = &Command{
+	Name:    "benchcmp",
+	Summary: "Compare go test -bench output against a baseline and fail on regressions",
+	Run:     runBenchcmp,
+}
+
+func runBenchcmp(args []string) error {
+	fs := newFlagSet("benchcmp")
+	baseline := fs.String("baseline", "test/bench/baseline.txt", "go test -bench output to compare against")
+	threshold := fs.Float64("threshold", 0.3, "Largest allowed slowdown in ns/op or growth in allocs/op, as a fraction")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError(fs, "expected one file of go test -bench output")
+	}
+	if *threshold < 0 {
+		return usageError(fs, "-threshold must not be negative")
+	}
+
+	base, err := readBenchFile(*baseline)
+	if err != nil {
+		return err
+	}
+	current, err := readBenchFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	rows := compareBenchmarks(base, current, *threshold)
+	if !slices.ContainsFunc(rows, func(r benchRow) bool { return !r.Fresh }) {
+		return fmt.Errorf("no benchmarks in common with %s", *baseline)
+	}
+	regressions, err := writeBenchComparison(stdout, rows)
+	if err != nil {
+		return err
+	}
+	if regressions > 0 {
+		return fmt.Errorf("%d benchmark(s) regressed beyond %.0f%%", regressions, *threshold*100)
+	}
+	return nil
+}
+
+// benchResult is the median of one benchmark's runs
+type benchResult struct {
+	NsPerOp     float64
+	AllocsPerOp float64
+}
+
+// benchRow compares one benchmark with its baseline
+type benchRow struct {
+	Name             string
+	Base, Current    benchResult
+	NsDelta          float64 // fractional change in ns/op
+	AllocsDelta      float64 // fractional change in allocs/op
+	Regressed, Fresh bool    // Fresh: not in the baseline
+}
+
+// readBenchFile parses a file of go test -bench output
+func readBenchFile(path string) (map[string]benchResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open benchmarks: %w", err)
+	}
+	defer f.Close()
+	results, err := parseBenchmarks(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return results, nil
+}
+
+// parseBenchmarks reads go test -bench output into the median result of
+// each benchmark, keyed by package and name without the GOMAXPROCS suffix
+func parseBenchmarks(r io.Reader) (map[string]benchResult, error) {
+	ns := make(map[string][]float64)
+	allocs := make(map[string][]float64)
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "pkg:" {
+			pkg = fields[1]
+			continue
+		}
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		if pkg != "" {
+			name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+		}
+		// Values follow the iteration count as "<value> <unit>" pairs
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s value %q of %s", fields[i+1], fields[i], name)
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				ns[name] = append(ns[name], v)
+			case "allocs/op":
+				allocs[name] = append(allocs[name], v)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]benchResult, len(ns))
+	for name, runs := range ns {
+		results[name] = benchResult{NsPerOp: median(runs), AllocsPerOp: median(allocs[name])}
+	}
+	return results, nil
+}
+
+// median of values, 0 when there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// compareBenchmarks pairs the current results with the baseline, sorted by
+// name; benchmarks missing from the current run are left out
+func compareBenchmarks(base, current map[string]benchResult, threshold float64) []benchRow {
+	rows := make([]benchRow, 0, len(current))
+	for name, cur := range current {
+		row := benchRow{Name: name, Current: cur}
+		b, ok := base[name]
+		if !ok || b.NsPerOp <= 0 {
+			row.Fresh = true
+			rows = append(rows, row)
+			continue
+		}
+		row.Base = b
+		row.NsDelta = cur.NsPerOp/b.NsPerOp - 1
+		if b.AllocsPerOp > 0 {
+			row.AllocsDelta = cur.AllocsPerOp/b.AllocsPerOp - 1
+		} else if cur.AllocsPerOp > 0 {
+			row.AllocsDelta = 1
+		}
+		row.Regressed = row.NsDelta > threshold || row.AllocsDelta > threshold
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	return rows
+}
+
+// writeBenchComparison renders the rows and returns how many regressed
+func writeBenchComparison(w io.Writer, rows []benchRow) (int, error) {
+	regressions := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Benchmark\tBase ns/op\tns/op\tDelta\tBase allocs\tAllocs\tDelta\t\t")
+	for _, r := range rows {
+		if r.Fresh {
+			fmt.Fprintf(tw, "%s\t-\t%.0f\t-\t-\t%.0f\t-\tnew\t\n", r.Name, r.Current.NsPerOp, r.Current.AllocsPerOp)
+			continue
+		}
+		status := "ok"
+		if r.Regressed {
+			status = "REGRESSION"
+			regressions++
+		}
+		fmt.Fprintf(tw, "%s\t%.0f\t%.0f\t%+.1f%%\t%.0f\t%.0f\t%+.1f%%\t%s\t\n", r.Name,
+			r.Base.NsPerOp, r.Current.NsPerOp, r.NsDelta*100, r.Base.AllocsPerOp, r.Current.AllocsPerOp, r.AllocsDelta*100, status)
+	}
+	return regressions, tw.Flush()
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/binance"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var bootstrapTestnetCommand = &Command{
+	Name:    "bootstrap-testnet",
+	Summary: "Check API keys, balances and order placement against the Binance testnet",
+	Run:     runBootstrapTestnet,
+}
+
+// Readiness check outcomes
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// readinessCheck is one step of the testnet readiness report
+type readinessCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// readinessReport is what bootstrap-testnet found
+type readinessReport struct {
+	Symbol   string           `json:"symbol"`
+	Ready    bool             `json:"ready"`
+	Checks   []readinessCheck `json:"checks"`
+	Balances []types.Balance  `json:"balances,omitempty"`
+}
+
+func (r *readinessReport) add(name, status, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, readinessCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// sandboxExchange creates the testnet client bootstrap-testnet checks;
+// swapped in tests
+var sandboxExchange = func(apiKey, secretKey string) (types.ExchangeClient, error) {
+	return binance.NewClient(binance.ExchangeConfig{
+		APIKey:    apiKey,
+		SecretKey: secretKey,
+		Sandbox:   true,
+		RateLimit: binance.RateLimitConfig{RequestsPerSecond: 10, Burst: 10},
+	})
+}
+
+func runBootstrapTestnet(args []string) error {
+	fs := newFlagSet("bootstrap-testnet")
+	symbol := fs.String("symbol", "BTCUSDT", "Symbol to check balances and place the test order on")
+	testOrder := fs.Bool("test-order", false, "Place a limit buy far below the market and cancel it")
+	format := fs.String("format", "text", "Output format (text, json)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return usageError(fs, fmt.Sprintf("unknown format %q", *format))
+	}
+	if _, _, ok := types.SplitSymbol(*symbol); !ok {
+		return usageError(fs, fmt.Sprintf("cannot tell the quote asset of %s", *symbol))
+	}
+	apiKey, secretKey := os.Getenv("EXCHANGE_API_KEY"), os.Getenv("EXCHANGE_SECRET_KEY")
+	if apiKey == "" || secretKey == "" {
+		return usageError(fs, "EXCHANGE_API_KEY and EXCHANGE_SECRET_KEY must hold testnet keys")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	report := checkTestnet(ctx, apiKey, secretKey, *symbol, *testOrder)
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if err := writeReadiness(stdout, report); err != nil {
+		return err
+	}
+	if !report.Ready {
+		return fmt.Errorf("testnet is not ready for %s", *symbol)
+	}
+	return nil
+}
+
+// checkTestnet runs the readiness checks in order; a failed connection or
+// rejected keys skip the checks that need them
+func checkTestnet(ctx context.Context, apiKey, secretKey, symbol string, testOrder bool) *readinessReport {
+	report := &readinessReport{Symbol: symbol}
+	defer func() {
+		report.Ready = true
+		for _, c := range report.Checks {
+			if c.Status == checkFail {
+				report.Ready = false
+			}
+		}
+	}()
+
+	client, err := sandboxExchange(apiKey, secretKey)
+	if err == nil {
+		err = client.Ping(ctx)
+	}
+	if err != nil {
+		report.add("connection", checkFail, "%v", err)
+		return report
+	}
+	defer client.Close()
+	report.add("connection", checkOK, "testnet reachable, clock synced")
+
+	balances, err := client.GetBalances(ctx)
+	if err != nil {
+		report.add("api keys", checkFail, "signed request rejected: %v", err)
+		return report
+	}
+	report.add("api keys", checkOK, "signed requests accepted")
+	report.Balances = balances
+
+	ticker, err := client.GetTicker(ctx, symbol)
+	if err != nil {
+		report.add("symbol", checkFail, "%v", err)
+		return report
+	}
+	rules, err := client.GetSymbolRules(ctx, symbol)
+	switch {
+	case errors.Is(err, types.ErrNotSupported):
+		rules = &types.SymbolRules{Symbol: symbol}
+		report.add("symbol", checkOK, "last price %.8g, no order size rules", ticker.Price)
+	case err != nil:
+		report.add("symbol", checkFail, "%v", err)
+		return report
+	case rules.Halted:
+		report.add("symbol", checkFail, "%s is not open for trading", symbol)
+		return report
+	default:
+		report.add("symbol", checkOK, "last price %.8g, min notional %.8g", ticker.Price, rules.MinNotional)
+	}
+
+	// The test order is a minimum-size buy at half the bid
+	price := ticker.Bid
+	if price <= 0 {
+		price = ticker.Price
+	}
+	price = roundDown(price/2, rules.TickSize)
+	quantity := testQuantity(*rules, price)
+
+	_, quote, _ := types.SplitSymbol(symbol)
+	free := 0.0
+	for _, b := range balances {
+		if b.Asset == quote {
+			free = b.Free
+		}
+	}
+	switch need := quantity * price; {
+	case free == 0:
+		report.add("balances", checkFail, "no %s; request some from the testnet faucet", quote)
+	case free < need:
+		report.add("balances", checkWarn, "%.8g %s free, a minimum order needs %.8g", free, quote, need)
+	default:
+		report.add("balances", checkOK, "%.8g %s free across %d asset(s)", free, quote, len(balances))
+	}
+
+	if !testOrder {
+		report.add("test order", checkSkip, "pass -test-order to place and cancel one")
+		return report
+	}
+	if price <= 0 || quantity <= 0 {
+		report.add("test order", checkFail, "cannot size an order at price %.8g", price)
+		return report
+	}
+	status, detail := placeTestOrder(ctx, client, symbol, quantity, price)
+	report.add("test order", status, "%s", detail)
+	return report
+}
+
+// placeTestOrder places a limit buy that should rest, finds it among the
+// active orders by its client order id and cancels it
+func placeTestOrder(ctx context.Context, client types.ExchangeClient, symbol string, quantity, price float64) (string, string) {
+	clientID := "bootstrap-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	order := types.Order{Symbol: symbol, Side: types.OrderSideBuy, Type: types.OrderTypeLimit, Quantity: quantity, Price: price,
+		Status: types.OrderStatusNew, Timestamp: time.Now(), ExchangeOrder: &types.ExchangeOrder{ClientOrderID: clientID}}
+	if err := client.PlaceOrder(ctx, order); err != nil {
+		return checkFail, fmt.Sprintf("placing %.8g @ %.8g failed: %v", quantity, price, err)
+	}
+
+	active, err := client.GetActiveOrders(ctx, symbol)
+	if err != nil {
+		return checkFail, fmt.Sprintf("placed %s but listing open orders failed: %v", clientID, err)
+	}
+	for _, o := range active {
+		if o.ExchangeOrder == nil || o.ExchangeOrder.ClientOrderID != clientID {
+			continue
+		}
+		if err := client.CancelOrder(ctx, o.ID); err != nil {
+			return checkFail, fmt.Sprintf("order %s is resting but canceling it failed: %v", o.ID, err)
+		}
+		return checkOK, fmt.Sprintf("placed and canceled %.8g @ %.8g", quantity, price)
+	}
+	return checkWarn, fmt.Sprintf("placed %s but it is not among the open orders; check whether it filled", clientID)
+}
+
+// testQuantity is the smallest quantity the rules accept at price
+func testQuantity(rules types.SymbolRules, price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+	quantity := max(rules.MinQty, rules.MinNotional/price)
+	if rules.StepSize > 0 {
+		quantity = math.Ceil(quantity/rules.StepSize-1e-9) * rules.StepSize
+	}
+	if quantity == 0 {
+		quantity = 10 / price // no rules: about 10 in the quote asset
+	}
+	return quantity
+}
+
+// roundDown rounds v down to a multiple of tick, v itself without a tick
+func roundDown(v, tick float64) float64 {
+	if tick <= 0 {
+		return v
+	}
+	return math.Floor(v/tick+1e-9) * tick
+}
+
+// writeReadiness renders the checks and balances as text
+func writeReadiness(w io.Writer, report *readinessReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Check\tStatus\tDetail")
+	for _, c := range report.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, c.Status, c.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Balances) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "Asset\tFree\tLocked\t")
+		for _, b := range report.Balances {
+			fmt.Fprintf(tw, "%s\t%.8f\t%.8f\t\n", b.Asset, b.Free, b.Locked)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if report.Ready {
+		fmt.Fprintf(w, "\nReady: %s can trade on the testnet; set EXCHANGE_SANDBOX=true and start a bot\n", report.Symbol)
+	} else {
+		fmt.Fprintf(w, "\nNot ready: fix the failed checks above\n")
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var dcaCommand = botCommand("dca", "Run the DCA strategy bot", func(cfg *config.Config) (app.BotSpec, error) {
+	if cfg.Strategy.DCA == nil {
+		return app.BotSpec{}, fmt.Errorf("dca strategy config is missing")
+	}
+	dcaCfg := *cfg.Strategy.DCA
+	id, name, err := botIdentity(dcaCfg.ID, dcaCfg.Name, "dca", "DCA Bot")
+	if err != nil {
+		return app.BotSpec{}, err
+	}
+	spec := app.BotSpec{
+		ID:           id,
+		Name:         name,
+		Icon:         "🤖",
+		Symbol:       dcaCfg.Symbol,
+		LoopInterval: time.Minute,
+		PriceSwing:   500,
+		Build: func(c *app.Container) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateDCA(dcaCfg, c.Exchange())
+		},
+	}
+	if candidate := cfg.Shadow.Strategy.DCA; candidate != nil {
+		spec.Shadow, err = shadowSpec(cfg.Shadow, id, func(c *app.Container, exchange types.ExchangeClient) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateDCA(*candidate, exchange)
+		})
+	}
+	return spec, err
+})
+
+var gridCommand = botCommand("grid", "Run the Grid strategy bot", func(cfg *config.Config) (app.BotSpec, error) {
+	if cfg.Strategy.Grid == nil {
+		return app.BotSpec{}, fmt.Errorf("grid strategy config is missing")
+	}
+	gridCfg := *cfg.Strategy.Grid
+	id, name, err := botIdentity(gridCfg.ID, gridCfg.Name, "grid", "Grid Bot")
+	if err != nil {
+		return app.BotSpec{}, err
+	}
+	spec := app.BotSpec{
+		ID:           id,
+		Name:         name,
+		Icon:         "🔲",
+		Symbol:       gridCfg.Symbol,
+		LoopInterval: 30 * time.Second,
+		PriceSwing:   1000,
+		Build: func(c *app.Container) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateGrid(gridCfg, c.Exchange())
+		},
+	}
+	if candidate := cfg.Shadow.Strategy.Grid; candidate != nil {
+		spec.Shadow, err = shadowSpec(cfg.Shadow, id, func(c *app.Container, exchange types.ExchangeClient) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateGrid(*candidate, exchange)
+		})
+	}
+	return spec, err
+})
+
+var comboCommand = botCommand("combo", "Run the Combo (DCA + Grid) strategy bot", func(cfg *config.Config) (app.BotSpec, error) {
+	if cfg.Strategy.Combo == nil {
+		return app.BotSpec{}, fmt.Errorf("combo strategy config is missing")
+	}
+	comboCfg := *cfg.Strategy.Combo
+	id, name, err := botIdentity(comboCfg.ID, comboCfg.Name, "combo", "Combo Bot")
+	if err != nil {
+		return app.BotSpec{}, err
+	}
+	spec := app.BotSpec{
+		ID:           id,
+		Name:         name,
+		Icon:         "🎯",
+		Symbol:       "BTCUSDT", // Default symbol
+		LoopInterval: time.Minute,
+		PriceSwing:   750,
+		Build: func(c *app.Container) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateCombo(comboCfg, c.Exchange())
+		},
+	}
+	if candidate := cfg.Shadow.Strategy.Combo; candidate != nil {
+		// Sub-accounts are reached through live clients the shadow cannot stand in for
+		for _, sub := range candidate.Strategies {
+			if sub.Account != "" {
+				return app.BotSpec{}, fmt.Errorf("shadow combo strategy %s cannot trade account %s", sub.ID, sub.Account)
+			}
+		}
+		spec.Shadow, err = shadowSpec(cfg.Shadow, id, func(c *app.Container, exchange types.ExchangeClient) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateCombo(*candidate, exchange)
+		})
+	}
+	return spec, err
+})
+
+var breakoutCommand = botCommand("breakout", "Run the channel breakout strategy bot", func(cfg *config.Config) (app.BotSpec, error) {
+	if cfg.Strategy.Breakout == nil {
+		return app.BotSpec{}, fmt.Errorf("breakout strategy config is missing")
+	}
+	breakoutCfg := *cfg.Strategy.Breakout
+	id, name, err := botIdentity(breakoutCfg.ID, breakoutCfg.Name, "breakout", "Breakout Bot")
+	if err != nil {
+		return app.BotSpec{}, err
+	}
+	spec := app.BotSpec{
+		ID:           id,
+		Name:         name,
+		Icon:         "📈",
+		Symbol:       breakoutCfg.Symbol,
+		LoopInterval: time.Minute,
+		PriceSwing:   500,
+		Build: func(c *app.Container) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateBreakout(breakoutCfg, c.Exchange())
+		},
+	}
+	if candidate := cfg.Shadow.Strategy.Breakout; candidate != nil {
+		spec.Shadow, err = shadowSpec(cfg.Shadow, id, func(c *app.Container, exchange types.ExchangeClient) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateBreakout(*candidate, exchange)
+		})
+	}
+	return spec, err
+})
+
+var customCommand = botCommand("custom", "Run a plugin strategy configured under strategy.custom", func(cfg *config.Config) (app.BotSpec, error) {
+	if cfg.Strategy.Custom == nil || cfg.Strategy.Custom.Type == "" {
+		return app.BotSpec{}, fmt.Errorf("custom strategy config with a type is missing")
+	}
+	custom := *cfg.Strategy.Custom
+	symbol, _ := custom.Config["symbol"].(string)
+	if symbol == "" {
+		symbol = "BTCUSDT"
+	}
+	configuredID, _ := custom.Config["id"].(string)
+	configuredName, _ := custom.Config["name"].(string)
+	id, name, err := botIdentity(configuredID, configuredName, custom.Type, "Custom Bot ("+custom.Type+")")
+	if err != nil {
+		return app.BotSpec{}, err
+	}
+	spec := app.BotSpec{
+		ID:           id,
+		Name:         name,
+		Icon:         "🧩",
+		Symbol:       symbol,
+		LoopInterval: time.Minute,
+		Build: func(c *app.Container) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateRegistered(custom.Type, custom.Config, c.Exchange())
+		},
+	}
+	if cfg.Shadow.Strategy.Custom != nil {
+		candidate := *cfg.Shadow.Strategy.Custom
+		if candidate.Type == "" {
+			candidate.Type = custom.Type
+		}
+		spec.Shadow, err = shadowSpec(cfg.Shadow, id, func(c *app.Container, exchange types.ExchangeClient) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateRegistered(candidate.Type, candidate.Config, exchange)
+		})
+	}
+	return spec, err
+})
+
+// botIdentity returns the bot's state and metrics id and display name: the
+// strategy's configured id and name, or the defaults of its type. Instances
+// sharing a state dir need distinct ids.
+func botIdentity(id, name, defaultID, defaultName string) (string, string, error) {
+	if err := strategy.ValidateID(id); err != nil {
+		return "", "", err
+	}
+	if id == "" {
+		id = defaultID
+	}
+	if name == "" {
+		name = defaultName
+		if id != defaultID {
+			name += " (" + id + ")"
+		}
+	}
+	return id, name, nil
+}
+
+// shadowSpec runs a candidate in shadow mode or A/B tests it against bot
+// id, under the configured shadow id or "<id>-shadow"
+func shadowSpec(cfg config.ShadowConfig, id string, build func(c *app.Container, exchange types.ExchangeClient) (strategy.Strategy, error)) (*app.ShadowSpec, error) {
+	if err := strategy.ValidateID(cfg.ID); err != nil {
+		return nil, fmt.Errorf("shadow: %w", err)
+	}
+	shadowID := cfg.ID
+	if shadowID == "" {
+		shadowID = id + "-shadow"
+	}
+	if shadowID == id {
+		return nil, fmt.Errorf("shadow id %q must differ from the bot's", shadowID)
+	}
+	return &app.ShadowSpec{ID: shadowID, Days: cfg.Days, Allocation: cfg.Allocation, Interval: cfg.Interval, Alpha: cfg.Alpha, Build: build}, nil
+}
+
+// botCommand builds a subcommand that runs a long-lived strategy bot.
+// Adding a strategy bot only needs a spec function here.
+func botCommand(name, summary string, spec func(cfg *config.Config) (app.BotSpec, error)) *Command {
+	return &Command{
+		Name:    name,
+		Summary: summary,
+		Run: func(args []string) error {
+			fs := newFlagSet(name)
+			configFile := fs.String("config", "", "Path to config file")
+			if err := parseFlags(fs, args); err != nil {
+				return err
+			}
+
+			cfg, err := app.LoadConfig(*configFile)
+			if err != nil {
+				return err
+			}
+
+			botSpec, err := spec(cfg)
+			if err != nil {
+				return err
+			}
+
+			c, err := app.NewContainer(cfg)
+			if err != nil {
+				return err
+			}
+
+			return app.RunBot(c, botSpec)
+		},
+	}
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/binance"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var capacityCommand = &Command{
+	Name:    "capacity",
+	Summary: "Estimate the capital each configured strategy can trade before slippage erodes its edge",
+	Run:     runCapacity,
+}
+
+func runCapacity(args []string) error {
+	fs := newFlagSet("capacity")
+	configFile := fs.String("config", "", "Path to config file")
+	edge := fs.Float64("edge-bps", 25, "Expected return per order before costs, in basis points")
+	participation := fs.Float64("max-participation", 0.01, "Largest share of daily volume the strategy may trade")
+	ordersPerDay := fs.Float64("orders-per-day", 0, "Orders a day of grid and breakout strategies, which trade on price (default: volume limit skipped)")
+	depth := fs.Int("depth", 1000, "Order book levels to fetch")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *edge <= 0 {
+		return usageError(fs, "-edge-bps must be positive")
+	}
+	if *participation <= 0 || *participation > 1 {
+		return usageError(fs, "-max-participation must be in (0, 1]")
+	}
+	if *ordersPerDay < 0 {
+		return usageError(fs, "-orders-per-day must not be negative")
+	}
+
+	cfg, err := app.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	inputs, err := capacityInputs(cfg.Strategy, *ordersPerDay)
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("no dca, grid, breakout or combo strategy configured")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	client, err := marketExchange()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, in := range inputs {
+		if !seen[in.Symbol] {
+			seen[in.Symbol] = true
+			symbols = append(symbols, in.Symbol)
+		}
+	}
+	tickers, err := client.GetTickers(ctx, symbols)
+	if err != nil {
+		return fmt.Errorf("failed to get tickers: %w", err)
+	}
+	prices := types.TickersBySymbol(tickers)
+
+	reports := make([]analytics.CapacityReport, 0, len(inputs))
+	for _, in := range inputs {
+		in.EdgeBps, in.MaxParticipation = *edge, *participation
+		book, err := client.GetOrderBook(ctx, in.Symbol, *depth)
+		if err != nil {
+			return fmt.Errorf("failed to get %s order book: %w", in.Symbol, err)
+		}
+		ticker, ok := prices[in.Symbol]
+		if !ok {
+			return fmt.Errorf("no ticker for %s", in.Symbol)
+		}
+		// Ticker volume is in the base asset
+		reports = append(reports, analytics.EstimateCapacity(in, book, ticker.Volume*ticker.Price))
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+// marketExchange creates the client capacity reads public market data from;
+// swapped in tests
+var marketExchange = func() (types.ExchangeClient, error) {
+	return binance.NewClient(binance.ExchangeConfig{
+		RateLimit: binance.RateLimitConfig{RequestsPerSecond: 10, Burst: 10},
+	})
+}
+
+// capacityInputs describes the order flow of each configured strategy,
+// including a combo's sub-strategies. Strategies that trade on price rather
+// than a schedule are given ordersPerDay.
+func capacityInputs(cfg config.StrategyConfig, ordersPerDay float64) ([]analytics.CapacityInput, error) {
+	var inputs []analytics.CapacityInput
+	if cfg.DCA != nil {
+		inputs = append(inputs, dcaCapacity("dca", *cfg.DCA))
+	}
+	if cfg.Grid != nil {
+		inputs = append(inputs, gridCapacity("grid", *cfg.Grid, ordersPerDay))
+	}
+	if cfg.Breakout != nil {
+		inputs = append(inputs, breakoutCapacity("breakout", *cfg.Breakout, ordersPerDay))
+	}
+	if cfg.Combo == nil {
+		return inputs, nil
+	}
+
+	for i, sub := range cfg.Combo.Strategies {
+		id := sub.ID
+		if id == "" {
+			id = fmt.Sprintf("%s_%d", sub.Type, i)
+		}
+		data, err := json.Marshal(sub.Config)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		switch sub.Type {
+		case "dca":
+			var dca types.DCAConfig
+			if err := json.Unmarshal(data, &dca); err != nil {
+				return nil, fmt.Errorf("%s: invalid DCA config: %w", id, err)
+			}
+			inputs = append(inputs, dcaCapacity(id, dca))
+		case "grid":
+			var grid types.GridConfig
+			if err := json.Unmarshal(data, &grid); err != nil {
+				return nil, fmt.Errorf("%s: invalid Grid config: %w", id, err)
+			}
+			inputs = append(inputs, gridCapacity(id, grid, ordersPerDay))
+		case "breakout":
+			var breakout types.BreakoutConfig
+			if err := json.Unmarshal(data, &breakout); err != nil {
+				return nil, fmt.Errorf("%s: invalid Breakout config: %w", id, err)
+			}
+			inputs = append(inputs, breakoutCapacity(id, breakout, ordersPerDay))
+		}
+	}
+	return inputs, nil
+}
+
+// dcaCapacity buys InvestmentAmount every Interval, up to MaxInvestments buys
+func dcaCapacity(id string, cfg types.DCAConfig) analytics.CapacityInput {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	capital := cfg.InvestmentAmount * float64(max(cfg.MaxInvestments, 1))
+	return analytics.CapacityInput{Strategy: id, Symbol: cfg.Symbol, Capital: capital, OrderSize: cfg.InvestmentAmount, OrdersPerDay: float64(24*time.Hour) / float64(interval)}
+}
+
+// gridCapacity trades InvestmentPerLevel at each of GridLevels levels
+func gridCapacity(id string, cfg types.GridConfig, ordersPerDay float64) analytics.CapacityInput {
+	capital := cfg.InvestmentPerLevel * float64(max(cfg.GridLevels, 1))
+	return analytics.CapacityInput{Strategy: id, Symbol: cfg.Symbol, Capital: capital, OrderSize: cfg.InvestmentPerLevel, OrdersPerDay: ordersPerDay}
+}
+
+// breakoutCapacity enters and exits with its whole InvestmentAmount
+func breakoutCapacity(id string, cfg types.BreakoutConfig, ordersPerDay float64) analytics.CapacityInput {
+	return analytics.CapacityInput{Strategy: id, Symbol: cfg.Symbol, Capital: cfg.InvestmentAmount, OrderSize: cfg.InvestmentAmount, OrdersPerDay: ordersPerDay}
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/binance"
+)
+
+var checkDataCommand = &Command{
+	Name:    "check-data",
+	Summary: "Check a candle file for gaps, duplicates, zero volume and outlier prices",
+	Run:     runCheckData,
+}
+
+func runCheckData(args []string) error {
+	fs := newFlagSet("check-data")
+	data := fs.String("data", "", "Path to .csv or .parquet candles")
+	interval := fs.Duration("interval", 0, "Expected bar spacing (default: inferred)")
+	threshold := fs.Float64("outlier", 12, "Robust z-score of a close-to-close move flagged as an outlier")
+	format := fs.String("format", "text", "Output format (text, json)")
+	strict := fs.Bool("strict", false, "Exit with status 1 when any issue is found")
+	repairOut := fs.String("repair-out", "", "Fill gaps from Binance and write the repaired candles to this path")
+	symbol := fs.String("symbol", "BTCUSDT", "Symbol fetched by -repair-out")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *data == "" {
+		return usageError(fs, "-data is required")
+	}
+	if *format != "text" && *format != "json" {
+		return usageError(fs, fmt.Sprintf("unknown format %q", *format))
+	}
+
+	opts := backtest.QualityOptions{Interval: *interval, OutlierThreshold: *threshold}
+	var report backtest.QualityReport
+	if *repairOut == "" {
+		// Stream so files of any size are checked in bounded memory
+		it, err := backtest.OpenCandles(*data)
+		if err != nil {
+			return err
+		}
+		if report, err = backtest.CheckIterator(it, opts); err != nil {
+			return err
+		}
+	} else {
+		it, err := backtest.OpenCandles(*data)
+		if err != nil {
+			return err
+		}
+		candles, err := backtest.CollectCandles(it)
+		if err != nil {
+			return err
+		}
+		report = backtest.CheckCandles(candles, opts)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		repaired, added, err := repairGaps(ctx, *symbol, candles, report)
+		if err != nil {
+			return err
+		}
+		if err := backtest.WriteCandles(*repairOut, repaired); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "check-data: added %d of %d missing bars, wrote %d candles to %s\n", added, report.Missing(), len(repaired), *repairOut)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if err := writeQualityReport(stdout, report); err != nil {
+		return err
+	}
+
+	if *strict && !report.OK() {
+		return fmt.Errorf("data quality issues: %s", report.Summary())
+	}
+	return nil
+}
+
+// writeQualityReport renders a quality report as text
+func writeQualityReport(w io.Writer, report backtest.QualityReport) error {
+	fmt.Fprintf(w, "Candles: %d (%s to %s, every %s)\n", report.Candles,
+		report.First.Format(time.RFC3339), report.Last.Format(time.RFC3339), report.Interval)
+	fmt.Fprintf(w, "Issues: %s\n", report.Summary())
+	if len(report.Issues) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Time\tKind\tDetail")
+	for _, issue := range report.Issues {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", issue.Time.Format(time.RFC3339), issue.Kind, issue.Detail)
+	}
+	return tw.Flush()
+}
+
+// checkCandles warns on stderr about data quality problems in loaded
+// candles and, when asked, fills gaps from the exchange
+func checkCandles(d *dataFlags, candles []backtest.Candle) ([]backtest.Candle, error) {
+	report := backtest.CheckCandles(candles, backtest.QualityOptions{})
+	if report.OK() {
+		return candles, nil
+	}
+	fmt.Fprintf(stderr, "warning: %s: %s\n", *d.data, report.Summary())
+	for i, issue := range report.Issues {
+		if i == 5 {
+			fmt.Fprintf(stderr, "  ... run 'trader check-data -data %s' for the full list\n", *d.data)
+			break
+		}
+		fmt.Fprintf(stderr, "  %s %s: %s\n", issue.Time.Format(time.RFC3339), issue.Kind, issue.Detail)
+	}
+
+	if !*d.repairGaps || len(report.Gaps) == 0 {
+		return candles, nil
+	}
+	repaired, added, err := repairGaps(context.Background(), *d.symbol, candles, report)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(stderr, "Repaired %d of %d missing bars from the exchange\n", added, report.Missing())
+	return repaired, nil
+}
+
+// repairGaps fetches the bars missing in report's gaps at the report's interval
+func repairGaps(ctx context.Context, symbol string, candles []backtest.Candle, report backtest.QualityReport) ([]backtest.Candle, int, error) {
+	interval, err := klineInterval(report.Interval)
+	if err != nil {
+		return nil, 0, err
+	}
+	return backtest.RepairGaps(ctx, candles, report.Gaps, func(ctx context.Context, start, end time.Time) ([]backtest.Candle, error) {
+		return fetchCandles(ctx, symbol, interval, start, end)
+	})
+}
+
+// klineIntervals maps bar lengths to Binance kline intervals
+var klineIntervals = map[time.Duration]string{
+	time.Minute:        "1m",
+	3 * time.Minute:    "3m",
+	5 * time.Minute:    "5m",
+	15 * time.Minute:   "15m",
+	30 * time.Minute:   "30m",
+	time.Hour:          "1h",
+	2 * time.Hour:      "2h",
+	4 * time.Hour:      "4h",
+	6 * time.Hour:      "6h",
+	8 * time.Hour:      "8h",
+	12 * time.Hour:     "12h",
+	24 * time.Hour:     "1d",
+	3 * 24 * time.Hour: "3d",
+	7 * 24 * time.Hour: "1w",
+}
+
+func klineInterval(d time.Duration) (string, error) {
+	interval, ok := klineIntervals[d]
+	if !ok {
+		return "", fmt.Errorf("no exchange interval for %s bars", d)
+	}
+	return interval, nil
+}
+
+// fetchCandles downloads [start, end] candles from Binance; swapped in tests
+var fetchCandles = func(ctx context.Context, symbol, interval string, start, end time.Time) ([]backtest.Candle, error) {
+	// Public market data needs no API keys
+	client, err := binance.NewClient(binance.ExchangeConfig{
+		RateLimit: binance.RateLimitConfig{RequestsPerSecond: 10, Burst: 10},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	klines, err := client.GetCandlesRange(ctx, symbol, interval, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch candles: %w", err)
+	}
+	candles := make([]backtest.Candle, 0, len(klines))
+	for _, k := range klines {
+		candles = append(candles, backtest.Candle{Time: k.Timestamp, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume})
+	}
+	return candles, nil
+}
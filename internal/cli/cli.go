@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// errUsage reports invalid arguments; the command's usage is already printed
+var errUsage = errors.New("invalid usage")
+
+// Command is a trader subcommand
+type Command struct {
+	Name    string
+	Summary string
+	Run     func(args []string) error
+}
+
+// commands lists subcommands in the order shown by help
+var commands = []*Command{
+	initCommand,
+	demoCommand,
+	configCommand,
+	bootstrapTestnetCommand,
+	dcaCommand,
+	gridCommand,
+	comboCommand,
+	breakoutCommand,
+	customCommand,
+	backtestCommand,
+	optimizeCommand,
+	experimentsCommand,
+	fetchDataCommand,
+	checkDataCommand,
+	reportCommand,
+	slippageCommand,
+	statementCommand,
+	capacityCommand,
+	shadowCommand,
+	regimeCommand,
+	observeCommand,
+	tuiCommand,
+	dashboardCommand,
+	pluginsCommand,
+	benchcmpCommand,
+	collectorCommand,
+	sentimentBackfillCommand,
+}
+
+// stdout and stderr are swapped in tests
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+// Run dispatches args to a subcommand and returns the process exit code
+func Run(args []string) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(stdout)
+		return 0
+	}
+
+	cmd := lookup(name)
+	if cmd == nil {
+		fmt.Fprintf(stderr, "trader: unknown command %q\n\n", name)
+		printUsage(stderr)
+		return 2
+	}
+
+	if err := cmd.Run(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "trader %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func lookup(name string) *Command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: trader <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.Name, cmd.Summary)
+	}
+	_ = tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'trader <command> -h' for command flags.")
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("trader "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parses args, mapping parse errors to errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// usageError prints msg with the flag defaults and returns errUsage
+func usageError(fs *flag.FlagSet, msg string) error {
+	fmt.Fprintf(stderr, "%s: %s\n", fs.Name(), msg)
+	fs.PrintDefaults()
+	return errUsage
+}
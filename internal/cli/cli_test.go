@@ -0,0 +1,1127 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func captureOutput(t *testing.T) (*bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	oldOut, oldErr := stdout, stderr
+	stdout, stderr = &out, &errOut
+	t.Cleanup(func() { stdout, stderr = oldOut, oldErr })
+	return &out, &errOut
+}
+
+func TestRun_Dispatch(t *testing.T) {
+	out, errOut := captureOutput(t)
+
+	if code := Run(nil); code != 2 {
+		t.Errorf("Run(nil) = %d, want 2", code)
+	}
+	if code := Run([]string{"unknown"}); code != 2 {
+		t.Errorf("Run(unknown) = %d, want 2", code)
+	}
+	if !strings.Contains(errOut.String(), `unknown command "unknown"`) {
+		t.Errorf("Expected unknown command message, got %q", errOut.String())
+	}
+
+	if code := Run([]string{"help"}); code != 0 {
+		t.Errorf("Run(help) = %d, want 0", code)
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(out.String(), cmd.Name) {
+			t.Errorf("Expected help to list %s", cmd.Name)
+		}
+	}
+
+	if code := Run([]string{"backtest"}); code != 2 {
+		t.Errorf("Run(backtest) without data = %d, want 2", code)
+	}
+}
+
+func TestRun_BacktestSynthetic(t *testing.T) {
+	out, _ := captureOutput(t)
+
+	if code := Run([]string{"backtest", "-synthetic", "sideways", "-bars", "300", "-grid-lower", "40000", "-grid-upper", "50000"}); code != 0 {
+		t.Fatalf("Run(backtest) = %d, want 0", code)
+	}
+
+	var cmp map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &cmp); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if _, ok := cmp["dca_results"]; !ok {
+		t.Error("Expected dca_results in output")
+	}
+
+	// A misspelt scenario fails instead of running sideways
+	if code := Run([]string{"backtest", "-synthetic", "sidways", "-bars", "300"}); code == 0 {
+		t.Error("Run(backtest -synthetic sidways) = 0, want an unknown scenario error")
+	}
+}
+
+func TestRun_BacktestBreakout(t *testing.T) {
+	out, _ := captureOutput(t)
+
+	if code := Run([]string{"backtest", "-synthetic", "bull", "-bars", "600", "-breakout", "-breakout-entry", "10", "-breakout-exit", "5", "-breakout-atr", "3"}); code != 0 {
+		t.Fatalf("Run(backtest -breakout) = %d, want 0", code)
+	}
+	var cmp backtest.StrategyComparison
+	if err := json.Unmarshal(out.Bytes(), &cmp); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if cmp.BreakoutResults == nil || cmp.BreakoutResults.TradeCount == 0 {
+		t.Fatalf("breakout_results = %+v, want breakout trades in a bull market", cmp.BreakoutResults)
+	}
+
+	if code := Run([]string{"backtest", "-synthetic", "bull", "-stream", "-breakout"}); code != 2 {
+		t.Errorf("Run(backtest -stream -breakout) = %d, want 2", code)
+	}
+}
+
+func TestRun_BacktestPortfolio(t *testing.T) {
+	out, _ := captureOutput(t)
+	path := filepath.Join(t.TempDir(), "portfolio.json")
+	portfolio := `{"legs": [
+		{"name": "btc-dca", "symbol": "BTCUSDT", "synthetic": "bull", "seed": 1, "dca": {"investment_amount": 500, "interval": "24h", "max_investments": 100}},
+		{"name": "eth-grid", "symbol": "ETHUSDT", "synthetic": "sideways", "seed": 2, "grid": {"lower_price": 40000, "upper_price": 50000, "grid_levels": 10, "investment_per_level": 500}}
+	]}`
+	if err := os.WriteFile(path, []byte(portfolio), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := Run([]string{"backtest", "-portfolio", path, "-bars", "600", "-initial", "3000"}); code != 0 {
+		t.Fatalf("Run(backtest -portfolio) = %d, want 0", code)
+	}
+	var result backtest.PortfolioResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if len(result.Legs) != 2 || result.Legs[0].Name != "btc-dca" || result.Legs[0].TradeCount == 0 {
+		t.Fatalf("legs = %+v, want both with the DCA leg trading", result.Legs)
+	}
+	// 3000 cannot fund a 500 buy on each of the 25 days next to the grid
+	if result.Legs[0].CashShortfalls+result.Legs[1].CashShortfalls == 0 {
+		t.Errorf("legs = %+v, want them to compete for the 3000 balance", result.Legs)
+	}
+}
+
+func TestRun_BacktestExecution(t *testing.T) {
+	out, _ := captureOutput(t)
+	if code := Run([]string{"backtest", "-synthetic", "sideways", "-bars", "300", "-execution", "market,limit_chase,twap", "-maker-fee", "0"}); code != 0 {
+		t.Fatalf("Run(backtest -execution) = %d, want 0", code)
+	}
+	var cmp backtest.ExecutionComparison
+	if err := json.Unmarshal(out.Bytes(), &cmp); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if len(cmp.Results) != 3 || cmp.Results[1].Execution.Style != backtest.ExecutionLimitChase || cmp.Results[0].Metrics.TradeCount == 0 {
+		t.Fatalf("results = %+v, want market, limit_chase and twap runs", cmp.Results)
+	}
+
+	if code := Run([]string{"backtest", "-synthetic", "sideways", "-execution", "vwap"}); code == 0 {
+		t.Error("expected an unknown execution style to fail")
+	}
+}
+
+func TestRun_BacktestOverlay(t *testing.T) {
+	out, _ := captureOutput(t)
+	if code := Run([]string{"backtest", "-synthetic", "sideways", "-bars", "500", "-overlay", "covered_call,cash_secured_put", "-overlay-tenor", "24h"}); code != 0 {
+		t.Fatalf("Run(backtest -overlay) = %d, want 0", code)
+	}
+	var cmp backtest.OverlayComparison
+	if err := json.Unmarshal(out.Bytes(), &cmp); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if len(cmp.Results) != 2 || cmp.Results[0].Overlay.Style != backtest.OverlayCoveredCall || cmp.Results[0].Written == 0 {
+		t.Fatalf("results = %+v, want covered call and cash-secured put runs", cmp.Results)
+	}
+}
+
+func TestRun_Regime(t *testing.T) {
+	out, _ := captureOutput(t)
+	modelPath := filepath.Join(t.TempDir(), "regime.json")
+	if code := Run([]string{"regime", "-synthetic", "high_vol", "-bars", "1000", "-model-out", modelPath}); code != 0 {
+		t.Fatalf("Run(regime) = %d, want 0", code)
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	for _, key := range []string{"train", "test", "rules"} {
+		if _, ok := report[key].(map[string]interface{})["counts"]; !ok {
+			t.Errorf("Expected a %s confusion matrix, got %v", key, report[key])
+		}
+	}
+	if _, err := ai.LoadRegimeDetector(modelPath); err != nil {
+		t.Errorf("saved model does not load: %v", err)
+	}
+}
+
+func TestRun_BacktestExchangeFees(t *testing.T) {
+	oldExchange := feeExchange
+	t.Cleanup(func() { feeExchange = oldExchange })
+	feeExchange = func() (types.ExchangeClient, error) { return mock.NewMockClient(), nil }
+
+	fees := func(args ...string) float64 {
+		t.Helper()
+		out, _ := captureOutput(t)
+		if code := Run(append([]string{"backtest", "-synthetic", "sideways", "-bars", "300", "-fee", "0"}, args...)); code != 0 {
+			t.Fatalf("Run(backtest %v) = %d, want 0", args, code)
+		}
+		var cmp struct {
+			DCA backtest.PerformanceMetrics `json:"dca_results"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &cmp); err != nil {
+			t.Fatalf("Expected JSON output: %v", err)
+		}
+		return cmp.DCA.TotalFees
+	}
+	if got := fees(); got != 0 {
+		t.Fatalf("Expected no fees with -fee 0, got %v", got)
+	}
+	// The mock exchange charges 0.1%
+	if got := fees("-exchange-fees"); got <= 0 {
+		t.Fatalf("Expected exchange fees, got %v", got)
+	}
+}
+
+func TestRun_OptimizeRanksResults(t *testing.T) {
+	out, errOut := captureOutput(t)
+
+	args := []string{"optimize", "-synthetic", "sideways", "-bars", "300", "-strategy", "grid", "-metric", "return", "-grid-levels", "5,20", "-grid-bands", "0.05,0.2", "-top", "3", "-workers", "2", "-progress"}
+	if code := Run(args); code != 0 {
+		t.Fatalf("Run(optimize) = %d, want 0", code)
+	}
+
+	var results []OptimizeResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if !strings.Contains(errOut.String(), "optimize: 4/4 runs") {
+		t.Errorf("Expected progress on stderr, got %q", errOut.String())
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("Results not ranked: %v > %v", results[i].Score, results[i-1].Score)
+		}
+	}
+}
+
+func TestRun_BacktestStreamParquet(t *testing.T) {
+	out, _ := captureOutput(t)
+	path := filepath.Join(t.TempDir(), "sideways.parquet")
+
+	if code := Run([]string{"backtest", "-synthetic", "sideways", "-bars", "300", "-fixture-out", path}); code != 0 {
+		t.Fatalf("Run(backtest -fixture-out) = %d, want 0", code)
+	}
+	candles, err := backtest.NewEngine(0).LoadCandles(path, 0)
+	if err != nil {
+		t.Fatalf("Expected parquet fixture: %v", err)
+	}
+	start := candles[0].Time.Format(time.RFC3339)
+	end := candles[len(candles)-1].Time.Format(time.RFC3339)
+
+	if code := Run([]string{"backtest", "-stream", "-data", path}); code != 2 {
+		t.Errorf("Run(backtest -stream) without range = %d, want 2", code)
+	}
+
+	out.Reset()
+	args := []string{"backtest", "-stream", "-data", path, "-resample", "4h", "-start", start, "-end", end, "-dca-interval", "24h"}
+	if code := Run(args); code != 0 {
+		t.Fatalf("Run(backtest -stream) = %d, want 0", code)
+	}
+	var cmp backtest.StrategyComparison
+	if err := json.Unmarshal(out.Bytes(), &cmp); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	// 300 hourly bars resampled to 4h with a buy per day
+	if cmp.DCAResults.TradeCount < 12 || cmp.DCAResults.TradeCount > 13 {
+		t.Errorf("Expected ~12 DCA buys, got %d", cmp.DCAResults.TradeCount)
+	}
+	if cmp.Regimes != nil {
+		t.Error("Streaming backtest should not report regimes")
+	}
+}
+
+func TestRun_RecordsExperiments(t *testing.T) {
+	out, errOut := captureOutput(t)
+	db := filepath.Join(t.TempDir(), "experiments.db")
+	t.Setenv("EXPERIMENTS_DB", db)
+
+	if code := Run([]string{"backtest", "-synthetic", "sideways", "-bars", "300"}); code != 0 {
+		t.Fatalf("Run(backtest) = %d, want 0: %s", code, errOut.String())
+	}
+	args := []string{"optimize", "-synthetic", "sideways", "-bars", "300", "-strategy", "grid", "-grid-levels", "5,20", "-grid-bands", "0.1", "-top", "1"}
+	if code := Run(args); code != 0 {
+		t.Fatalf("Run(optimize) = %d, want 0: %s", code, errOut.String())
+	}
+
+	// Both strategies of the backtest plus every optimizer run, not just the top one
+	out.Reset()
+	if code := Run([]string{"experiments", "list", "-format", "json"}); code != 0 {
+		t.Fatalf("Run(experiments list) = %d: %s", code, errOut.String())
+	}
+	var runs []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &runs); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("Expected 4 recorded runs, got %d", len(runs))
+	}
+	// Synthetic data is seeded, so backtest and optimize saw the same bars
+	if runs[0]["dataset_hash"] == "" || runs[0]["dataset_hash"] != runs[3]["dataset_hash"] {
+		t.Errorf("Expected matching dataset hashes, got %v and %v", runs[0]["dataset_hash"], runs[3]["dataset_hash"])
+	}
+
+	out.Reset()
+	if code := Run([]string{"experiments", "baseline", "2"}); code != 0 {
+		t.Fatalf("Run(experiments baseline) = %d: %s", code, errOut.String())
+	}
+	out.Reset()
+	if code := Run([]string{"experiments", "compare", "3", "4"}); code != 0 {
+		t.Fatalf("Run(experiments compare) = %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "Reference: baseline 2 (grid BTCUSDT)") {
+		t.Errorf("Expected comparison against the baseline, got %q", out.String())
+	}
+	if code := Run([]string{"experiments", "show", "99"}); code != 1 {
+		t.Errorf("Run(experiments show 99) = %d, want 1", code)
+	}
+}
+
+func TestRun_OptimizeBayes(t *testing.T) {
+	out, errOut := captureOutput(t)
+
+	args := []string{"optimize", "-synthetic", "sideways", "-bars", "300", "-strategy", "dca", "-method", "bayes", "-trials", "12",
+		"-metric", "calmar", "-dca-intervals", "6h,72h", "-dca-amounts", "50,200", "-top", "0", "-workers", "3", "-progress"}
+	if code := Run(args); code != 0 {
+		t.Fatalf("Run(optimize -method bayes) = %d, want 0: %s", code, errOut.String())
+	}
+
+	var results []OptimizeResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if len(results) != 12 {
+		t.Fatalf("Expected 12 trials, got %d", len(results))
+	}
+	if !strings.Contains(errOut.String(), "optimize: 12/12 runs") {
+		t.Errorf("Expected progress on stderr, got %q", errOut.String())
+	}
+	for _, r := range results {
+		interval, err := time.ParseDuration(r.Params["interval"].(string))
+		amount := r.Params["investment_amount"].(float64)
+		if err != nil || interval < 6*time.Hour || interval > 72*time.Hour || amount < 50 || amount > 200 {
+			t.Errorf("Trial outside the searched range: %v", r.Params)
+		}
+	}
+
+	if code := Run([]string{"optimize", "-synthetic", "sideways", "-method", "anneal"}); code != 2 {
+		t.Errorf("Run(optimize -method anneal) = %d, want 2", code)
+	}
+}
+
+func TestRun_OptimizeOverfit(t *testing.T) {
+	out, errOut := captureOutput(t)
+
+	args := []string{"optimize", "-synthetic", "sideways", "-bars", "400", "-strategy", "grid", "-grid-levels", "5,10,20", "-grid-bands", "0.05,0.1", "-overfit", "-blocks", "8", "-top", "2"}
+	if code := Run(args); code != 0 {
+		t.Fatalf("Run(optimize -overfit) = %d, want 0: %s", code, errOut.String())
+	}
+
+	var output struct {
+		Results     []OptimizeResult `json:"results"`
+		Overfitting OverfitReport    `json:"overfitting"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &output); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if len(output.Results) != 2 {
+		t.Errorf("Expected the top 2 results, got %d", len(output.Results))
+	}
+	report := output.Overfitting
+	if report.DeflatedSharpe.Trials != 6 || report.PBO.Combinations != 70 || len(report.Heatmap.Cells) != 2 {
+		t.Errorf("Unexpected overfitting report %+v", report)
+	}
+	if report.Selected["grid_levels"] != output.Results[0].Params["grid_levels"] {
+		t.Errorf("Selected %v, top result %v", report.Selected, output.Results[0].Params)
+	}
+	if !strings.Contains(errOut.String(), "Score by grid_levels (columns) and band (rows)") {
+		t.Errorf("Expected a heatmap on stderr, got %q", errOut.String())
+	}
+
+	if code := Run([]string{"optimize", "-synthetic", "sideways", "-overfit", "-blocks", "3"}); code != 2 {
+		t.Errorf("Run(optimize -blocks 3) = %d, want 2", code)
+	}
+}
+
+func TestRun_CheckData(t *testing.T) {
+	out, errOut := captureOutput(t)
+	candles, err := backtest.GenerateSynthetic(backtest.ScenarioConfig(backtest.SIDEWAYS_MARKET, 300, 42))
+	if err != nil {
+		t.Fatalf("GenerateSynthetic() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "gappy.csv")
+	gappy := append(append([]backtest.Candle(nil), candles[:100]...), candles[105:]...)
+	if err := backtest.WriteCandles(path, gappy); err != nil {
+		t.Fatalf("WriteCandles() error = %v", err)
+	}
+
+	if code := Run([]string{"check-data", "-data", path, "-format", "json", "-strict"}); code != 1 {
+		t.Fatalf("Run(check-data -strict) = %d, want 1", code)
+	}
+	var report backtest.QualityReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if report.Candles != 295 || len(report.Gaps) != 1 || report.Gaps[0].Missing != 5 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	oldFetch := fetchCandles
+	t.Cleanup(func() { fetchCandles = oldFetch })
+	fetchCandles = func(ctx context.Context, symbol, interval string, start, end time.Time) ([]backtest.Candle, error) {
+		if interval != "1h" {
+			t.Errorf("Expected 1h klines, got %s", interval)
+		}
+		return candles, nil
+	}
+
+	out.Reset()
+	errOut.Reset()
+	if code := Run([]string{"backtest", "-data", path, "-repair-gaps"}); code != 0 {
+		t.Fatalf("Run(backtest -repair-gaps) = %d, want 0: %s", code, errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "1 gap (5 missing bars)") || !strings.Contains(errOut.String(), "Repaired 5 of 5 missing bars") {
+		t.Errorf("Expected a gap warning and repair, got %q", errOut.String())
+	}
+}
+
+func TestRun_Init(t *testing.T) {
+	out, errOut := captureOutput(t)
+	dir := t.TempDir()
+
+	presets, err := config.Presets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presets) == 0 {
+		t.Fatal("expected built-in presets")
+	}
+	// Every preset writes a config that loads and builds its strategy
+	for _, p := range presets {
+		path := filepath.Join(dir, p.Name+".json")
+		if code := Run([]string{"init", "-preset", p.Name, "-symbol", "ETHUSDT", "-price", "2000", "-out", path}); code != 0 {
+			t.Fatalf("Run(init -preset %s) = %d: %s", p.Name, code, errOut.String())
+		}
+		cfg, err := config.Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", p.Name, err)
+		}
+		switch p.Strategy {
+		case "dca":
+			if cfg.Strategy.DCA == nil || cfg.Strategy.DCA.Symbol != "ETHUSDT" {
+				t.Errorf("%s: unexpected DCA config %+v", p.Name, cfg.Strategy.DCA)
+			}
+		case "grid":
+			grid := cfg.Strategy.Grid
+			if grid == nil || grid.Symbol != "ETHUSDT" || grid.LowerPrice != 2000*(1-p.Band) || grid.UpperPrice != 2000*(1+p.Band) {
+				t.Errorf("%s: unexpected grid config %+v", p.Name, grid)
+			}
+		}
+	}
+
+	// An existing config is kept unless forced
+	path := filepath.Join(dir, "dca-balanced.json")
+	if code := Run([]string{"init", "-preset", "dca-balanced", "-out", path}); code != 1 || !strings.Contains(errOut.String(), "already exists") {
+		t.Errorf("Run(init) over an existing file = %d, %q", code, errOut.String())
+	}
+	if code := Run([]string{"init", "-preset", "dca-balanced", "-out", path, "-force"}); code != 0 {
+		t.Errorf("Run(init -force) = %d", code)
+	}
+
+	// Grid bounds default to the last Binance price
+	oldFetch := fetchCandles
+	t.Cleanup(func() { fetchCandles = oldFetch })
+	fetchCandles = func(ctx context.Context, symbol, interval string, start, end time.Time) ([]backtest.Candle, error) {
+		return []backtest.Candle{{Time: end, Close: 60000}}, nil
+	}
+	out.Reset()
+	path = filepath.Join(dir, "grid.json")
+	if code := Run([]string{"init", "-preset", "grid-tight", "-out", path}); code != 0 {
+		t.Fatalf("Run(init -preset grid-tight) = %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "from 57000 to 63000 around 60000") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	out.Reset()
+	if code := Run([]string{"init", "-list"}); code != 0 || !strings.Contains(out.String(), "grid-wide") {
+		t.Errorf("Run(init -list) = %d, %q", code, out.String())
+	}
+	if code := Run([]string{"init", "-preset", "unknown", "-out", filepath.Join(dir, "x.json")}); code != 1 {
+		t.Errorf("Run(init -preset unknown) = %d, want 1", code)
+	}
+}
+
+func TestRun_Dashboard(t *testing.T) {
+	out, _ := captureOutput(t)
+	if code := Run([]string{"dashboard", "-title", "Desk"}); code != 0 {
+		t.Fatalf("Run(dashboard) = %d, want 0", code)
+	}
+	var dashboard map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &dashboard); err != nil {
+		t.Fatalf("Expected dashboard JSON: %v", err)
+	}
+	if dashboard["title"] != "Desk" {
+		t.Errorf("Expected title Desk, got %v", dashboard["title"])
+	}
+
+	out.Reset()
+	if code := Run([]string{"dashboard", "-list"}); code != 0 {
+		t.Fatalf("Run(dashboard -list) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "trader_portfolio_equity gauge {bot,exchange}") {
+		t.Errorf("Expected metric list, got %q", out.String())
+	}
+}
+
+func TestRun_Shadow(t *testing.T) {
+	dir := t.TempDir()
+	store, err := app.NewStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.Save("dca-shadow-shadow", map[string]interface{}{"bot": "dca", "symbol": "BTCUSDT", "started": started, "days": 7}); err != nil {
+		t.Fatal(err)
+	}
+	at := started.Add(time.Hour)
+	for _, e := range []struct {
+		bot    string
+		shadow bool
+		price  float64
+	}{{"dca", false, 100}, {"dca-shadow", true, 90}} {
+		execution := analytics.Execution{Time: at, Bot: e.bot, Symbol: "BTCUSDT", Side: "BUY", Type: "MARKET", Quantity: 1, ExpectedPrice: e.price, FilledPrice: e.price}
+		if err := store.Append("orders", app.JournalEntry{Time: at, Bot: e.bot, Action: "fill", Shadow: e.shadow, Execution: &execution}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, _ := captureOutput(t)
+	if code := Run([]string{"shadow", "-state-dir", dir, "-id", "dca-shadow", "-price", "110"}); code != 0 {
+		t.Fatalf("Run(shadow) = %d, want 0", code)
+	}
+	var report app.ShadowReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected report JSON: %v", err)
+	}
+	if report.Live.PnL != 10 || report.Variant.PnL != 20 || report.PnLDelta != 10 {
+		t.Errorf("Expected PnL 10 live, 20 shadow, got %+v", report)
+	}
+
+	if code := Run([]string{"shadow", "-state-dir", dir}); code != 2 {
+		t.Errorf("Run(shadow) without -id = %d, want 2", code)
+	}
+	if code := Run([]string{"shadow", "-state-dir", dir, "-id", "grid-shadow"}); code != 1 {
+		t.Errorf("Run(shadow) of an unknown shadow = %d, want 1", code)
+	}
+}
+
+func TestRun_SlippageModel(t *testing.T) {
+	dir := t.TempDir()
+	store, err := app.NewStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+	for _, filled := range []float64{100.1, 100.2, 100.3} {
+		execution := analytics.Execution{Time: at, Bot: "dca", Symbol: "BTCUSDT", Side: "BUY", Type: "MARKET", Quantity: 1, ExpectedPrice: 100, FilledPrice: filled}
+		if err := store.Append("orders", app.JournalEntry{Time: at, Bot: "dca", Action: "fill", Execution: &execution}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, _ := captureOutput(t)
+	modelPath := filepath.Join(dir, "slippage.json")
+	if code := Run([]string{"slippage", "-state-dir", dir, "-model-out", modelPath, "-min-fills", "3"}); code != 0 {
+		t.Fatalf("Run(slippage) = %d, want 0", code)
+	}
+	var report analytics.SlippageReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected report JSON: %v", err)
+	}
+	if report.Overall.Count != 3 {
+		t.Errorf("Expected 3 fills, got %d", report.Overall.Count)
+	}
+
+	model, err := backtest.LoadSlippageModel(modelPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bps := model.Hourly[14]; bps < 19 || bps > 21 {
+		t.Errorf("Expected ~20 bps at 14h, got %v", bps)
+	}
+
+	// The model feeds straight into a backtest
+	out.Reset()
+	if code := Run([]string{"backtest", "-synthetic", "sideways", "-bars", "200", "-slippage", modelPath}); code != 0 {
+		t.Fatalf("Run(backtest -slippage) = %d, want 0", code)
+	}
+}
+
+func TestRun_Observe(t *testing.T) {
+	dir := t.TempDir()
+	store, err := app.NewStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().Add(-time.Minute)
+	snapshot := app.BotSnapshot{
+		Bot:       "grid",
+		UpdatedAt: at,
+		Metrics:   types.StrategyMetrics{TotalTrades: 3, WinningTrades: 2, WinRate: 66.7},
+		Status: &types.StrategyStatus{
+			Type:    "grid",
+			Symbol:  "BTCUSDT",
+			Enabled: true,
+			Grid: &types.GridStatus{
+				Levels:     10,
+				LevelsHeld: 1,
+				OpenLevels: []types.GridLevel{{Level: 41000, Quantity: 0.002, AvgPrice: 40990}},
+			},
+		},
+	}
+	if err := store.Save("grid-state", snapshot); err != nil {
+		t.Fatal(err)
+	}
+	for i, equity := range []float64{10000, 10050, 10120} {
+		if err := store.Append("equity", analytics.EquityPoint{Time: at.Add(time.Duration(i) * time.Hour), Equity: equity}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeLimit, Quantity: 0.002, Price: 41000}
+	for _, entry := range []app.JournalEntry{
+		{Time: at, Bot: "grid", Action: "submit", Order: &order, ClientOrderID: "c1"},
+		{Time: at, Bot: "grid", Action: "place", ClientOrderID: "c1"},
+		{Time: at, Bot: "other", Action: "submit", Order: &order, ClientOrderID: "c2"},
+	} {
+		if err := store.Append("orders", entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, _ := captureOutput(t)
+	if code := Run([]string{"observe", "-state-dir", dir, "-once"}); code != 0 {
+		t.Fatalf("Run(observe -once) = %d, want 0", code)
+	}
+	for _, want := range []string{"Bot grid", "Equity  10120.00  (+1.20% over 3 snapshots)", "Status  grid  BTCUSDT  1/10 levels held", "Open levels (1)", "41000.00", "placed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if code := Run([]string{"observe", "-state-dir", dir, "-bot", "grid", "-json"}); code != 0 {
+		t.Fatalf("Run(observe -json) = %d, want 0", code)
+	}
+	var obs app.Observation
+	if err := json.Unmarshal(out.Bytes(), &obs); err != nil {
+		t.Fatalf("Expected observation JSON: %v", err)
+	}
+	if len(obs.Orders) != 1 || obs.Orders[0].Status != "placed" || len(obs.Equity) != 3 {
+		t.Errorf("observation = %+v", obs)
+	}
+
+	if code := Run([]string{"observe", "-state-dir", dir, "-bot", "missing", "-once"}); code != 1 {
+		t.Errorf("Run(observe) of a missing bot = %d, want 1", code)
+	}
+}
+
+func TestRun_Capacity(t *testing.T) {
+	oldExchange := marketExchange
+	t.Cleanup(func() { marketExchange = oldExchange })
+	marketExchange = func() (types.ExchangeClient, error) { return mock.NewMockClient(), nil }
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := `{
+  "app": {"name": "capacity"},
+  "exchange": {"name": "binance", "api_key": "key", "secret_key": "secret"},
+  "strategy": {
+    "dca": {"symbol": "BTCUSDT", "investment_amount": 100, "interval": "1h", "max_investments": 10, "enabled": true},
+    "combo": {"strategies": [{"type": "grid", "config": {"symbol": "BTCUSDT", "upper_price": 50000, "lower_price": 40000, "grid_levels": 10, "investment_per_level": 1000}}], "enabled": true}
+  }
+}`
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, errOut := captureOutput(t)
+	if code := Run([]string{"capacity", "-config", path, "-edge-bps", "1"}); code != 0 {
+		t.Fatalf("Run(capacity) = %d: %s", code, errOut.String())
+	}
+	var reports []analytics.CapacityReport
+	if err := json.Unmarshal(out.Bytes(), &reports); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if len(reports) != 2 || reports[0].Strategy != "dca" || reports[1].Strategy != "grid_0" {
+		t.Fatalf("reports = %+v, want the DCA bot and the combo's grid", reports)
+	}
+	// 24 buys a day may trade 1% of the mock's 45M daily volume
+	if dca := reports[0]; dca.Capital != 1000 || dca.Limit != analytics.LimitVolume || math.Abs(dca.Capacity-187500) > 1e-6 {
+		t.Errorf("DCA report = %+v, want 187500 limited by volume", dca)
+	}
+	if grid := reports[1]; grid.VolumeCapacity != 0 || grid.Capital != 10000 {
+		t.Errorf("grid report = %+v, want no volume limit without -orders-per-day", grid)
+	}
+
+	if code := Run([]string{"capacity", "-config", path, "-max-participation", "2"}); code != 2 {
+		t.Errorf("Run(capacity -max-participation 2) = %d, want 2", code)
+	}
+}
+
+func TestRun_TUI(t *testing.T) {
+	var canceled, paused int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/strategy/status", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(types.StrategyStatus{Type: "grid", Symbol: "BTCUSDT", Enabled: true, Grid: &types.GridStatus{
+			Levels: 3, LevelsHeld: 1, Prices: []float64{100, 110, 120},
+			OpenLevels: []types.GridLevel{{Level: 100, Quantity: 0.5, AvgPrice: 99.5}},
+			LevelStats: []types.GridLevelStats{{Level: 100, Buys: 3, Sells: 2, RoundTrips: 2, Profit: 9.5}},
+		}})
+	})
+	mux.HandleFunc("GET /v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"strategy": types.StrategyMetrics{TotalTrades: 4, TotalProfit: 12}})
+	})
+	mux.HandleFunc("GET /v1/exchange/status", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"available": paused == 0, "reason": "paused by operator: tui"})
+	})
+	mux.HandleFunc("GET /v1/ticker", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(types.Ticker{Symbol: r.URL.Query().Get("symbol"), Price: 105})
+	})
+	mux.HandleFunc("GET /v1/orders", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]types.Order{{ID: "7", Symbol: "BTCUSDT", Side: types.OrderSideSell, Type: types.OrderTypeLimit, Quantity: 0.5, Price: 110}})
+	})
+	mux.HandleFunc("DELETE /v1/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		canceled++
+	})
+	mux.HandleFunc("POST /v1/trading/pause", func(w http.ResponseWriter, r *http.Request) {
+		paused++
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	out, errOut := captureOutput(t)
+	if code := Run([]string{"tui", "-api", srv.URL, "-token", "secret", "-once"}); code != 0 {
+		t.Fatalf("Run(tui) = %d: %s", code, errOut.String())
+	}
+	frame := out.String()
+	for _, want := range []string{"price 105.00  RUNNING", "PnL     +12.00", "▶  105.00", "●  100.00", "○  110.00", "+9.50", "Open orders (1)"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("frame lacks %q:\n%s", want, frame)
+		}
+	}
+	// The price line sits between the levels around it
+	if strings.Index(frame, "110.00") > strings.Index(frame, "▶") || strings.Index(frame, "▶") > strings.Index(frame, "●") {
+		t.Errorf("price line out of place:\n%s", frame)
+	}
+
+	bot := &botAPI{base: srv.URL, token: "secret", client: srv.Client()}
+	ctx := context.Background()
+	if msg := bot.hotkey(ctx, 'p', bot.frame(ctx)); msg != "trading paused" || paused != 1 {
+		t.Errorf("pause hotkey = %q, %d calls", msg, paused)
+	}
+	if frame := bot.frame(ctx); bot.hotkey(ctx, 'c', frame) != "canceled 1 open order(s)" || canceled != 1 {
+		t.Errorf("cancel hotkey canceled %d orders", canceled)
+	}
+	out.Reset()
+	renderTUI(out, bot.frame(ctx), time.Now())
+	if !strings.Contains(out.String(), "PAUSED (paused by operator: tui)") {
+		t.Errorf("frame after pause:\n%s", out.String())
+	}
+
+	// The program refreshes on its own and runs hotkeys from key presses
+	model := &tuiModel{ctx: ctx, bot: bot, interval: time.Hour}
+	if _, cmd := model.Update(model.Init()()); cmd == nil || !strings.Contains(model.View(), "Open orders (1)") {
+		t.Errorf("first frame scheduled no refresh or lacks the orders:\n%s", model.View())
+	}
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if model.Update(cmd()); paused != 2 || !strings.Contains(model.View(), "trading paused") {
+		t.Errorf("p key paused %d times, view:\n%s", paused, model.View())
+	}
+	if _, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}}); cmd == nil || cmd() != tea.Quit() {
+		t.Error("q key does not quit")
+	}
+
+	// Without a token the hotkeys are refused
+	anonymous := &botAPI{base: srv.URL, client: srv.Client()}
+	if msg := anonymous.hotkey(ctx, 'c', anonymous.frame(ctx)); msg != "hotkeys need -token" {
+		t.Errorf("anonymous hotkey = %q", msg)
+	}
+}
+
+func TestRun_Statement(t *testing.T) {
+	dir := t.TempDir()
+	store, err := app.NewStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	for _, e := range []analytics.Execution{
+		{Time: day(1), Bot: "dca", Symbol: "BTCUSDT", Side: "BUY", Quantity: 2, FilledPrice: 100, Fee: 1},
+		{Time: day(10), Bot: "dca", Symbol: "BTCUSDT", Side: "SELL", Quantity: 1, FilledPrice: 130, Fee: 1},
+		{Time: day(20), Bot: "dca", Symbol: "BTCUSDT", Side: "BUY", Quantity: 1, FilledPrice: 150, Fee: 1},
+	} {
+		if err := store.Append("orders", app.JournalEntry{Time: e.Time, Bot: e.Bot, Action: "fill", Execution: &e}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldFetch := fetchCandles
+	t.Cleanup(func() { fetchCandles = oldFetch })
+	fetchCandles = func(ctx context.Context, symbol, interval string, start, end time.Time) ([]backtest.Candle, error) {
+		// 120 before the 15th, 160 after
+		price := 120.0
+		if end.After(day(15)) {
+			price = 160
+		}
+		return []backtest.Candle{{Time: end.Add(-time.Hour), Close: price}, {Time: end, Close: 0}}, nil
+	}
+
+	out, errOut := captureOutput(t)
+	if code := Run([]string{"statement", "-state-dir", dir, "-from", "2024-01-05", "-to", "2024-02-01", "-format", "json"}); code != 0 {
+		t.Fatalf("Run(statement) = %d: %s", code, errOut.String())
+	}
+	var ps app.PeriodStatement
+	if err := json.Unmarshal(out.Bytes(), &ps); err != nil {
+		t.Fatalf("Expected statement JSON: %v", err)
+	}
+	// Opening: 2 @ 100 worth 240. Closing: 2 @ 125 worth 320, 30 realized.
+	if ps.Opening == nil || ps.Opening.Value != 240 || ps.Closing.Value != 320 || ps.Closing.Positions[0].AvgPrice != 125 {
+		t.Fatalf("statement = %+v", ps)
+	}
+	if ps.RealizedPnL != 30 || ps.Fees != 2 || ps.Fills != 2 || ps.PnL != 30+70-40-2 {
+		t.Errorf("period realized %v, fees %v, fills %d, pnl %v", ps.RealizedPnL, ps.Fees, ps.Fills, ps.PnL)
+	}
+
+	out.Reset()
+	if code := Run([]string{"statement", "-state-dir", dir, "-to", "2024-02-01"}); code != 0 || !strings.Contains(out.String(), "PnL:            97.00 over 3 fill(s)") {
+		t.Errorf("Run(statement) text = %d, %q", code, out.String())
+	}
+	if code := Run([]string{"statement", "-state-dir", dir, "-from", "2024-02-01", "-to", "2024-01-01"}); code != 2 {
+		t.Errorf("Run(statement) with -from after -to = %d, want 2", code)
+	}
+}
+
+func TestRun_Benchcmp(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	baseline := write("baseline.txt", `goos: linux
+pkg: github.com/Zmey56/crypto-arbitrage-trader/internal/backtest
+BenchmarkEngine_BacktestGrid-8   	      10	 100000000 ns/op	  5000 B/op	     50 allocs/op
+BenchmarkEngine_BacktestGrid-8   	      10	 120000000 ns/op	  5000 B/op	     50 allocs/op
+BenchmarkEngine_BacktestGrid-8   	      10	 110000000 ns/op	  5000 B/op	     50 allocs/op
+pkg: github.com/Zmey56/crypto-arbitrage-trader/pkg/indicators
+BenchmarkSMA-8   	    5000	    200000 ns/op
+PASS
+`)
+	faster := write("faster.txt", `pkg: github.com/Zmey56/crypto-arbitrage-trader/internal/backtest
+BenchmarkEngine_BacktestGrid-4   	      10	 105000000 ns/op	  5000 B/op	     50 allocs/op
+pkg: github.com/Zmey56/crypto-arbitrage-trader/pkg/indicators
+BenchmarkSMA-4   	    5000	    150000 ns/op
+BenchmarkEMA-4   	    5000	    150000 ns/op
+`)
+	slower := write("slower.txt", `pkg: github.com/Zmey56/crypto-arbitrage-trader/internal/backtest
+BenchmarkEngine_BacktestGrid   	      10	 108000000 ns/op	  5000 B/op	     80 allocs/op
+pkg: github.com/Zmey56/crypto-arbitrage-trader/pkg/indicators
+BenchmarkSMA   	    5000	    300000 ns/op
+`)
+
+	out, errOut := captureOutput(t)
+	if code := Run([]string{"benchcmp", "-baseline", baseline, faster}); code != 0 {
+		t.Fatalf("Run(benchcmp) = %d: %s", code, errOut.String())
+	}
+	for _, want := range []string{"backtest.BenchmarkEngine_BacktestGrid", "-4.5%", "indicators.BenchmarkSMA", "-25.0%", "new"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("comparison lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	// The grid allocates 60% more and SMA takes 50% longer
+	out.Reset()
+	errOut.Reset()
+	if code := Run([]string{"benchcmp", "-baseline", baseline, slower}); code != 1 {
+		t.Fatalf("Run(benchcmp) on regressions = %d, want 1", code)
+	}
+	if strings.Count(out.String(), "REGRESSION") != 2 || !strings.Contains(errOut.String(), "2 benchmark(s) regressed beyond 30%") {
+		t.Errorf("unexpected regressions:\n%s%s", out.String(), errOut.String())
+	}
+	if code := Run([]string{"benchcmp", "-baseline", baseline, "-threshold", "0.7", slower}); code != 0 {
+		t.Errorf("Run(benchcmp -threshold 0.7) = %d, want 0", code)
+	}
+}
+
+// restingExchange keeps limit orders open until canceled
+type restingExchange struct {
+	*mock.MockClient
+	open     []types.Order
+	canceled []string
+}
+
+func (e *restingExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	order.ID = strconv.Itoa(len(e.open) + 1)
+	e.open = append(e.open, order)
+	return nil
+}
+
+func (e *restingExchange) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return e.open, nil
+}
+
+func (e *restingExchange) CancelOrder(ctx context.Context, orderID string) error {
+	e.canceled = append(e.canceled, orderID)
+	return nil
+}
+
+func TestRun_BootstrapTestnet(t *testing.T) {
+	exchange := &restingExchange{MockClient: mock.NewMockClient()}
+	oldExchange := sandboxExchange
+	t.Cleanup(func() { sandboxExchange = oldExchange })
+	sandboxExchange = func(apiKey, secretKey string) (types.ExchangeClient, error) { return exchange, nil }
+
+	out, errOut := captureOutput(t)
+	t.Setenv("EXCHANGE_API_KEY", "")
+	if code := Run([]string{"bootstrap-testnet"}); code != 2 {
+		t.Errorf("Run(bootstrap-testnet) without keys = %d, want 2", code)
+	}
+
+	t.Setenv("EXCHANGE_API_KEY", "key")
+	t.Setenv("EXCHANGE_SECRET_KEY", "secret")
+	if code := Run([]string{"bootstrap-testnet", "-test-order"}); code != 0 {
+		t.Fatalf("Run(bootstrap-testnet) = %d: %s", code, errOut.String())
+	}
+	for _, want := range []string{"api keys", "placed and canceled", "USDT", "Ready: BTCUSDT"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, out.String())
+		}
+	}
+	// A buy at half the 44999 bid for about 10 USDT
+	if len(exchange.open) != 1 || exchange.open[0].Price != 22499.5 || len(exchange.canceled) != 1 {
+		t.Fatalf("Expected one test order at 22499.5, canceled; got %+v, canceled %v", exchange.open, exchange.canceled)
+	}
+
+	// Without quote balance the testnet is not ready
+	out.Reset()
+	exchange.MockClient = mock.NewMockClient()
+	_ = exchange.MockClient.PlaceOrder(context.Background(), types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1, Price: 10000})
+	if code := Run([]string{"bootstrap-testnet", "-format", "json", "-symbol", "BTCUSDT"}); code != 1 {
+		t.Fatalf("Run(bootstrap-testnet) without USDT = %d, want 1", code)
+	}
+	var report readinessReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if report.Ready || report.Checks[len(report.Checks)-2].Name != "balances" || report.Checks[len(report.Checks)-2].Status != checkFail {
+		t.Errorf("Expected a failed balance check, got %+v", report.Checks)
+	}
+}
+
+func TestRun_SentimentBackfill(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "news.csv")
+	if err := os.WriteFile(csvPath, []byte("timestamp,symbol,score\n2024-01-01T00:00:00Z,BTCUSDT,-0.6\n2024-01-01T05:00:00Z,BTCUSDT,-0.7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stateDir := filepath.Join(dir, "state")
+
+	out, errOut := captureOutput(t)
+	if code := Run([]string{"sentiment-backfill", "-in", csvPath, "-state-dir", stateDir}); code != 0 {
+		t.Fatalf("Run(sentiment-backfill) = %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "BTCUSDT: 2 point(s)") {
+		t.Errorf("output = %q", out.String())
+	}
+	store, err := app.NewStateStore(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if points, err := app.ReadSentiment(store); err != nil || len(points) != 2 {
+		t.Fatalf("ReadSentiment() = %d points, %v", len(points), err)
+	}
+
+	// A filter vetoing buys without positive sentiment keeps the grid out
+	filterPath := filepath.Join(dir, "bullish.star")
+	if err := os.WriteFile(filterPath, []byte("def filter(signal, market):\n    return signal.side == \"SELL\" or (market.sentiment != None and market.sentiment > 0)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	args := []string{"backtest", "-synthetic", "sideways", "-bars", "300", "-grid-lower", "40000", "-grid-upper", "50000", "-sentiment", stateDir, "-filter", filterPath}
+	if code := Run(args); code != 0 {
+		t.Fatalf("Run(backtest) = %d: %s", code, errOut.String())
+	}
+	var cmp backtest.StrategyComparison
+	if err := json.Unmarshal(out.Bytes(), &cmp); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if cmp.GridResults.TradeCount != 0 {
+		t.Errorf("grid trades = %d, want buys vetoed on bearish sentiment", cmp.GridResults.TradeCount)
+	}
+
+	if code := Run([]string{"sentiment-backfill", "-state-dir", stateDir}); code != 2 {
+		t.Errorf("Run(sentiment-backfill) without -in = %d, want 2", code)
+	}
+	if code := Run([]string{"backtest", "-synthetic", "sideways", "-sentiment", filepath.Join(dir, "missing")}); code != 1 {
+		t.Errorf("Run(backtest) with a missing sentiment dir = %d, want 1", code)
+	}
+}
+
+func TestRun_Demo(t *testing.T) {
+	out, errOut := captureOutput(t)
+	args := []string{"demo", "-bars", "300", "-duration", "1500ms", "-interval", "500ms", "-log-level", "error"}
+	if code := Run(args); code != 0 {
+		t.Fatalf("Run(demo) = %d: %s", code, errOut.String())
+	}
+	for _, want := range []string{"== Backtest: DCA vs Grid on 300 synthetic sideways bars", "== Paper trading ", "Backtest winner: ", "Paper orders: "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Paper orders: 0 buy(s)") {
+		t.Errorf("paper session placed no buys:\n%s", out.String())
+	}
+
+	if code := Run([]string{"demo", "-duration", "0s"}); code != 2 {
+		t.Errorf("Run(demo -duration 0s) = %d, want 2", code)
+	}
+}
+
+func TestRun_ConfigMigrate(t *testing.T) {
+	dir := t.TempDir()
+	stateDir := filepath.Join(dir, "state")
+	path := filepath.Join(dir, "dca.json")
+	v1 := `{
+  "app": {"name": "dca-bot", "version": "1.0.0", "reporting_currency": "USD", "state_dir": "` + stateDir + `"},
+  "exchange": {"name": "binance", "api_key": "key", "secret_key": "secret", "sandbox": true},
+  "strategy": {"dca": {"symbol": "BTCUSDT", "investment_amount": 100, "interval": 86400000000000, "max_investments": 10, "enabled": true}},
+  "logging": {"level": "info", "format": "text"}
+}
+`
+	if err := os.WriteFile(path, []byte(v1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := app.NewStateStore(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("dca-state", app.BotSnapshot{Bot: "dca"}); err != nil {
+		t.Fatal(err)
+	}
+	// Written before journal entries named their bot
+	if err := store.Append("orders", map[string]string{"action": "place", "order_id": "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loading upgrades in memory
+	cfg, err := app.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.MigratedFrom != 1 || cfg.Strategy.DCA.Interval != 24*time.Hour {
+		t.Errorf("LoadConfig() = migrated from %d, interval %v", cfg.MigratedFrom, cfg.Strategy.DCA.Interval)
+	}
+
+	out, errOut := captureOutput(t)
+	if code := Run([]string{"config", "migrate", "-config", path, "-dry-run"}); code != 0 {
+		t.Fatalf("Run(config migrate -dry-run) = %d: %s", code, errOut.String())
+	}
+	for _, want := range []string{"schema v1 -> v2", `v2: strategy.dca.interval: 86400000000000 -> "24h0m0s"`, "attributed 1 journal record(s) without a bot to dca", "Dry run"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dry run output missing %q:\n%s", want, out.String())
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != v1 {
+		t.Errorf("dry run rewrote the config:\n%s", data)
+	}
+
+	out.Reset()
+	if code := Run([]string{"config", "migrate", "-config", path}); code != 0 {
+		t.Fatalf("Run(config migrate) = %d: %s", code, errOut.String())
+	}
+	if data, _ := os.ReadFile(path + ".v1.bak"); string(data) != v1 {
+		t.Errorf("backup = %q", data)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"schema_version": 2`) || !strings.Contains(string(data), `"interval": "24h0m0s"`) {
+		t.Errorf("migrated config:\n%s", data)
+	}
+	var bots []string
+	if err := store.Scan("orders", func(line []byte) error {
+		var entry app.JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		bots = append(bots, entry.Bot)
+		return nil
+	}); err != nil || len(bots) != 1 || bots[0] != "dca" {
+		t.Errorf("journal bots = %v, %v", bots, err)
+	}
+
+	out.Reset()
+	if code := Run([]string{"config", "migrate", "-config", path}); code != 0 {
+		t.Fatalf("second Run(config migrate) = %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "schema v2, up to date") || !strings.Contains(out.String(), "state schema v2, up to date") {
+		t.Errorf("second run output = %q", out.String())
+	}
+
+	// Files from a newer build are refused rather than misread
+	newer := filepath.Join(dir, "newer.json")
+	if err := os.WriteFile(newer, []byte(`{"schema_version": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := Run([]string{"config", "migrate", "-config", newer}); code != 1 {
+		t.Errorf("Run(config migrate newer) = %d, want 1", code)
+	}
+	if code := Run([]string{"config"}); code != 2 {
+		t.Errorf("Run(config) = %d, want 2", code)
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/fleet"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+)
+
+var collectorCommand = &Command{
+	Name:    "collector",
+	Summary: "Run the fleet collector that aggregates metrics pushed by bot instances",
+	Run:     runCollector,
+}
+
+func runCollector(args []string) error {
+	fs := newFlagSet("collector")
+	addr := fs.String("addr", ":9100", "Listen address")
+	stale := fs.Duration("stale", 5*time.Minute, "Flag instances silent for this long and drop them from totals")
+	token := fs.String("token", os.Getenv("COLLECTOR_TOKEN"), "Bearer token required to push reports")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *stale <= 0 {
+		return usageError(fs, "-stale must be positive")
+	}
+
+	log := logger.New(logger.LevelInfo)
+	srv := &http.Server{Addr: *addr, Handler: fleet.NewCollector(*stale).Handler(*token)}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("Fleet collector listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Info("Fleet collector stopped")
+	return nil
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+)
+
+var configCommand = &Command{
+	Name:    "config",
+	Summary: "Upgrade config files and state dirs written by older versions",
+	Run:     runConfig,
+}
+
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "migrate" {
+		fmt.Fprintln(stderr, "usage: trader config migrate [flags]")
+		return errUsage
+	}
+
+	fs := newFlagSet("config migrate")
+	configFile := fs.String("config", "", "Config file to upgrade in place, keeping the original as <file>.v<N>.bak")
+	stateDir := fs.String("state-dir", os.Getenv("STATE_DIR"), "State dir to upgrade (default $STATE_DIR, else the config's app.state_dir)")
+	dryRun := fs.Bool("dry-run", false, "Print the changes without writing anything")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if *configFile == "" && *stateDir == "" {
+		return usageError(fs, "-config or -state-dir is required")
+	}
+
+	if *configFile != "" {
+		cfg, err := migrateConfigFile(*configFile, *dryRun)
+		if err != nil {
+			return err
+		}
+		if *stateDir == "" {
+			*stateDir = cfg.App.StateDir
+		}
+	}
+	if *stateDir != "" {
+		if err := migrateStateDir(*stateDir, *dryRun); err != nil {
+			return err
+		}
+	}
+	if *dryRun {
+		fmt.Fprintln(stdout, "Dry run: nothing written")
+	}
+	return nil
+}
+
+// migrateConfigFile upgrades path to the current schema once the upgraded
+// file loads, and returns it
+func migrateConfigFile(path string, dryRun bool) (*config.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	migrated, err := config.Migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var cfg config.Config
+	if err := json.Unmarshal(migrated.Data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: upgraded config does not decode: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: upgraded config is invalid: %w", path, err)
+	}
+	if migrated.From == config.SchemaVersion {
+		fmt.Fprintf(stdout, "%s: schema v%d, up to date\n", path, migrated.From)
+		return &cfg, nil
+	}
+
+	fmt.Fprintf(stdout, "%s: schema v%d -> v%d\n", path, migrated.From, config.SchemaVersion)
+	printChanges(migrated.Changes)
+	if dryRun {
+		return &cfg, nil
+	}
+	backup := fmt.Sprintf("%s.v%d.bak", path, migrated.From)
+	if err := os.WriteFile(backup, data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to back up config: %w", err)
+	}
+	if err := os.WriteFile(path, migrated.Data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+	fmt.Fprintf(stdout, "  original kept as %s\n", backup)
+	return &cfg, nil
+}
+
+// migrateStateDir upgrades the state dir a bot persists its strategy state in
+func migrateStateDir(dir string, dryRun bool) error {
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("state dir %s: %w", dir, err)
+	}
+	store, err := app.NewStateStore(dir)
+	if err != nil {
+		return err
+	}
+	from, changes, err := app.MigrateState(store, dryRun)
+	if err != nil {
+		return err
+	}
+	if from == app.StateSchemaVersion {
+		fmt.Fprintf(stdout, "%s: state schema v%d, up to date\n", dir, from)
+		return nil
+	}
+	fmt.Fprintf(stdout, "%s: state schema v%d -> v%d\n", dir, from, app.StateSchemaVersion)
+	printChanges(changes)
+	return nil
+}
+
+// printChanges lists migration changes under their file
+func printChanges(changes []string) {
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "  no values changed")
+	}
+	for _, change := range changes {
+		fmt.Fprintln(stdout, "  "+strings.TrimSpace(change))
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/metrics"
+)
+
+var dashboardCommand = &Command{
+	Name:    "dashboard",
+	Summary: "Generate a Grafana dashboard for the metrics bots export",
+	Run:     runDashboard,
+}
+
+func runDashboard(args []string) error {
+	fs := newFlagSet("dashboard")
+	out := fs.String("out", "", "Write the dashboard JSON to this file (default: stdout)")
+	title := fs.String("title", "", "Dashboard title (default \"Crypto Trading Bots\")")
+	uid := fs.String("uid", "", "Dashboard UID (default \"crypto-trader\")")
+	list := fs.Bool("list", false, "List the exported metrics instead")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	descs := metrics.NewStandardRegistry().Descs()
+	if *list {
+		for _, d := range descs {
+			fmt.Fprintf(stdout, "%s %s {%s}\n", d.Name, d.Type, strings.Join(d.Labels, ","))
+		}
+		return nil
+	}
+
+	data, err := metrics.Dashboard(descs, metrics.DashboardOptions{Title: *title, UID: *uid})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write dashboard: %w", err)
+	}
+	fmt.Fprintf(stderr, "Wrote dashboard with %d metrics to %s\n", len(descs), *out)
+	return nil
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/binance"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/experiments"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// dataFlags selects the candle source shared by backtest, optimize and report
+type dataFlags struct {
+	data       *string
+	symbol     *string
+	start      *string
+	end        *string
+	initial    *float64
+	fee        *float64
+	makerFee   *float64
+	feesFrom   *bool
+	synthetic  *string
+	bars       *int
+	seed       *int64
+	fixtureOut *string
+	resample   *time.Duration
+	repairGaps *bool
+	slippage   *string
+	base       *bool
+
+	sentiment       *string
+	sentimentWindow *time.Duration
+}
+
+func addDataFlags(fs *flag.FlagSet) *dataFlags {
+	return &dataFlags{
+		data:       fs.String("data", "", "Path to .csv or .parquet candles (timestamp,open,high,low,close,volume)"),
+		symbol:     fs.String("symbol", "BTCUSDT", "Symbol"),
+		start:      fs.String("start", "", "Start (RFC3339)"),
+		end:        fs.String("end", "", "End (RFC3339)"),
+		initial:    fs.Float64("initial", 10000, "Initial balance"),
+		fee:        fs.Float64("fee", 0.001, "Taker fee rate"),
+		makerFee:   fs.Float64("maker-fee", -1, "Maker fee rate for limit fills (default -fee)"),
+		feesFrom:   fs.Bool("exchange-fees", false, "Use the -symbol maker/taker fees Binance reports instead of -fee and -maker-fee"),
+		base:       fs.Bool("base-metrics", false, "Also report returns, drawdown and Sharpe in the base asset of -symbol, to compare with holding it"),
+		synthetic:  fs.String("synthetic", "", "Generate synthetic data instead of -data (bull, bear, sideways, high_vol)"),
+		bars:       fs.Int("bars", 24*90, "Number of synthetic hourly bars"),
+		seed:       fs.Int64("seed", 42, "Synthetic data random seed"),
+		fixtureOut: fs.String("fixture-out", "", "Write synthetic candles to this .csv or .parquet path"),
+		resample:   fs.Duration("resample", 0, "Downsample -data to bars of this length while loading (e.g. 1h)"),
+		repairGaps: fs.Bool("repair-gaps", false, "Fill gaps in -data with candles fetched from Binance before running"),
+		slippage:   fs.String("slippage", "", "Market fill slippage: basis points (e.g. 5) or a model file written by trader slippage"),
+
+		sentiment:       fs.String("sentiment", "", "State dir whose recorded or backfilled sentiment -filter scripts see as market.sentiment"),
+		sentimentWindow: fs.Duration("sentiment-window", 24*time.Hour, "Span market.sentiment_momentum is measured over"),
+	}
+}
+
+// engine creates a backtest engine with the fee, -sentiment and -slippage flags
+func (d *dataFlags) engine() (*backtest.Engine, error) {
+	eng := backtest.NewEngine(*d.fee)
+	eng.SetBaseMetrics(*d.base)
+	if *d.sentiment != "" {
+		history, err := loadSentiment(*d.sentiment)
+		if err != nil {
+			return nil, err
+		}
+		eng.SetSentiment(history, *d.sentimentWindow)
+	}
+	if *d.makerFee >= 0 {
+		eng.SetFees(*d.makerFee, *d.fee)
+	}
+	if *d.feesFrom {
+		client, err := feeExchange()
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+		if err := eng.LoadFees(context.Background(), client, *d.symbol); err != nil {
+			return nil, err
+		}
+	}
+	if *d.slippage == "" {
+		return eng, nil
+	}
+	if bps, err := strconv.ParseFloat(*d.slippage, 64); err == nil {
+		eng.SetSlippage(&backtest.SlippageModel{Bps: bps})
+		return eng, nil
+	}
+	model, err := backtest.LoadSlippageModel(*d.slippage)
+	if err != nil {
+		return nil, err
+	}
+	eng.SetSlippage(model)
+	return eng, nil
+}
+
+// feeExchange creates the client -exchange-fees reads; swapped in tests
+var feeExchange = func() (types.ExchangeClient, error) {
+	return binance.NewClient(binance.ExchangeConfig{
+		RateLimit: binance.RateLimitConfig{RequestsPerSecond: 10, Burst: 10},
+	})
+}
+
+// validate checks that a candle source was selected
+func (d *dataFlags) validate(fs *flag.FlagSet) error {
+	if *d.data == "" && *d.synthetic == "" {
+		return usageError(fs, "either -data or -synthetic is required")
+	}
+	return nil
+}
+
+// load returns candles and the [start, end] range, defaulting to the candle range
+func (d *dataFlags) load(eng *backtest.Engine) ([]backtest.Candle, time.Time, time.Time, error) {
+	var (
+		candles []backtest.Candle
+		err     error
+	)
+	if *d.synthetic != "" {
+		var condition backtest.MarketCondition
+		condition, err = backtest.ParseMarketCondition(*d.synthetic)
+		if err == nil {
+			candles, err = backtest.GenerateSynthetic(backtest.ScenarioConfig(condition, *d.bars, *d.seed))
+		}
+		if err == nil && *d.fixtureOut != "" {
+			err = backtest.WriteCandles(*d.fixtureOut, candles)
+		}
+	} else {
+		candles, err = eng.LoadCandles(*d.data, *d.resample)
+		if err == nil {
+			candles, err = checkCandles(d, candles)
+		}
+	}
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+
+	startT, endT := candles[0].Time, candles[len(candles)-1].Time
+	if *d.start != "" {
+		if startT, err = time.Parse(time.RFC3339, *d.start); err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("invalid -start: %w", err)
+		}
+	}
+	if *d.end != "" {
+		if endT, err = time.Parse(time.RFC3339, *d.end); err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("invalid -end: %w", err)
+		}
+	}
+	return candles, startT, endT, nil
+}
+
+// strategyFlags holds DCA, Grid and optional breakout parameters for backtests
+type strategyFlags struct {
+	dcaInterval    *string
+	dcaAmount      *float64
+	dcaMax         *int
+	gridLower      *float64
+	gridUpper      *float64
+	gridLevels     *int
+	gridInvest     *float64
+	breakout       *bool
+	breakoutEntry  *int
+	breakoutExit   *int
+	breakoutATR    *float64
+	breakoutAmount *float64
+	filter         *string
+}
+
+func addStrategyFlags(fs *flag.FlagSet) *strategyFlags {
+	return &strategyFlags{
+		dcaInterval: fs.String("dca-interval", "24h", "DCA interval"),
+		dcaAmount:   fs.Float64("dca-amount", 100, "DCA investment amount"),
+		dcaMax:      fs.Int("dca-max", 100, "DCA max investments"),
+		gridLower:   fs.Float64("grid-lower", 30000, "Grid lower bound"),
+		gridUpper:   fs.Float64("grid-upper", 60000, "Grid upper bound"),
+		gridLevels:  fs.Int("grid-levels", 20, "Grid levels"),
+		gridInvest:  fs.Float64("grid-invest", 100, "Grid investment per level"),
+
+		breakout:       fs.Bool("breakout", false, "Also backtest a channel breakout"),
+		breakoutEntry:  fs.Int("breakout-entry", 20, "Breakout entry channel in bars"),
+		breakoutExit:   fs.Int("breakout-exit", 10, "Breakout exit channel in bars"),
+		breakoutATR:    fs.Float64("breakout-atr", 0, "Breakout trailing stop in ATRs (0 disables)"),
+		breakoutAmount: fs.Float64("breakout-amount", 1000, "Breakout investment per entry"),
+		filter:         fs.String("filter", "", "Starlark signal filter (.star) applied to the grid and breakout"),
+	}
+}
+
+func (s *strategyFlags) dcaConfig(symbol string) (types.DCAConfig, error) {
+	d, err := time.ParseDuration(*s.dcaInterval)
+	if err != nil {
+		return types.DCAConfig{}, fmt.Errorf("invalid -dca-interval: %w", err)
+	}
+	return types.DCAConfig{Symbol: symbol, InvestmentAmount: *s.dcaAmount, Interval: d, MaxInvestments: *s.dcaMax, Enabled: true}, nil
+}
+
+func (s *strategyFlags) gridConfig(symbol string) types.GridConfig {
+	return types.GridConfig{Symbol: symbol, UpperPrice: *s.gridUpper, LowerPrice: *s.gridLower, GridLevels: *s.gridLevels, InvestmentPerLevel: *s.gridInvest, Enabled: true, Filter: s.filterConfig()}
+}
+
+// filterConfig returns the -filter script, or nil without one
+func (s *strategyFlags) filterConfig() *types.SignalFilterConfig {
+	if *s.filter == "" {
+		return nil
+	}
+	return &types.SignalFilterConfig{File: *s.filter}
+}
+
+// breakoutConfig returns the breakout flags' config, or nil without -breakout
+func (s *strategyFlags) breakoutConfig(symbol string) *types.BreakoutConfig {
+	if !*s.breakout {
+		return nil
+	}
+	return &types.BreakoutConfig{Symbol: symbol, InvestmentAmount: *s.breakoutAmount, EntryPeriod: *s.breakoutEntry, ExitPeriod: *s.breakoutExit, ATRMultiplier: *s.breakoutATR, Enabled: true, Filter: s.filterConfig()}
+}
+
+// compare runs the DCA vs Grid comparison for the parsed flags, with a
+// breakout when requested
+func compare(d *dataFlags, s *strategyFlags) (*backtest.StrategyComparison, runWindow, error) {
+	dcaCfg, err := s.dcaConfig(*d.symbol)
+	if err != nil {
+		return nil, runWindow{}, err
+	}
+
+	eng, err := d.engine()
+	if err != nil {
+		return nil, runWindow{}, err
+	}
+	candles, startT, endT, err := d.load(eng)
+	if err != nil {
+		return nil, runWindow{}, err
+	}
+
+	window := runWindow{start: startT, end: endT, dataset: experiments.HashCandles(candles, startT, endT)}
+	cmp, err := eng.CompareStrategies(*d.symbol, candles, startT, endT, *d.initial, dcaCfg, s.gridConfig(*d.symbol))
+	if err != nil {
+		return nil, window, err
+	}
+	if breakoutCfg := s.breakoutConfig(*d.symbol); breakoutCfg != nil {
+		breakout, err := eng.BacktestBreakout(*d.symbol, candles, startT, endT, *breakoutCfg, *d.initial)
+		if err != nil {
+			return nil, window, err
+		}
+		cmp.BreakoutResults = &breakout
+	}
+	return cmp, window, nil
+}
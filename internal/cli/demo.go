@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var demoCommand = &Command{
+	Name:    "demo",
+	Summary: "Backtest DCA vs Grid on synthetic data, then paper trade the winner for a few minutes",
+	Run:     runDemo,
+}
+
+func runDemo(args []string) error {
+	fs := newFlagSet("demo")
+	data := addDataFlags(fs)
+	strategies := addStrategyFlags(fs)
+	duration := fs.Duration("duration", 5*time.Minute, "Length of the paper trading session")
+	interval := fs.Duration("interval", 5*time.Second, "Paper trading loop interval")
+	port := fs.Int("port", 0, "Serve the bot's HTTP API on this port during the session (0 disables)")
+	logLevel := fs.String("log-level", "info", "Paper session log level")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *duration <= 0 || *interval <= 0 {
+		return usageError(fs, "-duration and -interval must be positive")
+	}
+	if *data.data == "" && *data.synthetic == "" {
+		*data.synthetic = string(backtest.SIDEWAYS_MARKET)
+	}
+
+	// Step 1 and 2: generate or load candles and backtest both strategies
+	source := *data.data
+	if source == "" {
+		source = fmt.Sprintf("%d synthetic %s bars (seed %d)", *data.bars, *data.synthetic, *data.seed)
+	}
+	fmt.Fprintf(stdout, "== Backtest: DCA vs Grid on %s ==\n", source)
+	cmp, window, err := compare(data, strategies)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Period: %s to %s\n", window.start.Format(time.RFC3339), window.end.Format(time.RFC3339))
+	fmt.Fprintf(stdout, "%-6s %10s %10s %8s %8s %10s\n", "", "return %", "drawdown %", "sharpe", "trades", "fees")
+	for _, row := range []struct {
+		name string
+		m    backtest.PerformanceMetrics
+	}{{"DCA", cmp.DCAResults}, {"Grid", cmp.GridResults}} {
+		fmt.Fprintf(stdout, "%-6s %10.2f %10.2f %8.2f %8d %10.2f\n", row.name, row.m.TotalReturn, row.m.MaxDrawdown, row.m.SharpeRatio, row.m.TradeCount, row.m.TotalFees)
+	}
+
+	// Step 3: paper trade the winner on the mock exchange
+	cfg := &config.Config{
+		App:      config.AppConfig{Name: "trader-demo", Version: "demo", Port: *port, ReportingCurrency: "USD", SkipPreflight: true},
+		Exchange: config.ExchangeConfig{Name: "paper"},
+		Logging:  config.LoggingConfig{Level: *logLevel},
+	}
+	winner := "Grid"
+	if cmp.DCAResults.TotalReturn >= cmp.GridResults.TotalReturn {
+		winner = "DCA"
+	}
+	var build func(c *app.Container) (strategy.Strategy, error)
+	if winner == "DCA" {
+		dcaCfg, err := strategies.dcaConfig(*data.symbol)
+		if err != nil {
+			return err
+		}
+		cfg.Strategy.DCA = &dcaCfg
+		build = func(c *app.Container) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateDCA(dcaCfg, c.Exchange())
+		}
+	} else {
+		gridCfg := strategies.gridConfig(*data.symbol)
+		cfg.Strategy.Grid = &gridCfg
+		build = func(c *app.Container) (strategy.Strategy, error) {
+			return c.StrategyFactory().CreateGrid(gridCfg, c.Exchange())
+		}
+	}
+	fmt.Fprintf(stdout, "\n== Paper trading %s on %s for %s ==\n", winner, *data.symbol, *duration)
+
+	c, err := app.NewContainer(cfg)
+	if err != nil {
+		return err
+	}
+	var strat strategy.Strategy
+	spec := app.BotSpec{
+		ID:           "demo",
+		Name:         "Demo " + winner + " Bot",
+		Icon:         "🎬",
+		Symbol:       *data.symbol,
+		LoopInterval: *interval,
+		PriceSwing:   500,
+		Build: func(c *app.Container) (strategy.Strategy, error) {
+			s, err := build(c)
+			strat = s
+			return s, err
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	if err := app.RunBotContext(ctx, c, spec); err != nil {
+		return err
+	}
+
+	// Step 4: summarize the session
+	orders, err := c.Exchange().GetFilledOrders(context.Background(), *data.symbol)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "\n== Summary ==\n")
+	fmt.Fprintf(stdout, "Backtest winner: %s (%.2f%% vs %.2f%%)\n", winner, max(cmp.DCAResults.TotalReturn, cmp.GridResults.TotalReturn), min(cmp.DCAResults.TotalReturn, cmp.GridResults.TotalReturn))
+	printPaperOrders(orders)
+	if strat != nil {
+		m := strat.GetMetrics()
+		fmt.Fprintf(stdout, "Strategy: %d trade(s), win rate %.1f%%, net P&L %.2f\n", m.TotalTrades, m.WinRate, m.TotalProfit-m.TotalLoss)
+	}
+	fmt.Fprintln(stdout, "\nNext: trader init -list for presets, trader backtest -data to test on real candles.")
+	return nil
+}
+
+// printPaperOrders summarizes the orders the paper exchange filled
+func printPaperOrders(orders []types.Order) {
+	var buys, sells int
+	var bought, sold float64
+	for _, o := range orders {
+		if o.Side == types.OrderSideBuy {
+			buys++
+			bought += o.FilledAmount * o.FilledPrice
+		} else {
+			sells++
+			sold += o.FilledAmount * o.FilledPrice
+		}
+	}
+	fmt.Fprintf(stdout, "Paper orders: %d buy(s) for %.2f, %d sell(s) for %.2f\n", buys, bought, sells, sold)
+}
@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/experiments"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var experimentsCommand = &Command{
+	Name:    "experiments",
+	Summary: "List, compare and baseline recorded backtest runs",
+	Run:     runExperiments,
+}
+
+// addExperimentsFlag adds -experiments; backtest and optimize record their
+// runs there, defaulting to $EXPERIMENTS_DB
+func addExperimentsFlag(fs *flag.FlagSet) *string {
+	return fs.String("experiments", os.Getenv("EXPERIMENTS_DB"), "Record runs in this SQLite experiments db (default $EXPERIMENTS_DB)")
+}
+
+// runWindow is the tested range and dataset hash of a backtest
+type runWindow struct {
+	start, end time.Time
+	dataset    string
+}
+
+// recordRuns stamps runs with one batch id and the git revision and stores them
+func recordRuns(path, command string, window runWindow, d *dataFlags, runs []experiments.Run) error {
+	store, err := experiments.Open(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	now := time.Now()
+	batch := command + "-" + now.UTC().Format("20060102T150405.000")
+	revision := experiments.GitRevision()
+	for i := range runs {
+		runs[i].CreatedAt, runs[i].Batch, runs[i].Command = now, batch, command
+		runs[i].Symbol, runs[i].GitRevision, runs[i].DatasetHash = *d.symbol, revision, window.dataset
+		runs[i].Start, runs[i].End = window.start, window.end
+		runs[i].Initial, runs[i].FeeRate = *d.initial, *d.fee
+	}
+
+	ids, err := store.Record(context.Background(), runs...)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		fmt.Fprintf(stderr, "%s: recorded runs %d-%d (batch %s) in %s\n", command, ids[0], ids[len(ids)-1], batch, path)
+	}
+	return nil
+}
+
+// dcaParams are the recorded parameters of a DCA run
+func dcaParams(cfg types.DCAConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"interval":          cfg.Interval.String(),
+		"investment_amount": cfg.InvestmentAmount,
+		"max_investments":   cfg.MaxInvestments,
+	}
+}
+
+// gridParams are the recorded parameters of a Grid run
+func gridParams(cfg types.GridConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"grid_levels":          cfg.GridLevels,
+		"lower_price":          cfg.LowerPrice,
+		"upper_price":          cfg.UpperPrice,
+		"investment_per_level": cfg.InvestmentPerLevel,
+	}
+}
+
+func runExperiments(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(stderr, "usage: trader experiments <list|show|compare|baseline> [flags] [ids]")
+		return errUsage
+	}
+	action, args := args[0], args[1:]
+
+	fs := newFlagSet("experiments " + action)
+	db := fs.String("db", envOr("EXPERIMENTS_DB", "experiments.db"), "Experiments db")
+	strategyName := fs.String("strategy", "", "Only runs of this strategy")
+	symbol := fs.String("symbol", "", "Only runs on this symbol")
+	batch := fs.String("batch", "", "Only runs of this batch")
+	baselines := fs.Bool("baselines", false, "Only baselines")
+	limit := fs.Int("limit", 20, "Maximum runs to list (0 for all)")
+	format := fs.String("format", "text", "Output format (text, json)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return usageError(fs, fmt.Sprintf("unknown format %q", *format))
+	}
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return usageError(fs, err.Error())
+	}
+
+	if _, err := os.Stat(*db); err != nil {
+		return fmt.Errorf("experiments db %s: %w", *db, err)
+	}
+	store, err := experiments.Open(*db)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	var runs []experiments.Run
+	switch action {
+	case "list":
+		runs, err = store.List(ctx, experiments.Filter{Strategy: *strategyName, Symbol: *symbol, Batch: *batch, Baseline: *baselines, Limit: *limit})
+		if err != nil {
+			return err
+		}
+	case "show", "compare":
+		if len(ids) == 0 || (action == "show" && len(ids) != 1) {
+			return usageError(fs, action+" needs run ids")
+		}
+		for _, id := range ids {
+			run, err := store.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, *run)
+		}
+		if action == "compare" {
+			return writeComparison(ctx, stdout, store, runs, *format)
+		}
+	case "baseline":
+		if len(ids) != 1 {
+			return usageError(fs, "baseline needs one run id")
+		}
+		if err := store.MarkBaseline(ctx, ids[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Run %d is now the baseline\n", ids[0])
+		return nil
+	default:
+		return usageError(fs, fmt.Sprintf("unknown action %q", action))
+	}
+
+	if *format == "json" || action == "show" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if action == "show" {
+			return enc.Encode(runs[0])
+		}
+		return enc.Encode(runs)
+	}
+	return writeRuns(stdout, runs)
+}
+
+// writeRuns renders runs as an aligned table
+func writeRuns(w io.Writer, runs []experiments.Run) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCreated\tCommand\tStrategy\tSymbol\tReturn %\tSharpe\tMax DD %\tTrades\tRevision\tDataset\tParams\t")
+	for _, r := range runs {
+		id := strconv.FormatInt(r.ID, 10)
+		if r.Baseline {
+			id += "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.2f\t%.2f\t%.2f\t%d\t%s\t%s\t%s\t\n",
+			id, r.CreatedAt.Local().Format("2006-01-02 15:04"), r.Command, r.Strategy, r.Symbol,
+			r.Metrics.TotalReturn, r.Metrics.SharpeRatio, r.Metrics.MaxDrawdown, r.Metrics.TradeCount,
+			r.GitRevision, shortHash(r.DatasetHash), formatParams(r.Params))
+	}
+	return tw.Flush()
+}
+
+// writeComparison shows runs side by side with deltas against the baseline
+// of the first run's strategy and symbol, or against the first run
+func writeComparison(ctx context.Context, w io.Writer, store *experiments.Store, runs []experiments.Run, format string) error {
+	ref, err := store.Baseline(ctx, runs[0].Strategy, runs[0].Symbol)
+	if err != nil {
+		return err
+	}
+	if ref == nil {
+		ref = &runs[0]
+	}
+
+	type delta struct {
+		experiments.Run
+		DeltaReturn   float64 `json:"delta_return"`
+		DeltaSharpe   float64 `json:"delta_sharpe"`
+		DeltaDrawdown float64 `json:"delta_max_drawdown"`
+		SameDataset   bool    `json:"same_dataset"`
+	}
+	rows := make([]delta, len(runs))
+	for i, r := range runs {
+		rows[i] = delta{
+			Run:           r,
+			DeltaReturn:   r.Metrics.TotalReturn - ref.Metrics.TotalReturn,
+			DeltaSharpe:   r.Metrics.SharpeRatio - ref.Metrics.SharpeRatio,
+			DeltaDrawdown: r.Metrics.MaxDrawdown - ref.Metrics.MaxDrawdown,
+			SameDataset:   r.DatasetHash == ref.DatasetHash,
+		}
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{"reference": ref.ID, "runs": rows})
+	}
+
+	label := "run"
+	if ref.Baseline {
+		label = "baseline"
+	}
+	fmt.Fprintf(w, "Reference: %s %d (%s %s)\n\n", label, ref.ID, ref.Strategy, ref.Symbol)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tStrategy\tReturn %\tΔ Return\tSharpe\tΔ Sharpe\tMax DD %\tΔ Max DD\tSame data\tParams\t")
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%d\t%s\t%.2f\t%+.2f\t%.2f\t%+.2f\t%.2f\t%+.2f\t%t\t%s\t\n",
+			r.ID, r.Strategy, r.Metrics.TotalReturn, r.DeltaReturn, r.Metrics.SharpeRatio, r.DeltaSharpe,
+			r.Metrics.MaxDrawdown, r.DeltaDrawdown, r.SameDataset, formatParams(r.Params))
+	}
+	return tw.Flush()
+}
+
+// formatParams renders params as sorted key=value pairs
+func formatParams(params map[string]interface{}) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, params[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+func parseIDs(args []string) ([]int64, error) {
+	ids := make([]int64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid run id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+)
+
+var fetchDataCommand = &Command{
+	Name:    "fetch-data",
+	Summary: "Download historical candles from Binance into a backtest CSV",
+	Run:     runFetchData,
+}
+
+func runFetchData(args []string) error {
+	fs := newFlagSet("fetch-data")
+	symbol := fs.String("symbol", "BTCUSDT", "Symbol")
+	interval := fs.String("interval", "1h", "Kline interval (1m, 5m, 1h, 4h, 1d, ...)")
+	start := fs.String("start", "", "Start (RFC3339, default: -days before end)")
+	end := fs.String("end", "", "End (RFC3339, default: now)")
+	days := fs.Int("days", 90, "History length when -start is not set")
+	out := fs.String("out", "", "Output path (.csv or .parquet)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return usageError(fs, "-out is required")
+	}
+
+	endT := time.Now().UTC()
+	if *end != "" {
+		t, err := time.Parse(time.RFC3339, *end)
+		if err != nil {
+			return fmt.Errorf("invalid -end: %w", err)
+		}
+		endT = t
+	}
+	startT := endT.AddDate(0, 0, -*days)
+	if *start != "" {
+		t, err := time.Parse(time.RFC3339, *start)
+		if err != nil {
+			return fmt.Errorf("invalid -start: %w", err)
+		}
+		startT = t
+	}
+	if !startT.Before(endT) {
+		return usageError(fs, "start must be before end")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	candles, err := fetchCandles(ctx, *symbol, *interval, startT, endT)
+	if err != nil {
+		return err
+	}
+	if len(candles) == 0 {
+		return fmt.Errorf("no candles returned for %s %s", *symbol, *interval)
+	}
+	if err := backtest.WriteCandles(*out, candles); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Wrote %d %s %s candles to %s\n", len(candles), *symbol, *interval, *out)
+	return nil
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+)
+
+var initCommand = &Command{
+	Name:    "init",
+	Summary: "Write a ready-to-edit bot config from a built-in preset",
+	Run:     runInit,
+}
+
+func runInit(args []string) error {
+	fs := newFlagSet("init")
+	preset := fs.String("preset", "", "Preset to start from (see -list)")
+	symbol := fs.String("symbol", "", "Trading pair (default: the preset's first pair)")
+	price := fs.Float64("price", 0, "Price to center grid bounds on (default: the last Binance price)")
+	out := fs.String("out", "config.json", "Config file to write")
+	force := fs.Bool("force", false, "Overwrite an existing config file")
+	list := fs.Bool("list", false, "List the presets instead")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *list {
+		return listPresets()
+	}
+	if *preset == "" {
+		return usageError(fs, "-preset is required; -list shows the presets")
+	}
+	if *price < 0 {
+		return usageError(fs, "-price must not be negative")
+	}
+
+	p, err := config.LookupPreset(*preset)
+	if err != nil {
+		return err
+	}
+	if *symbol == "" {
+		*symbol = p.Symbols[0]
+	}
+	if p.Strategy == "grid" && *price == 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		if *price, err = lastPrice(ctx, *symbol); err != nil {
+			return fmt.Errorf("%w; pass -price to set it", err)
+		}
+	}
+
+	data, cfg, err := p.Render(*symbol, *price)
+	if err != nil {
+		return err
+	}
+	if err := validatePreset(p, cfg); err != nil {
+		return err
+	}
+
+	if !*force {
+		if _, err := os.Stat(*out); err == nil {
+			return fmt.Errorf("%s already exists; pass -force to overwrite it", *out)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.WriteFile(*out, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	fmt.Fprintf(stdout, "Wrote %s config for %s to %s\n", p.Name, *symbol, *out)
+	if grid := cfg.Strategy.Grid; grid != nil {
+		fmt.Fprintf(stdout, "Grid of %d levels from %g to %g around %g\n", grid.GridLevels, grid.LowerPrice, grid.UpperPrice, *price)
+	}
+	fmt.Fprintf(stdout, "Set exchange.api_key and exchange.secret_key, then run: trader %s -config %s\n", p.Strategy, *out)
+	return nil
+}
+
+// listPresets prints the built-in presets
+func listPresets() error {
+	presets, err := config.Presets()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PRESET\tSTRATEGY\tPAIRS\tDESCRIPTION")
+	for _, p := range presets {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.Name, p.Strategy, strings.Join(p.Symbols, ","), p.Description)
+	}
+	return tw.Flush()
+}
+
+// validatePreset builds the preset's strategy to check its settings
+func validatePreset(p config.Preset, cfg *config.Config) error {
+	factory := strategy.NewFactory(logger.New(logger.LevelError))
+	var err error
+	switch {
+	case cfg.Strategy.DCA != nil:
+		_, err = factory.CreateDCA(*cfg.Strategy.DCA, nil)
+	case cfg.Strategy.Grid != nil:
+		_, err = factory.CreateGrid(*cfg.Strategy.Grid, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("preset %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// lastPrice returns the close of the last minute candle of symbol on Binance
+func lastPrice(ctx context.Context, symbol string) (float64, error) {
+	end := time.Now().UTC()
+	candles, err := fetchCandles(ctx, symbol, "1m", end.Add(-10*time.Minute), end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the price of %s: %w", symbol, err)
+	}
+	if len(candles) == 0 {
+		return 0, fmt.Errorf("no recent candles for %s", symbol)
+	}
+	return candles[len(candles)-1].Close, nil
+}
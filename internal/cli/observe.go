@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var observeCommand = &Command{
+	Name:    "observe",
+	Summary: "Watch a running bot's equity, open levels and recent orders read-only",
+	Run:     runObserve,
+}
+
+func runObserve(args []string) error {
+	fs := newFlagSet("observe")
+	stateDir := fs.String("state-dir", os.Getenv("STATE_DIR"), "State dir of the bot to watch (default $STATE_DIR)")
+	bot := fs.String("bot", "", "Bot id (default: the only bot with a snapshot in the state dir)")
+	api := fs.String("api", "", "Also read live risk state from the bot's HTTP API, e.g. http://localhost:8080")
+	interval := fs.Duration("interval", 2*time.Second, "Refresh interval")
+	rows := fs.Int("rows", 10, "Recent orders to show")
+	once := fs.Bool("once", false, "Print one frame and exit")
+	asJSON := fs.Bool("json", false, "Print one observation as JSON and exit")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *stateDir == "" {
+		return usageError(fs, "-state-dir is required")
+	}
+	if *interval <= 0 || *rows <= 0 {
+		return usageError(fs, "-interval and -rows must be positive")
+	}
+
+	observer, err := app.NewObserver(*stateDir, *bot)
+	if err != nil {
+		return err
+	}
+	if *api != "" {
+		observer.SetAPI(*api)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Enough equity points for the sparkline
+	limit := max(*rows, sparklineWidth)
+	if *once || *asJSON {
+		obs, err := observer.Observe(ctx, limit)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(obs)
+		}
+		renderObservation(stdout, obs, *rows, time.Now())
+		return nil
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		// Redraw in place: cursor home, clear screen
+		fmt.Fprint(stdout, "\x1b[H\x1b[2J")
+		if obs, err := observer.Observe(ctx, limit); err != nil {
+			fmt.Fprintf(stdout, "Observing %s: %v\n", observer.Bot(), err)
+		} else {
+			renderObservation(stdout, obs, *rows, time.Now())
+		}
+		fmt.Fprintf(stdout, "\nRefreshing every %s, Ctrl-C to quit\n", *interval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+const sparklineWidth = 40
+
+// renderObservation draws one frame of the observer
+func renderObservation(w io.Writer, obs *app.Observation, rows int, now time.Time) {
+	fmt.Fprintf(w, "Bot %s  snapshot %s ago (%s)\n", obs.Bot, now.Sub(obs.UpdatedAt).Round(time.Second), obs.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+	switch {
+	case obs.APIError != "":
+		fmt.Fprintf(w, "API unavailable: %s\n", obs.APIError)
+	case obs.Live != nil:
+		if risk := riskLine(obs.Live); risk != "" {
+			fmt.Fprintf(w, "Risk    %s\n", risk)
+		}
+	}
+	fmt.Fprintln(w)
+
+	if n := len(obs.Equity); n > 0 {
+		first, last := obs.Equity[0].Equity, obs.Equity[n-1].Equity
+		change := 0.0
+		if first > 0 {
+			change = (last/first - 1) * 100
+		}
+		fmt.Fprintf(w, "Equity  %.2f  (%+.2f%% over %d snapshots)  %s\n", last, change, n, sparkline(obs.Equity, sparklineWidth))
+	} else {
+		fmt.Fprintln(w, "Equity  no snapshots recorded")
+	}
+
+	m := obs.Metrics
+	fmt.Fprintf(w, "Trades  %d (won %d, %.1f%%)  pnl %+.2f  volume %.2f  max drawdown %.2f\n",
+		m.TotalTrades, m.WinningTrades, m.WinRate, m.TotalProfit-m.TotalLoss, m.TotalVolume, m.MaxDrawdown)
+	if status := statusLine(obs.Status); status != "" {
+		fmt.Fprintf(w, "Status  %s\n", status)
+	}
+
+	if obs.Status != nil && obs.Status.Grid != nil && len(obs.Status.Grid.OpenLevels) > 0 {
+		levels := obs.Status.Grid.OpenLevels
+		fmt.Fprintf(w, "\nOpen levels (%d)\n", len(levels))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "LEVEL\tQUANTITY\tAVG PRICE\t")
+		for _, level := range levels {
+			fmt.Fprintf(tw, "%.2f\t%.8f\t%.2f\t\n", level.Level, level.Quantity, level.AvgPrice)
+		}
+		_ = tw.Flush()
+	}
+
+	fmt.Fprintln(w, "\nRecent orders")
+	if len(obs.Orders) == 0 {
+		fmt.Fprintln(w, "  none journaled")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tACCOUNT\tSIDE\tSYMBOL\tQUANTITY\tPRICE\tSTATUS\tFILLED")
+	orders := obs.Orders
+	if len(orders) > rows {
+		orders = orders[len(orders)-rows:]
+	}
+	for i := len(orders) - 1; i >= 0; i-- {
+		o := orders[i]
+		account, filled := o.Account, ""
+		if account == "" {
+			account = "main"
+		}
+		if o.FilledPrice > 0 {
+			filled = fmt.Sprintf("%.2f", o.FilledPrice)
+		}
+		status := o.Status
+		if o.Error != "" {
+			status += ": " + o.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.8f\t%.2f\t%s\t%s\n",
+			o.Time.Local().Format("01-02 15:04:05"), account, o.Side, o.Symbol, o.Quantity, o.Price, status, filled)
+	}
+	_ = tw.Flush()
+}
+
+// statusLine summarizes a strategy status on one line
+func statusLine(status *types.StrategyStatus) string {
+	if status == nil {
+		return ""
+	}
+	fields := []string{status.Type}
+	if status.Symbol != "" {
+		fields = append(fields, status.Symbol)
+	}
+	if !status.Enabled {
+		fields = append(fields, "disabled")
+	}
+	switch {
+	case status.DCA != nil:
+		fields = append(fields, fmt.Sprintf("%s %d/%d orders", status.DCA.Mode, status.DCA.Orders, status.DCA.MaxOrders))
+		if !status.DCA.NextOrder.IsZero() {
+			fields = append(fields, "next "+status.DCA.NextOrder.Local().Format("01-02 15:04"))
+		}
+	case status.Grid != nil:
+		fields = append(fields, fmt.Sprintf("%d/%d levels held", status.Grid.LevelsHeld, status.Grid.Levels))
+	case status.Combo != nil:
+		fields = append(fields, fmt.Sprintf("%d strategies", len(status.Combo.Strategies)))
+	}
+	return strings.Join(fields, "  ")
+}
+
+// riskLine summarizes the guard, deleverager and calendar from GET /metrics
+func riskLine(live map[string]interface{}) string {
+	var parts []string
+	if guard, ok := live["guard"].(map[string]interface{}); ok {
+		if tripped, _ := guard["tripped"].(bool); tripped {
+			parts = append(parts, fmt.Sprintf("GUARD TRIPPED (%v)", guard["reason"]))
+		} else {
+			parts = append(parts, "guard ok")
+		}
+	}
+	if deleverage, ok := live["deleverage"].(map[string]interface{}); ok {
+		parts = append(parts, fmt.Sprintf("drawdown %.1f%% scale %.2f", toFloat(deleverage["drawdown"])*100, toFloat(deleverage["scale"])))
+	}
+	if cal, ok := live["calendar"].(map[string]interface{}); ok {
+		if active, _ := cal["active"].([]interface{}); len(active) > 0 {
+			parts = append(parts, fmt.Sprintf("%d calendar blackout(s)", len(active)))
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+func toFloat(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
+
+// sparkline draws the last width equity points as block characters
+func sparkline(points []analytics.EquityPoint, width int) string {
+	if len(points) > width {
+		points = points[len(points)-width:]
+	}
+	lo, hi := points[0].Equity, points[0].Equity
+	for _, p := range points {
+		lo, hi = min(lo, p.Equity), max(hi, p.Equity)
+	}
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	var b strings.Builder
+	for _, p := range points {
+		i := 0
+		if hi > lo {
+			i = int((p.Equity - lo) / (hi - lo) * float64(len(blocks)-1))
+		}
+		b.WriteRune(blocks[i])
+	}
+	return b.String()
+}
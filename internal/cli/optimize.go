@@ -0,0 +1,410 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/experiments"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var optimizeCommand = &Command{
+	Name:    "optimize",
+	Summary: "Sweep strategy parameters and rank backtest results",
+	Run:     runOptimize,
+}
+
+// OptimizeResult is one evaluated parameter set
+type OptimizeResult struct {
+	Params  map[string]interface{}      `json:"params"`
+	Metrics backtest.PerformanceMetrics `json:"metrics"`
+	Score   float64                     `json:"score"`
+}
+
+func runOptimize(args []string) error {
+	fs := newFlagSet("optimize")
+	data := addDataFlags(fs)
+	strategyName := fs.String("strategy", "grid", "Strategy to optimize (dca, grid)")
+	metric := fs.String("metric", "sharpe", "Objective to maximize (return, sharpe, drawdown, calmar, return_dd)")
+	method := fs.String("method", "grid", "Search method: grid (every combination) or bayes (TPE over the list ranges)")
+	trials := fs.Int("trials", 50, "Evaluations for -method bayes")
+	searchSeed := fs.Int64("search-seed", 1, "Random seed for -method bayes")
+	top := fs.Int("top", 10, "Number of results to print")
+	dcaIntervals := fs.String("dca-intervals", "12h,24h,72h,168h", "Comma-separated DCA intervals")
+	dcaAmounts := fs.String("dca-amounts", "50,100,200", "Comma-separated DCA investment amounts")
+	dcaMax := fs.Int("dca-max", 100, "DCA max investments")
+	gridLevels := fs.String("grid-levels", "5,10,20,40", "Comma-separated grid level counts")
+	gridBands := fs.String("grid-bands", "0.1,0.2,0.3", "Comma-separated grid half-widths as a fraction of the first price")
+	gridInvest := fs.Float64("grid-invest", 100, "Grid investment per level")
+	workers := fs.Int("workers", runtime.NumCPU(), "Parallel backtest workers")
+	progress := fs.Bool("progress", false, "Report sweep progress on stderr")
+	overfit := fs.Bool("overfit", false, "Add deflated Sharpe, PBO and a parameter heatmap (printed on stderr) to the output")
+	blocks := fs.Int("blocks", 16, "Time blocks for the -overfit PBO cross-validation (even)")
+	record := addExperimentsFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := data.validate(fs); err != nil {
+		return err
+	}
+
+	score, ok := scorers[*metric]
+	if !ok {
+		return usageError(fs, fmt.Sprintf("unknown metric %q", *metric))
+	}
+	if *method != "grid" && *method != "bayes" {
+		return usageError(fs, fmt.Sprintf("unknown method %q", *method))
+	}
+	if *blocks < 2 || *blocks%2 != 0 {
+		return usageError(fs, "-blocks must be even and at least 2")
+	}
+
+	eng, err := data.engine()
+	if err != nil {
+		return err
+	}
+	candles, startT, endT, err := data.load(eng)
+	if err != nil {
+		return err
+	}
+	symbol := *data.symbol
+
+	// Slice the date range once; every run shares the window
+	ds, err := backtest.NewDataset(candles)
+	if err != nil {
+		return err
+	}
+	window := ds.Between(startT, endT)
+
+	// dcaJob and gridJob build the backtest for one parameter point
+	var evaluated []*trialInfo // in job order
+	dcaJob := func(interval time.Duration, amount float64) backtest.SweepJob {
+		cfg := types.DCAConfig{Symbol: symbol, InvestmentAmount: amount, Interval: interval, MaxInvestments: *dcaMax, Enabled: true}
+		info := &trialInfo{params: dcaParams(cfg), coords: map[string]float64{"interval_hours": interval.Hours(), "investment_amount": amount}}
+		evaluated = append(evaluated, info)
+		return backtest.SweepJob{
+			Params: map[string]interface{}{"interval": interval.String(), "investment_amount": amount},
+			Run: func() backtest.PerformanceMetrics {
+				metrics, returns := eng.DCASeries(symbol, window, startT, endT, cfg, *data.initial)
+				if *overfit {
+					info.returns = returns
+				}
+				return metrics
+			},
+		}
+	}
+	center := candlesFirstClose(ds.Candles(), startT)
+	gridJob := func(n int, band float64) backtest.SweepJob {
+		cfg := types.GridConfig{
+			Symbol:             symbol,
+			LowerPrice:         center * (1 - band),
+			UpperPrice:         center * (1 + band),
+			GridLevels:         n,
+			InvestmentPerLevel: *gridInvest,
+			Enabled:            true,
+		}
+		info := &trialInfo{params: gridParams(cfg), coords: map[string]float64{"grid_levels": float64(n), "band": band}}
+		evaluated = append(evaluated, info)
+		return backtest.SweepJob{
+			Params: map[string]interface{}{"grid_levels": n, "lower_price": cfg.LowerPrice, "upper_price": cfg.UpperPrice},
+			Run: func() backtest.PerformanceMetrics {
+				metrics, returns := eng.GridSeries(symbol, window, startT, endT, cfg, *data.initial)
+				if *overfit {
+					info.returns = returns
+				}
+				return metrics
+			},
+		}
+	}
+
+	// Grid search evaluates every listed combination; bayes searches the
+	// range spanned by each list
+	var (
+		space []backtest.Param
+		jobs  []backtest.SweepJob
+		build func(x map[string]float64) backtest.SweepJob
+	)
+	switch *strategyName {
+	case "dca":
+		intervals, err := parseDurations(*dcaIntervals)
+		if err != nil || len(intervals) == 0 {
+			return fmt.Errorf("invalid -dca-intervals: %w", orEmpty(err))
+		}
+		amounts, err := parseFloats(*dcaAmounts)
+		if err != nil || len(amounts) == 0 {
+			return fmt.Errorf("invalid -dca-amounts: %w", orEmpty(err))
+		}
+		for _, interval := range intervals {
+			for _, amount := range amounts {
+				if *method == "grid" {
+					jobs = append(jobs, dcaJob(interval, amount))
+				}
+			}
+		}
+		hours := make([]float64, len(intervals))
+		for i, interval := range intervals {
+			hours[i] = interval.Hours()
+		}
+		space = []backtest.Param{
+			{Name: "interval_hours", Min: slices.Min(hours), Max: slices.Max(hours), Log: true},
+			{Name: "investment_amount", Min: slices.Min(amounts), Max: slices.Max(amounts)},
+		}
+		build = func(x map[string]float64) backtest.SweepJob {
+			interval := time.Duration(x["interval_hours"] * float64(time.Hour)).Round(time.Minute)
+			return dcaJob(interval, math.Round(x["investment_amount"]*100)/100)
+		}
+	case "grid":
+		levels, err := parseInts(*gridLevels)
+		if err != nil || len(levels) == 0 {
+			return fmt.Errorf("invalid -grid-levels: %w", orEmpty(err))
+		}
+		bands, err := parseFloats(*gridBands)
+		if err != nil || len(bands) == 0 {
+			return fmt.Errorf("invalid -grid-bands: %w", orEmpty(err))
+		}
+		for _, n := range levels {
+			for _, band := range bands {
+				if *method == "grid" {
+					jobs = append(jobs, gridJob(n, band))
+				}
+			}
+		}
+		space = []backtest.Param{
+			{Name: "grid_levels", Min: float64(slices.Min(levels)), Max: float64(slices.Max(levels)), Integer: true},
+			{Name: "band", Min: slices.Min(bands), Max: slices.Max(bands)},
+		}
+		build = func(x map[string]float64) backtest.SweepJob {
+			return gridJob(int(x["grid_levels"]), math.Round(x["band"]*10000)/10000)
+		}
+	default:
+		return usageError(fs, fmt.Sprintf("unknown strategy %q", *strategyName))
+	}
+
+	var reportProgress func(done, total int)
+	if *progress {
+		reportProgress = func(done, total int) { fmt.Fprintf(stderr, "optimize: %d/%d runs\n", done, total) }
+	}
+	var sweep []backtest.SweepResult
+	switch *method {
+	case "grid":
+		sweep, err = backtest.RunSweep(context.Background(), jobs, backtest.SweepOptions{Workers: *workers, Progress: reportProgress})
+	case "bayes":
+		sweep, err = backtest.RunTPE(context.Background(), space, build, score, backtest.TPEOptions{
+			Trials:   *trials,
+			Seed:     *searchSeed,
+			Workers:  *workers,
+			Progress: reportProgress,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	results := make([]OptimizeResult, len(sweep))
+	for i, r := range sweep {
+		results[i] = OptimizeResult{Params: r.Params, Metrics: r.Metrics, Score: score(r.Metrics)}
+	}
+
+	// Every evaluated run is recorded, not only the top results
+	if *record != "" {
+		runs := make([]experiments.Run, len(sweep))
+		for i, r := range results {
+			runs[i] = experiments.Run{Strategy: *strategyName, Params: evaluated[i].params, Metrics: r.Metrics, Score: r.Score}
+		}
+		w := runWindow{start: startT, end: endT, dataset: experiments.HashCandles(window, startT, endT)}
+		if err := recordRuns(*record, "optimize", w, data, runs); err != nil {
+			return err
+		}
+	}
+
+	var report *OverfitReport
+	if *overfit {
+		axes := [2]string{"interval_hours", "investment_amount"}
+		if *strategyName == "grid" {
+			axes = [2]string{"grid_levels", "band"}
+		}
+		if report, err = overfitReport(results, evaluated, axes, *blocks); err != nil {
+			return err
+		}
+		writeHeatmap(stderr, report.Heatmap)
+	}
+
+	rankResults(results)
+	if *top > 0 && len(results) > *top {
+		results = results[:*top]
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if report != nil {
+		return enc.Encode(map[string]interface{}{"results": results, "overfitting": report})
+	}
+	return enc.Encode(results)
+}
+
+// trialInfo is what optimize keeps about each evaluated job
+type trialInfo struct {
+	params  map[string]interface{} // full parameters, as recorded in experiments
+	coords  map[string]float64     // position in the search space
+	returns []float64              // per-bar returns, kept for -overfit
+}
+
+// OverfitReport holds the overfitting diagnostics of a search
+type OverfitReport struct {
+	Selected       map[string]interface{}  `json:"selected"` // params of the top-ranked trial
+	DeflatedSharpe backtest.DeflatedSharpe `json:"deflated_sharpe"`
+	PBO            backtest.PBO            `json:"pbo"`
+	Heatmap        backtest.Heatmap        `json:"heatmap"`
+}
+
+// overfitReport computes the diagnostics for results in job order
+func overfitReport(results []OptimizeResult, evaluated []*trialInfo, axes [2]string, blocks int) (*OverfitReport, error) {
+	selected := 0
+	returns := make([][]float64, len(evaluated))
+	coords := make([]map[string]float64, len(evaluated))
+	scores := make([]float64, len(results))
+	bars := 0
+	for _, info := range evaluated {
+		bars = max(bars, len(info.returns))
+	}
+	for i, info := range evaluated {
+		// Trials that never traded (e.g. fewer than 2 grid levels) earn nothing
+		returns[i] = info.returns
+		if len(returns[i]) < bars {
+			returns[i] = make([]float64, bars)
+		}
+		coords[i], scores[i] = info.coords, results[i].Score
+		if results[i].Score > results[selected].Score {
+			selected = i
+		}
+	}
+
+	pbo, err := backtest.ComputePBO(returns, blocks)
+	if err != nil {
+		return nil, fmt.Errorf("pbo: %w", err)
+	}
+	return &OverfitReport{
+		Selected:       results[selected].Params,
+		DeflatedSharpe: backtest.ComputeDeflatedSharpe(returns, selected),
+		PBO:            pbo,
+		Heatmap:        backtest.StabilityHeatmap(axes[0], axes[1], coords, scores, 8),
+	}, nil
+}
+
+// writeHeatmap prints the score surface with the best cell starred
+func writeHeatmap(w io.Writer, h backtest.Heatmap) {
+	fmt.Fprintf(w, "Score by %s (columns) and %s (rows):\n", h.X, h.Y)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "\t")
+	for _, x := range h.XTicks {
+		fmt.Fprintf(tw, "%.4g\t", x)
+	}
+	fmt.Fprintln(tw)
+	for row, y := range h.YTicks {
+		fmt.Fprintf(tw, "%.4g\t", y)
+		for _, cell := range h.Cells[row] {
+			switch {
+			case cell == nil:
+				fmt.Fprint(tw, "-\t")
+			case *cell == h.Best:
+				fmt.Fprintf(tw, "*%.4g\t", *cell)
+			default:
+				fmt.Fprintf(tw, "%.4g\t", *cell)
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "Best %.4g, neighbours %.4g\n", h.Best, h.NeighborMean)
+}
+
+// scorers map a ranking metric to a higher-is-better score
+var scorers = map[string]func(backtest.PerformanceMetrics) float64{
+	"return":   func(m backtest.PerformanceMetrics) float64 { return m.TotalReturn },
+	"sharpe":   func(m backtest.PerformanceMetrics) float64 { return m.SharpeRatio },
+	"drawdown": func(m backtest.PerformanceMetrics) float64 { return -m.MaxDrawdown },
+	// Drawdown is floored at 0.01% so a flat equity curve does not divide by zero
+	"calmar":    func(m backtest.PerformanceMetrics) float64 { return m.AnnualizedReturn / math.Max(m.MaxDrawdown, 0.01) },
+	"return_dd": func(m backtest.PerformanceMetrics) float64 { return m.TotalReturn / math.Max(m.MaxDrawdown, 0.01) },
+}
+
+// orEmpty describes a list flag that failed to parse or was empty
+func orEmpty(err error) error {
+	if err != nil {
+		return err
+	}
+	return errors.New("empty list")
+}
+
+// rankResults sorts by score, best first, keeping sweep order for ties
+func rankResults(results []OptimizeResult) {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+}
+
+// candlesFirstClose returns the first close at or after start
+func candlesFirstClose(candles []backtest.Candle, start time.Time) float64 {
+	for _, c := range candles {
+		if !c.Time.Before(start) {
+			return c.Close
+		}
+	}
+	return candles[len(candles)-1].Close
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func parseFloats(s string) ([]float64, error) {
+	var out []float64
+	for _, part := range splitList(s) {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func parseInts(s string) ([]int, error) {
+	var out []int
+	for _, part := range splitList(s) {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func parseDurations(s string) ([]time.Duration, error) {
+	var out []time.Duration
+	for _, part := range splitList(s) {
+		v, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
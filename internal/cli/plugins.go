@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/plugins"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+)
+
+var pluginsCommand = &Command{
+	Name:    "plugins",
+	Summary: "Load configured strategy plugins and list registered strategy types",
+	Run:     runPlugins,
+}
+
+func runPlugins(args []string) error {
+	fs := newFlagSet("plugins")
+	configFile := fs.String("config", "", "Path to config file")
+	dir := fs.String("dir", "", "Plugin directory (overrides config)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := app.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if *dir != "" {
+		cfg.Plugins.Dir = *dir
+	}
+
+	registry := strategy.DefaultRegistry()
+	if err := plugins.LoadAll(cfg.Plugins, registry, logger.New(logger.LevelError)); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "Built-in: dca, grid, combo")
+	names := registry.Names()
+	if len(names) == 0 {
+		fmt.Fprintln(stdout, "Plugins:  none")
+		return nil
+	}
+	for _, name := range names {
+		fmt.Fprintf(stdout, "Plugin:   %s\n", name)
+	}
+	return nil
+}
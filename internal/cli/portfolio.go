@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// portfolioLeg is one leg of a -portfolio file: a strategy config and the
+// candles of its symbol, loaded from data or generated
+type portfolioLeg struct {
+	Name      string                `json:"name"`
+	Symbol    string                `json:"symbol"`
+	Data      string                `json:"data"`      // .csv or .parquet candles
+	Synthetic string                `json:"synthetic"` // scenario instead of data
+	Seed      int64                 `json:"seed"`
+	DCA       *types.DCAConfig      `json:"dca"`
+	Grid      *types.GridConfig     `json:"grid"`
+	Breakout  *types.BreakoutConfig `json:"breakout"`
+}
+
+// loadPortfolio reads the legs of a -portfolio file
+func loadPortfolio(path string) ([]portfolioLeg, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read portfolio: %w", err)
+	}
+	var file struct {
+		Legs []portfolioLeg `json:"legs"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode portfolio: %w", err)
+	}
+	if len(file.Legs) == 0 {
+		return nil, fmt.Errorf("portfolio %s has no legs", path)
+	}
+	return file.Legs, nil
+}
+
+// backtestPortfolio runs the legs of a -portfolio file on one shared balance
+// over [-start, -end], by default the span covered by any leg's candles
+func backtestPortfolio(d *dataFlags, path string) (*backtest.PortfolioResult, error) {
+	legs, err := loadPortfolio(path)
+	if err != nil {
+		return nil, err
+	}
+	eng, err := d.engine()
+	if err != nil {
+		return nil, err
+	}
+
+	var startT, endT time.Time
+	simLegs := make([]backtest.PortfolioLeg, 0, len(legs))
+	for _, leg := range legs {
+		var candles []backtest.Candle
+		switch {
+		case leg.Data != "":
+			candles, err = eng.LoadCandles(leg.Data, *d.resample)
+		case leg.Synthetic != "":
+			var condition backtest.MarketCondition
+			if condition, err = backtest.ParseMarketCondition(leg.Synthetic); err == nil {
+				candles, err = backtest.GenerateSynthetic(backtest.ScenarioConfig(condition, *d.bars, leg.Seed))
+			}
+		default:
+			err = fmt.Errorf("set data or synthetic")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("portfolio leg %s: %w", leg.Symbol, err)
+		}
+		if startT.IsZero() || candles[0].Time.Before(startT) {
+			startT = candles[0].Time
+		}
+		if last := candles[len(candles)-1].Time; last.After(endT) {
+			endT = last
+		}
+		if leg.DCA != nil {
+			leg.DCA.Symbol = leg.Symbol
+		}
+		if leg.Grid != nil {
+			leg.Grid.Symbol = leg.Symbol
+		}
+		simLeg := backtest.PortfolioLeg{Name: leg.Name, Symbol: leg.Symbol, Candles: candles, DCA: leg.DCA, Grid: leg.Grid}
+		if leg.Breakout != nil {
+			leg.Breakout.Symbol = leg.Symbol
+			simLeg.Build = backtest.BreakoutBuilder(*leg.Breakout)
+		}
+		simLegs = append(simLegs, simLeg)
+	}
+	if *d.start != "" {
+		if startT, err = time.Parse(time.RFC3339, *d.start); err != nil {
+			return nil, fmt.Errorf("invalid -start: %w", err)
+		}
+	}
+	if *d.end != "" {
+		if endT, err = time.Parse(time.RFC3339, *d.end); err != nil {
+			return nil, fmt.Errorf("invalid -end: %w", err)
+		}
+	}
+	return eng.BacktestPortfolio(simLegs, startT, endT, *d.initial)
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var regimeCommand = &Command{
+	Name:    "regime",
+	Summary: "Train and evaluate the market regime detector on auto-labeled candles",
+	Run:     runRegime,
+}
+
+// regimeReport is the evaluation printed by trader regime
+type regimeReport struct {
+	Labeling ai.LabelOptions       `json:"labeling"`
+	Samples  int                   `json:"samples"`
+	Labels   map[ai.RegimeType]int `json:"labels"` // samples per regime
+	Train    ai.ConfusionMatrix    `json:"train"`
+	Test     ai.ConfusionMatrix    `json:"test"`
+	Rules    ai.ConfusionMatrix    `json:"rules"` // the built-in rules on the test samples
+}
+
+func runRegime(args []string) error {
+	fs := newFlagSet("regime")
+	data := addDataFlags(fs)
+	lookback := fs.Int("lookback", 50, "Candles the features are computed over (at least 20)")
+	horizon := fs.Int("horizon", 24, "Candles ahead a label looks at")
+	trend := fs.Float64("trend", 0.03, "Forward return labeling a trend up or down")
+	highVol := fs.Float64("high-vol", 0.8, "Forward volatility quantile above which a range is labeled high volatility")
+	lowVol := fs.Float64("low-vol", 0.2, "Forward volatility quantile below which a range is labeled low volatility")
+	testFraction := fs.Float64("test", 0.3, "Share of the latest samples held out for evaluation")
+	modelOut := fs.String("model-out", "", "Write the fitted model to this file for the live bot to load")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := data.validate(fs); err != nil {
+		return err
+	}
+	if *testFraction <= 0 || *testFraction >= 1 {
+		return usageError(fs, "-test must be between 0 and 1")
+	}
+
+	eng, err := data.engine()
+	if err != nil {
+		return err
+	}
+	candles, startT, endT, err := data.load(eng)
+	if err != nil {
+		return err
+	}
+	dataset, err := backtest.NewDataset(candles)
+	if err != nil {
+		return err
+	}
+	window := dataset.Between(startT, endT)
+	series := make([]types.Candle, len(window))
+	for i, c := range window {
+		series[i] = types.Candle{Symbol: *data.symbol, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume, Timestamp: c.Time}
+	}
+
+	labeling := ai.LabelOptions{Lookback: *lookback, Horizon: *horizon, Trend: *trend, HighVolQuantile: *highVol, LowVolQuantile: *lowVol}
+	samples := ai.LabelCandles(series, labeling)
+	// Train labels look Horizon candles ahead; those reaching into the test
+	// period are dropped so the evaluation sees no future it was fitted on
+	split := int(float64(len(samples)) * (1 - *testFraction))
+	trainEnd := split - *horizon
+	if trainEnd <= 0 || split >= len(samples) {
+		return fmt.Errorf("%d labeled samples are too few to train and test on", len(samples))
+	}
+	train, test := samples[:trainEnd], samples[split:]
+
+	model, err := ai.FitRegimeModel(train, labeling)
+	if err != nil {
+		return err
+	}
+	rules := ai.NewRegimeDetector(nil)
+	report := regimeReport{
+		Labeling: model.Labeling,
+		Samples:  len(samples),
+		Labels:   make(map[ai.RegimeType]int),
+		Train:    ai.EvaluateRegimes(train, model.Predict),
+		Test:     ai.EvaluateRegimes(test, model.Predict),
+		Rules:    ai.EvaluateRegimes(test, rules.Classify),
+	}
+	for _, s := range samples {
+		report.Labels[s.Regime]++
+	}
+
+	if *modelOut != "" {
+		if err := model.Save(*modelOut); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Wrote regime model from %d sample(s) to %s\n", len(train), *modelOut)
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
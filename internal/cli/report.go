@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+)
+
+var reportCommand = &Command{
+	Name:    "report",
+	Summary: "Print a DCA vs Grid performance report with per-regime breakdown",
+	Run:     runReport,
+}
+
+func runReport(args []string) error {
+	fs := newFlagSet("report")
+	data := addDataFlags(fs)
+	strategies := addStrategyFlags(fs)
+	format := fs.String("format", "text", "Output format (text, json)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := data.validate(fs); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return usageError(fs, fmt.Sprintf("unknown format %q", *format))
+	}
+
+	cmp, _, err := compare(data, strategies)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cmp)
+	}
+	return writeReport(stdout, *data.symbol, cmp)
+}
+
+// writeReport renders a comparison as aligned text tables
+func writeReport(w io.Writer, symbol string, cmp *backtest.StrategyComparison) error {
+	fmt.Fprintf(w, "Symbol: %s\n", symbol)
+	fmt.Fprintf(w, "Period: %s\n", cmp.Period)
+	fmt.Fprintf(w, "Market: %s\n\n", cmp.MarketType)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Strategy\tReturn %\tAnnual %\tMax DD %\tSharpe\tTrades\tWin %\tFees\t")
+	type result struct {
+		name string
+		m    backtest.PerformanceMetrics
+	}
+	rows := []result{{"dca", cmp.DCAResults}, {"grid", cmp.GridResults}}
+	if cmp.BreakoutResults != nil {
+		rows = append(rows, result{"breakout", *cmp.BreakoutResults})
+	}
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%.2f\t%.2f\t%d\t%.1f\t%.2f\t\n",
+			row.name, row.m.TotalReturn, row.m.AnnualizedReturn, row.m.MaxDrawdown, row.m.SharpeRatio, row.m.TradeCount, row.m.WinRate, row.m.TotalFees)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if cmp.Regimes == nil || len(cmp.Regimes.Regimes) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "By market regime:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Regime\tSegments\tBars\tStrategy\tAvg return %\tAvg max DD %\tBest in\t")
+	for _, r := range cmp.Regimes.Regimes {
+		names := make([]string, 0, len(r.AvgReturn))
+		for name := range r.AvgReturn {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%.2f\t%.2f\t%d\t\n",
+				r.Condition, r.Segments, r.Bars, name, r.AvgReturn[name], r.AvgMaxDrawdown[name], r.BestCount[name])
+		}
+	}
+	return tw.Flush()
+}
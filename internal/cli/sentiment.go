@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+)
+
+var sentimentBackfillCommand = &Command{
+	Name:    "sentiment-backfill",
+	Summary: "Score historical news or scores from CSV into the bot's sentiment history",
+	Run:     runSentimentBackfill,
+}
+
+func runSentimentBackfill(args []string) error {
+	fs := newFlagSet("sentiment-backfill")
+	in := fs.String("in", "", "CSV with timestamp, symbol and text or score columns, optionally source and confidence")
+	stateDir := fs.String("state-dir", os.Getenv("STATE_DIR"), "Bot state dir to journal sentiment in (default $STATE_DIR)")
+	interval := fs.Duration("interval", time.Hour, "Span aggregated into one sentiment point")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return usageError(fs, "-in is required")
+	}
+	if *stateDir == "" {
+		return usageError(fs, "-state-dir is required")
+	}
+	if *interval <= 0 {
+		return usageError(fs, "-interval must be positive")
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	records, err := ai.ReadSentimentCSV(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no sentiment records in %s", *in)
+	}
+
+	points := ai.NewSentimentAnalyzer(nil, nil).Backfill(records, *interval)
+	store, err := app.NewStateStore(*stateDir)
+	if err != nil {
+		return err
+	}
+	if err := app.AppendSentiment(store, points); err != nil {
+		return err
+	}
+
+	history := ai.NewSentimentHistory(points...)
+	for _, symbol := range history.Symbols() {
+		series := history.Series(symbol, time.Time{}, time.Time{})
+		fmt.Fprintf(stdout, "%s: %d point(s) from %s to %s\n", symbol, len(series),
+			series[0].Timestamp.Format(time.RFC3339), series[len(series)-1].Timestamp.Format(time.RFC3339))
+	}
+	fmt.Fprintf(stdout, "Backfilled %d record(s) into %s\n", len(records), *stateDir)
+	return nil
+}
+
+// loadSentiment reads the sentiment journaled in a state dir by a bot or
+// sentiment-backfill
+func loadSentiment(dir string) (*ai.SentimentHistory, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("sentiment dir %s: %w", dir, err)
+	}
+	store, err := app.NewStateStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	points, err := app.ReadSentiment(store)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no sentiment recorded in %s", dir)
+	}
+	return ai.NewSentimentHistory(points...), nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+)
+
+var shadowCommand = &Command{
+	Name:    "shadow",
+	Summary: "Compare a shadow strategy's hypothetical fills with the live bot's",
+	Run:     runShadow,
+}
+
+func runShadow(args []string) error {
+	fs := newFlagSet("shadow")
+	stateDir := fs.String("state-dir", os.Getenv("STATE_DIR"), "Bot state dir holding the order journal (default $STATE_DIR)")
+	id := fs.String("id", "", "Shadow strategy id, e.g. dca-shadow")
+	price := fs.Float64("price", 0, "Price open positions are valued at (default: the last fill price)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *stateDir == "" {
+		return usageError(fs, "-state-dir is required")
+	}
+	if *id == "" {
+		return usageError(fs, "-id is required")
+	}
+	if *price < 0 {
+		return usageError(fs, "-price must not be negative")
+	}
+	if _, err := os.Stat(*stateDir); err != nil {
+		return fmt.Errorf("state dir %s: %w", *stateDir, err)
+	}
+
+	store, err := app.NewStateStore(*stateDir)
+	if err != nil {
+		return err
+	}
+	report, err := app.ReadShadowReport(store, *id, *price)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
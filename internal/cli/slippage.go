@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+)
+
+var slippageCommand = &Command{
+	Name:    "slippage",
+	Summary: "Summarize live fill slippage and fees, and fit a backtest slippage model",
+	Run:     runSlippage,
+}
+
+func runSlippage(args []string) error {
+	fs := newFlagSet("slippage")
+	stateDir := fs.String("state-dir", os.Getenv("STATE_DIR"), "Bot state dir holding the order journal (default $STATE_DIR)")
+	bot := fs.String("bot", "", "Only fills of this bot")
+	symbol := fs.String("symbol", "", "Only fills of this symbol")
+	modelOut := fs.String("model-out", "", "Write a slippage model for backtest -slippage to this file")
+	minFills := fs.Int("min-fills", 20, "Fills an hour needs before the model prices it separately")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *stateDir == "" {
+		return usageError(fs, "-state-dir is required")
+	}
+	if _, err := os.Stat(*stateDir); err != nil {
+		return fmt.Errorf("state dir %s: %w", *stateDir, err)
+	}
+
+	store, err := app.NewStateStore(*stateDir)
+	if err != nil {
+		return err
+	}
+	all, err := app.ReadExecutions(store)
+	if err != nil {
+		return err
+	}
+	var executions []analytics.Execution
+	for _, e := range all {
+		if (*bot == "" || e.Bot == *bot) && (*symbol == "" || e.Symbol == *symbol) {
+			executions = append(executions, e)
+		}
+	}
+	if len(executions) == 0 {
+		return fmt.Errorf("no attributed fills in %s", *stateDir)
+	}
+	report := analytics.SummarizeSlippage(executions)
+
+	if *modelOut != "" {
+		model := slippageModel(report, *minFills)
+		data, err := json.MarshalIndent(model, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode slippage model: %w", err)
+		}
+		if err := os.WriteFile(*modelOut, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write slippage model: %w", err)
+		}
+		fmt.Fprintf(stderr, "Wrote slippage model from %d fill(s) to %s\n", len(executions), *modelOut)
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// slippageModel prices fills at the overall mean cost, and hours with at
+// least minFills fills at their own mean. Price improvement is not modeled.
+func slippageModel(report analytics.SlippageReport, minFills int) backtest.SlippageModel {
+	model := backtest.SlippageModel{Bps: max(report.Overall.MeanBps, 0)}
+	for hour, stats := range report.ByHour {
+		if stats.Count >= minFills {
+			if model.Hourly == nil {
+				model.Hourly = make(map[int]float64)
+			}
+			model.Hourly[hour] = max(stats.MeanBps, 0)
+		}
+	}
+	return model
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+)
+
+var statementCommand = &Command{
+	Name:    "statement",
+	Summary: "Re-price the portfolio as of past dates from journaled fills and historical candles",
+	Run:     runStatement,
+}
+
+func runStatement(args []string) error {
+	fs := newFlagSet("statement")
+	stateDir := fs.String("state-dir", os.Getenv("STATE_DIR"), "Bot state dir holding the order journal (default $STATE_DIR)")
+	from := fs.String("from", "", "Start of the period, RFC3339 or YYYY-MM-DD (default: the first fill)")
+	to := fs.String("to", "", "Date to re-price at, RFC3339 or YYYY-MM-DD (default now)")
+	bot := fs.String("bot", "", "Only fills of this bot")
+	format := fs.String("format", "text", "Output format (text, json)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *stateDir == "" {
+		return usageError(fs, "-state-dir is required")
+	}
+	if *format != "text" && *format != "json" {
+		return usageError(fs, fmt.Sprintf("unknown format %q", *format))
+	}
+	var fromT time.Time
+	toT := time.Now()
+	var err error
+	if *from != "" {
+		if fromT, err = parseDate(*from); err != nil {
+			return usageError(fs, fmt.Sprintf("invalid -from: %v", err))
+		}
+	}
+	if *to != "" {
+		if toT, err = parseDate(*to); err != nil {
+			return usageError(fs, fmt.Sprintf("invalid -to: %v", err))
+		}
+	}
+	if !fromT.IsZero() && !fromT.Before(toT) {
+		return usageError(fs, "-from must be before -to")
+	}
+	if _, err := os.Stat(*stateDir); err != nil {
+		return fmt.Errorf("state dir %s: %w", *stateDir, err)
+	}
+
+	store, err := app.NewStateStore(*stateDir)
+	if err != nil {
+		return err
+	}
+	all, err := app.ReadExecutions(store)
+	if err != nil {
+		return err
+	}
+	var executions []analytics.Execution
+	for _, e := range all {
+		if *bot == "" || e.Bot == *bot {
+			executions = append(executions, e)
+		}
+	}
+	if len(executions) == 0 {
+		return fmt.Errorf("no attributed fills in %s", *stateDir)
+	}
+	equity, err := app.ReadEquity(store)
+	if err != nil {
+		return err
+	}
+
+	statement, err := app.RepricePeriod(context.Background(), executions, equity, fromT, toT, candlePrice)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statement)
+	}
+	return writeStatement(stdout, statement)
+}
+
+// parseDate parses an RFC3339 time or a UTC date
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// candlePrice is the close of symbol's last hourly Binance candle ending by at
+func candlePrice(ctx context.Context, symbol string, at time.Time) (float64, error) {
+	candles, err := fetchCandles(ctx, symbol, "1h", at.Add(-2*time.Hour), at)
+	if err != nil {
+		return 0, err
+	}
+	for i := len(candles) - 1; i >= 0; i-- {
+		if !candles[i].Time.Add(time.Hour).After(at) {
+			return candles[i].Close, nil
+		}
+	}
+	return 0, fmt.Errorf("no candle ends by %s", at.Format(time.RFC3339))
+}
+
+// writeStatement renders the closing positions and the period's PnL
+func writeStatement(w io.Writer, ps *app.PeriodStatement) error {
+	closing := ps.Closing
+	if ps.Opening != nil {
+		fmt.Fprintf(w, "Period: %s to %s\n\n", ps.Opening.At.Format(time.RFC3339), closing.At.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(w, "As of: %s\n\n", closing.At.Format(time.RFC3339))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Symbol\tQuantity\tAvg price\tPrice\tValue\tUnrealized\tRealized\t")
+	for _, p := range closing.Positions {
+		fmt.Fprintf(tw, "%s\t%.8f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			p.Symbol, p.Quantity, p.AvgPrice, p.Price, p.Value, p.UnrealizedPnL, p.RealizedPnL)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nValue:          %.2f (cost %.2f)\n", closing.Value, closing.Cost)
+	if closing.Equity != nil {
+		fmt.Fprintf(w, "Recorded equity: %.2f at %s\n", closing.Equity.Equity, closing.Equity.Time.Format(time.RFC3339))
+	}
+	if ps.Opening != nil {
+		fmt.Fprintf(w, "Opening value:  %.2f\n", ps.Opening.Value)
+	}
+	fmt.Fprintf(w, "Realized PnL:   %.2f\n", ps.RealizedPnL)
+	fmt.Fprintf(w, "Unrealized PnL: %.2f\n", closing.UnrealizedPnL)
+	fmt.Fprintf(w, "Fees:           %.2f\n", ps.Fees)
+	fmt.Fprintf(w, "PnL:            %.2f over %d fill(s)\n", ps.PnL, ps.Fills)
+	return nil
+}
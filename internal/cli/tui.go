@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/app"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var tuiCommand = &Command{
+	Name:    "tui",
+	Summary: "Operate a running bot from the terminal: price, grid levels, PnL and pause/resume/cancel hotkeys",
+	Run:     runTUI,
+}
+
+func runTUI(args []string) error {
+	fs := newFlagSet("tui")
+	api := fs.String("api", "http://localhost:8080", "The bot's HTTP API")
+	token := fs.String("token", os.Getenv("API_TOKEN"), "The bot's app.api_token, needed for open orders and hotkeys (default $API_TOKEN)")
+	interval := fs.Duration("interval", 2*time.Second, "Refresh interval")
+	once := fs.Bool("once", false, "Print one frame and exit")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return usageError(fs, "-interval must be positive")
+	}
+
+	bot := &botAPI{base: strings.TrimSuffix(*api, "/"), token: *token, client: &http.Client{Timeout: 5 * time.Second}}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *once {
+		renderTUI(stdout, bot.frame(ctx), time.Now())
+		return nil
+	}
+
+	// The program puts the terminal in raw mode, so keys arrive without
+	// Enter, and restores it on exit
+	program := tea.NewProgram(&tuiModel{ctx: ctx, bot: bot, interval: *interval},
+		tea.WithContext(ctx), tea.WithOutput(stdout), tea.WithAltScreen())
+	if _, err := program.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		return err
+	}
+	return nil
+}
+
+// tuiModel is the terminal UI's state between refreshes
+type tuiModel struct {
+	ctx      context.Context
+	bot      *botAPI
+	interval time.Duration
+	frame    tuiFrame
+	message  string // result of the last hotkey
+}
+
+// frameMsg carries a fetched frame, tickMsg asks for the next one and
+// hotkeyMsg carries a hotkey's outcome
+type (
+	frameMsg  tuiFrame
+	tickMsg   struct{}
+	hotkeyMsg string
+)
+
+func (m *tuiModel) fetch() tea.Msg {
+	return frameMsg(m.bot.frame(m.ctx))
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return m.fetch
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case frameMsg:
+		m.frame = tuiFrame(msg)
+		return m, tea.Tick(m.interval, func(time.Time) tea.Msg { return tickMsg{} })
+	case tickMsg:
+		return m, m.fetch
+	case hotkeyMsg:
+		if msg != "" {
+			m.message = string(msg)
+		}
+	case tea.KeyMsg:
+		switch key := msg.String(); key {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "p", "r", "c":
+			// Hotkeys call the API off the update loop; the next refresh
+			// shows their effect
+			frame := m.frame
+			return m, func() tea.Msg { return hotkeyMsg(m.bot.hotkey(m.ctx, key[0], frame)) }
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+	frame := m.frame
+	frame.Message = m.message
+	renderTUI(&b, frame, time.Now())
+	return b.String()
+}
+
+// tuiFrame is one refresh of a bot's state; fields the API did not answer
+// stay empty and the first failure is kept in Error
+type tuiFrame struct {
+	Status   *types.StrategyStatus
+	Ticker   *types.Ticker
+	Metrics  *types.StrategyMetrics
+	Exchange map[string]interface{}
+	Orders   []types.Order // open orders; nil without a token
+	Error    string
+	Message  string // result of the last hotkey
+}
+
+// botAPI reads and operates a bot through its HTTP API
+type botAPI struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+// frame fetches the bot's status, price, metrics and open orders
+func (b *botAPI) frame(ctx context.Context) tuiFrame {
+	var frame tuiFrame
+	fail := func(err error) {
+		if err != nil && frame.Error == "" {
+			frame.Error = err.Error()
+		}
+	}
+
+	var status types.StrategyStatus
+	if err := b.do(ctx, http.MethodGet, "/v1/strategy/status", &status); err != nil {
+		fail(err)
+		return frame
+	}
+	frame.Status = &status
+
+	var metrics struct {
+		Strategy types.StrategyMetrics `json:"strategy"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/v1/metrics", &metrics); err == nil {
+		frame.Metrics = &metrics.Strategy
+	} else {
+		fail(err)
+	}
+	if err := b.do(ctx, http.MethodGet, "/v1/exchange/status", &frame.Exchange); err != nil {
+		fail(err)
+	}
+	if status.Symbol == "" {
+		return frame
+	}
+	symbol := url.QueryEscape(status.Symbol)
+	var ticker types.Ticker
+	if err := b.do(ctx, http.MethodGet, "/v1/ticker?symbol="+symbol, &ticker); err == nil {
+		frame.Ticker = &ticker
+	} else {
+		fail(err)
+	}
+	if b.token != "" {
+		frame.Orders = make([]types.Order, 0)
+		fail(b.do(ctx, http.MethodGet, "/v1/orders?symbol="+symbol, &frame.Orders))
+	}
+	return frame
+}
+
+// hotkey runs the action bound to key and describes the outcome
+func (b *botAPI) hotkey(ctx context.Context, key byte, frame tuiFrame) string {
+	if b.token == "" && strings.ContainsRune("prc", rune(key)) {
+		return "hotkeys need -token"
+	}
+	switch key {
+	case 'p':
+		if err := b.do(ctx, http.MethodPost, "/v1/trading/pause?reason=tui", nil); err != nil {
+			return "pause failed: " + err.Error()
+		}
+		return "trading paused"
+	case 'r':
+		if err := b.do(ctx, http.MethodPost, "/v1/trading/resume", nil); err != nil {
+			return "resume failed: " + err.Error()
+		}
+		return "trading resumed"
+	case 'c':
+		var canceled int
+		for _, order := range frame.Orders {
+			if err := b.do(ctx, http.MethodDelete, "/v1/orders/"+url.PathEscape(order.ID), nil); err != nil {
+				return fmt.Sprintf("canceled %d of %d orders: %v", canceled, len(frame.Orders), err)
+			}
+			canceled++
+		}
+		return fmt.Sprintf("canceled %d open order(s)", canceled)
+	}
+	return ""
+}
+
+// do sends a request to the API and decodes the response into v
+func (b *botAPI) do(ctx context.Context, method, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, b.base+path, nil)
+	if err != nil {
+		return err
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr app.APIError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s %s: %s", method, path, apiErr.Error)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(bytes.NewReader(body)).Decode(v)
+}
+
+// renderTUI draws one frame of the terminal UI
+func renderTUI(w io.Writer, frame tuiFrame, now time.Time) {
+	status := frame.Status
+	if status == nil {
+		fmt.Fprintf(w, "Bot API unavailable: %s\n", frame.Error)
+		fmt.Fprintln(w, "\n[q] quit")
+		return
+	}
+
+	state := "RUNNING"
+	if available, _ := frame.Exchange["available"].(bool); frame.Exchange != nil && !available {
+		state = fmt.Sprintf("PAUSED (%v)", frame.Exchange["reason"])
+	}
+	price := "-"
+	if frame.Ticker != nil {
+		price = fmt.Sprintf("%.2f", frame.Ticker.Price)
+	}
+	fmt.Fprintf(w, "%s  price %s  %s  %s\n", statusLine(status), price, state, now.Local().Format("15:04:05"))
+	if m := frame.Metrics; m != nil {
+		fmt.Fprintf(w, "PnL     %+.2f  trades %d (won %d, %.1f%%)  volume %.2f  max drawdown %.2f\n",
+			m.TotalProfit-m.TotalLoss, m.TotalTrades, m.WinningTrades, m.WinRate, m.TotalVolume, m.MaxDrawdown)
+	}
+
+	if grid := status.Grid; grid != nil && len(grid.Prices) > 0 {
+		fmt.Fprintln(w)
+		renderGridLevels(w, grid, frame.Ticker, frame.Orders)
+	}
+
+	switch {
+	case frame.Orders == nil:
+		fmt.Fprintln(w, "\nOpen orders: pass -token to list them")
+	case len(frame.Orders) == 0:
+		fmt.Fprintln(w, "\nOpen orders: none")
+	default:
+		fmt.Fprintf(w, "\nOpen orders (%d)\n", len(frame.Orders))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSIDE\tTYPE\tQUANTITY\tPRICE")
+		for _, o := range frame.Orders {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%.8f\t%.2f\n", o.ID, o.Side, o.Type, o.Quantity, o.Price)
+		}
+		_ = tw.Flush()
+	}
+
+	if frame.Error != "" {
+		fmt.Fprintf(w, "\nAPI error: %s\n", frame.Error)
+	}
+	if frame.Message != "" {
+		fmt.Fprintf(w, "\n%s\n", frame.Message)
+	}
+	fmt.Fprintln(w, "\n[p] pause  [r] resume  [c] cancel open orders  [q] quit")
+}
+
+// renderGridLevels draws the grid highest level first: ● marks a level
+// holding a position, ○ an empty one, and the price line sits between the
+// levels around it. Levels that traded show their round trips and profit.
+func renderGridLevels(w io.Writer, grid *types.GridStatus, ticker *types.Ticker, orders []types.Order) {
+	held := make(map[float64]types.GridLevel, len(grid.OpenLevels))
+	for _, level := range grid.OpenLevels {
+		held[level.Level] = level
+	}
+	stats := make(map[float64]types.GridLevelStats, len(grid.LevelStats))
+	for _, s := range grid.LevelStats {
+		stats[s.Level] = s
+	}
+	resting := make(map[float64]int)
+	for _, o := range orders {
+		resting[o.Price]++
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tLEVEL\tQUANTITY\tAVG PRICE\tORDERS\tTRIPS\tPROFIT")
+	priceShown := ticker == nil
+	for i := len(grid.Prices) - 1; i >= 0; i-- {
+		level := grid.Prices[i]
+		if !priceShown && ticker.Price >= level {
+			fmt.Fprintf(tw, "▶\t%.2f\tprice\t\t\t\t\n", ticker.Price)
+			priceShown = true
+		}
+		marker, quantity, avg := "○", "", ""
+		if pos, ok := held[level]; ok {
+			marker, quantity, avg = "●", fmt.Sprintf("%.8f", pos.Quantity), fmt.Sprintf("%.2f", pos.AvgPrice)
+		}
+		count := ""
+		if n := resting[level]; n > 0 {
+			count = fmt.Sprint(n)
+		}
+		trips, profit := "", ""
+		if s, ok := stats[level]; ok {
+			trips, profit = fmt.Sprint(s.RoundTrips), fmt.Sprintf("%+.2f", s.Profit)
+		}
+		fmt.Fprintf(tw, "%s\t%.2f\t%s\t%s\t%s\t%s\t%s\n", marker, level, quantity, avg, count, trips, profit)
+	}
+	if !priceShown {
+		fmt.Fprintf(tw, "▶\t%.2f\tprice\t\t\t\t\n", ticker.Price)
+	}
+	_ = tw.Flush()
+}
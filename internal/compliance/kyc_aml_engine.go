@@ -11,7 +11,7 @@ type ComplianceEngine struct {
 	amlMonitor    AMLMonitor
 	sanctionsDB   SanctionsDatabase
 	riskScorer    RiskScorer
-	reportManager ReportManager
+	reportManager *ReportManager
 }
 
 type SanctionsDatabase struct {
@@ -22,10 +22,6 @@ type RiskScorer struct {
 	// Risk scoring functionality
 }
 
-type ReportManager struct {
-	// Report management functionality
-}
-
 type Customer struct {
 	ID                 string        `json:"id"`
 	Name               string        `json:"name"`
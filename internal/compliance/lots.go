@@ -0,0 +1,80 @@
+package compliance
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// DefaultLongTermAfter is the holding period from which gains are long-term
+const DefaultLongTermAfter = 365 * 24 * time.Hour
+
+// Treatment classifies the gain on a lot acquired at acquired and sold at sold
+func Treatment(acquired, sold time.Time, longTermAfter time.Duration) TaxTreatment {
+	if longTermAfter <= 0 {
+		longTermAfter = DefaultLongTermAfter
+	}
+	if sold.Sub(acquired) >= longTermAfter {
+		return TaxTreatmentLongTerm
+	}
+	return TaxTreatmentShortTerm
+}
+
+// SelectLots picks the lots a sale of quantity at price and time at disposes
+// of under cfg.Method and estimates its tax. Lots are oldest first and are
+// not modified; a quantity beyond them is left out of the impact.
+func SelectLots(lots []types.TaxLot, quantity, price float64, at time.Time, cfg types.TaxLotConfig) *types.TaxImpact {
+	method := cfg.Method
+	if method == "" {
+		method = types.LotSpecific
+	}
+	rate := func(lot types.TaxLot) float64 {
+		if Treatment(lot.Acquired, at, cfg.LongTermAfter) == TaxTreatmentLongTerm {
+			return cfg.LongTermRate
+		}
+		return cfg.ShortTermRate
+	}
+
+	order := make([]types.TaxLot, len(lots))
+	copy(order, lots)
+	switch method {
+	case types.LotHIFO:
+		sort.SliceStable(order, func(i, j int) bool { return order[i].Price > order[j].Price })
+	case types.LotSpecific:
+		// Tax per unit sold is linear in quantity, so taking the cheapest
+		// lots first minimizes the total; losses come first
+		sort.SliceStable(order, func(i, j int) bool {
+			return (price-order[i].Price)*rate(order[i]) < (price-order[j].Price)*rate(order[j])
+		})
+	}
+
+	impact := &types.TaxImpact{Method: method, Price: price}
+	left := quantity
+	for _, lot := range order {
+		if left <= 1e-12 {
+			break
+		}
+		sold := math.Min(lot.Quantity, left)
+		left -= sold
+		sale := types.LotSale{
+			Lot:       lot.ID,
+			Quantity:  sold,
+			CostBasis: sold * lot.Price,
+			Gain:      sold * (price - lot.Price),
+			Treatment: string(Treatment(lot.Acquired, at, cfg.LongTermAfter)),
+		}
+		impact.Lots = append(impact.Lots, sale)
+		impact.Quantity += sold
+		impact.CostBasis += sale.CostBasis
+		if sale.Treatment == string(TaxTreatmentLongTerm) {
+			impact.LongTermGain += sale.Gain
+		} else {
+			impact.ShortTermGain += sale.Gain
+		}
+		impact.Tax += sale.Gain * rate(lot)
+	}
+	impact.Proceeds = impact.Quantity * price
+	return impact
+}
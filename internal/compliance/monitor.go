@@ -0,0 +1,357 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrNonCompliant is returned for buys an enforcing monitor rejects
+var ErrNonCompliant = errors.New("order fails compliance checks")
+
+// Monitor modes
+const (
+	ModeMonitor = "monitor" // record violations, place the order anyway
+	ModeEnforce = "enforce" // also reject buys that fail a check
+)
+
+// Compliance check names
+const (
+	CheckSize         = "size"
+	CheckVelocity     = "velocity"
+	CheckJurisdiction = "jurisdiction"
+)
+
+// Audit stages
+const (
+	StagePreTrade  = "pre_trade"
+	StagePostTrade = "post_trade"
+)
+
+// MonitorConfig configures transaction monitoring of every order (disabled
+// without checks)
+type MonitorConfig struct {
+	Mode string `json:"mode"` // ModeMonitor (default) or ModeEnforce
+
+	// MaxOrderValue limits the quote value of one order
+	MaxOrderValue float64 `json:"max_order_value"`
+
+	// MaxOrders and MaxValue limit the orders placed, and their quote value,
+	// within VelocityWindow (default 24h)
+	MaxOrders      int           `json:"max_orders"`
+	MaxValue       float64       `json:"max_value"`
+	VelocityWindow time.Duration `json:"velocity_window"`
+
+	// Jurisdiction is where the account is held, e.g. "US"; Restricted lists
+	// the assets or symbols flagged in each jurisdiction
+	Jurisdiction string              `json:"jurisdiction"`
+	Restricted   map[string][]string `json:"restricted"`
+
+	// Reports queues SARs and periodic summaries of the reviews (needs a
+	// state dir)
+	Reports ReportConfig `json:"reports"`
+}
+
+// UnmarshalJSON implements custom parsing for velocity_window
+func (c *MonitorConfig) UnmarshalJSON(data []byte) error {
+	type Alias MonitorConfig
+	aux := &struct {
+		VelocityWindow string `json:"velocity_window"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.VelocityWindow != "" {
+		duration, err := time.ParseDuration(aux.VelocityWindow)
+		if err != nil {
+			return fmt.Errorf("invalid velocity_window format: %w", err)
+		}
+		c.VelocityWindow = duration
+	}
+	return nil
+}
+
+// Enabled reports whether any check is configured
+func (c MonitorConfig) Enabled() bool {
+	return c.MaxOrderValue > 0 || c.MaxOrders > 0 || c.MaxValue > 0 || len(c.restricted()) > 0
+}
+
+// Validate checks the mode, limits and jurisdiction
+func (c MonitorConfig) Validate() error {
+	if c.Mode != "" && c.Mode != ModeMonitor && c.Mode != ModeEnforce {
+		return fmt.Errorf("unknown mode %q (monitor, enforce)", c.Mode)
+	}
+	if c.MaxOrderValue < 0 || c.MaxOrders < 0 || c.MaxValue < 0 || c.VelocityWindow < 0 {
+		return fmt.Errorf("limits and velocity window must not be negative")
+	}
+	if len(c.Restricted) > 0 && c.Jurisdiction == "" {
+		return fmt.Errorf("restricted assets need the account's jurisdiction")
+	}
+	if err := c.Reports.Validate(); err != nil {
+		return fmt.Errorf("reports: %w", err)
+	}
+	return nil
+}
+
+// restricted returns the assets and symbols flagged in the account's
+// jurisdiction
+func (c MonitorConfig) restricted() map[string]bool {
+	flagged := make(map[string]bool)
+	for jurisdiction, assets := range c.Restricted {
+		if !strings.EqualFold(jurisdiction, c.Jurisdiction) {
+			continue
+		}
+		for _, asset := range assets {
+			flagged[strings.ToUpper(asset)] = true
+		}
+	}
+	return flagged
+}
+
+// CheckResult is the outcome of one check of an order
+type CheckResult struct {
+	Check  string `json:"check"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// AuditRecord is a compliance review of an order: its checks before it is
+// placed and the outcome after
+type AuditRecord struct {
+	Time       time.Time       `json:"time"`
+	Stage      string          `json:"stage"`
+	StrategyID string          `json:"strategy_id,omitempty"`
+	Symbol     string          `json:"symbol"`
+	Side       types.OrderSide `json:"side"`
+	Quantity   float64         `json:"quantity"`
+	Value      float64         `json:"value"`
+	Checks     []CheckResult   `json:"checks,omitempty"`
+	RiskScore  float64         `json:"risk_score"`
+	Blocked    bool            `json:"blocked,omitempty"`
+	Error      string          `json:"error,omitempty"` // of the placement, post-trade
+}
+
+// Violations returns the checks the order failed
+func (r AuditRecord) Violations() []CheckResult {
+	var failed []CheckResult
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// velocityOrder is a placed order as the velocity check remembers it
+type velocityOrder struct {
+	time  time.Time
+	value float64
+}
+
+// Monitor runs compliance checks on every order before it is placed and
+// records them, and the placement's outcome, in an audit log. In enforce mode
+// buys failing a check are rejected; sells always go through so positions
+// can be closed.
+type Monitor struct {
+	mu      sync.Mutex
+	cfg     MonitorConfig
+	flagged map[string]bool
+	audit   func(AuditRecord)
+	scorer  RiskScorer
+	now     func() time.Time
+
+	placed     []velocityOrder // within the velocity window
+	reviewed   int
+	violations int
+	blocked    int
+}
+
+// NewMonitor creates a monitor for cfg recording its reviews with audit
+func NewMonitor(cfg MonitorConfig, audit func(AuditRecord)) *Monitor {
+	if cfg.Mode == "" {
+		cfg.Mode = ModeMonitor
+	}
+	if cfg.VelocityWindow <= 0 {
+		cfg.VelocityWindow = 24 * time.Hour
+	}
+	return &Monitor{cfg: cfg, flagged: cfg.restricted(), audit: audit, now: time.Now}
+}
+
+// PreTrade checks order and records the review. It returns ErrNonCompliant
+// for a buy failing a check in enforce mode.
+func (m *Monitor) PreTrade(order types.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.prune(now)
+	record := m.record(now, StagePreTrade, order)
+	record.Checks = m.checks(order, record.Value)
+	violations := record.Violations()
+	record.RiskScore = m.scorer.CalculateRiskScore(RiskFactors{
+		KYCStatus:         KYCStatusVerified, // the exchange verified the account
+		AMLRisk:           RiskLevel(min(len(violations), int(RiskLevelCritical))),
+		SanctionsHit:      !passed(record.Checks, CheckJurisdiction),
+		TransactionAmount: record.Value,
+	})
+	m.reviewed++
+	if len(violations) > 0 {
+		m.violations++
+		record.Blocked = m.cfg.Mode == ModeEnforce && order.Side == types.OrderSideBuy
+	}
+	if record.Blocked {
+		m.blocked++
+	}
+	m.audit(record)
+
+	if record.Blocked {
+		details := make([]string, len(violations))
+		for i, violation := range violations {
+			details[i] = violation.Detail
+		}
+		return fmt.Errorf("%w: %s", ErrNonCompliant, strings.Join(details, "; "))
+	}
+	return nil
+}
+
+// PostTrade records the outcome of placing order; placed orders count
+// towards the velocity limits
+func (m *Monitor) PostTrade(order types.Order, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	record := m.record(now, StagePostTrade, order)
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		m.placed = append(m.placed, velocityOrder{time: now, value: record.Value})
+	}
+	m.audit(record)
+}
+
+func (m *Monitor) record(now time.Time, stage string, order types.Order) AuditRecord {
+	record := AuditRecord{
+		Time:     now,
+		Stage:    stage,
+		Symbol:   order.Symbol,
+		Side:     order.Side,
+		Quantity: order.Quantity,
+		Value:    order.Quantity * order.Price,
+	}
+	if order.Decision != nil {
+		record.StrategyID = order.Decision.StrategyID
+	}
+	return record
+}
+
+// checks runs the configured checks on an order worth value
+func (m *Monitor) checks(order types.Order, value float64) []CheckResult {
+	var results []CheckResult
+	if limit := m.cfg.MaxOrderValue; limit > 0 {
+		result := CheckResult{Check: CheckSize, Passed: value <= limit}
+		if !result.Passed {
+			result.Detail = fmt.Sprintf("order value %.2f exceeds %.2f", value, limit)
+		}
+		results = append(results, result)
+	}
+
+	if m.cfg.MaxOrders > 0 || m.cfg.MaxValue > 0 {
+		total := value
+		for _, placed := range m.placed {
+			total += placed.value
+		}
+		result := CheckResult{Check: CheckVelocity, Passed: true}
+		switch {
+		case m.cfg.MaxOrders > 0 && len(m.placed)+1 > m.cfg.MaxOrders:
+			result.Passed = false
+			result.Detail = fmt.Sprintf("more than %d orders within %s", m.cfg.MaxOrders, m.cfg.VelocityWindow)
+		case m.cfg.MaxValue > 0 && total > m.cfg.MaxValue:
+			result.Passed = false
+			result.Detail = fmt.Sprintf("%.2f traded within %s exceeds %.2f", total, m.cfg.VelocityWindow, m.cfg.MaxValue)
+		}
+		results = append(results, result)
+	}
+
+	if len(m.flagged) > 0 {
+		result := CheckResult{Check: CheckJurisdiction, Passed: true}
+		symbol := strings.ToUpper(order.Symbol)
+		base, quote, _ := types.SplitSymbol(symbol)
+		for _, name := range []string{symbol, base, quote} {
+			if name != "" && m.flagged[name] {
+				result.Passed = false
+				result.Detail = fmt.Sprintf("%s is restricted in %s", name, m.cfg.Jurisdiction)
+				break
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// passed reports whether results hold no failed check named check
+func passed(results []CheckResult, check string) bool {
+	for _, result := range results {
+		if result.Check == check && !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// prune drops placed orders older than the velocity window
+func (m *Monitor) prune(now time.Time) {
+	cutoff := now.Add(-m.cfg.VelocityWindow)
+	keep := m.placed[:0]
+	for _, placed := range m.placed {
+		if placed.time.After(cutoff) {
+			keep = append(keep, placed)
+		}
+	}
+	m.placed = keep
+}
+
+// Status reports the mode and the orders reviewed, flagged and blocked
+func (m *Monitor) Status() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return map[string]interface{}{
+		"mode":       m.cfg.Mode,
+		"reviewed":   m.reviewed,
+		"violations": m.violations,
+		"blocked":    m.blocked,
+	}
+}
+
+// Client wraps an exchange client so every order is reviewed
+func (m *Monitor) Client(exchange types.ExchangeClient) types.ExchangeClient {
+	return &monitoredClient{ExchangeClient: exchange, monitor: m}
+}
+
+// monitoredClient runs the monitor around PlaceOrder
+type monitoredClient struct {
+	types.ExchangeClient
+	monitor *Monitor
+}
+
+// PlaceOrder reviews the order, places it unless it is blocked and records
+// the outcome
+func (c *monitoredClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	if err := c.monitor.PreTrade(order); err != nil {
+		return err
+	}
+	err := c.ExchangeClient.PlaceOrder(ctx, order)
+	c.monitor.PostTrade(order, err)
+	return err
+}
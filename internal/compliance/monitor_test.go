@@ -0,0 +1,112 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// recordingClient keeps placed orders
+type recordingClient struct {
+	types.ExchangeClient
+	orders []types.Order
+}
+
+func (c *recordingClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	c.orders = append(c.orders, order)
+	return nil
+}
+
+func TestMonitor_Checks(t *testing.T) {
+	var cfg MonitorConfig
+	if err := json.Unmarshal([]byte(`{
+		"mode": "enforce",
+		"max_order_value": 1000,
+		"max_orders": 2,
+		"velocity_window": "1h",
+		"jurisdiction": "US",
+		"restricted": {"us": ["XMR"], "EU": ["BTC"]}
+	}`), &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := cfg.Validate(); err != nil || !cfg.Enabled() {
+		t.Fatalf("Validate() = %v, Enabled() = %v", err, cfg.Enabled())
+	}
+
+	var audit []AuditRecord
+	m := NewMonitor(cfg, func(record AuditRecord) { audit = append(audit, record) })
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	inner := &recordingClient{}
+	client := m.Client(inner)
+	ctx := context.Background()
+	buy := func(symbol string, quantity float64) error {
+		return client.PlaceOrder(ctx, types.Order{Symbol: symbol, Side: types.OrderSideBuy, Quantity: quantity, Price: 100})
+	}
+
+	if err := buy("BTCUSDT", 5); err != nil {
+		t.Fatalf("compliant buy: %v", err)
+	}
+	if len(audit) != 2 || audit[0].Stage != StagePreTrade || audit[1].Stage != StagePostTrade || len(audit[0].Violations()) != 0 {
+		t.Fatalf("audit = %+v, want a clean pre- and post-trade record", audit)
+	}
+
+	if err := buy("BTCUSDT", 20); !errors.Is(err, ErrNonCompliant) {
+		t.Errorf("oversized buy: err = %v, want ErrNonCompliant", err)
+	}
+	if err := buy("XMRUSDT", 1); !errors.Is(err, ErrNonCompliant) {
+		t.Errorf("restricted buy: err = %v, want ErrNonCompliant", err)
+	}
+	if len(audit) != 4 || !audit[3].Blocked || audit[3].RiskScore <= audit[0].RiskScore {
+		t.Errorf("restricted buy audit = %+v, want blocked with a higher risk score", audit[3])
+	}
+
+	// Sells go through, flagged
+	if err := client.PlaceOrder(ctx, types.Order{Symbol: "XMRUSDT", Side: types.OrderSideSell, Quantity: 1, Price: 100}); err != nil {
+		t.Fatalf("restricted sell: %v", err)
+	}
+	if err := buy("BTCUSDT", 1); !errors.Is(err, ErrNonCompliant) {
+		t.Errorf("third order within the hour: err = %v, want ErrNonCompliant", err)
+	}
+	now = now.Add(time.Hour)
+	if err := buy("BTCUSDT", 1); err != nil {
+		t.Errorf("order after the velocity window: %v", err)
+	}
+
+	if len(inner.orders) != 3 {
+		t.Errorf("placed %d orders, want 3", len(inner.orders))
+	}
+	status := m.Status()
+	if status["reviewed"] != 6 || status["violations"] != 4 || status["blocked"] != 3 {
+		t.Errorf("status = %v", status)
+	}
+}
+
+func TestMonitor_MonitorMode(t *testing.T) {
+	var audit []AuditRecord
+	m := NewMonitor(MonitorConfig{MaxOrderValue: 100}, func(record AuditRecord) { audit = append(audit, record) })
+	inner := &recordingClient{}
+	order := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1, Price: 500}
+	if err := m.Client(inner).PlaceOrder(context.Background(), order); err != nil {
+		t.Fatalf("monitor mode: %v", err)
+	}
+	if len(inner.orders) != 1 || len(audit) != 2 || audit[0].Blocked || len(audit[0].Violations()) != 1 {
+		t.Errorf("orders = %d, audit = %+v; want the order placed and flagged", len(inner.orders), audit)
+	}
+}
+
+func TestMonitorConfig_Validate(t *testing.T) {
+	for name, cfg := range map[string]MonitorConfig{
+		"unknown mode":    {Mode: "block", MaxOrders: 1},
+		"negative limit":  {MaxOrderValue: -1},
+		"no jurisdiction": {Restricted: map[string][]string{"US": {"XMR"}}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want an error", name)
+		}
+	}
+}
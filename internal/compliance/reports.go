@@ -0,0 +1,405 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report types
+const (
+	ReportSAR     = "sar"     // suspicious activity report
+	ReportSummary = "summary" // periodic summary of the orders reviewed
+)
+
+// Filing states
+const (
+	ReportPending = "pending"
+	ReportFiled   = "filed"
+)
+
+// DefaultSARRiskScore is the risk score from which a flagged order is reported
+const DefaultSARRiskScore = 1.0
+
+var (
+	// ErrReportNotFound is returned for unknown report ids
+	ErrReportNotFound = errors.New("report not found")
+	// ErrReportFiled is returned when filing a report twice
+	ErrReportFiled = errors.New("report already filed")
+)
+
+// ReportConfig configures the compliance reports queued for filing
+type ReportConfig struct {
+	// SummaryInterval is how often a summary of the orders reviewed is
+	// queued (disabled when zero)
+	SummaryInterval time.Duration `json:"summary_interval"`
+
+	// SARRiskScore is the risk score from which an order failing a check is
+	// reported as suspicious (default 1.0: restricted assets, or several
+	// violations at once)
+	SARRiskScore float64 `json:"sar_risk_score"`
+}
+
+// UnmarshalJSON implements custom parsing for summary_interval
+func (c *ReportConfig) UnmarshalJSON(data []byte) error {
+	type Alias ReportConfig
+	aux := &struct {
+		SummaryInterval string `json:"summary_interval"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.SummaryInterval != "" {
+		duration, err := time.ParseDuration(aux.SummaryInterval)
+		if err != nil {
+			return fmt.Errorf("invalid summary_interval format: %w", err)
+		}
+		c.SummaryInterval = duration
+	}
+	return nil
+}
+
+// Validate checks the interval and threshold
+func (c ReportConfig) Validate() error {
+	if c.SummaryInterval < 0 || c.SARRiskScore < 0 {
+		return fmt.Errorf("summary interval and SAR risk score must not be negative")
+	}
+	return nil
+}
+
+// Report is a compliance report rendered to a file and awaiting filing
+type Report struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	From      time.Time  `json:"from"`
+	To        time.Time  `json:"to"`
+	Created   time.Time  `json:"created"`
+	File      string     `json:"file"`
+	Status    string     `json:"status"`
+	FiledAt   *time.Time `json:"filed_at,omitempty"`
+	Reference string     `json:"reference,omitempty"` // given by the regulator on filing
+}
+
+// reportIndex is the file listing the reports and their filing status
+const reportIndex = "reports.json"
+
+// ReportManager queues suspicious activity reports and periodic summaries of
+// the orders the monitor reviews, renders them to text files in its directory
+// and tracks whether they were filed. The reports and their status survive
+// restarts; the orders of the summary period in progress do not.
+type ReportManager struct {
+	mu      sync.Mutex
+	dir     string
+	cfg     ReportConfig
+	reports []Report
+	now     func() time.Time
+
+	from   time.Time     // start of the summary period
+	period []AuditRecord // pre-trade reviews since from
+}
+
+// NewReportManager creates a report manager keeping its reports in dir
+func NewReportManager(dir string, cfg ReportConfig) (*ReportManager, error) {
+	if cfg.SARRiskScore <= 0 {
+		cfg.SARRiskScore = DefaultSARRiskScore
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create report dir: %w", err)
+	}
+	m := &ReportManager{dir: dir, cfg: cfg, now: time.Now}
+	data, err := os.ReadFile(filepath.Join(dir, reportIndex))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read report index: %w", err)
+	default:
+		if err := json.Unmarshal(data, &m.reports); err != nil {
+			return nil, fmt.Errorf("failed to decode report index: %w", err)
+		}
+	}
+	m.from = m.now()
+	return m, nil
+}
+
+// Observe takes a review from the monitor: pre-trade reviews count towards
+// the summary, and those failing a check at or above the SAR risk score are
+// reported as suspicious
+func (m *ReportManager) Observe(record AuditRecord) error {
+	if record.Stage != StagePreTrade {
+		return nil
+	}
+	m.mu.Lock()
+	m.period = append(m.period, record)
+	m.mu.Unlock()
+
+	violations := record.Violations()
+	if len(violations) == 0 || record.RiskScore < m.cfg.SARRiskScore {
+		return nil
+	}
+	details := make([]string, len(violations))
+	for i, violation := range violations {
+		details[i] = violation.Detail
+	}
+	sar := SARReport{
+		Description: fmt.Sprintf("%s %s %.8g (%.2f): %s", record.Side, record.Symbol, record.Quantity, record.Value, strings.Join(details, "; ")),
+		Timestamp:   record.Time,
+	}
+	_, err := m.QueueSAR(sar, []AuditRecord{record})
+	return err
+}
+
+// QueueSAR renders a suspicious activity report on records and queues it for
+// filing; an empty sar.ID is assigned
+func (m *ReportManager) QueueSAR(sar SARReport, records []AuditRecord) (Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if sar.Timestamp.IsZero() {
+		sar.Timestamp = now
+	}
+	report := m.newReport(ReportSAR, now)
+	if sar.ID == "" {
+		sar.ID = report.ID
+	}
+	report.Title = "Suspicious activity: " + sar.Description
+	report.From, report.To = sar.Timestamp, sar.Timestamp
+	for _, record := range records {
+		if record.Time.Before(report.From) {
+			report.From = record.Time
+		}
+		if record.Time.After(report.To) {
+			report.To = record.Time
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "SUSPICIOUS ACTIVITY REPORT %s\n\n", sar.ID)
+	fmt.Fprintf(&b, "Detected:    %s\n", sar.Timestamp.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Description: %s\n", sar.Description)
+	if len(records) > 0 {
+		b.WriteString("\nOrders:\n")
+		for _, record := range records {
+			writeRecord(&b, record)
+		}
+	}
+	return m.queue(report, b.String())
+}
+
+// Summarize renders a summary of the orders reviewed since the last one and
+// queues it for filing
+func (m *ReportManager) Summarize() (Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	report := m.newReport(ReportSummary, now)
+	report.From, report.To = m.from, now
+	report.Title = fmt.Sprintf("Compliance summary %s to %s", m.from.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339))
+
+	var flagged, blocked int
+	var value, flaggedValue float64
+	byCheck := make(map[string]int)
+	for _, record := range m.period {
+		value += record.Value
+		violations := record.Violations()
+		if len(violations) > 0 {
+			flagged++
+			flaggedValue += record.Value
+		}
+		if record.Blocked {
+			blocked++
+		}
+		for _, violation := range violations {
+			byCheck[violation.Check]++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "COMPLIANCE SUMMARY %s\n\n", report.ID)
+	fmt.Fprintf(&b, "Period:         %s to %s\n", report.From.UTC().Format(time.RFC3339), report.To.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Orders:         %d (%.2f)\n", len(m.period), value)
+	fmt.Fprintf(&b, "Flagged:        %d (%.2f)\n", flagged, flaggedValue)
+	fmt.Fprintf(&b, "Blocked:        %d\n", blocked)
+	checks := make([]string, 0, len(byCheck))
+	for check := range byCheck {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+	for _, check := range checks {
+		fmt.Fprintf(&b, "  %-13s %d\n", check+":", byCheck[check])
+	}
+	sars := 0
+	for _, r := range m.reports {
+		if r.Type == ReportSAR && !r.Created.Before(report.From) {
+			sars++
+		}
+	}
+	fmt.Fprintf(&b, "SARs queued:    %d\n", sars)
+	if flagged > 0 {
+		b.WriteString("\nFlagged orders:\n")
+		for _, record := range m.period {
+			if len(record.Violations()) > 0 {
+				writeRecord(&b, record)
+			}
+		}
+	}
+
+	queued, err := m.queue(report, b.String())
+	if err != nil {
+		return queued, err
+	}
+	m.from, m.period = now, nil
+	return queued, nil
+}
+
+// writeRecord renders one reviewed order
+func writeRecord(b *strings.Builder, record AuditRecord) {
+	fmt.Fprintf(b, "- %s %s %s %.8g value %.2f risk %.2f", record.Time.UTC().Format(time.RFC3339), record.Side, record.Symbol, record.Quantity, record.Value, record.RiskScore)
+	if record.StrategyID != "" {
+		fmt.Fprintf(b, " strategy %s", record.StrategyID)
+	}
+	if record.Blocked {
+		b.WriteString(" BLOCKED")
+	}
+	b.WriteString("\n")
+	for _, violation := range record.Violations() {
+		fmt.Fprintf(b, "    %s: %s\n", violation.Check, violation.Detail)
+	}
+}
+
+// newReport starts a pending report of type kind created at now
+func (m *ReportManager) newReport(kind string, now time.Time) Report {
+	id := fmt.Sprintf("%s-%s-%d", kind, now.UTC().Format("20060102-150405"), len(m.reports)+1)
+	return Report{ID: id, Type: kind, Created: now, File: id + ".txt", Status: ReportPending}
+}
+
+// queue writes the report's file and adds it to the index
+func (m *ReportManager) queue(report Report, body string) (Report, error) {
+	if err := os.WriteFile(filepath.Join(m.dir, report.File), []byte(body), 0o644); err != nil {
+		return report, fmt.Errorf("failed to write report %s: %w", report.ID, err)
+	}
+	m.reports = append(m.reports, report)
+	if err := m.save(); err != nil {
+		m.reports = m.reports[:len(m.reports)-1]
+		return report, err
+	}
+	return report, nil
+}
+
+// save atomically writes the report index
+func (m *ReportManager) save() error {
+	data, err := json.MarshalIndent(m.reports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report index: %w", err)
+	}
+	path := filepath.Join(m.dir, reportIndex)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("failed to write report index: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write report index: %w", err)
+	}
+	return nil
+}
+
+// Reports returns the reports in the given filing status, or all of them
+// when status is empty, oldest first
+func (m *ReportManager) Reports(status string) []Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reports := []Report{}
+	for _, report := range m.reports {
+		if status == "" || report.Status == status {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// Open returns the report with id and the path of its file
+func (m *ReportManager) Open(id string) (Report, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, report := range m.reports {
+		if report.ID == id {
+			return report, filepath.Join(m.dir, report.File), nil
+		}
+	}
+	return Report{}, "", fmt.Errorf("%w: %s", ErrReportNotFound, id)
+}
+
+// MarkFiled records that the report with id was filed under reference
+func (m *ReportManager) MarkFiled(id, reference string) (Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.reports {
+		if m.reports[i].ID != id {
+			continue
+		}
+		if m.reports[i].Status == ReportFiled {
+			return m.reports[i], fmt.Errorf("%w: %s", ErrReportFiled, id)
+		}
+		previous := m.reports[i]
+		now := m.now()
+		m.reports[i].Status, m.reports[i].FiledAt, m.reports[i].Reference = ReportFiled, &now, reference
+		if err := m.save(); err != nil {
+			m.reports[i] = previous
+			return previous, err
+		}
+		return m.reports[i], nil
+	}
+	return Report{}, fmt.Errorf("%w: %s", ErrReportNotFound, id)
+}
+
+// Run queues a summary every SummaryInterval until ctx is done
+func (m *ReportManager) Run(ctx context.Context, onError func(error)) {
+	if m.cfg.SummaryInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.cfg.SummaryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Summarize(); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Status reports the reports pending and filed by type
+func (m *ReportManager) Status() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pending := map[string]int{ReportSAR: 0, ReportSummary: 0}
+	filed := 0
+	for _, report := range m.reports {
+		if report.Status == ReportPending {
+			pending[report.Type]++
+		} else {
+			filed++
+		}
+	}
+	return map[string]interface{}{
+		"pending_sars":      pending[ReportSAR],
+		"pending_summaries": pending[ReportSummary],
+		"filed":             filed,
+	}
+}
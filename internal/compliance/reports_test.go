@@ -0,0 +1,92 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestReportManager(t *testing.T) {
+	dir := t.TempDir()
+	reports, err := NewReportManager(dir, ReportConfig{})
+	if err != nil {
+		t.Fatalf("NewReportManager() error = %v", err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reports.now = func() time.Time { return now }
+	reports.from = now
+
+	m := NewMonitor(MonitorConfig{
+		MaxOrderValue: 1000,
+		Jurisdiction:  "US",
+		Restricted:    map[string][]string{"US": {"XMR"}},
+	}, func(record AuditRecord) {
+		if err := reports.Observe(record); err != nil {
+			t.Fatalf("Observe() error = %v", err)
+		}
+	})
+	m.now = reports.now
+	client := m.Client(&recordingClient{})
+	for _, order := range []types.Order{
+		{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1, Price: 100},
+		{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1, Price: 5000}, // oversized, not suspicious
+		{Symbol: "XMRUSDT", Side: types.OrderSideBuy, Quantity: 2, Price: 150},  // restricted
+	} {
+		if err := client.PlaceOrder(context.Background(), order); err != nil {
+			t.Fatalf("PlaceOrder(%s) error = %v", order.Symbol, err)
+		}
+	}
+
+	pending := reports.Reports(ReportPending)
+	if len(pending) != 1 || pending[0].Type != ReportSAR {
+		t.Fatalf("pending = %+v, want one SAR", pending)
+	}
+	sar, path, err := reports.Open(pending[0].ID)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	body, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(body), "XMR is restricted in US") {
+		t.Errorf("SAR file = %q, %v", body, err)
+	}
+
+	now = now.Add(24 * time.Hour)
+	summary, err := reports.Summarize()
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	body, _ = os.ReadFile(filepath.Join(dir, summary.File))
+	for _, want := range []string{"Orders:         3 (5400.00)", "Flagged:        2 (5300.00)", "SARs queued:    1"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("summary lacks %q:\n%s", want, body)
+		}
+	}
+
+	if _, err := reports.MarkFiled(sar.ID, "FIN-1"); err != nil {
+		t.Fatalf("MarkFiled() error = %v", err)
+	}
+	if _, err := reports.MarkFiled(sar.ID, "FIN-1"); !errors.Is(err, ErrReportFiled) {
+		t.Errorf("second MarkFiled() error = %v, want ErrReportFiled", err)
+	}
+	if _, err := reports.MarkFiled("missing", ""); !errors.Is(err, ErrReportNotFound) {
+		t.Errorf("MarkFiled(missing) error = %v, want ErrReportNotFound", err)
+	}
+
+	// The queue and filing status survive a restart
+	reopened, err := NewReportManager(dir, ReportConfig{})
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	if pending := reopened.Reports(ReportPending); len(pending) != 1 || pending[0].ID != summary.ID {
+		t.Errorf("pending after restart = %+v, want the summary", pending)
+	}
+	if filed := reopened.Reports(ReportFiled); len(filed) != 1 || filed[0].Reference != "FIN-1" || filed[0].FiledAt == nil {
+		t.Errorf("filed after restart = %+v", filed)
+	}
+}
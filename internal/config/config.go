@@ -6,15 +6,61 @@ import (
 	"os"
 	"time"
 
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/ai"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/analytics"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/calendar"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/compliance"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/chaos"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/maintenance"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/marketdata"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/ratelimit"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/fleet"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
 // Config is the main application configuration
 type Config struct {
-	App      AppConfig      `json:"app"`
-	Exchange ExchangeConfig `json:"exchange"`
-	Strategy StrategyConfig `json:"strategy"`
-	Logging  LoggingConfig  `json:"logging"`
+	// SchemaVersion is the version of the file's schema; Load upgrades
+	// older files to the current SchemaVersion in memory
+	SchemaVersion int `json:"schema_version"`
+
+	// MigratedFrom is the schema version Load upgraded the file from, 0
+	// when it was current
+	MigratedFrom int `json:"-"`
+
+	App       AppConfig       `json:"app"`
+	Exchange  ExchangeConfig  `json:"exchange"`
+	Strategy  StrategyConfig  `json:"strategy"`
+	Logging   LoggingConfig   `json:"logging"`
+	Plugins   PluginConfig    `json:"plugins"`
+	Portfolio PortfolioConfig `json:"portfolio"`
+
+	// Calendar pauses new entries around scheduled events (disabled without events or a feed)
+	Calendar calendar.Config `json:"calendar"`
+
+	// Guard trips a circuit breaker on abnormal order activity (disabled without limits)
+	Guard risk.GuardConfig `json:"guard"`
+
+	// Restrictions blacklist symbols and limit the hours they are bought in,
+	// for every strategy or single ones; editable at runtime
+	Restrictions risk.RestrictionsConfig `json:"restrictions"`
+
+	// Compliance checks every order against size, velocity and jurisdiction
+	// rules and records the results in the audit log (disabled without checks)
+	Compliance compliance.MonitorConfig `json:"compliance"`
+
+	// MarketAnalytics serves the volatility, correlation and regime of symbols
+	// from the market data candles (needs exchange.market_data timeframes)
+	MarketAnalytics analytics.MarketConfig `json:"market_analytics"`
+
+	// Sentiment records the news sentiment of traded symbols over time and
+	// passes it to strategies (disabled without feeds)
+	Sentiment ai.SentimentConfig `json:"sentiment"`
+
+	// Shadow runs a candidate strategy config next to the live one (disabled without a candidate)
+	Shadow ShadowConfig `json:"shadow"`
 }
 
 // AppConfig describes application settings
@@ -23,6 +69,57 @@ type AppConfig struct {
 	Version string `json:"version"`
 	Port    int    `json:"port"`
 	Debug   bool   `json:"debug"`
+
+	// ReportingCurrency is the currency portfolio value is reported in (USD, EUR, BTC)
+	ReportingCurrency string `json:"reporting_currency"`
+
+	// StateDir holds persistent strategy state and the order journal (disabled when empty)
+	StateDir string `json:"state_dir"`
+
+	// ExchangeLockCheck refuses to start while the exchange holds open bot
+	// orders missing from the state dir's journal, i.e. placed by an instance
+	// running against another state dir (needs a state dir)
+	ExchangeLockCheck bool `json:"exchange_lock_check"`
+
+	// SkipPreflight starts the bot without checking that its symbols are
+	// listed and tradable and that grid ranges contain the current price
+	SkipPreflight bool `json:"skip_preflight"`
+
+	// APIToken authorizes manual order endpoints (disabled when empty)
+	APIToken string `json:"api_token"`
+
+	// Collector is where this instance pushes fleet metrics (disabled when its url is empty)
+	Collector fleet.Config `json:"collector"`
+
+	// StallThreshold is how long a trading loop may go without fetching
+	// market data or executing its strategy before /health reports it
+	// degraded (default five loop intervals)
+	StallThreshold time.Duration `json:"stall_threshold"`
+}
+
+// UnmarshalJSON implements custom parsing for the stall threshold ("10m")
+func (a *AppConfig) UnmarshalJSON(data []byte) error {
+	type Alias AppConfig
+	aux := &struct {
+		StallThreshold string `json:"stall_threshold"`
+		*Alias
+	}{
+		Alias: (*Alias)(a),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.StallThreshold != "" {
+		threshold, err := time.ParseDuration(aux.StallThreshold)
+		if err != nil {
+			return fmt.Errorf("invalid stall_threshold format: %w", err)
+		}
+		a.StallThreshold = threshold
+	}
+
+	return nil
 }
 
 // ExchangeConfig describes exchange settings
@@ -32,6 +129,66 @@ type ExchangeConfig struct {
 	SecretKey  string `json:"secret_key"`
 	Passphrase string `json:"passphrase"`
 	Sandbox    bool   `json:"sandbox"`
+
+	// Maintenance pauses trading during exchange downtime
+	Maintenance maintenance.Config `json:"maintenance"`
+
+	// RateLimit shares one request budget between the bot's consumers of the exchange
+	RateLimit ratelimit.Config `json:"rate_limit"`
+
+	// MarketData gives strategies higher-timeframe candles and indicators
+	MarketData marketdata.Config `json:"market_data"`
+
+	// Chaos injects latency, timeouts, rejections, duplicate fills and
+	// out-of-order updates into the paper exchange
+	Chaos chaos.Config `json:"chaos"`
+
+	// Accounts are further accounts or sub-accounts on the same exchange that
+	// strategies can be bound to; the credentials above are the main account
+	Accounts []AccountConfig `json:"accounts"`
+}
+
+// MainAccount names the account configured directly under exchange
+const MainAccount = "main"
+
+// AccountConfig describes an additional exchange account or sub-account
+type AccountConfig struct {
+	Name       string `json:"name"`
+	APIKey     string `json:"api_key"`
+	SecretKey  string `json:"secret_key"`
+	Passphrase string `json:"passphrase"`
+
+	// SubAccount identifies a sub-account traded with the main account's
+	// credentials when no keys of its own are given
+	SubAccount string `json:"sub_account"`
+
+	// RateLimit is the account's own request budget; exchanges limit
+	// requests per account, so accounts never share one
+	RateLimit ratelimit.Config `json:"rate_limit"`
+}
+
+// validateAccounts checks account names are set and unique
+func (e ExchangeConfig) validateAccounts() error {
+	seen := map[string]bool{MainAccount: true}
+	for i, account := range e.Accounts {
+		if account.Name == "" {
+			return fmt.Errorf("account %d: name is required", i)
+		}
+		if seen[account.Name] {
+			return fmt.Errorf("account %s: duplicate name (%q is the main account)", account.Name, MainAccount)
+		}
+		seen[account.Name] = true
+		if account.APIKey == "" && account.SubAccount == "" {
+			return fmt.Errorf("account %s: api key or sub_account is required", account.Name)
+		}
+		if (account.APIKey == "") != (account.SecretKey == "") {
+			return fmt.Errorf("account %s: api key and secret key must be set together", account.Name)
+		}
+		if err := account.RateLimit.Validate(); err != nil {
+			return fmt.Errorf("account %s rate limit: %w", account.Name, err)
+		}
+	}
+	return nil
 }
 
 // StrategyConfig groups strategy configurations
@@ -39,6 +196,151 @@ type StrategyConfig struct {
 	DCA   *types.DCAConfig   `json:"dca"`
 	Grid  *types.GridConfig  `json:"grid"`
 	Combo *types.ComboConfig `json:"combo"`
+
+	// Breakout runs a channel breakout strategy
+	Breakout *types.BreakoutConfig `json:"breakout"`
+
+	// Custom runs a plugin-provided strategy registered under Custom.Type
+	Custom *types.StrategyConfig `json:"custom"`
+}
+
+// ShadowConfig is a candidate strategy config, variant B, run next to the
+// live strategy, variant A. By default B runs in shadow mode: it gets the
+// live bot's market data and its orders are journaled, filled against the
+// live quotes, but never sent. With an allocation B is A/B tested: it trades
+// live on that share of the quote balance. A bot runs the candidate of its
+// own strategy type.
+type ShadowConfig struct {
+	ID       string         `json:"id"`       // state and journal id (default "<bot id>-shadow")
+	Days     int            `json:"days"`     // days until the comparison report is written (default 7)
+	Strategy StrategyConfig `json:"strategy"` // the candidate, in the strategy section's shape
+
+	// Allocation is the share of the quote balance B trades live with, e.g.
+	// 0.1 (default 0: shadow mode)
+	Allocation float64 `json:"allocation"`
+
+	// Interval is the period paired returns of A and B are compared over (default 24h)
+	Interval time.Duration `json:"interval"`
+
+	// Alpha is the significance level of the paired t-test (default 0.05)
+	Alpha float64 `json:"alpha"`
+}
+
+// UnmarshalJSON implements custom parsing for the return interval ("24h")
+func (s *ShadowConfig) UnmarshalJSON(data []byte) error {
+	type Alias ShadowConfig
+	aux := &struct {
+		Interval string `json:"interval"`
+		*Alias
+	}{
+		Alias: (*Alias)(s),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Interval != "" {
+		interval, err := time.ParseDuration(aux.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval format: %w", err)
+		}
+		s.Interval = interval
+	}
+	return nil
+}
+
+// Enabled reports whether a candidate strategy is configured
+func (s ShadowConfig) Enabled() bool {
+	c := s.Strategy
+	return c.DCA != nil || c.Grid != nil || c.Combo != nil || c.Custom != nil
+}
+
+// Validate checks the report period, allocation and test settings
+func (s ShadowConfig) Validate() error {
+	if s.Days < 0 {
+		return fmt.Errorf("days must not be negative")
+	}
+	if s.Allocation < 0 || s.Allocation >= 1 {
+		return fmt.Errorf("allocation must be at least 0 and below 1, got %v", s.Allocation)
+	}
+	if s.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+	if s.Alpha < 0 || s.Alpha >= 1 {
+		return fmt.Errorf("alpha must be at least 0 and below 1, got %v", s.Alpha)
+	}
+	return nil
+}
+
+// PluginConfig lists strategy plugins (Go plugins built with -buildmode=plugin)
+type PluginConfig struct {
+	Dir   string   `json:"dir"`   // every *.so file in Dir is loaded
+	Paths []string `json:"paths"` // additional plugin files
+}
+
+// PortfolioConfig describes portfolio settings
+type PortfolioConfig struct {
+	// Import seeds positions from existing exchange holdings at startup
+	Import portfolio.ImportConfig `json:"import"`
+
+	// Deleverage scales down buys as portfolio drawdown deepens
+	Deleverage risk.DeleverageConfig `json:"deleverage"`
+
+	// Dust converts balances too small to trade where the exchange supports it
+	Dust portfolio.DustConfig `json:"dust"`
+
+	// Rebalance trades the held assets back to target weights
+	Rebalance portfolio.RebalanceConfig `json:"rebalance"`
+
+	// Netting aggregates strategies' positions in the same symbol
+	Netting portfolio.NettingConfig `json:"netting"`
+
+	// SnapshotInterval is how often equity is appended to the state dir's
+	// equity curve (default 1h; recorded only when a state dir is set)
+	SnapshotInterval time.Duration `json:"snapshot_interval"`
+
+	// PerformanceWindow is the trailing span of the equity curve live
+	// strategy metrics compute Sharpe, Sortino, Calmar and drawdown over
+	// (default 90 days)
+	PerformanceWindow time.Duration `json:"performance_window"`
+
+	// Benchmark is an asset, e.g. BTC, whose price is recorded with every
+	// equity snapshot so performance is also reported in units of it
+	Benchmark string `json:"benchmark"`
+}
+
+// UnmarshalJSON implements custom parsing for durations ("15m", "1h")
+func (p *PortfolioConfig) UnmarshalJSON(data []byte) error {
+	type Alias PortfolioConfig
+	aux := &struct {
+		SnapshotInterval  string `json:"snapshot_interval"`
+		PerformanceWindow string `json:"performance_window"`
+		*Alias
+	}{
+		Alias: (*Alias)(p),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.SnapshotInterval != "" {
+		interval, err := time.ParseDuration(aux.SnapshotInterval)
+		if err != nil {
+			return fmt.Errorf("invalid snapshot_interval format: %w", err)
+		}
+		p.SnapshotInterval = interval
+	}
+	if aux.PerformanceWindow != "" {
+		window, err := time.ParseDuration(aux.PerformanceWindow)
+		if err != nil {
+			return fmt.Errorf("invalid performance_window format: %w", err)
+		}
+		p.PerformanceWindow = window
+	}
+
+	return nil
 }
 
 // LoggingConfig describes logging configuration
@@ -46,20 +348,77 @@ type LoggingConfig struct {
 	Level  string `json:"level"`
 	File   string `json:"file"`
 	Format string `json:"format"`
+
+	// Rotation of the log file; zero values disable each limit
+	MaxSizeMB  int           `json:"max_size_mb"`
+	MaxAge     time.Duration `json:"max_age"`
+	MaxBackups int           `json:"max_backups"`
+
+	// Components overrides the level of named loggers and those nested
+	// under them, e.g. {"grid": "debug", "combo.dca": "warn"}
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// UnmarshalJSON implements custom parsing for the rotation age ("24h")
+func (l *LoggingConfig) UnmarshalJSON(data []byte) error {
+	type Alias LoggingConfig
+	aux := &struct {
+		MaxAge string `json:"max_age"`
+		*Alias
+	}{
+		Alias: (*Alias)(l),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.MaxAge != "" {
+		age, err := time.ParseDuration(aux.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid max_age format: %w", err)
+		}
+		l.MaxAge = age
+	}
+
+	return nil
+}
+
+// Validate checks the rotation limits and component levels
+func (l LoggingConfig) Validate() error {
+	if l.MaxSizeMB < 0 || l.MaxAge < 0 || l.MaxBackups < 0 {
+		return fmt.Errorf("rotation limits must not be negative")
+	}
+	for component, level := range l.Components {
+		switch level {
+		case "debug", "info", "warn", "error":
+		default:
+			return fmt.Errorf("component %s: unknown level %q", component, level)
+		}
+	}
+	return nil
 }
 
 // Load reads configuration from a JSON file
 func Load(filename string) (*Config, error) {
-	file, err := os.Open(filename)
+	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
-	defer file.Close()
+
+	// Older files are upgraded first, so fields they encoded differently decode
+	migrated, err := Migrate(data)
+	if err != nil {
+		return nil, err
+	}
 
 	var config Config
-	if err := json.NewDecoder(file).Decode(&config); err != nil {
+	if err := json.Unmarshal(migrated.Data, &config); err != nil {
 		return nil, fmt.Errorf("failed to decode config: %w", err)
 	}
+	if migrated.From < SchemaVersion {
+		config.MigratedFrom = migrated.From
+	}
 
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
@@ -71,11 +430,24 @@ func Load(filename string) (*Config, error) {
 // LoadFromEnv populates configuration from environment variables
 func LoadFromEnv() *Config {
 	return &Config{
+		SchemaVersion: SchemaVersion,
 		App: AppConfig{
 			Name:    getEnv("APP_NAME", "crypto-trading-bot"),
 			Version: getEnv("APP_VERSION", "1.0.0"),
 			Port:    getEnvAsInt("APP_PORT", 8080),
 			Debug:   getEnvAsBool("APP_DEBUG", false),
+
+			ReportingCurrency: getEnv("REPORTING_CURRENCY", "USD"),
+			StateDir:          getEnv("STATE_DIR", ""),
+			ExchangeLockCheck: getEnvAsBool("EXCHANGE_LOCK_CHECK", false),
+			SkipPreflight:     getEnvAsBool("SKIP_PREFLIGHT", false),
+			APIToken:          getEnv("API_TOKEN", ""),
+			Collector: fleet.Config{
+				URL:      getEnv("COLLECTOR_URL", ""),
+				Instance: getEnv("INSTANCE_NAME", ""),
+				Token:    getEnv("COLLECTOR_TOKEN", ""),
+			},
+			StallThreshold: getEnvAsDuration("STALL_THRESHOLD", 0),
 		},
 		Exchange: ExchangeConfig{
 			Name:       getEnv("EXCHANGE_NAME", "binance"),
@@ -93,13 +465,20 @@ func LoadFromEnv() *Config {
 				PriceThreshold:   getEnvAsFloat("DCA_PRICE_THRESHOLD", 0.0),
 				StopLoss:         getEnvAsFloat("DCA_STOP_LOSS", 0.0),
 				TakeProfit:       getEnvAsFloat("DCA_TAKE_PROFIT", 0.0),
+				Mode:             getEnv("DCA_MODE", ""),
 				Enabled:          getEnvAsBool("DCA_ENABLED", true),
 			},
 		},
+		Plugins: PluginConfig{
+			Dir: getEnv("PLUGIN_DIR", ""),
+		},
 		Logging: LoggingConfig{
-			Level:  getEnv("LOG_LEVEL", "info"),
-			File:   getEnv("LOG_FILE", ""),
-			Format: getEnv("LOG_FORMAT", "text"),
+			Level:      getEnv("LOG_LEVEL", "info"),
+			File:       getEnv("LOG_FILE", ""),
+			Format:     getEnv("LOG_FORMAT", "text"),
+			MaxSizeMB:  getEnvAsInt("LOG_MAX_SIZE_MB", 0),
+			MaxAge:     getEnvAsDuration("LOG_MAX_AGE", 0),
+			MaxBackups: getEnvAsInt("LOG_MAX_BACKUPS", 0),
 		},
 	}
 }
@@ -122,23 +501,119 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("exchange secret key is required")
 	}
 
+	if err := c.Exchange.Maintenance.Validate(); err != nil {
+		return fmt.Errorf("exchange maintenance: %w", err)
+	}
+
+	if err := c.Exchange.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("exchange rate limit: %w", err)
+	}
+
+	if err := c.Exchange.MarketData.Validate(); err != nil {
+		return fmt.Errorf("exchange market data: %w", err)
+	}
+
+	if err := c.Exchange.Chaos.Validate(); err != nil {
+		return fmt.Errorf("exchange chaos: %w", err)
+	}
+
+	if err := c.Exchange.validateAccounts(); err != nil {
+		return fmt.Errorf("exchange accounts: %w", err)
+	}
+
+	if c.App.ExchangeLockCheck && c.App.StateDir == "" {
+		return fmt.Errorf("app exchange lock check needs a state dir")
+	}
+
+	if c.Shadow.Enabled() && c.App.StateDir == "" {
+		return fmt.Errorf("shadow mode needs a state dir")
+	}
+
+	if err := c.App.Collector.Validate(); err != nil {
+		return fmt.Errorf("app collector: %w", err)
+	}
+
+	if c.App.StallThreshold < 0 {
+		return fmt.Errorf("app stall threshold must not be negative")
+	}
+
+	if err := c.Logging.Validate(); err != nil {
+		return fmt.Errorf("logging: %w", err)
+	}
+
+	if c.Portfolio.SnapshotInterval < 0 {
+		return fmt.Errorf("portfolio snapshot interval must not be negative")
+	}
+
+	if err := c.Portfolio.Deleverage.Validate(); err != nil {
+		return fmt.Errorf("portfolio deleverage: %w", err)
+	}
+
+	if err := c.Portfolio.Dust.Validate(); err != nil {
+		return fmt.Errorf("portfolio dust: %w", err)
+	}
+
+	if err := c.Portfolio.Rebalance.Validate(); err != nil {
+		return fmt.Errorf("portfolio rebalance: %w", err)
+	}
+
+	if err := c.Portfolio.Netting.Validate(); err != nil {
+		return fmt.Errorf("portfolio netting: %w", err)
+	}
+
+	if err := c.Calendar.Validate(); err != nil {
+		return fmt.Errorf("calendar: %w", err)
+	}
+
+	if err := c.Guard.Validate(); err != nil {
+		return fmt.Errorf("guard: %w", err)
+	}
+
+	if err := c.Restrictions.Validate(); err != nil {
+		return fmt.Errorf("restrictions: %w", err)
+	}
+
+	if err := c.Compliance.Validate(); err != nil {
+		return fmt.Errorf("compliance: %w", err)
+	}
+
+	if err := c.MarketAnalytics.Validate(); err != nil {
+		return fmt.Errorf("market analytics: %w", err)
+	}
+	if interval := c.MarketAnalytics.Interval; interval != "" && !c.Exchange.MarketData.HasInterval(interval) {
+		return fmt.Errorf("market analytics: interval %s is not an exchange.market_data timeframe", interval)
+	}
+
+	if err := c.Sentiment.Validate(); err != nil {
+		return fmt.Errorf("sentiment: %w", err)
+	}
+
+	if err := c.Shadow.Validate(); err != nil {
+		return fmt.Errorf("shadow: %w", err)
+	}
+
 	return nil
 }
 
-// Save writes configuration to a JSON file
+// Save writes configuration to a JSON file in the current schema
 func (c *Config) Save(filename string) error {
-	file, err := os.Create(filename)
+	data, err := json.Marshal(c)
 	if err != nil {
-		return fmt.Errorf("failed to create config file: %w", err)
+		return fmt.Errorf("failed to encode config: %w", err)
 	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(c); err != nil {
+	var file map[string]interface{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	stringDurations(file)
+	file["schema_version"] = SchemaVersion
+	if data, err = json.MarshalIndent(file, "", "  "); err != nil {
 		return fmt.Errorf("failed to encode config: %w", err)
 	}
 
+	if err := os.WriteFile(filename, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to create config file: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SchemaVersion is the config file schema this build reads and writes.
+// Files without schema_version are version 1.
+const SchemaVersion = 2
+
+// ErrNewerSchema reports a config file or state dir written by a newer build
+var ErrNewerSchema = errors.New("schema is newer than this build supports")
+
+// Migration upgrades a decoded config file to version To from the one before
+type Migration struct {
+	To          int
+	Description string
+
+	// Apply rewrites file in place and describes each change it made
+	Apply func(file map[string]interface{}) []string
+}
+
+// migrations are applied in order; a schema change appends one and bumps
+// SchemaVersion
+var migrations = []Migration{
+	{To: 2, Description: "write durations as strings (\"24h\") instead of nanoseconds", Apply: stringDurations},
+}
+
+// Migrated is a config file upgraded to SchemaVersion
+type Migrated struct {
+	From    int      // version of the file read
+	Changes []string // one line per rewritten value, prefixed with its migration
+	Data    []byte   // the upgraded file
+}
+
+// Migrate upgrades config file data to SchemaVersion. Data already at
+// SchemaVersion is returned as is; upgraded files are re-encoded with keys in
+// sorted order.
+func Migrate(data []byte) (*Migrated, error) {
+	var file map[string]interface{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	from, err := fileVersion(file)
+	if err != nil {
+		return nil, err
+	}
+	if from > SchemaVersion {
+		return nil, fmt.Errorf("config %w: version %d, supported %d", ErrNewerSchema, from, SchemaVersion)
+	}
+	result := &Migrated{From: from, Data: data}
+	if from == SchemaVersion {
+		return result, nil
+	}
+
+	for _, m := range migrations {
+		if m.To <= from {
+			continue
+		}
+		for _, change := range m.Apply(file) {
+			result.Changes = append(result.Changes, fmt.Sprintf("v%d: %s", m.To, change))
+		}
+	}
+	file["schema_version"] = SchemaVersion
+	if result.Data, err = json.MarshalIndent(file, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	result.Data = append(result.Data, '\n')
+	return result, nil
+}
+
+// fileVersion returns the schema_version of a decoded config file
+func fileVersion(file map[string]interface{}) (int, error) {
+	raw, ok := file["schema_version"]
+	if !ok {
+		return 1, nil
+	}
+	version, ok := raw.(float64)
+	if !ok || version < 1 || version != float64(int(version)) {
+		return 0, fmt.Errorf("invalid schema_version %v", raw)
+	}
+	return int(version), nil
+}
+
+// durationKeys are the keys whose values are parsed as duration strings.
+// Durations encoded by Save before version 2 were integer nanoseconds,
+// which those parsers reject.
+var durationKeys = map[string]bool{
+	"interval": true, "stall_threshold": true, "snapshot_interval": true, "performance_window": true,
+	"max_age": true, "summary_interval": true, "velocity_window": true, "batch_delay": true,
+	"vwap_window": true, "refresh": true, "lead": true, "chase_timeout": true,
+	"min_interval": true, "loss_cooldown": true, "long_term_after": true, "momentum_window": true,
+	"flip_window": true, "cooldown": true, "latency": true, "jitter": true, "poll_interval": true,
+	"backoff": true, "max_backoff": true, "before": true, "after": true,
+}
+
+// builtinTypes are the strategy types whose config maps use durationKeys;
+// plugin configs are left alone
+var builtinTypes = map[string]bool{"dca": true, "grid": true, "breakout": true}
+
+// stringDurations rewrites numeric durations as duration strings
+func stringDurations(file map[string]interface{}) []string {
+	var changes []string
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			strategyType, typed := v["type"].(string)
+			for key, child := range v {
+				if key == "config" && typed && !builtinTypes[strategyType] {
+					continue
+				}
+				if n, ok := child.(float64); ok && durationKeys[key] {
+					v[key] = time.Duration(n).String()
+					changes = append(changes, fmt.Sprintf("%s%s: %.0f -> %q", path, key, n, v[key]))
+					continue
+				}
+				walk(path+key+".", child)
+			}
+		case []interface{}:
+			for i, child := range v {
+				walk(fmt.Sprintf("%s%d.", path, i), child)
+			}
+		}
+	}
+	walk("", file)
+	sort.Strings(changes)
+	return changes
+}
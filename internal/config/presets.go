@@ -0,0 +1,115 @@
+package config
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+)
+
+//go:embed presets/*.json
+var presetFiles embed.FS
+
+// Preset is a ready-made strategy setup that new users start from
+type Preset struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Strategy    string   `json:"strategy"` // "dca" or "grid"
+	Symbols     []string `json:"symbols"`  // pairs the preset suits; the first is the default
+
+	// Band places grid bounds at price × (1 ± Band)
+	Band float64 `json:"band,omitempty"`
+
+	// Config is the config file without the symbol and grid bounds
+	Config json.RawMessage `json:"config"`
+}
+
+// Presets returns the built-in presets sorted by name
+func Presets() ([]Preset, error) {
+	entries, err := presetFiles.ReadDir("presets")
+	if err != nil {
+		return nil, err
+	}
+	presets := make([]Preset, 0, len(entries))
+	for _, entry := range entries {
+		data, err := presetFiles.ReadFile(path.Join("presets", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var preset Preset
+		if err := json.Unmarshal(data, &preset); err != nil {
+			return nil, fmt.Errorf("preset %s: %w", entry.Name(), err)
+		}
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+// LookupPreset returns the built-in preset called name
+func LookupPreset(name string) (Preset, error) {
+	presets, err := Presets()
+	if err != nil {
+		return Preset{}, err
+	}
+	for _, preset := range presets {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q", name)
+}
+
+// Render returns the preset's config file for symbol, ready to edit. Grid
+// presets center their bounds on price, which other presets ignore. The
+// config is checked to load, but keeps placeholder API keys.
+func (p Preset) Render(symbol string, price float64) ([]byte, *Config, error) {
+	if symbol == "" {
+		return nil, nil, fmt.Errorf("symbol is required")
+	}
+
+	var file map[string]interface{}
+	if err := json.Unmarshal(p.Config, &file); err != nil {
+		return nil, nil, fmt.Errorf("preset %s: %w", p.Name, err)
+	}
+	strategies, _ := file["strategy"].(map[string]interface{})
+	settings, ok := strategies[p.Strategy].(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("preset %s has no %s strategy", p.Name, p.Strategy)
+	}
+	settings["symbol"] = symbol
+
+	if p.Strategy == "grid" {
+		if price <= 0 {
+			return nil, nil, fmt.Errorf("grid presets need the current price of %s", symbol)
+		}
+		if p.Band <= 0 || p.Band >= 1 {
+			return nil, nil, fmt.Errorf("preset %s: band must be within (0, 1)", p.Name)
+		}
+		settings["lower_price"] = significant(price * (1 - p.Band))
+		settings["upper_price"] = significant(price * (1 + p.Band))
+	}
+
+	file["schema_version"] = SchemaVersion
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("preset %s: %w", p.Name, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("preset %s: %w", p.Name, err)
+	}
+	return append(data, '\n'), &config, nil
+}
+
+// significant rounds a price to six significant digits
+func significant(price float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(price, 'g', 6, 64), 64)
+	return rounded
+}
@@ -4,26 +4,101 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
 type CrossChainArbitrageEngine struct {
 	bridges      map[string]Bridge
 	dexes        map[string]*DEXClient
-	flashLoaners map[string]*FlashLoanProvider
+	flashLoaners map[string]FlashLoanProvider
 	gasTracker   *GasTracker
+	liquidity    *LiquidityChecker
 
 	// Concurrent execution
 	executor *CrossChainExecutor
 	mutex    sync.RWMutex
 }
 
+// DEXClient trades one chain's tokens. With Exchange set, e.g. a
+// uniswap.Client, each leg is a market swap of the token against Quote, so
+// an exchange configured with a private or bundle endpoint simulates the
+// swap and keeps it out of the public mempool. Without one it fills at mock
+// prices.
 type DEXClient struct {
-	// DEX client functionality
+	Exchange types.ExchangeClient
+	Quote    string // token the legs trade against, e.g. "USDC"
+}
+
+// swapSeq disambiguates client order ids of swaps placed within one nanosecond
+var swapSeq atomic.Int64
+
+// SetDEX trades chain's legs through dex
+func (ace *CrossChainArbitrageEngine) SetDEX(chain string, dex *DEXClient) {
+	ace.mutex.Lock()
+	defer ace.mutex.Unlock()
+	if ace.dexes == nil {
+		ace.dexes = make(map[string]*DEXClient)
+	}
+	ace.dexes[chain] = dex
 }
 
-// BuyToken buys tokens on a DEX
+// swap places a market order at the quoted price and returns it as filled.
+// Buys spend amount of the quote token and sells sell amount of the token.
+// A swap the exchange did not fill at once, such as one not yet mined,
+// fails the leg.
+func (dc *DEXClient) swap(ctx context.Context, side types.OrderSide, token string, amount float64) (*types.Order, error) {
+	symbol := token + dc.Quote
+	ticker, err := dc.Exchange.GetTicker(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote %s: %w", symbol, err)
+	}
+	price := ticker.Bid
+	if side == types.OrderSideBuy {
+		price = ticker.Ask
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("no %s price for %s", side, symbol)
+	}
+	quantity := amount
+	if side == types.OrderSideBuy {
+		quantity = amount / price
+	}
+
+	clientOrderID := types.NewClientOrderID("crosschain", "x", swapSeq.Add(1))
+	order := types.Order{Symbol: symbol, Side: side, Type: types.OrderTypeMarket, Quantity: quantity, Price: price,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: clientOrderID}}
+	if err := dc.Exchange.PlaceOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	placed, err := dc.Exchange.GetOrderByClientID(ctx, symbol, clientOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s swap: %w", symbol, err)
+	}
+	if placed.Status != types.OrderStatusFilled {
+		return nil, fmt.Errorf("%s swap %s is %s", symbol, placed.ID, placed.Status)
+	}
+	filled := *placed
+	if filled.FilledAmount == 0 {
+		filled.FilledAmount = quantity
+	}
+	if filled.FilledPrice == 0 {
+		filled.FilledPrice = price
+	}
+	return &filled, nil
+}
+
+// BuyToken spends amount of the quote token on the token
 func (dc *DEXClient) BuyToken(ctx context.Context, token string, amount float64) (*Transaction, error) {
+	if dc.Exchange != nil {
+		order, err := dc.swap(ctx, types.OrderSideBuy, token, amount)
+		if err != nil {
+			return nil, err
+		}
+		return &Transaction{ID: order.ID, TokenAmount: order.FilledAmount, ReceivedAmount: order.FilledAmount, Timestamp: time.Now()}, nil
+	}
 	return &Transaction{
 		ID:             fmt.Sprintf("buy_%s_%d", token, time.Now().Unix()),
 		TokenAmount:    amount / 45000.0, // Mock price
@@ -32,8 +107,16 @@ func (dc *DEXClient) BuyToken(ctx context.Context, token string, amount float64)
 	}, nil
 }
 
-// SellToken sells tokens on a DEX
+// SellToken sells amount of the token for the quote token
 func (dc *DEXClient) SellToken(ctx context.Context, token string, amount float64) (*Transaction, error) {
+	if dc.Exchange != nil {
+		order, err := dc.swap(ctx, types.OrderSideSell, token, amount)
+		if err != nil {
+			return nil, err
+		}
+		return &Transaction{ID: order.ID, TokenAmount: order.FilledAmount,
+			ReceivedAmount: order.FilledAmount * order.FilledPrice, Timestamp: time.Now()}, nil
+	}
 	return &Transaction{
 		ID:             fmt.Sprintf("sell_%s_%d", token, time.Now().Unix()),
 		TokenAmount:    amount,
@@ -42,41 +125,35 @@ func (dc *DEXClient) SellToken(ctx context.Context, token string, amount float64
 	}, nil
 }
 
-type FlashLoanProvider struct {
-	// Flash loan provider functionality
-}
-
 type FlashLoan struct {
 	Principal float64   `json:"principal"`
 	Fee       float64   `json:"fee"`
 	Token     string    `json:"token"`
 	Timestamp time.Time `json:"timestamp"`
+
+	TxHash string  `json:"tx_hash,omitempty"` // the borrowing transaction, on chain
+	Profit float64 `json:"profit"`            // expected, after repaying the loan and fee
 }
 
-// RequestLoan requests a flash loan
-func (flp *FlashLoanProvider) RequestLoan(ctx context.Context, token string, amount float64) (*FlashLoan, error) {
-	return &FlashLoan{
-		Principal: amount,
-		Fee:       amount * 0.0009, // 0.09% fee
-		Token:     token,
-		Timestamp: time.Now(),
-	}, nil
+// MockFlashLoanProvider lends at a flat 0.09% without touching a chain
+type MockFlashLoanProvider struct{}
+
+// Fee returns 0.09% of amount
+func (MockFlashLoanProvider) Fee(ctx context.Context, token string, amount float64) (float64, error) {
+	return amount * 0.0009, nil
 }
 
-// RepayLoan repays a flash loan
-func (flp *FlashLoanProvider) RepayLoan(ctx context.Context, loan *FlashLoan) error {
-	// Mock implementation
-	return nil
+// Execute records the loan without sending anything
+func (m MockFlashLoanProvider) Execute(ctx context.Context, req FlashLoanRequest) (*FlashLoan, error) {
+	fee, _ := m.Fee(ctx, req.Token, req.Amount)
+	return &FlashLoan{Principal: req.Amount, Fee: fee, Token: req.Token, Timestamp: time.Now(),
+		Profit: req.ExpectedProceeds - req.Amount - fee}, nil
 }
 
 type GasTracker struct {
 	// Gas tracking functionality
 }
 
-type CrossChainExecutor struct {
-	// Cross-chain execution functionality
-}
-
 type TransferReceipt struct {
 	ID        string    `json:"id"`
 	Status    string    `json:"status"`
@@ -91,7 +168,10 @@ type ArbitrageResult struct {
 	BridgeTransaction *TransferReceipt `json:"bridge_transaction"`
 	SellTransaction   *Transaction     `json:"sell_transaction"`
 	NetProfit         float64          `json:"net_profit"`
-	Success           bool             `json:"success"`
+	Success           bool             `json:"success"` // no leg failed
+
+	// Legs are the on-chain outcomes of buy, bridge and sell, in order
+	Legs []LegResult `json:"legs"`
 }
 
 type Transaction struct {
@@ -121,6 +201,7 @@ type ArbitrageOpportunity struct {
 	Risks           []string           `json:"risks"`
 	ExecutionTime   time.Duration      `json:"execution_time"`
 	GasFees         map[string]float64 `json:"gas_fees"`
+	Liquidity       *LiquidityEstimate `json:"liquidity,omitempty"`
 }
 
 // analyzeOpportunity analyzes a single arbitrage opportunity
@@ -169,6 +250,8 @@ func (ace *CrossChainArbitrageEngine) ScanArbitrageOpportunities(
 	var wg sync.WaitGroup
 	opsChan := make(chan ArbitrageOpportunity, 100)
 
+	checker := ace.liquidityChecker()
+
 	// Parallel scan of all chain pairs
 	for _, token := range tokens {
 		for buyChain := range ace.dexes {
@@ -182,9 +265,15 @@ func (ace *CrossChainArbitrageEngine) ScanArbitrageOpportunities(
 					defer wg.Done()
 
 					opp := ace.analyzeOpportunity(ctx, token, buy, sell)
-					if opp.ProfitMargin > ace.getMinProfitThreshold() {
-						opsChan <- opp
+					if opp.ProfitMargin <= ace.getMinProfitThreshold() {
+						return
+					}
+					if checker != nil {
+						if ok, err := checker.Check(ctx, &opp); err != nil || !ok {
+							return
+						}
 					}
+					opsChan <- opp
 				}(token, buyChain, sellChain)
 			}
 		}
@@ -210,8 +299,21 @@ func (ace *CrossChainArbitrageEngine) ExecuteArbitrage(
 	opportunity ArbitrageOpportunity,
 ) (*ArbitrageResult, error) {
 
-	// Obtain a flash loan for initial capital
-	flashLoan, err := ace.flashLoaners[opportunity.BuyChain].RequestLoan(
+	// Re-check depth right before trading; the size may shrink
+	if checker := ace.liquidityChecker(); checker != nil {
+		ok, err := checker.Check(ctx, &opportunity)
+		if err != nil {
+			return nil, fmt.Errorf("liquidity check failed: %w", err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("opportunity %s rejected: %s", opportunity.ID, opportunity.Liquidity.Reason)
+		}
+	}
+
+	// Price the initial capital as a flash loan. A loan cannot outlive its
+	// transaction, so across a bridge it only prices the capital; atomic
+	// single-chain plans go through FlashLoanProvider.Execute.
+	fee, err := ace.flashLoaners[opportunity.BuyChain].Fee(
 		ctx,
 		opportunity.TokenSymbol,
 		opportunity.RequiredCapital,
@@ -219,6 +321,12 @@ func (ace *CrossChainArbitrageEngine) ExecuteArbitrage(
 	if err != nil {
 		return nil, fmt.Errorf("flash loan failed: %w", err)
 	}
+	flashLoan := &FlashLoan{
+		Principal: opportunity.RequiredCapital,
+		Fee:       fee,
+		Token:     opportunity.TokenSymbol,
+		Timestamp: time.Now(),
+	}
 
 	// Execute arbitrage within a single transaction
 	result := &ArbitrageResult{
@@ -233,9 +341,14 @@ func (ace *CrossChainArbitrageEngine) ExecuteArbitrage(
 		opportunity.RequiredCapital,
 	)
 	if err != nil {
+		failLeg(result, "buy", opportunity.BuyChain, err)
 		return result, fmt.Errorf("buy failed: %w", err)
 	}
 	result.BuyTransaction = buyTx
+	if !ace.confirmLeg(ctx, result, "buy", opportunity.BuyChain, buyTx.ID) {
+		skipLegs(result)
+		return result, legError(result)
+	}
 
 	// Step 2: Bridge tokens to the destination chain
 	bridgeTx, err := ace.bridges[opportunity.BuyChain].Transfer(
@@ -246,9 +359,14 @@ func (ace *CrossChainArbitrageEngine) ExecuteArbitrage(
 		opportunity.SellChain,
 	)
 	if err != nil {
+		failLeg(result, "bridge", opportunity.BuyChain, err)
 		return result, fmt.Errorf("bridge failed: %w", err)
 	}
 	result.BridgeTransaction = bridgeTx
+	if !ace.confirmLeg(ctx, result, "bridge", opportunity.BuyChain, bridgeTx.ID) {
+		skipLegs(result)
+		return result, legError(result)
+	}
 
 	// Step 3: Sell token on destination chain
 	sellTx, err := ace.dexes[opportunity.SellChain].SellToken(
@@ -257,21 +375,22 @@ func (ace *CrossChainArbitrageEngine) ExecuteArbitrage(
 		buyTx.TokenAmount,
 	)
 	if err != nil {
+		failLeg(result, "sell", opportunity.SellChain, err)
 		return result, fmt.Errorf("sell failed: %w", err)
 	}
 	result.SellTransaction = sellTx
+	if !ace.confirmLeg(ctx, result, "sell", opportunity.SellChain, sellTx.ID) {
+		skipLegs(result)
+		return result, legError(result)
+	}
 
-	// Step 4: Repay flash loan
+	// Step 4: Cover the capital and its fee
 	repayment := flashLoan.Principal + flashLoan.Fee
 	if sellTx.ReceivedAmount < repayment {
+		result.EndTime = time.Now()
 		return result, fmt.Errorf("insufficient funds to repay flash loan")
 	}
 
-	err = ace.flashLoaners[opportunity.BuyChain].RepayLoan(ctx, flashLoan)
-	if err != nil {
-		return result, fmt.Errorf("loan repayment failed: %w", err)
-	}
-
 	result.NetProfit = sellTx.ReceivedAmount - repayment
 	result.EndTime = time.Now()
 	result.Success = true
@@ -0,0 +1,60 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// revertingExchange fails every order, like a swap failing simulation
+type revertingExchange struct {
+	*mock.MockClient
+}
+
+var errSimulation = errors.New("reverted in simulation")
+
+func (revertingExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	return errSimulation
+}
+
+func TestCrossChainArbitrageEngine_DEXLegs(t *testing.T) {
+	engine := &CrossChainArbitrageEngine{
+		bridges:      map[string]Bridge{"ethereum": fakeBridge{}},
+		flashLoaners: map[string]FlashLoanProvider{"ethereum": MockFlashLoanProvider{}},
+	}
+	buyExchange, sellExchange := mock.NewMockClient(), mock.NewMockClient()
+	engine.SetDEX("ethereum", &DEXClient{Exchange: buyExchange, Quote: "USDT"})
+	engine.SetDEX("arbitrum", &DEXClient{Exchange: sellExchange, Quote: "USDT"})
+	opp := ArbitrageOpportunity{ID: "op", TokenSymbol: "BTC", BuyChain: "ethereum", SellChain: "arbitrum", RequiredCapital: 1000}
+
+	// The mock quotes 44999/45001 on both chains, so the trade loses the spread
+	result, err := engine.ExecuteArbitrage(context.Background(), opp)
+	if err == nil || result.BuyTransaction == nil || result.SellTransaction == nil {
+		t.Fatalf("ExecuteArbitrage() = %+v, %v, want both legs traded and a shortfall", result, err)
+	}
+	bought := 1000 / 45001.0
+	if buy := result.BuyTransaction; math.Abs(buy.TokenAmount-bought) > 1e-12 {
+		t.Errorf("bought %v BTC, want %v", buy.TokenAmount, bought)
+	}
+	if sell := result.SellTransaction; math.Abs(sell.ReceivedAmount-bought*44999) > 1e-9 {
+		t.Errorf("sold for %v USDT, want %v", sell.ReceivedAmount, bought*44999)
+	}
+	// Each leg is the exchange's order
+	if filled, _ := buyExchange.GetFilledOrders(context.Background(), "BTCUSDT"); len(filled) != 1 || filled[0].ID != result.BuyTransaction.ID {
+		t.Errorf("buy leg %s, exchange filled %+v", result.BuyTransaction.ID, filled)
+	}
+	if filled, _ := sellExchange.GetFilledOrders(context.Background(), "BTCUSDT"); len(filled) != 1 || filled[0].Side != types.OrderSideSell {
+		t.Errorf("sell exchange filled %+v, want one sell", filled)
+	}
+
+	// A swap the exchange rejects fails its leg before anything is bridged
+	engine.SetDEX("ethereum", &DEXClient{Exchange: revertingExchange{mock.NewMockClient()}, Quote: "USDT"})
+	result, err = engine.ExecuteArbitrage(context.Background(), opp)
+	if !errors.Is(err, errSimulation) || len(result.Legs) != 3 || result.Legs[0].Status != LegDropped || result.Legs[1].Status != LegSkipped {
+		t.Fatalf("ExecuteArbitrage() = %+v, %v, want the buy leg failed and the rest skipped", result, err)
+	}
+}
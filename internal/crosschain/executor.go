@@ -0,0 +1,301 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTxNotFound reports a transaction a node neither included nor holds in
+// its mempool
+var ErrTxNotFound = errors.New("transaction not found")
+
+// ErrLegFailed reports an arbitrage leg that did not confirm
+var ErrLegFailed = errors.New("arbitrage leg failed")
+
+// TxReceipt is a transaction's inclusion in a block
+type TxReceipt struct {
+	BlockNumber uint64
+	BlockHash   string
+	Reverted    bool
+}
+
+// ChainClient reads and replaces transactions on one chain
+type ChainClient interface {
+	// Receipt returns the transaction's inclusion, nil while it is pending,
+	// or ErrTxNotFound once the node no longer knows it
+	Receipt(ctx context.Context, txHash string) (*TxReceipt, error)
+	// BlockNumber returns the head of the canonical chain
+	BlockNumber(ctx context.Context) (uint64, error)
+	// BlockHash returns the hash of the canonical block at height
+	BlockHash(ctx context.Context, height uint64) (string, error)
+	// Resubmit sends the transaction again with the same nonce and its gas
+	// price multiplied by gasBump, returning the replacement's hash
+	Resubmit(ctx context.Context, txHash string, gasBump float64) (string, error)
+}
+
+// LegStatus is the outcome of one arbitrage leg
+type LegStatus string
+
+const (
+	LegSubmitted LegStatus = "submitted" // sent, not tracked without an executor
+	LegConfirmed LegStatus = "confirmed"
+	LegReverted  LegStatus = "reverted"
+	LegDropped   LegStatus = "dropped"
+	LegTimedOut  LegStatus = "timed_out"
+	LegSkipped   LegStatus = "skipped" // not sent because an earlier leg failed
+)
+
+// LegResult is what happened to one arbitrage leg on chain
+type LegResult struct {
+	Leg           string    `json:"leg"` // buy, bridge or sell
+	Chain         string    `json:"chain"`
+	TxHash        string    `json:"tx_hash,omitempty"` // the hash that was included, or the last sent
+	Status        LegStatus `json:"status"`
+	BlockNumber   uint64    `json:"block_number,omitempty"`
+	Confirmations uint64    `json:"confirmations"`
+	Resubmissions int       `json:"resubmissions"`
+	Reorgs        int       `json:"reorgs"` // times the inclusion was reorged out
+	Error         string    `json:"error,omitempty"`
+}
+
+// ExecutorConfig tunes transaction monitoring
+type ExecutorConfig struct {
+	Confirmations map[string]uint64 // blocks required by chain (default 12)
+	PollInterval  time.Duration     // default 5s
+	StuckAfter    time.Duration     // pending time before resubmitting (default 3m)
+	GasBump       float64           // gas price multiplier per resubmission (default 1.125)
+	MaxResubmits  int               // default 3
+	Timeout       time.Duration     // per leg (default 30m)
+}
+
+func (c ExecutorConfig) withDefaults() ExecutorConfig {
+	if c.PollInterval <= 0 {
+		c.PollInterval = 5 * time.Second
+	}
+	if c.StuckAfter <= 0 {
+		c.StuckAfter = 3 * time.Minute
+	}
+	if c.GasBump <= 1 {
+		c.GasBump = 1.125 // replacements must pay at least 10% more
+	}
+	if c.MaxResubmits <= 0 {
+		c.MaxResubmits = 3
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Minute
+	}
+	return c
+}
+
+// confirmations returns the blocks required on chain
+func (c ExecutorConfig) confirmations(chain string) uint64 {
+	if n := c.Confirmations[chain]; n > 0 {
+		return n
+	}
+	return 12
+}
+
+// CrossChainExecutor follows submitted transactions until they are final
+type CrossChainExecutor struct {
+	chains map[string]ChainClient
+	cfg    ExecutorConfig
+}
+
+// NewCrossChainExecutor monitors transactions on chains, keyed by chain name
+func NewCrossChainExecutor(chains map[string]ChainClient, cfg ExecutorConfig) *CrossChainExecutor {
+	return &CrossChainExecutor{chains: chains, cfg: cfg.withDefaults()}
+}
+
+// Confirm waits until txHash has the chain's required confirmations. A
+// transaction pending past StuckAfter, or dropped from the mempool, is
+// resubmitted with bumped gas; any of its versions may be the one included.
+// An inclusion whose block leaves the canonical chain is a reorg and is
+// waited for again.
+func (e *CrossChainExecutor) Confirm(ctx context.Context, chain, leg, txHash string) LegResult {
+	result := LegResult{Leg: leg, Chain: chain, TxHash: txHash, Status: LegTimedOut}
+	client, ok := e.chains[chain]
+	if !ok {
+		result.Status, result.Error = LegDropped, fmt.Sprintf("no client for chain %s", chain)
+		return result
+	}
+	required := e.cfg.confirmations(chain)
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(e.cfg.PollInterval)
+	defer ticker.Stop()
+
+	hashes := []string{txHash} // the original and its replacements, newest last
+	sent := time.Now()
+	var included *TxReceipt
+	for {
+		receipt, hash, err := e.receipt(ctx, client, hashes)
+		switch {
+		case errors.Is(err, ErrTxNotFound):
+			if included != nil {
+				result.Reorgs++
+				included = nil
+			}
+			if result.Resubmissions >= e.cfg.MaxResubmits {
+				result.Status, result.Error = LegDropped, fmt.Sprintf("dropped after %d resubmission(s)", result.Resubmissions)
+				return result
+			}
+			if hash, err := e.resubmit(ctx, client, &result, hashes); err == nil {
+				hashes, sent = append(hashes, hash), time.Now()
+			}
+		case err != nil:
+			result.Error = err.Error() // transient; poll again
+		case receipt == nil:
+			if included != nil {
+				result.Reorgs++
+				included = nil
+			}
+			if time.Since(sent) >= e.cfg.StuckAfter && result.Resubmissions < e.cfg.MaxResubmits {
+				if hash, err := e.resubmit(ctx, client, &result, hashes); err == nil {
+					hashes, sent = append(hashes, hash), time.Now()
+				}
+			}
+		default:
+			result.TxHash, result.BlockNumber, result.Error = hash, receipt.BlockNumber, ""
+			if included != nil && included.BlockHash != receipt.BlockHash {
+				result.Reorgs++
+			}
+			included = receipt
+			if final, err := e.settle(ctx, client, &result, receipt, required); err != nil {
+				result.Error = err.Error()
+			} else if final {
+				return result
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if result.Error == "" {
+				result.Error = fmt.Sprintf("%d of %d confirmations: %v", result.Confirmations, required, ctx.Err())
+			}
+			return result
+		case <-ticker.C:
+		}
+	}
+}
+
+// receipt returns the receipt of whichever of hashes was included, nil while
+// one is pending, and ErrTxNotFound when the node knows none of them
+func (e *CrossChainExecutor) receipt(ctx context.Context, client ChainClient, hashes []string) (*TxReceipt, string, error) {
+	pending := false
+	var errs []error
+	for _, hash := range hashes {
+		receipt, err := client.Receipt(ctx, hash)
+		switch {
+		case errors.Is(err, ErrTxNotFound):
+		case err != nil:
+			errs = append(errs, fmt.Errorf("receipt of %s: %w", hash, err))
+		case receipt != nil:
+			return receipt, hash, nil
+		default:
+			pending = true
+		}
+	}
+	if pending {
+		return nil, "", nil
+	}
+	if len(errs) > 0 {
+		return nil, "", errors.Join(errs...)
+	}
+	return nil, "", ErrTxNotFound
+}
+
+// resubmit replaces the newest of hashes with bumped gas
+func (e *CrossChainExecutor) resubmit(ctx context.Context, client ChainClient, result *LegResult, hashes []string) (string, error) {
+	hash, err := client.Resubmit(ctx, hashes[len(hashes)-1], e.cfg.GasBump)
+	if err != nil {
+		result.Error = fmt.Sprintf("resubmit failed: %v", err)
+		return "", err
+	}
+	result.Resubmissions++
+	result.TxHash = hash
+	return hash, nil
+}
+
+// settle counts the confirmations of an included transaction and reports
+// whether the leg is final: reverted, or confirmed in a canonical block
+func (e *CrossChainExecutor) settle(ctx context.Context, client ChainClient, result *LegResult, receipt *TxReceipt, required uint64) (bool, error) {
+	canonical, err := client.BlockHash(ctx, receipt.BlockNumber)
+	if err != nil {
+		return false, fmt.Errorf("block %d: %w", receipt.BlockNumber, err)
+	}
+	if canonical != receipt.BlockHash {
+		result.Confirmations = 0 // the node has not caught up with the reorg yet
+		return false, nil
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return false, fmt.Errorf("chain head: %w", err)
+	}
+	result.Confirmations = 0
+	if head >= receipt.BlockNumber {
+		result.Confirmations = head - receipt.BlockNumber + 1
+	}
+	if result.Confirmations < required {
+		return false, nil
+	}
+	if receipt.Reverted {
+		result.Status, result.Error = LegReverted, "transaction reverted"
+	} else {
+		result.Status = LegConfirmed
+	}
+	return true, nil
+}
+
+// SetExecutor follows every leg of executed arbitrages to finality, so
+// results report per-leg outcomes; nil only records the legs as submitted
+func (ace *CrossChainArbitrageEngine) SetExecutor(executor *CrossChainExecutor) {
+	ace.mutex.Lock()
+	defer ace.mutex.Unlock()
+	ace.executor = executor
+}
+
+// confirmLeg appends the outcome of a sent leg to result and reports whether
+// it confirmed
+func (ace *CrossChainArbitrageEngine) confirmLeg(ctx context.Context, result *ArbitrageResult, leg, chain, txHash string) bool {
+	ace.mutex.RLock()
+	executor := ace.executor
+	ace.mutex.RUnlock()
+
+	outcome := LegResult{Leg: leg, Chain: chain, TxHash: txHash, Status: LegSubmitted}
+	if executor != nil {
+		outcome = executor.Confirm(ctx, chain, leg, txHash)
+	}
+	result.Legs = append(result.Legs, outcome)
+	return executor == nil || outcome.Status == LegConfirmed
+}
+
+// failLeg records a leg that could not be sent, skips the legs after it and
+// closes the result
+func failLeg(result *ArbitrageResult, leg, chain string, err error) {
+	result.Legs = append(result.Legs, LegResult{Leg: leg, Chain: chain, Status: LegDropped, Error: err.Error()})
+	skipLegs(result)
+}
+
+// skipLegs marks the legs not reached as skipped and closes the result
+func skipLegs(result *ArbitrageResult) {
+	for _, leg := range arbitrageLegs[len(result.Legs):] {
+		result.Legs = append(result.Legs, LegResult{Leg: leg, Status: LegSkipped})
+	}
+	result.EndTime = time.Now()
+}
+
+// legError describes the last recorded leg, which did not confirm
+func legError(result *ArbitrageResult) error {
+	for i := len(result.Legs) - 1; i >= 0; i-- {
+		if leg := result.Legs[i]; leg.Status != LegSkipped {
+			return fmt.Errorf("%w: %s leg %s: %s", ErrLegFailed, leg.Leg, leg.Status, leg.Error)
+		}
+	}
+	return ErrLegFailed
+}
+
+// arbitrageLegs are the legs of an arbitrage in execution order
+var arbitrageLegs = []string{"buy", "bridge", "sell"}
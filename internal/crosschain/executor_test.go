@@ -0,0 +1,219 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeChain is a chain whose head advances one block per poll of the
+// original transaction; script changes its state at a given poll
+type fakeChain struct {
+	mu       sync.Mutex
+	original string
+	polls    int
+	head     uint64
+	blocks   map[uint64]string
+	receipts map[string]*TxReceipt // nil: pending
+	script   func(c *fakeChain, poll int)
+	replaced []string
+}
+
+func newFakeChain(original string, script func(c *fakeChain, poll int)) *fakeChain {
+	return &fakeChain{original: original, head: 100, blocks: map[uint64]string{},
+		receipts: map[string]*TxReceipt{original: nil}, script: script}
+}
+
+// include puts hash into a new block at the head
+func (c *fakeChain) include(hash, blockHash string, reverted bool) {
+	c.blocks[c.head] = blockHash
+	c.receipts[hash] = &TxReceipt{BlockNumber: c.head, BlockHash: blockHash, Reverted: reverted}
+}
+
+func (c *fakeChain) Receipt(ctx context.Context, txHash string) (*TxReceipt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if txHash == c.original {
+		c.polls++
+		c.head++
+		c.script(c, c.polls)
+	}
+	receipt, ok := c.receipts[txHash]
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+	return receipt, nil
+}
+
+func (c *fakeChain) BlockNumber(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.head, nil
+}
+
+func (c *fakeChain) BlockHash(ctx context.Context, height uint64) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.blocks[height], nil
+}
+
+func (c *fakeChain) Resubmit(ctx context.Context, txHash string, gasBump float64) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash := fmt.Sprintf("%s-r%d", c.original, len(c.replaced)+1)
+	c.replaced = append(c.replaced, hash)
+	c.receipts[hash] = nil
+	return hash, nil
+}
+
+func testExecutor(chain *fakeChain) *CrossChainExecutor {
+	return NewCrossChainExecutor(map[string]ChainClient{"ethereum": chain}, ExecutorConfig{
+		Confirmations: map[string]uint64{"ethereum": 3},
+		PollInterval:  time.Millisecond,
+		StuckAfter:    time.Hour,
+		MaxResubmits:  2,
+		Timeout:       5 * time.Second,
+	})
+}
+
+func TestCrossChainExecutor_Confirm(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        func(c *fakeChain, poll int)
+		status        LegStatus
+		txHash        string
+		reorgs        int
+		resubmissions int
+	}{
+		{"confirms", func(c *fakeChain, poll int) {
+			if poll == 2 {
+				c.include("tx", "a", false)
+			}
+		}, LegConfirmed, "tx", 0, 0},
+		{"waits again after a reorg", func(c *fakeChain, poll int) {
+			switch poll {
+			case 2:
+				c.include("tx", "a", false)
+			case 3: // the block is replaced and the tx is back in the mempool
+				c.blocks[c.receipts["tx"].BlockNumber] = "b"
+				c.receipts["tx"] = nil
+			case 5:
+				c.include("tx", "c", false)
+			}
+		}, LegConfirmed, "tx", 1, 0},
+		{"resubmits a dropped transaction", func(c *fakeChain, poll int) {
+			switch poll {
+			case 2:
+				delete(c.receipts, "tx")
+			case 4:
+				c.include("tx-r1", "a", false)
+			}
+		}, LegConfirmed, "tx-r1", 0, 1},
+		{"reports a revert", func(c *fakeChain, poll int) {
+			if poll == 2 {
+				c.include("tx", "a", true)
+			}
+		}, LegReverted, "tx", 0, 0},
+		{"gives up on a transaction that keeps dropping", func(c *fakeChain, poll int) {
+			for hash := range c.receipts {
+				delete(c.receipts, hash)
+			}
+		}, LegDropped, "tx-r2", 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leg := testExecutor(newFakeChain("tx", tt.script)).Confirm(context.Background(), "ethereum", "buy", "tx")
+			if leg.Status != tt.status || leg.TxHash != tt.txHash || leg.Reorgs != tt.reorgs || leg.Resubmissions != tt.resubmissions {
+				t.Fatalf("Confirm() = %+v, want %s on %s with %d reorg(s) and %d resubmission(s)", leg, tt.status, tt.txHash, tt.reorgs, tt.resubmissions)
+			}
+			if tt.status == LegConfirmed && leg.Confirmations < 3 {
+				t.Errorf("confirmations = %d, want at least 3", leg.Confirmations)
+			}
+		})
+	}
+}
+
+// fakeBridge sends every transfer as transaction "bridge"
+type fakeBridge struct{}
+
+func (fakeBridge) Transfer(ctx context.Context, token string, amount float64, fromChain, toChain string) (*TransferReceipt, error) {
+	return &TransferReceipt{ID: "bridge", Status: "sent", Timestamp: time.Now()}, nil
+}
+func (fakeBridge) EstimateTime(fromChain, toChain string) time.Duration { return time.Minute }
+func (fakeBridge) EstimateFee(token string, amount float64, fromChain, toChain string) (float64, error) {
+	return 0, nil
+}
+
+// routedChain answers for the buy on one fake chain and the bridge on another
+type routedChain struct {
+	buy, bridge *fakeChain
+}
+
+func (r routedChain) chain(hash string) *fakeChain {
+	if strings.HasPrefix(hash, "bridge") {
+		return r.bridge
+	}
+	return r.buy
+}
+
+func (r routedChain) Receipt(ctx context.Context, txHash string) (*TxReceipt, error) {
+	return r.chain(txHash).Receipt(ctx, txHash)
+}
+func (r routedChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return max(r.buy.head, r.bridge.head), nil
+}
+func (r routedChain) BlockHash(ctx context.Context, height uint64) (string, error) {
+	if hash, _ := r.bridge.BlockHash(ctx, height); hash != "" {
+		return hash, nil
+	}
+	return r.buy.BlockHash(ctx, height)
+}
+func (r routedChain) Resubmit(ctx context.Context, txHash string, gasBump float64) (string, error) {
+	return r.chain(txHash).Resubmit(ctx, txHash, gasBump)
+}
+
+func TestCrossChainArbitrageEngine_LegStatuses(t *testing.T) {
+	engine := &CrossChainArbitrageEngine{
+		bridges:      map[string]Bridge{"ethereum": fakeBridge{}},
+		dexes:        map[string]*DEXClient{"ethereum": {}, "arbitrum": {}},
+		flashLoaners: map[string]FlashLoanProvider{"ethereum": MockFlashLoanProvider{}},
+	}
+	opp := ArbitrageOpportunity{ID: "op", TokenSymbol: "ETH", BuyChain: "ethereum", SellChain: "arbitrum", RequiredCapital: 1000}
+
+	// Without an executor the legs are only known to be sent
+	result, err := engine.ExecuteArbitrage(context.Background(), opp)
+	if err != nil || !result.Success || len(result.Legs) != 3 || result.Legs[2].Status != LegSubmitted {
+		t.Fatalf("ExecuteArbitrage() = %+v, %v", result, err)
+	}
+
+	buyHash := fmt.Sprintf("buy_ETH_%d", time.Now().Unix())
+	buy := newFakeChain(buyHash, func(c *fakeChain, poll int) {
+		if poll == 1 {
+			c.include(c.original, "a", false)
+		}
+	})
+	bridge := newFakeChain("bridge", func(c *fakeChain, poll int) {
+		if poll == 1 {
+			c.head = 200
+			c.include("bridge", "z", true)
+		}
+	})
+	engine.SetExecutor(NewCrossChainExecutor(map[string]ChainClient{"ethereum": routedChain{buy: buy, bridge: bridge}},
+		ExecutorConfig{Confirmations: map[string]uint64{"ethereum": 1}, PollInterval: time.Millisecond, Timeout: 5 * time.Second}))
+
+	result, err = engine.ExecuteArbitrage(context.Background(), opp)
+	if !errors.Is(err, ErrLegFailed) || result.Success {
+		t.Fatalf("ExecuteArbitrage() = %+v, %v; want a failed bridge leg", result, err)
+	}
+	var statuses []LegStatus
+	for _, leg := range result.Legs {
+		statuses = append(statuses, leg.Status)
+	}
+	if fmt.Sprint(statuses) != "[confirmed reverted skipped]" || result.SellTransaction != nil || result.EndTime.IsZero() {
+		t.Errorf("legs = %v, sell = %+v", statuses, result.SellTransaction)
+	}
+}
@@ -0,0 +1,262 @@
+package crosschain
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/evm"
+)
+
+// ErrUnprofitable reports a flash loan that would not clear its minimum
+// profit, by estimate or in simulation; it is never sent
+var ErrUnprofitable = errors.New("flash loan unprofitable")
+
+// Ethereum mainnet deployments used when FlashLoanConfig.Pool is empty
+const (
+	DefaultAavePool      = "0x87870Bca3F3fD6335C3F4ce8392D69350B4fA4E2" // Aave v3 Pool
+	DefaultBalancerVault = "0xBA12222222228d8Ba445958a75a0704d566BF2C8" // Balancer v2 Vault
+)
+
+// Function selectors (first four bytes of the keccak-256 of the signature)
+const (
+	selectorFlashLoanSimple       = "42b0b77c" // Pool.flashLoanSimple(address,address,uint256,bytes,uint16)
+	selectorFlashLoanPremiumTotal = "074b2e43" // Pool.FLASHLOAN_PREMIUM_TOTAL()
+	selectorBalancerFlashLoan     = "5c38449e" // Vault.flashLoan(address,address[],uint256[],bytes)
+	selectorProtocolFeesCollector = "d2946c2b" // Vault.getProtocolFeesCollector()
+	selectorFlashLoanFeePercent   = "d877845c" // ProtocolFeesCollector.getFlashLoanFeePercentage()
+)
+
+// FlashLoanProvider lends capital that is repaid within the transaction
+// borrowing it
+type FlashLoanProvider interface {
+	// Fee returns the premium charged on borrowing amount of token
+	Fee(ctx context.Context, token string, amount float64) (float64, error)
+	// Execute borrows for the receiver contract, which runs the request's
+	// trades and repays the loan and fee in the same transaction. Loans that
+	// would not clear MinProfit are not sent and fail with ErrUnprofitable.
+	Execute(ctx context.Context, req FlashLoanRequest) (*FlashLoan, error)
+}
+
+// FlashLoanRequest is an atomic arbitrage funded by a flash loan
+type FlashLoanRequest struct {
+	Token    string // symbol, for the loan record
+	Asset    string // address of the ERC-20 borrowed
+	Decimals int
+	Amount   float64
+
+	// Params are handed to the receiver contract, which decodes the trades
+	// from them. It must revert when the trades return less than the loan,
+	// its fee and the minimum profit, so simulation catches stale prices.
+	Params []byte
+
+	ExpectedProceeds float64 // what the trades return in the asset
+	GasCost          float64 // in the asset
+	MinProfit        float64 // in the asset, after fee and gas
+}
+
+// FlashLoanConfig configures an on-chain flash loan provider
+type FlashLoanConfig struct {
+	RPCURL   string
+	Account  string // sends the borrowing transaction; the endpoint signs it
+	Receiver string // contract receiving the loan
+	Pool     string // Aave v3 Pool or Balancer Vault; mainnet when empty
+}
+
+// evmLender holds what the Aave and Balancer adapters share
+type evmLender struct {
+	rpc      *evm.RPCClient
+	account  evm.Address
+	receiver evm.Address
+	pool     evm.Address
+}
+
+func newEVMLender(cfg FlashLoanConfig, defaultPool string) (*evmLender, error) {
+	if cfg.RPCURL == "" {
+		return nil, fmt.Errorf("rpc url is required")
+	}
+	if cfg.Pool == "" {
+		cfg.Pool = defaultPool
+	}
+	l := &evmLender{rpc: evm.NewRPCClient(cfg.RPCURL)}
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *evm.Address
+	}{
+		{"account", cfg.Account, &l.account},
+		{"receiver", cfg.Receiver, &l.receiver},
+		{"pool", cfg.Pool, &l.pool},
+	} {
+		a, err := evm.ParseAddress(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		*field.dst = a
+	}
+	return l, nil
+}
+
+// execute checks the estimated profit, simulates data against the pool and
+// sends it. The simulation runs the receiver's trades at the latest block,
+// so a receiver that reverts on a shortfall is caught before paying gas.
+func (l *evmLender) execute(ctx context.Context, req FlashLoanRequest, fee float64, data []byte) (*FlashLoan, error) {
+	loan := &FlashLoan{Principal: req.Amount, Fee: fee, Token: req.Token, Timestamp: time.Now(),
+		Profit: req.ExpectedProceeds - req.Amount - fee - req.GasCost}
+	if loan.Profit < req.MinProfit {
+		return nil, fmt.Errorf("%w: expected %.8g %s after fee %.8g and gas %.8g, minimum %.8g",
+			ErrUnprofitable, loan.Profit, req.Token, fee, req.GasCost, req.MinProfit)
+	}
+
+	tx := map[string]string{"from": l.account.String(), "to": l.pool.String(), "data": "0x" + hex.EncodeToString(data)}
+	var result string
+	err := l.rpc.Call(ctx, &result, "eth_call", tx, "latest")
+	var rpcErr *evm.RPCError
+	if errors.As(err, &rpcErr) {
+		return nil, fmt.Errorf("%w: reverted in simulation: %s", ErrUnprofitable, rpcErr.Message)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate flash loan: %w", err)
+	}
+
+	if err := l.rpc.Call(ctx, &loan.TxHash, "eth_sendTransaction", tx); err != nil {
+		return nil, fmt.Errorf("failed to send flash loan: %w", err)
+	}
+	return loan, nil
+}
+
+// parseAsset reads the request's asset and converts its amount to units
+func parseAsset(req FlashLoanRequest) (evm.Address, *big.Int, error) {
+	asset, err := evm.ParseAddress(req.Asset)
+	if err != nil {
+		return asset, nil, fmt.Errorf("asset: %w", err)
+	}
+	if req.Amount <= 0 {
+		return asset, nil, fmt.Errorf("amount must be positive")
+	}
+	return asset, evm.ToUnits(req.Amount, req.Decimals), nil
+}
+
+// AaveFlashLoans borrows single assets from an Aave v3 Pool with
+// flashLoanSimple. The receiver implements executeOperation and approves the
+// pool for the loan plus premium.
+type AaveFlashLoans struct {
+	*evmLender
+}
+
+// NewAaveFlashLoans creates an Aave v3 adapter
+func NewAaveFlashLoans(cfg FlashLoanConfig) (*AaveFlashLoans, error) {
+	l, err := newEVMLender(cfg, DefaultAavePool)
+	if err != nil {
+		return nil, fmt.Errorf("aave: %w", err)
+	}
+	return &AaveFlashLoans{l}, nil
+}
+
+// Fee applies the pool's FLASHLOAN_PREMIUM_TOTAL, in basis points
+func (a *AaveFlashLoans) Fee(ctx context.Context, token string, amount float64) (float64, error) {
+	data, err := a.rpc.EthCall(ctx, a.pool, evm.EncodeCall(selectorFlashLoanPremiumTotal))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get aave premium: %w", err)
+	}
+	premium, err := evm.WordAt(data, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get aave premium: %w", err)
+	}
+	return amount * float64(premium.Int64()) / 10000, nil
+}
+
+// Execute sends flashLoanSimple once it is estimated and simulated profitable
+func (a *AaveFlashLoans) Execute(ctx context.Context, req FlashLoanRequest) (*FlashLoan, error) {
+	asset, units, err := parseAsset(req)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := a.Fee(ctx, req.Token, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return a.execute(ctx, req, fee, aaveFlashLoanCall(a.receiver, asset, units, req.Params))
+}
+
+// aaveFlashLoanCall encodes flashLoanSimple(receiver, asset, amount, params, 0)
+func aaveFlashLoanCall(receiver, asset evm.Address, amount *big.Int, params []byte) []byte {
+	return evm.EncodeCall(selectorFlashLoanSimple,
+		receiver.Word(),
+		asset.Word(),
+		evm.UintWord(amount),
+		evm.UintWord(big.NewInt(5*32)), // params follow the five head words
+		evm.UintWord(new(big.Int)),     // no referral code
+		evm.BytesTail(params),
+	)
+}
+
+// BalancerFlashLoans borrows from the Balancer v2 Vault. The receiver
+// implements receiveFlashLoan and transfers the loan plus fee back to the
+// vault.
+type BalancerFlashLoans struct {
+	*evmLender
+}
+
+// NewBalancerFlashLoans creates a Balancer v2 adapter
+func NewBalancerFlashLoans(cfg FlashLoanConfig) (*BalancerFlashLoans, error) {
+	l, err := newEVMLender(cfg, DefaultBalancerVault)
+	if err != nil {
+		return nil, fmt.Errorf("balancer: %w", err)
+	}
+	return &BalancerFlashLoans{l}, nil
+}
+
+// Fee applies the protocol fees collector's flash loan fee, an 18-decimal
+// fraction (zero on mainnet at the time of writing)
+func (b *BalancerFlashLoans) Fee(ctx context.Context, token string, amount float64) (float64, error) {
+	data, err := b.rpc.EthCall(ctx, b.pool, evm.EncodeCall(selectorProtocolFeesCollector))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balancer fees collector: %w", err)
+	}
+	if len(data) < 32 {
+		return 0, fmt.Errorf("failed to get balancer fees collector: short return data: %d bytes", len(data))
+	}
+	var collector evm.Address
+	copy(collector[:], data[12:32])
+
+	if data, err = b.rpc.EthCall(ctx, collector, evm.EncodeCall(selectorFlashLoanFeePercent)); err != nil {
+		return 0, fmt.Errorf("failed to get balancer flash loan fee: %w", err)
+	}
+	percentage, err := evm.WordAt(data, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balancer flash loan fee: %w", err)
+	}
+	rate, _ := new(big.Float).Quo(new(big.Float).SetInt(percentage), big.NewFloat(1e18)).Float64()
+	return amount * rate, nil
+}
+
+// Execute sends Vault.flashLoan once it is estimated and simulated profitable
+func (b *BalancerFlashLoans) Execute(ctx context.Context, req FlashLoanRequest) (*FlashLoan, error) {
+	asset, units, err := parseAsset(req)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := b.Fee(ctx, req.Token, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return b.execute(ctx, req, fee, balancerFlashLoanCall(b.receiver, asset, units, req.Params))
+}
+
+// balancerFlashLoanCall encodes flashLoan(receiver, [asset], [amount], params)
+func balancerFlashLoanCall(receiver, asset evm.Address, amount *big.Int, params []byte) []byte {
+	one := evm.UintWord(big.NewInt(1))
+	return evm.EncodeCall(selectorBalancerFlashLoan,
+		receiver.Word(),
+		evm.UintWord(big.NewInt(4*32)), // tokens follow the four head words
+		evm.UintWord(big.NewInt(6*32)), // amounts follow the one-token array
+		evm.UintWord(big.NewInt(8*32)), // params follow the one-amount array
+		one, asset.Word(),
+		one, evm.UintWord(amount),
+		evm.BytesTail(params),
+	)
+}
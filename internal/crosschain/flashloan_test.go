@@ -0,0 +1,181 @@
+package crosschain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/evm"
+)
+
+const (
+	testAccount   = "0x00000000000000000000000000000000000000aa"
+	testReceiver  = "0x00000000000000000000000000000000000000bb"
+	testCollector = "0x00000000000000000000000000000000000000cc"
+	testWETH      = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+)
+
+// fakeLendingNode answers an Aave pool charging 5 bps and a Balancer vault
+// charging 0.1%; flash loans revert in simulation while revert is set
+type fakeLendingNode struct {
+	mu     sync.Mutex
+	revert bool
+	calls  []string // data of simulated flash loans
+	sent   []string // data of sent flash loans
+}
+
+func (n *fakeLendingNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int64             `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var tx struct{ From, To, Data string }
+	_ = json.Unmarshal(req.Params[0], &tx)
+	word := func(v int64, scale string) string {
+		x, _ := new(big.Int).SetString(scale, 10)
+		return "0x" + hex.EncodeToString(evm.UintWord(x.Mul(x, big.NewInt(v))))
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var result interface{}
+	var err error
+	selector := strings.TrimPrefix(tx.Data, "0x")[:8]
+	switch {
+	case selector == selectorFlashLoanPremiumTotal:
+		result = word(5, "1")
+	case selector == selectorProtocolFeesCollector:
+		a, _ := evm.ParseAddress(testCollector)
+		result = "0x" + hex.EncodeToString(a.Word())
+	case selector == selectorFlashLoanFeePercent:
+		result = word(1, "1000000000000000") // 0.001e18
+	case req.Method == "eth_call" && n.revert:
+		n.calls = append(n.calls, tx.Data)
+		err = fmt.Errorf("execution reverted: profit below minimum")
+	case req.Method == "eth_call":
+		n.calls = append(n.calls, tx.Data)
+		result = "0x"
+	case req.Method == "eth_sendTransaction":
+		n.sent = append(n.sent, tx.Data)
+		result = fmt.Sprintf("0x%064x", len(n.sent))
+	default:
+		err = fmt.Errorf("unexpected %s", req.Method)
+	}
+
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result}
+	if err != nil {
+		response = map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": 3, "message": err.Error()}}
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func TestFlashLoanProviders(t *testing.T) {
+	node := &fakeLendingNode{}
+	server := httptest.NewServer(node)
+	defer server.Close()
+	cfg := FlashLoanConfig{RPCURL: server.URL, Account: testAccount, Receiver: testReceiver}
+
+	aave, err := NewAaveFlashLoans(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balancer, err := NewBalancerFlashLoans(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := []byte("swap route")
+	req := FlashLoanRequest{Token: "WETH", Asset: testWETH, Decimals: 18, Amount: 10, Params: params,
+		ExpectedProceeds: 10.05, GasCost: 0.005, MinProfit: 0.01}
+
+	for _, tt := range []struct {
+		name     string
+		provider FlashLoanProvider
+		fee      float64
+		pool     string
+	}{
+		{"aave", aave, 0.005, DefaultAavePool},
+		{"balancer", balancer, 0.01, DefaultBalancerVault},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			node.calls, node.sent = nil, nil
+			fee, err := tt.provider.Fee(context.Background(), "WETH", 10)
+			if err != nil || math.Abs(fee-tt.fee) > 1e-12 {
+				t.Fatalf("Fee() = %v, %v; want %v", fee, err, tt.fee)
+			}
+
+			loan, err := tt.provider.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if loan.TxHash == "" || math.Abs(loan.Profit-(0.05-tt.fee-0.005)) > 1e-9 || len(node.calls) != 1 || len(node.sent) != 1 {
+				t.Fatalf("loan = %+v, %d simulated, %d sent", loan, len(node.calls), len(node.sent))
+			}
+			if node.calls[0] != node.sent[0] || !strings.Contains(node.sent[0], hex.EncodeToString(params)) {
+				t.Errorf("sent %s, want the simulated call carrying the params", node.sent[0])
+			}
+
+			// Not worth it by estimate: nothing reaches the node
+			short := req
+			short.ExpectedProceeds = 10.01
+			if _, err := tt.provider.Execute(context.Background(), short); !errors.Is(err, ErrUnprofitable) || len(node.calls) != 1 {
+				t.Errorf("Execute() below the minimum = %v after %d simulation(s)", err, len(node.calls))
+			}
+
+			// The receiver reverts at the latest block: simulated, never sent
+			node.revert = true
+			defer func() { node.revert = false }()
+			if _, err := tt.provider.Execute(context.Background(), req); !errors.Is(err, ErrUnprofitable) || len(node.sent) != 1 {
+				t.Errorf("Execute() reverting = %v after %d send(s)", err, len(node.sent))
+			}
+		})
+	}
+}
+
+func TestFlashLoanCalldata(t *testing.T) {
+	receiver, _ := evm.ParseAddress(testReceiver)
+	asset, _ := evm.ParseAddress(testWETH)
+	amount := big.NewInt(1e18)
+	params := make([]byte, 33) // pads to two words
+
+	aave := aaveFlashLoanCall(receiver, asset, amount, params)
+	if len(aave) != 4+32*8 || hex.EncodeToString(aave[:4]) != selectorFlashLoanSimple {
+		t.Fatalf("aave calldata is %d bytes", len(aave))
+	}
+	if offset, _ := evm.WordAt(aave[4:], 3); offset.Int64() != 160 {
+		t.Errorf("aave params offset = %d, want 160", offset)
+	}
+	if length, _ := evm.WordAt(aave[4:], 5); length.Int64() != 33 {
+		t.Errorf("aave params length = %d, want 33", length)
+	}
+
+	balancer := balancerFlashLoanCall(receiver, asset, amount, params)
+	if len(balancer) != 4+32*11 || hex.EncodeToString(balancer[:4]) != selectorBalancerFlashLoan {
+		t.Fatalf("balancer calldata is %d bytes", len(balancer))
+	}
+	head := balancer[4:]
+	for i, want := range map[int]int64{1: 128, 2: 192, 3: 256} {
+		if offset, _ := evm.WordAt(head, i); offset.Int64() != want {
+			t.Errorf("balancer offset %d = %d, want %d", i, offset, want)
+		}
+	}
+	if token, _ := evm.WordAt(head, 5); new(big.Int).SetBytes(asset[:]).Cmp(token) != 0 {
+		t.Errorf("balancer token = %x", token)
+	}
+	if got, _ := evm.WordAt(head, 7); got.Cmp(amount) != 0 {
+		t.Errorf("balancer amount = %v", got)
+	}
+}
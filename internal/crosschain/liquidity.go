@@ -0,0 +1,209 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrInsufficientLiquidity reports a trade larger than a venue's depth
+var ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+
+// maxHalvings bounds how often a check shrinks an opportunity
+const maxHalvings = 6
+
+// Depth simulates trades against one venue's liquidity for a token, priced
+// in the asset the opportunity's capital is held in
+type Depth interface {
+	// Buy returns the tokens received for spending quote
+	Buy(quote float64) (float64, error)
+	// Sell returns the quote received for selling tokens
+	Sell(tokens float64) (float64, error)
+}
+
+// BookDepth walks a centralized exchange's order book
+type BookDepth struct {
+	Book *types.OrderBook
+}
+
+// Buy takes asks from the best price up
+func (b BookDepth) Buy(quote float64) (float64, error) {
+	tokens := 0.0
+	for _, level := range b.Book.Asks {
+		cost := level.Price * level.Amount
+		if cost >= quote {
+			return tokens + quote/level.Price, nil
+		}
+		tokens += level.Amount
+		quote -= cost
+	}
+	return tokens, fmt.Errorf("%w: %.2f left to spend after %d ask levels", ErrInsufficientLiquidity, quote, len(b.Book.Asks))
+}
+
+// Sell hits bids from the best price down
+func (b BookDepth) Sell(tokens float64) (float64, error) {
+	quote := 0.0
+	for _, level := range b.Book.Bids {
+		if level.Amount >= tokens {
+			return quote + tokens*level.Price, nil
+		}
+		quote += level.Amount * level.Price
+		tokens -= level.Amount
+	}
+	return quote, fmt.Errorf("%w: %.8f left to sell after %d bid levels", ErrInsufficientLiquidity, tokens, len(b.Book.Bids))
+}
+
+// PoolDepth prices trades on a constant-product (x*y=k) pool
+type PoolDepth struct {
+	TokenReserve float64
+	QuoteReserve float64
+	Fee          float64 // pool fee, e.g. 0.003 for 0.3%
+}
+
+// Buy swaps quote into the pool for tokens
+func (p PoolDepth) Buy(quote float64) (float64, error) {
+	if p.TokenReserve <= 0 || p.QuoteReserve <= 0 {
+		return 0, fmt.Errorf("%w: empty pool", ErrInsufficientLiquidity)
+	}
+	in := quote * (1 - p.Fee)
+	return p.TokenReserve * in / (p.QuoteReserve + in), nil
+}
+
+// Sell swaps tokens into the pool for quote
+func (p PoolDepth) Sell(tokens float64) (float64, error) {
+	if p.TokenReserve <= 0 || p.QuoteReserve <= 0 {
+		return 0, fmt.Errorf("%w: empty pool", ErrInsufficientLiquidity)
+	}
+	in := tokens * (1 - p.Fee)
+	return p.QuoteReserve * in / (p.TokenReserve + in), nil
+}
+
+// DepthSource provides the current depth of a token on a venue (a chain's
+// DEX or a centralized exchange)
+type DepthSource interface {
+	Depth(ctx context.Context, venue, token string) (Depth, error)
+}
+
+// DepthFunc adapts a function to DepthSource
+type DepthFunc func(ctx context.Context, venue, token string) (Depth, error)
+
+// Depth calls f
+func (f DepthFunc) Depth(ctx context.Context, venue, token string) (Depth, error) {
+	return f(ctx, venue, token)
+}
+
+// LiquidityEstimate records a liquidity check of an opportunity
+type LiquidityEstimate struct {
+	QuotedCapital float64 `json:"quoted_capital"` // size before the check
+	Capital       float64 `json:"capital"`        // size simulated last; the accepted size unless rejected
+	BuyPrice      float64 `json:"buy_price"`      // average buy fill
+	SellPrice     float64 `json:"sell_price"`     // average sell fill
+	BuySlippage   float64 `json:"buy_slippage"`   // buy fill above the quoted price, as a fraction
+	SellSlippage  float64 `json:"sell_slippage"`  // sell fill below the quoted price, as a fraction
+	NetProfit     float64 `json:"net_profit"`     // after slippage and gas
+	NetMargin     float64 `json:"net_margin"`     // net profit over capital
+	Rejected      bool    `json:"rejected"`
+	Reason        string  `json:"reason,omitempty"`
+}
+
+// LiquidityChecker sizes opportunities to the depth of both legs
+type LiquidityChecker struct {
+	source     DepthSource
+	minMargin  float64
+	minCapital float64
+}
+
+// NewLiquidityChecker creates a checker that accepts sizes netting at least
+// minMargin after slippage and gas, shrinking no lower than minCapital
+func NewLiquidityChecker(source DepthSource, minMargin, minCapital float64) *LiquidityChecker {
+	return &LiquidityChecker{source: source, minMargin: minMargin, minCapital: minCapital}
+}
+
+// Check simulates both legs of opp at its size and halves the size until the
+// net margin clears the minimum. The estimate is recorded in opp.Liquidity;
+// an accepted smaller size replaces RequiredCapital and EstimatedProfit.
+// It reports whether the opportunity is still worth executing.
+func (l *LiquidityChecker) Check(ctx context.Context, opp *ArbitrageOpportunity) (bool, error) {
+	buy, err := l.source.Depth(ctx, opp.BuyChain, opp.TokenSymbol)
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s depth on %s: %w", opp.TokenSymbol, opp.BuyChain, err)
+	}
+	sell, err := l.source.Depth(ctx, opp.SellChain, opp.TokenSymbol)
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s depth on %s: %w", opp.TokenSymbol, opp.SellChain, err)
+	}
+	gas := 0.0
+	for _, fee := range opp.GasFees {
+		gas += fee
+	}
+
+	capital := opp.RequiredCapital
+	for i := 0; i <= maxHalvings && capital >= l.minCapital && capital > 0; i++ {
+		estimate := simulateLegs(buy, sell, opp, capital, gas)
+		opp.Liquidity = estimate
+		if estimate.Reason == "" && estimate.NetMargin >= l.minMargin {
+			opp.RequiredCapital = capital
+			opp.EstimatedProfit = estimate.NetProfit
+			return true, nil
+		}
+		if estimate.Reason == "" {
+			estimate.Reason = fmt.Sprintf("net margin %.4f%% below %.4f%%", estimate.NetMargin*100, l.minMargin*100)
+		}
+		capital /= 2
+	}
+	if opp.Liquidity == nil {
+		opp.Liquidity = &LiquidityEstimate{QuotedCapital: opp.RequiredCapital,
+			Reason: fmt.Sprintf("capital %.2f below the minimum %.2f", opp.RequiredCapital, l.minCapital)}
+	}
+	opp.Liquidity.Rejected = true
+	return false, nil
+}
+
+// simulateLegs buys with capital on one venue and sells the tokens on the
+// other; a leg the venue cannot fill is reported in Reason
+func simulateLegs(buy, sell Depth, opp *ArbitrageOpportunity, capital, gas float64) *LiquidityEstimate {
+	estimate := &LiquidityEstimate{QuotedCapital: opp.RequiredCapital, Capital: capital}
+	tokens, err := buy.Buy(capital)
+	if err != nil {
+		estimate.Reason = "buy leg: " + err.Error()
+		return estimate
+	}
+	if tokens <= 0 {
+		estimate.Reason = "buy leg fills nothing"
+		return estimate
+	}
+	proceeds, err := sell.Sell(tokens)
+	if err != nil {
+		estimate.Reason = "sell leg: " + err.Error()
+		return estimate
+	}
+
+	estimate.BuyPrice = capital / tokens
+	estimate.SellPrice = proceeds / tokens
+	if opp.BuyPrice > 0 {
+		estimate.BuySlippage = estimate.BuyPrice/opp.BuyPrice - 1
+	}
+	if opp.SellPrice > 0 {
+		estimate.SellSlippage = 1 - estimate.SellPrice/opp.SellPrice
+	}
+	estimate.NetProfit = proceeds - capital - gas
+	estimate.NetMargin = estimate.NetProfit / capital
+	return estimate
+}
+
+// SetLiquidityChecker checks opportunities against venue depth when they are
+// scanned and again before execution; nil skips the check
+func (ace *CrossChainArbitrageEngine) SetLiquidityChecker(checker *LiquidityChecker) {
+	ace.mutex.Lock()
+	defer ace.mutex.Unlock()
+	ace.liquidity = checker
+}
+
+// liquidityChecker returns the configured checker, or nil
+func (ace *CrossChainArbitrageEngine) liquidityChecker() *LiquidityChecker {
+	ace.mutex.RLock()
+	defer ace.mutex.RUnlock()
+	return ace.liquidity
+}
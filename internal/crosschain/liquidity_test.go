@@ -0,0 +1,60 @@
+package crosschain
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestLiquidityChecker(t *testing.T) {
+	// Buy on an exchange at 100 and sell into a pool priced at 103
+	checker := func(askAmount, poolTokens float64) *LiquidityChecker {
+		return NewLiquidityChecker(DepthFunc(func(ctx context.Context, venue, token string) (Depth, error) {
+			if venue == "cex" {
+				return BookDepth{Book: &types.OrderBook{Asks: []types.OrderBookEntry{{Price: 100, Amount: askAmount}}}}, nil
+			}
+			return PoolDepth{TokenReserve: poolTokens, QuoteReserve: 103 * poolTokens}, nil
+		}), 0.01, 500)
+	}
+	opportunity := func() *ArbitrageOpportunity {
+		return &ArbitrageOpportunity{ID: "op", TokenSymbol: "ETH", BuyChain: "cex", SellChain: "dex",
+			BuyPrice: 100, SellPrice: 103, RequiredCapital: 10000, GasFees: map[string]float64{"ethereum": 10}}
+	}
+
+	tests := []struct {
+		name       string
+		askAmount  float64
+		poolTokens float64
+		wantOK     bool
+		capital    float64
+	}{
+		{"deep on both legs", 200, 100000, true, 10000},
+		{"pool impact shrinks the size", 200, 5000, true, 5000},
+		{"thin book shrinks the size", 50, 100000, true, 5000},
+		{"slippage erases the margin at every size", 200, 500, false, 10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opp := opportunity()
+			ok, err := checker(tt.askAmount, tt.poolTokens).Check(context.Background(), opp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK || opp.RequiredCapital != tt.capital {
+				t.Fatalf("ok = %v, capital = %.2f, want %v, %.2f (estimate %+v)", ok, opp.RequiredCapital, tt.wantOK, tt.capital, opp.Liquidity)
+			}
+			estimate := opp.Liquidity
+			if estimate == nil || estimate.QuotedCapital != 10000 || estimate.Rejected == ok {
+				t.Fatalf("estimate = %+v", estimate)
+			}
+			if ok && (estimate.NetMargin < 0.01 || math.Abs(opp.EstimatedProfit-estimate.NetProfit) > 1e-9 || estimate.SellSlippage <= 0) {
+				t.Fatalf("accepted estimate = %+v, profit %.2f", estimate, opp.EstimatedProfit)
+			}
+			if !ok && estimate.Reason == "" {
+				t.Fatal("rejection has no reason")
+			}
+		})
+	}
+}
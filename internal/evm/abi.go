@@ -0,0 +1,103 @@
+// Package evm holds what the on-chain clients share: addresses, ABI
+// encoding of calls and their results, token unit conversion and an
+// Ethereum JSON-RPC client.
+package evm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Address is a 20-byte Ethereum address
+type Address [20]byte
+
+// ParseAddress reads a 0x-prefixed hex address
+func ParseAddress(s string) (Address, error) {
+	var a Address
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(s), "0x"))
+	if err != nil || len(raw) != len(a) {
+		return a, fmt.Errorf("invalid address %q", s)
+	}
+	copy(a[:], raw)
+	return a, nil
+}
+
+func (a Address) String() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+// Word returns a as a 32-byte ABI word
+func (a Address) Word() []byte {
+	w := make([]byte, 32)
+	copy(w[12:], a[:])
+	return w
+}
+
+// UintWord returns v as a 32-byte ABI word
+func UintWord(v *big.Int) []byte {
+	return v.FillBytes(make([]byte, 32))
+}
+
+// BytesTail ABI-encodes dynamic bytes: the length word, then data padded to
+// a multiple of 32 bytes
+func BytesTail(data []byte) []byte {
+	tail := UintWord(big.NewInt(int64(len(data))))
+	tail = append(tail, data...)
+	if pad := len(data) % 32; pad != 0 {
+		tail = append(tail, make([]byte, 32-pad)...)
+	}
+	return tail
+}
+
+// EncodeCall builds call data from a selector and ABI words
+func EncodeCall(selector string, words ...[]byte) []byte {
+	data, _ := hex.DecodeString(selector)
+	for _, w := range words {
+		data = append(data, w...)
+	}
+	return data
+}
+
+// WordAt returns the i-th 32-byte word of ABI-encoded return data
+func WordAt(data []byte, i int) (*big.Int, error) {
+	if len(data) < 32*(i+1) {
+		return nil, fmt.Errorf("short return data: %d bytes", len(data))
+	}
+	return new(big.Int).SetBytes(data[32*i : 32*(i+1)]), nil
+}
+
+// DecodeHex reads 0x-prefixed hex data
+func DecodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(s, "0x")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
+
+// DecodeQuantity reads a 0x-prefixed hex quantity
+func DecodeQuantity(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(strings.TrimPrefix(s, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid quantity %q", s)
+	}
+	return v, nil
+}
+
+// ToUnits converts an amount to token units, rounding down
+func ToUnits(amount float64, decimals int) *big.Int {
+	scaled := new(big.Float).SetPrec(256).SetFloat64(amount)
+	scaled.Mul(scaled, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	units, _ := scaled.Int(nil)
+	return units
+}
+
+// FromUnits converts token units to an amount
+func FromUnits(units *big.Int, decimals int) float64 {
+	amount := new(big.Float).SetInt(units)
+	amount.Quo(amount, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	f, _ := amount.Float64()
+	return f
+}
@@ -0,0 +1,66 @@
+package evm
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	a, err := ParseAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	if err != nil {
+		t.Fatalf("ParseAddress: %v", err)
+	}
+	if got := a.String(); got != "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2" {
+		t.Errorf("String = %s", got)
+	}
+	if w := a.Word(); len(w) != 32 || w[11] != 0 || w[12] != 0xc0 {
+		t.Errorf("Word = %x", w)
+	}
+
+	for _, s := range []string{"", "0x1234", "0xzz2aaa39b223fe8d0a0e5c4f27ead9083c756cc2"} {
+		if _, err := ParseAddress(s); err == nil {
+			t.Errorf("ParseAddress(%q) succeeded", s)
+		}
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	tail := BytesTail([]byte{1, 2, 3})
+	if len(tail) != 64 || tail[31] != 3 || tail[32] != 1 || tail[35] != 0 {
+		t.Errorf("BytesTail = %x", tail)
+	}
+
+	data := EncodeCall("70a08231", UintWord(big.NewInt(7)))
+	if got := hex.EncodeToString(data[:4]); got != "70a08231" || len(data) != 36 {
+		t.Errorf("EncodeCall = %x", data)
+	}
+	if v, err := WordAt(data[4:], 0); err != nil || v.Int64() != 7 {
+		t.Errorf("WordAt = %v, %v", v, err)
+	}
+	if _, err := WordAt(data[4:], 1); err == nil {
+		t.Error("WordAt past the end succeeded")
+	}
+
+	if raw, err := DecodeHex("0x102"); err != nil || hex.EncodeToString(raw) != "0102" {
+		t.Errorf("DecodeHex = %x, %v", raw, err)
+	}
+	if v, err := DecodeQuantity("0x1bc16d674ec80000"); err != nil || v.String() != "2000000000000000000" {
+		t.Errorf("DecodeQuantity = %v, %v", v, err)
+	}
+	if _, err := DecodeQuantity("0x"); err == nil {
+		t.Error("DecodeQuantity of an empty quantity succeeded")
+	}
+}
+
+func TestUnits(t *testing.T) {
+	if got := ToUnits(1.5, 6).String(); got != "1500000" {
+		t.Errorf("ToUnits = %s", got)
+	}
+	if got := ToUnits(0.1234567, 6).String(); got != "123456" {
+		t.Errorf("ToUnits rounds to %s, want down", got)
+	}
+	if got := FromUnits(big.NewInt(2500000), 6); got != 2.5 {
+		t.Errorf("FromUnits = %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// RPCClient calls an Ethereum JSON-RPC endpoint
+type RPCClient struct {
+	url  string
+	http *http.Client
+	id   atomic.Int64
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// RPCError is an error answered by the endpoint, such as a reverted call
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// NewRPCClient creates a client of the endpoint at url
+func NewRPCClient(url string) *RPCClient {
+	return &RPCClient{url: url, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Call invokes method and decodes its result into result
+func (r *RPCClient) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: r.id.Add(1), Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed: HTTP %d", method, resp.StatusCode)
+	}
+
+	var response rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s failed: %w", method, response.Error)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// EthCall runs a read-only call against the latest block
+func (r *RPCClient) EthCall(ctx context.Context, to Address, data []byte) ([]byte, error) {
+	var result string
+	call := map[string]string{"to": to.String(), "data": "0x" + hex.EncodeToString(data)}
+	if err := r.Call(ctx, &result, "eth_call", call, "latest"); err != nil {
+		return nil, err
+	}
+	return DecodeHex(result)
+}
@@ -0,0 +1,300 @@
+package evm
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrReverted reports a transaction that failed on chain or in simulation
+var ErrReverted = errors.New("transaction reverted")
+
+// ErrNoBundles reports a bundle submission without a bundle endpoint
+var ErrNoBundles = errors.New("no bundle endpoint configured")
+
+// bundleBlocks is how many blocks a bundle is offered for before it is
+// dropped
+const bundleBlocks = 3
+
+// bundleGasLimit bounds each transaction of a bundle in its first
+// simulation, before its gas use is known
+const bundleGasLimit = 1_000_000
+
+// Call is a transaction to send: call data for a contract
+type Call struct {
+	To   Address
+	Data []byte
+}
+
+// Submitter sends transactions from an account whose key the RPC endpoint
+// holds (a local node, Clef or a signing proxy such as web3signer).
+//
+// Without a private endpoint transactions go to the public mempool through
+// eth_sendTransaction. A private endpoint such as Flashbots Protect gets
+// them simulated, priced and signed instead, so a transaction that would
+// revert is never sent and none can be front-run. A bundle endpoint (a
+// block builder accepting eth_callBundle and eth_sendBundle) takes several
+// transactions that must be mined together and in order, such as an
+// approval and the swap spending it.
+type Submitter struct {
+	rpc     *RPCClient
+	private *RPCClient // nil without a private endpoint
+	bundles *RPCClient // nil without a bundle endpoint
+	account Address
+}
+
+// NewSubmitter creates a submitter for account; privateURL and bundleURL
+// may be empty
+func NewSubmitter(rpc *RPCClient, account Address, privateURL, bundleURL string) *Submitter {
+	s := &Submitter{rpc: rpc, account: account}
+	if privateURL != "" {
+		s.private = NewRPCClient(privateURL)
+	}
+	if bundleURL != "" {
+		s.bundles = NewRPCClient(bundleURL)
+	}
+	return s
+}
+
+// Bundles reports whether SubmitBundle can be used
+func (s *Submitter) Bundles() bool {
+	return s.bundles != nil
+}
+
+// hexQuantity encodes v as a JSON-RPC quantity
+func hexQuantity(v *big.Int) string {
+	return "0x" + v.Text(16)
+}
+
+func (s *Submitter) transaction(call Call) map[string]string {
+	return map[string]string{"from": s.account.String(), "to": call.To.String(), "data": "0x" + hex.EncodeToString(call.Data)}
+}
+
+// Submit sends one transaction and returns its hash
+func (s *Submitter) Submit(ctx context.Context, call Call) (string, error) {
+	tx := s.transaction(call)
+	if s.private == nil {
+		var hash string
+		if err := s.rpc.Call(ctx, &hash, "eth_sendTransaction", tx); err != nil {
+			return "", err
+		}
+		return hash, nil
+	}
+
+	if err := s.simulate(ctx, tx); err != nil {
+		return "", err
+	}
+	var gas string
+	if err := s.rpc.Call(ctx, &gas, "eth_estimateGas", tx); err != nil {
+		return "", fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	gasLimit, err := DecodeQuantity(gas)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := s.nonce(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := s.price(ctx, tx); err != nil {
+		return "", err
+	}
+	tx["gas"], tx["nonce"] = hexQuantity(withHeadroom(gasLimit)), hexQuantity(nonce)
+	raw, err := s.sign(ctx, tx)
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	if err := s.private.Call(ctx, &hash, "eth_sendRawTransaction", raw); err != nil {
+		return "", fmt.Errorf("private submission failed: %w", err)
+	}
+	return hash, nil
+}
+
+// SubmitBundle sends calls as one bundle, mined together and in order or
+// not at all, and returns their hashes. The bundle is simulated with
+// eth_callBundle on top of the latest block, so it is never sent if any
+// call would revert; the simulation also sizes each call's gas limit. It is
+// then offered to the next few blocks with eth_sendBundle, and dropped when
+// none includes it.
+func (s *Submitter) SubmitBundle(ctx context.Context, calls ...Call) ([]string, error) {
+	if s.bundles == nil {
+		return nil, ErrNoBundles
+	}
+	nonce, err := s.nonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var head string
+	if err := s.rpc.Call(ctx, &head, "eth_blockNumber"); err != nil {
+		return nil, fmt.Errorf("failed to get block number: %w", err)
+	}
+	block, err := DecodeQuantity(head)
+	if err != nil {
+		return nil, err
+	}
+	fees := make(map[string]string)
+	if err := s.price(ctx, fees); err != nil {
+		return nil, err
+	}
+	txs := make([]map[string]string, len(calls))
+	for i, call := range calls {
+		txs[i] = s.transaction(call)
+		for field, value := range fees {
+			txs[i][field] = value
+		}
+		txs[i]["nonce"] = hexQuantity(new(big.Int).Add(nonce, big.NewInt(int64(i))))
+		txs[i]["gas"] = hexQuantity(big.NewInt(bundleGasLimit))
+	}
+	target := new(big.Int).Add(block, big.NewInt(1))
+
+	// Calls after the first may depend on it, so their gas cannot be
+	// estimated alone: simulate with a generous limit, then sign again with
+	// the gas each used
+	raws, err := s.signAll(ctx, txs)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.simulateBundle(ctx, raws, target)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range results {
+		txs[i]["gas"] = hexQuantity(withHeadroom(new(big.Int).SetUint64(result.GasUsed)))
+	}
+	if raws, err = s.signAll(ctx, txs); err != nil {
+		return nil, err
+	}
+	if results, err = s.simulateBundle(ctx, raws, target); err != nil {
+		return nil, err
+	}
+
+	for i := int64(0); i < bundleBlocks; i++ {
+		bundle := map[string]interface{}{"txs": raws, "blockNumber": hexQuantity(new(big.Int).Add(target, big.NewInt(i)))}
+		if err := s.bundles.Call(ctx, nil, "eth_sendBundle", bundle); err != nil {
+			return nil, fmt.Errorf("bundle submission failed: %w", err)
+		}
+	}
+	hashes := make([]string, len(results))
+	for i, result := range results {
+		hashes[i] = result.TxHash
+	}
+	return hashes, nil
+}
+
+// bundleResult is one transaction's outcome in eth_callBundle
+type bundleResult struct {
+	TxHash  string `json:"txHash"`
+	GasUsed uint64 `json:"gasUsed"`
+	Error   string `json:"error"`
+	Revert  string `json:"revert"`
+}
+
+// simulateBundle runs signed transactions with eth_callBundle as if mined
+// in block; any failing transaction is reported as ErrReverted
+func (s *Submitter) simulateBundle(ctx context.Context, raws []string, block *big.Int) ([]bundleResult, error) {
+	var simulation struct {
+		Results []bundleResult `json:"results"`
+	}
+	bundle := map[string]interface{}{"txs": raws, "blockNumber": hexQuantity(block), "stateBlockNumber": "latest"}
+	err := s.bundles.Call(ctx, &simulation, "eth_callBundle", bundle)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return nil, fmt.Errorf("%w in bundle simulation: %s", ErrReverted, rpcErr.Message)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate bundle: %w", err)
+	}
+	if len(simulation.Results) != len(raws) {
+		return nil, fmt.Errorf("bundle simulation returned %d results for %d transactions", len(simulation.Results), len(raws))
+	}
+	for i, result := range simulation.Results {
+		if result.Error != "" || result.Revert != "" {
+			return nil, fmt.Errorf("%w in bundle simulation of transaction %d: %s %s", ErrReverted, i, result.Error, result.Revert)
+		}
+	}
+	return simulation.Results, nil
+}
+
+// simulate runs tx with eth_call; a revert is reported as ErrReverted
+func (s *Submitter) simulate(ctx context.Context, tx map[string]string) error {
+	var result string
+	err := s.rpc.Call(ctx, &result, "eth_call", tx, "latest")
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return fmt.Errorf("%w in simulation: %s", ErrReverted, rpcErr.Message)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to simulate transaction: %w", err)
+	}
+	return nil
+}
+
+// nonce returns the account's next nonce, counting pending transactions
+func (s *Submitter) nonce(ctx context.Context) (*big.Int, error) {
+	var nonce string
+	if err := s.rpc.Call(ctx, &nonce, "eth_getTransactionCount", s.account.String(), "pending"); err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	return DecodeQuantity(nonce)
+}
+
+// price sets the EIP-1559 fees eth_signTransaction needs: the suggested tip,
+// and a fee cap of twice the latest base fee plus the tip so the
+// transaction survives a few blocks of rising fees
+func (s *Submitter) price(ctx context.Context, tx map[string]string) error {
+	var tip string
+	if err := s.rpc.Call(ctx, &tip, "eth_maxPriorityFeePerGas"); err != nil {
+		return fmt.Errorf("failed to get priority fee: %w", err)
+	}
+	var block struct {
+		BaseFee string `json:"baseFeePerGas"`
+	}
+	if err := s.rpc.Call(ctx, &block, "eth_getBlockByNumber", "latest", false); err != nil {
+		return fmt.Errorf("failed to get base fee: %w", err)
+	}
+	tipWei, err := DecodeQuantity(tip)
+	if err != nil {
+		return err
+	}
+	baseFee, err := DecodeQuantity(block.BaseFee)
+	if err != nil {
+		return err
+	}
+	maxFee := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tipWei)
+
+	tx["maxPriorityFeePerGas"] = hexQuantity(tipWei)
+	tx["maxFeePerGas"] = hexQuantity(maxFee)
+	return nil
+}
+
+// sign has the RPC endpoint sign tx and returns the raw transaction
+func (s *Submitter) sign(ctx context.Context, tx map[string]string) (string, error) {
+	var signed struct {
+		Raw string `json:"raw"`
+	}
+	if err := s.rpc.Call(ctx, &signed, "eth_signTransaction", tx); err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return signed.Raw, nil
+}
+
+func (s *Submitter) signAll(ctx context.Context, txs []map[string]string) ([]string, error) {
+	raws := make([]string, len(txs))
+	for i, tx := range txs {
+		raw, err := s.sign(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		raws[i] = raw
+	}
+	return raws, nil
+}
+
+// withHeadroom returns gas plus a fifth, for state that changes before
+// the transaction is mined
+func withHeadroom(gas *big.Int) *big.Int {
+	return new(big.Int).Quo(new(big.Int).Mul(gas, big.NewInt(6)), big.NewInt(5))
+}
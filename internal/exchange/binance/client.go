@@ -6,11 +6,14 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
@@ -41,7 +44,7 @@ type RetryConfig struct {
 
 // BinanceOrderResponse represents Binance order response
 type BinanceOrderResponse struct {
-	OrderID       string `json:"orderId"`
+	OrderID       int64  `json:"orderId"`
 	ClientOrderID string `json:"clientOrderId"`
 	Status        string `json:"status"`
 	TransactTime  int64  `json:"transactTime"`
@@ -60,12 +63,19 @@ type Client struct {
 	httpClient  *http.Client
 	rateLimiter *rate.Limiter
 	baseURL     string
+	futuresURL  string // USD-M futures API, used for /fapi endpoints
 
 	// Internal state
 	serverTimeOffset time.Duration
 	lastWeightUpdate time.Time
 	currentWeight    int
 
+	mu           sync.Mutex
+	orderSymbols map[string]string        // order id -> symbol, needed to cancel/query by id
+	filters      map[string]symbolFilters // exchange filters per symbol
+
+	fundingIntervals map[string]time.Duration // non-default funding intervals, nil until fetched
+
 	logger *logger.Logger
 }
 
@@ -75,7 +85,11 @@ func NewClient(config ExchangeConfig) (*Client, error) {
 		httpClient:  createHTTPClient(),
 		rateLimiter: rate.NewLimiter(rate.Limit(config.RateLimit.RequestsPerSecond), config.RateLimit.Burst),
 		baseURL:     getBinanceURL(config.Sandbox),
+		futuresURL:  getFuturesURL(config.Sandbox),
 		logger:      logger.New(logger.LevelInfo),
+
+		orderSymbols: make(map[string]string),
+		filters:      make(map[string]symbolFilters),
 	}
 
 	if err := client.syncServerTime(); err != nil {
@@ -86,25 +100,46 @@ func NewClient(config ExchangeConfig) (*Client, error) {
 }
 
 func (c *Client) PlaceOrder(ctx context.Context, order types.Order) error {
+	_, err := c.SubmitOrder(ctx, order)
+	return err
+}
+
+// SubmitOrder places an order and returns it with the exchange id and status
+func (c *Client) SubmitOrder(ctx context.Context, order types.Order) (*types.Order, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return fmt.Errorf("rate limit exceeded: %w", err)
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	// Round quantity/price to the symbol's lot size and tick size
+	filters, err := c.symbolFilters(ctx, order.Symbol)
+	if err != nil {
+		return nil, c.handleOrderError(err, order)
+	}
+	order = filters.apply(order)
+	// Binance would reject the order; say why without a round trip
+	if !filters.rules(order.Symbol).Tradable(order.Quantity, order.Price) {
+		return nil, fmt.Errorf("%s %.8f @ %.8f: %w", order.Symbol, order.Quantity, order.Price, types.ErrBelowMinimum)
 	}
 
 	params := c.buildOrderParams(order)
 
 	var response BinanceOrderResponse
 	if err := c.makeSignedRequest(ctx, "POST", "/api/v3/order", params, &response); err != nil {
-		return c.handleOrderError(err, order)
+		return nil, c.handleOrderError(err, order)
 	}
 
 	// Update order with exchange response
-	order.ID = response.OrderID
+	order.ID = strconv.FormatInt(response.OrderID, 10)
 	order.Status = c.mapBinanceOrderStatus(response.Status)
-	order.Timestamp = time.Unix(response.TransactTime/1000, 0)
+	order.Timestamp = time.UnixMilli(response.TransactTime)
+
+	c.mu.Lock()
+	c.orderSymbols[order.ID] = order.Symbol
+	c.mu.Unlock()
 
 	c.logger.Info("Order placed successfully: %s %.8f @ %.2f", order.Symbol, order.Quantity, order.Price)
 
-	return nil
+	return &order, nil
 }
 
 func (c *Client) CancelOrder(ctx context.Context, orderID string) error {
@@ -112,7 +147,13 @@ func (c *Client) CancelOrder(ctx context.Context, orderID string) error {
 		return fmt.Errorf("rate limit exceeded: %w", err)
 	}
 
+	symbol, err := c.orderSymbol(orderID)
+	if err != nil {
+		return err
+	}
+
 	params := map[string]interface{}{
+		"symbol":  symbol,
 		"orderId": orderID,
 	}
 
@@ -124,7 +165,13 @@ func (c *Client) GetOrder(ctx context.Context, orderID string) (*types.Order, er
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
 	}
 
+	symbol, err := c.orderSymbol(orderID)
+	if err != nil {
+		return nil, err
+	}
+
 	params := map[string]interface{}{
+		"symbol":  symbol,
 		"orderId": orderID,
 	}
 
@@ -136,6 +183,34 @@ func (c *Client) GetOrder(ctx context.Context, orderID string) (*types.Order, er
 	return c.parseOrderResponse(response), nil
 }
 
+// GetOrderByClientID queries a single order by its client order id, a far
+// lighter request than listing the symbol's orders
+func (c *Client) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	params := map[string]interface{}{
+		"symbol":            symbol,
+		"origClientOrderId": clientOrderID,
+	}
+
+	var response map[string]interface{}
+	if err := c.makeSignedRequest(ctx, "GET", "/api/v3/order", params, &response); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == errNoSuchOrder {
+			return nil, fmt.Errorf("%w: client id %s", types.ErrOrderNotFound, clientOrderID)
+		}
+		return nil, err
+	}
+
+	order := c.parseOrderResponse(response)
+	c.mu.Lock()
+	c.orderSymbols[order.ID] = symbol
+	c.mu.Unlock()
+	return order, nil
+}
+
 func (c *Client) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
@@ -151,9 +226,12 @@ func (c *Client) GetActiveOrders(ctx context.Context, symbol string) ([]types.Or
 	}
 
 	orders := make([]types.Order, len(response))
+	c.mu.Lock()
 	for i, orderData := range response {
 		orders[i] = *c.parseOrderResponse(orderData)
+		c.orderSymbols[orders[i].ID] = symbol
 	}
+	c.mu.Unlock()
 
 	return orders, nil
 }
@@ -175,7 +253,7 @@ func (c *Client) GetFilledOrders(ctx context.Context, symbol string) ([]types.Or
 
 	orders := make([]types.Order, 0, len(response))
 	for _, orderData := range response {
-		if status, ok := orderData["status"].(string); ok && status == "FILLED" {
+		if isFilled(orderData) {
 			order := c.parseOrderResponse(orderData)
 			orders = append(orders, *order)
 		}
@@ -184,6 +262,14 @@ func (c *Client) GetFilledOrders(ctx context.Context, symbol string) ([]types.Or
 	return orders, nil
 }
 
+// isFilled reports whether an order executed, in full or in part
+func isFilled(data map[string]interface{}) bool {
+	status, _ := data["status"].(string)
+	// Canceled and expired orders are final; keep any part that filled
+	partial := (status == "CANCELED" || status == "EXPIRED") && parseNumber(data["executedQty"]) > 0
+	return status == "FILLED" || partial
+}
+
 func (c *Client) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
@@ -201,6 +287,39 @@ func (c *Client) GetTicker(ctx context.Context, symbol string) (*types.Ticker, e
 	return c.parseTickerResponse(response), nil
 }
 
+// tickersPerRequest caps the symbols of one bulk ticker request, keeping
+// its URL short
+const tickersPerRequest = 100
+
+// GetTickers fetches the 24h tickers of symbols with the bulk ticker
+// endpoint, one request per tickersPerRequest symbols
+func (c *Client) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	tickers := make([]types.Ticker, 0, len(symbols))
+	for start := 0; start < len(symbols); start += tickersPerRequest {
+		batch := symbols[start:min(start+tickersPerRequest, len(symbols))]
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limit exceeded: %w", err)
+		}
+
+		list, err := json.Marshal(batch)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"symbols": string(list),
+		}
+
+		var response []map[string]interface{}
+		if err := c.makeRequest(ctx, "GET", "/api/v3/ticker/24hr", params, &response); err != nil {
+			return nil, err
+		}
+		for _, data := range response {
+			tickers = append(tickers, *c.parseTickerResponse(data))
+		}
+	}
+	return tickers, nil
+}
+
 func (c *Client) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
@@ -216,7 +335,7 @@ func (c *Client) GetOrderBook(ctx context.Context, symbol string, limit int) (*t
 		return nil, err
 	}
 
-	return c.parseOrderBookResponse(response), nil
+	return c.parseOrderBookResponse(symbol, response), nil
 }
 
 func (c *Client) GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error) {
@@ -238,7 +357,68 @@ func (c *Client) GetCandles(ctx context.Context, symbol string, interval string,
 	return c.parseCandlesResponse(response), nil
 }
 
+// GetCandlesRange fetches all candles within [start, end], paging through klines
+func (c *Client) GetCandlesRange(ctx context.Context, symbol string, interval string, start, end time.Time) ([]types.Candle, error) {
+	const pageLimit = 1000
+
+	var candles []types.Candle
+	from := start
+	for from.Before(end) {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limit exceeded: %w", err)
+		}
+
+		params := map[string]interface{}{
+			"symbol":    symbol,
+			"interval":  interval,
+			"startTime": from.UnixMilli(),
+			"endTime":   end.UnixMilli(),
+			"limit":     pageLimit,
+		}
+
+		var response [][]interface{}
+		if err := c.makeRequest(ctx, "GET", "/api/v3/klines", params, &response); err != nil {
+			return nil, err
+		}
+
+		page := c.parseCandlesResponse(response)
+		for i := range page {
+			page[i].Symbol = symbol
+		}
+		candles = append(candles, page...)
+
+		if len(page) < pageLimit {
+			break
+		}
+		from = page[len(page)-1].Timestamp.Add(time.Millisecond)
+	}
+
+	return candles, nil
+}
+
 func (c *Client) GetBalance(ctx context.Context) (*types.Balance, error) {
+	balances, err := c.accountBalances(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// For simplicity, return USDT balance
+	for _, balance := range balances {
+		if balance.Asset == "USDT" {
+			return &balance, nil
+		}
+	}
+
+	return nil, fmt.Errorf("USDT balance not found")
+}
+
+// GetBalances returns every asset with a non-zero balance
+func (c *Client) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	return c.accountBalances(ctx, false)
+}
+
+// accountBalances parses the balances of /api/v3/account, optionally keeping empty ones
+func (c *Client) accountBalances(ctx context.Context, includeEmpty bool) ([]types.Balance, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
 	}
@@ -249,31 +429,34 @@ func (c *Client) GetBalance(ctx context.Context) (*types.Balance, error) {
 	}
 
 	// Parse balances from account info
-	balances, ok := response["balances"].([]interface{})
+	entries, ok := response["balances"].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("invalid balance response")
 	}
 
-	// For simplicity, return USDT balance
-	for _, balance := range balances {
-		if balanceMap, ok := balance.(map[string]interface{}); ok {
-			if asset, ok := balanceMap["asset"].(string); ok && asset == "USDT" {
-				free, _ := strconv.ParseFloat(balanceMap["free"].(string), 64)
-				locked, _ := strconv.ParseFloat(balanceMap["locked"].(string), 64)
-				total := free + locked
-
-				return &types.Balance{
-					Asset:     asset,
-					Free:      free,
-					Locked:    locked,
-					Total:     total,
-					Timestamp: time.Now(),
-				}, nil
-			}
+	now := time.Now()
+	balances := make([]types.Balance, 0, len(entries))
+	for _, entry := range entries {
+		balanceMap, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		asset, _ := balanceMap["asset"].(string)
+		free := parseNumber(balanceMap["free"])
+		locked := parseNumber(balanceMap["locked"])
+		if asset == "" || (!includeEmpty && free+locked == 0) {
+			continue
 		}
+		balances = append(balances, types.Balance{
+			Asset:     asset,
+			Free:      free,
+			Locked:    locked,
+			Total:     free + locked,
+			Timestamp: now,
+		})
 	}
 
-	return nil, fmt.Errorf("USDT balance not found")
+	return balances, nil
 }
 
 func (c *Client) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
@@ -286,6 +469,35 @@ func (c *Client) GetTradingFees(ctx context.Context, symbol string) (*types.Trad
 	}, nil
 }
 
+// systemStatusResponse is the /sapi/v1/system/status payload (0 normal, 1 maintenance)
+type systemStatusResponse struct {
+	Status int    `json:"status"`
+	Msg    string `json:"msg"`
+}
+
+// GetSystemStatus reports whether Binance is under system maintenance.
+// The spot testnet has no /sapi endpoints, so sandbox clients return
+// types.ErrNotSupported.
+func (c *Client) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	if c.config.Sandbox {
+		return nil, fmt.Errorf("system status on testnet: %w", types.ErrNotSupported)
+	}
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	var response systemStatusResponse
+	if err := c.makeRequest(ctx, "GET", "/sapi/v1/system/status", nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get system status: %w", err)
+	}
+
+	state := types.SystemNormal
+	if response.Status != 0 {
+		state = types.SystemMaintenance
+	}
+	return &types.SystemStatus{State: state, Message: response.Msg, Timestamp: time.Now()}, nil
+}
+
 func (c *Client) Ping(ctx context.Context) error {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return fmt.Errorf("rate limit exceeded: %w", err)
@@ -313,6 +525,13 @@ func getBinanceURL(sandbox bool) string {
 	return "https://api.binance.com"
 }
 
+func getFuturesURL(sandbox bool) string {
+	if sandbox {
+		return "https://testnet.binancefuture.com"
+	}
+	return "https://fapi.binance.com"
+}
+
 func (c *Client) syncServerTime() error {
 	var response map[string]interface{}
 	if err := c.makeRequest(context.Background(), "GET", "/api/v3/time", nil, &response); err != nil {
@@ -341,36 +560,55 @@ func (c *Client) buildOrderParams(order types.Order) map[string]interface{} {
 		params["timeInForce"] = "GTC"
 	}
 
+	if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID != "" {
+		params["newClientOrderId"] = order.ExchangeOrder.ClientOrderID
+	}
+
 	return params
 }
 
 func (c *Client) makeSignedRequest(ctx context.Context, method, endpoint string, params map[string]interface{}, result interface{}) error {
-	timestamp := time.Now().Add(c.serverTimeOffset).UnixNano() / 1e6
-	params["timestamp"] = timestamp
+	signed := make(map[string]interface{}, len(params)+2)
+	for key, value := range params {
+		signed[key] = value
+	}
+	signed["timestamp"] = time.Now().Add(c.serverTimeOffset).UnixMilli()
+	signed["recvWindow"] = 5000
 
-	signature := c.generateSignature(params)
-	params["signature"] = signature
+	// The signature covers the exact query string and must be its last parameter
+	query := encodeParams(signed)
+	query += "&signature=" + c.generateSignature(query)
 
-	return c.makeRequest(ctx, method, endpoint, params, result)
+	return c.doRequest(ctx, method, endpoint, query, result)
 }
 
 func (c *Client) makeRequest(ctx context.Context, method, endpoint string, params map[string]interface{}, result interface{}) error {
-	url := c.baseURL + endpoint
+	return c.doRequest(ctx, method, endpoint, encodeParams(params), result)
+}
 
-	var req *http.Request
-	var err error
+func (c *Client) doRequest(ctx context.Context, method, endpoint, query string, result interface{}) error {
+	requestURL := c.baseURL + endpoint
+	if strings.HasPrefix(endpoint, "/fapi/") {
+		requestURL = c.futuresURL + endpoint
+	}
 
-	if method == "GET" {
-		req, err = c.buildGETRequest(ctx, url, params)
+	var body io.Reader
+	if method == http.MethodGet {
+		if query != "" {
+			requestURL += "?" + query
+		}
 	} else {
-		req, err = c.buildPOSTRequest(ctx, url, params)
+		body = strings.NewReader(query)
 	}
 
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
 	if err != nil {
 		return fmt.Errorf("failed to build request: %w", err)
 	}
-
-	if method != "GET" {
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.config.APIKey != "" {
 		req.Header.Set("X-MBX-APIKEY", c.config.APIKey)
 	}
 
@@ -387,49 +625,47 @@ func (c *Client) makeRequest(ctx context.Context, method, endpoint string, param
 	return nil
 }
 
-func (c *Client) buildGETRequest(ctx context.Context, requestURL string, params map[string]interface{}) (*http.Request, error) {
-	if len(params) > 0 {
-		values := make(map[string][]string)
-		for key, value := range params {
-			values[key] = []string{fmt.Sprintf("%v", value)}
+// encodeParams builds a deterministic (key-sorted) query string
+func encodeParams(params map[string]interface{}) string {
+	values := make(url.Values, len(params))
+	for key, value := range params {
+		// Lists repeat the key, e.g. asset=BTC&asset=ETH
+		if list, ok := value.([]string); ok {
+			for _, item := range list {
+				values.Add(key, item)
+			}
+			continue
 		}
-		query := url.Values(values)
-		requestURL += "?" + query.Encode()
+		values.Set(key, fmt.Sprintf("%v", value))
 	}
-
-	return http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	return values.Encode()
 }
 
-func (c *Client) buildPOSTRequest(ctx context.Context, requestURL string, params map[string]interface{}) (*http.Request, error) {
-	values := make(map[string][]string)
-	for key, value := range params {
-		values[key] = []string{fmt.Sprintf("%v", value)}
-	}
-	query := url.Values(values)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, strings.NewReader(query.Encode()))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	return req, nil
+func (c *Client) generateSignature(query string) string {
+	h := hmac.New(sha256.New, []byte(c.config.SecretKey))
+	h.Write([]byte(query))
+	return hex.EncodeToString(h.Sum(nil))
 }
 
-func (c *Client) generateSignature(params map[string]interface{}) string {
-	query := make(url.Values)
-	for key, value := range params {
-		query.Set(key, fmt.Sprintf("%v", value))
-	}
+// APIError is an error response returned by the Binance API
+type APIError struct {
+	StatusCode int
+	Code       int    `json:"code"`
+	Message    string `json:"msg"`
+}
 
-	h := hmac.New(sha256.New, []byte(c.config.SecretKey))
-	h.Write([]byte(query.Encode()))
-	return hex.EncodeToString(h.Sum(nil))
+func (e *APIError) Error() string {
+	return fmt.Sprintf("binance API error %d (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
 }
 
 func (c *Client) handleHTTPResponse(resp *http.Response, result interface{}) error {
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return apiErr
 	}
 
 	if result != nil {
@@ -439,6 +675,18 @@ func (c *Client) handleHTTPResponse(resp *http.Response, result interface{}) err
 	return nil
 }
 
+// orderSymbol returns the symbol of an order placed or listed by this client
+func (c *Client) orderSymbol(orderID string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	symbol, ok := c.orderSymbols[orderID]
+	if !ok {
+		return "", fmt.Errorf("unknown order %s: symbol is required by Binance", orderID)
+	}
+	return symbol, nil
+}
+
 func (c *Client) handleOrderError(err error, order types.Order) error {
 	c.logger.Error("Order placement failed: %v", err)
 	return fmt.Errorf("order placement failed: %w", err)
@@ -452,7 +700,7 @@ func (c *Client) mapBinanceOrderStatus(status string) types.OrderStatus {
 		return types.OrderStatusPartiallyFilled
 	case "FILLED":
 		return types.OrderStatusFilled
-	case "CANCELED":
+	case "CANCELED", "EXPIRED":
 		return types.OrderStatusCanceled
 	case "REJECTED":
 		return types.OrderStatusRejected
@@ -462,20 +710,33 @@ func (c *Client) mapBinanceOrderStatus(status string) types.OrderStatus {
 }
 
 func (c *Client) parseOrderResponse(data map[string]interface{}) *types.Order {
-	orderID, _ := data["orderId"].(string)
+	orderID, _ := data["orderId"].(float64)
 	symbol, _ := data["symbol"].(string)
 	side, _ := data["side"].(string)
 	orderType, _ := data["type"].(string)
 	status, _ := data["status"].(string)
 
-	quantity, _ := strconv.ParseFloat(data["origQty"].(string), 64)
-	price, _ := strconv.ParseFloat(data["price"].(string), 64)
-	filledQty, _ := strconv.ParseFloat(data["executedQty"].(string), 64)
+	clientOrderID, _ := data["clientOrderId"].(string)
+
+	quantity := parseNumber(data["origQty"])
+	price := parseNumber(data["price"])
+	filledQty := parseNumber(data["executedQty"])
 
-	transactTime, _ := data["transactTime"].(float64)
+	// Market orders report price 0; their average fill price is quote / base
+	filledPrice := price
+	if quote := parseNumber(data["cummulativeQuoteQty"]); quote > 0 && filledQty > 0 {
+		filledPrice = quote / filledQty
+	}
 
+	// Placement responses carry transactTime, order queries carry time
+	transactTime, ok := data["transactTime"].(float64)
+	if !ok {
+		transactTime, _ = data["time"].(float64)
+	}
+
+	id := strconv.FormatInt(int64(orderID), 10)
 	return &types.Order{
-		ID:           orderID,
+		ID:           id,
 		Symbol:       symbol,
 		Side:         types.OrderSide(side),
 		Type:         types.OrderType(orderType),
@@ -483,15 +744,27 @@ func (c *Client) parseOrderResponse(data map[string]interface{}) *types.Order {
 		Price:        price,
 		Status:       c.mapBinanceOrderStatus(status),
 		FilledAmount: filledQty,
-		FilledPrice:  price,
-		Timestamp:    time.Unix(int64(transactTime)/1000, 0),
+		FilledPrice:  filledPrice,
+		Timestamp:    time.UnixMilli(int64(transactTime)),
+		ExchangeOrder: &types.ExchangeOrder{
+			ExchangeOrderID: id,
+			Exchange:        "binance",
+			ClientOrderID:   clientOrderID,
+		},
 	}
 }
 
+// parseNumber parses Binance decimal strings, returning 0 when absent
+func parseNumber(v interface{}) float64 {
+	s, _ := v.(string)
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
 func (c *Client) parseTickerResponse(data map[string]interface{}) *types.Ticker {
 	symbol, _ := data["symbol"].(string)
-	price, _ := strconv.ParseFloat(data["lastPrice"].(string), 64)
-	volume, _ := strconv.ParseFloat(data["volume"].(string), 64)
+	price := parseNumber(data["lastPrice"])
+	volume := parseNumber(data["volume"])
 	timestamp, _ := data["closeTime"].(float64)
 
 	return &types.Ticker{
@@ -504,31 +777,28 @@ func (c *Client) parseTickerResponse(data map[string]interface{}) *types.Ticker
 	}
 }
 
-func (c *Client) parseOrderBookResponse(data map[string]interface{}) *types.OrderBook {
-	symbol, _ := data["symbol"].(string)
-
-	bidsData, _ := data["bids"].([][]interface{})
-	asksData, _ := data["asks"].([][]interface{})
-
-	bids := make([]types.OrderBookEntry, len(bidsData))
-	for i, bid := range bidsData {
-		price, _ := strconv.ParseFloat(bid[0].(string), 64)
-		amount, _ := strconv.ParseFloat(bid[1].(string), 64)
-		bids[i] = types.OrderBookEntry{Price: price, Amount: amount}
-	}
-
-	asks := make([]types.OrderBookEntry, len(asksData))
-	for i, ask := range asksData {
-		price, _ := strconv.ParseFloat(ask[0].(string), 64)
-		amount, _ := strconv.ParseFloat(ask[1].(string), 64)
-		asks[i] = types.OrderBookEntry{Price: price, Amount: amount}
-	}
+func (c *Client) parseOrderBookResponse(symbol string, data map[string]interface{}) *types.OrderBook {
+	bidsData, _ := data["bids"].([]interface{})
+	asksData, _ := data["asks"].([]interface{})
 
 	return &types.OrderBook{
 		Symbol: symbol,
-		Bids:   bids,
-		Asks:   asks,
+		Bids:   parseBookSide(bidsData),
+		Asks:   parseBookSide(asksData),
+	}
+}
+
+// parseBookSide parses [["price", "qty"], ...] depth levels
+func parseBookSide(levels []interface{}) []types.OrderBookEntry {
+	entries := make([]types.OrderBookEntry, 0, len(levels))
+	for _, level := range levels {
+		pair, ok := level.([]interface{})
+		if !ok || len(pair) < 2 {
+			continue
+		}
+		entries = append(entries, types.OrderBookEntry{Price: parseNumber(pair[0]), Amount: parseNumber(pair[1])})
 	}
+	return entries
 }
 
 func (c *Client) parseCandlesResponse(data [][]interface{}) []types.Candle {
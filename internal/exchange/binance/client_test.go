@@ -0,0 +1,586 @@
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+	"golang.org/x/time/rate"
+)
+
+const (
+	testAPIKey    = "test-key"
+	testSecretKey = "test-secret"
+)
+
+// recordedRequest is a request seen by the fake Binance server
+type recordedRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Query  string // raw query or form body
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *[]recordedRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.RawQuery
+		if r.Body != nil {
+			body, _ := io.ReadAll(r.Body)
+			if len(body) > 0 {
+				query = string(body)
+			}
+		}
+		mu.Lock()
+		requests = append(requests, recordedRequest{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Query: query})
+		mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &Client{
+		config:       ExchangeConfig{APIKey: testAPIKey, SecretKey: testSecretKey},
+		httpClient:   srv.Client(),
+		rateLimiter:  rate.NewLimiter(rate.Inf, 1),
+		baseURL:      srv.URL,
+		futuresURL:   srv.URL,
+		logger:       logger.New(logger.LevelError),
+		orderSymbols: make(map[string]string),
+		filters:      make(map[string]symbolFilters),
+	}
+	return client, &requests
+}
+
+// verifySignature checks that signature is the last parameter and signs everything before it
+func verifySignature(t *testing.T, query string) {
+	t.Helper()
+
+	idx := strings.LastIndex(query, "&signature=")
+	if idx < 0 {
+		t.Fatalf("signature missing from %q", query)
+	}
+	payload, signature := query[:idx], query[idx+len("&signature="):]
+	if strings.Contains(signature, "&") {
+		t.Fatalf("signature is not the last parameter: %q", query)
+	}
+
+	mac := hmac.New(sha256.New, []byte(testSecretKey))
+	mac.Write([]byte(payload))
+	if want := hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Fatalf("signature = %s, want %s", signature, want)
+	}
+
+	values, _ := url.ParseQuery(payload)
+	if values.Get("timestamp") == "" || values.Get("recvWindow") == "" {
+		t.Fatalf("timestamp/recvWindow missing from %q", payload)
+	}
+}
+
+func TestGetBalanceSignsRequest(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"balances":[{"asset":"BTC","free":"0.1","locked":"0"},{"asset":"USDT","free":"100.5","locked":"20.25"}]}`)
+	})
+
+	balance, err := client.GetBalance(context.Background())
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance.Free != 100.5 || balance.Locked != 20.25 || balance.Total != 120.75 {
+		t.Fatalf("unexpected balance %+v", balance)
+	}
+
+	req := (*requests)[0]
+	if req.Method != http.MethodGet || req.Path != "/api/v3/account" {
+		t.Fatalf("unexpected request %s %s", req.Method, req.Path)
+	}
+	if got := req.Header.Get("X-MBX-APIKEY"); got != testAPIKey {
+		t.Fatalf("X-MBX-APIKEY = %q on GET", got)
+	}
+	verifySignature(t, req.Query)
+}
+
+func TestGetFilledOrders(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"orderId":1,"clientOrderId":"j-1","symbol":"BTCUSDT","side":"BUY","type":"MARKET","status":"FILLED","origQty":"0.002","price":"0","executedQty":"0.002","cummulativeQuoteQty":"90.5","time":1700000000000},
+			{"orderId":2,"clientOrderId":"j-2","symbol":"BTCUSDT","side":"BUY","type":"LIMIT","status":"CANCELED","origQty":"0.002","price":"40000","executedQty":"0","time":1700000001000},
+			{"orderId":3,"clientOrderId":"j-3","symbol":"BTCUSDT","side":"SELL","type":"MARKET","status":"EXPIRED","origQty":"0.002","price":"0","executedQty":"0.001","cummulativeQuoteQty":"45","time":1700000002000}]`)
+	})
+
+	orders, err := client.GetFilledOrders(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetFilledOrders: %v", err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("got %d filled orders, want 2", len(orders))
+	}
+	if got := orders[0]; got.ExchangeOrder == nil || got.ExchangeOrder.ClientOrderID != "j-1" || got.FilledPrice != 45250 {
+		t.Fatalf("unexpected filled order %+v", got)
+	}
+	// An expired market order reports the part that filled
+	if got := orders[1]; got.Status != types.OrderStatusCanceled || got.FilledAmount != 0.001 || got.FilledPrice != 45000 {
+		t.Fatalf("unexpected partially filled order %+v", got)
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v3/exchangeInfo":
+			fmt.Fprint(w, `{"symbols":[{"symbol":"BTCUSDT","filters":[
+				{"filterType":"PRICE_FILTER","tickSize":"0.01000000"},
+				{"filterType":"LOT_SIZE","stepSize":"0.00001000","minQty":"0.00001000"}]}]}`)
+		case r.URL.Path == "/api/v3/order" && r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"orderId":28457,"clientOrderId":"abc","status":"NEW","transactTime":1700000000123}`)
+		case r.URL.Path == "/api/v3/order" && r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"orderId":28457,"symbol":"BTCUSDT","side":"BUY","type":"LIMIT","status":"NEW","origQty":"0.00123000","price":"20000.12000000","executedQty":"0.00000000","time":1700000000123}`)
+		case r.URL.Path == "/api/v3/order" && r.Method == http.MethodDelete:
+			fmt.Fprint(w, `{"orderId":28457,"status":"CANCELED"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	placed, err := client.SubmitOrder(ctx, types.Order{
+		Symbol:        "BTCUSDT",
+		Side:          types.OrderSideBuy,
+		Type:          types.OrderTypeLimit,
+		Quantity:      0.0012345678,
+		Price:         20000.123456,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "abc"},
+	})
+	if err != nil {
+		t.Fatalf("SubmitOrder: %v", err)
+	}
+	if placed.ID != "28457" || placed.Status != types.OrderStatusNew {
+		t.Fatalf("unexpected placed order %+v", placed)
+	}
+	if placed.Quantity != 0.00123 || placed.Price != 20000.12 {
+		t.Fatalf("order not rounded to filters: qty=%v price=%v", placed.Quantity, placed.Price)
+	}
+
+	post := (*requests)[1]
+	verifySignature(t, post.Query)
+	form, _ := url.ParseQuery(post.Query)
+	if form.Get("quantity") != "0.00123000" || form.Get("price") != "20000.12000000" || form.Get("timeInForce") != "GTC" || form.Get("newClientOrderId") != "abc" {
+		t.Fatalf("unexpected order params %v", form)
+	}
+
+	order, err := client.GetOrder(ctx, placed.ID)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if order.ID != "28457" || order.Quantity != 0.00123 || order.Timestamp.UnixMilli() != 1700000000123 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+
+	if err := client.CancelOrder(ctx, placed.ID); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	cancel := (*requests)[3]
+	if cancel.Method != http.MethodDelete {
+		t.Fatalf("cancel sent as %s", cancel.Method)
+	}
+	verifySignature(t, cancel.Query)
+	if form, _ := url.ParseQuery(cancel.Query); form.Get("symbol") != "BTCUSDT" || form.Get("orderId") != "28457" {
+		t.Fatalf("unexpected cancel params %v", form)
+	}
+
+	// Filters are cached per symbol
+	for _, req := range (*requests)[1:] {
+		if req.Path == "/api/v3/exchangeInfo" {
+			t.Fatalf("exchange info fetched more than once")
+		}
+	}
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	start := time.UnixMilli(1700000000000)
+	hour := int64(time.Hour / time.Millisecond)
+	order := func(id int64, status, executed string, at int64) string {
+		return fmt.Sprintf(`{"orderId":%d,"symbol":"BTCUSDT","side":"BUY","type":"LIMIT","status":%q,"origQty":"0.002","price":"40000","executedQty":%q,"time":%d}`,
+			id, status, executed, start.UnixMilli()+at*hour)
+	}
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		values := r.URL.Query()
+		switch {
+		case values.Get("startTime") == strconv.FormatInt(start.UnixMilli(), 10):
+			fmt.Fprint(w, `[]`) // nothing on the first day
+		case values.Get("startTime") != "":
+			fmt.Fprintf(w, "[%s,%s]", order(10, "FILLED", "0.002", 30), order(11, "CANCELED", "0", 31))
+		case values.Get("orderId") == "12":
+			fmt.Fprintf(w, "[%s,%s]", order(12, "FILLED", "0.002", 50), order(13, "FILLED", "0.002", 80))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	query := types.OrderHistoryQuery{Symbol: "BTCUSDT", Start: start, End: start.Add(72 * time.Hour), Limit: 2}
+	var ids []string
+	var cursors []string
+	for {
+		page, err := client.GetOrderHistory(context.Background(), query)
+		if err != nil {
+			t.Fatalf("GetOrderHistory: %v", err)
+		}
+		for _, o := range page.Orders {
+			ids = append(ids, o.ID)
+		}
+		if page.Next == "" {
+			break
+		}
+		cursors = append(cursors, page.Next)
+		query.Cursor = page.Next
+	}
+
+	// The empty first day is skipped, paging continues by id and stops past End
+	if strings.Join(ids, ",") != "10,12" {
+		t.Fatalf("filled orders = %v, want 10,12", ids)
+	}
+	wantCursors := []string{"t:" + strconv.FormatInt(start.UnixMilli()+24*hour, 10), "id:12"}
+	if strings.Join(cursors, ",") != strings.Join(wantCursors, ",") {
+		t.Fatalf("cursors = %v, want %v", cursors, wantCursors)
+	}
+	first, _ := url.ParseQuery((*requests)[0].Query)
+	if first.Get("endTime") != strconv.FormatInt(start.UnixMilli()+24*hour-1, 10) || first.Get("limit") != "2" {
+		t.Fatalf("unexpected first window %v", first)
+	}
+	verifySignature(t, (*requests)[0].Query)
+
+	if _, err := client.GetOrderHistory(context.Background(), types.OrderHistoryQuery{Symbol: "BTCUSDT", Cursor: "bogus"}); err == nil {
+		t.Fatal("expected an error for an invalid cursor")
+	}
+}
+
+func TestCancelUnknownOrder(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	if err := client.CancelOrder(context.Background(), "42"); err == nil {
+		t.Fatalf("expected error for order without known symbol")
+	}
+	if len(*requests) != 0 {
+		t.Fatalf("request sent for unknown order")
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":-1022,"msg":"Signature for this request is not valid."}`)
+	})
+
+	_, err := client.GetBalance(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != -1022 || !strings.Contains(apiErr.Message, "Signature") {
+		t.Fatalf("unexpected API error %+v", apiErr)
+	}
+}
+
+func TestGetOrderBook(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"lastUpdateId":1,"bids":[["100.5","2.0"],["100.4","1.5"]],"asks":[["100.6","3.0"]]}`)
+	})
+
+	book, err := client.GetOrderBook(context.Background(), "BTCUSDT", 5)
+	if err != nil {
+		t.Fatalf("GetOrderBook: %v", err)
+	}
+	if book.Symbol != "BTCUSDT" || len(book.Bids) != 2 || len(book.Asks) != 1 {
+		t.Fatalf("unexpected book %+v", book)
+	}
+	if book.Bids[1].Price != 100.4 || book.Asks[0].Amount != 3.0 {
+		t.Fatalf("unexpected levels %+v", book)
+	}
+}
+
+func TestGetTickers(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var symbols []string
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("symbols")), &symbols); err != nil {
+			t.Errorf("symbols: %v", err)
+		}
+		var out []string
+		for _, symbol := range symbols {
+			out = append(out, fmt.Sprintf(`{"symbol":%q,"lastPrice":"100.5","volume":"10"}`, symbol))
+		}
+		fmt.Fprint(w, "["+strings.Join(out, ",")+"]")
+	})
+
+	symbols := make([]string, tickersPerRequest+1)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("COIN%dUSDT", i)
+	}
+	tickers, err := client.GetTickers(context.Background(), symbols)
+	if err != nil {
+		t.Fatalf("GetTickers: %v", err)
+	}
+	if len(tickers) != len(symbols) || tickers[0].Symbol != "COIN0USDT" || tickers[0].Price != 100.5 {
+		t.Fatalf("unexpected tickers %+v", tickers[:1])
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("sent %d requests, want 2 batches", len(*requests))
+	}
+	if req := (*requests)[0]; req.Path != "/api/v3/ticker/24hr" {
+		t.Fatalf("unexpected path %s", req.Path)
+	}
+}
+
+func TestGetSystemStatus(t *testing.T) {
+	status := `{"status":0,"msg":"normal"}`
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sapi/v1/system/status" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, status)
+	})
+
+	got, err := client.GetSystemStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetSystemStatus: %v", err)
+	}
+	if !got.Operational() {
+		t.Fatalf("status = %+v, want normal", got)
+	}
+
+	status = `{"status":1,"msg":"system maintenance"}`
+	got, err = client.GetSystemStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetSystemStatus: %v", err)
+	}
+	if got.State != types.SystemMaintenance || got.Message != "system maintenance" {
+		t.Fatalf("status = %+v, want maintenance", got)
+	}
+}
+
+func TestGetFundingRate(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/premiumIndex":
+			fmt.Fprint(w, `{"symbol":"BTCUSDT","markPrice":"45000.5","lastFundingRate":"0.00012","nextFundingTime":1704096000000,"time":1704090000000}`)
+		case "/fapi/v1/fundingInfo":
+			fmt.Fprint(w, `[{"symbol":"ETHUSDT","fundingIntervalHours":4}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rate, err := client.GetFundingRate(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetFundingRate: %v", err)
+	}
+	if rate.Rate != 0.00012 || rate.MarkPrice != 45000.5 || rate.Interval != 8*time.Hour {
+		t.Fatalf("unexpected funding rate %+v", rate)
+	}
+	if rate.NextFundingTime.UnixMilli() != 1704096000000 {
+		t.Fatalf("NextFundingTime = %v", rate.NextFundingTime)
+	}
+
+	rate, err = client.GetFundingRate(context.Background(), "ETHUSDT")
+	if err != nil {
+		t.Fatalf("GetFundingRate: %v", err)
+	}
+	if rate.Interval != 4*time.Hour {
+		t.Fatalf("ETHUSDT interval = %v, want 4h", rate.Interval)
+	}
+
+	// The interval table is fetched once
+	var infoRequests int
+	for _, req := range *requests {
+		if req.Path == "/fapi/v1/fundingInfo" {
+			infoRequests++
+		}
+	}
+	if infoRequests != 1 {
+		t.Fatalf("fundingInfo requested %d times, want 1", infoRequests)
+	}
+}
+
+func TestGetBorrowRates(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"asset":"BTC","nextHourlyInterestRate":"0.00000571"},{"asset":"USDT","nextHourlyInterestRate":"0.0000041"}]`)
+	})
+
+	rates, err := client.GetBorrowRates(context.Background(), []string{"btc", "usdt"})
+	if err != nil {
+		t.Fatalf("GetBorrowRates: %v", err)
+	}
+	if len(rates) != 2 || rates[0].Asset != "BTC" || rates[0].HourlyRate != 0.00000571 || rates[1].HourlyRate != 0.0000041 {
+		t.Fatalf("unexpected rates %+v", rates)
+	}
+
+	req := (*requests)[0]
+	if req.Path != "/sapi/v1/margin/next-hourly-interest-rate" {
+		t.Fatalf("unexpected path %s", req.Path)
+	}
+	values, _ := url.ParseQuery(req.Query)
+	if values.Get("assets") != "BTC,USDT" || values.Get("isIsolated") != "FALSE" {
+		t.Fatalf("unexpected query %q", req.Query)
+	}
+	verifySignature(t, req.Query)
+
+	client.config.Sandbox = true
+	if _, err := client.GetBorrowRates(context.Background(), []string{"BTC"}); !errors.Is(err, types.ErrNotSupported) {
+		t.Fatalf("sandbox GetBorrowRates = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestSymbolRules(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"symbols":[{"symbol":"BTCUSDT","filters":[
+			{"filterType":"PRICE_FILTER","tickSize":"0.01000000"},
+			{"filterType":"LOT_SIZE","stepSize":"0.00001000","minQty":"0.00001000"},
+			{"filterType":"NOTIONAL","minNotional":"5.00000000"}]}]}`)
+	})
+	ctx := context.Background()
+
+	rules, err := client.GetSymbolRules(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetSymbolRules: %v", err)
+	}
+	if rules.StepSize != 0.00001 || rules.MinQty != 0.00001 || rules.MinNotional != 5 || rules.TickSize != 0.01 {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+
+	// 0.0001 BTC at 20000 is 2 USDT, under the 5 USDT minimum
+	_, err = client.SubmitOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: types.OrderSideSell, Type: types.OrderTypeLimit, Quantity: 0.0001, Price: 20000})
+	if !errors.Is(err, types.ErrBelowMinimum) {
+		t.Fatalf("SubmitOrder below minimum = %v, want ErrBelowMinimum", err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("expected only the exchange info request, got %d requests", len(*requests))
+	}
+}
+
+func TestSymbolRules_Listing(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("symbol") {
+		case "LUNAUSDT":
+			fmt.Fprint(w, `{"symbols":[{"symbol":"LUNAUSDT","status":"BREAK","filters":[]}]}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-1121,"msg":"Invalid symbol."}`)
+		}
+	})
+	ctx := context.Background()
+
+	rules, err := client.GetSymbolRules(ctx, "LUNAUSDT")
+	if err != nil || !rules.Halted {
+		t.Fatalf("GetSymbolRules(LUNAUSDT) = %+v, %v; want halted", rules, err)
+	}
+	if _, err := client.GetSymbolRules(ctx, "BTCUSDTT"); !errors.Is(err, types.ErrUnknownSymbol) {
+		t.Fatalf("GetSymbolRules(BTCUSDTT) = %v, want ErrUnknownSymbol", err)
+	}
+}
+
+func TestConvertDust(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"totalServiceCharge":"0.00002","totalTransfered":"0.001","transferResult":[
+			{"amount":"0.00003","fromAsset":"BTC","operateTime":1700000000123,"serviceChargeAmount":"0.00001","transferedAmount":"0.0006"},
+			{"amount":"0.0012","fromAsset":"ETH","operateTime":1700000000123,"serviceChargeAmount":"0.00001","transferedAmount":"0.0004"}]}`)
+	})
+
+	conversion, err := client.ConvertDust(context.Background(), []string{"btc", "ETH"})
+	if err != nil {
+		t.Fatalf("ConvertDust: %v", err)
+	}
+	if conversion.Asset != "BNB" || conversion.Received != 0.001 || conversion.Fee != 0.00002 ||
+		conversion.Converted["BTC"] != 0.00003 || conversion.Converted["ETH"] != 0.0012 || conversion.Timestamp.UnixMilli() != 1700000000123 {
+		t.Fatalf("unexpected conversion %+v", conversion)
+	}
+
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.Path != "/sapi/v1/asset/dust" {
+		t.Fatalf("unexpected request %s %s", req.Method, req.Path)
+	}
+	verifySignature(t, req.Query)
+	if values, _ := url.ParseQuery(req.Query); strings.Join(values["asset"], ",") != "BTC,ETH" {
+		t.Fatalf("unexpected query %q", req.Query)
+	}
+
+	client.config.Sandbox = true
+	if _, err := client.ConvertDust(context.Background(), []string{"BTC"}); !errors.Is(err, types.ErrNotSupported) {
+		t.Fatalf("sandbox ConvertDust = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestSavings(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sapi/v1/simple-earn/flexible/list":
+			fmt.Fprint(w, `{"rows":[{"asset":"USDT","productId":"USDT001","latestAnnualPercentageRate":"0.0512"}],"total":1}`)
+		case "/sapi/v1/simple-earn/flexible/position":
+			fmt.Fprint(w, `{"rows":[{"asset":"USDT","productId":"USDT001","totalAmount":"250.5","latestAnnualPercentageRate":"0.0498","cumulativeTotalRewards":"0.5"}],"total":1}`)
+		default:
+			fmt.Fprint(w, `{"success":true}`)
+		}
+	})
+	ctx := context.Background()
+
+	savings, err := client.GetSavings(ctx, "usdt")
+	if err != nil {
+		t.Fatalf("GetSavings: %v", err)
+	}
+	if savings.Asset != "USDT" || savings.Amount != 250.5 || savings.APR != 0.0498 || savings.Interest != 0.5 {
+		t.Fatalf("unexpected savings %+v", savings)
+	}
+
+	if err := client.RedeemSavings(ctx, "USDT", 100); err != nil {
+		t.Fatalf("RedeemSavings: %v", err)
+	}
+	req := (*requests)[len(*requests)-1]
+	if req.Method != http.MethodPost || req.Path != "/sapi/v1/simple-earn/flexible/redeem" {
+		t.Fatalf("unexpected request %s %s", req.Method, req.Path)
+	}
+	verifySignature(t, req.Query)
+	if values, _ := url.ParseQuery(req.Query); values.Get("productId") != "USDT001" || values.Get("amount") != "100.00000000" {
+		t.Fatalf("unexpected query %q", req.Query)
+	}
+
+	if err := client.SubscribeSavings(ctx, "BTC", 1); !errors.Is(err, types.ErrNotSupported) {
+		t.Fatalf("SubscribeSavings without a product = %v, want ErrNotSupported", err)
+	}
+	client.config.Sandbox = true
+	if _, err := client.GetSavings(ctx, "USDT"); !errors.Is(err, types.ErrNotSupported) {
+		t.Fatalf("sandbox GetSavings = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestRoundToStep(t *testing.T) {
+	tests := []struct {
+		value, step float64
+		want        float64
+	}{
+		{0.3, 0.1, 0.3},
+		{0.0012345, 0.00001, 0.00123},
+		{1.999, 1, 1},
+	}
+	for _, tt := range tests {
+		if got := roundToStep(tt.value, tt.step, math.Floor); got != tt.want {
+			t.Errorf("roundToStep(%v, %v) = %v, want %v", tt.value, tt.step, got, tt.want)
+		}
+	}
+}
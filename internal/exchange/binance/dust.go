@@ -0,0 +1,63 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// dustAsset is what Binance converts small balances into
+const dustAsset = "BNB"
+
+// dustResponse is the result of /sapi/v1/asset/dust
+type dustResponse struct {
+	TotalServiceCharge string `json:"totalServiceCharge"`
+	TotalTransfered    string `json:"totalTransfered"`
+	TransferResult     []struct {
+		Amount        string `json:"amount"`
+		FromAsset     string `json:"fromAsset"`
+		OperateTime   int64  `json:"operateTime"`
+		ServiceCharge string `json:"serviceChargeAmount"`
+	} `json:"transferResult"`
+}
+
+// ConvertDust converts balances too small to trade into BNB. Binance allows
+// one conversion every six hours and rejects assets it does not consider dust.
+func (c *Client) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	if c.config.Sandbox {
+		return nil, fmt.Errorf("dust conversion on testnet: %w", types.ErrNotSupported)
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("no assets requested")
+	}
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	upper := make([]string, len(assets))
+	for i, asset := range assets {
+		upper[i] = strings.ToUpper(asset)
+	}
+	var response dustResponse
+	if err := c.makeSignedRequest(ctx, "POST", "/sapi/v1/asset/dust", map[string]interface{}{"asset": upper}, &response); err != nil {
+		return nil, fmt.Errorf("failed to convert dust: %w", err)
+	}
+
+	conversion := &types.DustConversion{
+		Asset:     dustAsset,
+		Converted: make(map[string]float64, len(response.TransferResult)),
+		Received:  parseNumber(response.TotalTransfered),
+		Fee:       parseNumber(response.TotalServiceCharge),
+		Timestamp: time.Now(),
+	}
+	for _, result := range response.TransferResult {
+		conversion.Converted[result.FromAsset] += parseNumber(result.Amount)
+		if result.OperateTime > 0 {
+			conversion.Timestamp = time.UnixMilli(result.OperateTime)
+		}
+	}
+	return conversion, nil
+}
@@ -0,0 +1,133 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// symbolFilters holds the LOT_SIZE, PRICE_FILTER and (MIN_)NOTIONAL
+// constraints of a symbol
+type symbolFilters struct {
+	StepSize    float64
+	MinQty      float64
+	TickSize    float64
+	MinNotional float64
+	Halted      bool // status other than TRADING
+}
+
+// rules returns the filters as exchange-neutral symbol rules
+func (f symbolFilters) rules(symbol string) types.SymbolRules {
+	return types.SymbolRules{
+		Symbol:      symbol,
+		StepSize:    f.StepSize,
+		MinQty:      f.MinQty,
+		MinNotional: f.MinNotional,
+		TickSize:    f.TickSize,
+		Halted:      f.Halted,
+	}
+}
+
+// apply rounds quantity down to the step size and price to the tick size
+func (f symbolFilters) apply(order types.Order) types.Order {
+	if f.StepSize > 0 {
+		order.Quantity = roundToStep(order.Quantity, f.StepSize, math.Floor)
+	}
+	if f.TickSize > 0 && order.Price > 0 {
+		order.Price = roundToStep(order.Price, f.TickSize, math.Round)
+	}
+	return order
+}
+
+func roundToStep(value, step float64, round func(float64) float64) float64 {
+	// The epsilon absorbs float error such as 0.3/0.1 = 2.9999999999999996
+	steps := round(value/step + 1e-9)
+	precision := int(math.Max(0, math.Ceil(-math.Log10(step))))
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(steps*step, 'f', precision, 64), 64)
+	return rounded
+}
+
+// errInvalidSymbol is the API error code of exchangeInfo for an unknown symbol
+const errInvalidSymbol = -1121
+
+// errNoSuchOrder is the API error code of an order query for an unknown order
+const errNoSuchOrder = -2013
+
+// exchangeInfoResponse is the subset of /api/v3/exchangeInfo used for filters
+type exchangeInfoResponse struct {
+	Symbols []struct {
+		Symbol  string `json:"symbol"`
+		Status  string `json:"status"`
+		Filters []struct {
+			FilterType  string `json:"filterType"`
+			StepSize    string `json:"stepSize"`
+			MinQty      string `json:"minQty"`
+			TickSize    string `json:"tickSize"`
+			MinNotional string `json:"minNotional"`
+		} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// symbolFilters returns the cached filters for symbol, fetching them on first use
+func (c *Client) symbolFilters(ctx context.Context, symbol string) (symbolFilters, error) {
+	c.mu.Lock()
+	filters, ok := c.filters[symbol]
+	c.mu.Unlock()
+	if ok {
+		return filters, nil
+	}
+
+	var response exchangeInfoResponse
+	params := map[string]interface{}{"symbol": symbol}
+	if err := c.makeRequest(ctx, "GET", "/api/v3/exchangeInfo", params, &response); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == errInvalidSymbol {
+			return symbolFilters{}, fmt.Errorf("%s: %w", symbol, types.ErrUnknownSymbol)
+		}
+		return symbolFilters{}, fmt.Errorf("failed to get exchange info for %s: %w", symbol, err)
+	}
+
+	found := false
+	for _, info := range response.Symbols {
+		if info.Symbol != symbol {
+			continue
+		}
+		found = true
+		filters.Halted = info.Status != "" && info.Status != "TRADING"
+		for _, filter := range info.Filters {
+			switch filter.FilterType {
+			case "LOT_SIZE":
+				filters.StepSize = parseNumber(filter.StepSize)
+				filters.MinQty = parseNumber(filter.MinQty)
+			case "PRICE_FILTER":
+				filters.TickSize = parseNumber(filter.TickSize)
+			case "MIN_NOTIONAL", "NOTIONAL":
+				filters.MinNotional = parseNumber(filter.MinNotional)
+			}
+		}
+	}
+
+	if !found {
+		return symbolFilters{}, fmt.Errorf("%s: %w", symbol, types.ErrUnknownSymbol)
+	}
+
+	c.mu.Lock()
+	c.filters[symbol] = filters
+	c.mu.Unlock()
+
+	return filters, nil
+}
+
+// GetSymbolRules returns the lot size, tick size and minimums of symbol
+func (c *Client) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	filters, err := c.symbolFilters(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	rules := filters.rules(symbol)
+	return &rules, nil
+}
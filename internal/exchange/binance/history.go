@@ -0,0 +1,95 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+const (
+	// historyPageLimit is the most orders /api/v3/allOrders returns at once
+	historyPageLimit = 1000
+	// historyWindow is the longest time range /api/v3/allOrders accepts
+	historyWindow = 24 * time.Hour
+)
+
+// GetOrderHistory returns a page of filled orders for query.Symbol.
+//
+// Binance pages order history by order id and only bounds it by time in
+// windows of up to 24 hours. The first page of a query with a start time
+// walks such windows until it meets an order; later pages continue by id
+// and end at the first order placed after query.End. Cursors are "t:<ms>"
+// for a window start and "id:<order id>" for the next order.
+func (c *Client) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	if query.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	limit := query.Limit
+	if limit <= 0 || limit > historyPageLimit {
+		limit = historyPageLimit
+	}
+	end := query.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	cursor := query.Cursor
+	if cursor == "" {
+		cursor = "id:0"
+		if !query.Start.IsZero() {
+			cursor = "t:" + strconv.FormatInt(query.Start.UnixMilli(), 10)
+		}
+	}
+	kind, value, _ := strings.Cut(cursor, ":")
+	position, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || (kind != "id" && kind != "t") {
+		return nil, fmt.Errorf("invalid order history cursor %q", cursor)
+	}
+
+	params := map[string]interface{}{
+		"symbol": query.Symbol,
+		"limit":  limit,
+	}
+	var windowEnd int64
+	if kind == "id" {
+		params["orderId"] = position
+	} else {
+		windowEnd = min(position+historyWindow.Milliseconds()-1, end.UnixMilli())
+		params["startTime"] = position
+		params["endTime"] = windowEnd
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+	var response []map[string]interface{}
+	if err := c.makeSignedRequest(ctx, "GET", "/api/v3/allOrders", params, &response); err != nil {
+		return nil, err
+	}
+
+	page := &types.OrderHistoryPage{}
+	var lastID int64
+	for _, data := range response {
+		order := c.parseOrderResponse(data)
+		lastID, _ = strconv.ParseInt(order.ID, 10, 64)
+		// Ids grow with time, so nothing later is in range
+		if order.Timestamp.After(end) {
+			return page, nil
+		}
+		if query.Contains(order.Timestamp) && isFilled(data) {
+			page.Orders = append(page.Orders, *order)
+		}
+	}
+
+	switch {
+	case len(response) == limit, kind == "t" && len(response) > 0:
+		page.Next = "id:" + strconv.FormatInt(lastID+1, 10)
+	case kind == "t" && windowEnd < end.UnixMilli():
+		page.Next = "t:" + strconv.FormatInt(windowEnd+1, 10)
+	}
+	return page, nil
+}
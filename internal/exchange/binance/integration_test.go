@@ -0,0 +1,248 @@
+//go:build integration
+
+// Integration tests against the Binance spot testnet (https://testnet.binance.vision).
+// They place real testnet orders with tiny sizes and are skipped unless
+// BINANCE_TESTNET_API_KEY and BINANCE_TESTNET_SECRET_KEY are set:
+//
+//	go test -tags integration ./internal/exchange/binance/...
+package binance
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+const integrationSymbol = "BTCUSDT"
+
+func newTestnetClient(t *testing.T, secret string) *Client {
+	t.Helper()
+
+	apiKey := os.Getenv("BINANCE_TESTNET_API_KEY")
+	secretKey := os.Getenv("BINANCE_TESTNET_SECRET_KEY")
+	if apiKey == "" || secretKey == "" {
+		t.Skip("BINANCE_TESTNET_API_KEY/BINANCE_TESTNET_SECRET_KEY not set")
+	}
+	if secret != "" {
+		secretKey = secret
+	}
+
+	client, err := NewClient(ExchangeConfig{
+		APIKey:    apiKey,
+		SecretKey: secretKey,
+		Sandbox:   true,
+		RateLimit: RateLimitConfig{RequestsPerSecond: 5, Burst: 1},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.logger = logger.New(logger.LevelError)
+	return client
+}
+
+func integrationContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func currentPrice(t *testing.T, ctx context.Context, client *Client) float64 {
+	t.Helper()
+	ticker, err := client.GetTicker(ctx, integrationSymbol)
+	if err != nil {
+		t.Fatalf("GetTicker: %v", err)
+	}
+	if ticker.Price <= 0 {
+		t.Fatalf("invalid ticker price %v", ticker.Price)
+	}
+	return ticker.Price
+}
+
+func TestIntegrationMarketData(t *testing.T) {
+	client := newTestnetClient(t, "")
+	ctx := integrationContext(t)
+
+	currentPrice(t, ctx, client)
+
+	book, err := client.GetOrderBook(ctx, integrationSymbol, 5)
+	if err != nil {
+		t.Fatalf("GetOrderBook: %v", err)
+	}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 || book.Bids[0].Price >= book.Asks[0].Price {
+		t.Fatalf("unexpected order book %+v", book)
+	}
+
+	candles, err := client.GetCandles(ctx, integrationSymbol, "1m", 10)
+	if err != nil {
+		t.Fatalf("GetCandles: %v", err)
+	}
+	if len(candles) != 10 || candles[0].Close <= 0 {
+		t.Fatalf("unexpected candles %+v", candles)
+	}
+}
+
+func TestIntegrationCarryRates(t *testing.T) {
+	client := newTestnetClient(t, "")
+	ctx := integrationContext(t)
+
+	funding, err := client.GetFundingRate(ctx, integrationSymbol)
+	if err != nil {
+		t.Fatalf("GetFundingRate: %v", err)
+	}
+	if funding.MarkPrice <= 0 || funding.Interval <= 0 || funding.NextFundingTime.IsZero() {
+		t.Fatalf("unexpected funding rate %+v", funding)
+	}
+
+	if _, err := client.GetBorrowRates(ctx, []string{"BTC"}); !errors.Is(err, types.ErrNotSupported) {
+		t.Fatalf("GetBorrowRates on testnet = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestIntegrationBalance(t *testing.T) {
+	client := newTestnetClient(t, "")
+	ctx := integrationContext(t)
+
+	balance, err := client.GetBalance(ctx)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance.Asset != "USDT" || balance.Total != balance.Free+balance.Locked || balance.Free <= 0 {
+		t.Fatalf("unexpected balance %+v", balance)
+	}
+}
+
+func TestIntegrationInvalidSignature(t *testing.T) {
+	client := newTestnetClient(t, "not-the-secret")
+
+	_, err := client.GetBalance(integrationContext(t))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.Code != -1022 {
+		t.Fatalf("expected invalid signature (-1022), got %+v", apiErr)
+	}
+}
+
+func TestIntegrationOrderLifecycle(t *testing.T) {
+	client := newTestnetClient(t, "")
+	ctx := integrationContext(t)
+
+	// A limit buy well below market rests on the book without filling
+	price := currentPrice(t, ctx, client) * 0.8
+	placed, err := client.SubmitOrder(ctx, types.Order{
+		Symbol:   integrationSymbol,
+		Side:     types.OrderSideBuy,
+		Type:     types.OrderTypeLimit,
+		Quantity: 15 / price,
+		Price:    price,
+	})
+	if err != nil {
+		t.Fatalf("SubmitOrder: %v", err)
+	}
+	t.Cleanup(func() { _ = client.CancelOrder(context.Background(), placed.ID) })
+
+	active, err := client.GetActiveOrders(ctx, integrationSymbol)
+	if err != nil {
+		t.Fatalf("GetActiveOrders: %v", err)
+	}
+	found := false
+	for _, order := range active {
+		found = found || order.ID == placed.ID
+	}
+	if !found {
+		t.Fatalf("order %s not among active orders", placed.ID)
+	}
+
+	order, err := client.GetOrder(ctx, placed.ID)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if order.Status != types.OrderStatusNew || order.Quantity != placed.Quantity || order.Price != placed.Price {
+		t.Fatalf("order = %+v, placed %+v", order, placed)
+	}
+
+	if err := client.CancelOrder(ctx, placed.ID); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	order, err = client.GetOrder(ctx, placed.ID)
+	if err != nil {
+		t.Fatalf("GetOrder after cancel: %v", err)
+	}
+	if order.Status != types.OrderStatusCanceled {
+		t.Fatalf("status after cancel = %s", order.Status)
+	}
+}
+
+func TestIntegrationRateLimiter(t *testing.T) {
+	client := newTestnetClient(t, "")
+	ctx := integrationContext(t)
+
+	// 5 rps with burst 1: six calls need at least a second
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if _, err := client.GetTicker(ctx, integrationSymbol); err != nil {
+			t.Fatalf("GetTicker: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("six requests took %v, rate limiter not applied", elapsed)
+	}
+}
+
+func TestIntegrationDCA(t *testing.T) {
+	client := newTestnetClient(t, "")
+	ctx := integrationContext(t)
+
+	dca := strategy.NewDCAStrategy(types.DCAConfig{
+		Symbol:           integrationSymbol,
+		InvestmentAmount: 15,
+		Interval:         time.Hour,
+		MaxInvestments:   1,
+		Enabled:          true,
+	}, client, logger.New(logger.LevelError))
+	if err := dca.ValidateConfig(); err != nil {
+		t.Fatalf("ValidateConfig: %v", err)
+	}
+
+	price := currentPrice(t, ctx, client)
+	if err := dca.Execute(ctx, types.MarketData{Symbol: integrationSymbol, Price: price, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if metrics := dca.GetMetrics(); metrics.TotalTrades != 1 {
+		t.Fatalf("expected one DCA buy, metrics %+v", metrics)
+	}
+}
+
+func TestIntegrationGrid(t *testing.T) {
+	client := newTestnetClient(t, "")
+	ctx := integrationContext(t)
+
+	// A narrow grid around the current price so the top level is above market
+	price := currentPrice(t, ctx, client)
+	grid, err := strategy.NewGridStrategy(types.GridConfig{
+		Symbol:             integrationSymbol,
+		LowerPrice:         price * 0.999,
+		UpperPrice:         price * 1.001,
+		GridLevels:         2,
+		InvestmentPerLevel: 12,
+		Enabled:            true,
+	}, client, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatalf("NewGridStrategy: %v", err)
+	}
+
+	if err := grid.Execute(ctx, types.MarketData{Symbol: integrationSymbol, Price: price, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if metrics := grid.GetMetrics(); metrics.TotalTrades == 0 {
+		t.Fatalf("expected grid buys, metrics %+v", metrics)
+	}
+}
@@ -0,0 +1,127 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// defaultFundingInterval applies to USD-M perpetuals not listed in /fapi/v1/fundingInfo
+const defaultFundingInterval = 8 * time.Hour
+
+// premiumIndexResponse is the subset of /fapi/v1/premiumIndex used for funding
+type premiumIndexResponse struct {
+	Symbol          string `json:"symbol"`
+	MarkPrice       string `json:"markPrice"`
+	LastFundingRate string `json:"lastFundingRate"`
+	NextFundingTime int64  `json:"nextFundingTime"`
+	Time            int64  `json:"time"`
+}
+
+// fundingInfoResponse lists symbols with a non-default funding interval
+type fundingInfoResponse []struct {
+	Symbol               string `json:"symbol"`
+	FundingIntervalHours int    `json:"fundingIntervalHours"`
+}
+
+// borrowRateResponse is one entry of /sapi/v1/margin/next-hourly-interest-rate
+type borrowRateResponse struct {
+	Asset                  string `json:"asset"`
+	NextHourlyInterestRate string `json:"nextHourlyInterestRate"`
+}
+
+// GetFundingRate returns the current funding rate of a USD-M perpetual
+func (c *Client) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	var response premiumIndexResponse
+	params := map[string]interface{}{"symbol": symbol}
+	if err := c.makeRequest(ctx, "GET", "/fapi/v1/premiumIndex", params, &response); err != nil {
+		return nil, fmt.Errorf("failed to get funding rate for %s: %w", symbol, err)
+	}
+
+	interval, err := c.fundingInterval(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.FundingRate{
+		Symbol:          symbol,
+		Rate:            parseNumber(response.LastFundingRate),
+		Interval:        interval,
+		MarkPrice:       parseNumber(response.MarkPrice),
+		NextFundingTime: time.UnixMilli(response.NextFundingTime),
+		Timestamp:       time.UnixMilli(response.Time),
+	}, nil
+}
+
+// fundingInterval returns the cached funding interval for symbol, fetching
+// the interval table on first use
+func (c *Client) fundingInterval(ctx context.Context, symbol string) (time.Duration, error) {
+	c.mu.Lock()
+	intervals := c.fundingIntervals
+	c.mu.Unlock()
+
+	if intervals == nil {
+		var response fundingInfoResponse
+		if err := c.makeRequest(ctx, "GET", "/fapi/v1/fundingInfo", nil, &response); err != nil {
+			return 0, fmt.Errorf("failed to get funding info: %w", err)
+		}
+
+		intervals = make(map[string]time.Duration, len(response))
+		for _, info := range response {
+			if info.FundingIntervalHours > 0 {
+				intervals[info.Symbol] = time.Duration(info.FundingIntervalHours) * time.Hour
+			}
+		}
+
+		c.mu.Lock()
+		c.fundingIntervals = intervals
+		c.mu.Unlock()
+	}
+
+	if interval, ok := intervals[symbol]; ok {
+		return interval, nil
+	}
+	return defaultFundingInterval, nil
+}
+
+// GetBorrowRates returns the next hourly cross-margin borrow rate for each asset.
+// The spot testnet has no margin endpoints, so sandbox clients return
+// types.ErrNotSupported.
+func (c *Client) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	if c.config.Sandbox {
+		return nil, fmt.Errorf("borrow rates on testnet: %w", types.ErrNotSupported)
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("no assets requested")
+	}
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	params := map[string]interface{}{
+		"assets":     strings.ToUpper(strings.Join(assets, ",")),
+		"isIsolated": "FALSE",
+	}
+	var response []borrowRateResponse
+	if err := c.makeSignedRequest(ctx, "GET", "/sapi/v1/margin/next-hourly-interest-rate", params, &response); err != nil {
+		return nil, fmt.Errorf("failed to get borrow rates: %w", err)
+	}
+
+	now := time.Now()
+	rates := make([]types.BorrowRate, 0, len(response))
+	for _, r := range response {
+		rates = append(rates, types.BorrowRate{
+			Asset:      r.Asset,
+			HourlyRate: parseNumber(r.NextHourlyInterestRate),
+			Timestamp:  now,
+		})
+	}
+	return rates, nil
+}
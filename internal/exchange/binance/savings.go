@@ -0,0 +1,123 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// flexibleProduct is one entry of /sapi/v1/simple-earn/flexible/list
+type flexibleProduct struct {
+	Asset                      string `json:"asset"`
+	ProductID                  string `json:"productId"`
+	LatestAnnualPercentageRate string `json:"latestAnnualPercentageRate"`
+}
+
+// flexibleProductsResponse is the result of /sapi/v1/simple-earn/flexible/list
+type flexibleProductsResponse struct {
+	Rows []flexibleProduct `json:"rows"`
+}
+
+// flexiblePositionResponse is the result of /sapi/v1/simple-earn/flexible/position
+type flexiblePositionResponse struct {
+	Rows []struct {
+		Asset                      string `json:"asset"`
+		ProductID                  string `json:"productId"`
+		TotalAmount                string `json:"totalAmount"`
+		LatestAnnualPercentageRate string `json:"latestAnnualPercentageRate"`
+		CumulativeTotalRewards     string `json:"cumulativeTotalRewards"`
+	} `json:"rows"`
+}
+
+// GetSavings returns the asset's Simple Earn flexible position, empty when
+// nothing is subscribed. The spot testnet has no Simple Earn endpoints, so
+// sandbox clients return types.ErrNotSupported.
+func (c *Client) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	if c.config.Sandbox {
+		return nil, fmt.Errorf("savings on testnet: %w", types.ErrNotSupported)
+	}
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	asset = strings.ToUpper(asset)
+	var response flexiblePositionResponse
+	if err := c.makeSignedRequest(ctx, "GET", "/sapi/v1/simple-earn/flexible/position", map[string]interface{}{"asset": asset}, &response); err != nil {
+		return nil, fmt.Errorf("failed to get %s savings: %w", asset, err)
+	}
+
+	balance := &types.SavingsBalance{Asset: asset, Timestamp: time.Now()}
+	for _, row := range response.Rows {
+		if row.Asset != asset {
+			continue
+		}
+		balance.Amount += parseNumber(row.TotalAmount)
+		balance.Interest += parseNumber(row.CumulativeTotalRewards)
+		balance.APR = parseNumber(row.LatestAnnualPercentageRate)
+	}
+	if balance.APR == 0 {
+		// Nothing subscribed yet: quote the product's rate
+		product, err := c.flexibleProduct(ctx, asset)
+		if err != nil {
+			return nil, err
+		}
+		balance.APR = parseNumber(product.LatestAnnualPercentageRate)
+	}
+	return balance, nil
+}
+
+// SubscribeSavings moves amount of asset from the spot wallet into its
+// flexible product
+func (c *Client) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	return c.moveSavings(ctx, "/sapi/v1/simple-earn/flexible/subscribe", asset, amount)
+}
+
+// RedeemSavings moves amount of asset from its flexible product back into
+// the spot wallet; flexible redemptions are credited at once
+func (c *Client) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	return c.moveSavings(ctx, "/sapi/v1/simple-earn/flexible/redeem", asset, amount)
+}
+
+// moveSavings subscribes or redeems amount of asset's flexible product
+func (c *Client) moveSavings(ctx context.Context, endpoint, asset string, amount float64) error {
+	if c.config.Sandbox {
+		return fmt.Errorf("savings on testnet: %w", types.ErrNotSupported)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("invalid savings amount %v", amount)
+	}
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	asset = strings.ToUpper(asset)
+	product, err := c.flexibleProduct(ctx, asset)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{
+		"productId": product.ProductID,
+		"amount":    fmt.Sprintf("%.8f", amount),
+	}
+	if err := c.makeSignedRequest(ctx, "POST", endpoint, params, nil); err != nil {
+		return fmt.Errorf("failed to move %.8f %s in savings: %w", amount, asset, err)
+	}
+	return nil
+}
+
+// flexibleProduct looks up the flexible product of asset
+func (c *Client) flexibleProduct(ctx context.Context, asset string) (*flexibleProduct, error) {
+	var response flexibleProductsResponse
+	if err := c.makeSignedRequest(ctx, "GET", "/sapi/v1/simple-earn/flexible/list", map[string]interface{}{"asset": asset}, &response); err != nil {
+		return nil, fmt.Errorf("failed to get %s savings products: %w", asset, err)
+	}
+	for i := range response.Rows {
+		if response.Rows[i].Asset == asset {
+			return &response.Rows[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no flexible savings product for %s: %w", asset, types.ErrNotSupported)
+}
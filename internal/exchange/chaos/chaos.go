@@ -0,0 +1,216 @@
+// Package chaos injects exchange faults into a simulated exchange: latency,
+// timeouts, rejected orders, duplicate fills and out-of-order order updates.
+// Running a bot against the paper exchange with chaos enabled shows how its
+// strategies and order handling cope before they meet a live venue.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var (
+	// ErrTimeout is returned for requests that time out. A timed-out order
+	// may still have been placed, as on a live exchange.
+	ErrTimeout = errors.New("chaos: request timed out")
+	// ErrRejected is returned for orders the injector rejects
+	ErrRejected = errors.New("chaos: order rejected")
+)
+
+// Config configures an Injector; rates are probabilities in [0, 1]
+type Config struct {
+	Latency       time.Duration `json:"latency"`        // added to every request
+	Jitter        time.Duration `json:"jitter"`         // random extra latency up to this
+	TimeoutRate   float64       `json:"timeout_rate"`   // requests failing with ErrTimeout
+	RejectRate    float64       `json:"reject_rate"`    // orders failing with ErrRejected
+	DuplicateRate float64       `json:"duplicate_rate"` // filled orders reported twice in order history
+	ReorderRate   float64       `json:"reorder_rate"`   // order lists returned shuffled
+	Seed          int64         `json:"seed"`           // makes faults repeatable; 0 seeds from the clock
+}
+
+// UnmarshalJSON implements custom parsing for durations ("250ms", "1s")
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type Alias Config
+	aux := &struct {
+		Latency string `json:"latency"`
+		Jitter  string `json:"jitter"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"latency", aux.Latency, &c.Latency},
+		{"jitter", aux.Jitter, &c.Jitter},
+	} {
+		if field.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", field.name, err)
+		}
+		*field.dst = d
+	}
+	return nil
+}
+
+// Enabled reports whether any fault is configured
+func (c Config) Enabled() bool {
+	return c.Latency > 0 || c.Jitter > 0 || c.TimeoutRate > 0 || c.RejectRate > 0 || c.DuplicateRate > 0 || c.ReorderRate > 0
+}
+
+// Validate checks the config
+func (c Config) Validate() error {
+	if c.Latency < 0 || c.Jitter < 0 {
+		return fmt.Errorf("latency and jitter must not be negative")
+	}
+	for _, rate := range []struct {
+		name  string
+		value float64
+	}{
+		{"timeout_rate", c.TimeoutRate},
+		{"reject_rate", c.RejectRate},
+		{"duplicate_rate", c.DuplicateRate},
+		{"reorder_rate", c.ReorderRate},
+	} {
+		if rate.value < 0 || rate.value > 1 {
+			return fmt.Errorf("%s must be within [0, 1], got %v", rate.name, rate.value)
+		}
+	}
+	return nil
+}
+
+// Injector decides which requests fail and counts the faults it injected.
+// One injector can wrap several clients, e.g. one per account.
+type Injector struct {
+	cfg Config
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	counts map[string]int
+}
+
+// Fault kinds counted in Status
+const (
+	faultTimeout   = "timeouts"
+	faultGhost     = "timed_out_orders_placed"
+	faultReject    = "rejected_orders"
+	faultDuplicate = "duplicate_fills"
+	faultReorder   = "reordered_lists"
+)
+
+// NewInjector creates an injector for cfg
+func NewInjector(cfg Config) *Injector {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{cfg: cfg, rng: rand.New(rand.NewSource(seed)), counts: make(map[string]int)}
+}
+
+// Client wraps an exchange client so its requests suffer the injector's faults
+func (i *Injector) Client(exchange types.ExchangeClient) types.ExchangeClient {
+	return &client{ExchangeClient: exchange, injector: i}
+}
+
+// Status reports the config and the faults injected so far
+func (i *Injector) Status() map[string]interface{} {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	injected := make(map[string]int, len(i.counts))
+	for fault, n := range i.counts {
+		injected[fault] = n
+	}
+	return map[string]interface{}{
+		"latency":        i.cfg.Latency.String(),
+		"jitter":         i.cfg.Jitter.String(),
+		"timeout_rate":   i.cfg.TimeoutRate,
+		"reject_rate":    i.cfg.RejectRate,
+		"duplicate_rate": i.cfg.DuplicateRate,
+		"reorder_rate":   i.cfg.ReorderRate,
+		"injected":       injected,
+	}
+}
+
+// roll reports whether a fault of the given rate happens, counting it
+func (i *Injector) roll(rate float64, fault string) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.rng.Float64() >= rate {
+		return false
+	}
+	i.counts[fault]++
+	return true
+}
+
+// delay waits out the request's latency, or until ctx is done
+func (i *Injector) delay(ctx context.Context) error {
+	d := i.cfg.Latency
+	if i.cfg.Jitter > 0 {
+		i.mu.Lock()
+		d += time.Duration(i.rng.Int63n(int64(i.cfg.Jitter)))
+		i.mu.Unlock()
+	}
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// request applies latency and timeouts ahead of a request
+func (i *Injector) request(ctx context.Context) error {
+	if err := i.delay(ctx); err != nil {
+		return err
+	}
+	if i.roll(i.cfg.TimeoutRate, faultTimeout) {
+		return ErrTimeout
+	}
+	return nil
+}
+
+// orders duplicates and shuffles an order list as an inconsistent exchange
+// would report it
+func (i *Injector) orders(orders []types.Order, duplicates bool) []types.Order {
+	if len(orders) == 0 {
+		return orders
+	}
+	result := make([]types.Order, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, order)
+		if duplicates && i.roll(i.cfg.DuplicateRate, faultDuplicate) {
+			result = append(result, order)
+		}
+	}
+	if len(result) > 1 && i.roll(i.cfg.ReorderRate, faultReorder) {
+		i.mu.Lock()
+		i.rng.Shuffle(len(result), func(a, b int) { result[a], result[b] = result[b], result[a] })
+		i.mu.Unlock()
+	}
+	return result
+}
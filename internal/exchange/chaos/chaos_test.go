@@ -0,0 +1,126 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/sim"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func newSim(t *testing.T) *sim.Exchange {
+	t.Helper()
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{100}, QuoteBalance: 1e6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ex
+}
+
+func buy(id string) types.Order {
+	return types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 1, Price: 100,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: id}}
+}
+
+func TestInjectorOrderFaults(t *testing.T) {
+	ctx := context.Background()
+
+	// Every order rejected, none reaches the exchange
+	ex := newSim(t)
+	client := NewInjector(Config{RejectRate: 1, Seed: 1}).Client(ex)
+	if err := client.PlaceOrder(ctx, buy("a")); !errors.Is(err, ErrRejected) {
+		t.Fatalf("PlaceOrder() error = %v, want ErrRejected", err)
+	}
+	if n := len(ex.Orders()); n != 0 {
+		t.Fatalf("rejected order reached the exchange: %d orders", n)
+	}
+
+	// Every order times out; some are placed regardless
+	ex = newSim(t)
+	injector := NewInjector(Config{TimeoutRate: 1, Seed: 1})
+	client = injector.Client(ex)
+	for i := 0; i < 40; i++ {
+		if err := client.PlaceOrder(ctx, buy("t")); !errors.Is(err, ErrTimeout) {
+			t.Fatalf("PlaceOrder() error = %v, want ErrTimeout", err)
+		}
+	}
+	injected := injector.Status()["injected"].(map[string]int)
+	if placed := len(ex.Orders()); placed == 0 || placed == 40 || placed != injected[faultGhost] {
+		t.Errorf("%d of 40 timed-out orders placed, counted %d", placed, injected[faultGhost])
+	}
+}
+
+func TestInjectorHistoryFaults(t *testing.T) {
+	ctx := context.Background()
+	ex := newSim(t)
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		if err := ex.PlaceOrder(ctx, buy(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := NewInjector(Config{DuplicateRate: 1, Seed: 3}).Client(ex)
+	filled, err := client.GetFilledOrders(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filled) != 10 || filled[0].ID != filled[1].ID {
+		t.Fatalf("got %d fills, want each of 5 reported twice", len(filled))
+	}
+
+	client = NewInjector(Config{ReorderRate: 1, Seed: 3}).Client(ex)
+	filled, err = client.GetFilledOrders(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inOrder := true
+	for i, order := range filled {
+		inOrder = inOrder && order.ExchangeOrder.ClientOrderID == string(rune('a'+i))
+	}
+	if len(filled) != 5 || inOrder {
+		t.Errorf("fills not reordered: %d fills, in placement order %v", len(filled), inOrder)
+	}
+}
+
+func TestInjectorLatency(t *testing.T) {
+	client := NewInjector(Config{Latency: 20 * time.Millisecond, Jitter: 10 * time.Millisecond}).Client(newSim(t))
+
+	start := time.Now()
+	if _, err := client.GetTicker(context.Background(), "BTCUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("request took %v, want at least the 20ms latency", elapsed)
+	}
+
+	// Latency gives way to the caller's deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := client.GetTicker(ctx, "BTCUSDT"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetTicker() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"latency":"250ms","jitter":"1s","reject_rate":0.1}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Latency != 250*time.Millisecond || cfg.Jitter != time.Second || !cfg.Enabled() {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if (Config{}).Enabled() {
+		t.Error("zero config should be disabled")
+	}
+	for _, bad := range []Config{{TimeoutRate: 1.5}, {DuplicateRate: -0.1}, {Latency: -time.Second}} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v) accepted an invalid config", bad)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package chaos
+
+import (
+	"context"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// client injects an Injector's faults into each exchange request
+type client struct {
+	types.ExchangeClient
+	injector *Injector
+}
+
+// PlaceOrder may reject the order or time out. Half the timed-out orders
+// reach the exchange anyway, so callers must find out whether they filled.
+func (c *client) PlaceOrder(ctx context.Context, order types.Order) error {
+	if err := c.injector.delay(ctx); err != nil {
+		return err
+	}
+	if c.injector.roll(c.injector.cfg.RejectRate, faultReject) {
+		return ErrRejected
+	}
+	if c.injector.roll(c.injector.cfg.TimeoutRate, faultTimeout) {
+		if c.injector.roll(0.5, faultGhost) {
+			_ = c.ExchangeClient.PlaceOrder(ctx, order)
+		}
+		return ErrTimeout
+	}
+	return c.ExchangeClient.PlaceOrder(ctx, order)
+}
+
+func (c *client) CancelOrder(ctx context.Context, orderID string) error {
+	if err := c.injector.request(ctx); err != nil {
+		return err
+	}
+	return c.ExchangeClient.CancelOrder(ctx, orderID)
+}
+
+func (c *client) GetOrder(ctx context.Context, orderID string) (*types.Order, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetOrder(ctx, orderID)
+}
+
+func (c *client) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetOrderByClientID(ctx, symbol, clientOrderID)
+}
+
+func (c *client) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	orders, err := c.ExchangeClient.GetActiveOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return c.injector.orders(orders, false), nil
+}
+
+// GetFilledOrders may report fills twice and out of placement order
+func (c *client) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	orders, err := c.ExchangeClient.GetFilledOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return c.injector.orders(orders, true), nil
+}
+
+// GetOrderHistory may report fills twice and out of placement order
+func (c *client) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	page, err := c.ExchangeClient.GetOrderHistory(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	shuffled := *page
+	shuffled.Orders = c.injector.orders(page.Orders, true)
+	return &shuffled, nil
+}
+
+func (c *client) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetTicker(ctx, symbol)
+}
+
+func (c *client) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetTickers(ctx, symbols)
+}
+
+func (c *client) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetOrderBook(ctx, symbol, limit)
+}
+
+func (c *client) GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetCandles(ctx, symbol, interval, limit)
+}
+
+func (c *client) GetBalance(ctx context.Context) (*types.Balance, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetBalance(ctx)
+}
+
+func (c *client) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetBalances(ctx)
+}
+
+func (c *client) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetTradingFees(ctx, symbol)
+}
+
+func (c *client) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetSymbolRules(ctx, symbol)
+}
+
+func (c *client) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.ConvertDust(ctx, assets)
+}
+
+func (c *client) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetFundingRate(ctx, symbol)
+}
+
+func (c *client) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetBorrowRates(ctx, assets)
+}
+
+func (c *client) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetSavings(ctx, asset)
+}
+
+func (c *client) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	if err := c.injector.request(ctx); err != nil {
+		return err
+	}
+	return c.ExchangeClient.SubscribeSavings(ctx, asset, amount)
+}
+
+func (c *client) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	if err := c.injector.request(ctx); err != nil {
+		return err
+	}
+	return c.ExchangeClient.RedeemSavings(ctx, asset, amount)
+}
+
+func (c *client) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	if err := c.injector.request(ctx); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetSystemStatus(ctx)
+}
+
+func (c *client) Ping(ctx context.Context) error {
+	if err := c.injector.request(ctx); err != nil {
+		return err
+	}
+	return c.ExchangeClient.Ping(ctx)
+}
@@ -2,11 +2,15 @@ package exchange
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/binance"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/uniswap"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
@@ -18,19 +22,30 @@ type Client interface {
 	GetOrder(ctx context.Context, orderID string) (*types.Order, error)
 	GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error)
 	GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error)
+	GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error)
 
 	// Market data
 	GetTicker(ctx context.Context, symbol string) (*types.Ticker, error)
+	GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error)
 	GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error)
 	GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error)
 
 	// Account information
 	GetBalance(ctx context.Context) (*types.Balance, error)
+	GetBalances(ctx context.Context) ([]types.Balance, error)
 	GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error)
+	GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error)
+	ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error)
+	GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error)
+	GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error)
+	GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error)
+	SubscribeSavings(ctx context.Context, asset string, amount float64) error
+	RedeemSavings(ctx context.Context, asset string, amount float64) error
 
 	// WebSocket streams (omitted in demo)
 
 	// Connection management
+	GetSystemStatus(ctx context.Context) (*types.SystemStatus, error)
 	Ping(ctx context.Context) error
 	Close() error
 }
@@ -53,6 +68,9 @@ type ExchangeConfig struct {
 	Sandbox    bool
 	RateLimit  RateLimitConfig
 	Retry      RetryConfig
+
+	// Uniswap configures the "uniswap" on-chain client
+	Uniswap uniswap.Config
 }
 
 type UnifiedClient struct {
@@ -77,6 +95,73 @@ func NewUnifiedClient(configs []ExchangeConfig) (*UnifiedClient, error) {
 	}, nil
 }
 
+// NewUnifiedClientFromClients combines clients already built, keyed by venue name
+func NewUnifiedClientFromClients(clients map[string]Client, log *logger.Logger) *UnifiedClient {
+	return &UnifiedClient{clients: clients, logger: log}
+}
+
+// Venues returns the venue names, sorted
+func (u *UnifiedClient) Venues() []string {
+	names := make([]string, 0, len(u.clients))
+	for name := range u.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Client returns the client of the named venue
+func (u *UnifiedClient) Client(name string) (Client, bool) {
+	client, ok := u.clients[name]
+	return client, ok
+}
+
+// Quotes fetches every venue's best bid and ask for symbol concurrently,
+// sorted by venue. Venues failing to quote are left out; it errors only when
+// none quotes.
+func (u *UnifiedClient) Quotes(ctx context.Context, symbol string) ([]types.VenueQuote, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		quotes []types.VenueQuote
+		errs   []error
+	)
+	for name, client := range u.clients {
+		wg.Add(1)
+		go func(name string, client Client) {
+			defer wg.Done()
+			ticker, err := client.GetTicker(ctx, symbol)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			// Venues without a book quote the last price on both sides
+			quote := types.VenueQuote{Venue: name, Bid: ticker.Bid, Ask: ticker.Ask}
+			if quote.Bid <= 0 {
+				quote.Bid = ticker.Price
+			}
+			if quote.Ask <= 0 {
+				quote.Ask = ticker.Price
+			}
+			quotes = append(quotes, quote)
+		}(name, client)
+	}
+	wg.Wait()
+
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("no venue quotes %s: %w", symbol, errors.Join(errs...))
+	}
+	for _, err := range errs {
+		if u.logger != nil {
+			u.logger.Warn("Venue quote failed: %v", err)
+		}
+	}
+	sort.Slice(quotes, func(i, j int) bool { return quotes[i].Venue < quotes[j].Venue })
+	return quotes, nil
+}
+
 func createExchangeClient(config ExchangeConfig) (Client, error) {
 	switch strings.ToLower(config.Name) {
 	case "binance":
@@ -94,6 +179,8 @@ func createExchangeClient(config ExchangeConfig) (Client, error) {
 			},
 		}
 		return binance.NewClient(binanceConfig)
+	case "uniswap":
+		return uniswap.NewClient(config.Uniswap)
 	default:
 		return nil, fmt.Errorf("unsupported exchange: %s", config.Name)
 	}
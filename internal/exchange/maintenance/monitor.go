@@ -0,0 +1,291 @@
+// Package maintenance tells trading loops when to pause because the exchange
+// is down: during scheduled maintenance windows, while the exchange reports a
+// non-normal system status, and after repeated request failures, backing off
+// exponentially instead of hammering failing endpoints. An operator can also
+// pause trading by hand.
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Window is a scheduled maintenance period
+type Window struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Config configures a Monitor; zero fields take defaults
+type Config struct {
+	PollInterval time.Duration `json:"poll_interval"` // system status poll period (default 1m)
+	Windows      []Window      `json:"windows"`       // maintenance calendar
+	Lead         time.Duration `json:"lead"`          // pause this long before a window starts
+	MaxFailures  int           `json:"max_failures"`  // consecutive failures that mark the exchange degraded (default 5)
+	Backoff      time.Duration `json:"backoff"`       // first pause when degraded, doubled per further failure (default 30s)
+	MaxBackoff   time.Duration `json:"max_backoff"`   // backoff cap (default 15m)
+}
+
+// UnmarshalJSON implements custom parsing for durations ("30s", "15m")
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type Alias Config
+	aux := &struct {
+		PollInterval string `json:"poll_interval"`
+		Lead         string `json:"lead"`
+		Backoff      string `json:"backoff"`
+		MaxBackoff   string `json:"max_backoff"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"poll_interval", aux.PollInterval, &c.PollInterval},
+		{"lead", aux.Lead, &c.Lead},
+		{"backoff", aux.Backoff, &c.Backoff},
+		{"max_backoff", aux.MaxBackoff, &c.MaxBackoff},
+	} {
+		if field.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(field.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s format: %w", field.name, err)
+		}
+		*field.dst = duration
+	}
+
+	return nil
+}
+
+// Validate checks the config
+func (c Config) Validate() error {
+	if c.PollInterval < 0 || c.Lead < 0 || c.Backoff < 0 || c.MaxBackoff < 0 {
+		return fmt.Errorf("maintenance durations must not be negative")
+	}
+	if c.MaxFailures < 0 {
+		return fmt.Errorf("maintenance max failures must not be negative")
+	}
+	for i, w := range c.Windows {
+		if !w.End.After(w.Start) {
+			return fmt.Errorf("maintenance window %d: end must be after start", i)
+		}
+	}
+	return nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval == 0 {
+		c.PollInterval = time.Minute
+	}
+	if c.MaxFailures == 0 {
+		c.MaxFailures = 5
+	}
+	if c.Backoff == 0 {
+		c.Backoff = 30 * time.Second
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = 15 * time.Minute
+	}
+	return c
+}
+
+// Monitor tracks exchange availability for one exchange client
+type Monitor struct {
+	mu     sync.Mutex
+	client types.ExchangeClient
+	cfg    Config
+	logger *logger.Logger
+	now    func() time.Time
+
+	status      types.SystemStatus // last polled system status
+	supported   bool               // false once the exchange reports ErrNotSupported
+	failures    int                // consecutive failed requests
+	pausedUntil time.Time          // end of the current failure backoff
+	paused      string             // operator pause reason; "" when not paused
+}
+
+// NewMonitor creates a monitor for client
+func NewMonitor(client types.ExchangeClient, cfg Config, log *logger.Logger) *Monitor {
+	return &Monitor{
+		client:    client,
+		cfg:       cfg.withDefaults(),
+		logger:    log,
+		now:       time.Now,
+		status:    types.SystemStatus{State: types.SystemNormal},
+		supported: true,
+	}
+}
+
+// Run polls the system status every PollInterval until ctx is canceled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Refresh(ctx); err != nil {
+			m.logger.Warn("Failed to get exchange system status: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh polls the exchange system status once. Exchanges without a status
+// endpoint are left to the calendar and failure tracking.
+func (m *Monitor) Refresh(ctx context.Context) error {
+	m.mu.Lock()
+	supported := m.supported
+	m.mu.Unlock()
+	if !supported {
+		return nil
+	}
+
+	status, err := m.client.GetSystemStatus(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case errors.Is(err, types.ErrNotSupported):
+		m.supported = false
+		return nil
+	case err != nil:
+		return err
+	}
+	m.status = *status
+	return nil
+}
+
+// Report records the outcome of an exchange request. MaxFailures consecutive
+// failures pause trading for Backoff, doubling with every further failure.
+func (m *Monitor) Report(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		m.failures = 0
+		m.pausedUntil = time.Time{}
+		return
+	}
+
+	m.failures++
+	if m.failures < m.cfg.MaxFailures {
+		return
+	}
+	backoff := m.cfg.Backoff
+	for i := m.cfg.MaxFailures; i < m.failures && backoff < m.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	m.pausedUntil = m.now().Add(min(backoff, m.cfg.MaxBackoff))
+}
+
+// Pause stops trading until Resume, whatever the exchange's state
+func (m *Monitor) Pause(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if reason == "" {
+		reason = "no reason given"
+	}
+	m.paused = reason
+}
+
+// Resume ends an operator pause
+func (m *Monitor) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = ""
+}
+
+// Available reports whether trading may proceed now, with the reason when not
+func (m *Monitor) Available() (bool, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.paused != "" {
+		return false, "paused by operator: " + m.paused
+	}
+	now := m.now()
+	if w, ok := m.windowLocked(now); ok {
+		reason := fmt.Sprintf("scheduled maintenance until %s", w.End.Format(time.RFC3339))
+		if w.Reason != "" {
+			reason += ": " + w.Reason
+		}
+		return false, reason
+	}
+	if !m.status.Operational() {
+		reason := "exchange status " + m.status.State
+		if m.status.Message != "" {
+			reason += ": " + m.status.Message
+		}
+		return false, reason
+	}
+	if now.Before(m.pausedUntil) {
+		return false, fmt.Sprintf("%d consecutive request failures, retrying at %s", m.failures, m.pausedUntil.Format(time.RFC3339))
+	}
+	return true, ""
+}
+
+// Status reports the monitor state for status endpoints
+func (m *Monitor) Status() map[string]interface{} {
+	available, reason := m.Available()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := map[string]interface{}{
+		"available":    available,
+		"system_state": m.status.State,
+		"failures":     m.failures,
+		"paused":       m.paused != "",
+	}
+	if reason != "" {
+		status["reason"] = reason
+	}
+	if w, ok := m.nextWindowLocked(m.now()); ok {
+		status["next_window"] = w
+	}
+	return status
+}
+
+// windowLocked returns the window covering now, including the lead time
+func (m *Monitor) windowLocked(now time.Time) (Window, bool) {
+	for _, w := range m.cfg.Windows {
+		if !now.Before(w.Start.Add(-m.cfg.Lead)) && now.Before(w.End) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// nextWindowLocked returns the earliest window that has not ended
+func (m *Monitor) nextWindowLocked(now time.Time) (Window, bool) {
+	var next Window
+	found := false
+	for _, w := range m.cfg.Windows {
+		if w.End.After(now) && (!found || w.Start.Before(next.Start)) {
+			next, found = w, true
+		}
+	}
+	return next, found
+}
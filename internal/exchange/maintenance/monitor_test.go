@@ -0,0 +1,147 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/sim"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newMonitor(t *testing.T, script sim.Script, cfg Config) (*Monitor, *sim.Exchange, *time.Time) {
+	t.Helper()
+
+	script.Symbol = "BTCUSDT"
+	if script.Prices == nil {
+		script.Prices = []float64{100, 100, 100}
+	}
+	ex, err := sim.NewExchange(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := start
+	m := NewMonitor(ex, cfg, logger.New(logger.LevelError))
+	m.now = func() time.Time { return now }
+	return m, ex, &now
+}
+
+func TestMonitorSystemStatus(t *testing.T) {
+	m, ex, _ := newMonitor(t, sim.Script{SystemStates: map[int]string{1: types.SystemMaintenance}}, Config{})
+	ctx := context.Background()
+
+	for step, want := range []bool{true, false, true} {
+		if step > 0 {
+			ex.Step()
+		}
+		if err := m.Refresh(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if ok, reason := m.Available(); ok != want {
+			t.Fatalf("step %d: available = %v (%s), want %v", step, ok, reason, want)
+		}
+	}
+}
+
+func TestMonitorWindows(t *testing.T) {
+	window := Window{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Reason: "upgrade"}
+	m, _, now := newMonitor(t, sim.Script{}, Config{Windows: []Window{window}, Lead: 10 * time.Minute})
+
+	if ok, _ := m.Available(); !ok {
+		t.Fatalf("available before the window")
+	}
+	if next, ok := m.Status()["next_window"].(Window); !ok || next != window {
+		t.Fatalf("next_window = %v", m.Status()["next_window"])
+	}
+
+	*now = start.Add(50 * time.Minute)
+	if ok, reason := m.Available(); ok || reason == "" {
+		t.Fatalf("should pause within the lead time, got %v %q", ok, reason)
+	}
+
+	*now = window.End
+	if ok, _ := m.Available(); !ok {
+		t.Fatalf("should resume when the window ends")
+	}
+}
+
+func TestMonitorFailureBackoff(t *testing.T) {
+	m, _, now := newMonitor(t, sim.Script{}, Config{MaxFailures: 2, Backoff: time.Minute, MaxBackoff: 3 * time.Minute})
+	failure := errors.New("connection refused")
+
+	m.Report(failure)
+	if ok, _ := m.Available(); !ok {
+		t.Fatalf("one failure should not pause")
+	}
+	m.Report(failure)
+	if ok, _ := m.Available(); ok {
+		t.Fatalf("two failures should pause")
+	}
+	*now = now.Add(time.Minute)
+	if ok, _ := m.Available(); !ok {
+		t.Fatalf("should retry after the backoff")
+	}
+
+	// Further failures double the backoff up to the cap
+	m.Report(failure)
+	*now = now.Add(119 * time.Second)
+	if ok, _ := m.Available(); ok {
+		t.Fatalf("third failure should back off 2m")
+	}
+	m.Report(failure)
+	m.Report(failure)
+	*now = now.Add(3*time.Minute - time.Second)
+	if ok, _ := m.Available(); ok {
+		t.Fatalf("backoff should be capped at 3m, not lifted early")
+	}
+	*now = now.Add(time.Second)
+	if ok, _ := m.Available(); !ok {
+		t.Fatalf("backoff should be capped at 3m")
+	}
+
+	m.Report(nil)
+	if m.Status()["failures"] != 0 {
+		t.Fatalf("success should reset failures")
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	var cfg Config
+	data := `{"poll_interval": "30s", "lead": "5m", "max_failures": 3,
+		"windows": [{"start": "2024-01-01T01:00:00Z", "end": "2024-01-01T03:00:00Z"}]}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PollInterval != 30*time.Second || cfg.Lead != 5*time.Minute || cfg.MaxFailures != 3 || len(cfg.Windows) != 1 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Windows[0].End = cfg.Windows[0].Start
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for an empty window")
+	}
+}
+
+func TestMonitorOperatorPause(t *testing.T) {
+	m, _, _ := newMonitor(t, sim.Script{}, Config{})
+
+	m.Pause("checking fills")
+	if ok, reason := m.Available(); ok || reason != "paused by operator: checking fills" {
+		t.Fatalf("available = %v (%s), want an operator pause", ok, reason)
+	}
+	if paused, _ := m.Status()["paused"].(bool); !paused {
+		t.Errorf("status = %v, want paused", m.Status())
+	}
+	m.Resume()
+	if ok, reason := m.Available(); !ok {
+		t.Errorf("available after resume = false (%s)", reason)
+	}
+}
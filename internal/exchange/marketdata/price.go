@@ -0,0 +1,190 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Reference price sources
+const (
+	PriceLast     = "last"      // last trade price
+	PriceMid      = "mid"       // midpoint of the best bid and ask
+	PriceVWAP     = "vwap"      // volume-weighted average over the VWAP window
+	PriceDepthMid = "depth_mid" // mid weighted by the size resting on each side
+)
+
+// PriceConfig selects the reference price strategies see as
+// MarketData.Price, for signals and level crossings alike
+type PriceConfig struct {
+	Source     string        `json:"source"`      // PriceLast (default), PriceMid, PriceVWAP or PriceDepthMid
+	VWAPWindow time.Duration `json:"vwap_window"` // averaged by PriceVWAP in whole minutes (default 5m)
+	Depth      int           `json:"depth"`       // book levels per side weighed by PriceDepthMid (default 10)
+}
+
+// UnmarshalJSON implements custom parsing for vwap_window
+func (c *PriceConfig) UnmarshalJSON(data []byte) error {
+	type Alias PriceConfig
+	aux := &struct {
+		VWAPWindow string `json:"vwap_window"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.VWAPWindow != "" {
+		window, err := time.ParseDuration(aux.VWAPWindow)
+		if err != nil {
+			return fmt.Errorf("invalid vwap_window format: %w", err)
+		}
+		c.VWAPWindow = window
+	}
+	return nil
+}
+
+// Validate checks the source and its settings
+func (c PriceConfig) Validate() error {
+	switch c.Source {
+	case "", PriceLast, PriceMid, PriceVWAP, PriceDepthMid:
+	default:
+		return fmt.Errorf("unknown price source %q (last, mid, vwap, depth_mid)", c.Source)
+	}
+	if c.VWAPWindow < 0 || c.Depth < 0 {
+		return fmt.Errorf("price vwap window and depth must not be negative")
+	}
+	return nil
+}
+
+// PriceSource derives the reference price of market data from the exchange
+type PriceSource struct {
+	exchange types.ExchangeClient
+	cfg      PriceConfig
+}
+
+// NewPriceSource creates a price source reading books and candles from exchange
+func NewPriceSource(exchange types.ExchangeClient, cfg PriceConfig) *PriceSource {
+	if cfg.Source == "" {
+		cfg.Source = PriceLast
+	}
+	if cfg.VWAPWindow <= 0 {
+		cfg.VWAPWindow = 5 * time.Minute
+	}
+	if cfg.Depth <= 0 {
+		cfg.Depth = 10
+	}
+	return &PriceSource{exchange: exchange, cfg: cfg}
+}
+
+// Source names the configured source
+func (p *PriceSource) Source() string {
+	return p.cfg.Source
+}
+
+// Apply sets market.Price to the reference price; market.Ticker keeps the
+// last price. On error the last price is left in place. A book fetched for
+// the price is attached to market.
+func (p *PriceSource) Apply(ctx context.Context, market *types.MarketData) error {
+	var price float64
+	var err error
+	switch p.cfg.Source {
+	case PriceLast:
+		return nil
+	case PriceMid:
+		price, err = p.mid(ctx, market)
+	case PriceVWAP:
+		price, err = p.vwap(ctx, market.Symbol)
+	case PriceDepthMid:
+		price, err = p.depthMid(ctx, market)
+	}
+	if err != nil {
+		return fmt.Errorf("%s price of %s: %w", p.cfg.Source, market.Symbol, err)
+	}
+	market.Price = price
+	return nil
+}
+
+// mid is the ticker's bid/ask midpoint, or the book's when the ticker has none
+func (p *PriceSource) mid(ctx context.Context, market *types.MarketData) (float64, error) {
+	if t := market.Ticker; t != nil && t.Bid > 0 && t.Ask > 0 {
+		return (t.Bid + t.Ask) / 2, nil
+	}
+	book, err := p.book(ctx, market, 1)
+	if err != nil {
+		return 0, err
+	}
+	return (book.Bids[0].Price + book.Asks[0].Price) / 2, nil
+}
+
+// depthMid weighs the volume-weighted price of each side's top levels by the
+// size resting on the other: the more is bid, the closer the price moves to
+// the ask, where the next trade is likelier
+func (p *PriceSource) depthMid(ctx context.Context, market *types.MarketData) (float64, error) {
+	book, err := p.book(ctx, market, p.cfg.Depth)
+	if err != nil {
+		return 0, err
+	}
+	bid, bidSize := sideAverage(book.Bids, p.cfg.Depth)
+	ask, askSize := sideAverage(book.Asks, p.cfg.Depth)
+	if bidSize+askSize <= 0 {
+		return 0, fmt.Errorf("order book has no size")
+	}
+	return (bid*askSize + ask*bidSize) / (bidSize + askSize), nil
+}
+
+// book fetches the order book, attaches it to market and checks that both
+// sides have a level
+func (p *PriceSource) book(ctx context.Context, market *types.MarketData, depth int) (*types.OrderBook, error) {
+	book, err := p.exchange.GetOrderBook(ctx, market.Symbol, depth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order book: %w", err)
+	}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return nil, fmt.Errorf("order book is one-sided")
+	}
+	market.OrderBook = book
+	return book, nil
+}
+
+// sideAverage returns the volume-weighted price and total size of a book
+// side's first depth levels
+func sideAverage(levels []types.OrderBookEntry, depth int) (float64, float64) {
+	var notional, size float64
+	for i, level := range levels {
+		if i == depth {
+			break
+		}
+		notional += level.Price * level.Amount
+		size += level.Amount
+	}
+	if size == 0 {
+		return 0, 0
+	}
+	return notional / size, size
+}
+
+// vwap averages the typical price of the one-minute candles in the window by
+// their volume; the candle still forming counts
+func (p *PriceSource) vwap(ctx context.Context, symbol string) (float64, error) {
+	minutes := int(math.Ceil(p.cfg.VWAPWindow.Minutes()))
+	candles, err := p.exchange.GetCandles(ctx, symbol, "1m", minutes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get candles: %w", err)
+	}
+	var notional, volume float64
+	for _, c := range candles {
+		notional += (c.High + c.Low + c.Close) / 3 * c.Volume
+		volume += c.Volume
+	}
+	if volume <= 0 {
+		return 0, fmt.Errorf("no volume in the last %s", p.cfg.VWAPWindow)
+	}
+	return notional / volume, nil
+}
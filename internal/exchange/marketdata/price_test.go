@@ -0,0 +1,96 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// bookClient serves a fixed order book on top of rising candles
+type bookClient struct {
+	candleClient
+	book *types.OrderBook
+	err  error
+}
+
+func (c *bookClient) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.book, nil
+}
+
+func TestPriceSource(t *testing.T) {
+	client := &bookClient{
+		candleClient: candleClient{calls: map[string]int{}},
+		book: &types.OrderBook{
+			Bids: []types.OrderBookEntry{{Price: 99, Amount: 3}, {Price: 98, Amount: 1}},
+			Asks: []types.OrderBookEntry{{Price: 101, Amount: 1}},
+		},
+	}
+	market := func() types.MarketData {
+		return types.MarketData{Symbol: "BTCUSDT", Price: 100.5, Ticker: &types.Ticker{Price: 100.5, Bid: 100, Ask: 100.4}}
+	}
+
+	for _, tt := range []struct {
+		cfg  PriceConfig
+		want float64
+	}{
+		{PriceConfig{}, 100.5},
+		{PriceConfig{Source: PriceMid}, 100.2},
+		// Bids average 98.75 for 4, asks 101 for 1: the heavy bid side pulls
+		// the price towards the ask
+		{PriceConfig{Source: PriceDepthMid}, (98.75*1 + 101*4) / 5.0},
+		{PriceConfig{Source: PriceDepthMid, Depth: 1}, (99*1 + 101*3) / 4.0},
+		// Three one-minute candles closing 100, 101, 102 with equal volume
+		{PriceConfig{Source: PriceVWAP, VWAPWindow: 150 * time.Second}, 101},
+	} {
+		md := market()
+		if err := NewPriceSource(client, tt.cfg).Apply(context.Background(), &md); err != nil {
+			t.Fatalf("%+v: %v", tt.cfg, err)
+		}
+		if math.Abs(md.Price-tt.want) > 1e-9 {
+			t.Errorf("%+v: price = %v, want %v", tt.cfg, md.Price, tt.want)
+		}
+		if md.Ticker.Price != 100.5 {
+			t.Errorf("%+v: ticker price changed to %v", tt.cfg, md.Ticker.Price)
+		}
+	}
+	if client.calls["1m"] != 1 {
+		t.Errorf("1m candle fetches = %d, want 1", client.calls["1m"])
+	}
+
+	// Mid falls back to the book when the ticker has no quotes
+	md := types.MarketData{Symbol: "BTCUSDT", Price: 100.5, Ticker: &types.Ticker{Price: 100.5}}
+	if err := NewPriceSource(client, PriceConfig{Source: PriceMid}).Apply(context.Background(), &md); err != nil || md.Price != 100 || md.OrderBook == nil {
+		t.Errorf("mid from book: price = %v, book = %v, err = %v", md.Price, md.OrderBook, err)
+	}
+
+	// Without a book the last price stays
+	client.err = errors.New("unavailable")
+	md = market()
+	if err := NewPriceSource(client, PriceConfig{Source: PriceDepthMid}).Apply(context.Background(), &md); err == nil || md.Price != 100.5 {
+		t.Errorf("failed book: price = %v, err = %v", md.Price, err)
+	}
+}
+
+func TestPriceConfig(t *testing.T) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"price":{"source":"vwap","vwap_window":"10m"}}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil || cfg.Price.VWAPWindow != 10*time.Minute {
+		t.Errorf("price = %+v, err = %v", cfg.Price, err)
+	}
+	if cfg.Enabled() {
+		t.Error("a price source alone should not enable timeframes")
+	}
+	if err := (Config{Price: PriceConfig{Source: "close"}}).Validate(); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
@@ -0,0 +1,266 @@
+// Package marketdata gives strategies higher-timeframe context: candles of
+// configured intervals and indicators computed over them, e.g. a 1h trend
+// filter for a grid running on one-minute ticks. Candles are cached per symbol
+// and interval and only refetched once their refresh period has passed.
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/indicators"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Timeframe configures one higher timeframe; zero fields take defaults
+type Timeframe struct {
+	Interval   string        `json:"interval"`   // exchange candle interval ("1h", "4h", "1d")
+	Bars       int           `json:"bars"`       // candles fetched and kept (default 200)
+	Indicators []string      `json:"indicators"` // "sma:50", "ema:200", "rsi:14", "atr:14"
+	Refresh    time.Duration `json:"refresh"`    // how long fetched candles are reused (default 1m)
+}
+
+// UnmarshalJSON implements custom parsing for durations ("30s", "5m")
+func (t *Timeframe) UnmarshalJSON(data []byte) error {
+	type Alias Timeframe
+	aux := &struct {
+		Refresh string `json:"refresh"`
+		*Alias
+	}{
+		Alias: (*Alias)(t),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Refresh != "" {
+		refresh, err := time.ParseDuration(aux.Refresh)
+		if err != nil {
+			return fmt.Errorf("invalid refresh format: %w", err)
+		}
+		t.Refresh = refresh
+	}
+
+	return nil
+}
+
+func (t Timeframe) withDefaults() Timeframe {
+	if t.Bars <= 0 {
+		t.Bars = 200
+	}
+	if t.Refresh <= 0 {
+		t.Refresh = time.Minute
+	}
+	return t
+}
+
+// Config lists the timeframes provided to strategies (disabled when empty)
+type Config struct {
+	Timeframes []Timeframe `json:"timeframes"`
+
+	// Price picks the reference price strategies trade on (default: last)
+	Price PriceConfig `json:"price"`
+}
+
+// Enabled reports whether any timeframe is configured
+func (c Config) Enabled() bool {
+	return len(c.Timeframes) > 0
+}
+
+// HasInterval reports whether a timeframe of interval is configured
+func (c Config) HasInterval(interval string) bool {
+	for _, tf := range c.Timeframes {
+		if tf.Interval == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the config
+func (c Config) Validate() error {
+	if err := c.Price.Validate(); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(c.Timeframes))
+	for _, tf := range c.Timeframes {
+		if tf.Interval == "" {
+			return fmt.Errorf("timeframe interval is required")
+		}
+		if seen[tf.Interval] {
+			return fmt.Errorf("timeframe %s is configured twice", tf.Interval)
+		}
+		seen[tf.Interval] = true
+		if tf.Bars < 0 || tf.Refresh < 0 {
+			return fmt.Errorf("timeframe %s: bars and refresh must not be negative", tf.Interval)
+		}
+		bars := tf.withDefaults().Bars
+		for _, spec := range tf.Indicators {
+			ind, err := parseIndicator(spec)
+			if err != nil {
+				return fmt.Errorf("timeframe %s: %w", tf.Interval, err)
+			}
+			if ind.bars() > bars {
+				return fmt.Errorf("timeframe %s: %s needs %d bars, only %d fetched", tf.Interval, spec, ind.bars(), bars)
+			}
+		}
+	}
+	return nil
+}
+
+// indicator is a parsed indicator spec such as "ema:200"
+type indicator struct {
+	kind   string
+	period int
+}
+
+// parseIndicator parses "kind:period"
+func parseIndicator(spec string) (indicator, error) {
+	kind, period, ok := strings.Cut(spec, ":")
+	if !ok {
+		return indicator{}, fmt.Errorf("indicator %q: expected kind:period", spec)
+	}
+	n, err := strconv.Atoi(period)
+	if err != nil || n <= 0 {
+		return indicator{}, fmt.Errorf("indicator %q: period must be a positive integer", spec)
+	}
+	switch kind {
+	case "sma", "ema", "rsi", "atr":
+	default:
+		return indicator{}, fmt.Errorf("indicator %q: unknown kind %s (sma, ema, rsi, atr)", spec, kind)
+	}
+	return indicator{kind: kind, period: n}, nil
+}
+
+// name is the key the indicator's value is published under
+func (ind indicator) name() string {
+	return fmt.Sprintf("%s_%d", ind.kind, ind.period)
+}
+
+// bars is the number of candles needed for one value
+func (ind indicator) bars() int {
+	if ind.kind == "rsi" || ind.kind == "atr" {
+		return ind.period + 1
+	}
+	return ind.period
+}
+
+// latest computes the indicator's value at the last candle
+func (ind indicator) latest(candles []types.Candle) (float64, bool) {
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	var values []float64
+	switch ind.kind {
+	case "sma":
+		values = indicators.SMA(closes, ind.period)
+	case "ema":
+		values = indicators.EMA(closes, ind.period)
+	case "rsi":
+		values = indicators.RSI(closes, ind.period)
+	case "atr":
+		highs := make([]float64, len(candles))
+		lows := make([]float64, len(candles))
+		for i, c := range candles {
+			highs[i], lows[i] = c.High, c.Low
+		}
+		values = indicators.ATR(highs, lows, closes, ind.period)
+	}
+	if len(values) == 0 {
+		return 0, false
+	}
+	return values[len(values)-1], true
+}
+
+// Provider fetches and caches higher-timeframe data
+type Provider struct {
+	exchange   types.ExchangeClient
+	timeframes []Timeframe
+	indicators [][]indicator // per timeframe
+
+	mu    sync.Mutex
+	cache map[string]*types.Timeframe // keyed by symbol and interval
+	now   func() time.Time
+}
+
+// NewProvider creates a provider fetching candles from exchange
+func NewProvider(exchange types.ExchangeClient, cfg Config) (*Provider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
+		exchange: exchange,
+		cache:    make(map[string]*types.Timeframe),
+		now:      time.Now,
+	}
+	for _, tf := range cfg.Timeframes {
+		inds := make([]indicator, 0, len(tf.Indicators))
+		for _, spec := range tf.Indicators {
+			ind, _ := parseIndicator(spec)
+			inds = append(inds, ind)
+		}
+		p.timeframes = append(p.timeframes, tf.withDefaults())
+		p.indicators = append(p.indicators, inds)
+	}
+	return p, nil
+}
+
+// Context returns every configured timeframe for symbol, refetching those
+// older than their refresh period. A timeframe that fails to refresh keeps
+// its previous data, if any, and the failures are returned alongside the
+// context so callers can still trade on what is available.
+func (p *Provider) Context(ctx context.Context, symbol string) (*types.MarketContext, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	mc := &types.MarketContext{Timeframes: make(map[string]*types.Timeframe, len(p.timeframes))}
+	var errs []error
+	for i, tf := range p.timeframes {
+		key := symbol + "|" + tf.Interval
+		cached := p.cache[key]
+		if cached == nil || p.now().Sub(cached.UpdatedAt) >= tf.Refresh {
+			fresh, err := p.fetch(ctx, symbol, tf, p.indicators[i])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", symbol, tf.Interval, err))
+			} else {
+				p.cache[key] = fresh
+				cached = fresh
+			}
+		}
+		if cached != nil {
+			mc.Timeframes[tf.Interval] = cached
+		}
+	}
+	return mc, errors.Join(errs...)
+}
+
+// fetch downloads a timeframe's candles and computes its indicators
+func (p *Provider) fetch(ctx context.Context, symbol string, tf Timeframe, inds []indicator) (*types.Timeframe, error) {
+	candles, err := p.exchange.GetCandles(ctx, symbol, tf.Interval, tf.Bars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch candles: %w", err)
+	}
+
+	data := &types.Timeframe{
+		Interval:   tf.Interval,
+		Candles:    candles,
+		Indicators: make(map[string]float64, len(inds)),
+		UpdatedAt:  p.now(),
+	}
+	for _, ind := range inds {
+		if value, ok := ind.latest(candles); ok {
+			data.Indicators[ind.name()] = value
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,139 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// candleClient serves rising candles and counts fetches per interval
+type candleClient struct {
+	types.ExchangeClient
+	calls map[string]int
+	err   error
+}
+
+func (c *candleClient) GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error) {
+	c.calls[interval]++
+	if c.err != nil {
+		return nil, c.err
+	}
+	candles := make([]types.Candle, limit)
+	for i := range candles {
+		price := float64(100 + i)
+		candles[i] = types.Candle{Symbol: symbol, Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 1}
+	}
+	return candles, nil
+}
+
+func TestProviderContext(t *testing.T) {
+	client := &candleClient{calls: make(map[string]int)}
+	p, err := NewProvider(client, Config{Timeframes: []Timeframe{
+		{Interval: "1h", Bars: 60, Indicators: []string{"sma:50", "ema:20", "rsi:14", "atr:14"}},
+		{Interval: "4h", Bars: 10, Refresh: 10 * time.Minute},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	mc, err := p.Context(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Closes run 100..159, so the last 50 average 134.5
+	if sma, ok := mc.Indicator("1h", "sma_50"); !ok || math.Abs(sma-134.5) > 1e-9 {
+		t.Errorf("sma_50 = %v, %v; want 134.5", sma, ok)
+	}
+	if rsi, _ := mc.Indicator("1h", "rsi_14"); rsi != 100 {
+		t.Errorf("rsi_14 = %v on a steady rise, want 100", rsi)
+	}
+	if atr, _ := mc.Indicator("1h", "atr_14"); math.Abs(atr-2) > 1e-9 {
+		t.Errorf("atr_14 = %v, want 2", atr)
+	}
+	if _, ok := mc.Indicator("1h", "ema_200"); ok {
+		t.Error("unconfigured indicator reported")
+	}
+	if got := len(mc.Timeframe("4h").Candles); got != 10 {
+		t.Errorf("4h candles = %d, want 10", got)
+	}
+
+	// Cached until each timeframe's refresh period passes
+	now = now.Add(2 * time.Minute)
+	if _, err := p.Context(ctx, "BTCUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	if client.calls["1h"] != 2 || client.calls["4h"] != 1 {
+		t.Errorf("fetches = %v, want 1h refreshed and 4h cached", client.calls)
+	}
+
+	// Symbols are cached separately
+	if _, err := p.Context(ctx, "ETHUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	if client.calls["4h"] != 2 {
+		t.Errorf("4h fetches = %d, want 2", client.calls["4h"])
+	}
+}
+
+func TestProviderKeepsStaleDataOnError(t *testing.T) {
+	client := &candleClient{calls: make(map[string]int)}
+	p, err := NewProvider(client, Config{Timeframes: []Timeframe{{Interval: "1h", Bars: 20, Indicators: []string{"sma:20"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := p.Context(ctx, "BTCUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	client.err = errors.New("boom")
+	now = now.Add(time.Hour)
+
+	mc, err := p.Context(ctx, "BTCUSDT")
+	if err == nil {
+		t.Fatal("expected refresh error")
+	}
+	if _, ok := mc.Indicator("1h", "sma_20"); !ok {
+		t.Error("stale data dropped after a failed refresh")
+	}
+
+	var nilContext *types.MarketContext
+	if _, ok := nilContext.Indicator("1h", "sma_20"); ok {
+		t.Error("nil context reported an indicator")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"missing interval": {Timeframes: []Timeframe{{}}},
+		"duplicate":        {Timeframes: []Timeframe{{Interval: "1h"}, {Interval: "1h"}}},
+		"unknown kind":     {Timeframes: []Timeframe{{Interval: "1h", Indicators: []string{"vwap:20"}}}},
+		"bad period":       {Timeframes: []Timeframe{{Interval: "1h", Indicators: []string{"sma:x"}}}},
+		"too few bars":     {Timeframes: []Timeframe{{Interval: "1h", Bars: 100, Indicators: []string{"ema:200"}}}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"timeframes":[{"interval":"1h","indicators":["ema:200"],"refresh":"5m"}]}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeframes[0].Refresh != 5*time.Minute {
+		t.Errorf("refresh = %s, want 5m", cfg.Timeframes[0].Refresh)
+	}
+}
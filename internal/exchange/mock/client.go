@@ -2,6 +2,8 @@ package mock
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
@@ -75,6 +77,16 @@ func (mc *MockClient) GetOrder(ctx context.Context, orderID string) (*types.Orde
 	return nil, nil
 }
 
+// GetOrderByClientID gets a mock order by client order id
+func (mc *MockClient) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	for _, order := range mc.orders {
+		if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID == clientOrderID {
+			return order, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: client id %s", types.ErrOrderNotFound, clientOrderID)
+}
+
 // GetActiveOrders gets active mock orders
 func (mc *MockClient) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
 	var activeOrders []types.Order
@@ -97,6 +109,19 @@ func (mc *MockClient) GetFilledOrders(ctx context.Context, symbol string) ([]typ
 	return filledOrders, nil
 }
 
+// GetOrderHistory pages filled mock orders
+func (mc *MockClient) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	filled, _ := mc.GetFilledOrders(ctx, query.Symbol)
+	// Orders are kept by id; pages need a stable order
+	sort.Slice(filled, func(i, j int) bool {
+		if !filled[i].Timestamp.Equal(filled[j].Timestamp) {
+			return filled[i].Timestamp.Before(filled[j].Timestamp)
+		}
+		return filled[i].ID < filled[j].ID
+	})
+	return types.PageOrders(filled, query)
+}
+
 // GetTicker gets mock ticker data
 func (mc *MockClient) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
 	return &types.Ticker{
@@ -109,6 +134,11 @@ func (mc *MockClient) GetTicker(ctx context.Context, symbol string) (*types.Tick
 	}, nil
 }
 
+// GetTickers gets mock ticker data for each symbol
+func (mc *MockClient) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	return types.FetchTickers(ctx, symbols, mc.GetTicker)
+}
+
 // GetOrderBook gets mock order book
 func (mc *MockClient) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
 	return &types.OrderBook{
@@ -151,6 +181,18 @@ func (mc *MockClient) GetBalance(ctx context.Context) (*types.Balance, error) {
 	return mc.balances["USDT"], nil
 }
 
+// GetBalances gets every non-zero mock balance, sorted by asset
+func (mc *MockClient) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	balances := make([]types.Balance, 0, len(mc.balances))
+	for _, balance := range mc.balances {
+		if balance.Total != 0 {
+			balances = append(balances, *balance)
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Asset < balances[j].Asset })
+	return balances, nil
+}
+
 // GetTradingFees gets mock trading fees
 func (mc *MockClient) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
 	return &types.TradingFees{
@@ -161,6 +203,58 @@ func (mc *MockClient) GetTradingFees(ctx context.Context, symbol string) (*types
 	}, nil
 }
 
+// GetFundingRate gets a mock funding rate of 0.01% per 8h
+func (mc *MockClient) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	now := time.Now()
+	return &types.FundingRate{
+		Symbol:          symbol,
+		Rate:            0.0001,
+		Interval:        8 * time.Hour,
+		MarkPrice:       45000.0,
+		NextFundingTime: now.Truncate(8 * time.Hour).Add(8 * time.Hour),
+		Timestamp:       now,
+	}, nil
+}
+
+// GetSymbolRules is not supported: the mock exchange accepts any size
+func (mc *MockClient) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	return nil, types.ErrNotSupported
+}
+
+// ConvertDust is not supported by the mock exchange
+func (mc *MockClient) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	return nil, types.ErrNotSupported
+}
+
+// GetBorrowRates gets mock borrow rates of 0.0005% per hour
+func (mc *MockClient) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	rates := make([]types.BorrowRate, 0, len(assets))
+	for _, asset := range assets {
+		rates = append(rates, types.BorrowRate{Asset: asset, HourlyRate: 0.000005, Timestamp: time.Now()})
+	}
+	return rates, nil
+}
+
+// GetSavings is not supported by the mock exchange
+func (mc *MockClient) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	return nil, types.ErrNotSupported
+}
+
+// SubscribeSavings is not supported by the mock exchange
+func (mc *MockClient) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+// RedeemSavings is not supported by the mock exchange
+func (mc *MockClient) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+// GetSystemStatus always reports normal operation
+func (mc *MockClient) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	return &types.SystemStatus{State: types.SystemNormal, Timestamp: time.Now()}, nil
+}
+
 // Ping pings the mock exchange
 func (mc *MockClient) Ping(ctx context.Context) error {
 	return nil
@@ -0,0 +1,316 @@
+// Package ratelimit shares one exchange request budget between consumers
+// (strategies, portfolio refresh, status polling) of the same client. Each
+// consumer can be held to its own quota, and requests waiting for the shared
+// budget are served by priority: orders first, candles and order books last.
+package ratelimit
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Priority orders requests waiting for the shared budget
+type Priority int
+
+// Request priorities, highest first
+const (
+	PriorityHigh   Priority = iota // order placement and cancellation
+	PriorityNormal                 // account, order status and tickers
+	PriorityLow                    // candles, order books and reference data
+)
+
+var priorityNames = [...]string{"high", "normal", "low"}
+
+func (p Priority) String() string {
+	if p < 0 || int(p) >= len(priorityNames) {
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// Limit is a token bucket rate
+type Limit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"` // defaults to one second of requests
+}
+
+func (l Limit) limiter() *rate.Limiter {
+	burst := l.Burst
+	if burst <= 0 {
+		burst = max(1, int(l.RequestsPerSecond))
+	}
+	return rate.NewLimiter(rate.Limit(l.RequestsPerSecond), burst)
+}
+
+// Config configures a Budget (disabled when RequestsPerSecond is zero)
+type Config struct {
+	Limit
+
+	// Consumers caps individual consumers below the shared budget; consumers
+	// not listed are bound by the shared budget only
+	Consumers map[string]Limit `json:"consumers"`
+}
+
+// Enabled reports whether a shared budget is configured
+func (c Config) Enabled() bool {
+	return c.RequestsPerSecond > 0
+}
+
+// Validate checks the config
+func (c Config) Validate() error {
+	if c.RequestsPerSecond < 0 || c.Burst < 0 {
+		return fmt.Errorf("rate limit must not be negative")
+	}
+	for name, limit := range c.Consumers {
+		if !c.Enabled() {
+			return fmt.Errorf("consumer %s needs a shared rate limit", name)
+		}
+		if limit.RequestsPerSecond <= 0 || limit.Burst < 0 {
+			return fmt.Errorf("consumer %s: requests per second must be positive", name)
+		}
+		if limit.RequestsPerSecond > c.RequestsPerSecond {
+			return fmt.Errorf("consumer %s: %.2f req/s exceeds the shared %.2f req/s", name, limit.RequestsPerSecond, c.RequestsPerSecond)
+		}
+	}
+	return nil
+}
+
+// saturationWindow is the period Status measures utilization over
+const saturationWindow = time.Minute
+
+// Budget grants requests from a shared token bucket by priority
+type Budget struct {
+	cfg    Config
+	global *rate.Limiter
+
+	mu        sync.Mutex
+	consumers map[string]*consumer
+	queue     waitQueue
+	seq       uint64
+	granting  bool        // a grant loop is running
+	grants    []time.Time // grants within saturationWindow
+}
+
+// consumer tracks one consumer's quota and counters
+type consumer struct {
+	limiter *rate.Limiter // nil without a quota
+	granted int
+	limited int // requests delayed by the consumer's own quota
+	queued  int
+	waited  time.Duration
+}
+
+// waiter is a request queued for the shared budget
+type waiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// NewBudget creates a budget for cfg
+func NewBudget(cfg Config) *Budget {
+	return &Budget{
+		cfg:       cfg,
+		global:    cfg.Limit.limiter(),
+		consumers: make(map[string]*consumer),
+	}
+}
+
+// Wait blocks until the consumer's quota and the shared budget allow one
+// request at the given priority, or ctx is done
+func (b *Budget) Wait(ctx context.Context, name string, priority Priority) error {
+	start := time.Now()
+
+	b.mu.Lock()
+	c := b.consumer(name)
+	b.mu.Unlock()
+
+	if c.limiter != nil {
+		reservation := c.limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			b.mu.Lock()
+			c.limited++
+			b.mu.Unlock()
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				reservation.Cancel()
+				return fmt.Errorf("rate limit wait for %s: %w", name, ctx.Err())
+			}
+		}
+	}
+
+	b.mu.Lock()
+	b.seq++
+	w := &waiter{priority: priority, seq: b.seq, ready: make(chan struct{})}
+	heap.Push(&b.queue, w)
+	c.queued++
+	if !b.granting {
+		b.granting = true
+		go b.grant()
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		b.mu.Lock()
+		c.queued--
+		c.granted++
+		c.waited += time.Since(start)
+		b.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		c.queued--
+		if w.index >= 0 {
+			heap.Remove(&b.queue, w.index)
+			return fmt.Errorf("rate limit wait for %s: %w", name, ctx.Err())
+		}
+		// Granted while being cancelled; the request may go ahead
+		c.granted++
+		c.waited += time.Since(start)
+		return nil
+	}
+}
+
+// grant takes a token from the shared bucket and hands it to the highest
+// priority waiter at that moment, until the queue is empty
+func (b *Budget) grant() {
+	for {
+		_ = b.global.Wait(context.Background())
+
+		b.mu.Lock()
+		if b.queue.Len() == 0 {
+			// Everyone cancelled while the token was awaited
+			b.granting = false
+			b.mu.Unlock()
+			return
+		}
+		w := heap.Pop(&b.queue).(*waiter)
+		close(w.ready)
+		now := time.Now()
+		b.grants = append(b.grants, now)
+		b.prune(now)
+		if b.queue.Len() == 0 {
+			b.granting = false
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+	}
+}
+
+// consumer returns the named consumer, creating it on first use
+func (b *Budget) consumer(name string) *consumer {
+	c, ok := b.consumers[name]
+	if !ok {
+		c = &consumer{}
+		if limit, capped := b.cfg.Consumers[name]; capped {
+			c.limiter = limit.limiter()
+		}
+		b.consumers[name] = c
+	}
+	return c
+}
+
+// prune drops grants older than the saturation window
+func (b *Budget) prune(now time.Time) {
+	cutoff := now.Add(-saturationWindow)
+	i := 0
+	for i < len(b.grants) && b.grants[i].Before(cutoff) {
+		i++
+	}
+	b.grants = b.grants[i:]
+}
+
+// ConsumerStatus reports one consumer's use of the budget
+type ConsumerStatus struct {
+	Name              string        `json:"name"`
+	RequestsPerSecond float64       `json:"requests_per_second,omitempty"` // own quota, 0 when uncapped
+	Granted           int           `json:"granted"`
+	Limited           int           `json:"limited"` // requests delayed by the consumer's quota
+	Queued            int           `json:"queued"`
+	AverageWait       time.Duration `json:"average_wait"`
+}
+
+// Status reports the shared budget's saturation and per-consumer counters
+type Status struct {
+	RequestsPerSecond float64          `json:"requests_per_second"`
+	Queued            map[string]int   `json:"queued"`     // waiting for the shared budget, by priority
+	Saturation        float64          `json:"saturation"` // share of the last minute's capacity used, 0-1
+	Consumers         []ConsumerStatus `json:"consumers"`
+}
+
+// Status returns a snapshot of the budget
+func (b *Budget) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune(time.Now())
+	status := Status{
+		RequestsPerSecond: b.cfg.RequestsPerSecond,
+		Queued:            make(map[string]int),
+		Saturation:        min(1, float64(len(b.grants))/(b.cfg.RequestsPerSecond*saturationWindow.Seconds())),
+	}
+	for _, w := range b.queue {
+		status.Queued[w.priority.String()]++
+	}
+	for name, c := range b.consumers {
+		cs := ConsumerStatus{Name: name, Granted: c.granted, Limited: c.limited, Queued: c.queued}
+		if limit, capped := b.cfg.Consumers[name]; capped {
+			cs.RequestsPerSecond = limit.RequestsPerSecond
+		}
+		if c.granted > 0 {
+			cs.AverageWait = c.waited / time.Duration(c.granted)
+		}
+		status.Consumers = append(status.Consumers, cs)
+	}
+	sort.Slice(status.Consumers, func(i, j int) bool {
+		return status.Consumers[i].Name < status.Consumers[j].Name
+	})
+	return status
+}
+
+// waitQueue is a heap of waiters by priority, then arrival
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBudgetServesHighPriorityFirst(t *testing.T) {
+	budget := NewBudget(Config{Limit: Limit{RequestsPerSecond: 20, Burst: 1}})
+	ctx := context.Background()
+
+	// Use up the burst so the next grant is ~50ms away
+	if err := budget.Wait(ctx, "strategy", PriorityHigh); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	start := func(name string, priority Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := budget.Wait(ctx, name, priority); err != nil {
+				t.Errorf("Wait(%s) error = %v", name, err)
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}()
+	}
+	start("candles", PriorityLow)
+	time.Sleep(10 * time.Millisecond)
+	start("orders", PriorityHigh)
+	time.Sleep(10 * time.Millisecond)
+	if queued := budget.Status().Queued; queued["low"] != 1 || queued["high"] != 1 {
+		t.Errorf("Expected one low and one high waiter, got %v", queued)
+	}
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != "orders" {
+		t.Errorf("Expected the order request first, got %v", order)
+	}
+}
+
+func TestBudgetConsumerQuota(t *testing.T) {
+	budget := NewBudget(Config{
+		Limit:     Limit{RequestsPerSecond: 1000, Burst: 100},
+		Consumers: map[string]Limit{"portfolio": {RequestsPerSecond: 10, Burst: 1}},
+	})
+	ctx := context.Background()
+
+	begin := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := budget.Wait(ctx, "portfolio", PriorityNormal); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(begin); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected the 10 req/s quota to space requests, took %v", elapsed)
+	}
+	for i := 0; i < 3; i++ {
+		if err := budget.Wait(ctx, "strategy", PriorityNormal); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+
+	status := budget.Status()
+	if len(status.Consumers) != 2 {
+		t.Fatalf("Expected 2 consumers, got %+v", status.Consumers)
+	}
+	portfolio, strategy := status.Consumers[0], status.Consumers[1]
+	if portfolio.Granted != 3 || portfolio.Limited != 2 || portfolio.RequestsPerSecond != 10 {
+		t.Errorf("Unexpected portfolio status %+v", portfolio)
+	}
+	if strategy.Granted != 3 || strategy.Limited != 0 {
+		t.Errorf("Unexpected strategy status %+v", strategy)
+	}
+	if status.Saturation <= 0 || status.Saturation > 1 {
+		t.Errorf("Expected saturation in (0, 1], got %v", status.Saturation)
+	}
+}
+
+func TestBudgetWaitCancelled(t *testing.T) {
+	budget := NewBudget(Config{Limit: Limit{RequestsPerSecond: 1, Burst: 1}})
+	if err := budget.Wait(context.Background(), "strategy", PriorityLow); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := budget.Wait(ctx, "strategy", PriorityLow); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() = %v, want deadline exceeded", err)
+	}
+	if queued := budget.Status().Queued; len(queued) != 0 {
+		t.Errorf("Cancelled waiter left in the queue: %v", queued)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	invalid := []Config{
+		{Limit: Limit{RequestsPerSecond: -1}},
+		{Consumers: map[string]Limit{"portfolio": {RequestsPerSecond: 1}}},
+		{Limit: Limit{RequestsPerSecond: 5}, Consumers: map[string]Limit{"portfolio": {RequestsPerSecond: 10}}},
+		{Limit: Limit{RequestsPerSecond: 5}, Consumers: map[string]Limit{"portfolio": {}}},
+	}
+	for i, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("config %d: expected validation error", i)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Client wraps an exchange client so every request of the named consumer
+// waits for the budget at the priority of its kind
+func (b *Budget) Client(name string, exchange types.ExchangeClient) types.ExchangeClient {
+	return &client{ExchangeClient: exchange, budget: b, name: name}
+}
+
+// client applies a Budget to each exchange request
+type client struct {
+	types.ExchangeClient
+	budget *Budget
+	name   string
+}
+
+func (c *client) wait(ctx context.Context, priority Priority) error {
+	return c.budget.Wait(ctx, c.name, priority)
+}
+
+func (c *client) PlaceOrder(ctx context.Context, order types.Order) error {
+	if err := c.wait(ctx, PriorityHigh); err != nil {
+		return err
+	}
+	return c.ExchangeClient.PlaceOrder(ctx, order)
+}
+
+func (c *client) CancelOrder(ctx context.Context, orderID string) error {
+	if err := c.wait(ctx, PriorityHigh); err != nil {
+		return err
+	}
+	return c.ExchangeClient.CancelOrder(ctx, orderID)
+}
+
+func (c *client) GetOrder(ctx context.Context, orderID string) (*types.Order, error) {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetOrder(ctx, orderID)
+}
+
+func (c *client) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetOrderByClientID(ctx, symbol, clientOrderID)
+}
+
+func (c *client) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetActiveOrders(ctx, symbol)
+}
+
+func (c *client) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetFilledOrders(ctx, symbol)
+}
+
+// GetOrderHistory is a bulk read and yields to trading requests
+func (c *client) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetOrderHistory(ctx, query)
+}
+
+func (c *client) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetTicker(ctx, symbol)
+}
+
+func (c *client) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetTickers(ctx, symbols)
+}
+
+func (c *client) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetOrderBook(ctx, symbol, limit)
+}
+
+func (c *client) GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetCandles(ctx, symbol, interval, limit)
+}
+
+func (c *client) GetBalance(ctx context.Context) (*types.Balance, error) {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetBalance(ctx)
+}
+
+func (c *client) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetBalances(ctx)
+}
+
+func (c *client) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetTradingFees(ctx, symbol)
+}
+
+func (c *client) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetSymbolRules(ctx, symbol)
+}
+
+func (c *client) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.ConvertDust(ctx, assets)
+}
+
+func (c *client) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetFundingRate(ctx, symbol)
+}
+
+func (c *client) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetBorrowRates(ctx, assets)
+}
+
+func (c *client) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetSavings(ctx, asset)
+}
+
+func (c *client) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return err
+	}
+	return c.ExchangeClient.SubscribeSavings(ctx, asset, amount)
+}
+
+func (c *client) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return err
+	}
+	return c.ExchangeClient.RedeemSavings(ctx, asset, amount)
+}
+
+func (c *client) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	if err := c.wait(ctx, PriorityLow); err != nil {
+		return nil, err
+	}
+	return c.ExchangeClient.GetSystemStatus(ctx)
+}
+
+func (c *client) Ping(ctx context.Context) error {
+	if err := c.wait(ctx, PriorityNormal); err != nil {
+		return err
+	}
+	return c.ExchangeClient.Ping(ctx)
+}
@@ -0,0 +1,671 @@
+// Package sim provides a scripted, deterministic exchange for strategy tests.
+//
+// A Script fixes the price path, fill behavior and injected failures up front;
+// the test then advances the exchange one Step at a time and feeds the
+// returned market data to a strategy. Nothing depends on the wall clock.
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrRejected is returned for orders rejected by the script
+var ErrRejected = errors.New("sim: order rejected")
+
+// Script describes how the simulated exchange behaves
+type Script struct {
+	Symbol   string
+	Prices   []float64     // price path; Step moves to the next price
+	Start    time.Time     // time of the first price (defaults to 2024-01-01 UTC)
+	Interval time.Duration // time between prices (defaults to 1h)
+
+	// Fill behavior
+	FillDelay int     // steps before a placed order fills; 0 fills on placement
+	FillRatio float64 // fraction of the quantity filled; 0 means fully filled
+	Fee       float64 // fee rate charged on the filled notional
+
+	// Spread puts the bid and ask this far apart around the price; market
+	// orders buy at the ask and sell at the bid
+	Spread float64
+
+	// RestingLimits makes limit orders that do not cross the spread rest in
+	// the book until the price trades through them, filling at their limit
+	// price; FillDelay then applies to market orders only
+	RestingLimits bool
+
+	// Failures, keyed by 1-based call number (PlaceOrder) or step index (GetTicker)
+	Rejects      map[int]error
+	TickerErrors map[int]error
+
+	// Carry costs; a nil BorrowRates makes GetBorrowRates return types.ErrNotSupported
+	FundingRate     float64            // per funding interval
+	FundingInterval time.Duration      // defaults to 8h
+	BorrowRates     map[string]float64 // hourly rate per asset
+
+	// SavingsRate is the annual rate flexible savings of the quote asset
+	// earn, credited every step; 0 makes the savings methods return
+	// types.ErrNotSupported
+	SavingsRate float64
+
+	// Order size rules; orders are rounded to the step size and rejected
+	// below the minimums with types.ErrBelowMinimum. Nil imposes none and
+	// makes GetSymbolRules and ConvertDust return types.ErrNotSupported.
+	Rules *types.SymbolRules
+
+	// System states by step index (e.g. types.SystemMaintenance); normal otherwise
+	SystemStates map[int]string
+
+	// Starting balances
+	QuoteAsset   string // defaults to USDT
+	BaseAsset    string // defaults to BTC
+	QuoteBalance float64
+	BaseBalance  float64
+}
+
+// Exchange is a types.ExchangeClient driven by a Script
+type Exchange struct {
+	mu     sync.Mutex
+	script Script
+	step   int
+	calls  int
+	nextID int
+
+	orders   []*types.Order
+	byClient map[string]*types.Order // by client order id
+	pending  map[string]int          // order id -> steps until fill
+	resting  map[string]bool
+	quote    float64
+	base     float64
+
+	savings  float64 // quote asset in flexible savings
+	interest float64 // credited to savings so far
+}
+
+// NewExchange creates a simulated exchange positioned at the first price
+func NewExchange(script Script) (*Exchange, error) {
+	if len(script.Prices) == 0 {
+		return nil, fmt.Errorf("sim: script has no prices")
+	}
+	for i, price := range script.Prices {
+		if price <= 0 {
+			return nil, fmt.Errorf("sim: price %d must be positive, got %v", i, price)
+		}
+	}
+	if script.FillRatio < 0 || script.FillRatio > 1 {
+		return nil, fmt.Errorf("sim: fill ratio must be in [0, 1], got %v", script.FillRatio)
+	}
+	if script.Start.IsZero() {
+		script.Start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if script.Interval <= 0 {
+		script.Interval = time.Hour
+	}
+	if script.FundingInterval <= 0 {
+		script.FundingInterval = 8 * time.Hour
+	}
+	if script.QuoteAsset == "" {
+		script.QuoteAsset = "USDT"
+	}
+	if script.BaseAsset == "" {
+		script.BaseAsset = "BTC"
+	}
+
+	return &Exchange{
+		script:   script,
+		byClient: make(map[string]*types.Order),
+		pending:  make(map[string]int),
+		resting:  make(map[string]bool),
+		quote:    script.QuoteBalance,
+		base:     script.BaseBalance,
+	}, nil
+}
+
+// Market returns the market data at the current step
+func (e *Exchange) Market() types.MarketData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.marketLocked()
+}
+
+// Step advances to the next price, settling orders whose fill delay elapsed.
+// It returns false once the price path is exhausted.
+func (e *Exchange) Step() (types.MarketData, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.step+1 >= len(e.script.Prices) {
+		return e.marketLocked(), false
+	}
+	e.step++
+	if e.savings > 0 {
+		credit := e.savings * e.script.SavingsRate * e.script.Interval.Hours() / (365 * 24)
+		e.savings += credit
+		e.interest += credit
+	}
+
+	price := e.script.Prices[e.step]
+	for _, order := range e.orders {
+		if e.resting[order.ID] {
+			if order.Side == types.OrderSideBuy && price <= order.Price || order.Side == types.OrderSideSell && price >= order.Price {
+				delete(e.resting, order.ID)
+				e.fillLocked(order, order.Price)
+			}
+			continue
+		}
+		remaining, ok := e.pending[order.ID]
+		if !ok {
+			continue
+		}
+		if remaining > 1 {
+			e.pending[order.ID] = remaining - 1
+			continue
+		}
+		delete(e.pending, order.ID)
+		e.fillLocked(order, e.marketPriceLocked(order.Side))
+	}
+
+	return e.marketLocked(), true
+}
+
+// Run feeds every remaining step to fn, starting with the current one
+func (e *Exchange) Run(fn func(step int, market types.MarketData) error) error {
+	market := e.Market()
+	for step := e.StepIndex(); ; step++ {
+		if err := fn(step, market); err != nil {
+			return err
+		}
+		var ok bool
+		if market, ok = e.Step(); !ok {
+			return nil
+		}
+	}
+}
+
+// StepIndex returns the index of the current price
+func (e *Exchange) StepIndex() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.step
+}
+
+// Savings returns the quote asset in flexible savings
+func (e *Exchange) Savings() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.savings
+}
+
+// Orders returns copies of all accepted orders in placement order
+func (e *Exchange) Orders() []types.Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	orders := make([]types.Order, len(e.orders))
+	for i, order := range e.orders {
+		orders[i] = *order
+	}
+	return orders
+}
+
+// Balances returns the quote and base asset balances
+func (e *Exchange) Balances() (quote, base float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.quote, e.base
+}
+
+// PlaceOrder accepts an order, or returns the scripted rejection for this call
+func (e *Exchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.calls++
+	if err, ok := e.script.Rejects[e.calls]; ok {
+		if err == nil {
+			err = ErrRejected
+		}
+		return err
+	}
+	if order.Quantity <= 0 {
+		return fmt.Errorf("sim: invalid quantity %v", order.Quantity)
+	}
+	if rules := e.script.Rules; rules != nil {
+		price := order.Price
+		if price <= 0 {
+			price = e.script.Prices[e.step]
+		}
+		if !rules.Tradable(order.Quantity, price) {
+			return fmt.Errorf("sim: %.8f %s @ %v: %w", order.Quantity, order.Symbol, price, types.ErrBelowMinimum)
+		}
+		order.Quantity = rules.RoundQuantity(order.Quantity)
+	}
+
+	e.nextID++
+	order.ID = fmt.Sprintf("sim-%d", e.nextID)
+	order.Status = types.OrderStatusNew
+	order.Timestamp = e.timeLocked()
+	placed := &order
+	e.orders = append(e.orders, placed)
+	if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID != "" {
+		e.byClient[order.ExchangeOrder.ClientOrderID] = placed
+	}
+
+	switch {
+	case e.script.RestingLimits && order.Type == types.OrderTypeLimit:
+		bid, ask := e.quotesLocked()
+		if order.Side == types.OrderSideBuy && order.Price >= ask || order.Side == types.OrderSideSell && order.Price <= bid {
+			e.fillLocked(placed, e.marketPriceLocked(order.Side))
+		} else {
+			e.resting[order.ID] = true
+		}
+	case e.script.FillDelay > 0:
+		e.pending[order.ID] = e.script.FillDelay
+	default:
+		e.fillLocked(placed, e.marketPriceLocked(order.Side))
+	}
+	return nil
+}
+
+// CancelOrder cancels an order that has not filled yet
+func (e *Exchange) CancelOrder(ctx context.Context, orderID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order := e.findLocked(orderID)
+	if order == nil {
+		return fmt.Errorf("sim: unknown order %s", orderID)
+	}
+	_, pending := e.pending[orderID]
+	if !pending && !e.resting[orderID] {
+		return fmt.Errorf("sim: order %s is %s", orderID, order.Status)
+	}
+	delete(e.pending, orderID)
+	delete(e.resting, orderID)
+	order.Status = types.OrderStatusCanceled
+	return nil
+}
+
+// GetOrder returns a copy of an order
+func (e *Exchange) GetOrder(ctx context.Context, orderID string) (*types.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order := e.findLocked(orderID)
+	if order == nil {
+		return nil, fmt.Errorf("sim: unknown order %s", orderID)
+	}
+	copied := *order
+	return &copied, nil
+}
+
+// GetOrderByClientID returns a copy of the order placed with clientOrderID
+func (e *Exchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order, ok := e.byClient[clientOrderID]
+	if !ok {
+		return nil, fmt.Errorf("sim: client id %s: %w", clientOrderID, types.ErrOrderNotFound)
+	}
+	copied := *order
+	return &copied, nil
+}
+
+// GetActiveOrders returns orders waiting for their fill delay or resting
+// in the book
+func (e *Exchange) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return e.filterOrders(symbol, func(o *types.Order) bool {
+		_, ok := e.pending[o.ID]
+		return ok || e.resting[o.ID]
+	}), nil
+}
+
+// GetFilledOrders returns fully or partially filled orders
+func (e *Exchange) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return e.filterOrders(symbol, func(o *types.Order) bool {
+		return o.Status == types.OrderStatusFilled || o.Status == types.OrderStatusPartiallyFilled
+	}), nil
+}
+
+// GetOrderHistory pages the filled orders
+func (e *Exchange) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	filled, _ := e.GetFilledOrders(ctx, query.Symbol)
+	return types.PageOrders(filled, query)
+}
+
+// GetTicker returns the current price, or the scripted error for this step
+func (e *Exchange) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err, ok := e.script.TickerErrors[e.step]; ok {
+		return nil, err
+	}
+	bid, ask := e.quotesLocked()
+	return &types.Ticker{
+		Symbol:    symbol,
+		Price:     e.script.Prices[e.step],
+		Bid:       bid,
+		Ask:       ask,
+		Timestamp: e.timeLocked(),
+	}, nil
+}
+
+// GetTickers returns the current price under each symbol
+func (e *Exchange) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	return types.FetchTickers(ctx, symbols, e.GetTicker)
+}
+
+// GetOrderBook returns a single-level book at the current bid and ask
+func (e *Exchange) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	bid, ask := e.quotesLocked()
+	return &types.OrderBook{
+		Symbol: symbol,
+		Bids:   []types.OrderBookEntry{{Price: bid, Amount: 1}},
+		Asks:   []types.OrderBookEntry{{Price: ask, Amount: 1}},
+	}, nil
+}
+
+// GetCandles returns up to limit flat candles of the path so far, newest last
+func (e *Exchange) GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	first := 0
+	if limit > 0 && e.step+1 > limit {
+		first = e.step + 1 - limit
+	}
+	candles := make([]types.Candle, 0, e.step+1-first)
+	for i := first; i <= e.step; i++ {
+		price := e.script.Prices[i]
+		candles = append(candles, types.Candle{
+			Symbol:    symbol,
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			Timestamp: e.script.Start.Add(time.Duration(i) * e.script.Interval),
+		})
+	}
+	return candles, nil
+}
+
+// GetBalance returns the quote asset balance
+func (e *Exchange) GetBalance(ctx context.Context) (*types.Balance, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return &types.Balance{
+		Asset:     e.script.QuoteAsset,
+		Free:      e.quote,
+		Total:     e.quote,
+		Timestamp: e.timeLocked(),
+	}, nil
+}
+
+// GetBalances returns the non-zero quote and base balances
+func (e *Exchange) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var balances []types.Balance
+	for _, b := range []struct {
+		asset  string
+		amount float64
+	}{{e.script.QuoteAsset, e.quote}, {e.script.BaseAsset, e.base}} {
+		if b.amount != 0 {
+			balances = append(balances, types.Balance{Asset: b.asset, Free: b.amount, Total: b.amount, Timestamp: e.timeLocked()})
+		}
+	}
+	return balances, nil
+}
+
+// GetTradingFees returns the scripted fee for both sides
+func (e *Exchange) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
+	return &types.TradingFees{
+		Symbol:    symbol,
+		MakerFee:  e.script.Fee,
+		TakerFee:  e.script.Fee,
+		Timestamp: e.script.Start,
+	}, nil
+}
+
+// GetSymbolRules returns the scripted order size rules
+func (e *Exchange) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	if e.script.Rules == nil {
+		return nil, types.ErrNotSupported
+	}
+	rules := *e.script.Rules
+	rules.Symbol = symbol
+	return &rules, nil
+}
+
+// ConvertDust converts a base balance too small to sell into the quote asset
+// at the current price
+func (e *Exchange) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	if e.script.Rules == nil {
+		return nil, types.ErrNotSupported
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	conversion := &types.DustConversion{Asset: e.script.QuoteAsset, Converted: make(map[string]float64), Timestamp: e.timeLocked()}
+	for _, asset := range assets {
+		if asset != e.script.BaseAsset {
+			return nil, fmt.Errorf("sim: cannot convert %s", asset)
+		}
+		price := e.script.Prices[e.step]
+		if !e.script.Rules.IsDust(e.base, price) {
+			return nil, fmt.Errorf("sim: %.8f %s is not dust", e.base, asset)
+		}
+		conversion.Converted[asset] = e.base
+		conversion.Received += e.base * price
+		e.quote += e.base * price
+		e.base = 0
+	}
+	return conversion, nil
+}
+
+// GetFundingRate returns the scripted funding rate, marked at the current price
+func (e *Exchange) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.timeLocked()
+	return &types.FundingRate{
+		Symbol:          symbol,
+		Rate:            e.script.FundingRate,
+		Interval:        e.script.FundingInterval,
+		MarkPrice:       e.script.Prices[e.step],
+		NextFundingTime: now.Truncate(e.script.FundingInterval).Add(e.script.FundingInterval),
+		Timestamp:       now,
+	}, nil
+}
+
+// GetBorrowRates returns the scripted hourly borrow rates
+func (e *Exchange) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	if e.script.BorrowRates == nil {
+		return nil, types.ErrNotSupported
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rates := make([]types.BorrowRate, 0, len(assets))
+	for _, asset := range assets {
+		rate, ok := e.script.BorrowRates[asset]
+		if !ok {
+			return nil, fmt.Errorf("sim: no borrow rate for %s", asset)
+		}
+		rates = append(rates, types.BorrowRate{Asset: asset, HourlyRate: rate, Timestamp: e.timeLocked()})
+	}
+	return rates, nil
+}
+
+// GetSavings returns the quote asset's savings at the scripted rate
+func (e *Exchange) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	if err := e.savingsAsset(asset); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return &types.SavingsBalance{Asset: asset, Amount: e.savings, APR: e.script.SavingsRate, Interest: e.interest, Timestamp: e.timeLocked()}, nil
+}
+
+// SubscribeSavings moves quote asset from the balance into savings
+func (e *Exchange) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	if err := e.savingsAsset(asset); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if amount <= 0 || amount > e.quote+1e-9 {
+		return fmt.Errorf("sim: cannot subscribe %.8f %s of %.8f", amount, asset, e.quote)
+	}
+	e.quote -= amount
+	e.savings += amount
+	return nil
+}
+
+// RedeemSavings moves quote asset from savings back into the balance
+func (e *Exchange) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	if err := e.savingsAsset(asset); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if amount <= 0 || amount > e.savings+1e-9 {
+		return fmt.Errorf("sim: cannot redeem %.8f %s of %.8f", amount, asset, e.savings)
+	}
+	amount = min(amount, e.savings)
+	e.savings -= amount
+	e.quote += amount
+	return nil
+}
+
+// savingsAsset checks savings are scripted and asset is the quote asset
+func (e *Exchange) savingsAsset(asset string) error {
+	if e.script.SavingsRate == 0 {
+		return types.ErrNotSupported
+	}
+	if asset != e.script.QuoteAsset {
+		return fmt.Errorf("sim: no savings for %s: %w", asset, types.ErrNotSupported)
+	}
+	return nil
+}
+
+// GetSystemStatus returns the scripted system state of the current step
+func (e *Exchange) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	state, ok := e.script.SystemStates[e.step]
+	if !ok {
+		state = types.SystemNormal
+	}
+	return &types.SystemStatus{State: state, Timestamp: e.timeLocked()}, nil
+}
+
+// Ping always succeeds
+func (e *Exchange) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close is a no-op
+func (e *Exchange) Close() error {
+	return nil
+}
+
+func (e *Exchange) marketLocked() types.MarketData {
+	price := e.script.Prices[e.step]
+	bid, ask := e.quotesLocked()
+	return types.MarketData{
+		Symbol:    e.script.Symbol,
+		Price:     price,
+		Timestamp: e.timeLocked(),
+		Ticker:    &types.Ticker{Symbol: e.script.Symbol, Price: price, Bid: bid, Ask: ask, Timestamp: e.timeLocked()},
+	}
+}
+
+// quotesLocked returns the bid and ask around the current price
+func (e *Exchange) quotesLocked() (bid, ask float64) {
+	price := e.script.Prices[e.step]
+	return price - e.script.Spread/2, price + e.script.Spread/2
+}
+
+// marketPriceLocked returns the price a market order of side fills at
+func (e *Exchange) marketPriceLocked(side types.OrderSide) float64 {
+	bid, ask := e.quotesLocked()
+	if side == types.OrderSideBuy {
+		return ask
+	}
+	return bid
+}
+
+func (e *Exchange) timeLocked() time.Time {
+	return e.script.Start.Add(time.Duration(e.step) * e.script.Interval)
+}
+
+// fillLocked fills an order at price and settles balances
+func (e *Exchange) fillLocked(order *types.Order, price float64) {
+	qty := order.Quantity
+	order.Status = types.OrderStatusFilled
+	if ratio := e.script.FillRatio; ratio > 0 && ratio < 1 {
+		qty *= ratio
+		if e.script.Rules != nil {
+			qty = e.script.Rules.RoundQuantity(qty)
+		}
+		order.Status = types.OrderStatusPartiallyFilled
+	}
+	order.FilledAmount = qty
+	order.FilledPrice = price
+
+	notional := qty * price
+	fee := notional * e.script.Fee
+	if order.Side == types.OrderSideBuy {
+		e.quote -= notional + fee
+		e.base += qty
+	} else {
+		e.quote += notional - fee
+		e.base -= qty
+	}
+}
+
+func (e *Exchange) findLocked(orderID string) *types.Order {
+	for _, order := range e.orders {
+		if order.ID == orderID {
+			return order
+		}
+	}
+	return nil
+}
+
+func (e *Exchange) filterOrders(symbol string, keep func(*types.Order) bool) []types.Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var orders []types.Order
+	for _, order := range e.orders {
+		if (symbol == "" || order.Symbol == symbol) && keep(order) {
+			orders = append(orders, *order)
+		}
+	}
+	return orders
+}
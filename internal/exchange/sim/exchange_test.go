@@ -0,0 +1,216 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func buyOrder(qty float64) types.Order {
+	return types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: qty}
+}
+
+func TestNewExchangeValidatesScript(t *testing.T) {
+	scripts := []Script{
+		{},
+		{Prices: []float64{100, 0}},
+		{Prices: []float64{100}, FillRatio: 1.5},
+	}
+	for _, script := range scripts {
+		if _, err := NewExchange(script); err == nil {
+			t.Errorf("expected error for %+v", script)
+		}
+	}
+}
+
+func TestFillDelayAndCancel(t *testing.T) {
+	ctx := context.Background()
+	ex, err := NewExchange(Script{Symbol: "BTCUSDT", Prices: []float64{100, 90, 80}, FillDelay: 1, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ex.PlaceOrder(ctx, buyOrder(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ex.PlaceOrder(ctx, buyOrder(2)); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := ex.GetActiveOrders(ctx, "BTCUSDT"); len(active) != 2 {
+		t.Fatalf("active orders = %d, want 2", len(active))
+	}
+	if err := ex.CancelOrder(ctx, "sim-2"); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	market, ok := ex.Step()
+	if !ok || market.Price != 90 {
+		t.Fatalf("Step = %v, %v", market.Price, ok)
+	}
+
+	order, _ := ex.GetOrder(ctx, "sim-1")
+	if order.Status != types.OrderStatusFilled || order.FilledPrice != 90 {
+		t.Fatalf("order after delay = %+v", order)
+	}
+	if order, _ := ex.GetOrder(ctx, "sim-2"); order.Status != types.OrderStatusCanceled {
+		t.Fatalf("canceled order = %+v", order)
+	}
+	if err := ex.CancelOrder(ctx, "sim-1"); err == nil {
+		t.Fatalf("expected error canceling a filled order")
+	}
+	if quote, base := ex.Balances(); quote != 910 || base != 1 {
+		t.Fatalf("balances = %v, %v", quote, base)
+	}
+
+	ex.Step()
+	if _, ok := ex.Step(); ok {
+		t.Fatalf("expected the price path to be exhausted")
+	}
+}
+
+func TestGetOrderByClientID(t *testing.T) {
+	ctx := context.Background()
+	ex, err := NewExchange(Script{Symbol: "BTCUSDT", Prices: []float64{100, 90}, FillDelay: 1, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := buyOrder(1)
+	order.ExchangeOrder = &types.ExchangeOrder{ClientOrderID: "grid-1"}
+	if err := ex.PlaceOrder(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := ex.GetOrderByClientID(ctx, "BTCUSDT", "grid-1"); err != nil || found.Status != types.OrderStatusNew {
+		t.Fatalf("working order = %+v, %v", found, err)
+	}
+	ex.Step()
+	if found, err := ex.GetOrderByClientID(ctx, "BTCUSDT", "grid-1"); err != nil || found.Status != types.OrderStatusFilled || found.FilledPrice != 90 {
+		t.Fatalf("filled order = %+v, %v", found, err)
+	}
+	if _, err := ex.GetOrderByClientID(ctx, "BTCUSDT", "grid-2"); !errors.Is(err, types.ErrOrderNotFound) {
+		t.Fatalf("unknown client id error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestPartialFillAndFee(t *testing.T) {
+	ex, err := NewExchange(Script{Prices: []float64{100}, FillRatio: 0.25, Fee: 0.01, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ex.PlaceOrder(context.Background(), buyOrder(4)); err != nil {
+		t.Fatal(err)
+	}
+
+	order := ex.Orders()[0]
+	if order.Status != types.OrderStatusPartiallyFilled || order.FilledAmount != 1 {
+		t.Fatalf("order = %+v", order)
+	}
+	if quote, base := ex.Balances(); quote != 899 || base != 1 {
+		t.Fatalf("balances = %v, %v", quote, base)
+	}
+}
+
+func TestScriptedFailures(t *testing.T) {
+	ctx := context.Background()
+	down := errors.New("exchange down")
+	ex, err := NewExchange(Script{
+		Prices:       []float64{100, 101},
+		Rejects:      map[int]error{1: nil, 3: down},
+		TickerErrors: map[int]error{1: down},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ex.PlaceOrder(ctx, buyOrder(1)); !errors.Is(err, ErrRejected) {
+		t.Fatalf("call 1 = %v, want ErrRejected", err)
+	}
+	if err := ex.PlaceOrder(ctx, buyOrder(1)); err != nil {
+		t.Fatalf("call 2 = %v", err)
+	}
+	if err := ex.PlaceOrder(ctx, buyOrder(1)); !errors.Is(err, down) {
+		t.Fatalf("call 3 = %v, want scripted error", err)
+	}
+	if n := len(ex.Orders()); n != 1 {
+		t.Fatalf("accepted orders = %d, want 1", n)
+	}
+
+	if _, err := ex.GetTicker(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("GetTicker at step 0: %v", err)
+	}
+	ex.Step()
+	if _, err := ex.GetTicker(ctx, "BTCUSDT"); !errors.Is(err, down) {
+		t.Fatalf("GetTicker at step 1 = %v, want scripted error", err)
+	}
+}
+
+func TestCarryRates(t *testing.T) {
+	ctx := context.Background()
+	ex, err := NewExchange(Script{Symbol: "BTCUSDT", Prices: []float64{100, 110}, FundingRate: 0.0001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ex.GetBorrowRates(ctx, []string{"BTC"}); !errors.Is(err, types.ErrNotSupported) {
+		t.Fatalf("GetBorrowRates without rates = %v, want ErrNotSupported", err)
+	}
+
+	ex.Step()
+	funding, err := ex.GetFundingRate(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if funding.Rate != 0.0001 || funding.Interval.Hours() != 8 || funding.MarkPrice != 110 {
+		t.Fatalf("unexpected funding rate %+v", funding)
+	}
+	if got := funding.NextFundingTime.Sub(funding.Timestamp).Hours(); got != 7 {
+		t.Fatalf("next funding in %vh, want 7h", got)
+	}
+
+	ex, _ = NewExchange(Script{Prices: []float64{100}, BorrowRates: map[string]float64{"BTC": 0.00001}})
+	rates, err := ex.GetBorrowRates(ctx, []string{"BTC"})
+	if err != nil || len(rates) != 1 || rates[0].HourlyRate != 0.00001 {
+		t.Fatalf("GetBorrowRates = %+v, %v", rates, err)
+	}
+	if _, err := ex.GetBorrowRates(ctx, []string{"ETH"}); err == nil {
+		t.Fatalf("expected error for unscripted asset")
+	}
+}
+
+func TestRestingLimits(t *testing.T) {
+	ctx := context.Background()
+	ex, err := NewExchange(Script{Symbol: "BTCUSDT", Prices: []float64{100, 99.5, 98}, Spread: 1, RestingLimits: true, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker, _ := ex.GetTicker(ctx, "BTCUSDT"); ticker.Bid != 99.5 || ticker.Ask != 100.5 {
+		t.Fatalf("ticker = %+v, want the spread around 100", ticker)
+	}
+
+	// A market buy pays the ask; a limit at the bid rests until traded through
+	if err := ex.PlaceOrder(ctx, buyOrder(1)); err != nil {
+		t.Fatal(err)
+	}
+	limit := buyOrder(1)
+	limit.Type, limit.Price = types.OrderTypeLimit, 99
+	if err := ex.PlaceOrder(ctx, limit); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := ex.GetActiveOrders(ctx, "BTCUSDT"); len(active) != 1 || active[0].ID != "sim-2" {
+		t.Fatalf("active orders = %+v, want the limit resting", active)
+	}
+
+	ex.Step()
+	if order, _ := ex.GetOrder(ctx, "sim-2"); order.Status != types.OrderStatusNew {
+		t.Fatalf("limit at 99.5 = %+v, want it resting", order)
+	}
+	ex.Step()
+	order, _ := ex.GetOrder(ctx, "sim-2")
+	if order.Status != types.OrderStatusFilled || order.FilledPrice != 99 {
+		t.Fatalf("limit at 98 = %+v, want it filled at 99", order)
+	}
+	if quote, base := ex.Balances(); quote != 1000-100.5-99 || base != 2 {
+		t.Fatalf("balances = %v, %v", quote, base)
+	}
+}
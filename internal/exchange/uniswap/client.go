@@ -0,0 +1,688 @@
+// Package uniswap trades token pairs on Uniswap v3 through an Ethereum
+// JSON-RPC endpoint, so strategies can buy tokens no centralized exchange lists.
+//
+// Transactions are sent from Config.Account and signed by the endpoint (a
+// local node, Clef or a signing proxy such as web3signer); the client never
+// holds a private key. With Config.PrivateRPCURL or Config.BundleRPCURL they
+// skip the public mempool.
+package uniswap
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/evm"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Ethereum mainnet deployments used when Config leaves them empty
+const (
+	DefaultQuoter = "0x61fFE014bA17989E743c5F6cB21bF9697530B21e" // QuoterV2
+	DefaultRouter = "0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45" // SwapRouter02
+)
+
+// exchangeName tags the orders the client places
+const exchangeName = "uniswap"
+
+// ErrReverted reports a swap that failed on chain or in simulation
+var ErrReverted = evm.ErrReverted
+
+// Token is an ERC-20 token
+type Token struct {
+	Symbol   string // asset name in balances, e.g. "WETH"
+	Address  string
+	Decimals int // read from the token when 0
+}
+
+// Pair is a token pair traded through one Uniswap v3 pool
+type Pair struct {
+	Symbol string // defaults to base and quote symbol, e.g. "PEPEWETH"
+	Base   Token
+	Quote  Token
+	Fee    uint32 // pool fee tier in millionths, e.g. 3000 for 0.3%
+}
+
+// Config holds the Uniswap adapter configuration
+type Config struct {
+	RPCURL  string
+	Account string // address trading; the endpoint signs its transactions
+	Pairs   []Pair
+	Quoter  string // QuoterV2 address, DefaultQuoter when empty
+	Router  string // SwapRouter02 address, DefaultRouter when empty
+
+	// Slippage bounds each swap's output below the order price, e.g. 0.005
+	// for 0.5%; default 0.5%
+	Slippage float64
+
+	// ReceiptTimeout is how long PlaceOrder waits for the swap to be mined
+	// before leaving the order working; default 3m
+	ReceiptTimeout time.Duration
+	PollInterval   time.Duration // receipt polling interval, default 2s
+
+	// PrivateRPCURL is a private transaction endpoint such as Flashbots
+	// Protect (https://rpc.flashbots.net). Transactions are simulated, signed
+	// by RPCURL and sent there instead of the public mempool, so swaps
+	// cannot be front-run or sandwiched.
+	PrivateRPCURL string
+
+	// BundleRPCURL is a block builder accepting eth_callBundle and
+	// eth_sendBundle without a Flashbots signature header, or a proxy that
+	// adds one. Each swap is sent there as a bundle together with the
+	// approval it needs, simulated first and mined atomically or not at
+	// all; it takes precedence over PrivateRPCURL.
+	BundleRPCURL string
+}
+
+// pair is a configured pair with parsed addresses
+type pair struct {
+	Pair
+	base, quote evm.Address
+}
+
+// Client implements types.ExchangeClient for Uniswap v3 swaps
+type Client struct {
+	config    Config
+	rpc       *evm.RPCClient
+	submitter *evm.Submitter
+	account   evm.Address
+	quoter    evm.Address
+	router    evm.Address
+	pairs     map[string]*pair
+	tokens    []Token // distinct tokens of all pairs, in config order
+	logger    *logger.Logger
+
+	mu       sync.Mutex
+	decimals map[evm.Address]int
+	orders   []*types.Order // swaps placed by the client, oldest first
+}
+
+// NewClient validates config and creates a client
+func NewClient(config Config) (*Client, error) {
+	if config.RPCURL == "" {
+		return nil, fmt.Errorf("rpc url is required")
+	}
+	if len(config.Pairs) == 0 {
+		return nil, fmt.Errorf("at least one pair is required")
+	}
+	if config.Quoter == "" {
+		config.Quoter = DefaultQuoter
+	}
+	if config.Router == "" {
+		config.Router = DefaultRouter
+	}
+	if config.Slippage == 0 {
+		config.Slippage = 0.005
+	}
+	if config.Slippage < 0 || config.Slippage >= 1 {
+		return nil, fmt.Errorf("slippage must be within [0, 1)")
+	}
+	if config.ReceiptTimeout <= 0 {
+		config.ReceiptTimeout = 3 * time.Minute
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2 * time.Second
+	}
+
+	c := &Client{
+		config:   config,
+		rpc:      evm.NewRPCClient(config.RPCURL),
+		pairs:    make(map[string]*pair),
+		decimals: make(map[evm.Address]int),
+		logger:   logger.New(logger.LevelInfo),
+	}
+	var err error
+	if c.account, err = evm.ParseAddress(config.Account); err != nil {
+		return nil, fmt.Errorf("account: %w", err)
+	}
+	c.submitter = evm.NewSubmitter(c.rpc, c.account, config.PrivateRPCURL, config.BundleRPCURL)
+	if c.quoter, err = evm.ParseAddress(config.Quoter); err != nil {
+		return nil, fmt.Errorf("quoter: %w", err)
+	}
+	if c.router, err = evm.ParseAddress(config.Router); err != nil {
+		return nil, fmt.Errorf("router: %w", err)
+	}
+
+	seen := make(map[evm.Address]bool)
+	for _, p := range config.Pairs {
+		if p.Symbol == "" {
+			p.Symbol = p.Base.Symbol + p.Quote.Symbol
+		}
+		if p.Base.Symbol == "" || p.Quote.Symbol == "" {
+			return nil, fmt.Errorf("pair %s: token symbols are required", p.Symbol)
+		}
+		if p.Fee == 0 {
+			return nil, fmt.Errorf("pair %s: fee tier is required", p.Symbol)
+		}
+		if c.pairs[p.Symbol] != nil {
+			return nil, fmt.Errorf("pair %s: duplicate symbol", p.Symbol)
+		}
+		entry := &pair{Pair: p}
+		if entry.base, err = evm.ParseAddress(p.Base.Address); err != nil {
+			return nil, fmt.Errorf("pair %s base: %w", p.Symbol, err)
+		}
+		if entry.quote, err = evm.ParseAddress(p.Quote.Address); err != nil {
+			return nil, fmt.Errorf("pair %s quote: %w", p.Symbol, err)
+		}
+		for _, token := range []struct {
+			Token
+			address evm.Address
+		}{{p.Base, entry.base}, {p.Quote, entry.quote}} {
+			if token.Decimals > 0 {
+				c.decimals[token.address] = token.Decimals
+			}
+			if !seen[token.address] {
+				seen[token.address] = true
+				c.tokens = append(c.tokens, token.Token)
+			}
+		}
+		c.pairs[p.Symbol] = entry
+	}
+	return c, nil
+}
+
+func (c *Client) pair(symbol string) (*pair, error) {
+	p, ok := c.pairs[symbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown pair %s", symbol)
+	}
+	return p, nil
+}
+
+// tokenDecimals returns a token's decimals, reading them once from the token
+func (c *Client) tokenDecimals(ctx context.Context, token evm.Address) (int, error) {
+	c.mu.Lock()
+	decimals, ok := c.decimals[token]
+	c.mu.Unlock()
+	if ok {
+		return decimals, nil
+	}
+
+	data, err := c.rpc.EthCall(ctx, token, evm.EncodeCall(selectorDecimals))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read decimals of %s: %w", token, err)
+	}
+	v, err := evm.WordAt(data, 0)
+	if err != nil || v.Int64() > 77 {
+		return 0, fmt.Errorf("invalid decimals of %s", token)
+	}
+	c.mu.Lock()
+	c.decimals[token] = int(v.Int64())
+	c.mu.Unlock()
+	return int(v.Int64()), nil
+}
+
+// quote returns the output of swapping amountIn through the pool
+func (c *Client) quote(ctx context.Context, p *pair, in, out evm.Address, amountIn *big.Int) (*big.Int, error) {
+	data, err := c.rpc.EthCall(ctx, c.quoter, evm.EncodeCall(selectorQuoteExactInput,
+		in.Word(), out.Word(), evm.UintWord(amountIn), evm.UintWord(big.NewInt(int64(p.Fee))), evm.UintWord(new(big.Int))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote %s: %w", p.Symbol, err)
+	}
+	return evm.WordAt(data, 0)
+}
+
+// quoteOutput returns the input needed to receive amountOut from the pool
+func (c *Client) quoteOutput(ctx context.Context, p *pair, in, out evm.Address, amountOut *big.Int) (*big.Int, error) {
+	data, err := c.rpc.EthCall(ctx, c.quoter, evm.EncodeCall(selectorQuoteExactOutput,
+		in.Word(), out.Word(), evm.UintWord(amountOut), evm.UintWord(big.NewInt(int64(p.Fee))), evm.UintWord(new(big.Int))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote %s: %w", p.Symbol, err)
+	}
+	return evm.WordAt(data, 0)
+}
+
+// GetTicker quotes one base token: Bid is what selling it returns and Ask
+// what buying it costs, both including the pool fee and price impact
+func (c *Client) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	p, err := c.pair(symbol)
+	if err != nil {
+		return nil, err
+	}
+	baseDecimals, err := c.tokenDecimals(ctx, p.base)
+	if err != nil {
+		return nil, err
+	}
+	quoteDecimals, err := c.tokenDecimals(ctx, p.quote)
+	if err != nil {
+		return nil, err
+	}
+
+	one := evm.ToUnits(1, baseDecimals)
+	bid, err := c.quote(ctx, p, p.base, p.quote, one)
+	if err != nil {
+		return nil, err
+	}
+	ask, err := c.quoteOutput(ctx, p, p.quote, p.base, one)
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := &types.Ticker{
+		Symbol:    symbol,
+		Bid:       evm.FromUnits(bid, quoteDecimals),
+		Ask:       evm.FromUnits(ask, quoteDecimals),
+		Timestamp: time.Now(),
+	}
+	ticker.Price = (ticker.Bid + ticker.Ask) / 2
+	return ticker, nil
+}
+
+// GetTickers quotes each symbol in turn; pools have no bulk quote
+func (c *Client) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	return types.FetchTickers(ctx, symbols, c.GetTicker)
+}
+
+// PlaceOrder swaps at market through the pair's pool. Buys spend
+// Quantity*Price of the quote token, sells spend Quantity of the base
+// token; either fails before sending when the quoted output is more than
+// Config.Slippage below what Price promises, and the swap reverts on chain
+// if it would fill below that bound.
+func (c *Client) PlaceOrder(ctx context.Context, order types.Order) error {
+	if order.Type != types.OrderTypeMarket {
+		return fmt.Errorf("uniswap %s orders: %w", order.Type, types.ErrNotSupported)
+	}
+	p, err := c.pair(order.Symbol)
+	if err != nil {
+		return err
+	}
+	baseDecimals, err := c.tokenDecimals(ctx, p.base)
+	if err != nil {
+		return err
+	}
+	quoteDecimals, err := c.tokenDecimals(ctx, p.quote)
+	if err != nil {
+		return err
+	}
+
+	in, out := p.quote, p.base
+	amountIn, expected := evm.ToUnits(order.Quantity*order.Price, quoteDecimals), evm.ToUnits(order.Quantity, baseDecimals)
+	if order.Side == types.OrderSideSell {
+		in, out = p.base, p.quote
+		amountIn, expected = evm.ToUnits(order.Quantity, baseDecimals), evm.ToUnits(order.Quantity*order.Price, quoteDecimals)
+	}
+	if amountIn.Sign() <= 0 {
+		return fmt.Errorf("%s %.8f @ %.8f: %w", order.Symbol, order.Quantity, order.Price, types.ErrBelowMinimum)
+	}
+
+	quoted, err := c.quote(ctx, p, in, out, amountIn)
+	if err != nil {
+		return err
+	}
+	if order.Price <= 0 {
+		expected = quoted
+	}
+	minOut := new(big.Int).Mul(expected, big.NewInt(int64(math.Round((1-c.config.Slippage)*1e6))))
+	minOut.Quo(minOut, big.NewInt(1e6))
+	if quoted.Cmp(minOut) < 0 {
+		return fmt.Errorf("%s %s: quoted output %s is below the slippage bound %s", order.Symbol, order.Side, quoted, minOut)
+	}
+
+	calls := []evm.Call{{To: c.router, Data: evm.EncodeCall(selectorExactInputSingle,
+		in.Word(), out.Word(), evm.UintWord(big.NewInt(int64(p.Fee))), c.account.Word(),
+		evm.UintWord(amountIn), evm.UintWord(minOut), evm.UintWord(new(big.Int)))}}
+	approval, err := c.approval(ctx, in, amountIn)
+	if err != nil {
+		return err
+	}
+	if approval != nil {
+		calls = append([]evm.Call{*approval}, calls...)
+	}
+	hash, err := c.send(ctx, calls)
+	if err != nil {
+		return fmt.Errorf("failed to send %s swap: %w", order.Symbol, err)
+	}
+
+	placed := order
+	placed.ID = hash
+	placed.Status = types.OrderStatusNew
+	placed.Timestamp = time.Now()
+	placed.ExchangeOrder = &types.ExchangeOrder{ExchangeOrderID: hash, Exchange: exchangeName}
+	if order.ExchangeOrder != nil {
+		placed.ExchangeOrder.ClientOrderID = order.ExchangeOrder.ClientOrderID
+	}
+	c.mu.Lock()
+	c.orders = append(c.orders, &placed)
+	c.mu.Unlock()
+
+	rcpt, err := c.waitReceipt(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if rcpt == nil && c.submitter.Bundles() {
+		// The bundle was only offered to the blocks right after it was sent
+		c.mu.Lock()
+		placed.Status = types.OrderStatusRejected
+		c.mu.Unlock()
+		return fmt.Errorf("%s swap %s: bundle not included after %s", order.Symbol, hash, c.config.ReceiptTimeout)
+	}
+	if rcpt == nil {
+		c.logger.Warn("Uniswap swap %s not mined after %s, order left working", hash, c.config.ReceiptTimeout)
+		return nil
+	}
+	if !c.settle(&placed, rcpt) {
+		return fmt.Errorf("%s swap %s: %w", order.Symbol, hash, ErrReverted)
+	}
+	return nil
+}
+
+// approval returns the call approving the router to spend token, or nil
+// when its allowance already covers amount. The approval is unlimited so
+// later swaps skip it.
+func (c *Client) approval(ctx context.Context, token evm.Address, amount *big.Int) (*evm.Call, error) {
+	data, err := c.rpc.EthCall(ctx, token, evm.EncodeCall(selectorAllowance, c.account.Word(), c.router.Word()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allowance: %w", err)
+	}
+	allowance, err := evm.WordAt(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if allowance.Cmp(amount) >= 0 {
+		return nil, nil
+	}
+	unlimited := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	return &evm.Call{To: token, Data: evm.EncodeCall(selectorApprove, c.router.Word(), evm.UintWord(unlimited))}, nil
+}
+
+// send submits calls in order and returns the last one's hash. With a
+// bundle endpoint they go as one bundle; otherwise each call but the last
+// is sent alone and waited for, since the next one depends on it.
+func (c *Client) send(ctx context.Context, calls []evm.Call) (string, error) {
+	if c.submitter.Bundles() {
+		hashes, err := c.submitter.SubmitBundle(ctx, calls...)
+		if err != nil {
+			return "", err
+		}
+		return hashes[len(hashes)-1], nil
+	}
+	for _, call := range calls[:len(calls)-1] {
+		hash, err := c.submitter.Submit(ctx, call)
+		if err != nil {
+			return "", err
+		}
+		rcpt, err := c.waitReceipt(ctx, hash)
+		if err != nil {
+			return "", err
+		}
+		if rcpt == nil || rcpt.Status != "0x1" {
+			return "", fmt.Errorf("transaction %s to %s did not succeed", hash, call.To)
+		}
+	}
+	return c.submitter.Submit(ctx, calls[len(calls)-1])
+}
+
+// receipt returns a transaction's receipt, or nil while it is pending
+func (c *Client) receipt(ctx context.Context, hash string) (*receipt, error) {
+	var rcpt *receipt
+	if err := c.rpc.Call(ctx, &rcpt, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, fmt.Errorf("failed to get receipt of %s: %w", hash, err)
+	}
+	return rcpt, nil
+}
+
+// waitReceipt polls for a receipt up to Config.ReceiptTimeout; nil means the
+// transaction is still pending
+func (c *Client) waitReceipt(ctx context.Context, hash string) (*receipt, error) {
+	deadline := time.NewTimer(c.config.ReceiptTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(c.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		rcpt, err := c.receipt(ctx, hash)
+		if err != nil || rcpt != nil {
+			return rcpt, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return nil, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// settle books a mined swap from the token transfers in its receipt and
+// reports whether it succeeded
+func (c *Client) settle(order *types.Order, rcpt *receipt) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rcpt.Status != "0x1" {
+		order.Status = types.OrderStatusRejected
+		return false
+	}
+	p := c.pairs[order.Symbol]
+	baseDecimals, quoteDecimals := c.decimals[p.base], c.decimals[p.quote]
+	baseSent, baseReceived := rcpt.transfers(p.base, c.account)
+	quoteSent, quoteReceived := rcpt.transfers(p.quote, c.account)
+
+	base, quote := evm.FromUnits(baseReceived, baseDecimals), evm.FromUnits(quoteSent, quoteDecimals)
+	if order.Side == types.OrderSideSell {
+		base, quote = evm.FromUnits(baseSent, baseDecimals), evm.FromUnits(quoteReceived, quoteDecimals)
+	}
+	order.Status = types.OrderStatusFilled
+	order.FilledAmount = base
+	if base > 0 {
+		order.FilledPrice = quote / base
+	}
+	return true
+}
+
+// refresh settles swaps that were still pending
+func (c *Client) refresh(ctx context.Context) {
+	c.mu.Lock()
+	var pending []*types.Order
+	for _, order := range c.orders {
+		if order.Status == types.OrderStatusNew {
+			pending = append(pending, order)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, order := range pending {
+		rcpt, err := c.receipt(ctx, order.ID)
+		if err != nil {
+			c.logger.Warn("Uniswap swap %s: %v", order.ID, err)
+			continue
+		}
+		if rcpt != nil {
+			c.settle(order, rcpt)
+		}
+	}
+}
+
+// ordersWith returns copies of the client's swaps for symbol ("" for all) in status
+func (c *Client) ordersWith(symbol string, status types.OrderStatus) []types.Order {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var orders []types.Order
+	for _, order := range c.orders {
+		if (symbol == "" || order.Symbol == symbol) && order.Status == status {
+			orders = append(orders, *order)
+		}
+	}
+	return orders
+}
+
+// CancelOrder is not supported: a sent transaction cannot be withdrawn
+func (c *Client) CancelOrder(ctx context.Context, orderID string) error {
+	return types.ErrNotSupported
+}
+
+// GetOrder returns a swap placed by the client by transaction hash
+func (c *Client) GetOrder(ctx context.Context, orderID string) (*types.Order, error) {
+	c.refresh(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, order := range c.orders {
+		if order.ID == orderID {
+			found := *order
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("order %s not found", orderID)
+}
+
+// GetOrderByClientID returns a swap placed by the client by client order id
+func (c *Client) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	c.refresh(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, order := range c.orders {
+		if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID == clientOrderID {
+			found := *order
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: client id %s", types.ErrOrderNotFound, clientOrderID)
+}
+
+// GetActiveOrders returns swaps not mined yet
+func (c *Client) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	c.refresh(ctx)
+	return c.ordersWith(symbol, types.OrderStatusNew), nil
+}
+
+// GetFilledOrders returns the swaps the client placed that succeeded
+func (c *Client) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	c.refresh(ctx)
+	return c.ordersWith(symbol, types.OrderStatusFilled), nil
+}
+
+// GetOrderHistory pages the swaps the client placed that succeeded
+func (c *Client) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	c.refresh(ctx)
+	return types.PageOrders(c.ordersWith("", types.OrderStatusFilled), query)
+}
+
+// GetOrderBook is not supported: pools have no order book
+func (c *Client) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	return nil, types.ErrNotSupported
+}
+
+// GetCandles is not supported without an indexer
+func (c *Client) GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]types.Candle, error) {
+	return nil, types.ErrNotSupported
+}
+
+// balance reads the account's balance of token
+func (c *Client) balance(ctx context.Context, token Token) (*types.Balance, error) {
+	addr, err := evm.ParseAddress(token.Address)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := c.tokenDecimals(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.rpc.EthCall(ctx, addr, evm.EncodeCall(selectorBalanceOf, c.account.Word()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s balance: %w", token.Symbol, err)
+	}
+	units, err := evm.WordAt(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	amount := evm.FromUnits(units, decimals)
+	return &types.Balance{Asset: token.Symbol, Free: amount, Total: amount, Timestamp: time.Now()}, nil
+}
+
+// GetBalance returns the balance of the first pair's quote token
+func (c *Client) GetBalance(ctx context.Context) (*types.Balance, error) {
+	return c.balance(ctx, c.config.Pairs[0].Quote)
+}
+
+// GetBalances returns the non-zero balances of the pairs' tokens and of ETH,
+// which pays for gas
+func (c *Client) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	var balances []types.Balance
+	for _, token := range c.tokens {
+		balance, err := c.balance(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Total > 0 {
+			balances = append(balances, *balance)
+		}
+	}
+
+	var wei string
+	if err := c.rpc.Call(ctx, &wei, "eth_getBalance", c.account.String(), "latest"); err != nil {
+		return nil, err
+	}
+	units, err := evm.DecodeQuantity(wei)
+	if err != nil {
+		return nil, err
+	}
+	if eth := evm.FromUnits(units, 18); eth > 0 {
+		balances = append(balances, types.Balance{Asset: "ETH", Free: eth, Total: eth, Timestamp: time.Now()})
+	}
+	return balances, nil
+}
+
+// GetTradingFees returns the pool fee tier; every swap takes liquidity
+func (c *Client) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
+	p, err := c.pair(symbol)
+	if err != nil {
+		return nil, err
+	}
+	fee := float64(p.Fee) / 1e6
+	return &types.TradingFees{Symbol: symbol, MakerFee: fee, TakerFee: fee, Timestamp: time.Now()}, nil
+}
+
+func (c *Client) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (c *Client) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (c *Client) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (c *Client) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (c *Client) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (c *Client) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+func (c *Client) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+// GetSystemStatus reports the endpoint degraded while its node is syncing
+func (c *Client) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	var syncing interface{}
+	if err := c.rpc.Call(ctx, &syncing, "eth_syncing"); err != nil {
+		return nil, err
+	}
+	status := &types.SystemStatus{State: types.SystemNormal, Timestamp: time.Now()}
+	if syncing != false {
+		status.State, status.Message = types.SystemDegraded, "node is syncing"
+	}
+	return status, nil
+}
+
+// Ping checks the endpoint answers
+func (c *Client) Ping(ctx context.Context) error {
+	var block string
+	return c.rpc.Call(ctx, &block, "eth_blockNumber")
+}
+
+func (c *Client) Close() error {
+	return nil
+}
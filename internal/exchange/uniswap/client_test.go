@@ -0,0 +1,452 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/evm"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+const (
+	testAccount = "0x00000000000000000000000000000000000000aa"
+	testWETH    = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+	testUSDC    = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+)
+
+// fakeNode answers the JSON-RPC calls of a WETH/USDC pool priced at 2000
+// USDC per WETH (2010 to buy one), with swaps mined at once. Requests to
+// /private act as a private relay accepting only raw transactions, and
+// requests to /bundle as a block builder accepting only bundles.
+type fakeNode struct {
+	mu       sync.Mutex
+	approved bool
+	revert   bool              // swaps revert, e.g. after the pool moved
+	sent     []string          // "to:selector" of sent transactions
+	private  int               // transactions received by the relay
+	signed   map[string]string // last transaction signed
+	receipts map[string]interface{}
+
+	simulated int      // bundles simulated by the builder
+	offered   []string // target blocks of the bundles sent to the builder
+	bundled   string   // raw transactions of the bundle mined
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int64             `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n.mu.Lock()
+	var result interface{}
+	var err error
+	bundle := req.Method == "eth_callBundle" || req.Method == "eth_sendBundle"
+	if r.URL.Path == "/private" && req.Method != "eth_sendRawTransaction" {
+		err = fmt.Errorf("relay does not serve %s", req.Method)
+	} else if bundle != (r.URL.Path == "/bundle") {
+		err = fmt.Errorf("%s does not serve %s", r.URL.Path, req.Method)
+	} else {
+		result, err = n.handle(req.Method, req.Params)
+	}
+	n.mu.Unlock()
+
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result}
+	if err != nil {
+		response = map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32000, "message": err.Error()}}
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (n *fakeNode) handle(method string, params []json.RawMessage) (interface{}, error) {
+	var tx struct{ From, To, Data string }
+	if len(params) > 0 {
+		_ = json.Unmarshal(params[0], &tx)
+	}
+	data, _ := evm.DecodeHex(tx.Data)
+	word := func(i int) *big.Int { return new(big.Int).SetBytes(data[4+32*i : 4+32*(i+1)]) }
+	words := func(values ...*big.Int) string {
+		out := ""
+		for _, v := range values {
+			out += hex.EncodeToString(evm.UintWord(v))
+		}
+		return "0x" + out
+	}
+
+	switch method {
+	case "eth_blockNumber":
+		return "0x10", nil
+	case "eth_syncing":
+		return false, nil
+	case "eth_getBalance":
+		return "0xde0b6b3a7640000", nil // 1 ETH
+	case "eth_getTransactionReceipt":
+		var hash string
+		_ = json.Unmarshal(params[0], &hash)
+		return n.receipts[hash], nil
+	case "eth_call":
+		selector := hex.EncodeToString(data[:4])
+		switch selector {
+		case selectorDecimals:
+			if strings.EqualFold(tx.To, testUSDC) {
+				return words(big.NewInt(6)), nil
+			}
+			return words(big.NewInt(18)), nil
+		case selectorAllowance:
+			if n.approved {
+				return words(new(big.Int).Lsh(big.NewInt(1), 255)), nil
+			}
+			return words(new(big.Int)), nil
+		case selectorBalanceOf:
+			if strings.EqualFold(tx.To, testUSDC) {
+				return words(big.NewInt(5000e6)), nil
+			}
+			return words(new(big.Int)), nil
+		case selectorQuoteExactInput:
+			return words(swapOutput(word(0), word(2))), nil
+		case selectorQuoteExactOutput:
+			// 2010 USDC per WETH bought
+			in := new(big.Int).Mul(word(2), big.NewInt(2010e6))
+			return words(in.Quo(in, big.NewInt(1e18))), nil
+		case selectorApprove:
+			return words(big.NewInt(1)), nil
+		case selectorExactInputSingle:
+			amountOut, ok := n.swap(word(0), word(4), word(5))
+			if !ok {
+				return nil, fmt.Errorf("execution reverted: Too little received")
+			}
+			return words(amountOut), nil
+		}
+		return nil, fmt.Errorf("unexpected call %s", selector)
+	case "eth_estimateGas":
+		return "0x30d40", nil // 200000
+	case "eth_getTransactionCount":
+		return "0x7", nil
+	case "eth_maxPriorityFeePerGas":
+		return "0x77359400", nil // 2 gwei
+	case "eth_getBlockByNumber":
+		return map[string]string{"baseFeePerGas": "0x3b9aca00"}, nil // 1 gwei
+	case "eth_signTransaction":
+		n.signed = make(map[string]string)
+		_ = json.Unmarshal(params[0], &n.signed)
+		// The fake raw transaction is the transaction's JSON
+		raw, _ := json.Marshal(n.signed)
+		return map[string]interface{}{"raw": "0x" + hex.EncodeToString(raw), "tx": n.signed}, nil
+	case "eth_sendRawTransaction":
+		var rawHex string
+		_ = json.Unmarshal(params[0], &rawHex)
+		raw, _ := evm.DecodeHex(rawHex)
+		if err := json.Unmarshal(raw, &tx); err != nil {
+			return nil, err
+		}
+		n.private++
+		data, _ = evm.DecodeHex(tx.Data)
+		return n.send(tx.To, data), nil
+	case "eth_sendTransaction":
+		return n.send(tx.To, data), nil
+	case "eth_callBundle", "eth_sendBundle":
+		var bundle struct {
+			Txs         []string `json:"txs"`
+			BlockNumber string   `json:"blockNumber"`
+		}
+		_ = json.Unmarshal(params[0], &bundle)
+		var txs []struct{ From, To, Data string }
+		for _, rawHex := range bundle.Txs {
+			raw, _ := evm.DecodeHex(rawHex)
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				return nil, err
+			}
+			txs = append(txs, tx)
+		}
+		if method == "eth_sendBundle" {
+			n.offered = append(n.offered, bundle.BlockNumber)
+			// Offers of the same bundle to later blocks are not mined again
+			if joined := strings.Join(bundle.Txs, ","); joined != n.bundled {
+				n.bundled = joined
+				for _, tx := range txs {
+					data, _ := evm.DecodeHex(tx.Data)
+					n.send(tx.To, data)
+				}
+			}
+			return map[string]string{"bundleHash": "0xb0"}, nil
+		}
+
+		n.simulated++
+		approved := n.approved
+		var results []map[string]interface{}
+		for i, tx := range txs {
+			data, _ := evm.DecodeHex(tx.Data)
+			word := func(i int) *big.Int { return new(big.Int).SetBytes(data[4+32*i : 4+32*(i+1)]) }
+			result := map[string]interface{}{"txHash": fmt.Sprintf("0x%064x", len(n.sent)+i+1), "gasUsed": 100000}
+			switch hex.EncodeToString(data[:4]) {
+			case selectorApprove:
+				approved = true
+			case selectorExactInputSingle:
+				if _, ok := n.swap(word(0), word(4), word(5)); !ok || !approved {
+					result["error"] = "execution reverted"
+				}
+			}
+			results = append(results, result)
+		}
+		return map[string]interface{}{"results": results}, nil
+	}
+	return nil, fmt.Errorf("unexpected method %s", method)
+}
+
+// send mines a transaction and returns its hash
+func (n *fakeNode) send(to string, data []byte) string {
+	word := func(i int) *big.Int { return new(big.Int).SetBytes(data[4+32*i : 4+32*(i+1)]) }
+	selector := hex.EncodeToString(data[:4])
+	n.sent = append(n.sent, strings.ToLower(to)+":"+selector)
+	hash := fmt.Sprintf("0x%064x", len(n.sent))
+	rcpt := map[string]interface{}{"status": "0x1", "gasUsed": "0x5208", "logs": []interface{}{}}
+	switch selector {
+	case selectorApprove:
+		n.approved = true
+	case selectorExactInputSingle:
+		in, out, amountIn := word(0), word(1), word(4)
+		amountOut, ok := n.swap(in, amountIn, word(5))
+		if !ok {
+			rcpt["status"] = "0x0"
+			break
+		}
+		pool := "0x00000000000000000000000000000000000000bb"
+		rcpt["logs"] = []interface{}{
+			transferLog(addressHex(in), testAccount, pool, amountIn),
+			transferLog(addressHex(out), pool, testAccount, amountOut),
+		}
+	}
+	n.receipts[hash] = rcpt
+	return hash
+}
+
+// swap returns a swap's output and whether it clears minOut
+func (n *fakeNode) swap(in, amountIn, minOut *big.Int) (*big.Int, bool) {
+	amountOut := swapOutput(in, amountIn)
+	return amountOut, !n.revert && amountOut.Cmp(minOut) >= 0
+}
+
+// swapOutput prices the pool at 2000 USDC per WETH
+func swapOutput(in, amountIn *big.Int) *big.Int {
+	out := new(big.Int)
+	if strings.EqualFold(addressHex(in), testWETH) {
+		return out.Quo(out.Mul(amountIn, big.NewInt(2000e6)), big.NewInt(1e18))
+	}
+	return out.Quo(out.Mul(amountIn, big.NewInt(1e18)), big.NewInt(2000e6))
+}
+
+func addressHex(word *big.Int) string {
+	var a evm.Address
+	copy(a[:], evm.UintWord(word)[12:])
+	return a.String()
+}
+
+func transferLog(token, from, to string, amount *big.Int) map[string]interface{} {
+	topic := func(addr string) string { return "0x" + strings.Repeat("0", 24) + strings.TrimPrefix(addr, "0x") }
+	return map[string]interface{}{
+		"address": token,
+		"topics":  []string{topicTransfer, topic(from), topic(to)},
+		"data":    "0x" + hex.EncodeToString(evm.UintWord(amount)),
+	}
+}
+
+// newTestClient creates a client of a fake node, sending through path
+// ("/private" or "/bundle") or, when empty, the public mempool
+func newTestClient(t *testing.T, path string) (*Client, *fakeNode) {
+	t.Helper()
+	node := &fakeNode{receipts: make(map[string]interface{})}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	var privateURL, bundleURL string
+	switch path {
+	case "/private":
+		privateURL = server.URL + path
+	case "/bundle":
+		bundleURL = server.URL + path
+	}
+	client, err := NewClient(Config{
+		RPCURL:        server.URL,
+		PrivateRPCURL: privateURL,
+		BundleRPCURL:  bundleURL,
+		Account:       testAccount,
+		Pairs: []Pair{{
+			Base:  Token{Symbol: "WETH", Address: testWETH},
+			Quote: Token{Symbol: "USDC", Address: testUSDC},
+			Fee:   500,
+		}},
+		Slippage: 0.01,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, node
+}
+
+func TestClient_Ticker(t *testing.T) {
+	client, _ := newTestClient(t, "")
+	ticker, err := client.GetTicker(context.Background(), "WETHUSDC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Bid != 2000 || ticker.Ask != 2010 || ticker.Price != 2005 {
+		t.Fatalf("ticker = %+v, want bid 2000, ask 2010, price 2005", ticker)
+	}
+	fees, err := client.GetTradingFees(context.Background(), "WETHUSDC")
+	if err != nil || fees.TakerFee != 0.0005 {
+		t.Fatalf("fees = %+v, %v, want 0.05%%", fees, err)
+	}
+	if _, err := client.GetTicker(context.Background(), "BTCUSDT"); err == nil {
+		t.Fatal("expected error for an unknown pair")
+	}
+}
+
+func TestClient_Swaps(t *testing.T) {
+	ctx := context.Background()
+	client, node := newTestClient(t, "")
+
+	// A buy approves the router once, then swaps 100 USDC for WETH
+	buy := types.Order{Symbol: "WETHUSDC", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.05, Price: 2000,
+		ExchangeOrder: &types.ExchangeOrder{ClientOrderID: "s1-1"}}
+	if err := client.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	swap := strings.ToLower(DefaultRouter) + ":" + selectorExactInputSingle
+	want := []string{testUSDC + ":" + selectorApprove, swap, swap}
+	if strings.Join(node.sent, " ") != strings.Join(want, " ") {
+		t.Fatalf("sent %v, want %v", node.sent, want)
+	}
+
+	filled, err := client.GetFilledOrders(ctx, "WETHUSDC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filled) != 2 {
+		t.Fatalf("filled %d orders, want 2", len(filled))
+	}
+	order := filled[0]
+	if math.Abs(order.FilledAmount-0.05) > 1e-12 || math.Abs(order.FilledPrice-2000) > 1e-9 || order.ExchangeOrder.ClientOrderID != "s1-1" {
+		t.Fatalf("filled order = %+v", order)
+	}
+	if got, err := client.GetOrder(ctx, order.ID); err != nil || got.Status != types.OrderStatusFilled {
+		t.Fatalf("GetOrder = %+v, %v", got, err)
+	}
+
+	// A sell priced above the pool is refused before anything is sent
+	sent := len(node.sent)
+	sell := types.Order{Symbol: "WETHUSDC", Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: 0.05, Price: 2100}
+	if err := client.PlaceOrder(ctx, sell); err == nil || !strings.Contains(err.Error(), "slippage") {
+		t.Fatalf("sell above the pool error = %v, want a slippage error", err)
+	}
+	if len(node.sent) != sent {
+		t.Fatal("a swap outside the slippage bound was sent")
+	}
+
+	limit := sell
+	limit.Type = types.OrderTypeLimit
+	if err := client.PlaceOrder(ctx, limit); !errors.Is(err, types.ErrNotSupported) {
+		t.Fatalf("limit order error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestClient_Balances(t *testing.T) {
+	client, _ := newTestClient(t, "")
+	balances, err := client.GetBalances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, b := range balances {
+		got[b.Asset] = b.Free
+	}
+	// WETH is zero and left out
+	if len(got) != 2 || got["USDC"] != 5000 || got["ETH"] != 1 {
+		t.Fatalf("balances = %v, want 5000 USDC and 1 ETH", got)
+	}
+}
+
+func TestClient_PrivateSubmission(t *testing.T) {
+	ctx := context.Background()
+	client, node := newTestClient(t, "/private")
+
+	buy := types.Order{Symbol: "WETHUSDC", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.05, Price: 2000}
+	if err := client.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	// The approval and the swap both went through the relay
+	if len(node.sent) != 2 || node.private != 2 {
+		t.Fatalf("sent %v with %d through the relay, want approval and swap through the relay", node.sent, node.private)
+	}
+	want := map[string]string{"gas": "0x3a980", "nonce": "0x7", "maxPriorityFeePerGas": "0x77359400", "maxFeePerGas": "0xee6b2800"}
+	for field, value := range want {
+		if node.signed[field] != value {
+			t.Fatalf("signed %s = %q, want %q", field, node.signed[field], value)
+		}
+	}
+	if filled, _ := client.GetFilledOrders(ctx, "WETHUSDC"); len(filled) != 1 {
+		t.Fatalf("filled %d orders, want 1", len(filled))
+	}
+
+	// A swap that fails simulation is never sent
+	node.revert = true
+	if err := client.PlaceOrder(ctx, buy); !errors.Is(err, ErrReverted) {
+		t.Fatalf("reverting swap error = %v, want ErrReverted", err)
+	}
+	if len(node.sent) != 2 {
+		t.Fatalf("a reverting swap was sent: %v", node.sent)
+	}
+}
+
+func TestClient_BundleSubmission(t *testing.T) {
+	ctx := context.Background()
+	client, node := newTestClient(t, "/bundle")
+
+	buy := types.Order{Symbol: "WETHUSDC", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.05, Price: 2000}
+	if err := client.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	// The approval and the swap went as one bundle, simulated before and
+	// after sizing their gas, and offered to the next three blocks
+	if len(node.sent) != 2 || node.private != 0 || node.simulated != 2 {
+		t.Fatalf("sent %v after %d simulations, want approval and swap in one bundle", node.sent, node.simulated)
+	}
+	if want := []string{"0x11", "0x12", "0x13"}; fmt.Sprint(node.offered) != fmt.Sprint(want) {
+		t.Fatalf("bundle offered to blocks %v, want %v", node.offered, want)
+	}
+	want := map[string]string{"gas": "0x1d4c0", "nonce": "0x8", "maxPriorityFeePerGas": "0x77359400", "maxFeePerGas": "0xee6b2800"}
+	for field, value := range want {
+		if node.signed[field] != value {
+			t.Fatalf("signed swap %s = %q, want %q", field, node.signed[field], value)
+		}
+	}
+	filled, _ := client.GetFilledOrders(ctx, "WETHUSDC")
+	if len(filled) != 1 || math.Abs(filled[0].FilledAmount-0.05) > 1e-9 {
+		t.Fatalf("filled %+v, want the swap booked from its receipt", filled)
+	}
+
+	// A bundle that fails simulation is never sent
+	node.revert = true
+	if err := client.PlaceOrder(ctx, buy); !errors.Is(err, ErrReverted) {
+		t.Fatalf("reverting bundle error = %v, want ErrReverted", err)
+	}
+	if len(node.sent) != 2 || len(node.offered) != 3 {
+		t.Fatalf("a reverting bundle was sent: %v", node.sent)
+	}
+}
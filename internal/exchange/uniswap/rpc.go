@@ -0,0 +1,71 @@
+package uniswap
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/evm"
+)
+
+// Function selectors (first four bytes of the keccak-256 of the signature)
+const (
+	selectorBalanceOf        = "70a08231" // balanceOf(address)
+	selectorAllowance        = "dd62ed3e" // allowance(address,address)
+	selectorApprove          = "095ea7b3" // approve(address,uint256)
+	selectorDecimals         = "313ce567" // decimals()
+	selectorQuoteExactInput  = "c6a5026a" // QuoterV2.quoteExactInputSingle((address,address,uint256,uint24,uint160))
+	selectorQuoteExactOutput = "bd21704a" // QuoterV2.quoteExactOutputSingle((address,address,uint256,uint24,uint160))
+	selectorExactInputSingle = "04e45aaf" // SwapRouter02.exactInputSingle((address,address,uint24,address,uint256,uint256,uint160))
+
+	// topicTransfer is the ERC-20 Transfer(address,address,uint256) event
+	topicTransfer = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+)
+
+// receipt is a mined transaction's receipt
+type receipt struct {
+	Status            string `json:"status"` // 0x1 on success
+	GasUsed           string `json:"gasUsed"`
+	EffectiveGasPrice string `json:"effectiveGasPrice"`
+	Logs              []struct {
+		Address string   `json:"address"`
+		Topics  []string `json:"topics"`
+		Data    string   `json:"data"`
+	} `json:"logs"`
+}
+
+// transfers sums the amounts of token moved from and to account in the
+// receipt's ERC-20 Transfer events
+func (r *receipt) transfers(token, account evm.Address) (sent, received *big.Int) {
+	sent, received = new(big.Int), new(big.Int)
+	for _, log := range r.Logs {
+		if len(log.Topics) != 3 || !strings.EqualFold(log.Topics[0], topicTransfer) {
+			continue
+		}
+		if emitter, err := evm.ParseAddress(log.Address); err != nil || emitter != token {
+			continue
+		}
+		amount, err := evm.DecodeHex(log.Data)
+		if err != nil {
+			continue
+		}
+		value := new(big.Int).SetBytes(amount)
+		if topicAddress(log.Topics[1]) == account {
+			sent.Add(sent, value)
+		}
+		if topicAddress(log.Topics[2]) == account {
+			received.Add(received, value)
+		}
+	}
+	return sent, received
+}
+
+// topicAddress reads an address from an indexed event topic
+func topicAddress(topic string) evm.Address {
+	var a evm.Address
+	raw, err := evm.DecodeHex(topic)
+	if err != nil || len(raw) != 32 {
+		return a
+	}
+	copy(a[:], raw[12:])
+	return a
+}
@@ -0,0 +1,88 @@
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"math"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+)
+
+// DatasetHasher fingerprints the candles inside a backtest window, so runs
+// over the same bars hash equally whether they came from CSV, Parquet or a
+// resampled stream
+type DatasetHasher struct {
+	start, end time.Time
+	sum        hash.Hash
+	buf        [48]byte
+}
+
+// NewDatasetHasher hashes candles in [start, end]
+func NewDatasetHasher(start, end time.Time) *DatasetHasher {
+	return &DatasetHasher{start: start, end: end, sum: sha256.New()}
+}
+
+// Add hashes c if it falls in the window
+func (h *DatasetHasher) Add(c backtest.Candle) {
+	if c.Time.Before(h.start) || c.Time.After(h.end) {
+		return
+	}
+	binary.LittleEndian.PutUint64(h.buf[0:], uint64(c.Time.UnixMilli()))
+	for i, v := range []float64{c.Open, c.High, c.Low, c.Close, c.Volume} {
+		binary.LittleEndian.PutUint64(h.buf[8+8*i:], math.Float64bits(v))
+	}
+	h.sum.Write(h.buf[:])
+}
+
+// Sum returns the hex digest of the candles added so far
+func (h *DatasetHasher) Sum() string {
+	return hex.EncodeToString(h.sum.Sum(nil))
+}
+
+// HashCandles returns the dataset hash of candles in [start, end]
+func HashCandles(candles []backtest.Candle, start, end time.Time) string {
+	h := NewDatasetHasher(start, end)
+	for _, c := range candles {
+		h.Add(c)
+	}
+	return h.Sum()
+}
+
+// HashingIterator feeds every candle it yields to a DatasetHasher
+type HashingIterator struct {
+	backtest.CandleIterator
+	hasher *DatasetHasher
+}
+
+// NewHashingIterator wraps it
+func NewHashingIterator(it backtest.CandleIterator, hasher *DatasetHasher) *HashingIterator {
+	return &HashingIterator{CandleIterator: it, hasher: hasher}
+}
+
+// Next advances and hashes the next candle
+func (h *HashingIterator) Next() bool {
+	if !h.CandleIterator.Next() {
+		return false
+	}
+	h.hasher.Add(h.CandleIterator.Candle())
+	return true
+}
+
+// GitRevision returns the checked out commit, suffixed "-dirty" with
+// uncommitted changes, or "" outside a git work tree
+func GitRevision() string {
+	out, err := exec.Command("git", "rev-parse", "--short=12", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	rev := strings.TrimSpace(string(out))
+	if status, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output(); err == nil && len(strings.TrimSpace(string(status))) > 0 {
+		rev += "-dirty"
+	}
+	return rev
+}
@@ -0,0 +1,270 @@
+// Package experiments records backtest runs in SQLite so optimization
+// history survives: parameters, git revision, dataset hash and metrics of
+// every run, with one baseline per strategy and symbol to compare against.
+package experiments
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// ErrNotFound is returned for unknown run ids
+var ErrNotFound = errors.New("experiment run not found")
+
+// Run is one recorded backtest
+type Run struct {
+	ID          int64                       `json:"id"`
+	CreatedAt   time.Time                   `json:"created_at"`
+	Batch       string                      `json:"batch"`    // shared by the runs of one command invocation
+	Command     string                      `json:"command"`  // backtest or optimize
+	Strategy    string                      `json:"strategy"` // dca or grid
+	Symbol      string                      `json:"symbol"`
+	Params      map[string]interface{}      `json:"params"`
+	GitRevision string                      `json:"git_revision"`
+	DatasetHash string                      `json:"dataset_hash"`
+	Start       time.Time                   `json:"start"`
+	End         time.Time                   `json:"end"`
+	Initial     float64                     `json:"initial"`  // starting balance
+	FeeRate     float64                     `json:"fee_rate"` // taker fee rate
+	Metrics     backtest.PerformanceMetrics `json:"metrics"`
+	Score       float64                     `json:"score"` // optimizer ranking score, 0 for plain backtests
+	Baseline    bool                        `json:"baseline"`
+}
+
+// Filter narrows List; zero fields match everything
+type Filter struct {
+	Strategy string
+	Symbol   string
+	Batch    string
+	Baseline bool // only baselines
+	Limit    int  // newest first; 0 means no limit
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at   TEXT    NOT NULL,
+	batch        TEXT    NOT NULL,
+	command      TEXT    NOT NULL,
+	strategy     TEXT    NOT NULL,
+	symbol       TEXT    NOT NULL,
+	params       TEXT    NOT NULL,
+	git_revision TEXT    NOT NULL,
+	dataset_hash TEXT    NOT NULL,
+	start_time   TEXT    NOT NULL,
+	end_time     TEXT    NOT NULL,
+	initial      REAL    NOT NULL,
+	fee_rate     REAL    NOT NULL,
+	metrics      TEXT    NOT NULL,
+	score        REAL    NOT NULL,
+	baseline     INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS runs_strategy_symbol ON runs (strategy, symbol);
+CREATE INDEX IF NOT EXISTS runs_batch ON runs (batch);
+`
+
+const (
+	insertColumns = `created_at, batch, command, strategy, symbol, params, git_revision, dataset_hash,
+	start_time, end_time, initial, fee_rate, metrics, score, baseline`
+	columns = `id, ` + insertColumns
+)
+
+// Store is an experiments database
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the database at path
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open experiments db: %w", err)
+	}
+	// One connection serializes writers, which SQLite requires anyway
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create experiments schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record inserts runs in one transaction and returns their ids
+func (s *Store) Record(ctx context.Context, runs ...Run) ([]int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO runs (`+insertColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, 0, len(runs))
+	for _, run := range runs {
+		if run.CreatedAt.IsZero() {
+			run.CreatedAt = time.Now()
+		}
+		params, err := json.Marshal(run.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode params: %w", err)
+		}
+		metrics, err := json.Marshal(run.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode metrics: %w", err)
+		}
+		res, err := stmt.ExecContext(ctx,
+			formatTime(run.CreatedAt), run.Batch, run.Command, run.Strategy, run.Symbol, string(params),
+			run.GitRevision, run.DatasetHash, formatTime(run.Start), formatTime(run.End),
+			run.Initial, run.FeeRate, string(metrics), run.Score, run.Baseline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert run: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read run id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit runs: %w", err)
+	}
+	return ids, nil
+}
+
+// List returns matching runs, newest first
+func (s *Store) List(ctx context.Context, f Filter) ([]Run, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	for _, cond := range []struct {
+		column string
+		value  string
+	}{{"strategy", f.Strategy}, {"symbol", f.Symbol}, {"batch", f.Batch}} {
+		if cond.value != "" {
+			where = append(where, cond.column+" = ?")
+			args = append(args, cond.value)
+		}
+	}
+	if f.Baseline {
+		where = append(where, "baseline = 1")
+	}
+
+	query := `SELECT ` + columns + ` FROM runs`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY id DESC`
+	if f.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, f.Limit)
+	}
+	return s.query(ctx, query, args...)
+}
+
+// Get returns a run by id
+func (s *Store) Get(ctx context.Context, id int64) (*Run, error) {
+	runs, err := s.query(ctx, `SELECT `+columns+` FROM runs WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return &runs[0], nil
+}
+
+// Baseline returns the baseline for a strategy and symbol, or nil without one
+func (s *Store) Baseline(ctx context.Context, strategy, symbol string) (*Run, error) {
+	runs, err := s.query(ctx, `SELECT `+columns+` FROM runs WHERE strategy = ? AND symbol = ? AND baseline = 1`, strategy, symbol)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return &runs[0], nil
+}
+
+// MarkBaseline makes a run the baseline of its strategy and symbol,
+// replacing the previous one
+func (s *Store) MarkBaseline(ctx context.Context, id int64) error {
+	run, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `UPDATE runs SET baseline = 0 WHERE strategy = ? AND symbol = ?`, run.Strategy, run.Symbol); err != nil {
+		return fmt.Errorf("failed to clear baseline: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE runs SET baseline = 1 WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to set baseline: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit baseline: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...interface{}) ([]Run, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var (
+			run                 Run
+			created, start, end string
+			params, metrics     string
+		)
+		if err := rows.Scan(&run.ID, &created, &run.Batch, &run.Command, &run.Strategy, &run.Symbol, &params,
+			&run.GitRevision, &run.DatasetHash, &start, &end, &run.Initial, &run.FeeRate, &metrics, &run.Score, &run.Baseline); err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		if err := json.Unmarshal([]byte(params), &run.Params); err != nil {
+			return nil, fmt.Errorf("run %d: invalid params: %w", run.ID, err)
+		}
+		if err := json.Unmarshal([]byte(metrics), &run.Metrics); err != nil {
+			return nil, fmt.Errorf("run %d: invalid metrics: %w", run.ID, err)
+		}
+		run.CreatedAt, run.Start, run.End = parseTime(created), parseTime(start), parseTime(end)
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+	return runs, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
@@ -0,0 +1,87 @@
+package experiments
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/backtest"
+)
+
+func TestStore_RecordListBaseline(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "experiments.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids, err := store.Record(ctx,
+		Run{Batch: "b1", Command: "optimize", Strategy: "grid", Symbol: "BTCUSDT", Params: map[string]interface{}{"grid_levels": 10},
+			Start: start, End: start.Add(24 * time.Hour), Metrics: backtest.PerformanceMetrics{TotalReturn: 4, SharpeRatio: 1.2}, Score: 1.2},
+		Run{Batch: "b1", Command: "optimize", Strategy: "grid", Symbol: "BTCUSDT", Params: map[string]interface{}{"grid_levels": 20}},
+		Run{Batch: "b2", Command: "backtest", Strategy: "dca", Symbol: "BTCUSDT"},
+	)
+	if err != nil || len(ids) != 3 {
+		t.Fatalf("Record() = %v, %v", ids, err)
+	}
+	store.Close()
+
+	// History survives reopening the database
+	store, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	grid, err := store.List(ctx, Filter{Strategy: "grid"})
+	if err != nil || len(grid) != 2 || grid[0].ID != ids[1] {
+		t.Fatalf("List(grid) = %+v, %v", grid, err)
+	}
+	run, err := store.Get(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Params["grid_levels"] != float64(10) || run.Metrics.SharpeRatio != 1.2 || !run.Start.Equal(start) || run.Batch != "b1" {
+		t.Errorf("Unexpected run %+v", run)
+	}
+	if _, err := store.Get(ctx, 99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(99) error = %v, want ErrNotFound", err)
+	}
+
+	// Marking a baseline replaces the previous one for the strategy and symbol
+	for _, id := range []int64{ids[0], ids[1], ids[2]} {
+		if err := store.MarkBaseline(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	baseline, err := store.Baseline(ctx, "grid", "BTCUSDT")
+	if err != nil || baseline == nil || baseline.ID != ids[1] {
+		t.Fatalf("Baseline(grid) = %+v, %v", baseline, err)
+	}
+	if baselines, _ := store.List(ctx, Filter{Baseline: true}); len(baselines) != 2 {
+		t.Errorf("Expected one baseline per strategy, got %d", len(baselines))
+	}
+}
+
+func TestHashCandles(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []backtest.Candle
+	for i := 0; i < 10; i++ {
+		candles = append(candles, backtest.Candle{Time: start.Add(time.Duration(i) * time.Hour), Close: 100 + float64(i)})
+	}
+	end := start.Add(5 * time.Hour)
+
+	// Bars outside the window do not change the hash
+	if HashCandles(candles, start, end) != HashCandles(candles[:6], start, end) {
+		t.Error("Hash should only cover the window")
+	}
+	changed := append([]backtest.Candle(nil), candles...)
+	changed[3].Close++
+	if HashCandles(candles, start, end) == HashCandles(changed, start, end) {
+		t.Error("Hash should change with the data")
+	}
+}
@@ -0,0 +1,215 @@
+package fleet
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Standing is one instance's row on the leaderboard
+type Standing struct {
+	Rank     int       `json:"rank"`
+	Report   Report    `json:"report"`
+	Received time.Time `json:"received"`
+	Stale    bool      `json:"stale"` // no report within the stale period
+}
+
+// EquitySummary combines the latest reports of live instances
+type EquitySummary struct {
+	Equity    float64            `json:"equity"`
+	PnL       float64            `json:"pnl"`
+	Instances int                `json:"instances"`
+	Stale     int                `json:"stale"` // excluded from the totals
+	ByBot     map[string]float64 `json:"by_bot"`
+	Updated   time.Time          `json:"updated"`
+}
+
+// DriftGroup lists config hashes seen within a group of instances
+type DriftGroup struct {
+	Group    string              `json:"group"`
+	Expected string              `json:"expected"` // hash shared by most instances
+	Configs  map[string][]string `json:"configs"`  // hash -> instances
+	Drifted  []string            `json:"drifted"`  // instances not on the expected hash
+}
+
+// Collector keeps the latest report of each instance
+type Collector struct {
+	mu         sync.RWMutex
+	staleAfter time.Duration
+	now        func() time.Time
+	latest     map[string]Standing
+}
+
+// NewCollector creates a collector; instances silent for staleAfter
+// (default 5m) are flagged stale and left out of combined equity
+func NewCollector(staleAfter time.Duration) *Collector {
+	if staleAfter <= 0 {
+		staleAfter = 5 * time.Minute
+	}
+	return &Collector{
+		staleAfter: staleAfter,
+		now:        time.Now,
+		latest:     make(map[string]Standing),
+	}
+}
+
+// Record stores a report, replacing the instance's previous one
+func (c *Collector) Record(r Report) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if r.Group == "" {
+		r.Group = r.Bot + "/" + r.Symbol
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	received := c.now()
+	if r.Time.IsZero() {
+		r.Time = received
+	}
+	c.latest[r.Instance] = Standing{Report: r, Received: received}
+	return nil
+}
+
+// Leaderboard ranks instances by PnL, best first
+func (c *Collector) Leaderboard() []Standing {
+	standings := c.snapshot()
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Report.PnL != standings[j].Report.PnL {
+			return standings[i].Report.PnL > standings[j].Report.PnL
+		}
+		return standings[i].Report.Instance < standings[j].Report.Instance
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
+
+// Equity sums equity and PnL over live instances
+func (c *Collector) Equity() EquitySummary {
+	summary := EquitySummary{ByBot: make(map[string]float64), Updated: c.now()}
+	for _, s := range c.snapshot() {
+		if s.Stale {
+			summary.Stale++
+			continue
+		}
+		summary.Instances++
+		summary.Equity += s.Report.Equity
+		summary.PnL += s.Report.PnL
+		summary.ByBot[s.Report.Bot] += s.Report.PnL
+	}
+	return summary
+}
+
+// Drift returns the groups whose live instances run different configs
+func (c *Collector) Drift() []DriftGroup {
+	groups := make(map[string]map[string][]string)
+	for _, s := range c.snapshot() {
+		if s.Stale || s.Report.ConfigHash == "" {
+			continue
+		}
+		configs := groups[s.Report.Group]
+		if configs == nil {
+			configs = make(map[string][]string)
+			groups[s.Report.Group] = configs
+		}
+		configs[s.Report.ConfigHash] = append(configs[s.Report.ConfigHash], s.Report.Instance)
+	}
+
+	drift := make([]DriftGroup, 0)
+	for group, configs := range groups {
+		if len(configs) < 2 {
+			continue
+		}
+		hashes := make([]string, 0, len(configs))
+		for hash, instances := range configs {
+			sort.Strings(instances)
+			hashes = append(hashes, hash)
+		}
+		// Most instances wins; ties break on the hash so the answer is stable
+		sort.Slice(hashes, func(i, j int) bool {
+			if len(configs[hashes[i]]) != len(configs[hashes[j]]) {
+				return len(configs[hashes[i]]) > len(configs[hashes[j]])
+			}
+			return hashes[i] < hashes[j]
+		})
+
+		d := DriftGroup{Group: group, Expected: hashes[0], Configs: configs}
+		for _, hash := range hashes[1:] {
+			d.Drifted = append(d.Drifted, configs[hash]...)
+		}
+		sort.Strings(d.Drifted)
+		drift = append(drift, d)
+	}
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Group < drift[j].Group })
+	return drift
+}
+
+// snapshot copies the latest standings with their stale flags set
+func (c *Collector) snapshot() []Standing {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := c.now()
+	standings := make([]Standing, 0, len(c.latest))
+	for _, s := range c.latest {
+		s.Stale = now.Sub(s.Received) > c.staleAfter
+		standings = append(standings, s)
+	}
+	return standings
+}
+
+// Handler serves the collector API. When token is set, POST /reports
+// requires "Authorization: Bearer <token>"; the read endpoints stay open.
+func (c *Collector) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	mux.HandleFunc("POST /reports", func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing bearer token"})
+				return
+			}
+		}
+		var report Report
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := c.Record(report); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "recorded"})
+	})
+
+	mux.HandleFunc("GET /leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.Leaderboard())
+	})
+
+	mux.HandleFunc("GET /equity", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.Equity())
+	})
+
+	mux.HandleFunc("GET /drift", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.Drift())
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
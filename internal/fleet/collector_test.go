@@ -0,0 +1,101 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+)
+
+func TestCollector_Aggregates(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCollector(5 * time.Minute)
+	c.now = func() time.Time { return now }
+
+	// An instance that last reported long ago is stale
+	if err := c.Record(Report{Instance: "old", Bot: "dca", Symbol: "BTCUSDT", Equity: 500, PnL: 99, ConfigHash: "zzz"}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+
+	for _, r := range []Report{
+		{Instance: "a", Bot: "dca", Symbol: "BTCUSDT", Equity: 1000, PnL: 20, ConfigHash: "h1"},
+		{Instance: "b", Bot: "dca", Symbol: "BTCUSDT", Equity: 1100, PnL: 50, ConfigHash: "h1"},
+		{Instance: "c", Bot: "dca", Symbol: "BTCUSDT", Equity: 900, PnL: -10, ConfigHash: "h2"},
+		{Instance: "d", Bot: "grid", Symbol: "ETHUSDT", Equity: 2000, PnL: 5, ConfigHash: "g1"},
+	} {
+		if err := c.Record(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Record(Report{Bot: "dca"}); err == nil {
+		t.Error("Expected a report without an instance to be rejected")
+	}
+
+	equity := c.Equity()
+	if equity.Equity != 5000 || equity.PnL != 65 || equity.Instances != 4 || equity.Stale != 1 {
+		t.Errorf("Unexpected equity summary %+v", equity)
+	}
+	if equity.ByBot["dca"] != 60 || equity.ByBot["grid"] != 5 {
+		t.Errorf("Unexpected per-bot PnL %v", equity.ByBot)
+	}
+
+	board := c.Leaderboard()
+	var order []string
+	for _, s := range board {
+		order = append(order, s.Report.Instance)
+	}
+	if !reflect.DeepEqual(order, []string{"old", "b", "a", "d", "c"}) || !board[0].Stale || board[1].Rank != 2 {
+		t.Errorf("Unexpected leaderboard %v", order)
+	}
+
+	drift := c.Drift()
+	if len(drift) != 1 || drift[0].Group != "dca/BTCUSDT" || drift[0].Expected != "h1" || !reflect.DeepEqual(drift[0].Drifted, []string{"c"}) {
+		t.Errorf("Unexpected drift %+v", drift)
+	}
+}
+
+func TestReporter_PushesToCollector(t *testing.T) {
+	c := NewCollector(time.Minute)
+	srv := httptest.NewServer(c.Handler("secret"))
+	defer srv.Close()
+	log := logger.New(logger.LevelError)
+	source := func() Report { return Report{Bot: "grid", Symbol: "BTCUSDT", Equity: 1500, PnL: 12} }
+
+	denied := NewReporter(Config{URL: srv.URL, Instance: "edge-1"}, "grid", source, log)
+	if err := denied.Push(context.Background()); err == nil {
+		t.Fatal("Expected a push without the token to fail")
+	}
+
+	reporter := NewReporter(Config{URL: srv.URL + "/", Instance: "edge-1", Group: "canary", Token: "secret"}, "grid", source, log)
+	if err := reporter.Push(context.Background()); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	resp, err := http.Get(srv.URL + "/leaderboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var board []Standing
+	if err := json.NewDecoder(resp.Body).Decode(&board); err != nil {
+		t.Fatal(err)
+	}
+	if len(board) != 1 || board[0].Report.Instance != "edge-1" || board[0].Report.Group != "canary" || board[0].Report.PnL != 12 {
+		t.Errorf("Unexpected leaderboard %+v", board)
+	}
+}
+
+func TestHashConfig(t *testing.T) {
+	a, _ := HashConfig(map[string]interface{}{"symbol": "BTCUSDT", "levels": 10})
+	b, _ := HashConfig(map[string]interface{}{"levels": 10, "symbol": "BTCUSDT"})
+	c, _ := HashConfig(map[string]interface{}{"levels": 12, "symbol": "BTCUSDT"})
+	if a != b || a == c {
+		t.Errorf("HashConfig: %s %s %s", a, b, c)
+	}
+}
@@ -0,0 +1,50 @@
+// Package fleet aggregates metrics from many bot instances. Each bot pushes
+// periodic Reports to a central Collector, which serves combined equity, a
+// per-bot PnL leaderboard and config drift between instances of one group.
+package fleet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Report is one instance's state, pushed to the collector
+type Report struct {
+	Instance   string                `json:"instance"`    // unique per running process
+	Group      string                `json:"group"`       // instances expected to share a config
+	Bot        string                `json:"bot"`         // bot id, e.g. "dca"
+	Symbol     string                `json:"symbol"`      // traded symbol
+	Version    string                `json:"version"`     // app version
+	ConfigHash string                `json:"config_hash"` // see HashConfig
+	Equity     float64               `json:"equity"`      // portfolio value in the reporting currency
+	PnL        float64               `json:"pnl"`         // strategy profit minus loss
+	Metrics    types.StrategyMetrics `json:"metrics"`
+	Time       time.Time             `json:"time"`
+}
+
+// Validate checks the fields the collector keys on
+func (r Report) Validate() error {
+	if r.Instance == "" {
+		return fmt.Errorf("instance is required")
+	}
+	if r.Bot == "" {
+		return fmt.Errorf("bot is required")
+	}
+	return nil
+}
+
+// HashConfig fingerprints a config section for drift detection. Equal
+// configs hash equally since encoding/json sorts map keys.
+func HashConfig(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
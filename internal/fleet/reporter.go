@@ -0,0 +1,151 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+)
+
+// Config points a bot at a collector; reporting is off without a URL
+type Config struct {
+	URL      string        `json:"url"`      // collector base URL, e.g. http://collector:9100
+	Instance string        `json:"instance"` // defaults to <hostname>-<bot>
+	Group    string        `json:"group"`    // drift group; the collector defaults it to <bot>/<symbol>
+	Token    string        `json:"token"`    // bearer token expected by the collector
+	Interval time.Duration `json:"interval"` // push period (default 1m)
+}
+
+// UnmarshalJSON implements custom parsing for the interval ("30s", "1m")
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type Alias Config
+	aux := &struct {
+		Interval string `json:"interval"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Interval != "" {
+		interval, err := time.ParseDuration(aux.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval format: %w", err)
+		}
+		c.Interval = interval
+	}
+
+	return nil
+}
+
+// Enabled reports whether a collector is configured
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+// Validate checks the config
+func (c Config) Validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("collector interval must not be negative")
+	}
+	if c.URL != "" && !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+		return fmt.Errorf("collector url must be http or https")
+	}
+	return nil
+}
+
+// Reporter pushes an instance's reports to the collector
+type Reporter struct {
+	cfg    Config
+	source func() Report
+	client *http.Client
+	logger *logger.Logger
+}
+
+// NewReporter creates a reporter for bot; source builds each report and the
+// reporter fills in the instance and group
+func NewReporter(cfg Config, bot string, source func() Report, log *logger.Logger) *Reporter {
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Instance == "" {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			host = "localhost"
+		}
+		cfg.Instance = host + "-" + bot
+	}
+	return &Reporter{
+		cfg:    cfg,
+		source: source,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: log,
+	}
+}
+
+// Instance returns the name this reporter pushes under
+func (r *Reporter) Instance() string {
+	return r.cfg.Instance
+}
+
+// Run pushes a report every interval until ctx is canceled. A failed push
+// is logged and retried on the next tick; the collector keeps the last one.
+func (r *Reporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Push(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Warn("Failed to report to collector: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Push sends one report
+func (r *Reporter) Push(ctx context.Context) error {
+	report := r.source()
+	report.Instance = r.cfg.Instance
+	if r.cfg.Group != "" {
+		report.Group = r.cfg.Group
+	}
+	if report.Time.IsZero() {
+		report.Time = time.Now()
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(r.cfg.URL, "/")+"/reports", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.cfg.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -2,8 +2,12 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // Level is a logging verbosity level
@@ -17,76 +21,198 @@ const (
 	LevelFatal
 )
 
-// Logger is a minimal logger wrapper
+// String returns the level's lowercase name
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "fatal"
+	}
+}
+
+// Logger is a minimal logger wrapper. Loggers derived with Named and
+// WithFields share the output and levels of the logger they came from.
 type Logger struct {
-	level  Level
+	core      *core
+	component string   // dotted path, e.g. "combo.dca"; "" for the root
+	fields    []string // key=value
+	prefix    string
+}
+
+// core is the output and levels shared by a logger and its children
+type core struct {
 	logger *log.Logger
+	closer io.Closer
+
+	mu     sync.RWMutex
+	level  Level
+	levels map[string]Level // overrides by component
 }
 
 // New creates a new logger writing to stdout
 func New(level Level) *Logger {
-	return &Logger{
-		level:  level,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
-	}
+	return newLogger(level, os.Stdout, nil)
 }
 
-// NewWithFile creates a logger writing to a file
-func NewWithFile(level Level, filename string) (*Logger, error) {
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+// NewWithFile creates a logger writing to a file, rotated as set by
+// rotation; a zero Rotation appends to the file forever
+func NewWithFile(level Level, filename string, rotation Rotation) (*Logger, error) {
+	file, err := openRotatingFile(filename, rotation)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
+	return newLogger(level, file, file), nil
+}
 
-	return &Logger{
+func newLogger(level Level, out io.Writer, closer io.Closer) *Logger {
+	return &Logger{core: &core{
+		logger: log.New(out, "", log.LstdFlags),
+		closer: closer,
 		level:  level,
-		logger: log.New(file, "", log.LstdFlags),
-	}, nil
+		levels: make(map[string]Level),
+	}}
 }
 
 // Debug logs a debug message
 func (l *Logger) Debug(format string, args ...interface{}) {
-	if l.level <= LevelDebug {
-		l.logger.Printf("[DEBUG] "+format, args...)
-	}
+	l.print(LevelDebug, "[DEBUG] ", format, args...)
 }
 
 // Info logs an info message
 func (l *Logger) Info(format string, args ...interface{}) {
-	if l.level <= LevelInfo {
-		l.logger.Printf("[INFO] "+format, args...)
-	}
+	l.print(LevelInfo, "[INFO] ", format, args...)
 }
 
 // Warn logs a warning
 func (l *Logger) Warn(format string, args ...interface{}) {
-	if l.level <= LevelWarn {
-		l.logger.Printf("[WARN] "+format, args...)
-	}
+	l.print(LevelWarn, "[WARN] ", format, args...)
 }
 
 // Error logs an error
 func (l *Logger) Error(format string, args ...interface{}) {
-	if l.level <= LevelError {
-		l.logger.Printf("[ERROR] "+format, args...)
-	}
+	l.print(LevelError, "[ERROR] ", format, args...)
 }
 
 // Fatal logs a fatal error and exits
 func (l *Logger) Fatal(format string, args ...interface{}) {
-	if l.level <= LevelFatal {
-		l.logger.Printf("[FATAL] "+format, args...)
+	if l.print(LevelFatal, "[FATAL] ", format, args...) {
 		os.Exit(1)
 	}
 }
 
-// WithFields returns the same logger; placeholder for structured logging
+// print writes the message if level is enabled for the logger's component
+func (l *Logger) print(level Level, tag, format string, args ...interface{}) bool {
+	if level < l.core.levelOf(l.component) {
+		return false
+	}
+	l.core.logger.Printf(tag+l.prefix+format, args...)
+	return true
+}
+
+// Named returns a child logger for component, nested under this logger's
+// component with a dot (e.g. "combo.dca"). Its messages are prefixed with
+// the component, and SetComponentLevel can change its level at runtime.
+func (l *Logger) Named(component string) *Logger {
+	if l.component != "" {
+		component = l.component + "." + component
+	}
+	return l.child(component, l.fields)
+}
+
+// WithFields returns a child logger whose messages carry fields as
+// key=value pairs after the component
 func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
-	// Simple implementation - in a real project you can use structured logging
-	return l
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := l.fields
+	for _, k := range keys {
+		pairs = append(pairs[:len(pairs):len(pairs)], fmt.Sprintf("%s=%v", k, fields[k]))
+	}
+	return l.child(l.component, pairs)
+}
+
+func (l *Logger) child(component string, fields []string) *Logger {
+	child := &Logger{core: l.core, component: component, fields: fields}
+	parts := fields
+	if component != "" {
+		parts = append([]string{component}, fields...)
+	}
+	if len(parts) > 0 {
+		child.prefix = "[" + strings.Join(parts, " ") + "] "
+	}
+	return child
 }
 
-// SetLevel adjusts logging verbosity level
+// Component returns the logger's dotted component name; "" for the root
+func (l *Logger) Component() string {
+	return l.component
+}
+
+// SetLevel adjusts logging verbosity level of components without an override
 func (l *Logger) SetLevel(level Level) {
-	l.level = level
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.level = level
+}
+
+// SetComponentLevel overrides the level of component and the components
+// nested under it
+func (l *Logger) SetComponentLevel(component string, level Level) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.levels[component] = level
+}
+
+// ClearComponentLevel removes component's override
+func (l *Logger) ClearComponentLevel(component string) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	delete(l.core.levels, component)
+}
+
+// Levels returns the default level and the component overrides
+func (l *Logger) Levels() (Level, map[string]Level) {
+	l.core.mu.RLock()
+	defer l.core.mu.RUnlock()
+	levels := make(map[string]Level, len(l.core.levels))
+	for component, level := range l.core.levels {
+		levels[component] = level
+	}
+	return l.core.level, levels
+}
+
+// Close closes the log file, if any
+func (l *Logger) Close() error {
+	if l.core.closer == nil {
+		return nil
+	}
+	return l.core.closer.Close()
+}
+
+// levelOf returns the override of component or its nearest parent, or the
+// default level
+func (c *core) levelOf(component string) Level {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for component != "" {
+		if level, ok := c.levels[component]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(component, '.')
+		if i < 0 {
+			break
+		}
+		component = component[:i]
+	}
+	return c.level
 }
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamed_LevelsAndPrefix(t *testing.T) {
+	var out bytes.Buffer
+	root := newLogger(LevelInfo, &out, nil)
+	combo := root.Named("combo")
+	dca := combo.Named("dca").WithFields(map[string]interface{}{"symbol": "BTCUSDT"})
+
+	dca.Info("bought %d", 1)
+	if got := out.String(); !strings.Contains(got, "[INFO] [combo.dca symbol=BTCUSDT] bought 1") {
+		t.Errorf("output = %q, want the component and fields prefixed", got)
+	}
+
+	// An override applies to the component and those nested under it
+	out.Reset()
+	root.SetComponentLevel("combo", LevelDebug)
+	dca.Debug("nested")
+	root.Debug("root")
+	if got := out.String(); !strings.Contains(got, "nested") || strings.Contains(got, "root") {
+		t.Errorf("output = %q, want debug from combo.dca only", got)
+	}
+
+	out.Reset()
+	root.SetComponentLevel("combo.dca", LevelError)
+	dca.Warn("quiet")
+	combo.Debug("loud")
+	if got := out.String(); strings.Contains(got, "quiet") || !strings.Contains(got, "loud") {
+		t.Errorf("output = %q, want the nearest override to win", got)
+	}
+
+	root.ClearComponentLevel("combo")
+	root.ClearComponentLevel("combo.dca")
+	if level, overrides := root.Levels(); level != LevelInfo || len(overrides) != 0 {
+		t.Errorf("Levels() = %v, %v after clearing", level, overrides)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f, err := openRotatingFile(path, Rotation{MaxSize: 10, MaxAge: time.Hour, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.now = func() time.Time { return now }
+	f.opened = now
+
+	write := func(s string) {
+		t.Helper()
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("12345678")
+	now = now.Add(time.Second)
+	write("123") // past 10 bytes: rotates
+	now = now.Add(time.Hour)
+	write("a") // an hour old: rotates
+	now = now.Add(time.Second)
+	write("123456789")
+	write("12") // rotates, dropping the oldest backup
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want the 2 newest", backups)
+	}
+	oldest, _ := os.ReadFile(backups[0])
+	current, _ := os.ReadFile(path)
+	if string(oldest) != "123" || string(current) != "12" {
+		t.Errorf("oldest backup %q, current %q; want 123 and 12", oldest, current)
+	}
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DashboardOptions configures a generated Grafana dashboard; zero fields take defaults
+type DashboardOptions struct {
+	Title     string   // default "Crypto Trading Bots"
+	UID       string   // default "crypto-trader"
+	Variables []string // labels offered as dashboard filters (default exchange, bot, strategy, symbol)
+}
+
+func (o DashboardOptions) withDefaults() DashboardOptions {
+	if o.Title == "" {
+		o.Title = "Crypto Trading Bots"
+	}
+	if o.UID == "" {
+		o.UID = "crypto-trader"
+	}
+	if o.Variables == nil {
+		o.Variables = []string{LabelExchange, LabelBot, LabelStrategy, LabelSymbol}
+	}
+	return o
+}
+
+// Dashboard generates a Grafana dashboard with one row per metric group and
+// one time series panel per metric. Each panel filters on the dashboard
+// variables its metric is labeled with; counters are shown as rates.
+func Dashboard(descs []Desc, opts DashboardOptions) ([]byte, error) {
+	opts = opts.withDefaults()
+	if len(descs) == 0 {
+		return nil, fmt.Errorf("no metrics to chart")
+	}
+
+	datasource := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+	templating := []interface{}{map[string]interface{}{
+		"name":  "datasource",
+		"label": "Data source",
+		"type":  "datasource",
+		"query": "prometheus",
+	}}
+	variables := make(map[string]bool)
+	for _, label := range opts.Variables {
+		metric, ok := firstWithLabel(descs, label)
+		if !ok {
+			continue
+		}
+		variables[label] = true
+		templating = append(templating, map[string]interface{}{
+			"name":       label,
+			"label":      label,
+			"type":       "query",
+			"datasource": datasource,
+			"query":      fmt.Sprintf("label_values(%s, %s)", metric, label),
+			"refresh":    2,
+			"multi":      true,
+			"includeAll": true,
+			"allValue":   ".*",
+			"current":    map[string]interface{}{"text": "All", "value": "$__all"},
+		})
+	}
+
+	var panels []interface{}
+	id, y := 1, 0
+	group := ""
+	col := 0
+	for _, d := range descs {
+		if d.Group != group || id == 1 {
+			if col > 0 {
+				y += 8
+			}
+			group, col = d.Group, 0
+			title := group
+			if title == "" {
+				title = "Metrics"
+			}
+			panels = append(panels, map[string]interface{}{
+				"id":        id,
+				"type":      "row",
+				"title":     title,
+				"collapsed": false,
+				"gridPos":   map[string]int{"h": 1, "w": 24, "x": 0, "y": y},
+			})
+			id++
+			y++
+		}
+
+		panels = append(panels, map[string]interface{}{
+			"id":          id,
+			"type":        "timeseries",
+			"title":       panelTitle(d),
+			"description": d.Help,
+			"datasource":  datasource,
+			"gridPos":     map[string]int{"h": 8, "w": 12, "x": col * 12, "y": y},
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]interface{}{"unit": d.Unit},
+				"overrides": []interface{}{},
+			},
+			"options": map[string]interface{}{
+				"legend":  map[string]interface{}{"displayMode": "list", "placement": "bottom", "showLegend": true},
+				"tooltip": map[string]interface{}{"mode": "multi", "sort": "desc"},
+			},
+			"targets": []interface{}{map[string]interface{}{
+				"refId":        "A",
+				"datasource":   datasource,
+				"expr":         Query(d, variables),
+				"legendFormat": legendFormat(d),
+			}},
+		})
+		id++
+		if col++; col == 2 {
+			col = 0
+			y += 8
+		}
+	}
+
+	dashboard := map[string]interface{}{
+		"uid":           opts.UID,
+		"title":         opts.Title,
+		"tags":          []string{"crypto", "trading", "generated"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"version":       1,
+		"editable":      true,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-24h", "to": "now"},
+		"templating":    map[string]interface{}{"list": templating},
+		"panels":        panels,
+	}
+	data, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Query returns the PromQL a panel charts for d, filtered by the labels in
+// variables. Counters are charted as per-second rates.
+func Query(d Desc, variables map[string]bool) string {
+	var matchers []string
+	for _, label := range d.Labels {
+		if variables[label] {
+			matchers = append(matchers, fmt.Sprintf(`%s=~"$%s"`, label, label))
+		}
+	}
+	selector := d.Name
+	if len(matchers) > 0 {
+		selector += "{" + strings.Join(matchers, ",") + "}"
+	}
+	if d.Type == Counter {
+		return fmt.Sprintf("rate(%s[$__rate_interval])", selector)
+	}
+	return selector
+}
+
+// firstWithLabel returns the first metric labeled with label
+func firstWithLabel(descs []Desc, label string) (string, bool) {
+	for _, d := range descs {
+		for _, l := range d.Labels {
+			if l == label {
+				return d.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// panelTitle derives a title from the metric name, e.g. "Strategy pnl"
+func panelTitle(d Desc) string {
+	name := strings.TrimPrefix(d.Name, "trader_")
+	name = strings.TrimSuffix(name, "_total")
+	title := strings.ReplaceAll(name, "_", " ")
+	if d.Type == Counter {
+		title += " (per second)"
+	}
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+// legendFormat names a series by its label values
+func legendFormat(d Desc) string {
+	parts := make([]string, 0, len(d.Labels))
+	for _, label := range d.Labels {
+		parts = append(parts, "{{"+label+"}}")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	r, err := NewRegistry(
+		Desc{Name: "orders_total", Help: "Orders placed.", Type: Counter, Labels: []string{"bot", "side"}},
+		Desc{Name: "equity", Help: "Account equity.", Type: Gauge},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AddCollector(func(r *Registry) {
+		if err := r.Set("equity", 1234.5); err != nil {
+			t.Error(err)
+		}
+	})
+	if err := r.Add("orders_total", 2, "dca", "BUY"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add("orders_total", 1, `a"b`, "SELL"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add("orders_total", -1, "dca", "BUY"); err == nil {
+		t.Error("expected error decreasing a counter")
+	}
+	if err := r.Set("orders_total", 1, "dca"); err == nil {
+		t.Error("expected error for missing label values")
+	}
+	if err := r.Set("unknown", 1); err == nil {
+		t.Error("expected error for an unregistered metric")
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	want := `# HELP orders_total Orders placed.
+# TYPE orders_total counter
+orders_total{bot="a\"b",side="SELL"} 1
+orders_total{bot="dca",side="BUY"} 2
+# HELP equity Account equity.
+# TYPE equity gauge
+equity 1234.5
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("exposition =\n%s\nwant\n%s", got, want)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestRegistryRejectsBadDescs(t *testing.T) {
+	for name, d := range map[string]Desc{
+		"bad name":        {Name: "bad-name", Help: "x", Type: Gauge},
+		"no help":         {Name: "x", Type: Gauge},
+		"counter suffix":  {Name: "orders", Help: "x", Type: Counter},
+		"unknown type":    {Name: "x", Help: "x", Type: "summary"},
+		"reserved label":  {Name: "x", Help: "x", Type: Gauge, Labels: []string{"__name"}},
+		"duplicate label": {Name: "x", Help: "x", Type: Gauge, Labels: []string{"bot", "bot"}},
+	} {
+		if _, err := NewRegistry(d); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := NewRegistry(Standard[0], Standard[0]); err == nil {
+		t.Error("expected error registering a metric twice")
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	data, err := Dashboard(Standard, DashboardOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dashboard struct {
+		UID        string `json:"uid"`
+		Templating struct {
+			List []struct {
+				Name  string `json:"name"`
+				Query string `json:"query"`
+			} `json:"list"`
+		} `json:"templating"`
+		Panels []struct {
+			Type    string `json:"type"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		t.Fatal(err)
+	}
+	if dashboard.UID != "crypto-trader" {
+		t.Errorf("uid = %q", dashboard.UID)
+	}
+
+	var variables []string
+	for _, v := range dashboard.Templating.List {
+		variables = append(variables, v.Name)
+	}
+	if got := strings.Join(variables, ","); got != "datasource,exchange,bot,strategy,symbol" {
+		t.Errorf("variables = %s", got)
+	}
+
+	// Every registered metric is charted exactly once
+	charted := make(map[string]int)
+	for _, p := range dashboard.Panels {
+		for _, target := range p.Targets {
+			for _, d := range Standard {
+				if strings.Contains(target.Expr, d.Name+"{") {
+					charted[d.Name]++
+				}
+			}
+		}
+	}
+	for _, d := range Standard {
+		if charted[d.Name] != 1 {
+			t.Errorf("%s charted %d times, want 1", d.Name, charted[d.Name])
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	vars := map[string]bool{"bot": true, "exchange": true}
+	counter := Desc{Name: "orders_total", Type: Counter, Labels: []string{"bot", "side"}}
+	if got, want := Query(counter, vars), `rate(orders_total{bot=~"$bot"}[$__rate_interval])`; got != want {
+		t.Errorf("Query(counter) = %s, want %s", got, want)
+	}
+	if got := Query(Desc{Name: "equity", Type: Gauge}, vars); got != "equity" {
+		t.Errorf("Query(gauge) = %s, want equity", got)
+	}
+}
+
+func TestCommittedDashboardIsCurrent(t *testing.T) {
+	committed, err := os.ReadFile("../../deployments/monitoring/grafana/trader-dashboard.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := Dashboard(NewStandardRegistry().Descs(), DashboardOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(committed) != string(generated) {
+		t.Error("deployments/monitoring/grafana/trader-dashboard.json is stale; run make dashboards")
+	}
+}
@@ -0,0 +1,253 @@
+// Package metrics declares the bot's Prometheus metrics in one registry, so
+// metric names and labels stay stable across strategies and exchanges and
+// Grafana dashboards can be generated from the same declarations.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Type is a Prometheus metric type
+type Type string
+
+// Supported metric types
+const (
+	Gauge   Type = "gauge"
+	Counter Type = "counter"
+)
+
+// Desc declares one metric
+type Desc struct {
+	Name   string
+	Help   string
+	Type   Type
+	Labels []string
+	Unit   string // Grafana unit id, e.g. "currencyUSD", "percentunit", "short"
+	Group  string // dashboard row the metric is shown in
+}
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func (d Desc) validate() error {
+	if !namePattern.MatchString(d.Name) {
+		return fmt.Errorf("invalid metric name %q", d.Name)
+	}
+	if d.Help == "" {
+		return fmt.Errorf("metric %s: help is required", d.Name)
+	}
+	switch d.Type {
+	case Gauge:
+	case Counter:
+		if !strings.HasSuffix(d.Name, "_total") {
+			return fmt.Errorf("counter %s must end in _total", d.Name)
+		}
+	default:
+		return fmt.Errorf("metric %s: unknown type %q", d.Name, d.Type)
+	}
+	seen := make(map[string]bool, len(d.Labels))
+	for _, label := range d.Labels {
+		if !namePattern.MatchString(label) || strings.HasPrefix(label, "__") {
+			return fmt.Errorf("metric %s: invalid label %q", d.Name, label)
+		}
+		if seen[label] {
+			return fmt.Errorf("metric %s: duplicate label %s", d.Name, label)
+		}
+		seen[label] = true
+	}
+	return nil
+}
+
+// family holds a metric's declaration and its series by label values
+type family struct {
+	desc   Desc
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64
+}
+
+// Registry holds declared metrics and their current values
+type Registry struct {
+	mu         sync.Mutex
+	order      []string
+	families   map[string]*family
+	collectors []func(*Registry)
+}
+
+// NewRegistry creates a registry declaring descs
+func NewRegistry(descs ...Desc) (*Registry, error) {
+	r := &Registry{families: make(map[string]*family)}
+	if err := r.Register(descs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Register declares metrics; names must be unique
+func (r *Registry) Register(descs ...Desc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range descs {
+		if err := d.validate(); err != nil {
+			return err
+		}
+		if _, exists := r.families[d.Name]; exists {
+			return fmt.Errorf("metric %s is already registered", d.Name)
+		}
+		r.families[d.Name] = &family{desc: d, series: make(map[string]*series)}
+		r.order = append(r.order, d.Name)
+	}
+	return nil
+}
+
+// Descs returns the declared metrics in registration order
+func (r *Registry) Descs() []Desc {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	descs := make([]Desc, 0, len(r.order))
+	for _, name := range r.order {
+		descs = append(descs, r.families[name].desc)
+	}
+	return descs
+}
+
+// Set sets the series of name with the given label values, in the order the
+// labels were declared. Counters may be set to totals read from elsewhere.
+func (r *Registry) Set(name string, value float64, labels ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.series(name, labels)
+	if err != nil {
+		return err
+	}
+	s.value = value
+	return nil
+}
+
+// Add increases the series of name with the given label values by delta
+func (r *Registry) Add(name string, delta float64, labels ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.series(name, labels)
+	if err != nil {
+		return err
+	}
+	if delta < 0 && r.families[name].desc.Type == Counter {
+		return fmt.Errorf("counter %s cannot decrease", name)
+	}
+	s.value += delta
+	return nil
+}
+
+// series returns the series for labels, creating it; r.mu must be held
+func (r *Registry) series(name string, labels []string) (*series, error) {
+	f, ok := r.families[name]
+	if !ok {
+		return nil, fmt.Errorf("metric %s is not registered", name)
+	}
+	if len(labels) != len(f.desc.Labels) {
+		return nil, fmt.Errorf("metric %s: got %d label values, want %d (%s)", name, len(labels), len(f.desc.Labels), strings.Join(f.desc.Labels, ", "))
+	}
+	key := strings.Join(labels, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), labels...)}
+		f.series[key] = s
+	}
+	return s, nil
+}
+
+// AddCollector registers fn to update values before every export
+func (r *Registry) AddCollector(fn func(*Registry)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, fn)
+}
+
+// WriteText runs the collectors and writes every metric in the Prometheus
+// text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]func(*Registry){}, r.collectors...)
+	r.mu.Unlock()
+	for _, collect := range collectors {
+		collect(r)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	for _, name := range r.order {
+		f := r.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, escapeHelp(f.desc.Help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.desc.Type)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := f.series[key]
+			b.WriteString(name)
+			if len(s.values) > 0 {
+				b.WriteByte('{')
+				for i, label := range f.desc.Labels {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(&b, "%s=\"%s\"", label, escapeLabel(s.values[i]))
+				}
+				b.WriteByte('}')
+			}
+			fmt.Fprintf(&b, " %s\n", formatValue(s.value))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the registry in the Prometheus text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WriteText(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,111 @@
+package metrics
+
+// Labels shared by bot metrics; every bot series carries bot and exchange
+const (
+	LabelBot      = "bot"
+	LabelStrategy = "strategy"
+	LabelSymbol   = "symbol"
+	LabelExchange = "exchange"
+	LabelPriority = "priority"
+	LabelReason   = "reason"
+	LabelAccount  = "account"
+)
+
+// Bot metric names. They are part of the monitoring contract: dashboards and
+// alerts refer to them, so rename only with a migration note.
+const (
+	StrategyTrades        = "trader_strategy_trades_total"
+	StrategyWinningTrades = "trader_strategy_winning_trades_total"
+	StrategyPnL           = "trader_strategy_pnl"
+	StrategyWinRate       = "trader_strategy_win_rate_percent"
+	StrategyVolume        = "trader_strategy_volume_total"
+	StrategyDrawdown      = "trader_strategy_max_drawdown"
+	StrategySharpe        = "trader_strategy_sharpe_ratio"
+	StrategySortino       = "trader_strategy_sortino_ratio"
+	StrategyCalmar        = "trader_strategy_calmar_ratio"
+	StrategyLoopStalled   = "trader_strategy_loop_stalled"
+	StrategyHeartbeatAge  = "trader_strategy_heartbeat_age_seconds"
+	StrategyDataMissing   = "trader_strategy_data_missing"
+
+	PortfolioEquity     = "trader_portfolio_equity"
+	PortfolioValue      = "trader_portfolio_positions_value"
+	PortfolioUnrealized = "trader_portfolio_unrealized_pnl"
+	PortfolioPositions  = "trader_portfolio_positions"
+	AccountEquity       = "trader_account_equity"
+	AccountUnrealized   = "trader_account_unrealized_pnl"
+
+	DeleverageDrawdown = "trader_deleverage_drawdown_ratio"
+	DeleverageScale    = "trader_deleverage_scale"
+	CalendarBlackout   = "trader_calendar_blackouts"
+	OrdersRejected     = "trader_orders_rejected_total"
+	GuardTripped       = "trader_guard_tripped"
+	GuardTrips         = "trader_guard_trips_total"
+
+	ExecutionSlippage = "trader_execution_slippage_bps"
+	ExecutionFees     = "trader_execution_fees_total"
+	ExecutionMaker    = "trader_execution_maker_ratio"
+
+	SentimentScore   = "trader_sentiment_score"
+	SentimentExtreme = "trader_sentiment_extreme"
+
+	ExchangeAvailable   = "trader_exchange_available"
+	RateLimitSaturation = "trader_rate_limit_saturation_ratio"
+	RateLimitQueued     = "trader_rate_limit_queued_requests"
+)
+
+var (
+	strategyLabels = []string{LabelBot, LabelExchange, LabelStrategy, LabelSymbol}
+	botLabels      = []string{LabelBot, LabelExchange}
+	accountLabels  = []string{LabelBot, LabelExchange, LabelAccount}
+	symbolLabels   = []string{LabelBot, LabelExchange, LabelSymbol}
+)
+
+// Standard declares the metrics every bot exports
+var Standard = []Desc{
+	{Name: StrategyTrades, Help: "Trades executed by the strategy.", Type: Counter, Labels: strategyLabels, Unit: "short", Group: "Strategy"},
+	{Name: StrategyWinningTrades, Help: "Trades closed at a profit.", Type: Counter, Labels: strategyLabels, Unit: "short", Group: "Strategy"},
+	{Name: StrategyPnL, Help: "Strategy profit minus loss in the quote currency.", Type: Gauge, Labels: strategyLabels, Unit: "currencyUSD", Group: "Strategy"},
+	{Name: StrategyWinRate, Help: "Share of winning trades, in percent.", Type: Gauge, Labels: strategyLabels, Unit: "percent", Group: "Strategy"},
+	{Name: StrategyVolume, Help: "Traded volume in the quote currency.", Type: Counter, Labels: strategyLabels, Unit: "currencyUSD", Group: "Strategy"},
+	{Name: StrategyDrawdown, Help: "Largest drawdown of the equity curve over the performance window, in percent.", Type: Gauge, Labels: strategyLabels, Unit: "percent", Group: "Strategy"},
+	{Name: StrategySharpe, Help: "Annualized Sharpe ratio of the equity curve over the performance window.", Type: Gauge, Labels: strategyLabels, Unit: "short", Group: "Strategy"},
+	{Name: StrategySortino, Help: "Annualized Sortino ratio of the equity curve over the performance window.", Type: Gauge, Labels: strategyLabels, Unit: "short", Group: "Strategy"},
+	{Name: StrategyCalmar, Help: "Annualized return over max drawdown of the equity curve over the performance window.", Type: Gauge, Labels: strategyLabels, Unit: "short", Group: "Strategy"},
+	{Name: StrategyLoopStalled, Help: "1 while the trading loop has not fetched market data or executed for longer than the stall threshold.", Type: Gauge, Labels: strategyLabels, Unit: "short", Group: "Strategy"},
+	{Name: StrategyHeartbeatAge, Help: "Seconds since the trading loop's oldest stage last succeeded.", Type: Gauge, Labels: strategyLabels, Unit: "s", Group: "Strategy"},
+	{Name: StrategyDataMissing, Help: "1 while the strategy holds because the candles it decides on are unavailable.", Type: Gauge, Labels: strategyLabels, Unit: "short", Group: "Strategy"},
+
+	{Name: PortfolioEquity, Help: "Account equity including cash.", Type: Gauge, Labels: botLabels, Unit: "currencyUSD", Group: "Portfolio"},
+	{Name: PortfolioValue, Help: "Market value of open positions.", Type: Gauge, Labels: botLabels, Unit: "currencyUSD", Group: "Portfolio"},
+	{Name: PortfolioUnrealized, Help: "Unrealized PnL of open positions.", Type: Gauge, Labels: botLabels, Unit: "currencyUSD", Group: "Portfolio"},
+	{Name: PortfolioPositions, Help: "Open positions.", Type: Gauge, Labels: botLabels, Unit: "short", Group: "Portfolio"},
+	{Name: AccountEquity, Help: "Equity of each exchange account including cash.", Type: Gauge, Labels: accountLabels, Unit: "currencyUSD", Group: "Portfolio"},
+	{Name: AccountUnrealized, Help: "Unrealized PnL of each exchange account's open positions.", Type: Gauge, Labels: accountLabels, Unit: "currencyUSD", Group: "Portfolio"},
+
+	{Name: DeleverageDrawdown, Help: "Portfolio drawdown from the equity peak, 0-1.", Type: Gauge, Labels: botLabels, Unit: "percentunit", Group: "Risk"},
+	{Name: DeleverageScale, Help: "Multiplier applied to buy sizes by the deleverager.", Type: Gauge, Labels: botLabels, Unit: "short", Group: "Risk"},
+	{Name: CalendarBlackout, Help: "Calendar events currently blacking out new entries.", Type: Gauge, Labels: botLabels, Unit: "short", Group: "Risk"},
+	{Name: GuardTripped, Help: "1 while the order anomaly guard blocks all orders.", Type: Gauge, Labels: botLabels, Unit: "short", Group: "Risk"},
+	{Name: GuardTrips, Help: "Times the order anomaly guard tripped.", Type: Counter, Labels: botLabels, Unit: "short", Group: "Risk"},
+	{Name: OrdersRejected, Help: "Orders rejected by risk controls.", Type: Counter, Labels: []string{LabelBot, LabelExchange, LabelReason}, Unit: "short", Group: "Risk"},
+
+	{Name: ExecutionSlippage, Help: "Notional-weighted fill slippage against the expected price, in basis points.", Type: Gauge, Labels: strategyLabels, Unit: "short", Group: "Execution"},
+	{Name: ExecutionFees, Help: "Commission paid on attributed fills in the quote currency.", Type: Counter, Labels: strategyLabels, Unit: "currencyUSD", Group: "Execution"},
+	{Name: ExecutionMaker, Help: "Share of DCA buy volume filled as maker by limit chasing, 0-1.", Type: Gauge, Labels: strategyLabels, Unit: "percentunit", Group: "Execution"},
+
+	{Name: SentimentScore, Help: "Latest recorded news sentiment, -1 to 1.", Type: Gauge, Labels: symbolLabels, Unit: "short", Group: "Sentiment"},
+	{Name: SentimentExtreme, Help: "1 while sentiment is at or above the high alert threshold, -1 at or below the low one, 0 otherwise.", Type: Gauge, Labels: symbolLabels, Unit: "short", Group: "Sentiment"},
+
+	{Name: ExchangeAvailable, Help: "1 while the exchange accepts trading, 0 during maintenance or backoff.", Type: Gauge, Labels: botLabels, Unit: "short", Group: "Exchange"},
+	{Name: RateLimitSaturation, Help: "Share of the last minute's request budget used, 0-1.", Type: Gauge, Labels: botLabels, Unit: "percentunit", Group: "Exchange"},
+	{Name: RateLimitQueued, Help: "Requests waiting for the shared request budget.", Type: Gauge, Labels: []string{LabelBot, LabelExchange, LabelPriority}, Unit: "short", Group: "Exchange"},
+}
+
+// NewStandardRegistry creates a registry declaring the Standard metrics
+func NewStandardRegistry() *Registry {
+	r, err := NewRegistry(Standard...)
+	if err != nil {
+		panic(err) // the declarations above are fixed
+	}
+	return r
+}
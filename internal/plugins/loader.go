@@ -0,0 +1,84 @@
+// Package plugins loads external strategies built as Go plugins.
+//
+// A plugin is a main package built with -buildmode=plugin that exports
+//
+//	func Register(registry *strategy.Registry) error
+//
+// and registers one or more strategy types. Plugins must be built with the
+// same Go toolchain and module versions as the trader binary, and require cgo.
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/config"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/strategy"
+)
+
+// RegisterSymbol is the function every strategy plugin must export
+const RegisterSymbol = "Register"
+
+// RegisterFunc is the signature of a plugin's Register symbol
+type RegisterFunc = func(registry *strategy.Registry) error
+
+// Load opens a plugin file and lets it register its strategies
+func Load(path string, registry *strategy.Registry) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open plugin %s: %w", path, err)
+	}
+
+	sym, err := p.Lookup(RegisterSymbol)
+	if err != nil {
+		return fmt.Errorf("plugin %s does not export %s: %w", path, RegisterSymbol, err)
+	}
+
+	register, ok := sym.(RegisterFunc)
+	if !ok {
+		return fmt.Errorf("plugin %s: %s has type %T, want func(*strategy.Registry) error", path, RegisterSymbol, sym)
+	}
+
+	if err := register(registry); err != nil {
+		return fmt.Errorf("plugin %s failed to register: %w", path, err)
+	}
+	return nil
+}
+
+// Discover returns plugin files from cfg: *.so files in Dir followed by Paths
+func Discover(cfg config.PluginConfig) ([]string, error) {
+	var paths []string
+	if cfg.Dir != "" {
+		matches, err := filepath.Glob(filepath.Join(cfg.Dir, "*.so"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plugin dir: %w", err)
+		}
+		if _, err := os.Stat(cfg.Dir); err != nil {
+			return nil, fmt.Errorf("plugin dir %s: %w", cfg.Dir, err)
+		}
+		sort.Strings(matches)
+		paths = append(paths, matches...)
+	}
+	return append(paths, cfg.Paths...), nil
+}
+
+// LoadAll loads every plugin discovered from cfg into registry
+func LoadAll(cfg config.PluginConfig, registry *strategy.Registry, log *logger.Logger) error {
+	paths, err := Discover(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		before := len(registry.Names())
+		if err := Load(path, registry); err != nil {
+			return err
+		}
+		log.Info("Loaded plugin %s (%d strategy types)", path, len(registry.Names())-before)
+	}
+	return nil
+}
@@ -0,0 +1,436 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrAllocationExceeded is returned when an order does not fit a strategy's capital account
+var ErrAllocationExceeded = errors.New("order exceeds strategy allocation")
+
+// CapitalAccount is a strategy's virtual balance within a shared exchange account
+type CapitalAccount struct {
+	StrategyID string
+	Allocated  float64            // quote capital assigned to the strategy
+	Available  float64            // quote capital not yet spent or reserved
+	Reserved   float64            // quote held by buys still working
+	Holdings   map[string]float64 // base quantity bought per symbol, less sells still working
+	Spent      float64
+	Proceeds   float64
+	LastUpdate time.Time
+
+	working map[string]types.Order // reserved orders by client order id
+}
+
+// CapitalAllocator assigns virtual balances to strategies and enforces them on orders
+type CapitalAllocator struct {
+	logger *logger.Logger
+
+	mu       sync.RWMutex
+	accounts map[string]*CapitalAccount
+	seq      atomic.Int64
+}
+
+// NewCapitalAllocator creates an empty capital allocator
+func NewCapitalAllocator(logger *logger.Logger) *CapitalAllocator {
+	return &CapitalAllocator{
+		logger:   logger,
+		accounts: make(map[string]*CapitalAccount),
+	}
+}
+
+// Allocate assigns (or tops up) quote capital for a strategy
+func (a *CapitalAllocator) Allocate(strategyID string, amount float64) error {
+	if strategyID == "" {
+		return fmt.Errorf("strategy id is required")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("allocation must be positive")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	account, exists := a.accounts[strategyID]
+	if !exists {
+		account = &CapitalAccount{
+			StrategyID: strategyID,
+			Holdings:   make(map[string]float64),
+			working:    make(map[string]types.Order),
+		}
+		a.accounts[strategyID] = account
+	}
+
+	account.Allocated += amount
+	account.Available += amount
+	account.LastUpdate = time.Now()
+
+	a.logger.Info("Capital allocated: %s +%.2f (available %.2f)", strategyID, amount, account.Available)
+	return nil
+}
+
+// Resize sets a strategy's allocation, moving the difference into or out of
+// its available capital. Available never drops below zero; capital already
+// spent is released by sells.
+func (a *CapitalAllocator) Resize(strategyID string, allocated float64) error {
+	if allocated <= 0 {
+		return fmt.Errorf("allocation must be positive")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	account, exists := a.accounts[strategyID]
+	if !exists {
+		return fmt.Errorf("no capital account for %s", strategyID)
+	}
+
+	account.Available = math.Max(account.Available+allocated-account.Allocated, 0)
+	account.Allocated = allocated
+	account.LastUpdate = time.Now()
+	return nil
+}
+
+// Account returns a snapshot of a strategy's capital account
+func (a *CapitalAllocator) Account(strategyID string) (CapitalAccount, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	account, exists := a.accounts[strategyID]
+	if !exists {
+		return CapitalAccount{}, false
+	}
+	return account.snapshot(), true
+}
+
+// Accounts returns snapshots of all capital accounts sorted by strategy id
+func (a *CapitalAllocator) Accounts() []CapitalAccount {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	accounts := make([]CapitalAccount, 0, len(a.accounts))
+	for _, account := range a.accounts {
+		accounts = append(accounts, account.snapshot())
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].StrategyID < accounts[j].StrategyID
+	})
+	return accounts
+}
+
+// CheckOrder verifies that an order fits the strategy's virtual balance
+func (a *CapitalAllocator) CheckOrder(strategyID string, order types.Order) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	account, exists := a.accounts[strategyID]
+	if !exists {
+		return fmt.Errorf("%w: no capital account for %s", ErrAllocationExceeded, strategyID)
+	}
+
+	switch order.Side {
+	case types.OrderSideBuy:
+		cost := order.Quantity * order.Price
+		if cost > account.Available {
+			return fmt.Errorf("%w: %s needs %.2f, available %.2f", ErrAllocationExceeded, strategyID, cost, account.Available)
+		}
+	case types.OrderSideSell:
+		held := account.Holdings[order.Symbol]
+		if order.Quantity > held {
+			return fmt.Errorf("%w: %s sells %.8f %s, holds %.8f", ErrAllocationExceeded, strategyID, order.Quantity, order.Symbol, held)
+		}
+	}
+
+	return nil
+}
+
+// ApplyFill debits or credits a strategy's account for an executed order's
+// fill. An order reported filled without an amount filled its quantity.
+func (a *CapitalAllocator) ApplyFill(strategyID string, order types.Order) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if account, exists := a.accounts[strategyID]; exists {
+		account.applyFill(order)
+	}
+}
+
+// Reserve holds a placed order's cost (buys) or quantity (sells) in the
+// strategy's account until the exchange reports it final; Reconcile then
+// books its fill and releases the rest. Orders without a client order id
+// cannot be looked up and are not reserved.
+func (a *CapitalAllocator) Reserve(strategyID string, order types.Order) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	account, exists := a.accounts[strategyID]
+	if !exists || order.ExchangeOrder == nil || order.ExchangeOrder.ClientOrderID == "" {
+		return
+	}
+	account.working[order.ExchangeOrder.ClientOrderID] = order
+	switch order.Side {
+	case types.OrderSideBuy:
+		account.Available -= order.Quantity * order.Price
+		account.Reserved += order.Quantity * order.Price
+	case types.OrderSideSell:
+		account.Holdings[order.Symbol] -= order.Quantity
+	}
+	account.LastUpdate = time.Now()
+}
+
+// Reconcile looks the strategy's reserved orders up on exchange and books
+// those it reports final
+func (a *CapitalAllocator) Reconcile(ctx context.Context, strategyID string, exchange types.ExchangeClient) error {
+	a.mu.RLock()
+	var working []types.Order
+	if account, exists := a.accounts[strategyID]; exists {
+		for _, order := range account.working {
+			working = append(working, order)
+		}
+	}
+	a.mu.RUnlock()
+
+	for _, order := range working {
+		id := order.ExchangeOrder.ClientOrderID
+		report, open, err := lookupOrder(ctx, exchange, order.Symbol, id)
+		if err := a.settle(strategyID, id, report, open, err); err != nil {
+			return fmt.Errorf("failed to get order %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// lookupOrder gets an order by client order id and whether it is still
+// working. Some exchanges leave a final partial fill partially filled; such
+// an order is working only while open. The order is returned even when
+// listing the open orders fails.
+func lookupOrder(ctx context.Context, exchange types.ExchangeClient, symbol, clientOrderID string) (*types.Order, bool, error) {
+	report, err := exchange.GetOrderByClientID(ctx, symbol, clientOrderID)
+	if err != nil {
+		return nil, false, err
+	}
+	switch report.Status {
+	case types.OrderStatusNew:
+		return report, true, nil
+	case types.OrderStatusPartiallyFilled:
+		active, err := exchange.GetActiveOrders(ctx, symbol)
+		if err != nil && !errors.Is(err, types.ErrNotSupported) {
+			return report, true, err
+		}
+		for _, order := range active {
+			if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID == clientOrderID {
+				return report, true, nil
+			}
+		}
+	}
+	return report, false, nil
+}
+
+// settle books the exchange's answer to a lookup of a reserved order once
+// the order is no longer working: its fill, with the unfilled rest released.
+// Exchanges that cannot look orders up are taken to have filled them in full;
+// an order the exchange does not know filled nothing. Lookup errors are
+// returned and keep the reservation.
+func (a *CapitalAllocator) settle(strategyID, clientOrderID string, report *types.Order, working bool, err error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	account, exists := a.accounts[strategyID]
+	if !exists {
+		return nil
+	}
+	order, reserved := account.working[clientOrderID]
+	if !reserved {
+		return nil
+	}
+
+	fill := order
+	switch {
+	case errors.Is(err, types.ErrNotSupported):
+		fill.Status, fill.FilledAmount, fill.FilledPrice = types.OrderStatusFilled, order.Quantity, order.Price
+	case errors.Is(err, types.ErrOrderNotFound):
+		fill.Status, fill.FilledAmount = types.OrderStatusRejected, 0
+	case err != nil:
+		return err
+	case working:
+		return nil
+	default:
+		fill.Status, fill.FilledAmount, fill.FilledPrice = report.Status, report.FilledAmount, report.FilledPrice
+	}
+
+	delete(account.working, clientOrderID)
+	switch order.Side {
+	case types.OrderSideBuy:
+		account.Available += order.Quantity * order.Price
+		account.Reserved -= order.Quantity * order.Price
+	case types.OrderSideSell:
+		account.Holdings[order.Symbol] += order.Quantity
+	}
+	account.applyFill(fill)
+	return nil
+}
+
+// applyFill books an order's fill
+func (account *CapitalAccount) applyFill(order types.Order) {
+	quantity := order.FilledAmount
+	price := order.FilledPrice
+	if quantity == 0 && order.Status == types.OrderStatusFilled {
+		quantity = order.Quantity
+	}
+	if quantity <= 0 {
+		account.LastUpdate = time.Now()
+		return
+	}
+	if price == 0 {
+		price = order.Price
+	}
+	notional := quantity * price
+
+	switch order.Side {
+	case types.OrderSideBuy:
+		account.Available -= notional
+		account.Spent += notional
+		account.Holdings[order.Symbol] += quantity
+	case types.OrderSideSell:
+		account.Available += notional
+		account.Proceeds += notional
+		account.Holdings[order.Symbol] -= quantity
+		if account.Holdings[order.Symbol] <= 0 {
+			delete(account.Holdings, order.Symbol)
+		}
+	}
+	account.LastUpdate = time.Now()
+}
+
+// Client wraps an exchange client so orders are checked and booked against a strategy's account
+func (a *CapitalAllocator) Client(strategyID string, exchange types.ExchangeClient) types.ExchangeClient {
+	return &allocatedClient{
+		ExchangeClient: exchange,
+		allocator:      a,
+		strategyID:     strategyID,
+	}
+}
+
+// snapshot copies the account including holdings
+func (c *CapitalAccount) snapshot() CapitalAccount {
+	out := *c
+	out.working = nil
+	out.Holdings = make(map[string]float64, len(c.Holdings))
+	for symbol, qty := range c.Holdings {
+		out.Holdings[symbol] = qty
+	}
+	return out
+}
+
+// allocatedClient enforces a capital account on PlaceOrder
+type allocatedClient struct {
+	types.ExchangeClient
+	allocator  *CapitalAllocator
+	strategyID string
+}
+
+// PlaceOrder rejects orders exceeding the allocation and reserves accepted
+// ones, booking what the exchange reports filled once they are final
+func (c *allocatedClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	if err := c.allocator.Reconcile(ctx, c.strategyID, c.ExchangeClient); err != nil {
+		c.allocator.logger.Warn("Capital account of %s not reconciled: %v", c.strategyID, err)
+	}
+	if err := c.allocator.CheckOrder(c.strategyID, order); err != nil {
+		return err
+	}
+
+	if order.ExchangeOrder == nil || order.ExchangeOrder.ClientOrderID == "" {
+		exchangeOrder := types.ExchangeOrder{}
+		if order.ExchangeOrder != nil {
+			exchangeOrder = *order.ExchangeOrder
+		}
+		exchangeOrder.ClientOrderID = types.NewClientOrderID(c.strategyID, "a", c.allocator.seq.Add(1))
+		order.ExchangeOrder = &exchangeOrder
+	}
+	clientOrderID := order.ExchangeOrder.ClientOrderID
+
+	c.allocator.Reserve(c.strategyID, order)
+	if err := c.ExchangeClient.PlaceOrder(ctx, order); err != nil {
+		_ = c.allocator.settle(c.strategyID, clientOrderID, nil, false, types.ErrOrderNotFound)
+		return err
+	}
+
+	report, open, err := lookupOrder(ctx, c.ExchangeClient, order.Symbol, clientOrderID)
+	if err := c.allocator.settle(c.strategyID, clientOrderID, report, open, err); err != nil {
+		c.allocator.logger.Warn("Order %s of %s not reported yet: %v", clientOrderID, c.strategyID, err)
+	}
+	return nil
+}
+
+// GetOrderByClientID books the fill of a reserved order no longer working
+func (c *allocatedClient) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	report, open, err := lookupOrder(ctx, c.ExchangeClient, symbol, clientOrderID)
+	_ = c.allocator.settle(c.strategyID, clientOrderID, report, open, err)
+	if report != nil {
+		return report, nil
+	}
+	return nil, err
+}
+
+// CancelOrder releases what canceled orders left unfilled
+func (c *allocatedClient) CancelOrder(ctx context.Context, orderID string) error {
+	if err := c.ExchangeClient.CancelOrder(ctx, orderID); err != nil {
+		return err
+	}
+	if err := c.allocator.Reconcile(ctx, c.strategyID, c.ExchangeClient); err != nil {
+		c.allocator.logger.Warn("Capital account of %s not reconciled: %v", c.strategyID, err)
+	}
+	return nil
+}
+
+// GetBalance reports the strategy's virtual quote balance instead of the shared account
+func (c *allocatedClient) GetBalance(ctx context.Context) (*types.Balance, error) {
+	balance, err := c.ExchangeClient.GetBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	account, exists := c.allocator.Account(c.strategyID)
+	if !exists {
+		return balance, nil
+	}
+
+	virtual := *balance
+	virtual.Free = account.Available
+	if virtual.Free > balance.Free {
+		virtual.Free = balance.Free
+	}
+	virtual.Locked = 0
+	virtual.Total = virtual.Free
+	return &virtual, nil
+}
+
+// GetBalances reports the shared account with the quote asset replaced by the virtual balance
+func (c *allocatedClient) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	balances, err := c.ExchangeClient.GetBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := c.GetBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]types.Balance, 0, len(balances))
+	for _, balance := range balances {
+		if balance.Asset == quote.Asset {
+			balance = *quote
+		}
+		out = append(out, balance)
+	}
+	return out, nil
+}
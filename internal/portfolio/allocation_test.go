@@ -0,0 +1,105 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/sim"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestCapitalAllocator_Client(t *testing.T) {
+	allocator := NewCapitalAllocator(logger.New(logger.LevelError))
+	if err := allocator.Allocate("dca_0", 150); err != nil {
+		t.Fatalf("Allocate() error = %v", err)
+	}
+
+	client := allocator.Client("dca_0", mock.NewMockClient())
+	ctx := context.Background()
+	buy := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.002, Price: 50000}
+
+	if err := client.PlaceOrder(ctx, buy); err != nil {
+		t.Fatalf("First buy should fit allocation: %v", err)
+	}
+
+	err := client.PlaceOrder(ctx, buy)
+	if !errors.Is(err, ErrAllocationExceeded) {
+		t.Fatalf("Expected ErrAllocationExceeded, got %v", err)
+	}
+
+	sell := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: 0.003, Price: 50000}
+	if err := client.PlaceOrder(ctx, sell); !errors.Is(err, ErrAllocationExceeded) {
+		t.Fatalf("Expected sell beyond holdings to be blocked, got %v", err)
+	}
+
+	sell.Quantity = 0.002
+	if err := client.PlaceOrder(ctx, sell); err != nil {
+		t.Fatalf("Sell of own holdings should succeed: %v", err)
+	}
+
+	account, ok := allocator.Account("dca_0")
+	if !ok {
+		t.Fatal("Expected account to exist")
+	}
+	if account.Available != 150 {
+		t.Errorf("Expected available 150 after round trip, got %f", account.Available)
+	}
+	if len(account.Holdings) != 0 {
+		t.Errorf("Expected no holdings, got %v", account.Holdings)
+	}
+
+	balance, err := client.GetBalance(ctx)
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	if balance.Free != 150 {
+		t.Errorf("Expected virtual free balance 150, got %f", balance.Free)
+	}
+}
+
+func TestCapitalAllocator_BooksFills(t *testing.T) {
+	allocator := NewCapitalAllocator(logger.New(logger.LevelError))
+	if err := allocator.Allocate("grid_0", 1000); err != nil {
+		t.Fatal(err)
+	}
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{100, 100}, FillDelay: 1, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := allocator.Client("grid_0", ex)
+	ctx := context.Background()
+	buy := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeLimit, Quantity: 5, Price: 100}
+
+	// A working order holds its cost without spending it
+	if err := client.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	if account, _ := allocator.Account("grid_0"); account.Available != 500 || account.Reserved != 500 || account.Spent != 0 {
+		t.Fatalf("account with a working buy = %+v, want 500 reserved", account)
+	}
+
+	// Canceling it releases the cost
+	if err := client.CancelOrder(ctx, ex.Orders()[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if account, _ := allocator.Account("grid_0"); account.Available != 1000 || account.Reserved != 0 || len(account.Holdings) != 0 {
+		t.Fatalf("account after the cancel = %+v, want everything released", account)
+	}
+
+	// Half of the next buy fills; the rest is released
+	ex, err = sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{100}, FillRatio: 0.5, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = allocator.Client("grid_0", ex)
+	if err := client.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	account, _ := allocator.Account("grid_0")
+	if account.Available != 750 || account.Reserved != 0 || account.Spent != 250 || account.Holdings["BTCUSDT"] != 2.5 {
+		t.Fatalf("account after a half fill = %+v, want 250 spent on 2.5", account)
+	}
+}
@@ -0,0 +1,118 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+)
+
+// WeightConstraints keep optimized weights implementable: each asset's
+// weight stays within [Min, Max] and a rebalance moves at most MaxTurnover of
+// the capital. Nil bounds and a zero turnover impose nothing; a zero Max is
+// read as 1.
+type WeightConstraints struct {
+	Min         []float64
+	Max         []float64
+	MaxTurnover float64
+}
+
+// Validate checks the constraints of n assets can all be met by weights
+// summing to 1
+func (c WeightConstraints) Validate(n int) error {
+	if c.Min != nil && len(c.Min) != n || c.Max != nil && len(c.Max) != n {
+		return fmt.Errorf("weight bounds need one entry per asset")
+	}
+	var minTotal, maxTotal float64
+	for i := 0; i < n; i++ {
+		lo, hi := c.bounds(i)
+		if lo < 0 || hi > 1 || lo > hi {
+			return fmt.Errorf("asset %d: weight bounds [%.2f, %.2f] must lie within [0, 1]", i, lo, hi)
+		}
+		minTotal += lo
+		maxTotal += hi
+	}
+	if minTotal > 1+1e-9 {
+		return fmt.Errorf("minimum weights sum to %.2f, more than 1", minTotal)
+	}
+	if maxTotal < 1-1e-9 {
+		return fmt.Errorf("maximum weights sum to %.2f, less than 1", maxTotal)
+	}
+	if c.MaxTurnover < 0 || c.MaxTurnover > 1 {
+		return fmt.Errorf("max turnover must be in [0, 1]")
+	}
+	return nil
+}
+
+// bounds returns asset i's minimum and maximum weight
+func (c WeightConstraints) bounds(i int) (float64, float64) {
+	lo, hi := 0.0, 1.0
+	if c.Min != nil {
+		lo = c.Min[i]
+	}
+	if c.Max != nil && c.Max[i] > 0 {
+		hi = c.Max[i]
+	}
+	return lo, hi
+}
+
+// Apply returns the weights to rebalance to from current towards target:
+// the weights within the bounds closest to target, cut back towards current
+// so no more than MaxTurnover of the capital changes hands. Weights outside
+// the bounds at the start move into them at that pace.
+func (c WeightConstraints) Apply(current, target []float64) []float64 {
+	bounded := c.bound(target)
+	if c.MaxTurnover <= 0 || len(current) != len(bounded) {
+		return bounded
+	}
+	turnover := Turnover(current, bounded)
+	if turnover <= c.MaxTurnover {
+		return bounded
+	}
+	step := c.MaxTurnover / turnover
+	weights := make([]float64, len(bounded))
+	for i := range weights {
+		weights[i] = current[i] + (bounded[i]-current[i])*step
+	}
+	return weights
+}
+
+// bound returns the weights within the bounds closest to target, the
+// Euclidean projection: target shifted by one amount and clipped, with the
+// shift found by bisection so the weights sum to 1
+func (c WeightConstraints) bound(target []float64) []float64 {
+	weights := make([]float64, len(target))
+	shifted := func(shift float64) float64 {
+		var total float64
+		for i, t := range target {
+			lo, hi := c.bounds(i)
+			weights[i] = math.Min(math.Max(t+shift, lo), hi)
+			total += weights[i]
+		}
+		return total
+	}
+	// At -1 every weight sits at its minimum and at 1 at its maximum
+	low, high := -1.0, 1.0
+	for iter := 0; iter < 100; iter++ {
+		mid := (low + high) / 2
+		if shifted(mid) < 1 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	shifted(high)
+	return weights
+}
+
+// Turnover returns the share of capital moved going from current to target
+// weights: half the sum of the absolute weight changes
+func Turnover(current, target []float64) float64 {
+	var moved float64
+	for i := range target {
+		var from float64
+		if i < len(current) {
+			from = current[i]
+		}
+		moved += math.Abs(target[i] - from)
+	}
+	return moved / 2
+}
@@ -0,0 +1,51 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightConstraints_Apply(t *testing.T) {
+	// Capping the first asset shifts its excess equally onto the others
+	c := WeightConstraints{Max: []float64{0.5, 0, 0}, Min: []float64{0, 0, 0.1}}
+	weights := c.Apply(nil, []float64{0.8, 0.2, 0})
+	want := []float64{0.5, 0.35, 0.15}
+	for i := range want {
+		if math.Abs(weights[i]-want[i]) > 1e-9 {
+			t.Fatalf("Expected bounded weights %v, got %v", want, weights)
+		}
+	}
+
+	// Weights already within the bounds are kept
+	weights = WeightConstraints{}.Apply(nil, []float64{0.3, 0.7})
+	if math.Abs(weights[0]-0.3) > 1e-9 || math.Abs(weights[1]-0.7) > 1e-9 {
+		t.Errorf("Expected unconstrained weights unchanged, got %v", weights)
+	}
+
+	// A turnover cap moves only part of the way
+	c = WeightConstraints{MaxTurnover: 0.1}
+	weights = c.Apply([]float64{0.5, 0.5}, []float64{0.9, 0.1})
+	if math.Abs(weights[0]-0.6) > 1e-9 || math.Abs(weights[1]-0.4) > 1e-9 {
+		t.Errorf("Expected weights [0.6 0.4] after a 0.1 turnover, got %v", weights)
+	}
+	if got := Turnover([]float64{0.5, 0.5}, weights); math.Abs(got-0.1) > 1e-9 {
+		t.Errorf("Turnover = %v, want 0.1", got)
+	}
+}
+
+func TestWeightConstraints_Validate(t *testing.T) {
+	for name, c := range map[string]WeightConstraints{
+		"length":          {Min: []float64{0.1}},
+		"min above max":   {Min: []float64{0.6, 0}, Max: []float64{0.5, 0}},
+		"mins above one":  {Min: []float64{0.6, 0.6}},
+		"maxes below one": {Max: []float64{0.4, 0.4}},
+		"turnover":        {MaxTurnover: 1.5},
+	} {
+		if err := c.Validate(2); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := (WeightConstraints{Min: []float64{0.2, 0.2}, Max: []float64{0.8, 0}, MaxTurnover: 0.2}).Validate(2); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// DustConfig configures the conversion of balances too small to trade
+type DustConfig struct {
+	Convert    bool          `json:"convert"`     // convert dust on exchanges that support it, e.g. into BNB on Binance
+	Interval   time.Duration `json:"interval"`    // between conversions; defaults to 24h
+	QuoteAsset string        `json:"quote_asset"` // pairs are <asset><quote>; defaults to USDT
+}
+
+// UnmarshalJSON implements custom parsing for durations ("6h", "24h")
+func (c *DustConfig) UnmarshalJSON(data []byte) error {
+	type Alias DustConfig
+	aux := &struct {
+		Interval string `json:"interval"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Interval != "" {
+		d, err := time.ParseDuration(aux.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		c.Interval = d
+	}
+	return nil
+}
+
+// Validate checks the config
+func (c DustConfig) Validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+	return nil
+}
+
+// DustBalance is a holding too small to sell
+type DustBalance struct {
+	Asset    string
+	Symbol   string
+	Quantity float64
+	Price    float64
+	Value    float64 // in the quote asset
+}
+
+// FindDust lists the balances too small to sell against quote. Assets the
+// exchange reports no order size rules or price for are left out.
+func (m *Manager) FindDust(ctx context.Context, quote string) ([]DustBalance, error) {
+	if quote == "" {
+		quote = "USDT"
+	}
+	balances, err := m.exchange.GetBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	var found []DustBalance
+	for _, balance := range balances {
+		if balance.Asset == quote || balance.Total <= 0 {
+			continue
+		}
+		symbol := balance.Asset + quote
+		price, isDust, err := m.isDust(ctx, symbol, balance.Total)
+		if err != nil {
+			m.logger.Debug("Cannot tell whether %s is dust: %v", balance.Asset, err)
+			continue
+		}
+		if isDust {
+			found = append(found, DustBalance{Asset: balance.Asset, Symbol: symbol, Quantity: balance.Total, Price: price, Value: balance.Total * price})
+		}
+	}
+	return found, nil
+}
+
+// isDust reports whether quantity of symbol is too small to sell, and at what price
+func (m *Manager) isDust(ctx context.Context, symbol string, quantity float64) (float64, bool, error) {
+	rules, err := m.exchange.GetSymbolRules(ctx, symbol)
+	if errors.Is(err, types.ErrNotSupported) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	ticker, err := m.exchange.GetTicker(ctx, symbol)
+	if err != nil {
+		return 0, false, err
+	}
+	return ticker.Price, rules.IsDust(quantity, ticker.Price), nil
+}
+
+// ConvertDust converts the dust balances on the exchange and drops their
+// positions. It returns nil when there is no dust.
+func (m *Manager) ConvertDust(ctx context.Context, quote string) (*types.DustConversion, error) {
+	found, err := m.FindDust(ctx, quote)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	assets := make([]string, 0, len(found))
+	for _, d := range found {
+		assets = append(assets, d.Asset)
+	}
+	conversion, err := m.exchange.ConvertDust(ctx, assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert dust: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range found {
+		if _, ok := conversion.Converted[d.Asset]; ok {
+			delete(m.positions, d.Symbol)
+		}
+	}
+	m.updatePortfolioMetrics()
+	return conversion, nil
+}
+
+// RunDustConversion converts dust every cfg.Interval until ctx is done.
+// It stops early on exchanges without dust conversion.
+func (m *Manager) RunDustConversion(ctx context.Context, cfg DustConfig) {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		conversion, err := m.ConvertDust(ctx, cfg.QuoteAsset)
+		switch {
+		case errors.Is(err, types.ErrNotSupported):
+			m.logger.Info("Dust conversion stopped: %v", err)
+			return
+		case err != nil:
+			m.logger.Error("Dust conversion error: %v", err)
+		case conversion != nil:
+			m.logger.Info("Converted dust of %d asset(s) into %.8f %s (fee %.8f)",
+				len(conversion.Converted), conversion.Received, conversion.Asset, conversion.Fee)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// dustClient adds order size rules and dust conversion to holdingsClient
+type dustClient struct {
+	*holdingsClient
+	converted []string
+}
+
+func (d *dustClient) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	return &types.SymbolRules{Symbol: symbol, StepSize: 0.0001, MinQty: 0.0001, MinNotional: 10}, nil
+}
+
+func (d *dustClient) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	d.converted = append(d.converted, assets...)
+	converted := make(map[string]float64, len(assets))
+	for _, asset := range assets {
+		converted[asset] = 0.0001
+	}
+	return &types.DustConversion{Asset: "BNB", Converted: converted, Received: 0.01, Timestamp: time.Now()}, nil
+}
+
+func TestManager_Dust(t *testing.T) {
+	// The mock prices everything at 45000: 0.0001 BTC is 4.5 USDT, under the 10 minimum
+	client := &dustClient{holdingsClient: &holdingsClient{
+		MockClient: mock.NewMockClient(),
+		balances: []types.Balance{
+			{Asset: "USDT", Total: 1000},
+			{Asset: "BTC", Total: 0.0001},
+			{Asset: "ETH", Total: 2},
+		},
+	}}
+	manager := NewManager(client, logger.New(logger.LevelError))
+	ctx := context.Background()
+
+	dust, err := manager.FindDust(ctx, "")
+	if err != nil {
+		t.Fatalf("FindDust() error = %v", err)
+	}
+	if len(dust) != 1 || dust[0].Symbol != "BTCUSDT" || dust[0].Value != 4.5 {
+		t.Fatalf("FindDust() = %+v, want the BTC balance", dust)
+	}
+
+	// Dust is not imported as a tradable position
+	imported, err := manager.ImportHoldings(ctx, ImportConfig{})
+	if err != nil {
+		t.Fatalf("ImportHoldings() error = %v", err)
+	}
+	if len(imported) != 1 || imported[0].Asset != "ETH" {
+		t.Fatalf("ImportHoldings() = %+v, want only ETH", imported)
+	}
+
+	manager.positions["BTCUSDT"] = &types.Position{Symbol: "BTCUSDT", Quantity: 0.0001, AvgPrice: 40000}
+	conversion, err := manager.ConvertDust(ctx, "USDT")
+	if err != nil {
+		t.Fatalf("ConvertDust() error = %v", err)
+	}
+	if conversion == nil || conversion.Received != 0.01 || strings.Join(client.converted, ",") != "BTC" {
+		t.Fatalf("ConvertDust() = %+v converting %v, want BTC converted", conversion, client.converted)
+	}
+	if _, ok := manager.GetPosition("BTCUSDT"); ok {
+		t.Error("Converted dust should leave the positions")
+	}
+	if _, ok := manager.GetPosition("ETHUSDT"); !ok {
+		t.Error("ETH position should be kept")
+	}
+}
+
+func TestDustConfig(t *testing.T) {
+	var cfg DustConfig
+	if err := json.Unmarshal([]byte(`{"convert":true,"interval":"6h","quote_asset":"FDUSD"}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Convert || cfg.Interval != 6*time.Hour || cfg.QuoteAsset != "FDUSD" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if err := (DustConfig{Interval: -time.Hour}).Validate(); err == nil {
+		t.Error("Expected an error for a negative interval")
+	}
+}
@@ -0,0 +1,184 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// CostBasis is a user-supplied entry for coins bought outside the bot
+type CostBasis struct {
+	Price    float64   `json:"price"`              // average price per unit in the quote asset
+	Acquired time.Time `json:"acquired,omitempty"` // acquisition date for holding periods
+}
+
+// ImportConfig configures the startup import of existing exchange holdings
+type ImportConfig struct {
+	Enabled    bool                 `json:"enabled"`
+	QuoteAsset string               `json:"quote_asset"` // pairs are <asset><quote>; defaults to USDT
+	CostBasis  map[string]CostBasis `json:"cost_basis"`  // by asset, takes precedence over fills
+}
+
+// Cost basis sources of an imported position
+const (
+	BasisUser   = "user"   // from ImportConfig.CostBasis
+	BasisFills  = "fills"  // replayed from the exchange's filled orders
+	BasisMarket = "market" // no history; valued at the current price
+	BasisMixed  = "mixed"  // fills for part of the holding, market price for the rest
+)
+
+// ImportedPosition reports how one holding was imported
+type ImportedPosition struct {
+	Asset     string
+	Symbol    string
+	Quantity  float64
+	AvgPrice  float64
+	Realized  float64 // PnL realized by sells in the replayed fills
+	Source    string  // where the cost basis came from
+	FromFills float64 // quantity explained by fill history
+}
+
+// ImportHoldings seeds positions from the exchange balances so PnL is correct
+// for coins held before the bot started. The cost basis of each asset comes
+// from cfg.CostBasis, else from replaying the pair's filled orders with the
+// average cost method; any quantity the history does not explain is valued at
+// the current price. Holdings too small to sell are dust and left out.
+// Existing positions for imported symbols are replaced.
+func (m *Manager) ImportHoldings(ctx context.Context, cfg ImportConfig) ([]ImportedPosition, error) {
+	quote := cfg.QuoteAsset
+	if quote == "" {
+		quote = "USDT"
+	}
+
+	balances, err := m.exchange.GetBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	var imported []ImportedPosition
+	positions := make(map[string]*types.Position)
+	for _, balance := range balances {
+		if balance.Asset == quote || balance.Total <= 0 {
+			continue
+		}
+		if _, isDust, err := m.isDust(ctx, balance.Asset+quote, balance.Total); err == nil && isDust {
+			m.logger.Info("Skipping %s holding: %.8f is below the exchange minimum", balance.Asset, balance.Total)
+			continue
+		}
+
+		position, report, err := m.importHolding(ctx, balance, quote, cfg.CostBasis)
+		if err != nil {
+			m.logger.Warn("Skipping %s holding: %v", balance.Asset, err)
+			continue
+		}
+		positions[position.Symbol] = position
+		imported = append(imported, report)
+		m.logger.Info("Imported %s: %.8f @ %.2f (%s)", report.Symbol, report.Quantity, report.AvgPrice, report.Source)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for symbol, position := range positions {
+		m.positions[symbol] = position
+	}
+	m.updatePortfolioMetrics()
+	return imported, nil
+}
+
+// importHolding builds the position for one asset balance
+func (m *Manager) importHolding(ctx context.Context, balance types.Balance, quote string, basis map[string]CostBasis) (*types.Position, ImportedPosition, error) {
+	symbol := balance.Asset + quote
+	report := ImportedPosition{Asset: balance.Asset, Symbol: symbol, Quantity: balance.Total}
+	position := &types.Position{Symbol: symbol, Quantity: balance.Total, Timestamp: time.Now()}
+
+	if user, ok := basis[balance.Asset]; ok {
+		position.AvgPrice = user.Price
+		if !user.Acquired.IsZero() {
+			position.Timestamp = user.Acquired
+		}
+		report.AvgPrice, report.Source = user.Price, BasisUser
+		return position, report, nil
+	}
+
+	fills, err := types.FilledOrders(ctx, m.exchange, types.OrderHistoryQuery{Symbol: symbol})
+	if err != nil {
+		m.logger.Warn("No order history for %s: %v", symbol, err)
+	}
+	replay := replayFills(fills)
+	position.RealizedPnL = replay.realized
+	report.Realized = replay.realized
+	if !replay.firstBuy.IsZero() {
+		position.Timestamp = replay.firstBuy
+	}
+
+	// History explains the whole holding (extra coins were withdrawn or spent)
+	fromFills := min(replay.quantity, balance.Total)
+	report.FromFills = fromFills
+	if fromFills >= balance.Total {
+		position.AvgPrice = replay.avgPrice
+		report.AvgPrice, report.Source = replay.avgPrice, BasisFills
+		return position, report, nil
+	}
+
+	ticker, err := m.exchange.GetTicker(ctx, symbol)
+	if err != nil {
+		return nil, report, fmt.Errorf("failed to price %s: %w", symbol, err)
+	}
+	rest := balance.Total - fromFills
+	position.AvgPrice = (replay.avgPrice*fromFills + ticker.Price*rest) / balance.Total
+	position.CurrentPrice = ticker.Price
+	report.AvgPrice, report.Source = position.AvgPrice, BasisMarket
+	if fromFills > 0 {
+		report.Source = BasisMixed
+	}
+	return position, report, nil
+}
+
+// fillReplay is the average-cost position implied by a fill history
+type fillReplay struct {
+	quantity float64
+	avgPrice float64
+	realized float64
+	firstBuy time.Time
+}
+
+// replayFills applies fills oldest first. Sells beyond the replayed quantity,
+// e.g. of coins bought before the history starts, only flatten the position.
+func replayFills(fills []types.Order) fillReplay {
+	sorted := append([]types.Order(nil), fills...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	var r fillReplay
+	for _, fill := range sorted {
+		quantity, price := fill.Quantity, fill.Price
+		if fill.FilledAmount > 0 {
+			quantity = fill.FilledAmount
+		}
+		if fill.FilledPrice > 0 {
+			price = fill.FilledPrice
+		}
+		if quantity <= 0 {
+			continue
+		}
+
+		switch fill.Side {
+		case types.OrderSideBuy:
+			r.avgPrice = (r.avgPrice*r.quantity + price*quantity) / (r.quantity + quantity)
+			r.quantity += quantity
+			if r.firstBuy.IsZero() {
+				r.firstBuy = fill.Timestamp
+			}
+		case types.OrderSideSell:
+			sold := min(quantity, r.quantity)
+			r.realized += (price - r.avgPrice) * sold
+			r.quantity -= sold
+			if r.quantity <= 1e-12 {
+				r.quantity, r.avgPrice, r.firstBuy = 0, 0, time.Time{}
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,98 @@
+package portfolio
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// holdingsClient adds scripted balances and fills to the mock exchange
+type holdingsClient struct {
+	*mock.MockClient
+	balances []types.Balance
+	fills    map[string][]types.Order
+}
+
+func (h *holdingsClient) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	return h.balances, nil
+}
+
+func (h *holdingsClient) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return h.fills[symbol], nil
+}
+
+// GetOrderHistory pages the fills two at a time
+func (h *holdingsClient) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	fills := h.fills[query.Symbol]
+	query.Symbol, query.Limit = "", 2 // fills are kept by symbol
+	return types.PageOrders(fills, query)
+}
+
+func fill(side types.OrderSide, quantity, price float64, at time.Time) types.Order {
+	return types.Order{Side: side, Quantity: quantity, Price: price, Status: types.OrderStatusFilled, Timestamp: at}
+}
+
+func TestManager_ImportHoldings(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	acquired := day.AddDate(-2, 0, 0)
+	client := &holdingsClient{
+		MockClient: mock.NewMockClient(),
+		balances: []types.Balance{
+			{Asset: "USDT", Total: 1000},
+			{Asset: "BTC", Total: 0.15},
+			{Asset: "ETH", Total: 2},
+			{Asset: "SOL", Total: 10},
+		},
+		fills: map[string][]types.Order{
+			// Listed out of order; replayed oldest first
+			"BTCUSDT": {
+				fill(types.OrderSideSell, 0.05, 48000, day.Add(2*time.Hour)),
+				fill(types.OrderSideBuy, 0.1, 40000, day),
+				fill(types.OrderSideBuy, 0.1, 50000, day.Add(time.Hour)),
+			},
+			"SOLUSDT": {fill(types.OrderSideBuy, 5, 100, day)},
+		},
+	}
+	manager := NewManager(client, logger.New(logger.LevelError))
+
+	imported, err := manager.ImportHoldings(context.Background(), ImportConfig{
+		CostBasis: map[string]CostBasis{"ETH": {Price: 1500, Acquired: acquired}},
+	})
+	if err != nil {
+		t.Fatalf("ImportHoldings() error = %v", err)
+	}
+	if len(imported) != 3 {
+		t.Fatalf("Expected 3 imported holdings, got %+v", imported)
+	}
+
+	btc, ok := manager.GetPosition("BTCUSDT")
+	if !ok || btc.AvgPrice != 45000 || math.Abs(btc.RealizedPnL-150) > 1e-9 || !btc.Timestamp.Equal(day) {
+		t.Errorf("Unexpected BTC position %+v", btc)
+	}
+
+	eth, _ := manager.GetPosition("ETHUSDT")
+	if eth.Quantity != 2 || eth.AvgPrice != 1500 || !eth.Timestamp.Equal(acquired) {
+		t.Errorf("Unexpected ETH position %+v", eth)
+	}
+
+	// Half the SOL is explained by fills at 100, the rest priced at the mock 45000
+	sol, _ := manager.GetPosition("SOLUSDT")
+	if sol.AvgPrice != (5*100+5*45000)/10.0 {
+		t.Errorf("Unexpected SOL basis %v", sol.AvgPrice)
+	}
+	for _, report := range imported {
+		want := map[string]string{"BTC": BasisFills, "ETH": BasisUser, "SOL": BasisMixed}[report.Asset]
+		if report.Source != want {
+			t.Errorf("%s basis source = %s, want %s", report.Asset, report.Source, want)
+		}
+	}
+
+	if _, ok := manager.GetPosition("USDTUSDT"); ok {
+		t.Error("Quote asset should not be imported as a position")
+	}
+}
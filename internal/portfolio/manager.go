@@ -3,6 +3,7 @@ package portfolio
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -24,6 +25,17 @@ type Manager struct {
 	totalInvested float64
 	totalValue    float64
 	lastUpdate    time.Time
+
+	// Valuation in the reporting currency (optional)
+	valuator      *Valuator
+	balance       *types.Balance
+	lastValuation *types.Valuation
+
+	// Positions of each strategy by symbol, aggregated under the netting
+	// rule, and the last refreshed price of every symbol held
+	strategyPositions map[string]map[string]*StrategyPosition
+	netting           string
+	prices            map[string]float64
 }
 
 // NewManager creates a new portfolio manager
@@ -128,34 +140,198 @@ func (m *Manager) UpdatePosition(order types.Order) error {
 	return nil
 }
 
-// RefreshPortfolio syncs portfolio with exchange market data
-func (m *Manager) RefreshPortfolio(ctx context.Context) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// refreshWorkers bounds the ticker requests of a refresh that falls back
+// to pricing symbols one at a time
+const refreshWorkers = 8
 
-	// Fetch balance from exchange (unused in mock)
-	_, err := m.exchange.GetBalance(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get balance: %w", err)
+// RefreshPortfolio syncs portfolio with exchange market data. Prices and
+// the balance are fetched without holding the lock, so readers are not
+// blocked by the exchange, and then applied together.
+func (m *Manager) RefreshPortfolio(ctx context.Context) error {
+	m.mu.RLock()
+	symbols := make([]string, 0, len(m.positions))
+	for symbol := range m.positions {
+		symbols = append(symbols, symbol)
+	}
+	for _, p := range m.openStrategyPositions() {
+		if _, ok := m.positions[p.Symbol]; !ok && !slices.Contains(symbols, p.Symbol) {
+			symbols = append(symbols, p.Symbol)
+		}
+	}
+	m.mu.RUnlock()
+
+	var (
+		wg         sync.WaitGroup
+		balance    *types.Balance
+		balanceErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		balance, balanceErr = m.exchange.GetBalance(ctx)
+	}()
+	prices := m.fetchPrices(ctx, symbols)
+	wg.Wait()
+	if balanceErr != nil {
+		return fmt.Errorf("failed to get balance: %w", balanceErr)
 	}
 
-	// Update positions with current prices
-	for symbol, position := range m.positions {
-		ticker, err := m.exchange.GetTicker(ctx, symbol)
-		if err != nil {
-			m.logger.Warn("Failed to fetch ticker for %s: %v", symbol, err)
+	m.mu.Lock()
+	m.balance = balance
+	if m.prices == nil {
+		m.prices = make(map[string]float64, len(prices))
+	}
+	now := time.Now()
+	for symbol, price := range prices {
+		m.prices[symbol] = price
+		// Positions closed meanwhile are gone; unchanged ones are left as they are
+		position, ok := m.positions[symbol]
+		if !ok {
 			continue
 		}
-
-		position.CurrentPrice = ticker.Price
-		position.UnrealizedPnL = (ticker.Price - position.AvgPrice) * position.Quantity
-		position.Timestamp = time.Now()
+		pnl := (price - position.AvgPrice) * position.Quantity
+		if position.CurrentPrice == price && position.UnrealizedPnL == pnl {
+			continue
+		}
+		position.CurrentPrice = price
+		position.UnrealizedPnL = pnl
+		position.Timestamp = now
 	}
 
 	// Recompute aggregated portfolio metrics
 	m.updatePortfolioMetrics()
+	m.lastUpdate = now
+	valuator := m.valuator
+	positions := append([]types.Position(nil), m.portfolio.Positions...)
+	m.mu.Unlock()
+
+	// Record valuation in the reporting currency for the equity curve
+	if valuator != nil {
+		if err := m.updateValuation(ctx, valuator, positions, balance); err != nil {
+			m.logger.Warn("Failed to value portfolio in %s: %v", valuator.Currency(), err)
+		}
+	}
+	return nil
+}
+
+// fetchPrices prices symbols with one batch request. When the batch fails,
+// as it does on some exchanges when a single symbol is unknown, each symbol
+// is priced on its own, concurrently, and the ones that fail are left out.
+func (m *Manager) fetchPrices(ctx context.Context, symbols []string) map[string]float64 {
+	prices := make(map[string]float64, len(symbols))
+	if len(symbols) == 0 {
+		return prices
+	}
+
+	tickers, err := m.exchange.GetTickers(ctx, symbols)
+	if err == nil {
+		for symbol, ticker := range types.TickersBySymbol(tickers) {
+			prices[symbol] = ticker.Price
+		}
+		for _, symbol := range symbols {
+			if _, ok := prices[symbol]; !ok {
+				m.logger.Warn("No ticker for %s", symbol)
+			}
+		}
+		return prices
+	}
+	m.logger.Warn("Failed to fetch tickers together, fetching them one by one: %v", err)
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, refreshWorkers)
+	)
+	for _, symbol := range symbols {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(symbol string) {
+			defer func() { <-sem; wg.Done() }()
+			ticker, err := m.exchange.GetTicker(ctx, symbol)
+			if err != nil {
+				m.logger.Warn("Failed to fetch ticker for %s: %v", symbol, err)
+				return
+			}
+			mu.Lock()
+			prices[symbol] = ticker.Price
+			mu.Unlock()
+		}(symbol)
+	}
+	wg.Wait()
+	return prices
+}
 
-	m.lastUpdate = time.Now()
+// Equity returns the portfolio value including cash: the latest valuation
+// when one is recorded, else positions plus the quote balance
+func (m *Manager) Equity() float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.lastValuation != nil {
+		return m.lastValuation.TotalValue
+	}
+	equity := m.portfolio.TotalValue
+	if m.balance != nil {
+		equity += m.balance.Total
+	}
+	return equity
+}
+
+// Balance returns the quote balance from the last refresh, or nil before one
+func (m *Manager) Balance() *types.Balance {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.balance
+}
+
+// SetValuator enables valuation in a reporting currency on each refresh
+func (m *Manager) SetValuator(valuator *Valuator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.valuator = valuator
+}
+
+// GetValuation returns the latest valuation in the reporting currency
+func (m *Manager) GetValuation() (*types.Valuation, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.lastValuation == nil {
+		return nil, false
+	}
+	valuation := *m.lastValuation
+	return &valuation, true
+}
+
+// GetValuationHistory returns recorded valuations within [from, to]
+func (m *Manager) GetValuationHistory(from, to time.Time) []types.Valuation {
+	m.mu.RLock()
+	valuator := m.valuator
+	m.mu.RUnlock()
+
+	if valuator == nil {
+		return nil
+	}
+	return valuator.History(from, to)
+}
+
+// updateValuation values a snapshot of positions and cash and appends it
+// to history
+func (m *Manager) updateValuation(ctx context.Context, valuator *Valuator, positions []types.Position, balance *types.Balance) error {
+	var balances []types.Balance
+	if balance != nil {
+		balances = append(balances, *balance)
+	}
+
+	valuation, err := valuator.Value(ctx, positions, balances)
+	if err != nil {
+		return err
+	}
+
+	valuator.Record(*valuation)
+	m.mu.Lock()
+	m.lastValuation = valuation
+	m.mu.Unlock()
 	return nil
 }
 
@@ -0,0 +1,134 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// batchClient counts single and bulk ticker requests. Bulk requests fail
+// when a symbol is unknown and wait for release when it is set.
+type batchClient struct {
+	*mock.MockClient
+	mu           sync.Mutex
+	single, bulk int
+	unknown      string
+	release      chan struct{}
+}
+
+func (b *batchClient) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	b.mu.Lock()
+	b.single++
+	b.mu.Unlock()
+	if symbol == b.unknown {
+		return nil, fmt.Errorf("invalid symbol %s", symbol)
+	}
+	return b.MockClient.GetTicker(ctx, symbol)
+}
+
+func (b *batchClient) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	b.mu.Lock()
+	b.bulk++
+	b.mu.Unlock()
+	if b.release != nil {
+		<-b.release
+	}
+	for _, symbol := range symbols {
+		if symbol == b.unknown {
+			return nil, fmt.Errorf("invalid symbol %s", symbol)
+		}
+	}
+	return b.MockClient.GetTickers(ctx, symbols)
+}
+
+func newBatchManager(t *testing.T, client *batchClient, symbols ...string) *Manager {
+	t.Helper()
+	m := NewManager(client, logger.New(logger.LevelError))
+	for _, symbol := range symbols {
+		order := types.Order{Symbol: symbol, Side: types.OrderSideBuy, Status: types.OrderStatusFilled, FilledAmount: 1, FilledPrice: 40000}
+		if err := m.UpdatePosition(order); err != nil {
+			t.Fatalf("UpdatePosition: %v", err)
+		}
+	}
+	return m
+}
+
+func TestManager_RefreshPortfolioBatchesTickers(t *testing.T) {
+	client := &batchClient{MockClient: mock.NewMockClient()}
+	m := newBatchManager(t, client, "BTCUSDT", "ETHUSDT", "SOLUSDT")
+
+	if err := m.RefreshPortfolio(context.Background()); err != nil {
+		t.Fatalf("RefreshPortfolio: %v", err)
+	}
+	if client.bulk != 1 || client.single != 0 {
+		t.Fatalf("got %d bulk and %d single ticker requests, want 1 and 0", client.bulk, client.single)
+	}
+	for symbol, position := range m.GetAllPositions() {
+		if position.CurrentPrice != 45000 || position.UnrealizedPnL != 5000 {
+			t.Errorf("%s: price %v, unrealized %v", symbol, position.CurrentPrice, position.UnrealizedPnL)
+		}
+	}
+}
+
+func TestManager_RefreshPortfolioFallsBackPerSymbol(t *testing.T) {
+	client := &batchClient{MockClient: mock.NewMockClient(), unknown: "DELISTEDUSDT"}
+	m := newBatchManager(t, client, "BTCUSDT", "ETHUSDT", "DELISTEDUSDT")
+
+	if err := m.RefreshPortfolio(context.Background()); err != nil {
+		t.Fatalf("RefreshPortfolio: %v", err)
+	}
+	if client.single != 3 {
+		t.Fatalf("got %d single ticker requests after the batch failed, want 3", client.single)
+	}
+	for symbol, position := range m.GetAllPositions() {
+		want := 45000.0
+		if symbol == "DELISTEDUSDT" {
+			want = 0 // never priced
+		}
+		if position.CurrentPrice != want {
+			t.Errorf("%s: price %v, want %v", symbol, position.CurrentPrice, want)
+		}
+	}
+}
+
+func TestManager_RefreshPortfolioDoesNotBlockReaders(t *testing.T) {
+	client := &batchClient{MockClient: mock.NewMockClient(), release: make(chan struct{})}
+	m := newBatchManager(t, client, "BTCUSDT")
+
+	done := make(chan error, 1)
+	go func() { done <- m.RefreshPortfolio(context.Background()) }()
+
+	// The refresh is waiting on the exchange; readers still get through
+	read := make(chan struct{})
+	go func() {
+		m.GetMetrics()
+		m.GetPosition("BTCUSDT")
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("readers blocked while tickers were fetched")
+	}
+
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatalf("RefreshPortfolio: %v", err)
+	}
+	first, _ := m.GetPosition("BTCUSDT")
+	stamp := first.Timestamp
+
+	// An unchanged price leaves the position alone
+	if err := m.RefreshPortfolio(context.Background()); err != nil {
+		t.Fatalf("RefreshPortfolio: %v", err)
+	}
+	if again, _ := m.GetPosition("BTCUSDT"); !again.Timestamp.Equal(stamp) {
+		t.Errorf("unchanged position updated at %v, was %v", again.Timestamp, stamp)
+	}
+}
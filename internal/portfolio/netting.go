@@ -0,0 +1,289 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Netting rules aggregating strategies' positions in a symbol
+const (
+	NettingNet   = "net"   // longs and shorts offset into one position (default)
+	NettingGross = "gross" // longs and shorts are reported side by side
+)
+
+// Sides of an aggregated position
+const (
+	SideLong  = "long"
+	SideShort = "short"
+	SideFlat  = "flat" // fully offset
+)
+
+// NettingConfig configures how strategies' positions are aggregated
+type NettingConfig struct {
+	Mode string `json:"mode"` // NettingNet (default) or NettingGross
+}
+
+// Validate checks the mode
+func (c NettingConfig) Validate() error {
+	switch c.Mode {
+	case "", NettingNet, NettingGross:
+		return nil
+	}
+	return fmt.Errorf("unknown netting mode %q (net, gross)", c.Mode)
+}
+
+// StrategyPosition is one strategy's position in a symbol, built from its
+// fills: long while Quantity is positive, short while negative
+type StrategyPosition struct {
+	StrategyID  string  `json:"strategy_id"`
+	Symbol      string  `json:"symbol"`
+	Quantity    float64 `json:"quantity"`
+	AvgPrice    float64 `json:"avg_price"`
+	RealizedPnL float64 `json:"realized_pnl"`
+}
+
+// Apply adds a fill: it extends the position on its side and closes it,
+// realizing PnL, on the other; the remainder opens the opposite side
+func (p *StrategyPosition) Apply(side types.OrderSide, quantity, price float64) {
+	delta := quantity
+	if side == types.OrderSideSell {
+		delta = -quantity
+	}
+	if p.Quantity == 0 || (p.Quantity > 0) == (delta > 0) {
+		held := math.Abs(p.Quantity)
+		p.AvgPrice = (held*p.AvgPrice + quantity*price) / (held + quantity)
+		p.Quantity += delta
+		return
+	}
+
+	closed := math.Min(quantity, math.Abs(p.Quantity))
+	if p.Quantity > 0 {
+		p.RealizedPnL += (price - p.AvgPrice) * closed
+	} else {
+		p.RealizedPnL += (p.AvgPrice - price) * closed
+	}
+	p.Quantity += delta
+	switch {
+	case math.Abs(p.Quantity) < 1e-12:
+		p.Quantity, p.AvgPrice = 0, 0
+	case quantity > closed:
+		p.AvgPrice = price // flipped sides
+	}
+}
+
+// ExposureReport is the market exposure of the strategies' positions, per
+// strategy and aggregated per symbol under the netting rule. Values are in
+// the quote currency at the last refreshed price, or the average price
+// before one.
+type ExposureReport struct {
+	Netting    string             `json:"netting"`
+	Gross      float64            `json:"gross"` // long plus short value
+	Net        float64            `json:"net"`   // long minus short value
+	Strategies []StrategyExposure `json:"strategies"`
+	Symbols    []SymbolExposure   `json:"symbols"`
+}
+
+// StrategyExposure is one strategy's exposure across its symbols
+type StrategyExposure struct {
+	StrategyID string             `json:"strategy_id"`
+	Long       float64            `json:"long"`
+	Short      float64            `json:"short"`
+	Gross      float64            `json:"gross"`
+	Net        float64            `json:"net"`
+	Positions  []StrategyPosition `json:"positions"`
+}
+
+// SymbolExposure is the aggregated position in a symbol: under NettingNet
+// one per symbol, with Offset the quantity longs and shorts cancelled; under
+// NettingGross one per side
+type SymbolExposure struct {
+	Symbol     string   `json:"symbol"`
+	Side       string   `json:"side"`
+	Quantity   float64  `json:"quantity"` // signed: negative when short
+	Offset     float64  `json:"offset,omitempty"`
+	Price      float64  `json:"price"`
+	Value      float64  `json:"value"` // signed like Quantity
+	Strategies []string `json:"strategies"`
+}
+
+// ApplyFill records a strategy's fill in its position in the symbol, so
+// strategies trading the same symbol in opposite directions are tracked
+// apart instead of closing each other's positions
+func (m *Manager) ApplyFill(strategyID, symbol string, side types.OrderSide, quantity, price float64) {
+	if quantity <= 0 || price <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.strategyPositions == nil {
+		m.strategyPositions = make(map[string]map[string]*StrategyPosition)
+	}
+	positions := m.strategyPositions[strategyID]
+	if positions == nil {
+		positions = make(map[string]*StrategyPosition)
+		m.strategyPositions[strategyID] = positions
+	}
+	position := positions[symbol]
+	if position == nil {
+		position = &StrategyPosition{StrategyID: strategyID, Symbol: symbol}
+		positions[symbol] = position
+	}
+	position.Apply(side, quantity, price)
+}
+
+// SetNetting sets the rule aggregating strategies' positions (default net)
+func (m *Manager) SetNetting(cfg NettingConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.netting = cfg.Mode
+}
+
+// StrategyPositions returns the strategies' open positions by strategy and
+// symbol
+func (m *Manager) StrategyPositions() []StrategyPosition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.openStrategyPositions()
+}
+
+// openStrategyPositions lists the non-flat strategy positions, sorted
+func (m *Manager) openStrategyPositions() []StrategyPosition {
+	var positions []StrategyPosition
+	for _, bySymbol := range m.strategyPositions {
+		for _, p := range bySymbol {
+			if p.Quantity != 0 {
+				positions = append(positions, *p)
+			}
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].StrategyID != positions[j].StrategyID {
+			return positions[i].StrategyID < positions[j].StrategyID
+		}
+		return positions[i].Symbol < positions[j].Symbol
+	})
+	return positions
+}
+
+// Exposure reports gross and net exposure per strategy and the positions
+// aggregated per symbol under the netting rule
+func (m *Manager) Exposure() ExposureReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	report := ExposureReport{Netting: m.netting, Strategies: []StrategyExposure{}, Symbols: []SymbolExposure{}}
+	if report.Netting == "" {
+		report.Netting = NettingNet
+	}
+
+	type side struct {
+		quantity   float64
+		strategies []string
+	}
+	longs, shorts := make(map[string]*side), make(map[string]*side)
+	var symbols []string
+	for _, p := range m.openStrategyPositions() {
+		value := p.Quantity * m.markPrice(p)
+		n := len(report.Strategies)
+		if n == 0 || report.Strategies[n-1].StrategyID != p.StrategyID {
+			report.Strategies = append(report.Strategies, StrategyExposure{StrategyID: p.StrategyID})
+			n++
+		}
+		s := &report.Strategies[n-1]
+		s.Positions = append(s.Positions, p)
+		sides := longs
+		if p.Quantity > 0 {
+			s.Long += value
+		} else {
+			s.Short -= value
+			sides = shorts
+		}
+		s.Gross, s.Net = s.Long+s.Short, s.Long-s.Short
+
+		if longs[p.Symbol] == nil && shorts[p.Symbol] == nil {
+			symbols = append(symbols, p.Symbol)
+		}
+		if sides[p.Symbol] == nil {
+			sides[p.Symbol] = &side{}
+		}
+		sides[p.Symbol].quantity += p.Quantity
+		sides[p.Symbol].strategies = append(sides[p.Symbol].strategies, p.StrategyID)
+	}
+	for _, s := range report.Strategies {
+		report.Gross += s.Gross
+		report.Net += s.Net
+	}
+
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		price := m.symbolPrice(symbol)
+		long, short := longs[symbol], shorts[symbol]
+		if report.Netting == NettingGross {
+			for _, sd := range []*side{long, short} {
+				if sd == nil {
+					continue
+				}
+				name := SideLong
+				if sd.quantity < 0 {
+					name = SideShort
+				}
+				report.Symbols = append(report.Symbols, SymbolExposure{Symbol: symbol, Side: name, Quantity: sd.quantity,
+					Price: price, Value: sd.quantity * price, Strategies: sd.strategies})
+			}
+			continue
+		}
+
+		net := SymbolExposure{Symbol: symbol, Price: price}
+		for _, sd := range []*side{long, short} {
+			if sd != nil {
+				net.Quantity += sd.quantity
+				net.Strategies = append(net.Strategies, sd.strategies...)
+			}
+		}
+		if long != nil && short != nil {
+			net.Offset = math.Min(long.quantity, -short.quantity)
+		}
+		net.Value = net.Quantity * price
+		switch {
+		case math.Abs(net.Quantity) < 1e-12:
+			net.Quantity, net.Value, net.Side = 0, 0, SideFlat
+		case net.Quantity > 0:
+			net.Side = SideLong
+		default:
+			net.Side = SideShort
+		}
+		report.Symbols = append(report.Symbols, net)
+	}
+	return report
+}
+
+// markPrice is the price a strategy position is valued at
+func (m *Manager) markPrice(p StrategyPosition) float64 {
+	if price, ok := m.prices[p.Symbol]; ok {
+		return price
+	}
+	return p.AvgPrice
+}
+
+// symbolPrice is the last refreshed price of symbol, or the average price of
+// the strategies' positions in it before a refresh
+func (m *Manager) symbolPrice(symbol string) float64 {
+	if price, ok := m.prices[symbol]; ok {
+		return price
+	}
+	var quantity, cost float64
+	for _, bySymbol := range m.strategyPositions {
+		if p := bySymbol[symbol]; p != nil && p.Quantity != 0 {
+			quantity += math.Abs(p.Quantity)
+			cost += math.Abs(p.Quantity) * p.AvgPrice
+		}
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return cost / quantity
+}
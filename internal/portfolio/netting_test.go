@@ -0,0 +1,72 @@
+package portfolio
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestManager_Exposure(t *testing.T) {
+	m := NewManager(mock.NewMockClient(), logger.New(logger.LevelError))
+
+	// A grid long and a momentum short on the same symbol stay apart
+	m.ApplyFill("grid-btc", "BTCUSDT", types.OrderSideBuy, 2, 100)
+	m.ApplyFill("grid-btc", "BTCUSDT", types.OrderSideBuy, 2, 110)
+	m.ApplyFill("momentum", "BTCUSDT", types.OrderSideSell, 1, 120)
+	m.ApplyFill("momentum", "ETHUSDT", types.OrderSideBuy, 3, 10)
+
+	positions := m.StrategyPositions()
+	if len(positions) != 3 || positions[0].Quantity != 4 || positions[0].AvgPrice != 105 || positions[1].Quantity != -1 {
+		t.Fatalf("positions = %+v", positions)
+	}
+
+	report := m.Exposure()
+	if report.Netting != NettingNet || len(report.Strategies) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	grid, momentum := report.Strategies[0], report.Strategies[1]
+	if grid.Gross != 420 || grid.Net != 420 {
+		t.Errorf("grid exposure = %+v, want 420 gross and net", grid)
+	}
+	if momentum.Long != 30 || momentum.Short != 120 || momentum.Gross != 150 || momentum.Net != -90 {
+		t.Errorf("momentum exposure = %+v, want 30 long, 120 short", momentum)
+	}
+	if report.Gross != 570 || report.Net != 330 {
+		t.Errorf("total gross %v, net %v, want 570 and 330", report.Gross, report.Net)
+	}
+	btc := report.Symbols[0]
+	if btc.Symbol != "BTCUSDT" || btc.Side != SideLong || btc.Quantity != 3 || btc.Offset != 1 || len(btc.Strategies) != 2 {
+		t.Errorf("net BTC = %+v, want 3 long with 1 offset", btc)
+	}
+
+	// Gross netting reports each side of the symbol
+	m.SetNetting(NettingConfig{Mode: NettingGross})
+	report = m.Exposure()
+	if len(report.Symbols) != 3 || report.Symbols[0].Side != SideLong || report.Symbols[1].Side != SideShort || report.Symbols[1].Quantity != -1 {
+		t.Errorf("gross symbols = %+v", report.Symbols)
+	}
+
+	// Covering more than the short realizes its PnL and flips it long
+	m.ApplyFill("momentum", "BTCUSDT", types.OrderSideBuy, 3, 100)
+	positions = m.StrategyPositions()
+	if p := positions[1]; p.Quantity != 2 || p.AvgPrice != 100 || p.RealizedPnL != 20 {
+		t.Errorf("flipped momentum = %+v, want 2 long @ 100 with 20 realized", p)
+	}
+
+	// Refreshed prices value the positions
+	if err := m.RefreshPortfolio(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	report = m.Exposure()
+	if price := report.Symbols[0].Price; price == 105 || math.Abs(report.Strategies[0].Net-4*price) > 1e-9 {
+		t.Errorf("refreshed BTC price %v, grid exposure %+v", price, report.Strategies[0])
+	}
+
+	if err := (NettingConfig{Mode: "fifo"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
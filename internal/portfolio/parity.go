@@ -0,0 +1,112 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+)
+
+// Covariance returns the sample covariance matrix of equal-length return series
+func Covariance(series [][]float64) ([][]float64, error) {
+	if len(series) == 0 {
+		return nil, fmt.Errorf("no return series")
+	}
+	n := len(series[0])
+	if n < 2 {
+		return nil, fmt.Errorf("need at least 2 observations, got %d", n)
+	}
+	means := make([]float64, len(series))
+	for i, s := range series {
+		if len(s) != n {
+			return nil, fmt.Errorf("series %d has %d observations, want %d", i, len(s), n)
+		}
+		for _, r := range s {
+			means[i] += r
+		}
+		means[i] /= float64(n)
+	}
+
+	cov := make([][]float64, len(series))
+	for i := range cov {
+		cov[i] = make([]float64, len(series))
+	}
+	for i := range series {
+		for j := i; j < len(series); j++ {
+			var sum float64
+			for k := 0; k < n; k++ {
+				sum += (series[i][k] - means[i]) * (series[j][k] - means[j])
+			}
+			cov[i][j] = sum / float64(n-1)
+			cov[j][i] = cov[i][j]
+		}
+	}
+	return cov, nil
+}
+
+// RiskParityWeights returns long-only weights summing to 1 under which every
+// asset contributes the same share of portfolio volatility, solved by cyclical
+// coordinate descent. Every asset needs a positive variance.
+func RiskParityWeights(cov [][]float64) ([]float64, error) {
+	n := len(cov)
+	if n == 0 {
+		return nil, fmt.Errorf("empty covariance matrix")
+	}
+	for i, row := range cov {
+		if len(row) != n {
+			return nil, fmt.Errorf("covariance row %d has %d columns, want %d", i, len(row), n)
+		}
+		if row[i] <= 0 {
+			return nil, fmt.Errorf("asset %d has no variance", i)
+		}
+	}
+
+	// Solve x_i * (cov x)_i = 1/n for each i, starting from inverse volatility
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = 1 / math.Sqrt(cov[i][i])
+	}
+	budget := 1 / float64(n)
+	for iter := 0; iter < 500; iter++ {
+		var change float64
+		for i := range x {
+			var c float64
+			for j := range x {
+				if j != i {
+					c += cov[i][j] * x[j]
+				}
+			}
+			next := (-c + math.Sqrt(c*c+4*cov[i][i]*budget)) / (2 * cov[i][i])
+			change = math.Max(change, math.Abs(next-x[i])/x[i])
+			x[i] = next
+		}
+		if change < 1e-10 {
+			break
+		}
+	}
+
+	var total float64
+	for _, v := range x {
+		total += v
+	}
+	for i := range x {
+		x[i] /= total
+	}
+	return x, nil
+}
+
+// RiskContributions returns each asset's share of portfolio variance under weights
+func RiskContributions(weights []float64, cov [][]float64) []float64 {
+	contributions := make([]float64, len(weights))
+	var variance float64
+	for i := range weights {
+		for j := range weights {
+			contributions[i] += weights[i] * cov[i][j] * weights[j]
+		}
+		variance += contributions[i]
+	}
+	if variance > 0 {
+		for i := range contributions {
+			contributions[i] /= variance
+		}
+	}
+	return contributions
+}
@@ -0,0 +1,59 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRiskParityWeights(t *testing.T) {
+	// Uncorrelated assets: weights are inverse to volatility
+	cov := [][]float64{
+		{0.04, 0},
+		{0, 0.01},
+	}
+	weights, err := RiskParityWeights(cov)
+	if err != nil {
+		t.Fatalf("RiskParityWeights() error = %v", err)
+	}
+	if math.Abs(weights[0]-1.0/3) > 1e-6 || math.Abs(weights[1]-2.0/3) > 1e-6 {
+		t.Errorf("Expected weights [1/3 2/3], got %v", weights)
+	}
+
+	// Correlated assets still get equal risk contributions
+	cov = [][]float64{
+		{0.09, 0.012, 0.006},
+		{0.012, 0.04, 0.01},
+		{0.006, 0.01, 0.01},
+	}
+	weights, err = RiskParityWeights(cov)
+	if err != nil {
+		t.Fatalf("RiskParityWeights() error = %v", err)
+	}
+	var total float64
+	for i, c := range RiskContributions(weights, cov) {
+		total += weights[i]
+		if math.Abs(c-1.0/3) > 1e-6 {
+			t.Errorf("Asset %d contributes %.6f of risk, want 1/3", i, c)
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Weights sum to %v, want 1", total)
+	}
+
+	if _, err := RiskParityWeights([][]float64{{0.01, 0}, {0, 0}}); err == nil {
+		t.Error("Expected error for an asset without variance")
+	}
+}
+
+func TestCovariance(t *testing.T) {
+	cov, err := Covariance([][]float64{{1, 2, 3}, {2, 4, 6}})
+	if err != nil {
+		t.Fatalf("Covariance() error = %v", err)
+	}
+	if cov[0][0] != 1 || cov[0][1] != 2 || cov[1][0] != 2 || cov[1][1] != 4 {
+		t.Errorf("Unexpected covariance %v", cov)
+	}
+	if _, err := Covariance([][]float64{{1, 2}, {1}}); err == nil {
+		t.Error("Expected error for mismatched series")
+	}
+}
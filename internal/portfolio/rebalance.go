@@ -0,0 +1,306 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// RebalanceConfig sets target weights for the portfolio's assets and how
+// the orders moving towards them are placed
+type RebalanceConfig struct {
+	// Targets maps assets to their share of the rebalanced value, e.g.
+	// {"BTC": 0.6, "ETH": 0.3}; the quote asset holds the rest
+	Targets    map[string]float64 `json:"targets"`
+	QuoteAsset string             `json:"quote_asset"` // pairs are <asset><quote>; defaults to USDT
+
+	Threshold   float64       `json:"threshold"`    // weight drift an asset needs before it is traded (default 0.01)
+	MinNotional float64       `json:"min_notional"` // smallest order worth placing, in the quote asset
+	BatchSize   int           `json:"batch_size"`   // orders placed together (default 5)
+	BatchDelay  time.Duration `json:"batch_delay"`  // pause between batches
+	Interval    time.Duration `json:"interval"`     // between scheduled rebalances; 0 disables them
+}
+
+// UnmarshalJSON implements custom parsing for durations ("5s", "24h")
+func (c *RebalanceConfig) UnmarshalJSON(data []byte) error {
+	type Alias RebalanceConfig
+	aux := &struct {
+		BatchDelay string `json:"batch_delay"`
+		Interval   string `json:"interval"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.BatchDelay != "" {
+		d, err := time.ParseDuration(aux.BatchDelay)
+		if err != nil {
+			return fmt.Errorf("invalid batch_delay: %w", err)
+		}
+		c.BatchDelay = d
+	}
+	if aux.Interval != "" {
+		d, err := time.ParseDuration(aux.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		c.Interval = d
+	}
+	return nil
+}
+
+// Enabled reports whether any targets are set
+func (c RebalanceConfig) Enabled() bool {
+	return len(c.Targets) > 0
+}
+
+// Validate checks the config
+func (c RebalanceConfig) Validate() error {
+	var total float64
+	for asset, weight := range c.Targets {
+		if strings.EqualFold(asset, c.quote()) {
+			return fmt.Errorf("target %s is the quote asset, which holds the rest", asset)
+		}
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("target %s must be in [0, 1], got %v", asset, weight)
+		}
+		total += weight
+	}
+	if total > 1+1e-9 {
+		return fmt.Errorf("targets sum to %.4f, more than 1", total)
+	}
+	switch {
+	case c.Threshold < 0 || c.Threshold >= 1:
+		return fmt.Errorf("threshold must be at least 0 and below 1")
+	case c.MinNotional < 0:
+		return fmt.Errorf("min_notional must not be negative")
+	case c.BatchSize < 0:
+		return fmt.Errorf("batch_size must not be negative")
+	case c.BatchDelay < 0 || c.Interval < 0:
+		return fmt.Errorf("batch_delay and interval must not be negative")
+	}
+	return nil
+}
+
+// quote returns the quote asset
+func (c RebalanceConfig) quote() string {
+	if c.QuoteAsset == "" {
+		return "USDT"
+	}
+	return strings.ToUpper(c.QuoteAsset)
+}
+
+// RebalanceTrade is the move of one asset towards its target weight
+type RebalanceTrade struct {
+	Asset    string          `json:"asset"`
+	Symbol   string          `json:"symbol"`
+	Current  float64         `json:"current"` // weight
+	Target   float64         `json:"target"`
+	Side     types.OrderSide `json:"side,omitempty"`
+	Quantity float64         `json:"quantity,omitempty"`
+	Price    float64         `json:"price"`
+	Notional float64         `json:"notional,omitempty"`
+	Skipped  string          `json:"skipped,omitempty"` // why no order is placed
+}
+
+// RebalancePlan lists the trades moving the portfolio to its targets
+type RebalancePlan struct {
+	Time   time.Time        `json:"time"`
+	Quote  string           `json:"quote"`
+	Value  float64          `json:"value"` // of the targeted assets and the quote balance
+	Trades []RebalanceTrade `json:"trades"`
+}
+
+// Orders returns the market orders of the plan, sells first so they free
+// the quote asset the buys spend
+func (p *RebalancePlan) Orders() []types.Order {
+	var orders []types.Order
+	for _, side := range []types.OrderSide{types.OrderSideSell, types.OrderSideBuy} {
+		for _, trade := range p.Trades {
+			if trade.Skipped != "" || trade.Side != side {
+				continue
+			}
+			action := types.DecisionBuy
+			if side == types.OrderSideSell {
+				action = types.DecisionSell
+			}
+			orders = append(orders, types.Order{
+				Symbol:    trade.Symbol,
+				Side:      side,
+				Type:      types.OrderTypeMarket,
+				Quantity:  trade.Quantity,
+				Price:     trade.Price,
+				Status:    types.OrderStatusNew,
+				Timestamp: p.Time,
+				Decision:  &types.Decision{Strategy: "rebalance", Action: action},
+			})
+		}
+	}
+	return orders
+}
+
+// PlanRebalance prices the targeted assets and works out the trades that
+// bring each to its target weight. Assets that drifted less than the
+// threshold, or whose trade is below the exchange's or the configured
+// minimum, are listed as skipped.
+func (m *Manager) PlanRebalance(ctx context.Context, cfg RebalanceConfig) (*RebalancePlan, error) {
+	balances, err := m.exchange.GetBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	quote := cfg.quote()
+	held := make(map[string]types.Balance, len(balances))
+	for _, balance := range balances {
+		held[strings.ToUpper(balance.Asset)] = balance
+	}
+
+	assets := make([]string, 0, len(cfg.Targets))
+	for asset := range cfg.Targets {
+		assets = append(assets, strings.ToUpper(asset))
+	}
+	sort.Strings(assets)
+
+	symbols := make([]string, len(assets))
+	for i, asset := range assets {
+		symbols[i] = asset + quote
+	}
+	tickers, err := m.exchange.GetTickers(ctx, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price targets: %w", err)
+	}
+	prices := types.TickersBySymbol(tickers)
+
+	plan := &RebalancePlan{Time: time.Now(), Quote: quote, Value: held[quote].Total}
+	for i, asset := range assets {
+		symbol := symbols[i]
+		ticker := prices[symbol]
+		if ticker.Price <= 0 {
+			return nil, fmt.Errorf("no price for %s", symbol)
+		}
+		plan.Value += held[asset].Total * ticker.Price
+		plan.Trades = append(plan.Trades, RebalanceTrade{Asset: asset, Symbol: symbol, Price: ticker.Price})
+	}
+	if plan.Value <= 0 {
+		return nil, fmt.Errorf("nothing to rebalance: no %s or targeted assets held", quote)
+	}
+
+	threshold := cfg.Threshold
+	if threshold == 0 {
+		threshold = 0.01
+	}
+	for i := range plan.Trades {
+		trade := &plan.Trades[i]
+		balance := held[trade.Asset]
+		trade.Current = balance.Total * trade.Price / plan.Value
+		trade.Target = targetWeight(cfg.Targets, trade.Asset)
+
+		drift := trade.Target - trade.Current
+		if math.Abs(drift) < threshold {
+			trade.Skipped = "within threshold"
+			continue
+		}
+		trade.Side, trade.Quantity = types.OrderSideBuy, drift*plan.Value/trade.Price
+		if drift < 0 {
+			// Locked coins back open orders and cannot be sold
+			trade.Side, trade.Quantity = types.OrderSideSell, math.Min(-drift*plan.Value/trade.Price, balance.Free)
+		}
+
+		rules, err := m.exchange.GetSymbolRules(ctx, trade.Symbol)
+		switch {
+		case errors.Is(err, types.ErrNotSupported):
+			rules = &types.SymbolRules{Symbol: trade.Symbol}
+		case err != nil:
+			return nil, fmt.Errorf("failed to get %s rules: %w", trade.Symbol, err)
+		}
+		if !rules.Tradable(trade.Quantity, trade.Price) {
+			trade.Skipped = "below exchange minimum"
+			continue
+		}
+		trade.Quantity = rules.RoundQuantity(trade.Quantity)
+		trade.Notional = trade.Quantity * trade.Price
+		if trade.Notional < cfg.MinNotional {
+			trade.Skipped = "below min_notional"
+		}
+	}
+	return plan, nil
+}
+
+// targetWeight looks an asset's target up regardless of case
+func targetWeight(targets map[string]float64, asset string) float64 {
+	for a, weight := range targets {
+		if strings.EqualFold(a, asset) {
+			return weight
+		}
+	}
+	return 0
+}
+
+// ExecuteRebalance places the plan's orders on exchange in batches of
+// cfg.BatchSize, pausing cfg.BatchDelay between batches. It stops at the
+// first failed order and returns how many were placed.
+func (m *Manager) ExecuteRebalance(ctx context.Context, exchange types.ExchangeClient, plan *RebalancePlan, cfg RebalanceConfig) (int, error) {
+	batch := cfg.BatchSize
+	if batch <= 0 {
+		batch = 5
+	}
+
+	var placed int
+	for i, order := range plan.Orders() {
+		if i > 0 && i%batch == 0 && cfg.BatchDelay > 0 {
+			select {
+			case <-ctx.Done():
+				return placed, ctx.Err()
+			case <-time.After(cfg.BatchDelay):
+			}
+		}
+		if err := exchange.PlaceOrder(ctx, order); err != nil {
+			return placed, fmt.Errorf("failed to place rebalance %s %s: %w", order.Side, order.Symbol, err)
+		}
+		placed++
+		m.logger.Info("Rebalance order placed: %s %s %.8f @ %.2f", order.Side, order.Symbol, order.Quantity, order.Price)
+	}
+	return placed, nil
+}
+
+// RunRebalance rebalances every cfg.Interval until ctx is done, placing
+// orders through exchange
+func (m *Manager) RunRebalance(ctx context.Context, exchange types.ExchangeClient, cfg RebalanceConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		plan, err := m.PlanRebalance(ctx, cfg)
+		if err != nil {
+			m.logger.Error("Rebalance planning error: %v", err)
+			continue
+		}
+		placed, err := m.ExecuteRebalance(ctx, exchange, plan, cfg)
+		if err != nil {
+			m.logger.Error("Rebalance stopped after %d order(s): %v", placed, err)
+			continue
+		}
+		if placed > 0 {
+			m.logger.Info("Rebalanced %.2f %s with %d order(s)", plan.Value, plan.Quote, placed)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// rebalanceClient prices symbols from a table and records placed orders
+type rebalanceClient struct {
+	*dustClient
+	prices map[string]float64
+	placed []types.Order
+}
+
+func (r *rebalanceClient) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	return &types.Ticker{Symbol: symbol, Price: r.prices[symbol]}, nil
+}
+
+func (r *rebalanceClient) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	return types.FetchTickers(ctx, symbols, r.GetTicker)
+}
+
+func (r *rebalanceClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	r.placed = append(r.placed, order)
+	return nil
+}
+
+func TestManager_Rebalance(t *testing.T) {
+	client := &rebalanceClient{
+		dustClient: &dustClient{holdingsClient: &holdingsClient{
+			MockClient: mock.NewMockClient(),
+			balances: []types.Balance{
+				{Asset: "USDT", Free: 4000, Total: 4000},
+				{Asset: "BTC", Free: 0.2, Total: 0.2},
+				{Asset: "ETH", Free: 2, Total: 2},
+				{Asset: "SOL", Free: 10, Total: 10}, // not targeted, left alone
+			},
+		}},
+		prices: map[string]float64{"BTCUSDT": 45000, "ETHUSDT": 2000, "LINKUSDT": 10},
+	}
+	manager := NewManager(client, logger.New(logger.LevelError))
+	ctx := context.Background()
+
+	var cfg RebalanceConfig
+	if err := json.Unmarshal([]byte(`{"targets": {"BTC": 0.5, "eth": 0.25, "LINK": 0.01}, "threshold": 0.012, "min_notional": 300, "batch_delay": "1ms"}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	// 4000 USDT + 9000 of BTC + 4000 of ETH
+	plan, err := manager.PlanRebalance(ctx, cfg)
+	if err != nil {
+		t.Fatalf("PlanRebalance() error = %v", err)
+	}
+	if plan.Value != 17000 || len(plan.Trades) != 3 {
+		t.Fatalf("plan = %+v, want three trades over 17000", plan)
+	}
+	btc, eth, link := plan.Trades[0], plan.Trades[1], plan.Trades[2]
+	if btc.Side != types.OrderSideSell || btc.Quantity != 0.0111 || btc.Skipped != "" {
+		t.Errorf("BTC trade = %+v, want a sell of 500 USDT rounded to 0.0111", btc)
+	}
+	if eth.Side != types.OrderSideBuy || eth.Quantity != 0.125 || eth.Skipped != "below min_notional" {
+		t.Errorf("ETH trade = %+v, want a 250 USDT buy skipped under min_notional", eth)
+	}
+	if link.Skipped != "within threshold" {
+		t.Errorf("LINK trade = %+v, want a 0.01 drift skipped", link)
+	}
+
+	// Without the minimum both trade, sells first and one per batch
+	cfg.MinNotional, cfg.BatchSize = 0, 1
+	if plan, err = manager.PlanRebalance(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	placed, err := manager.ExecuteRebalance(ctx, client, plan, cfg)
+	if err != nil || placed != 2 {
+		t.Fatalf("ExecuteRebalance() = %d, %v; want 2 orders", placed, err)
+	}
+	if time.Since(start) < time.Millisecond {
+		t.Error("Expected a pause between batches")
+	}
+	if client.placed[0].Side != types.OrderSideSell || client.placed[1].Symbol != "ETHUSDT" || client.placed[1].Decision.Strategy != "rebalance" {
+		t.Errorf("placed = %+v, want the BTC sell before the ETH buy", client.placed)
+	}
+	if notional := client.placed[1].Quantity * client.placed[1].Price; math.Abs(notional-250) > 1e-9 {
+		t.Errorf("ETH buy = %.2f USDT, want 250", notional)
+	}
+}
+
+func TestRebalanceConfig_Validate(t *testing.T) {
+	for name, cfg := range map[string]RebalanceConfig{
+		"quote target":   {Targets: map[string]float64{"USDT": 0.5}},
+		"over one":       {Targets: map[string]float64{"BTC": 0.7, "ETH": 0.4}},
+		"negative":       {Targets: map[string]float64{"BTC": -0.1}},
+		"threshold":      {Targets: map[string]float64{"BTC": 0.5}, Threshold: 1},
+		"negative batch": {Targets: map[string]float64{"BTC": 0.5}, BatchSize: -1},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
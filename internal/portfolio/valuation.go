@@ -0,0 +1,250 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// usdAliases are assets treated as 1:1 with USD
+var usdAliases = map[string]bool{
+	"USD":   true,
+	"USDT":  true,
+	"USDC":  true,
+	"BUSD":  true,
+	"TUSD":  true,
+	"FDUSD": true,
+}
+
+// fxRate is a cached conversion rate
+type fxRate struct {
+	rate    float64
+	fetched time.Time
+}
+
+// Valuator converts positions and balances into a reporting currency
+type Valuator struct {
+	exchange types.ExchangeClient
+	logger   *logger.Logger
+	currency string
+	bridge   string // asset used for cross rates
+	cacheTTL time.Duration
+
+	mu         sync.Mutex
+	rates      map[string]fxRate
+	history    []types.Valuation
+	maxHistory int
+}
+
+// NewValuator creates a valuator reporting in the given currency (USD, EUR, BTC, ...)
+func NewValuator(exchange types.ExchangeClient, logger *logger.Logger, currency string) *Valuator {
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = "USD"
+	}
+
+	return &Valuator{
+		exchange:   exchange,
+		logger:     logger,
+		currency:   currency,
+		bridge:     "USDT",
+		cacheTTL:   time.Minute,
+		rates:      make(map[string]fxRate),
+		maxHistory: 10000,
+	}
+}
+
+// Currency returns the reporting currency
+func (v *Valuator) Currency() string {
+	return v.currency
+}
+
+// SetCacheTTL adjusts how long conversion rates are reused
+func (v *Valuator) SetCacheTTL(ttl time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cacheTTL = ttl
+}
+
+// Rate returns the price of one unit of asset in the reporting currency
+func (v *Valuator) Rate(ctx context.Context, asset string) (float64, error) {
+	return v.rate(ctx, strings.ToUpper(asset), v.currency)
+}
+
+// Convert converts an amount of asset into the reporting currency
+func (v *Valuator) Convert(ctx context.Context, amount float64, asset string) (float64, error) {
+	if amount == 0 {
+		return 0, nil
+	}
+
+	rate, err := v.Rate(ctx, asset)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+// Value computes the value of positions and balances in the reporting currency
+func (v *Valuator) Value(ctx context.Context, positions []types.Position, balances []types.Balance) (*types.Valuation, error) {
+	valuation := &types.Valuation{
+		Currency:  v.currency,
+		Positions: make(map[string]float64),
+		Timestamp: time.Now(),
+	}
+
+	for _, balance := range balances {
+		value, err := v.Convert(ctx, balance.Total, balance.Asset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to value %s balance: %w", balance.Asset, err)
+		}
+		valuation.Cash += value
+	}
+
+	for _, position := range positions {
+		price := position.CurrentPrice
+		if price == 0 {
+			price = position.AvgPrice
+		}
+
+		quote := v.bridge
+		if _, q, ok := types.SplitSymbol(position.Symbol); ok {
+			quote = q
+		}
+
+		value, err := v.Convert(ctx, position.Quantity*price, quote)
+		if err != nil {
+			return nil, fmt.Errorf("failed to value position %s: %w", position.Symbol, err)
+		}
+		valuation.Positions[position.Symbol] = value
+		valuation.TotalValue += value
+	}
+
+	valuation.TotalValue += valuation.Cash
+	return valuation, nil
+}
+
+// Record appends a valuation to the equity curve history
+func (v *Valuator) Record(valuation types.Valuation) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.history = append(v.history, valuation)
+	if len(v.history) > v.maxHistory {
+		v.history = v.history[len(v.history)-v.maxHistory:]
+	}
+}
+
+// History returns recorded valuations within [from, to]; zero bounds are open
+func (v *Valuator) History(from, to time.Time) []types.Valuation {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var out []types.Valuation
+	for _, val := range v.history {
+		if !from.IsZero() && val.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && val.Timestamp.After(to) {
+			continue
+		}
+		out = append(out, val)
+	}
+	return out
+}
+
+// ValueAt returns the latest recorded valuation at or before t
+func (v *Valuator) ValueAt(t time.Time) (types.Valuation, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	idx := sort.Search(len(v.history), func(i int) bool {
+		return v.history[i].Timestamp.After(t)
+	})
+	if idx == 0 {
+		return types.Valuation{}, false
+	}
+	return v.history[idx-1], true
+}
+
+// rate resolves a conversion rate using direct, inverse or bridged tickers
+func (v *Valuator) rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to || (usdAliases[from] && usdAliases[to]) {
+		return 1, nil
+	}
+
+	key := from + "/" + to
+	v.mu.Lock()
+	cached, ok := v.rates[key]
+	ttl := v.cacheTTL
+	v.mu.Unlock()
+	if ok && time.Since(cached.fetched) < ttl {
+		return cached.rate, nil
+	}
+
+	rate, err := v.lookupRate(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	v.mu.Lock()
+	v.rates[key] = fxRate{rate: rate, fetched: time.Now()}
+	v.mu.Unlock()
+
+	return rate, nil
+}
+
+// lookupRate queries tickers for a conversion rate
+func (v *Valuator) lookupRate(ctx context.Context, from, to string) (float64, error) {
+	// USD is not listed on most exchanges; price against the bridge stablecoin instead
+	target := to
+	if usdAliases[to] {
+		target = v.bridge
+	}
+	source := from
+	if usdAliases[from] {
+		source = v.bridge
+	}
+	if source == target {
+		return 1, nil
+	}
+
+	if price, err := v.tickerPrice(ctx, source+target); err == nil {
+		return price, nil
+	}
+	if price, err := v.tickerPrice(ctx, target+source); err == nil {
+		return 1 / price, nil
+	}
+
+	// Cross through the bridge asset
+	if source != v.bridge && target != v.bridge {
+		first, err := v.rate(ctx, source, v.bridge)
+		if err != nil {
+			return 0, err
+		}
+		second, err := v.rate(ctx, v.bridge, target)
+		if err != nil {
+			return 0, err
+		}
+		return first * second, nil
+	}
+
+	return 0, fmt.Errorf("no conversion rate for %s/%s", from, to)
+}
+
+// tickerPrice fetches a positive ticker price
+func (v *Valuator) tickerPrice(ctx context.Context, symbol string) (float64, error) {
+	ticker, err := v.exchange.GetTicker(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if ticker == nil || ticker.Price <= 0 {
+		return 0, fmt.Errorf("invalid price for %s", symbol)
+	}
+	return ticker.Price, nil
+}
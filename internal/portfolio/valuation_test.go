@@ -0,0 +1,103 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// priceExchange serves tickers from a fixed price table
+type priceExchange struct {
+	*mock.MockClient
+	prices map[string]float64
+	calls  int
+}
+
+func (p *priceExchange) GetTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	p.calls++
+	price, ok := p.prices[symbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown symbol %s", symbol)
+	}
+	return &types.Ticker{Symbol: symbol, Price: price, Timestamp: time.Now()}, nil
+}
+
+func newPriceExchange() *priceExchange {
+	return &priceExchange{
+		MockClient: mock.NewMockClient(),
+		prices: map[string]float64{
+			"BTCUSDT": 50000.0,
+			"EURUSDT": 1.25,
+			"ETHBTC":  0.05,
+		},
+	}
+}
+
+func TestValuator_Rate(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		asset    string
+		want     float64
+	}{
+		{name: "same currency", currency: "USD", asset: "USDT", want: 1},
+		{name: "direct ticker", currency: "USD", asset: "BTC", want: 50000},
+		{name: "inverse ticker", currency: "EUR", asset: "USDT", want: 0.8},
+		{name: "cross via bridge", currency: "EUR", asset: "BTC", want: 40000},
+		{name: "btc reporting", currency: "BTC", asset: "ETH", want: 0.05},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValuator(newPriceExchange(), logger.New(logger.LevelError), tt.currency)
+			got, err := v.Rate(context.Background(), tt.asset)
+			if err != nil {
+				t.Fatalf("Rate() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Rate() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValuator_ValueAndCache(t *testing.T) {
+	exchange := newPriceExchange()
+	v := NewValuator(exchange, logger.New(logger.LevelError), "EUR")
+
+	positions := []types.Position{{Symbol: "BTCUSDT", Quantity: 0.1, CurrentPrice: 50000}}
+	balances := []types.Balance{{Asset: "USDT", Total: 1000}}
+
+	valuation, err := v.Value(context.Background(), positions, balances)
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if math.Abs(valuation.Cash-800) > 1e-9 {
+		t.Errorf("Expected cash 800 EUR, got %f", valuation.Cash)
+	}
+	if math.Abs(valuation.TotalValue-4800) > 1e-9 {
+		t.Errorf("Expected total 4800 EUR, got %f", valuation.TotalValue)
+	}
+
+	calls := exchange.calls
+	if _, err := v.Value(context.Background(), positions, balances); err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if exchange.calls != calls {
+		t.Errorf("Expected cached rates to be reused, got %d extra ticker calls", exchange.calls-calls)
+	}
+
+	v.Record(*valuation)
+	if _, ok := v.ValueAt(valuation.Timestamp); !ok {
+		t.Error("Expected recorded valuation to be found")
+	}
+	if _, ok := v.ValueAt(valuation.Timestamp.Add(-time.Hour)); ok {
+		t.Error("Expected no valuation before first record")
+	}
+}
@@ -0,0 +1,22 @@
+package risk
+
+import (
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// FundingCost returns the funding paid on a perpetual position held for hold.
+// notional is signed (long > 0, short < 0); a negative result is funding received.
+func FundingCost(rate types.FundingRate, notional float64, hold time.Duration) float64 {
+	if rate.Interval <= 0 {
+		return 0
+	}
+	return notional * rate.Rate * float64(hold) / float64(rate.Interval)
+}
+
+// BorrowCost returns the interest on a margin loan of amount (in the borrowed
+// asset) held for hold
+func BorrowCost(rate types.BorrowRate, amount float64, hold time.Duration) float64 {
+	return amount * rate.HourlyRate * hold.Hours()
+}
@@ -0,0 +1,43 @@
+package risk
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestFundingCost(t *testing.T) {
+	rate := types.FundingRate{Rate: 0.0001, Interval: 8 * time.Hour}
+
+	tests := []struct {
+		name     string
+		notional float64
+		hold     time.Duration
+		want     float64
+	}{
+		{"long pays one interval", 10000, 8 * time.Hour, 1},
+		{"short receives a day", -10000, 24 * time.Hour, -3},
+		{"half interval", 10000, 4 * time.Hour, 0.5},
+	}
+	for _, tt := range tests {
+		if got := FundingCost(rate, tt.notional, tt.hold); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: FundingCost = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := FundingCost(types.FundingRate{Rate: 0.01}, 1000, time.Hour); got != 0 {
+		t.Errorf("zero interval should cost nothing, got %v", got)
+	}
+}
+
+func TestBorrowCost(t *testing.T) {
+	rate := types.BorrowRate{Asset: "BTC", HourlyRate: 0.00001}
+	if got := BorrowCost(rate, 2, 48*time.Hour); math.Abs(got-0.00096) > 1e-12 {
+		t.Errorf("BorrowCost = %v, want 0.00096", got)
+	}
+	if got := rate.DailyRate(); math.Abs(got-0.00024) > 1e-12 {
+		t.Errorf("DailyRate = %v, want 0.00024", got)
+	}
+}
@@ -0,0 +1,203 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrDeleveraged is returned for buys rejected while drawdown halts trading
+var ErrDeleveraged = errors.New("trading halted by drawdown")
+
+// DeleverageLevel scales buys to Scale once drawdown reaches Drawdown
+type DeleverageLevel struct {
+	Drawdown float64 `json:"drawdown"` // fraction below the equity peak, e.g. 0.1
+	Scale    float64 `json:"scale"`    // order size multiplier; 0 halts new buys
+}
+
+// DeleverageConfig configures drawdown-based deleveraging (disabled without levels)
+type DeleverageConfig struct {
+	Levels []DeleverageLevel `json:"levels"`
+
+	// Recovery is how far drawdown must fall below a level's threshold before
+	// sizes step back up, so equity hovering at a threshold does not flip scales
+	Recovery float64 `json:"recovery"`
+}
+
+// Enabled reports whether any deleverage level is configured
+func (c DeleverageConfig) Enabled() bool {
+	return len(c.Levels) > 0
+}
+
+// Validate checks that levels deepen while scales shrink
+func (c DeleverageConfig) Validate() error {
+	for i, level := range c.Levels {
+		if level.Drawdown <= 0 || level.Drawdown >= 1 {
+			return fmt.Errorf("deleverage level %d: drawdown must be between 0 and 1", i)
+		}
+		if level.Scale < 0 || level.Scale >= 1 {
+			return fmt.Errorf("deleverage level %d: scale must be at least 0 and below 1", i)
+		}
+		if i > 0 && level.Drawdown <= c.Levels[i-1].Drawdown {
+			return fmt.Errorf("deleverage level %d: drawdowns must increase", i)
+		}
+		if i > 0 && level.Scale > c.Levels[i-1].Scale {
+			return fmt.Errorf("deleverage level %d: scales must not increase", i)
+		}
+	}
+	if c.Recovery < 0 || c.Recovery >= 1 {
+		return fmt.Errorf("deleverage recovery must be at least 0 and below 1")
+	}
+	return nil
+}
+
+// Deleverager scales every buy by the level the portfolio drawdown has
+// reached and restores sizes as equity recovers. Sells are never touched, so
+// positions can always be reduced.
+type Deleverager struct {
+	mu  sync.Mutex
+	cfg DeleverageConfig
+
+	peak     float64
+	equity   float64
+	level    int // levels reached; 0 is full size
+	scaled   int
+	rejected int
+	updated  time.Time
+}
+
+// NewDeleverager creates a deleverager for cfg
+func NewDeleverager(cfg DeleverageConfig) *Deleverager {
+	return &Deleverager{cfg: cfg}
+}
+
+// Update records portfolio equity, moving the high-water mark and level.
+// Non-positive equity (no valuation yet) is ignored.
+func (d *Deleverager) Update(equity float64) {
+	if equity <= 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.equity = equity
+	d.updated = time.Now()
+	if equity > d.peak {
+		d.peak = equity
+	}
+
+	drawdown := d.drawdown()
+	reached, held := 0, 0
+	for _, level := range d.cfg.Levels {
+		if drawdown >= level.Drawdown {
+			reached++
+		}
+		if drawdown > level.Drawdown-d.cfg.Recovery {
+			held++
+		}
+	}
+	// Step down at once, step back up only past the recovery margin
+	d.level = max(reached, min(d.level, held))
+}
+
+// Scale returns the current order size multiplier
+func (d *Deleverager) Scale() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.scale()
+}
+
+func (d *Deleverager) scale() float64 {
+	if d.level == 0 {
+		return 1
+	}
+	return d.cfg.Levels[d.level-1].Scale
+}
+
+func (d *Deleverager) drawdown() float64 {
+	if d.peak <= 0 {
+		return 0
+	}
+	return 1 - d.equity/d.peak
+}
+
+// Run feeds equity into the deleverager every interval until ctx is done
+func (d *Deleverager) Run(ctx context.Context, interval time.Duration, equity func() float64) {
+	d.Update(equity())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Update(equity())
+		}
+	}
+}
+
+// Status reports the drawdown, active level and order counters
+func (d *Deleverager) Status() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	scale := d.scale()
+	return map[string]interface{}{
+		"peak_equity":     d.peak,
+		"equity":          d.equity,
+		"drawdown":        d.drawdown(),
+		"level":           d.level,
+		"scale":           scale,
+		"halted":          scale == 0,
+		"scaled_orders":   d.scaled,
+		"rejected_orders": d.rejected,
+		"last_update":     d.updated,
+	}
+}
+
+// apply scales a buy order, or rejects it while halted
+func (d *Deleverager) apply(order types.Order) (types.Order, error) {
+	if order.Side != types.OrderSideBuy {
+		return order, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	scale := d.scale()
+	switch {
+	case scale == 0:
+		d.rejected++
+		return order, fmt.Errorf("%w: drawdown %.1f%%", ErrDeleveraged, d.drawdown()*100)
+	case scale < 1:
+		d.scaled++
+		order.Quantity *= scale
+	}
+	return order, nil
+}
+
+// Client wraps an exchange client so buys are sized by the deleverager
+func (d *Deleverager) Client(exchange types.ExchangeClient) types.ExchangeClient {
+	return &deleveragedClient{ExchangeClient: exchange, deleverager: d}
+}
+
+// deleveragedClient applies a Deleverager on PlaceOrder
+type deleveragedClient struct {
+	types.ExchangeClient
+	deleverager *Deleverager
+}
+
+// PlaceOrder scales buys to the current level before placing them
+func (c *deleveragedClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	order, err := c.deleverager.apply(order)
+	if err != nil {
+		return err
+	}
+	return c.ExchangeClient.PlaceOrder(ctx, order)
+}
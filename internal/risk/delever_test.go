@@ -0,0 +1,100 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// recordingClient keeps placed orders
+type recordingClient struct {
+	types.ExchangeClient
+	orders []types.Order
+}
+
+func (c *recordingClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	c.orders = append(c.orders, order)
+	return nil
+}
+
+func TestDeleveragerLevelsAndRecovery(t *testing.T) {
+	cfg := DeleverageConfig{
+		Levels:   []DeleverageLevel{{Drawdown: 0.1, Scale: 0.5}, {Drawdown: 0.2, Scale: 0}},
+		Recovery: 0.02,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	d := NewDeleverager(cfg)
+
+	steps := []struct {
+		equity float64
+		scale  float64
+	}{
+		{1000, 1},
+		{1100, 1},  // new peak
+		{985, 0.5}, // -10.5%
+		{870, 0},   // -20.9% halts
+		{885, 0},   // -19.5% is within the recovery margin
+		{910, 0.5}, // -17.3% steps up one level
+		{1020, 1},  // -7.3%, past the margin of the first level
+		{0, 1},     // missing valuation is ignored
+		{980, 0.5}, // -10.9%
+		{1078, 1},  // -2%
+	}
+	for i, step := range steps {
+		d.Update(step.equity)
+		if got := d.Scale(); got != step.scale {
+			t.Fatalf("step %d: equity %.0f scale = %v, want %v", i, step.equity, got, step.scale)
+		}
+	}
+}
+
+func TestDeleveragerClient(t *testing.T) {
+	d := NewDeleverager(DeleverageConfig{Levels: []DeleverageLevel{{Drawdown: 0.1, Scale: 0.5}, {Drawdown: 0.2, Scale: 0}}})
+	inner := &recordingClient{}
+	client := d.Client(inner)
+	ctx := context.Background()
+
+	d.Update(1000)
+	d.Update(850)
+	if err := client.PlaceOrder(ctx, buy(100)); err != nil {
+		t.Fatalf("scaled buy: %v", err)
+	}
+	if inner.orders[0].Quantity != 0.5 {
+		t.Errorf("Expected quantity scaled to 0.5, got %v", inner.orders[0].Quantity)
+	}
+
+	d.Update(790)
+	if err := client.PlaceOrder(ctx, buy(100)); !errors.Is(err, ErrDeleveraged) {
+		t.Fatalf("buy while halted = %v, want ErrDeleveraged", err)
+	}
+	if err := client.PlaceOrder(ctx, sell(100)); err != nil {
+		t.Fatalf("sell while halted: %v", err)
+	}
+	if inner.orders[1].Quantity != 1 {
+		t.Errorf("Sells should keep their size, got %v", inner.orders[1].Quantity)
+	}
+
+	status := d.Status()
+	if status["halted"] != true || status["scaled_orders"] != 1 || status["rejected_orders"] != 1 {
+		t.Errorf("unexpected status %v", status)
+	}
+}
+
+func TestDeleverageConfigValidate(t *testing.T) {
+	invalid := []DeleverageConfig{
+		{Levels: []DeleverageLevel{{Drawdown: 0, Scale: 0.5}}},
+		{Levels: []DeleverageLevel{{Drawdown: 0.2, Scale: 0.5}, {Drawdown: 0.1, Scale: 0}}},
+		{Levels: []DeleverageLevel{{Drawdown: 0.1, Scale: 0.2}, {Drawdown: 0.2, Scale: 0.5}}},
+		{Levels: []DeleverageLevel{{Drawdown: 0.1, Scale: 1.5}}},
+		{Levels: []DeleverageLevel{{Drawdown: 0.1, Scale: 0.5}}, Recovery: -0.1},
+	}
+	for i, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("config %d: expected validation error", i)
+		}
+	}
+}
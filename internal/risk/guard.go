@@ -0,0 +1,248 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrCircuitOpen is returned for orders rejected while the guard is tripped
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// GuardConfig configures the order anomaly guard (disabled without limits)
+type GuardConfig struct {
+	// MaxOrdersPerMinute trips the breaker when more orders than this are
+	// placed within any minute
+	MaxOrdersPerMinute int `json:"max_orders_per_minute"`
+
+	// MaxFlips trips the breaker when a symbol's orders switch between buy
+	// and sell more often than this within FlipWindow (default 10m)
+	MaxFlips   int           `json:"max_flips"`
+	FlipWindow time.Duration `json:"flip_window"`
+
+	// Cooldown closes the breaker again after it tripped; 0 keeps it open
+	// until an operator resets it
+	Cooldown time.Duration `json:"cooldown"`
+}
+
+// UnmarshalJSON implements custom parsing for durations ("10m", "1h")
+func (c *GuardConfig) UnmarshalJSON(data []byte) error {
+	type Alias GuardConfig
+	aux := &struct {
+		FlipWindow string `json:"flip_window"`
+		Cooldown   string `json:"cooldown"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"flip_window", aux.FlipWindow, &c.FlipWindow},
+		{"cooldown", aux.Cooldown, &c.Cooldown},
+	} {
+		if field.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(field.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s format: %w", field.name, err)
+		}
+		*field.dst = duration
+	}
+	return nil
+}
+
+// Enabled reports whether any limit is configured
+func (c GuardConfig) Enabled() bool {
+	return c.MaxOrdersPerMinute > 0 || c.MaxFlips > 0
+}
+
+// Validate checks that limits and durations are not negative
+func (c GuardConfig) Validate() error {
+	if c.MaxOrdersPerMinute < 0 || c.MaxFlips < 0 {
+		return fmt.Errorf("guard limits must not be negative")
+	}
+	if c.FlipWindow < 0 || c.Cooldown < 0 {
+		return fmt.Errorf("guard durations must not be negative")
+	}
+	return nil
+}
+
+// guardOrder is a placed order as the guard remembers it
+type guardOrder struct {
+	time   time.Time
+	symbol string
+	side   types.OrderSide
+}
+
+// Guard is a circuit breaker against the bot misbehaving: a logic bug that
+// fires orders in a loop or flip-flops between buying and selling burns fees
+// long before a drawdown limit notices. Once tripped, every order is rejected
+// until the cooldown ends or the guard is reset.
+type Guard struct {
+	mu  sync.Mutex
+	cfg GuardConfig
+	now func() time.Time
+
+	orders    []guardOrder // placed within the flip window or the last minute
+	tripped   bool
+	reason    string
+	trippedAt time.Time
+	trips     int
+	rejected  int
+}
+
+// NewGuard creates a guard for cfg
+func NewGuard(cfg GuardConfig) *Guard {
+	if cfg.FlipWindow <= 0 {
+		cfg.FlipWindow = 10 * time.Minute
+	}
+	return &Guard{cfg: cfg, now: time.Now}
+}
+
+// Check rejects order while the breaker is open, and trips it when placing
+// order would exceed a limit
+func (g *Guard) Check(order types.Order) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.prune(now)
+	if g.tripped && g.cfg.Cooldown > 0 && !now.Before(g.trippedAt.Add(g.cfg.Cooldown)) {
+		g.tripped, g.reason = false, ""
+	}
+	if !g.tripped {
+		if reason := g.anomaly(now, order); reason != "" {
+			g.tripped, g.reason, g.trippedAt = true, reason, now
+			g.trips++
+		}
+	}
+	if g.tripped {
+		g.rejected++
+		return fmt.Errorf("%w: %s", ErrCircuitOpen, g.reason)
+	}
+	return nil
+}
+
+// anomaly returns why placing order now would be abnormal, or ""
+func (g *Guard) anomaly(now time.Time, order types.Order) string {
+	if limit := g.cfg.MaxOrdersPerMinute; limit > 0 && g.countSince(now.Add(-time.Minute)) >= limit {
+		return fmt.Sprintf("more than %d orders per minute", limit)
+	}
+	if limit := g.cfg.MaxFlips; limit > 0 {
+		flips, last := 0, order.Side
+		for i := len(g.orders) - 1; i >= 0; i-- {
+			o := g.orders[i]
+			if o.symbol != order.Symbol || !o.time.After(now.Add(-g.cfg.FlipWindow)) {
+				continue
+			}
+			if o.side != last {
+				flips++
+			}
+			last = o.side
+		}
+		if flips > limit {
+			return fmt.Sprintf("%s switched between buy and sell %d times within %s", order.Symbol, flips, g.cfg.FlipWindow)
+		}
+	}
+	return ""
+}
+
+// Record books an order the exchange accepted
+func (g *Guard) Record(order types.Order) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.orders = append(g.orders, guardOrder{time: g.now(), symbol: order.Symbol, side: order.Side})
+}
+
+// Reset closes a tripped breaker
+func (g *Guard) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tripped, g.reason = false, ""
+	g.orders = nil
+}
+
+// Tripped reports whether the breaker is open and why
+func (g *Guard) Tripped() (bool, string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.tripped, g.reason
+}
+
+// Status reports the breaker state and order counters
+func (g *Guard) Status() map[string]interface{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.prune(now)
+	status := map[string]interface{}{
+		"tripped":            g.tripped,
+		"trips":              g.trips,
+		"orders_last_minute": g.countSince(now.Add(-time.Minute)),
+		"rejected_orders":    g.rejected,
+	}
+	if g.tripped {
+		status["reason"] = g.reason
+		status["tripped_at"] = g.trippedAt
+		if g.cfg.Cooldown > 0 {
+			status["until"] = g.trippedAt.Add(g.cfg.Cooldown)
+		}
+	}
+	return status
+}
+
+// prune drops orders older than both the flip window and a minute
+func (g *Guard) prune(now time.Time) {
+	cutoff := now.Add(-max(g.cfg.FlipWindow, time.Minute))
+	i := 0
+	for i < len(g.orders) && !g.orders[i].time.After(cutoff) {
+		i++
+	}
+	g.orders = g.orders[i:]
+}
+
+func (g *Guard) countSince(since time.Time) int {
+	count := 0
+	for i := len(g.orders) - 1; i >= 0 && g.orders[i].time.After(since); i-- {
+		count++
+	}
+	return count
+}
+
+// Client wraps an exchange client so every order passes the guard
+func (g *Guard) Client(exchange types.ExchangeClient) types.ExchangeClient {
+	return &guardedClient{ExchangeClient: exchange, guard: g}
+}
+
+// guardedClient enforces a Guard on PlaceOrder
+type guardedClient struct {
+	types.ExchangeClient
+	guard *Guard
+}
+
+// PlaceOrder rejects orders while the breaker is open and records placed ones
+func (c *guardedClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	if err := c.guard.Check(order); err != nil {
+		return err
+	}
+	if err := c.ExchangeClient.PlaceOrder(ctx, order); err != nil {
+		return err
+	}
+	c.guard.Record(order)
+	return nil
+}
@@ -0,0 +1,107 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func sideOrder(side types.OrderSide) types.Order {
+	return types.Order{Symbol: "BTCUSDT", Side: side, Type: types.OrderTypeMarket, Quantity: 0.01, Price: 50000}
+}
+
+func TestGuard_OrdersPerMinute(t *testing.T) {
+	g := NewGuard(GuardConfig{MaxOrdersPerMinute: 3, Cooldown: 5 * time.Minute})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+	inner := &recordingClient{}
+	client := g.Client(inner)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := client.PlaceOrder(ctx, sideOrder(types.OrderSideBuy)); err != nil {
+			t.Fatalf("order %d: %v", i, err)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	if err := client.PlaceOrder(ctx, sideOrder(types.OrderSideBuy)); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("fourth order within a minute: err = %v, want ErrCircuitOpen", err)
+	}
+
+	// Open until the cooldown ends, even once the rate is back to normal
+	now = now.Add(2 * time.Minute)
+	if err := client.PlaceOrder(ctx, sideOrder(types.OrderSideSell)); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("order during cooldown: err = %v, want ErrCircuitOpen", err)
+	}
+	now = now.Add(4 * time.Minute)
+	if err := client.PlaceOrder(ctx, sideOrder(types.OrderSideBuy)); err != nil {
+		t.Fatalf("order after cooldown: %v", err)
+	}
+
+	if len(inner.orders) != 4 {
+		t.Errorf("placed %d orders, want 4", len(inner.orders))
+	}
+	status := g.Status()
+	if status["trips"] != 1 || status["rejected_orders"] != 2 || status["tripped"] != false {
+		t.Errorf("status = %v", status)
+	}
+}
+
+func TestGuard_FlipFlopping(t *testing.T) {
+	g := NewGuard(GuardConfig{MaxFlips: 2})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+	client := g.Client(&recordingClient{})
+	ctx := context.Background()
+
+	// Buy, sell, buy: two flips. Another symbol does not count.
+	for _, side := range []types.OrderSide{types.OrderSideBuy, types.OrderSideSell, types.OrderSideBuy} {
+		if err := client.PlaceOrder(ctx, sideOrder(side)); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+	other := sideOrder(types.OrderSideSell)
+	other.Symbol = "ETHUSDT"
+	if err := client.PlaceOrder(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PlaceOrder(ctx, sideOrder(types.OrderSideBuy)); err != nil {
+		t.Fatalf("repeated side is not a flip: %v", err)
+	}
+
+	err := client.PlaceOrder(ctx, sideOrder(types.OrderSideSell))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third flip: err = %v, want ErrCircuitOpen", err)
+	}
+	if tripped, reason := g.Tripped(); !tripped || reason == "" {
+		t.Fatalf("Tripped() = %v, %q", tripped, reason)
+	}
+
+	// Without a cooldown only a reset closes the breaker
+	now = now.Add(time.Hour)
+	if err := client.PlaceOrder(ctx, sideOrder(types.OrderSideSell)); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	g.Reset()
+	if err := client.PlaceOrder(ctx, sideOrder(types.OrderSideSell)); err != nil {
+		t.Fatalf("after reset: %v", err)
+	}
+}
+
+func TestGuardConfig_JSON(t *testing.T) {
+	var cfg GuardConfig
+	if err := json.Unmarshal([]byte(`{"max_flips": 4, "flip_window": "15m", "cooldown": "1h"}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Enabled() || cfg.FlipWindow != 15*time.Minute || cfg.Cooldown != time.Hour {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if err := (GuardConfig{MaxOrdersPerMinute: -1}).Validate(); err == nil {
+		t.Error("expected an error for a negative limit")
+	}
+}
@@ -0,0 +1,317 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrRestricted is returned for buys of a blacklisted symbol or outside its
+// trading hours
+var ErrRestricted = errors.New("symbol restricted")
+
+// TradingWindow is a daily window in which new entries are allowed
+type TradingWindow struct {
+	Start   string   `json:"start"`             // "HH:MM"
+	End     string   `json:"end"`               // "HH:MM", exclusive; at or before Start the window runs past midnight
+	Days    []string `json:"days,omitempty"`    // weekdays the window opens on, e.g. ["sat", "sun"]; empty means every day
+	Symbols []string `json:"symbols,omitempty"` // symbols it applies to; empty means all
+}
+
+// SymbolRules blacklists symbols and limits the hours they may be entered
+// in. A symbol covered by trading windows is only bought inside one of them;
+// symbols no window covers are not limited.
+type SymbolRules struct {
+	Blacklist []string        `json:"blacklist,omitempty"`
+	Hours     []TradingWindow `json:"hours,omitempty"`
+}
+
+// RestrictionsConfig holds rules for every strategy and rules for single
+// strategies, keyed by strategy id or type. A buy has to pass both.
+type RestrictionsConfig struct {
+	SymbolRules
+	Timezone   string                 `json:"timezone,omitempty"` // of the trading hours (default UTC)
+	Strategies map[string]SymbolRules `json:"strategies,omitempty"`
+}
+
+// Enabled reports whether any rule is configured
+func (c RestrictionsConfig) Enabled() bool {
+	if len(c.Blacklist) > 0 || len(c.Hours) > 0 {
+		return true
+	}
+	for _, rules := range c.Strategies {
+		if len(rules.Blacklist) > 0 || len(rules.Hours) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks times, weekdays and the timezone
+func (c RestrictionsConfig) Validate() error {
+	_, err := compileRestrictions(c)
+	return err
+}
+
+// window is a parsed TradingWindow
+type window struct {
+	start, end time.Duration         // since midnight
+	days       map[time.Weekday]bool // nil means every day
+	symbols    map[string]bool       // nil means all
+}
+
+// covers reports whether the window applies to symbol
+func (w window) covers(symbol string) bool {
+	return w.symbols == nil || w.symbols[symbol]
+}
+
+// open reports whether t falls inside the window
+func (w window) open(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	opensOn := func(day time.Weekday) bool { return w.days == nil || w.days[day] }
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end && opensOn(t.Weekday())
+	}
+	// Past midnight: the early hours belong to the window opened the day before
+	if offset >= w.start {
+		return opensOn(t.Weekday())
+	}
+	return offset < w.end && opensOn((t.Weekday()+6)%7)
+}
+
+// rules is a parsed SymbolRules
+type rules struct {
+	blacklist map[string]bool
+	hours     []window
+}
+
+// check returns why buying symbol at t breaks the rules, or ""
+func (r rules) check(symbol string, t time.Time) string {
+	if r.blacklist[symbol] {
+		return fmt.Sprintf("%s is blacklisted", symbol)
+	}
+	covered := false
+	for _, w := range r.hours {
+		if !w.covers(symbol) {
+			continue
+		}
+		if w.open(t) {
+			return ""
+		}
+		covered = true
+	}
+	if covered {
+		return fmt.Sprintf("%s is outside its trading hours", symbol)
+	}
+	return ""
+}
+
+// compiled is a parsed RestrictionsConfig
+type compiled struct {
+	location   *time.Location
+	global     rules
+	strategies map[string]rules
+}
+
+func compileRestrictions(cfg RestrictionsConfig) (*compiled, error) {
+	location := time.UTC
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+		location = loc
+	}
+	global, err := compileRules(cfg.SymbolRules)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiled{location: location, global: global, strategies: make(map[string]rules, len(cfg.Strategies))}
+	for strategy, symbolRules := range cfg.Strategies {
+		r, err := compileRules(symbolRules)
+		if err != nil {
+			return nil, fmt.Errorf("strategy %s: %w", strategy, err)
+		}
+		c.strategies[strategy] = r
+	}
+	return c, nil
+}
+
+func compileRules(cfg SymbolRules) (rules, error) {
+	r := rules{blacklist: symbolSet(cfg.Blacklist)}
+	for i, tw := range cfg.Hours {
+		start, err := parseClock(tw.Start)
+		if err != nil {
+			return rules{}, fmt.Errorf("hours[%d] start: %w", i, err)
+		}
+		end, err := parseClock(tw.End)
+		if err != nil {
+			return rules{}, fmt.Errorf("hours[%d] end: %w", i, err)
+		}
+		w := window{start: start, end: end, symbols: symbolSet(tw.Symbols)}
+		if len(tw.Symbols) == 0 {
+			w.symbols = nil
+		}
+		if len(tw.Days) > 0 {
+			w.days = make(map[time.Weekday]bool, len(tw.Days))
+			for _, name := range tw.Days {
+				day, ok := parseWeekday(name)
+				if !ok {
+					return rules{}, fmt.Errorf("hours[%d]: unknown day %q", i, name)
+				}
+				w.days[day] = true
+			}
+		}
+		r.hours = append(r.hours, w)
+	}
+	return r, nil
+}
+
+// parseClock parses "HH:MM" into the time since midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseWeekday accepts English weekday names and their three-letter
+// abbreviations in any case
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(name)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+func symbolSet(symbols []string) map[string]bool {
+	set := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		set[strings.ToUpper(symbol)] = true
+	}
+	return set
+}
+
+// Restrictions keeps buys away from blacklisted symbols and out of their
+// trading hours, for example to avoid thin overnight liquidity in some
+// alts. Sells always go through so positions can be closed. The rules can be
+// replaced while the bot runs.
+type Restrictions struct {
+	mu       sync.Mutex
+	cfg      RestrictionsConfig
+	rules    *compiled
+	now      func() time.Time
+	rejected int
+}
+
+// NewRestrictions creates restrictions enforcing cfg
+func NewRestrictions(cfg RestrictionsConfig) (*Restrictions, error) {
+	r := &Restrictions{now: time.Now}
+	if err := r.Update(cfg); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces the rules with cfg; invalid rules leave them unchanged
+func (r *Restrictions) Update(cfg RestrictionsConfig) error {
+	compiled, err := compileRestrictions(cfg)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg, r.rules = cfg, compiled
+	return nil
+}
+
+// Config returns the rules in force
+func (r *Restrictions) Config() RestrictionsConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cfg
+}
+
+// Check rejects a buy that breaks the global rules or those of the strategy
+// that decided it
+func (r *Restrictions) Check(order types.Order) error {
+	if order.Side != types.OrderSideBuy {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	symbol := strings.ToUpper(order.Symbol)
+	now := r.now().In(r.rules.location)
+	if reason := r.rules.global.check(symbol, now); reason != "" {
+		r.rejected++
+		return fmt.Errorf("%w: %s", ErrRestricted, reason)
+	}
+	if order.Decision == nil {
+		return nil
+	}
+	for _, key := range []string{order.Decision.StrategyID, order.Decision.Strategy} {
+		strategyRules, ok := r.rules.strategies[key]
+		if key == "" || !ok {
+			continue
+		}
+		if reason := strategyRules.check(symbol, now); reason != "" {
+			r.rejected++
+			return fmt.Errorf("%w: %s for %s", ErrRestricted, reason, key)
+		}
+	}
+	return nil
+}
+
+// Status reports the blacklists and the rejected order count
+func (r *Restrictions) Status() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blacklist := append([]string(nil), r.cfg.Blacklist...)
+	sort.Strings(blacklist)
+	status := map[string]interface{}{
+		"blacklist":       blacklist,
+		"trading_windows": len(r.cfg.Hours),
+		"rejected_orders": r.rejected,
+	}
+	if len(r.cfg.Strategies) > 0 {
+		strategies := make([]string, 0, len(r.cfg.Strategies))
+		for strategy := range r.cfg.Strategies {
+			strategies = append(strategies, strategy)
+		}
+		sort.Strings(strategies)
+		status["strategies"] = strategies
+	}
+	return status
+}
+
+// Client wraps an exchange client so every order passes the restrictions
+func (r *Restrictions) Client(exchange types.ExchangeClient) types.ExchangeClient {
+	return &restrictedClient{ExchangeClient: exchange, restrictions: r}
+}
+
+// restrictedClient enforces Restrictions on PlaceOrder
+type restrictedClient struct {
+	types.ExchangeClient
+	restrictions *Restrictions
+}
+
+// PlaceOrder rejects restricted buys
+func (c *restrictedClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	if err := c.restrictions.Check(order); err != nil {
+		return err
+	}
+	return c.ExchangeClient.PlaceOrder(ctx, order)
+}
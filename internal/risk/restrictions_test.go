@@ -0,0 +1,97 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestRestrictions(t *testing.T) {
+	var cfg RestrictionsConfig
+	err := json.Unmarshal([]byte(`{
+		"blacklist": ["lunausdt"],
+		"hours": [{"start": "08:00", "end": "22:00", "symbols": ["PEPEUSDT"]}],
+		"strategies": {
+			"dca-eth": {"blacklist": ["ETHUSDT"]},
+			"grid": {"hours": [{"start": "22:00", "end": "02:00", "days": ["mon"]}]}
+		}
+	}`), &cfg)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	r, err := NewRestrictions(cfg)
+	if err != nil {
+		t.Fatalf("NewRestrictions: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC) // a Monday
+	r.now = func() time.Time { return now }
+	inner := &recordingClient{}
+	client := r.Client(inner)
+	ctx := context.Background()
+
+	order := func(symbol string, side types.OrderSide, strategy, id string) types.Order {
+		o := types.Order{Symbol: symbol, Side: side, Type: types.OrderTypeMarket, Quantity: 1, Price: 1}
+		if strategy != "" {
+			o.Decision = &types.Decision{Strategy: strategy, StrategyID: id, Action: types.DecisionBuy}
+		}
+		return o
+	}
+	tests := []struct {
+		name    string
+		at      time.Time
+		order   types.Order
+		blocked bool
+	}{
+		{"blacklisted", now, order("LUNAUSDT", types.OrderSideBuy, "", ""), true},
+		{"blacklisted sell", now, order("LUNAUSDT", types.OrderSideSell, "", ""), false},
+		{"outside hours", now, order("PEPEUSDT", types.OrderSideBuy, "", ""), true},
+		{"inside hours", now.Add(-12 * time.Hour), order("PEPEUSDT", types.OrderSideBuy, "", ""), false},
+		{"not covered", now, order("BTCUSDT", types.OrderSideBuy, "", ""), false},
+		{"strategy blacklist", now, order("ETHUSDT", types.OrderSideBuy, "dca", "dca-eth"), true},
+		{"other strategy", now, order("ETHUSDT", types.OrderSideBuy, "dca", "dca-btc"), false},
+		{"overnight window", now.Add(2 * time.Hour), order("BTCUSDT", types.OrderSideBuy, "grid", "grid"), false},
+		{"window closed", now.Add(4 * time.Hour), order("BTCUSDT", types.OrderSideBuy, "grid", "grid"), true},
+		{"not on tuesday", now.Add(24 * time.Hour), order("BTCUSDT", types.OrderSideBuy, "grid", "grid"), true},
+	}
+	for _, tt := range tests {
+		now = tt.at
+		err := client.PlaceOrder(ctx, tt.order)
+		if blocked := errors.Is(err, ErrRestricted); blocked != tt.blocked {
+			t.Errorf("%s: err = %v, want blocked %v", tt.name, err, tt.blocked)
+		}
+	}
+	if status := r.Status(); status["rejected_orders"] != 5 {
+		t.Errorf("status = %v", status)
+	}
+
+	// Rules are replaced at runtime; invalid ones are refused
+	if err := r.Update(RestrictionsConfig{SymbolRules: SymbolRules{Hours: []TradingWindow{{Start: "25:00", End: "01:00"}}}}); err == nil {
+		t.Fatal("invalid hours accepted")
+	}
+	if err := r.Update(RestrictionsConfig{}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := client.PlaceOrder(ctx, order("LUNAUSDT", types.OrderSideBuy, "", "")); err != nil {
+		t.Errorf("buy after the blacklist was cleared: %v", err)
+	}
+}
+
+func TestRestrictionsConfig_Validate(t *testing.T) {
+	for _, cfg := range []RestrictionsConfig{
+		{Timezone: "Mars/Olympus"},
+		{SymbolRules: SymbolRules{Hours: []TradingWindow{{Start: "8am", End: "17:00"}}}},
+		{Strategies: map[string]SymbolRules{"grid": {Hours: []TradingWindow{{Start: "08:00", End: "17:00", Days: []string{"funday"}}}}}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", cfg)
+		}
+	}
+	cfg := RestrictionsConfig{Timezone: "Europe/Berlin", SymbolRules: SymbolRules{Hours: []TradingWindow{{Start: "08:00", End: "17:00", Days: []string{"Monday", "fri"}}}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrThrottled is returned for orders rejected by a Throttle
+var ErrThrottled = errors.New("order throttled")
+
+// Throttle enforces per-strategy trading guardrails: a minimum time between
+// orders, rolling hourly/daily order caps and a cooldown after a streak of
+// losing sells. Sells that reduce a held position are counted but never
+// blocked, so guardrails cannot trap a strategy in a position.
+type Throttle struct {
+	mu  sync.Mutex
+	cfg types.ThrottleConfig
+	now func() time.Time
+
+	orders        []time.Time // accepted orders within the last 24h
+	losses        int         // current losing streak
+	cooldownUntil time.Time
+	rejected      int
+	positions     map[string]throttlePosition // per symbol, to detect losing sells
+}
+
+type throttlePosition struct {
+	quantity float64
+	avgPrice float64
+}
+
+// NewThrottle creates a throttle for cfg
+func NewThrottle(cfg types.ThrottleConfig) *Throttle {
+	return &Throttle{cfg: cfg, now: time.Now, positions: make(map[string]throttlePosition)}
+}
+
+// SetClock replaces the wall clock, e.g. with candle time in backtests
+func (t *Throttle) SetClock(now func() time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.now = now
+}
+
+// ValidateThrottle checks a throttle config
+func ValidateThrottle(cfg types.ThrottleConfig) error {
+	if cfg.MinInterval < 0 || cfg.LossCooldown < 0 {
+		return fmt.Errorf("throttle durations must not be negative")
+	}
+	if cfg.MaxPerHour < 0 || cfg.MaxPerDay < 0 || cfg.LossStreak < 0 {
+		return fmt.Errorf("throttle limits must not be negative")
+	}
+	if cfg.MaxPerDay > 0 && cfg.MaxPerHour > cfg.MaxPerDay {
+		return fmt.Errorf("max per hour %d exceeds max per day %d", cfg.MaxPerHour, cfg.MaxPerDay)
+	}
+	if (cfg.LossStreak > 0) != (cfg.LossCooldown > 0) {
+		return fmt.Errorf("loss streak and loss cooldown must be set together")
+	}
+	return nil
+}
+
+// Check returns an ErrThrottled error if order may not be placed now
+func (t *Throttle) Check(order types.Order) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkLocked(order); err != nil {
+		t.rejected++
+		return err
+	}
+	return nil
+}
+
+func (t *Throttle) checkLocked(order types.Order) error {
+	if t.reducesPosition(order) {
+		return nil
+	}
+
+	now := t.now()
+	t.prune(now)
+
+	if now.Before(t.cooldownUntil) {
+		return fmt.Errorf("%w: loss streak cooldown until %s", ErrThrottled, t.cooldownUntil.Format(time.RFC3339))
+	}
+	if n := len(t.orders); n > 0 && t.cfg.MinInterval > 0 {
+		if next := t.orders[n-1].Add(t.cfg.MinInterval); now.Before(next) {
+			return fmt.Errorf("%w: next order allowed at %s", ErrThrottled, next.Format(time.RFC3339))
+		}
+	}
+	if t.cfg.MaxPerHour > 0 && t.countSince(now.Add(-time.Hour)) >= t.cfg.MaxPerHour {
+		return fmt.Errorf("%w: %d orders in the last hour", ErrThrottled, t.cfg.MaxPerHour)
+	}
+	if t.cfg.MaxPerDay > 0 && len(t.orders) >= t.cfg.MaxPerDay {
+		return fmt.Errorf("%w: %d orders in the last 24h", ErrThrottled, t.cfg.MaxPerDay)
+	}
+	return nil
+}
+
+// Record books an accepted order, updating the loss streak on sells
+func (t *Throttle) Record(order types.Order) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.orders = append(t.orders, now)
+	t.prune(now)
+
+	pos := t.positions[order.Symbol]
+	switch order.Side {
+	case types.OrderSideBuy:
+		total := pos.quantity + order.Quantity
+		if total > 0 {
+			pos.avgPrice = (pos.avgPrice*pos.quantity + order.Price*order.Quantity) / total
+		}
+		pos.quantity = total
+	case types.OrderSideSell:
+		if pos.quantity > 0 {
+			if order.Price < pos.avgPrice {
+				t.losses++
+			} else {
+				t.losses = 0
+			}
+		}
+		pos.quantity -= order.Quantity
+		if pos.quantity <= 1e-12 {
+			pos = throttlePosition{}
+		}
+	}
+	t.positions[order.Symbol] = pos
+
+	if t.cfg.LossStreak > 0 && t.losses >= t.cfg.LossStreak {
+		t.cooldownUntil = now.Add(t.cfg.LossCooldown)
+		t.losses = 0
+	}
+}
+
+// Status reports counters and the times trading is blocked until
+func (t *Throttle) Status() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.prune(now)
+
+	status := map[string]interface{}{
+		"orders_last_hour": t.countSince(now.Add(-time.Hour)),
+		"orders_last_day":  len(t.orders),
+		"loss_streak":      t.losses,
+		"rejected_orders":  t.rejected,
+	}
+	if now.Before(t.cooldownUntil) {
+		status["cooldown_until"] = t.cooldownUntil
+	}
+	if n := len(t.orders); n > 0 && t.cfg.MinInterval > 0 {
+		if next := t.orders[n-1].Add(t.cfg.MinInterval); now.Before(next) {
+			status["next_order_at"] = next
+		}
+	}
+	return status
+}
+
+// reducesPosition reports whether order is a sell against a held position
+func (t *Throttle) reducesPosition(order types.Order) bool {
+	return order.Side == types.OrderSideSell && t.positions[order.Symbol].quantity > 0
+}
+
+// prune drops orders older than 24h
+func (t *Throttle) prune(now time.Time) {
+	cutoff := now.Add(-24 * time.Hour)
+	i := 0
+	for i < len(t.orders) && !t.orders[i].After(cutoff) {
+		i++
+	}
+	t.orders = t.orders[i:]
+}
+
+func (t *Throttle) countSince(since time.Time) int {
+	count := 0
+	for i := len(t.orders) - 1; i >= 0 && t.orders[i].After(since); i-- {
+		count++
+	}
+	return count
+}
+
+// Client wraps an exchange client so every order passes the throttle
+func (t *Throttle) Client(exchange types.ExchangeClient) types.ExchangeClient {
+	return &throttledClient{ExchangeClient: exchange, throttle: t}
+}
+
+// throttledClient enforces a Throttle on PlaceOrder
+type throttledClient struct {
+	types.ExchangeClient
+	throttle *Throttle
+}
+
+// PlaceOrder rejects throttled orders and records accepted ones
+func (c *throttledClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	if err := c.throttle.Check(order); err != nil {
+		return err
+	}
+
+	if err := c.ExchangeClient.PlaceOrder(ctx, order); err != nil {
+		return err
+	}
+
+	c.throttle.Record(order)
+	return nil
+}
@@ -0,0 +1,149 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// fakeClock is a settable time source
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+func newThrottleAt(cfg types.ThrottleConfig) (*Throttle, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	throttle := NewThrottle(cfg)
+	throttle.now = clock.now
+	return throttle, clock
+}
+
+func buy(price float64) types.Order {
+	return types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1, Price: price}
+}
+
+func sell(price float64) types.Order {
+	return types.Order{Symbol: "BTCUSDT", Side: types.OrderSideSell, Quantity: 1, Price: price}
+}
+
+// place checks and records order, returning the check result
+func place(t *Throttle, order types.Order) error {
+	if err := t.Check(order); err != nil {
+		return err
+	}
+	t.Record(order)
+	return nil
+}
+
+func TestThrottleMinIntervalAndCaps(t *testing.T) {
+	throttle, clock := newThrottleAt(types.ThrottleConfig{MinInterval: 10 * time.Minute, MaxPerHour: 3, MaxPerDay: 5})
+
+	if err := place(throttle, buy(100)); err != nil {
+		t.Fatalf("first order: %v", err)
+	}
+	clock.advance(5 * time.Minute)
+	if err := place(throttle, buy(100)); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("order within min interval = %v, want ErrThrottled", err)
+	}
+
+	clock.advance(5 * time.Minute)
+	place(throttle, buy(100))
+	clock.advance(10 * time.Minute)
+	place(throttle, buy(100))
+	clock.advance(10 * time.Minute)
+	if err := place(throttle, buy(100)); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("fourth order in an hour = %v, want ErrThrottled", err)
+	}
+
+	clock.advance(time.Hour)
+	place(throttle, buy(100))
+	clock.advance(time.Hour)
+	place(throttle, buy(100))
+	clock.advance(time.Hour)
+	if err := place(throttle, buy(100)); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("sixth order in a day = %v, want ErrThrottled", err)
+	}
+
+	clock.advance(24 * time.Hour)
+	if err := place(throttle, buy(100)); err != nil {
+		t.Fatalf("order after the rolling day: %v", err)
+	}
+
+	status := throttle.Status()
+	if status["orders_last_day"] != 1 || status["rejected_orders"] != 3 {
+		t.Fatalf("unexpected status %v", status)
+	}
+	if _, ok := status["next_order_at"]; !ok {
+		t.Fatalf("status should report next_order_at, got %v", status)
+	}
+}
+
+func TestThrottleLossStreakCooldown(t *testing.T) {
+	throttle, clock := newThrottleAt(types.ThrottleConfig{LossStreak: 2, LossCooldown: 4 * time.Hour})
+
+	place(throttle, buy(100))
+	place(throttle, sell(90)) // loss 1
+	place(throttle, buy(100))
+	place(throttle, sell(110)) // win resets the streak
+	place(throttle, buy(100))
+	place(throttle, sell(95)) // loss 1
+	place(throttle, buy(100))
+	place(throttle, sell(95)) // loss 2 starts the cooldown
+
+	if err := place(throttle, buy(95)); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("buy during cooldown = %v, want ErrThrottled", err)
+	}
+	if _, ok := throttle.Status()["cooldown_until"]; !ok {
+		t.Fatalf("status should report cooldown_until")
+	}
+
+	clock.advance(4 * time.Hour)
+	if err := place(throttle, buy(95)); err != nil {
+		t.Fatalf("buy after cooldown: %v", err)
+	}
+}
+
+func TestThrottleNeverBlocksReducingSells(t *testing.T) {
+	throttle, _ := newThrottleAt(types.ThrottleConfig{MinInterval: time.Hour, MaxPerDay: 1})
+
+	place(throttle, buy(100))
+	if err := place(throttle, sell(80)); err != nil {
+		t.Fatalf("sell against a held position: %v", err)
+	}
+	if err := place(throttle, sell(80)); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("sell without a position = %v, want ErrThrottled", err)
+	}
+}
+
+func TestThrottledClient(t *testing.T) {
+	throttle, _ := newThrottleAt(types.ThrottleConfig{MaxPerHour: 1})
+	client := throttle.Client(mock.NewMockClient())
+
+	if err := client.PlaceOrder(context.Background(), buy(45000)); err != nil {
+		t.Fatalf("first order: %v", err)
+	}
+	if err := client.PlaceOrder(context.Background(), buy(45000)); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("second order = %v, want ErrThrottled", err)
+	}
+}
+
+func TestValidateThrottle(t *testing.T) {
+	invalid := []types.ThrottleConfig{
+		{MinInterval: -time.Second},
+		{MaxPerHour: 10, MaxPerDay: 5},
+		{LossStreak: 3},
+		{MaxPerDay: -1},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateThrottle(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+	if err := ValidateThrottle(types.ThrottleConfig{MinInterval: time.Minute, MaxPerHour: 5, MaxPerDay: 20, LossStreak: 3, LossCooldown: time.Hour}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package scripting evaluates user Starlark scripts in the execution pipeline.
+//
+// A signal filter script defines
+//
+//	def filter(signal, market):
+//	    ...
+//
+// and returns True/None to keep the signal, False to veto it, a number to
+// scale its quantity (0 vetoes), or a dict {"quantity": q} to replace it.
+//
+// signal has fields side ("BUY"/"SELL"), symbol, price, quantity, strength.
+// market has fields symbol, price, volume, timestamp (unix), weekday
+// ("Monday".."Sunday", UTC), hour (UTC) and the method
+// indicator(interval, name), which returns a configured higher-timeframe
+// indicator such as market.indicator("1h", "ema_200") or None when it is
+// unavailable. market.sentiment and market.sentiment_momentum are the
+// symbol's recorded sentiment score (-1 to 1) and its change over the
+// momentum window, or None when no sentiment is recorded. Builtins rsi(period=14),
+// sma(period) and ema(period) are computed over observed prices and return
+// None until enough history is available.
+package scripting
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/indicators"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+const (
+	// filterFunc is the function every filter script must define
+	filterFunc = "filter"
+
+	// maxSteps bounds the work a single filter call may do
+	maxSteps = 1_000_000
+
+	// maxHistory is the number of observed prices kept for indicators
+	maxHistory = 500
+)
+
+// StarlarkFilter vetoes or resizes signals using a Starlark script
+type StarlarkFilter struct {
+	name   string
+	fn     *starlark.Function
+	logger *logger.Logger
+
+	mu     sync.Mutex
+	prices []float64
+}
+
+// LoadFilter creates a filter from inline source or a script file
+func LoadFilter(cfg types.SignalFilterConfig, log *logger.Logger) (*StarlarkFilter, error) {
+	if cfg.Script != "" {
+		return NewStarlarkFilter("filter.star", cfg.Script, log)
+	}
+	if cfg.File == "" {
+		return nil, fmt.Errorf("filter script or file is required")
+	}
+
+	src, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filter script: %w", err)
+	}
+	return NewStarlarkFilter(cfg.File, string(src), log)
+}
+
+// NewStarlarkFilter compiles source and resolves its filter function
+func NewStarlarkFilter(name, source string, log *logger.Logger) (*StarlarkFilter, error) {
+	f := &StarlarkFilter{name: name, logger: log}
+
+	thread := f.newThread()
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, name, source, f.builtins())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load filter script %s: %w", name, err)
+	}
+
+	fn, ok := globals[filterFunc].(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("filter script %s must define %s(signal, market)", name, filterFunc)
+	}
+	if fn.NumParams() != 2 {
+		return nil, fmt.Errorf("filter script %s: %s must take 2 parameters, got %d", name, filterFunc, fn.NumParams())
+	}
+	f.fn = fn
+	return f, nil
+}
+
+// Observe records the market price for indicator builtins
+func (f *StarlarkFilter) Observe(market types.MarketData) {
+	if market.Price <= 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.prices = append(f.prices, market.Price)
+	if len(f.prices) > maxHistory {
+		f.prices = f.prices[len(f.prices)-maxHistory:]
+	}
+}
+
+// Filter runs the script; it returns the adjusted signal and false when vetoed
+func (f *StarlarkFilter) Filter(signal types.Signal, market types.MarketData) (types.Signal, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result, err := starlark.Call(f.newThread(), f.fn, starlark.Tuple{signalValue(signal), marketValue(market)}, nil)
+	if err != nil {
+		return signal, false, fmt.Errorf("filter script %s failed: %w", f.name, err)
+	}
+
+	switch v := result.(type) {
+	case starlark.NoneType:
+		return signal, true, nil
+	case starlark.Bool:
+		return signal, bool(v), nil
+	case starlark.Int, starlark.Float:
+		factor, _ := starlark.AsFloat(v)
+		if factor <= 0 {
+			return signal, false, nil
+		}
+		signal.Quantity *= factor
+		return signal, true, nil
+	case *starlark.Dict:
+		q, found, err := v.Get(starlark.String("quantity"))
+		if err != nil || !found {
+			return signal, false, fmt.Errorf("filter script %s: dict result needs a quantity", f.name)
+		}
+		quantity, ok := starlark.AsFloat(q)
+		if !ok {
+			return signal, false, fmt.Errorf("filter script %s: quantity must be a number, got %s", f.name, q.Type())
+		}
+		if quantity <= 0 {
+			return signal, false, nil
+		}
+		signal.Quantity = quantity
+		return signal, true, nil
+	default:
+		return signal, false, fmt.Errorf("filter script %s returned unsupported %s", f.name, result.Type())
+	}
+}
+
+func (f *StarlarkFilter) newThread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: f.name,
+		Print: func(_ *starlark.Thread, msg string) {
+			if f.logger != nil {
+				f.logger.Info("[%s] %s", f.name, msg)
+			}
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	return thread
+}
+
+// builtins exposes indicators over observed prices; they run with f.mu held
+func (f *StarlarkFilter) builtins() starlark.StringDict {
+	indicator := func(name string, defaultPeriod int, calc func(prices []float64, period int) []float64) *starlark.Builtin {
+		return starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			period := defaultPeriod
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "period?", &period); err != nil {
+				return nil, err
+			}
+			if period <= 0 {
+				return nil, fmt.Errorf("%s: period must be positive", b.Name())
+			}
+			values := calc(f.prices, period)
+			if len(values) == 0 {
+				return starlark.None, nil
+			}
+			return starlark.Float(values[len(values)-1]), nil
+		})
+	}
+
+	return starlark.StringDict{
+		"rsi":    indicator("rsi", 14, indicators.RSI),
+		"sma":    indicator("sma", 20, indicators.SMA),
+		"ema":    indicator("ema", 20, indicators.EMA),
+		"struct": starlark.NewBuiltin("struct", starlarkstruct.Make),
+	}
+}
+
+func signalValue(signal types.Signal) starlark.Value {
+	return starlarkstruct.FromStringDict(starlark.String("signal"), starlark.StringDict{
+		"side":     starlark.String(signal.Type),
+		"symbol":   starlark.String(signal.Symbol),
+		"price":    starlark.Float(signal.Price),
+		"quantity": starlark.Float(signal.Quantity),
+		"strength": starlark.Float(signal.Strength),
+	})
+}
+
+func marketValue(market types.MarketData) starlark.Value {
+	ts := market.Timestamp.UTC()
+	var sentiment, momentum starlark.Value = starlark.None, starlark.None
+	if market.Sentiment != nil {
+		sentiment, momentum = starlark.Float(market.Sentiment.Score), starlark.Float(market.Sentiment.Momentum)
+	}
+	return starlarkstruct.FromStringDict(starlark.String("market"), starlark.StringDict{
+		"symbol":    starlark.String(market.Symbol),
+		"price":     starlark.Float(market.Price),
+		"volume":    starlark.Float(market.Volume),
+		"timestamp": starlark.MakeInt64(ts.Unix()),
+		"weekday":   starlark.String(ts.Weekday().String()),
+		"hour":      starlark.MakeInt(ts.Hour()),
+
+		"sentiment":          sentiment,
+		"sentiment_momentum": momentum,
+		"indicator": starlark.NewBuiltin("indicator", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var interval, name string
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "interval", &interval, "name", &name); err != nil {
+				return nil, err
+			}
+			value, ok := market.Context.Indicator(interval, name)
+			if !ok {
+				return starlark.None, nil
+			}
+			return starlark.Float(value), nil
+		}),
+	})
+}
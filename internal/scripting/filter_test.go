@@ -0,0 +1,154 @@
+package scripting
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+var saturday = time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC)
+
+func newFilter(t *testing.T, src string) *StarlarkFilter {
+	t.Helper()
+	f, err := NewStarlarkFilter("test.star", src, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatalf("NewStarlarkFilter() error = %v", err)
+	}
+	return f
+}
+
+func TestStarlarkFilter_Results(t *testing.T) {
+	signal := types.Signal{Type: types.SignalTypeBuy, Symbol: "BTCUSDT", Price: 100, Quantity: 2}
+	market := types.MarketData{Symbol: "BTCUSDT", Price: 100, Timestamp: saturday}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantOK  bool
+		wantQty float64
+		wantErr bool
+	}{
+		{"none keeps", "return None", true, 2, false},
+		{"true keeps", "return True", true, 2, false},
+		{"false vetoes", "return False", false, 2, false},
+		{"scale", "return 0.5", true, 1, false},
+		{"zero vetoes", "return 0", false, 2, false},
+		{"dict replaces", `return {"quantity": 0.25}`, true, 0.25, false},
+		{"weekend veto", `return market.weekday not in ("Saturday", "Sunday")`, false, 2, false},
+		{"sell passes weekend rule", `return signal.side == "SELL" or market.hour < 12`, false, 2, false},
+		{"bad type", `return "yes"`, false, 2, true},
+		{"runtime error", `return 1 // 0`, false, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFilter(t, "def filter(signal, market):\n    "+tt.body+"\n")
+			got, ok, err := f.Filter(signal, market)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Filter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("Filter() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.Quantity != tt.wantQty {
+				t.Errorf("Filter() quantity = %v, want %v", got.Quantity, tt.wantQty)
+			}
+		})
+	}
+}
+
+func TestStarlarkFilter_RSI(t *testing.T) {
+	f := newFilter(t, `
+def filter(signal, market):
+    r = rsi(14)
+    if r == None:
+        return False
+    if r > 70:
+        return 0.5
+    return True
+`)
+	signal := types.Signal{Type: types.SignalTypeBuy, Quantity: 1}
+
+	if _, ok, _ := f.Filter(signal, types.MarketData{}); ok {
+		t.Error("Expected veto without price history")
+	}
+
+	// Steadily rising prices push RSI to 100
+	for i := 0; i < 20; i++ {
+		f.Observe(types.MarketData{Price: 100 + float64(i)})
+	}
+	got, ok, err := f.Filter(signal, types.MarketData{})
+	if err != nil || !ok {
+		t.Fatalf("Filter() = %v, %v", ok, err)
+	}
+	if got.Quantity != 0.5 {
+		t.Errorf("Expected halved quantity when RSI > 70, got %v", got.Quantity)
+	}
+}
+
+func TestStarlarkFilter_HigherTimeframe(t *testing.T) {
+	f := newFilter(t, `
+def filter(signal, market):
+    trend = market.indicator("1h", "ema_200")
+    if trend == None:
+        return True
+    return signal.side == "SELL" or market.price > trend
+`)
+	signal := types.Signal{Type: types.SignalTypeBuy, Quantity: 1}
+	market := types.MarketData{Price: 100, Context: &types.MarketContext{Timeframes: map[string]*types.Timeframe{
+		"1h": {Interval: "1h", Indicators: map[string]float64{"ema_200": 110}},
+	}}}
+
+	if _, ok, err := f.Filter(signal, market); err != nil || ok {
+		t.Errorf("Filter() = %v, %v; want buy below the 1h trend vetoed", ok, err)
+	}
+	market.Price = 120
+	if _, ok, err := f.Filter(signal, market); err != nil || !ok {
+		t.Errorf("Filter() = %v, %v; want buy above the 1h trend kept", ok, err)
+	}
+	if _, ok, err := f.Filter(signal, types.MarketData{Price: 100}); err != nil || !ok {
+		t.Errorf("Filter() = %v, %v; want signals kept without context", ok, err)
+	}
+}
+
+func TestStarlarkFilter_Sentiment(t *testing.T) {
+	f := newFilter(t, `
+def filter(signal, market):
+    if market.sentiment == None:
+        return True
+    return signal.side == "SELL" or market.sentiment_momentum > 0
+`)
+	signal := types.Signal{Type: types.SignalTypeBuy, Quantity: 1}
+	market := types.MarketData{Price: 100, Sentiment: &types.Sentiment{Score: 0.4, Momentum: -0.2}}
+
+	if _, ok, err := f.Filter(signal, market); err != nil || ok {
+		t.Errorf("Filter() = %v, %v; want buy on falling sentiment vetoed", ok, err)
+	}
+	market.Sentiment.Momentum = 0.1
+	if _, ok, err := f.Filter(signal, market); err != nil || !ok {
+		t.Errorf("Filter() = %v, %v; want buy on rising sentiment kept", ok, err)
+	}
+	if _, ok, err := f.Filter(signal, types.MarketData{Price: 100}); err != nil || !ok {
+		t.Errorf("Filter() = %v, %v; want signals kept without sentiment", ok, err)
+	}
+}
+
+func TestNewStarlarkFilter_Errors(t *testing.T) {
+	log := logger.New(logger.LevelError)
+	for name, src := range map[string]string{
+		"syntax":      "def filter(:\n",
+		"missing":     "x = 1\n",
+		"wrong arity": "def filter(signal):\n    return True\n",
+	} {
+		if _, err := NewStarlarkFilter("test.star", src, log); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if _, err := LoadFilter(types.SignalFilterConfig{}, log); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Errorf("Expected error for empty filter config, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrArbitrationBudget is returned when a buy does not fit the cash left in
+// an arbitration round
+var ErrArbitrationBudget = errors.New("order exceeds arbitration budget")
+
+// validateArbitration checks that no factor or reserve is negative
+func validateArbitration(config *types.ArbitrationConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.Strength < 0 || config.Edge < 0 || config.Weight < 0 {
+		return fmt.Errorf("arbitration factors must not be negative")
+	}
+	if config.Reserve < 0 {
+		return fmt.Errorf("arbitration reserve must not be negative")
+	}
+	return nil
+}
+
+// arbiter ranks the combo's sub-strategies each execution and shares the
+// main account's free balance among their buys in that order
+type arbiter struct {
+	config   types.ArbitrationConfig
+	exchange types.ExchangeClient
+	logger   *logger.Logger
+
+	mu       sync.Mutex
+	budget   float64
+	limited  bool // false when the balance could not be read this round
+	ranking  []string
+	scores   []float64
+	rejected map[string]int
+}
+
+func newArbiter(config types.ArbitrationConfig, exchange types.ExchangeClient, logger *logger.Logger) *arbiter {
+	if config.Strength == 0 && config.Edge == 0 && config.Weight == 0 {
+		config.Strength = 1
+	}
+	return &arbiter{
+		config:   config,
+		exchange: exchange,
+		logger:   logger,
+		rejected: make(map[string]int),
+	}
+}
+
+// score ranks a buy: the weighted sum of its signal strength, the strategy's
+// expected edge and its combo weight
+func (a *arbiter) score(signal types.Signal, edge, weight float64) float64 {
+	return a.config.Strength*signal.Strength + a.config.Edge*edge + a.config.Weight*weight
+}
+
+// begin opens a round with the free balance of symbol's quote asset less the
+// reserve and returns the order to execute the strategies in: sells first,
+// since they free cash, then buys by score, then the rest. Ties keep config
+// order.
+func (a *arbiter) begin(ctx context.Context, symbol string, ids []string, signals []types.Signal, edges, weights []float64) []int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	free, err := a.quoteBalance(ctx, symbol)
+	if err != nil {
+		a.logger.Warn("Arbitration budget unavailable, buys are not limited this round: %v", err)
+		a.limited = false
+	} else {
+		a.limited = true
+		a.budget = max(free-a.config.Reserve, 0)
+	}
+
+	rank := func(s types.Signal) int {
+		switch s.Type {
+		case types.SignalTypeSell:
+			return 0
+		case types.SignalTypeBuy:
+			return 1
+		}
+		return 2
+	}
+	scores := make([]float64, len(signals))
+	order := make([]int, len(signals))
+	for i, signal := range signals {
+		if signal.Type == types.SignalTypeBuy {
+			scores[i] = a.score(signal, edges[i], weights[i])
+		}
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		ri, rj := rank(signals[order[i]]), rank(signals[order[j]])
+		if ri != rj {
+			return ri < rj
+		}
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	a.ranking = make([]string, len(order))
+	a.scores = make([]float64, len(order))
+	for k, i := range order {
+		a.ranking[k], a.scores[k] = ids[i], scores[i]
+	}
+	return order
+}
+
+// quoteBalance returns the free balance of symbol's quote asset
+func (a *arbiter) quoteBalance(ctx context.Context, symbol string) (float64, error) {
+	_, quote, ok := types.SplitSymbol(symbol)
+	if !ok {
+		return 0, fmt.Errorf("unknown quote asset of %s", symbol)
+	}
+	balances, err := a.exchange.GetBalances(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, balance := range balances {
+		if balance.Asset == quote {
+			return balance.Free, nil
+		}
+	}
+	return 0, nil
+}
+
+// reserve takes a buy's notional out of the round's budget
+func (a *arbiter) reserve(ctx context.Context, strategyID string, order types.Order) (float64, error) {
+	if order.Side != types.OrderSideBuy {
+		return 0, nil
+	}
+	price := order.Price
+	if price == 0 {
+		ticker, err := a.exchange.GetTicker(ctx, order.Symbol)
+		if err != nil {
+			return 0, fmt.Errorf("failed to price %s for arbitration: %w", order.Symbol, err)
+		}
+		price = ticker.Price
+	}
+	notional := order.Quantity * price
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.limited {
+		return 0, nil
+	}
+	if notional > a.budget {
+		a.rejected[strategyID]++
+		return 0, fmt.Errorf("%w: %s needs %.2f, %.2f left", ErrArbitrationBudget, strategyID, notional, a.budget)
+	}
+	a.budget -= notional
+	return notional, nil
+}
+
+// settle returns a failed buy's notional to the budget, or adds a sell's
+// proceeds to it
+func (a *arbiter) settle(order types.Order, reserved float64, placed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.limited {
+		return
+	}
+	switch {
+	case !placed:
+		a.budget += reserved
+	case order.Side == types.OrderSideSell && order.Price > 0:
+		a.budget += order.Quantity * order.Price
+	}
+}
+
+// status snapshots the last round
+func (a *arbiter) status() *types.ArbitrationStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	status := &types.ArbitrationStatus{
+		Ranking: append([]string(nil), a.ranking...),
+		Scores:  append([]float64(nil), a.scores...),
+		Budget:  a.budget,
+	}
+	if len(a.rejected) > 0 {
+		status.Rejected = make(map[string]int, len(a.rejected))
+		for id, n := range a.rejected {
+			status.Rejected[id] = n
+		}
+	}
+	return status
+}
+
+// Client wraps a sub-strategy's exchange client so its buys draw on the
+// round's budget
+func (a *arbiter) Client(strategyID string, exchange types.ExchangeClient) types.ExchangeClient {
+	return &arbitratedClient{ExchangeClient: exchange, arbiter: a, strategyID: strategyID}
+}
+
+// arbitratedClient enforces the arbitration budget on PlaceOrder
+type arbitratedClient struct {
+	types.ExchangeClient
+	arbiter    *arbiter
+	strategyID string
+}
+
+// PlaceOrder rejects buys beyond the budget left and books placed orders
+func (c *arbitratedClient) PlaceOrder(ctx context.Context, order types.Order) error {
+	reserved, err := c.arbiter.reserve(ctx, c.strategyID, order)
+	if err != nil {
+		return err
+	}
+	if err := c.ExchangeClient.PlaceOrder(ctx, order); err != nil {
+		c.arbiter.settle(order, reserved, false)
+		return err
+	}
+	c.arbiter.settle(order, reserved, true)
+	return nil
+}
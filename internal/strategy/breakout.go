@@ -0,0 +1,508 @@
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/indicators"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Breakout exit reasons
+const (
+	ExitChannelLow   = "channel_low"
+	ExitTrailingStop = "trailing_stop"
+)
+
+// BreakoutStrategy is a Donchian channel breakout. It buys when price breaks
+// above the highest high of the last EntryPeriod completed bars and sells
+// the whole position when price breaks below the lowest low of the last
+// ExitPeriod bars or falls to a trailing stop ATRMultiplier ATRs below the
+// highest price since entry. Bars come from the exchange's candles; the last
+// one is taken to be still forming.
+type BreakoutStrategy struct {
+	Identity
+	config   types.BreakoutConfig
+	exchange types.ExchangeClient
+	logger   *logger.Logger
+	filter   SignalFilter
+	throttle *risk.Throttle
+
+	mu      sync.RWMutex
+	rules   symbolRules
+	skips   skipLog // buys turned down by the combo's arbitration
+	trace   *Tracer
+	feed    *candleFeed
+	channel breakoutChannel
+
+	quantity float64      // position held
+	entry    float64      // average entry price
+	peak     float64      // highest price since entry, the trailing stop's anchor
+	pending  *types.Order // placed, fill not reported yet
+
+	metrics types.StrategyMetrics
+}
+
+// breakoutChannel is the entry and exit channel and the ATR over completed bars
+type breakoutChannel struct {
+	upper, lower, atr float64
+	bars              int
+}
+
+// NewBreakoutStrategy creates a breakout strategy, filling zero periods and
+// the interval with defaults
+func NewBreakoutStrategy(config types.BreakoutConfig, exchange types.ExchangeClient, logger *logger.Logger) *BreakoutStrategy {
+	b := &BreakoutStrategy{
+		Identity: NewIdentity("breakout", config.ID, config.Name),
+		config:   breakoutDefaults(config),
+		exchange: exchange,
+		logger:   logger,
+		trace:    NewTracer("breakout"),
+	}
+	b.feed = newCandleFeed("Breakout", b.config.Symbol, b.config.Interval, b.historyCandles(), logger)
+	return b
+}
+
+func breakoutDefaults(cfg types.BreakoutConfig) types.BreakoutConfig {
+	if cfg.Interval == "" {
+		cfg.Interval = "1d"
+	}
+	if cfg.EntryPeriod <= 0 {
+		cfg.EntryPeriod = 20
+	}
+	if cfg.ExitPeriod <= 0 {
+		cfg.ExitPeriod = 10
+	}
+	if cfg.ATRPeriod <= 0 {
+		cfg.ATRPeriod = 14
+	}
+	return cfg
+}
+
+// ValidateBreakout checks a breakout config
+func ValidateBreakout(cfg types.BreakoutConfig) error {
+	if cfg.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if cfg.InvestmentAmount <= 0 {
+		return fmt.Errorf("investment amount must be positive")
+	}
+	if cfg.EntryPeriod < 0 || cfg.ExitPeriod < 0 || cfg.ATRPeriod < 0 {
+		return fmt.Errorf("breakout periods must not be negative")
+	}
+	if cfg.ATRMultiplier < 0 {
+		return fmt.Errorf("atr multiplier must not be negative")
+	}
+	if cfg.Throttle != nil {
+		if err := risk.ValidateThrottle(*cfg.Throttle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseBreakoutConfig reads a breakout config from a generic strategy
+// config map, as in a combo; enabled defaults to true
+func ParseBreakoutConfig(config map[string]interface{}) (types.BreakoutConfig, error) {
+	cfg := types.BreakoutConfig{Enabled: true}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid breakout config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid breakout config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (b *BreakoutStrategy) ValidateConfig() error {
+	return ValidateBreakout(b.config)
+}
+
+func (b *BreakoutStrategy) Execute(ctx context.Context, market types.MarketData) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trace.begin(market)
+	defer func() { b.trace.end(RuleNoBreakout, err) }()
+	if !b.config.Enabled {
+		b.trace.blocked(RuleDisabled, "strategy is disabled")
+		return nil
+	}
+	if b.filter != nil {
+		b.filter.Observe(market)
+	}
+	b.settle(ctx)
+	if b.pending != nil {
+		b.trace.blocked(RulePending, "%s %.8f not reported filled", b.pending.Side, b.pending.Quantity)
+		return nil
+	}
+
+	candles, ok := b.feed.get(ctx, b.exchange, marketTime(market))
+	if !ok {
+		b.trace.blocked(RuleNoData, "%s candles unavailable", b.config.Interval)
+		return nil
+	}
+	channel := newBreakoutChannel(closedBars(candles), b.config)
+	b.channel = channel
+	if need := max(b.config.EntryPeriod, b.config.ExitPeriod); channel.bars < need {
+		b.trace.blocked(RuleWarmingUp, "%d of %d %s bars closed", channel.bars, need, b.config.Interval)
+		return nil
+	}
+
+	rules, err := b.rules.get(ctx, b.exchange, b.config.Symbol)
+	if err != nil {
+		b.logger.Warn("Breakout trading without order size rules: %v", err)
+	}
+
+	price := market.Price
+	if b.quantity > dust {
+		return b.executeExit(ctx, price, rules)
+	}
+	if price <= channel.upper {
+		return nil
+	}
+
+	signal, ok := applyFilter(b.filter, b.logger, types.Signal{Type: types.SignalTypeBuy, Symbol: b.config.Symbol, Price: price, Quantity: b.config.InvestmentAmount / price, Timestamp: market.Timestamp}, market)
+	if !ok {
+		b.trace.blocked(RuleFilterVeto, "buy vetoed")
+		return nil
+	}
+	if !rules.Tradable(signal.Quantity, price) {
+		b.logger.Warn("Breakout BUY skipped: %.8f is below the exchange minimum", signal.Quantity)
+		b.trace.blocked(RuleBelowMinimum, "buy %.8f", signal.Quantity)
+		return nil
+	}
+	order := types.Order{Symbol: b.config.Symbol, Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: signal.Quantity, Price: price, Status: types.OrderStatusNew, Timestamp: time.Now(),
+		Decision: &types.Decision{Strategy: "breakout", StrategyID: b.ID(), Action: types.DecisionBuy}}
+	if err := b.place(ctx, order); err != nil {
+		if errors.Is(err, ErrArbitrationBudget) {
+			b.skips.skipped(b.logger.Info, "Breakout buys skipped until the arbitration budget fits them: %v", err)
+			return nil
+		}
+		if errors.Is(err, risk.ErrThrottled) || errors.Is(err, types.ErrBelowMinimum) {
+			b.logger.Info("Breakout BUY above %.2f skipped: %v", channel.upper, err)
+			return nil
+		}
+		return fmt.Errorf("breakout buy failed: %w", err)
+	}
+	b.skips.resumed(b.logger.Info, "Breakout buys fit the arbitration budget again")
+	return nil
+}
+
+// executeExit sells the position when price breaks the exit channel or the
+// trailing stop. Exits bypass the filter.
+func (b *BreakoutStrategy) executeExit(ctx context.Context, price float64, rules types.SymbolRules) error {
+	b.peak = math.Max(b.peak, price)
+	var reason string
+	switch {
+	case price < b.channel.lower:
+		reason = ExitChannelLow
+	case b.stopLocked() > 0 && price <= b.stopLocked():
+		reason = ExitTrailingStop
+	default:
+		return nil
+	}
+
+	quantity := sellQuantity(rules, b.quantity, b.quantity, price)
+	if quantity <= 0 {
+		b.trace.blocked(RuleBelowMinimum, "sell %.8f", b.quantity)
+		b.writeOffDust()
+		return nil
+	}
+	order := types.Order{Symbol: b.config.Symbol, Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: quantity, Price: price, Status: types.OrderStatusNew, Timestamp: time.Now(),
+		Decision: &types.Decision{Strategy: "breakout", StrategyID: b.ID(), Action: types.DecisionExit, Exit: reason}}
+	if err := b.place(ctx, order); err != nil {
+		if errors.Is(err, types.ErrBelowMinimum) {
+			b.writeOffDust()
+			return nil
+		}
+		return fmt.Errorf("breakout exit failed: %w", err)
+	}
+	return nil
+}
+
+// historyCandles is the number of candles the channels and ATR are computed
+// from, counting the open one
+func (b *BreakoutStrategy) historyCandles() int {
+	return max(b.config.EntryPeriod, b.config.ExitPeriod, b.config.ATRPeriod+1) + 1
+}
+
+// closedBars drops the candle still open from candles sorted oldest first
+func closedBars(candles []types.Candle) []types.Candle {
+	if len(candles) > 0 {
+		candles = candles[:len(candles)-1]
+	}
+	return candles
+}
+
+// newBreakoutChannel computes the channels over completed bars, oldest first
+func newBreakoutChannel(bars []types.Candle, cfg types.BreakoutConfig) breakoutChannel {
+	channel := breakoutChannel{bars: len(bars)}
+	if len(bars) == 0 {
+		return channel
+	}
+	channel.lower = math.Inf(1)
+	for i, bar := range bars {
+		if i >= len(bars)-cfg.EntryPeriod {
+			channel.upper = math.Max(channel.upper, bar.High)
+		}
+		if i >= len(bars)-cfg.ExitPeriod {
+			channel.lower = math.Min(channel.lower, bar.Low)
+		}
+	}
+
+	highs, lows, closes := make([]float64, len(bars)), make([]float64, len(bars)), make([]float64, len(bars))
+	for i, bar := range bars {
+		highs[i], lows[i], closes[i] = bar.High, bar.Low, bar.Close
+	}
+	if atr := indicators.ATR(highs, lows, closes, cfg.ATRPeriod); len(atr) > 0 {
+		channel.atr = atr[len(atr)-1]
+	}
+	return channel
+}
+
+// stopLocked returns the trailing stop price, or 0 without one
+func (b *BreakoutStrategy) stopLocked() float64 {
+	if b.config.ATRMultiplier <= 0 || b.channel.atr <= 0 || b.quantity <= dust {
+		return 0
+	}
+	return b.peak - b.config.ATRMultiplier*b.channel.atr
+}
+
+// place sends an order and books what the exchange reports as filled. An
+// order still working holds the strategy until settle books it.
+func (b *BreakoutStrategy) place(ctx context.Context, order types.Order) error {
+	tagOrder(&order, b.ID())
+	if err := b.exchange.PlaceOrder(ctx, order); err != nil {
+		b.trace.orderError(err, 0)
+		return err
+	}
+	rule := RuleBuy
+	if order.Decision.Action == types.DecisionExit {
+		rule = RuleExit
+	}
+	b.trace.triggered(rule, order, 0)
+
+	report, working, err := executionReport(ctx, b.exchange, order)
+	if err != nil {
+		b.logger.Warn("Breakout fill not reported yet: %v", err)
+	}
+	if working {
+		b.pending = &order
+		return nil
+	}
+	b.book(report)
+	return nil
+}
+
+// settle books the fill of an order that was still working on an earlier tick
+func (b *BreakoutStrategy) settle(ctx context.Context) {
+	if b.pending == nil {
+		return
+	}
+	report, working, err := executionReport(ctx, b.exchange, *b.pending)
+	if err != nil {
+		b.logger.Warn("Breakout fill not reported yet: %v", err)
+	}
+	if working {
+		return
+	}
+	b.pending = nil
+	b.book(report)
+}
+
+// book applies a final execution report to the position
+func (b *BreakoutStrategy) book(report types.Order) {
+	if report.FilledAmount <= dust {
+		b.logger.Warn("Breakout %s did not fill (%s)", report.Side, report.Status)
+		return
+	}
+	qty, price, realized := b.applyFill(report)
+	if report.Decision.Action == types.DecisionBuy {
+		b.logger.Info("Breakout BUY above %.2f qty=%.8f price=%.2f", b.channel.upper, qty, price)
+	} else {
+		b.logger.Info("Breakout %s exit qty=%.8f price=%.2f pnl=%.2f", report.Decision.Exit, qty, price, realized)
+	}
+	if qty < report.Quantity-dust {
+		b.logger.Warn("Breakout %s filled %.8f of %.8f", report.Side, qty, report.Quantity)
+	}
+	b.updateRates()
+}
+
+// applyFill books a fill into the position and the metrics and returns the
+// quantity, price and realized PnL booked
+func (b *BreakoutStrategy) applyFill(fill types.Order) (qty, price, realized float64) {
+	qty, price = filledAt(fill)
+	if qty <= dust {
+		return 0, price, 0
+	}
+	switch fill.Decision.Action {
+	case types.DecisionBuy:
+		b.entry = (b.entry*b.quantity + price*qty) / (b.quantity + qty)
+		b.quantity += qty
+		b.peak = math.Max(b.peak, price)
+	case types.DecisionExit, types.DecisionSell:
+		qty = math.Min(qty, b.quantity)
+		if qty <= dust {
+			return 0, price, 0
+		}
+		realized = (price - b.entry) * qty
+		recordRealized(&b.metrics, realized)
+		b.quantity -= qty
+		if b.quantity <= dust {
+			b.quantity, b.entry, b.peak = 0, 0, 0
+		}
+	}
+	b.metrics.TotalTrades++
+	b.metrics.TotalVolume += qty * price
+	return qty, price, realized
+}
+
+// writeOffDust drops a position too small to sell; the coins stay in the
+// account and the strategy can enter again
+func (b *BreakoutStrategy) writeOffDust() {
+	b.logger.Info("Breakout position %.8f %s is below the exchange minimum, set aside as dust", b.quantity, b.config.Symbol)
+	b.quantity, b.entry, b.peak = 0, 0, 0
+}
+
+// updateRates refreshes win rate and profit factor
+func (b *BreakoutStrategy) updateRates() {
+	b.metrics.LastUpdate = time.Now()
+	if b.metrics.TotalTrades > 0 {
+		b.metrics.WinRate = float64(b.metrics.WinningTrades) / float64(b.metrics.TotalTrades) * 100.0
+		if b.metrics.TotalLoss > 0 {
+			b.metrics.ProfitFactor = b.metrics.TotalProfit / b.metrics.TotalLoss
+		}
+	}
+}
+
+// Recover rebuilds the position and metrics from fills placed before a
+// restart. The trailing stop restarts from the highest entry fill.
+func (b *BreakoutStrategy) Recover(fills []types.Order) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, fill := range fills {
+		if fill.Symbol != b.config.Symbol || fill.Decision == nil || fill.Decision.Strategy != "breakout" {
+			continue
+		}
+		b.applyFill(fill)
+	}
+
+	b.updateRates()
+	b.logger.Info("Breakout state recovered: %.8f %s held", b.quantity, b.config.Symbol)
+	return nil
+}
+
+// SetSignalFilter installs a filter consulted before each entry
+func (b *BreakoutStrategy) SetSignalFilter(filter SignalFilter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.filter = filter
+}
+
+// SetThrottle routes orders through throttle and reports it in status
+func (b *BreakoutStrategy) SetThrottle(throttle *risk.Throttle) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.throttle = throttle
+	b.exchange = throttle.Client(b.exchange)
+}
+
+// Throttle returns the installed throttle, or nil
+func (b *BreakoutStrategy) Throttle() *risk.Throttle {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.throttle
+}
+
+// GetStatus returns the channel and the position held
+func (b *BreakoutStrategy) GetStatus() types.StrategyStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	status := types.StrategyStatus{
+		ID:      b.ID(),
+		Name:    b.Name(),
+		Type:    "breakout",
+		Symbol:  b.config.Symbol,
+		Enabled: b.config.Enabled,
+		Breakout: &types.BreakoutStatus{
+			Interval:    b.config.Interval,
+			EntryPeriod: b.config.EntryPeriod,
+			ExitPeriod:  b.config.ExitPeriod,
+			Upper:       b.channel.upper,
+			Lower:       b.channel.lower,
+			ATR:         b.channel.atr,
+			Quantity:    b.quantity,
+			EntryPrice:  b.entry,
+			StopPrice:   b.stopLocked(),
+			Pending:     b.pending != nil,
+		},
+		Data: b.feed.status(),
+	}
+	if b.throttle != nil {
+		status.Throttle = b.throttle.Status()
+	}
+	return status
+}
+
+// GetSignal reports the breakout the last channel shows at the market price
+func (b *BreakoutStrategy) GetSignal(market types.MarketData) types.Signal {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	signal := types.Signal{Type: types.SignalTypeHold, Symbol: market.Symbol, Price: market.Price, Timestamp: market.Timestamp}
+	switch {
+	case b.channel.bars == 0:
+	case b.quantity <= dust && market.Price > b.channel.upper:
+		signal.Type, signal.Quantity = types.SignalTypeBuy, b.config.InvestmentAmount/market.Price
+	case b.quantity > dust && market.Price < b.channel.lower:
+		signal.Type, signal.Quantity = types.SignalTypeSell, b.quantity
+	}
+	return signal
+}
+
+func (b *BreakoutStrategy) GetMetrics() types.StrategyMetrics {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.metrics
+}
+
+func (b *BreakoutStrategy) Shutdown(ctx context.Context) error {
+	b.logger.Info("Breakout strategy stopped")
+	return nil
+}
+
+// RequiredHistory returns the candles of the breakout's channels and ATR
+func (b *BreakoutStrategy) RequiredHistory() []HistoryRequirement {
+	return []HistoryRequirement{{Symbol: b.config.Symbol, Interval: b.config.Interval, Candles: b.historyCandles(), Exchange: b.exchange}}
+}
+
+// LoadHistory computes the channels from preloaded candles, so they are
+// reported before the first Execute
+func (b *BreakoutStrategy) LoadHistory(req HistoryRequirement, candles []types.Candle) {
+	if req.Symbol != b.config.Symbol || req.Interval != b.config.Interval || (req.Exchange != nil && req.Exchange != b.exchange) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.feed.load(candles, time.Now())
+	b.channel = newBreakoutChannel(closedBars(b.feed.candles), b.config)
+}
+
+// Markets returns the breakout's symbol
+func (b *BreakoutStrategy) Markets() []Market {
+	return []Market{{Symbol: b.config.Symbol, Exchange: b.exchange}}
+}
+
+// Trace returns the strategy's recent decisions, oldest first
+func (b *BreakoutStrategy) Trace() []TraceEntry {
+	return b.trace.Entries()
+}
@@ -0,0 +1,232 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/sim"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// runBreakout feeds every step of prices to a breakout strategy
+func runBreakout(t *testing.T, cfg types.BreakoutConfig, prices []float64) (*BreakoutStrategy, *sim.Exchange) {
+	t.Helper()
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: prices, QuoteBalance: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, err := NewFactory(logger.New(logger.LevelError)).CreateBreakout(cfg, ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := built.(*BreakoutStrategy)
+	err = ex.Run(func(step int, market types.MarketData) error {
+		return b.Execute(context.Background(), market)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, ex
+}
+
+func TestBreakout_EntersOnChannelHighAndExitsOnChannelLow(t *testing.T) {
+	cfg := types.BreakoutConfig{Symbol: "BTCUSDT", InvestmentAmount: 1030, EntryPeriod: 3, ExitPeriod: 2, Enabled: true}
+	b, ex := runBreakout(t, cfg, []float64{100, 101, 100, 99, 103, 104, 105, 101})
+
+	orders := ex.Orders()
+	if len(orders) != 2 {
+		t.Fatalf("placed %d orders, want a buy and an exit: %+v", len(orders), orders)
+	}
+	if buy := orders[0]; buy.Side != types.OrderSideBuy || buy.Price != 103 || math.Abs(buy.Quantity-10) > 1e-9 {
+		t.Errorf("entry = %s %.8f @ %.2f, want BUY 10 above the 3-bar high of 101", buy.Side, buy.Quantity, buy.Price)
+	}
+	// 101 breaks the 2-bar low of 104
+	if exit := orders[1]; exit.Side != types.OrderSideSell || exit.Price != 101 || exit.Decision.Exit != ExitChannelLow {
+		t.Errorf("exit = %s @ %.2f (%s), want a channel_low SELL at 101", exit.Side, exit.Price, exit.Decision.Exit)
+	}
+
+	metrics := b.GetMetrics()
+	if metrics.TotalTrades != 2 || metrics.LosingTrades != 1 || math.Abs(metrics.TotalLoss-20) > 1e-9 {
+		t.Errorf("metrics = %+v, want one losing round trip of 20", metrics)
+	}
+	status := b.GetStatus().Breakout
+	if status == nil || status.Quantity != 0 || status.Upper != 105 || status.Lower != 104 {
+		t.Errorf("status = %+v, want flat with the 105/104 channel", status)
+	}
+
+	// The first steps wait for the channel to fill
+	if trace := b.Trace(); trace[0].Rule != RuleWarmingUp {
+		t.Errorf("first decision = %s, want %s", trace[0].Rule, RuleWarmingUp)
+	}
+}
+
+func TestBreakout_TrailingStop(t *testing.T) {
+	cfg := types.BreakoutConfig{Symbol: "BTCUSDT", InvestmentAmount: 1030, EntryPeriod: 3, ExitPeriod: 4, ATRPeriod: 2, ATRMultiplier: 1, Enabled: true}
+	b, ex := runBreakout(t, cfg, []float64{100, 101, 100, 99, 103, 110, 108, 104})
+
+	orders := ex.Orders()
+	if len(orders) != 2 {
+		t.Fatalf("placed %d orders, want a buy and an exit: %+v", len(orders), orders)
+	}
+	// Peak 110 less a 2-bar ATR of 4.5 puts the stop at 105.5, above the
+	// 4-bar low of 99
+	if exit := orders[1]; exit.Price != 104 || exit.Decision.Exit != ExitTrailingStop {
+		t.Errorf("exit @ %.2f (%s), want a trailing_stop SELL at 104", exit.Price, exit.Decision.Exit)
+	}
+	if metrics := b.GetMetrics(); metrics.WinningTrades != 1 {
+		t.Errorf("metrics = %+v, want one winning round trip", metrics)
+	}
+}
+
+func TestBreakout_Recover(t *testing.T) {
+	cfg := types.BreakoutConfig{Symbol: "BTCUSDT", InvestmentAmount: 1000, Enabled: true}
+	b := NewBreakoutStrategy(cfg, &MockExchangeClient{}, logger.New(logger.LevelError))
+	fills := []types.Order{
+		{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 2, Price: 100, Decision: &types.Decision{Strategy: "breakout", Action: types.DecisionBuy}},
+		{Symbol: "BTCUSDT", Side: types.OrderSideSell, Quantity: 2, Price: 110, Decision: &types.Decision{Strategy: "breakout", Action: types.DecisionExit, Exit: ExitChannelLow}},
+		{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1, Price: 120, Decision: &types.Decision{Strategy: "breakout", Action: types.DecisionBuy}},
+		{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1, Price: 50, Decision: &types.Decision{Strategy: "dca", Action: types.DecisionBuy}},
+	}
+	if err := b.Recover(fills); err != nil {
+		t.Fatal(err)
+	}
+	status := b.GetStatus().Breakout
+	if status.Quantity != 1 || status.EntryPrice != 120 {
+		t.Errorf("recovered position %.8f @ %.2f, want 1 @ 120", status.Quantity, status.EntryPrice)
+	}
+	if metrics := b.GetMetrics(); metrics.TotalTrades != 3 || metrics.TotalProfit != 20 {
+		t.Errorf("recovered metrics = %+v", metrics)
+	}
+}
+
+func TestFactory_CreateBreakoutValidates(t *testing.T) {
+	factory := NewFactory(logger.New(logger.LevelError))
+	for name, cfg := range map[string]types.BreakoutConfig{
+		"no symbol":     {InvestmentAmount: 100},
+		"no investment": {Symbol: "BTCUSDT"},
+		"negative atr":  {Symbol: "BTCUSDT", InvestmentAmount: 100, ATRMultiplier: -1},
+		"bad id":        {Symbol: "BTCUSDT", InvestmentAmount: 100, ID: "a b"},
+	} {
+		if _, err := factory.CreateBreakout(cfg, &MockExchangeClient{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := NewRegistry().Register("breakout", func(map[string]interface{}, types.ExchangeClient, *logger.Logger) (Strategy, error) { return nil, nil }); err == nil {
+		t.Error("breakout is built in and cannot be registered")
+	}
+}
+
+func TestBreakout_LoadHistory(t *testing.T) {
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{100, 101, 100, 99, 103}, QuoteBalance: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, ok := ex.Step(); !ok {
+			break
+		}
+	}
+	cfg := types.BreakoutConfig{Symbol: "BTCUSDT", InvestmentAmount: 1000, EntryPeriod: 3, ExitPeriod: 2, Enabled: true}
+	built, err := NewFactory(logger.New(logger.LevelError)).CreateBreakout(cfg, ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := built.(*BreakoutStrategy)
+
+	reqs := b.RequiredHistory()
+	if len(reqs) != 1 || reqs[0].Symbol != "BTCUSDT" || reqs[0].Interval != "1d" || reqs[0].Candles != 16 {
+		t.Fatalf("required history = %+v, want 16 BTCUSDT 1d candles for the default 14-bar ATR", reqs)
+	}
+	candles, err := ex.GetCandles(context.Background(), "BTCUSDT", "1d", reqs[0].Candles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.LoadHistory(HistoryRequirement{Symbol: "BTCUSDT", Interval: "1h"}, candles)
+	if status := b.GetStatus().Breakout; status.Upper != 0 {
+		t.Errorf("status = %+v, want candles of another interval ignored", status)
+	}
+	// The open 103 bar is left out of the channels
+	b.LoadHistory(reqs[0], candles)
+	if status := b.GetStatus().Breakout; status.Upper != 101 || status.Lower != 99 {
+		t.Errorf("status = %+v, want the 101/99 channel of the closed bars", status)
+	}
+}
+
+// flakyCandles fails candle requests while down
+type flakyCandles struct {
+	*sim.Exchange
+	down bool
+}
+
+func (f *flakyCandles) GetCandles(ctx context.Context, symbol, interval string, limit int) ([]types.Candle, error) {
+	if f.down {
+		return nil, errors.New("connection refused")
+	}
+	return f.Exchange.GetCandles(ctx, symbol, interval, limit)
+}
+
+func TestBreakout_CandleFallback(t *testing.T) {
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{100, 101, 100, 99, 98, 97, 96}, QuoteBalance: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flaky := &flakyCandles{Exchange: ex}
+	cfg := types.BreakoutConfig{Symbol: "BTCUSDT", InvestmentAmount: 1000, EntryPeriod: 3, ExitPeriod: 2, Enabled: true}
+	built, err := NewFactory(logger.New(logger.LevelError)).CreateBreakout(cfg, flaky)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := built.(*BreakoutStrategy)
+	ctx := context.Background()
+	execute := func(market types.MarketData) *types.DataHealth {
+		t.Helper()
+		if err := b.Execute(ctx, market); err != nil {
+			t.Fatalf("Execute() error = %v, want missing candles to hold", err)
+		}
+		return b.GetStatus().Data
+	}
+
+	for i := 0; i < 3; i++ {
+		ex.Step()
+	}
+	market := ex.Market()
+	if data := execute(market); data.State != types.DataHealthy || data.Source != types.DataSourceExchange {
+		t.Errorf("data = %+v, want healthy from the exchange", data)
+	}
+	// The open candle has not closed: no refetch
+	flaky.down = true
+	if data := execute(market); data.State != types.DataHealthy || data.Source != types.DataSourceCache {
+		t.Errorf("data = %+v, want healthy from the cache", data)
+	}
+
+	// One bar behind the cache still serves
+	market, _ = ex.Step()
+	if data := execute(market); data.State != types.DataDegraded || data.Source != types.DataSourceCache || data.Failures != 1 {
+		t.Errorf("data = %+v, want degraded on the cache after one failure", data)
+	}
+	channel := b.GetStatus().Breakout
+	if channel.Upper != 101 || channel.Lower != 100 {
+		t.Errorf("channel = %.2f/%.2f, want the cached 101/100", channel.Upper, channel.Lower)
+	}
+
+	// Two bars behind the strategy holds
+	market, _ = ex.Step()
+	if data := execute(market); data.State != types.DataUnavailable || data.Failures != 2 || data.Error == "" {
+		t.Errorf("data = %+v, want unavailable", data)
+	}
+	if trace := b.Trace(); trace[len(trace)-1].Rule != RuleNoData {
+		t.Errorf("last decision = %s, want %s", trace[len(trace)-1].Rule, RuleNoData)
+	}
+
+	flaky.down = false
+	market, _ = ex.Step()
+	if data := execute(market); data.State != types.DataHealthy || data.Failures != 0 || data.Error != "" {
+		t.Errorf("data = %+v, want healthy once candles return", data)
+	}
+	if len(ex.Orders()) != 0 {
+		t.Errorf("placed %+v, want no orders", ex.Orders())
+	}
+}
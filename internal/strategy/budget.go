@@ -0,0 +1,265 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// defaultConversionSlippage bounds conversions without a MaxSlippage
+const defaultConversionSlippage = 0.005
+
+// validateBudget checks the reserve, the funding conversion pair and the
+// savings settings
+func validateBudget(config types.DCAConfig) error {
+	if config.Reserve < 0 {
+		return fmt.Errorf("reserve must not be negative")
+	}
+	if err := validateSavings(config); err != nil {
+		return err
+	}
+	f := config.Funding
+	if f == nil {
+		return nil
+	}
+	if config.Mode == types.DCAModeWithdraw {
+		return fmt.Errorf("withdrawals cannot use a funding asset")
+	}
+	_, quote, ok := types.SplitSymbol(config.Symbol)
+	if !ok {
+		return fmt.Errorf("cannot tell the quote asset of %s to fund", config.Symbol)
+	}
+	if f.Asset == "" || f.Asset == quote {
+		return fmt.Errorf("funding asset must be set and differ from %s", quote)
+	}
+	if f.Symbol != f.Asset+quote && f.Symbol != quote+f.Asset {
+		return fmt.Errorf("funding symbol must be %s or %s", f.Asset+quote, quote+f.Asset)
+	}
+	if f.MaxSlippage < 0 || f.MaxSlippage >= 1 {
+		return fmt.Errorf("funding max slippage must be within [0, 1)")
+	}
+	return nil
+}
+
+// fundingTotals accumulate the cost of funded buys
+type fundingTotals struct {
+	conversions int
+	spent       float64 // funding asset converted
+	received    float64 // quote asset received
+	fees        float64 // conversion and buy fees, in the quote asset
+	slippage    float64 // conversion cost against the mid price, in the quote asset
+}
+
+func (d *DCAStrategy) fundingStatus() *types.FundingStatus {
+	return &types.FundingStatus{
+		Asset:        d.config.Funding.Asset,
+		Conversions:  d.funding.conversions,
+		Spent:        d.funding.spent,
+		Received:     d.funding.received,
+		Fees:         d.funding.fees,
+		SlippageCost: d.funding.slippage,
+	}
+}
+
+// takerFee returns the exchange's taker fee for symbol, 0 when unknown
+func (d *DCAStrategy) takerFee(ctx context.Context, symbol string) float64 {
+	fees, err := d.exchange.GetTradingFees(ctx, symbol)
+	if err != nil || fees == nil {
+		return 0
+	}
+	return fees.TakerFee
+}
+
+// makerFee returns the exchange's maker fee for symbol, 0 when unknown
+func (d *DCAStrategy) makerFee(ctx context.Context, symbol string) float64 {
+	fees, err := d.exchange.GetTradingFees(ctx, symbol)
+	if err != nil || fees == nil {
+		return 0
+	}
+	return fees.MakerFee
+}
+
+// convert trades the funding asset at market for need of quote after fees.
+// It reports false when the conversion was blocked: the book would fill it
+// beyond MaxSlippage. A funding balance too small for the whole shortfall
+// converts nothing and leaves the buy to the quote balance.
+func (d *DCAStrategy) convert(ctx context.Context, balances []types.Balance, quote string, need float64) (float64, bool, error) {
+	f := d.config.Funding
+	available := 0.0
+	for _, balance := range balances {
+		if balance.Asset == f.Asset {
+			available = balance.Free
+		}
+	}
+	if available <= 0 {
+		return 0, true, nil
+	}
+
+	book, err := d.exchange.GetOrderBook(ctx, f.Symbol, 50)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get %s order book: %w", f.Symbol, err)
+	}
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return 0, false, fmt.Errorf("empty %s order book", f.Symbol)
+	}
+	mid := (book.Bids[0].Price + book.Asks[0].Price) / 2
+	fee := d.takerFee(ctx, f.Symbol)
+	gross := need / (1 - fee)
+
+	// Selling the funding asset walks the bids until gross quote is raised;
+	// buying the quote asset walks the asks for gross of it
+	order := types.Order{Symbol: f.Symbol, Type: types.OrderTypeMarket, Status: types.OrderStatusNew, Timestamp: time.Now(),
+		Decision: &types.Decision{Strategy: "dca", StrategyID: d.ID(), Action: types.DecisionConvert}}
+	var spent float64
+	var filled bool
+	if f.Symbol == f.Asset+quote {
+		order.Side = types.OrderSideSell
+		order.Quantity, filled = walkBook(book.Bids, gross, true)
+		spent = order.Quantity
+		order.Price = gross / order.Quantity
+	} else {
+		order.Side = types.OrderSideBuy
+		var cost float64
+		cost, filled = walkBook(book.Asks, gross, false)
+		order.Quantity = gross
+		spent = cost
+		order.Price = cost / gross
+	}
+
+	maxSlippage := f.MaxSlippage
+	if maxSlippage == 0 {
+		maxSlippage = defaultConversionSlippage
+	}
+	slippage := math.Abs(order.Price/mid - 1)
+	if !filled || slippage > maxSlippage {
+		d.logger.Warn("DCA conversion of %s skipped: filling %.2f %s costs %.4f%% against the mid price, above %.4f%%", f.Asset, gross, quote, slippage*100, maxSlippage*100)
+		d.trace.blocked(RuleSlippage, "%.2f %s from %s would cost %.4f%%", gross, quote, f.Asset, slippage*100)
+		return 0, false, nil
+	}
+	if spent > available {
+		return 0, true, nil
+	}
+
+	if err := d.exchange.PlaceOrder(ctx, order); err != nil {
+		d.trace.orderError(err, 0)
+		return 0, false, fmt.Errorf("failed to convert %s: %w", f.Asset, err)
+	}
+	d.funding.conversions++
+	d.funding.spent += spent
+	d.funding.received += need
+	d.funding.fees += gross - need
+	d.funding.slippage += gross * slippage
+	d.logger.Info("DCA converted %.8f %s into %.2f %s", spent, f.Asset, need, quote)
+	return need, true, nil
+}
+
+// walkBook takes levels, best first, until target is reached. Targets are in
+// the quote asset of the book when byQuote is set and in the base asset
+// otherwise; it returns the other side's total and whether the book was deep
+// enough.
+func walkBook(levels []types.OrderBookEntry, target float64, byQuote bool) (float64, bool) {
+	total := 0.0
+	for _, level := range levels {
+		size := level.Amount
+		if byQuote {
+			size = level.Amount * level.Price
+		}
+		if size >= target {
+			if byQuote {
+				return total + target/level.Price, true
+			}
+			return total + target*level.Price, true
+		}
+		target -= size
+		if byQuote {
+			total += level.Amount
+		} else {
+			total += level.Amount * level.Price
+		}
+	}
+	return total, false
+}
+
+// budget checks a buy of quantity at price against the free quote balance
+// above Reserve, converting a shortfall from the funding asset first. A buy
+// the balance cannot cover is shrunk to fit with ReduceLastBuy; otherwise, or
+// when what fits is below the exchange minimum, it returns 0 and the buy is
+// retried next tick.
+func (d *DCAStrategy) budget(ctx context.Context, quantity, price float64) (float64, error) {
+	_, quote, ok := types.SplitSymbol(d.config.Symbol)
+	if !ok || price <= 0 {
+		// Without a known quote asset the exchange is left to reject the buy
+		return quantity, nil
+	}
+	balances, err := d.exchange.GetBalances(ctx)
+	if errors.Is(err, types.ErrNotSupported) {
+		return quantity, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balances: %w", err)
+	}
+	free := 0.0
+	for _, balance := range balances {
+		if balance.Asset == quote {
+			free = balance.Free
+		}
+	}
+
+	spendable := math.Max(free-d.config.Reserve, 0)
+	cost := quantity * price
+	if cost > spendable && d.config.Funding != nil {
+		converted, ok, err := d.convert(ctx, balances, quote, cost-spendable)
+		if err != nil || !ok {
+			return 0, err
+		}
+		spendable += converted
+	}
+	if cost <= spendable {
+		return quantity, nil
+	}
+	if !d.config.ReduceLastBuy || spendable == 0 {
+		d.skips.skipped(d.logger.Warn, "DCA buys skipped until the balance covers them: %.2f %s needed, %.2f spendable above the %.2f reserve", cost, quote, spendable, d.config.Reserve)
+		d.trace.blocked(RuleInsufficient, "%.2f %s needed, %.2f spendable", cost, quote, spendable)
+		return 0, nil
+	}
+
+	reduced := spendable / price
+	rules, err := d.rules.get(ctx, d.exchange, d.config.Symbol)
+	if err != nil {
+		d.logger.Warn("DCA buy without order size rules: %v", err)
+	}
+	reduced = rules.RoundQuantity(reduced)
+	if !rules.Tradable(reduced, price) {
+		d.skips.skipped(d.logger.Warn, "DCA buys skipped until the balance covers them: the %.2f %s left buys %.8f, below the exchange minimum", spendable, quote, reduced)
+		d.trace.blocked(RuleBelowMinimum, "%.2f %s left buys %.8f", spendable, quote, reduced)
+		return 0, nil
+	}
+	d.logger.Info("DCA buy reduced from %.8f to %.8f to fit %.2f %s", quantity, reduced, spendable, quote)
+	return reduced, nil
+}
+
+// skipLog reports buys skipped for want of cash once when the skipping
+// starts and once when a buy fits again, rather than on every tick
+type skipLog struct {
+	skipping bool
+}
+
+// skipped logs a skipped buy unless the last buy was skipped too
+func (s *skipLog) skipped(logf func(string, ...interface{}), format string, args ...interface{}) {
+	if !s.skipping {
+		logf(format, args...)
+	}
+	s.skipping = true
+}
+
+// resumed logs the first buy placed after skipped ones
+func (s *skipLog) resumed(logf func(string, ...interface{}), format string, args ...interface{}) {
+	if s.skipping {
+		logf(format, args...)
+	}
+	s.skipping = false
+}
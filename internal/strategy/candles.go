@@ -0,0 +1,112 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// candleFeed serves the candles of one symbol and interval, oldest first with
+// the open one last. It answers from its cache until the open candle closes,
+// then fetches from the exchange. When the fetch fails it keeps serving the
+// cache while that is at most one bar behind; past that the feed is
+// unavailable and the strategy holds. The bar is the spacing of the last two
+// candles.
+type candleFeed struct {
+	name   string // strategy name for the logs
+	logger *logger.Logger
+	limit  int
+
+	candles []types.Candle
+	health  types.DataHealth
+}
+
+func newCandleFeed(name, symbol, interval string, limit int, logger *logger.Logger) *candleFeed {
+	return &candleFeed{
+		name:   name,
+		logger: logger,
+		limit:  limit,
+		health: types.DataHealth{Symbol: symbol, Interval: interval, State: types.DataHealthy},
+	}
+}
+
+// get returns the candles to decide on at now, or false when there are none
+// usable
+func (f *candleFeed) get(ctx context.Context, exchange types.ExchangeClient, now time.Time) ([]types.Candle, bool) {
+	if f.closesAfter(now, 1) {
+		f.health.Source = types.DataSourceCache
+		return f.candles, true
+	}
+
+	candles, err := exchange.GetCandles(ctx, f.health.Symbol, f.health.Interval, f.limit)
+	if err == nil && len(candles) == 0 {
+		err = errors.New("no candles returned")
+	}
+	if err == nil {
+		if f.health.State != types.DataHealthy {
+			f.logger.Info("%s %s %s candles available again", f.name, f.health.Symbol, f.health.Interval)
+		}
+		f.store(candles, now)
+		f.health.Source = types.DataSourceExchange
+		return f.candles, true
+	}
+
+	f.health.Failures++
+	f.health.Error = err.Error()
+	if f.closesAfter(now, 2) {
+		if f.health.State == types.DataHealthy {
+			f.logger.Warn("%s %s %s candles unavailable, using the cached ones: %v", f.name, f.health.Symbol, f.health.Interval, err)
+		}
+		f.health.State = types.DataDegraded
+		f.health.Source = types.DataSourceCache
+		return f.candles, true
+	}
+	if f.health.State != types.DataUnavailable {
+		f.logger.Error("%s %s %s candles unavailable, holding until they return: %v", f.name, f.health.Symbol, f.health.Interval, err)
+	}
+	f.health.State = types.DataUnavailable
+	f.health.Source = ""
+	return nil, false
+}
+
+// load caches candles fetched elsewhere, such as preloaded history
+func (f *candleFeed) load(candles []types.Candle, now time.Time) {
+	if len(candles) == 0 {
+		return
+	}
+	f.store(candles, now)
+	f.health.Source = ""
+}
+
+func (f *candleFeed) store(candles []types.Candle, now time.Time) {
+	f.candles = append([]types.Candle(nil), candles...)
+	sort.SliceStable(f.candles, func(i, j int) bool { return f.candles[i].Timestamp.Before(f.candles[j].Timestamp) })
+	f.health.State = types.DataHealthy
+	f.health.UpdatedAt = now
+	f.health.Failures = 0
+	f.health.Error = ""
+}
+
+// closesAfter reports whether the cache is less than bars bars old at now:
+// with bars 1, the open candle has not closed yet
+func (f *candleFeed) closesAfter(now time.Time, bars int) bool {
+	n := len(f.candles)
+	if n < 2 {
+		return false
+	}
+	bar := f.candles[n-1].Timestamp.Sub(f.candles[n-2].Timestamp)
+	if bar <= 0 {
+		return false
+	}
+	return now.Before(f.candles[n-1].Timestamp.Add(time.Duration(bars) * bar))
+}
+
+// status returns a copy of the feed's health
+func (f *candleFeed) status() *types.DataHealth {
+	health := f.health
+	return &health
+}
@@ -0,0 +1,277 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// defaultChaseTicks is how many ticks a limit chase bids without ChaseTicks
+const defaultChaseTicks = 5
+
+// ValidateExecution checks a DCA execution config
+func ValidateExecution(config types.ExecutionConfig) error {
+	switch config.Style {
+	case "", types.ExecutionMarket, types.ExecutionLimitChase:
+	default:
+		return fmt.Errorf("execution style must be %s or %s, got %q", types.ExecutionMarket, types.ExecutionLimitChase, config.Style)
+	}
+	if config.ChaseTicks < 0 {
+		return fmt.Errorf("execution chase ticks must not be negative")
+	}
+	if config.ChaseTimeout < 0 {
+		return fmt.Errorf("execution chase timeout must not be negative")
+	}
+	return nil
+}
+
+// limitChase is a DCA buy being worked as a limit order at the bid
+type limitChase struct {
+	order    types.Order // last limit order placed at the bid
+	working  bool        // order's fill is not booked yet
+	buy      int         // buy number, shared by the chase's orders
+	quantity float64     // to buy in total
+	filled   float64
+	cost     float64
+	ticks    int
+	started  time.Time
+}
+
+// executionTotals split the quote filled by buys into maker and taker volume
+type executionTotals struct {
+	maker float64
+	taker float64
+}
+
+// record counts a fill of an order of orderType: resting limit orders
+// are maker fills
+func (e *executionTotals) record(orderType types.OrderType, notional float64) {
+	if orderType == types.OrderTypeLimit {
+		e.maker += notional
+	} else {
+		e.taker += notional
+	}
+}
+
+// limitChasing reports whether buys are chased with limit orders
+func (d *DCAStrategy) limitChasing() bool {
+	return d.config.Execution != nil && d.config.Execution.Style == types.ExecutionLimitChase
+}
+
+// startChase bids for quantity at the best bid; continueChase works the
+// order on the following ticks
+func (d *DCAStrategy) startChase(ctx context.Context, market types.MarketData, quantity float64) error {
+	c := &limitChase{buy: d.buyCount + 1, quantity: quantity, started: marketTime(market)}
+	if err := d.bidChase(ctx, market, c, quantity); err != nil {
+		return err
+	}
+	d.chase = c
+	return nil
+}
+
+// continueChase books what the working limit order filled and, until the
+// buy is complete, re-pegs the rest to a rising bid. Once the chase ran for
+// ChaseTicks ticks or ChaseTimeout, the rest is bought at market.
+func (d *DCAStrategy) continueChase(ctx context.Context, market types.MarketData) error {
+	c := d.chase
+	c.ticks++
+	cfg := types.ExecutionConfig{}
+	if d.config.Execution != nil {
+		cfg = *d.config.Execution
+	}
+	maxTicks := cfg.ChaseTicks
+	if maxTicks <= 0 {
+		maxTicks = defaultChaseTicks
+	}
+	expired := c.ticks >= maxTicks || cfg.ChaseTimeout > 0 && marketTime(market).Sub(c.started) >= cfg.ChaseTimeout
+	bid := d.bid(ctx, market)
+
+	if c.working {
+		report, working, err := executionReport(ctx, d.exchange, c.order)
+		if err != nil {
+			d.logger.Warn("DCA limit buy not reported yet: %v", err)
+			return nil
+		}
+		if working {
+			if !expired && bid <= c.order.Price {
+				d.trace.blocked(RulePending, "limit buy %.8f @ %.2f working, tick %d of %d", c.order.Quantity, c.order.Price, c.ticks, maxTicks)
+				return nil
+			}
+			if report, err = d.cancelChase(ctx, c.order); err != nil {
+				// The order may have filled meanwhile; the next tick tells
+				d.logger.Warn("DCA limit buy not canceled: %v", err)
+				return nil
+			}
+		}
+		c.working = false
+		d.fillChase(ctx, c, report)
+	}
+
+	remaining := c.quantity - c.filled
+	rules, err := d.rules.get(ctx, d.exchange, d.config.Symbol)
+	if err != nil {
+		d.logger.Warn("DCA chase without order size rules: %v", err)
+	}
+	remaining = rules.RoundQuantity(remaining)
+	if remaining <= dust || !rules.Tradable(remaining, market.Price) {
+		d.finishChase()
+		return nil
+	}
+	if !expired {
+		return d.bidChase(ctx, market, c, remaining)
+	}
+
+	// Out of patience: cross the spread for the rest
+	order := types.Order{
+		Symbol:    d.config.Symbol,
+		Side:      types.OrderSideBuy,
+		Type:      types.OrderTypeMarket,
+		Quantity:  remaining,
+		Price:     market.Price,
+		Status:    types.OrderStatusNew,
+		Timestamp: time.Now(),
+		Decision:  &types.Decision{Strategy: "dca", StrategyID: d.ID(), Action: types.DecisionBuy, Buy: c.buy},
+	}
+	tagOrder(&order, d.ID())
+	if err := d.exchange.PlaceOrder(ctx, order); err != nil {
+		d.trace.orderError(err, 0)
+		return fmt.Errorf("failed to place order: %w", err)
+	}
+	d.trace.triggered(RuleBuy, order, 0)
+	d.skips.resumed(d.logger.Info, "DCA buys fit the budget again")
+	report, working, err := executionReport(ctx, d.exchange, order)
+	if err != nil || working {
+		d.logger.Warn("DCA market buy not reported yet, booked at %.2f: %v", order.Price, err)
+		report = order
+		report.FilledAmount, report.FilledPrice = order.Quantity, order.Price
+	}
+	d.fillChase(ctx, c, report)
+	d.finishChase()
+	return nil
+}
+
+// bidChase places a limit buy for quantity at the best bid
+func (d *DCAStrategy) bidChase(ctx context.Context, market types.MarketData, c *limitChase, quantity float64) error {
+	order := types.Order{
+		Symbol:    d.config.Symbol,
+		Side:      types.OrderSideBuy,
+		Type:      types.OrderTypeLimit,
+		Quantity:  quantity,
+		Price:     d.bid(ctx, market),
+		Status:    types.OrderStatusNew,
+		Timestamp: time.Now(),
+		Decision:  &types.Decision{Strategy: "dca", StrategyID: d.ID(), Action: types.DecisionBuy, Buy: c.buy},
+	}
+	tagOrder(&order, d.ID())
+
+	d.logger.Info("Placing DCA limit order: %s %.8f @ %.2f", order.Symbol, order.Quantity, order.Price)
+	if err := d.exchange.PlaceOrder(ctx, order); err != nil {
+		d.trace.orderError(err, 0)
+		return fmt.Errorf("failed to place order: %w", err)
+	}
+	d.trace.triggered(RuleBuy, order, 0)
+	d.skips.resumed(d.logger.Info, "DCA buys fit the budget again")
+	c.order, c.working = order, true
+	return nil
+}
+
+// cancelChase cancels the working limit order and returns what it filled
+func (d *DCAStrategy) cancelChase(ctx context.Context, order types.Order) (types.Order, error) {
+	active, err := d.exchange.GetActiveOrders(ctx, order.Symbol)
+	if err != nil {
+		return order, fmt.Errorf("failed to get open orders: %w", err)
+	}
+	open, ok := findOrder(active, order.ExchangeOrder.ClientOrderID)
+	if !ok {
+		return order, fmt.Errorf("limit buy %s is not open", order.ExchangeOrder.ClientOrderID)
+	}
+	if err := d.exchange.CancelOrder(ctx, open.ID); err != nil {
+		return order, fmt.Errorf("failed to cancel order %s: %w", open.ID, err)
+	}
+	canceled, err := d.exchange.GetOrder(ctx, open.ID)
+	if err != nil || canceled == nil {
+		// The fill is unknown until the order history reports it
+		return order, fmt.Errorf("failed to get canceled order %s: %w", open.ID, err)
+	}
+	return withFill(order, *canceled), nil
+}
+
+// fillChase adds an order's fill to the chase
+func (d *DCAStrategy) fillChase(ctx context.Context, c *limitChase, report types.Order) {
+	if report.FilledAmount <= dust {
+		return
+	}
+	notional := report.FilledAmount * report.FilledPrice
+	c.filled += report.FilledAmount
+	c.cost += notional
+	d.execution.record(report.Type, notional)
+	if d.config.Funding == nil {
+		return
+	}
+	if report.Type == types.OrderTypeLimit {
+		d.funding.fees += notional * d.makerFee(ctx, d.config.Symbol)
+	} else {
+		d.funding.fees += notional * d.takerFee(ctx, d.config.Symbol)
+	}
+}
+
+// finishChase books the chased buy at its average fill price
+func (d *DCAStrategy) finishChase() {
+	c := d.chase
+	d.chase = nil
+	if c.filled <= dust {
+		d.logger.Warn("DCA limit chase for %.8f %s filled nothing", c.quantity, d.config.Symbol)
+		return
+	}
+	price := c.cost / c.filled
+	order := c.order
+	order.Quantity = c.filled
+
+	d.lastBuy = c.started
+	d.buyCount = c.buy
+	d.updateMetrics(order, price)
+	if d.exit != nil {
+		d.exit.OnBuyAt(c.filled, price, c.started)
+	}
+	d.logger.Info("DCA buy executed: %s %.8f @ %.2f after %d ticks (buy #%d, maker ratio %.2f)",
+		order.Symbol, c.filled, price, c.ticks, d.buyCount, d.execution.makerRatio())
+}
+
+// bid returns the best bid: the market's ticker, then the exchange's,
+// falling back to the price
+func (d *DCAStrategy) bid(ctx context.Context, market types.MarketData) float64 {
+	if market.Ticker != nil && market.Ticker.Bid > 0 {
+		return market.Ticker.Bid
+	}
+	if market.OrderBook != nil && len(market.OrderBook.Bids) > 0 {
+		return market.OrderBook.Bids[0].Price
+	}
+	if ticker, err := d.exchange.GetTicker(ctx, d.config.Symbol); err == nil && ticker.Bid > 0 {
+		return ticker.Bid
+	}
+	return market.Price
+}
+
+// makerRatio is the maker share of the quote filled, 0 before any fill
+func (e executionTotals) makerRatio() float64 {
+	if total := e.maker + e.taker; total > 0 {
+		return e.maker / total
+	}
+	return 0
+}
+
+func (d *DCAStrategy) executionStatus() *types.ExecutionStatus {
+	style := d.config.Execution.Style
+	if style == "" {
+		style = types.ExecutionMarket
+	}
+	return &types.ExecutionStatus{
+		Style:       style,
+		Chasing:     d.chase != nil,
+		MakerVolume: d.execution.maker,
+		TakerVolume: d.execution.taker,
+		MakerRatio:  d.execution.makerRatio(),
+	}
+}
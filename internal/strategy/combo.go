@@ -2,22 +2,40 @@ package strategy
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
+// Combo weighting modes
+const (
+	WeightingEqual      = "equal"
+	WeightingRiskParity = "risk_parity"
+)
+
 // ComboStrategy combines multiple strategies with weighted signals
 type ComboStrategy struct {
+	Identity
 	config   types.ComboConfig
 	exchange types.ExchangeClient
+	accounts map[string]types.ExchangeClient // by name, for sub-strategies bound to an account
 	logger   *logger.Logger
 
 	strategies []Strategy
 	weights    []float64
+	allocator  *portfolio.CapitalAllocator
+	arbiter    *arbiter // nil without arbitration
+
+	// Risk parity state: per-strategy PnL changes (per unit of allocation
+	// when allocated) over the last Lookback executions
+	returns [][]float64
+	lastNet []float64
+	budget  float64
 
 	mu      sync.RWMutex
 	metrics types.StrategyMetrics
@@ -25,15 +43,35 @@ type ComboStrategy struct {
 
 // NewComboStrategy creates a new combo strategy
 func NewComboStrategy(config types.ComboConfig, exchange types.ExchangeClient, logger *logger.Logger) (*ComboStrategy, error) {
+	return newComboStrategy(config, exchange, nil, logger)
+}
+
+func newComboStrategy(config types.ComboConfig, exchange types.ExchangeClient, accounts map[string]types.ExchangeClient, logger *logger.Logger) (*ComboStrategy, error) {
 	if len(config.Strategies) == 0 {
 		return nil, fmt.Errorf("at least one strategy is required")
 	}
+	if err := validateWeighting(config); err != nil {
+		return nil, err
+	}
+	if err := validateArbitration(config.Arbitration); err != nil {
+		return nil, err
+	}
+	if config.Lookback <= 0 {
+		config.Lookback = 30
+	}
 
 	cs := &ComboStrategy{
+		Identity: NewIdentity("combo", config.ID, config.Name),
 		config:   config,
 		exchange: exchange,
+		accounts: accounts,
 		logger:   logger,
 		weights:  make([]float64, len(config.Strategies)),
+		returns:  make([][]float64, len(config.Strategies)),
+		lastNet:  make([]float64, len(config.Strategies)),
+	}
+	if config.Arbitration != nil {
+		cs.arbiter = newArbiter(*config.Arbitration, exchange, logger)
 	}
 
 	// Initialize strategies and weights
@@ -55,13 +93,19 @@ func (cs *ComboStrategy) initializeStrategies() error {
 	for i, strategyConfig := range cs.config.Strategies {
 		var strategy Strategy
 
+		exchange, err := cs.strategyExchange(i, strategyConfig)
+		if err != nil {
+			return err
+		}
+
 		switch strategyConfig.Type {
 		case "dca":
 			dcaConfig, err := cs.parseDCAConfig(strategyConfig.Config)
 			if err != nil {
 				return fmt.Errorf("invalid DCA config: %w", err)
 			}
-			strategy, err = factory.CreateDCA(dcaConfig, cs.exchange)
+			dcaConfig.ID, dcaConfig.Name = subStrategyID(i, strategyConfig), strategyConfig.Name
+			strategy, err = factory.CreateDCA(dcaConfig, exchange)
 			if err != nil {
 				return fmt.Errorf("failed to create DCA strategy: %w", err)
 			}
@@ -71,13 +115,37 @@ func (cs *ComboStrategy) initializeStrategies() error {
 			if err != nil {
 				return fmt.Errorf("invalid Grid config: %w", err)
 			}
-			strategy, err = factory.CreateGrid(gridConfig, cs.exchange)
+			gridConfig.ID, gridConfig.Name = subStrategyID(i, strategyConfig), strategyConfig.Name
+			strategy, err = factory.CreateGrid(gridConfig, exchange)
 			if err != nil {
 				return fmt.Errorf("failed to create Grid strategy: %w", err)
 			}
 
+		case "breakout":
+			breakoutConfig, err := ParseBreakoutConfig(strategyConfig.Config)
+			if err != nil {
+				return err
+			}
+			breakoutConfig.ID, breakoutConfig.Name = subStrategyID(i, strategyConfig), strategyConfig.Name
+			strategy, err = factory.CreateBreakout(breakoutConfig, exchange)
+			if err != nil {
+				return fmt.Errorf("failed to create Breakout strategy: %w", err)
+			}
+
 		default:
-			return fmt.Errorf("unsupported strategy type: %s", strategyConfig.Type)
+			// Externally registered strategies (e.g. loaded from plugins)
+			config := make(map[string]interface{}, len(strategyConfig.Config)+2)
+			for k, v := range strategyConfig.Config {
+				config[k] = v
+			}
+			config["id"] = subStrategyID(i, strategyConfig)
+			if strategyConfig.Name != "" {
+				config["name"] = strategyConfig.Name
+			}
+			strategy, err = factory.CreateRegistered(strategyConfig.Type, config, exchange)
+			if err != nil {
+				return err
+			}
 		}
 
 		cs.strategies[i] = strategy
@@ -87,6 +155,121 @@ func (cs *ComboStrategy) initializeStrategies() error {
 	return nil
 }
 
+// strategyExchange returns the exchange client for a sub-strategy: its bound
+// account's, wrapped in a capital account when the strategy has a dedicated
+// allocation and, on the main account, in the arbitration budget
+func (cs *ComboStrategy) strategyExchange(index int, strategyConfig types.StrategyConfig) (types.ExchangeClient, error) {
+	exchange, err := cs.allocatedExchange(index, strategyConfig)
+	if err != nil {
+		return nil, err
+	}
+	if cs.arbiter != nil && strategyConfig.Account == "" {
+		exchange = cs.arbiter.Client(subStrategyID(index, strategyConfig), exchange)
+	}
+	return exchange, nil
+}
+
+// allocatedExchange returns the sub-strategy's account client, wrapped in its
+// capital account when it has an allocation
+func (cs *ComboStrategy) allocatedExchange(index int, strategyConfig types.StrategyConfig) (types.ExchangeClient, error) {
+	exchange := cs.exchange
+	if strategyConfig.Account != "" {
+		account, ok := cs.accounts[strategyConfig.Account]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown account %q", subStrategyID(index, strategyConfig), strategyConfig.Account)
+		}
+		exchange = account
+	}
+	if strategyConfig.Allocation <= 0 {
+		return exchange, nil
+	}
+
+	if cs.allocator == nil {
+		cs.allocator = portfolio.NewCapitalAllocator(cs.logger)
+	}
+
+	id := subStrategyID(index, strategyConfig)
+	if err := cs.allocator.Allocate(id, strategyConfig.Allocation); err != nil {
+		return nil, fmt.Errorf("failed to allocate capital for %s: %w", id, err)
+	}
+	cs.budget += strategyConfig.Allocation
+	return cs.allocator.Client(id, exchange), nil
+}
+
+// validateWeighting checks the weighting mode; risk parity resizes
+// allocations within their total, so either all strategies or none have one
+func validateWeighting(config types.ComboConfig) error {
+	constraints := weightConstraints(config)
+	switch config.Weighting {
+	case "", WeightingEqual:
+		if constraints.Min != nil || constraints.Max != nil || constraints.MaxTurnover != 0 {
+			return fmt.Errorf("weight bounds and max turnover need risk_parity weighting")
+		}
+		return nil
+	case WeightingRiskParity:
+	default:
+		return fmt.Errorf("unknown weighting %q", config.Weighting)
+	}
+	if err := constraints.Validate(len(config.Strategies)); err != nil {
+		return err
+	}
+
+	if config.Lookback < 0 {
+		return fmt.Errorf("lookback must not be negative")
+	}
+	allocated := 0
+	for _, strategyConfig := range config.Strategies {
+		if strategyConfig.Allocation > 0 {
+			allocated++
+		}
+	}
+	if allocated != 0 && allocated != len(config.Strategies) {
+		return fmt.Errorf("risk parity needs an allocation on every strategy or on none")
+	}
+	return nil
+}
+
+// weightConstraints returns the configured bounds on risk parity weights;
+// Min and Max stay nil when no strategy sets them
+func weightConstraints(config types.ComboConfig) portfolio.WeightConstraints {
+	constraints := portfolio.WeightConstraints{MaxTurnover: config.MaxTurnover}
+	for i, strategyConfig := range config.Strategies {
+		if strategyConfig.MinWeight != 0 && constraints.Min == nil {
+			constraints.Min = make([]float64, len(config.Strategies))
+		}
+		if strategyConfig.MaxWeight != 0 && constraints.Max == nil {
+			constraints.Max = make([]float64, len(config.Strategies))
+		}
+		if constraints.Min != nil {
+			constraints.Min[i] = strategyConfig.MinWeight
+		}
+		if constraints.Max != nil {
+			constraints.Max[i] = strategyConfig.MaxWeight
+		}
+	}
+	return constraints
+}
+
+// subStrategyID returns the id of a sub-strategy, which also names its
+// capital account: the configured id, or <type>_<index>
+func subStrategyID(index int, strategyConfig types.StrategyConfig) string {
+	if strategyConfig.ID != "" {
+		return strategyConfig.ID
+	}
+	return fmt.Sprintf("%s_%d", strategyConfig.Type, index)
+}
+
+// GetCapitalAccounts returns virtual balances of sub-strategies with allocations
+func (cs *ComboStrategy) GetCapitalAccounts() []portfolio.CapitalAccount {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	if cs.allocator == nil {
+		return nil
+	}
+	return cs.allocator.Accounts()
+}
+
 // parseDCAConfig converts map to DCAConfig
 func (cs *ComboStrategy) parseDCAConfig(config map[string]interface{}) (types.DCAConfig, error) {
 	dcaConfig := types.DCAConfig{}
@@ -125,6 +308,29 @@ func (cs *ComboStrategy) parseDCAConfig(config map[string]interface{}) (types.DC
 		dcaConfig.Enabled = true // default
 	}
 
+	// Withdrawal (reverse DCA) settings
+	if mode, ok := config["mode"].(string); ok {
+		dcaConfig.Mode = mode
+	}
+	dcaConfig.Holdings, _ = config["holdings"].(float64)
+	dcaConfig.CostBasis, _ = config["cost_basis"].(float64)
+	dcaConfig.TakeProfit, _ = config["take_profit"].(float64)
+	dcaConfig.StopLoss, _ = config["stop_loss"].(float64)
+
+	dcaConfig.Filter = parseFilterConfig(config)
+
+	exit, err := ParseExitConfig(config)
+	if err != nil {
+		return dcaConfig, err
+	}
+	dcaConfig.Exit = exit
+
+	throttle, err := ParseThrottleConfig(config)
+	if err != nil {
+		return dcaConfig, err
+	}
+	dcaConfig.Throttle = throttle
+
 	return dcaConfig, nil
 }
 
@@ -168,6 +374,20 @@ func (cs *ComboStrategy) parseGridConfig(config map[string]interface{}) (types.G
 		gridConfig.Enabled = true // default
 	}
 
+	gridConfig.Filter = parseFilterConfig(config)
+
+	exit, err := ParseExitConfig(config)
+	if err != nil {
+		return gridConfig, err
+	}
+	gridConfig.Exit = exit
+
+	throttle, err := ParseThrottleConfig(config)
+	if err != nil {
+		return gridConfig, err
+	}
+	gridConfig.Throttle = throttle
+
 	return gridConfig, nil
 }
 
@@ -180,9 +400,9 @@ func (cs *ComboStrategy) Execute(ctx context.Context, market types.MarketData) e
 		return nil
 	}
 
-	// Execute all strategies
-	for i, strategy := range cs.strategies {
-		if err := strategy.Execute(ctx, market); err != nil {
+	// Execute all strategies, in ranked order under arbitration
+	for _, i := range cs.executionOrder(ctx, market) {
+		if err := cs.strategies[i].Execute(ctx, market); err != nil {
 			cs.logger.Error("Strategy %d execution failed: %v", i, err)
 			continue
 		}
@@ -191,9 +411,105 @@ func (cs *ComboStrategy) Execute(ctx context.Context, market types.MarketData) e
 	// Update combined metrics
 	cs.updateMetrics()
 
+	if cs.config.Weighting == WeightingRiskParity {
+		cs.recordReturns()
+		cs.rebalance()
+	}
+
 	return nil
 }
 
+// executionOrder returns the strategy indexes in config order, or ranked by
+// the arbiter on their current signals
+func (cs *ComboStrategy) executionOrder(ctx context.Context, market types.MarketData) []int {
+	if cs.arbiter == nil {
+		order := make([]int, len(cs.strategies))
+		for i := range order {
+			order[i] = i
+		}
+		return order
+	}
+
+	ids := make([]string, len(cs.strategies))
+	signals := make([]types.Signal, len(cs.strategies))
+	edges := make([]float64, len(cs.strategies))
+	for i, strategy := range cs.strategies {
+		ids[i] = subStrategyID(i, cs.config.Strategies[i])
+		signals[i] = strategy.GetSignal(market)
+		edges[i] = cs.config.Strategies[i].Edge
+	}
+	return cs.arbiter.begin(ctx, market.Symbol, ids, signals, edges, cs.weights)
+}
+
+// recordReturns appends each strategy's PnL change since the last execution
+func (cs *ComboStrategy) recordReturns() {
+	for i, strategy := range cs.strategies {
+		metrics := strategy.GetMetrics()
+		net := metrics.TotalProfit - metrics.TotalLoss
+		change := net - cs.lastNet[i]
+		cs.lastNet[i] = net
+
+		if cs.allocator != nil {
+			if account, ok := cs.allocator.Account(subStrategyID(i, cs.config.Strategies[i])); ok && account.Allocated > 0 {
+				change /= account.Allocated
+			}
+		}
+
+		cs.returns[i] = append(cs.returns[i], change)
+		if len(cs.returns[i]) > cs.config.Lookback {
+			cs.returns[i] = cs.returns[i][1:]
+		}
+	}
+}
+
+// rebalance sets weights so each strategy contributes equal PnL volatility
+// and resizes capital accounts to match. Weights stay unchanged until the
+// lookback window is full and every strategy's PnL has moved.
+func (cs *ComboStrategy) rebalance() {
+	if len(cs.returns[0]) < cs.config.Lookback {
+		return
+	}
+
+	cov, err := portfolio.Covariance(cs.returns)
+	if err != nil {
+		cs.logger.Debug("Risk parity skipped: %v", err)
+		return
+	}
+	weights, err := portfolio.RiskParityWeights(cov)
+	if err != nil {
+		cs.logger.Debug("Risk parity skipped: %v", err)
+		return
+	}
+	weights = weightConstraints(cs.config).Apply(cs.currentWeights(), weights)
+	copy(cs.weights, weights)
+
+	if cs.allocator == nil {
+		return
+	}
+	for i, weight := range weights {
+		id := subStrategyID(i, cs.config.Strategies[i])
+		if err := cs.allocator.Resize(id, cs.budget*weight); err != nil {
+			cs.logger.Error("Failed to resize allocation for %s: %v", id, err)
+		}
+	}
+}
+
+// currentWeights returns each strategy's share of the budget, or its signal
+// weight without allocations
+func (cs *ComboStrategy) currentWeights() []float64 {
+	current := make([]float64, len(cs.weights))
+	copy(current, cs.weights)
+	if cs.allocator == nil || cs.budget <= 0 {
+		return current
+	}
+	for i, strategyConfig := range cs.config.Strategies {
+		if account, ok := cs.allocator.Account(subStrategyID(i, strategyConfig)); ok {
+			current[i] = account.Allocated / cs.budget
+		}
+	}
+	return current
+}
+
 // GetSignal combines signals from all strategies with weights
 func (cs *ComboStrategy) GetSignal(market types.MarketData) types.Signal {
 	cs.mu.RLock()
@@ -253,6 +569,12 @@ func (cs *ComboStrategy) ValidateConfig() error {
 	if len(cs.config.Strategies) == 0 {
 		return fmt.Errorf("at least one strategy is required")
 	}
+	if err := validateWeighting(cs.config); err != nil {
+		return err
+	}
+	if err := validateArbitration(cs.config.Arbitration); err != nil {
+		return err
+	}
 
 	for i, strategy := range cs.config.Strategies {
 		if strategy.Type == "" {
@@ -290,6 +612,40 @@ func (cs *ComboStrategy) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// RequiredHistory merges the requirements of the sub-strategies, taking the
+// most candles asked of each symbol, interval and exchange
+func (cs *ComboStrategy) RequiredHistory() []HistoryRequirement {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	var reqs []HistoryRequirement
+	for _, strategy := range cs.strategies {
+	next:
+		for _, req := range strategy.RequiredHistory() {
+			for i := range reqs {
+				if reqs[i].Symbol == req.Symbol && reqs[i].Interval == req.Interval && reqs[i].Exchange == req.Exchange {
+					reqs[i].Candles = max(reqs[i].Candles, req.Candles)
+					continue next
+				}
+			}
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+// LoadHistory passes preloaded candles to the sub-strategies that take them
+func (cs *ComboStrategy) LoadHistory(req HistoryRequirement, candles []types.Candle) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	for _, strategy := range cs.strategies {
+		if loader, ok := strategy.(HistoryLoader); ok {
+			loader.LoadHistory(req, candles)
+		}
+	}
+}
+
 // updateMetrics aggregates metrics from all strategies
 func (cs *ComboStrategy) updateMetrics() {
 	var totalTrades, winningTrades, losingTrades int
@@ -331,31 +687,107 @@ func (cs *ComboStrategy) updateMetrics() {
 	}
 }
 
-// GetStatus returns combo strategy status
-func (cs *ComboStrategy) GetStatus() map[string]interface{} {
+// GetStatus returns the combo's weights and the status of each sub-strategy
+func (cs *ComboStrategy) GetStatus() types.StrategyStatus {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	combo := &types.ComboStatus{
+		Strategies:  make([]types.StrategyStatus, len(cs.strategies)),
+		Weights:     append([]float64(nil), cs.weights...),
+		TotalTrades: cs.metrics.TotalTrades,
+		WinRate:     cs.metrics.WinRate,
+		LastUpdate:  cs.metrics.LastUpdate,
+	}
+	for i, strategy := range cs.strategies {
+		sub := types.StrategyStatus{ID: strategy.ID(), Name: strategy.Name(), Type: cs.config.Strategies[i].Type}
+		if reporter, ok := strategy.(StatusReporter); ok {
+			sub = reporter.GetStatus()
+		}
+		sub.Account = cs.config.Strategies[i].Account
+		combo.Strategies[i] = sub
+	}
+	if cs.allocator != nil {
+		for _, account := range cs.allocator.Accounts() {
+			combo.CapitalAccounts = append(combo.CapitalAccounts, types.CapitalAccount{
+				StrategyID: account.StrategyID,
+				Allocated:  account.Allocated,
+				Available:  account.Available,
+				Holdings:   account.Holdings,
+				Spent:      account.Spent,
+				Proceeds:   account.Proceeds,
+				LastUpdate: account.LastUpdate,
+			})
+		}
+	}
+	if cs.arbiter != nil {
+		combo.Arbitration = cs.arbiter.status()
+	}
+
+	return types.StrategyStatus{ID: cs.ID(), Name: cs.Name(), Type: "combo", Enabled: cs.config.Enabled, Combo: combo}
+}
+
+// Markets returns the markets of the sub-strategies, each on its account's
+// exchange
+func (cs *ComboStrategy) Markets() []Market {
 	cs.mu.RLock()
 	defer cs.mu.RUnlock()
 
-	status := map[string]interface{}{
-		"enabled":      cs.config.Enabled,
-		"strategies":   len(cs.strategies),
-		"total_trades": cs.metrics.TotalTrades,
-		"win_rate":     cs.metrics.WinRate,
-		"last_update":  cs.metrics.LastUpdate,
+	var markets []Market
+	for _, strategy := range cs.strategies {
+		if lister, ok := strategy.(MarketLister); ok {
+			markets = append(markets, lister.Markets()...)
+		}
 	}
+	return markets
+}
 
-	// Add individual strategy statuses
-	strategyStatuses := make([]map[string]interface{}, len(cs.strategies))
+// Trace merges the decisions of the sub-strategies, named as in the combo
+// (e.g. "dca_0"), oldest first
+func (cs *ComboStrategy) Trace() []TraceEntry {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	traces := make([][]TraceEntry, 0, len(cs.strategies))
 	for i, strategy := range cs.strategies {
-		if statusProvider, ok := strategy.(interface{ GetStatus() map[string]interface{} }); ok {
-			strategyStatuses[i] = statusProvider.GetStatus()
-		} else {
-			strategyStatuses[i] = map[string]interface{}{
-				"type": fmt.Sprintf("strategy_%d", i),
-			}
+		traceable, ok := strategy.(Traceable)
+		if !ok {
+			continue
 		}
+		trace := traceable.Trace()
+		for j := range trace {
+			trace[j].Strategy = subStrategyID(i, cs.config.Strategies[i])
+		}
+		traces = append(traces, trace)
+	}
+	return mergeTraces(traces...)
+}
+
+// parseFilterConfig reads an optional {"filter": {"script"|"file": ...}} entry
+func parseFilterConfig(config map[string]interface{}) *types.SignalFilterConfig {
+	raw, ok := config["filter"].(map[string]interface{})
+	if !ok {
+		return nil
 	}
-	status["strategy_details"] = strategyStatuses
 
-	return status
+	filter := &types.SignalFilterConfig{}
+	filter.Script, _ = raw["script"].(string)
+	filter.File, _ = raw["file"].(string)
+	return filter
+}
+
+// decodeConfigObject decodes config[key] into out via JSON, reporting whether the key was present
+func decodeConfigObject(config map[string]interface{}, key string, out interface{}) (bool, error) {
+	raw, ok := config[key]
+	if !ok {
+		return false, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return true, fmt.Errorf("invalid %s config: %w", key, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return true, fmt.Errorf("invalid %s config: %w", key, err)
+	}
+	return true, nil
 }
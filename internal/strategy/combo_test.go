@@ -2,6 +2,9 @@ package strategy
 
 import (
 	"context"
+	"errors"
+	"math"
+	"slices"
 	"testing"
 	"time"
 
@@ -329,11 +332,286 @@ func TestComboStrategy_GetStatus(t *testing.T) {
 	}
 
 	status := strategy.GetStatus()
-	if status["enabled"] != true {
-		t.Errorf("Expected enabled=true, got %v", status["enabled"])
+	if !status.Enabled || status.Type != "combo" {
+		t.Errorf("Expected an enabled combo, got %+v", status)
 	}
 
-	if status["strategies"] != 1 {
-		t.Errorf("Expected 1 strategy, got %v", status["strategies"])
+	if len(status.Combo.Strategies) != 1 || status.Combo.Strategies[0].Type != "dca" {
+		t.Errorf("Expected 1 dca strategy, got %+v", status.Combo.Strategies)
+	}
+}
+
+func TestComboStrategy_IDs(t *testing.T) {
+	dca := map[string]interface{}{"symbol": "BTCUSDT", "investment_amount": 100.0, "interval": "24h", "max_investments": 10.0}
+	grid := map[string]interface{}{"symbol": "ETHUSDT", "upper_price": 3000.0, "lower_price": 2000.0, "grid_levels": 3.0, "investment_per_level": 100.0}
+	config := types.ComboConfig{
+		ID:      "combo-a",
+		Enabled: true,
+		Strategies: []types.StrategyConfig{
+			{Type: "dca", Config: dca},
+			{Type: "grid", Config: grid, ID: "grid-eth", Name: "ETH grid"},
+		},
+	}
+	factory := NewFactory(logger.New(logger.LevelError))
+	combo, err := factory.CreateCombo(config, &MockExchangeClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := combo.(StatusReporter).GetStatus()
+	if status.ID != "combo-a" || status.Name != "combo-a" {
+		t.Errorf("combo identity = %s %q, want combo-a", status.ID, status.Name)
+	}
+	if subs := status.Combo.Strategies; subs[0].ID != "dca_0" || subs[1].ID != "grid-eth" || subs[1].Name != "ETH grid" {
+		t.Errorf("sub-strategy identities = %+v", subs)
+	}
+
+	config.Strategies[0].ID = "grid-eth"
+	if _, err := factory.CreateCombo(config, &MockExchangeClient{}); err == nil {
+		t.Error("Expected an error for duplicate sub-strategy ids")
+	}
+	config.Strategies[0].ID = "dca/btc"
+	if _, err := factory.CreateCombo(config, &MockExchangeClient{}); err == nil {
+		t.Error("Expected an error for an id that cannot prefix client order ids")
+	}
+}
+
+// pnlStrategy reports a scripted net PnL path, one step per Execute
+type pnlStrategy struct {
+	Identity
+	steps []float64
+	net   float64
+	calls int
+}
+
+func (s *pnlStrategy) Execute(ctx context.Context, market types.MarketData) error {
+	s.net += s.steps[s.calls%len(s.steps)]
+	s.calls++
+	return nil
+}
+
+func (s *pnlStrategy) GetSignal(market types.MarketData) types.Signal {
+	return types.Signal{Type: types.SignalTypeHold}
+}
+
+func (s *pnlStrategy) ValidateConfig() error { return nil }
+
+func (s *pnlStrategy) GetMetrics() types.StrategyMetrics {
+	if s.net >= 0 {
+		return types.StrategyMetrics{TotalProfit: s.net}
+	}
+	return types.StrategyMetrics{TotalLoss: -s.net}
+}
+
+func (s *pnlStrategy) Shutdown(ctx context.Context) error { return nil }
+
+func (s *pnlStrategy) RequiredHistory() []HistoryRequirement { return nil }
+
+func TestComboStrategy_RiskParity(t *testing.T) {
+	dca := map[string]interface{}{"symbol": "BTCUSDT"}
+	config := types.ComboConfig{
+		Strategies: []types.StrategyConfig{
+			{Type: "dca", Config: dca, Allocation: 1000},
+			{Type: "dca", Config: dca, Allocation: 1000},
+		},
+		Enabled:   true,
+		Weighting: WeightingRiskParity,
+		Lookback:  8,
+	}
+
+	cs, err := NewComboStrategy(config, &MockExchangeClient{}, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatalf("Failed to create Combo strategy: %v", err)
+	}
+	// The second strategy's PnL swings three times as much
+	cs.strategies = []Strategy{
+		&pnlStrategy{steps: []float64{10, -10, 5, -5}},
+		&pnlStrategy{steps: []float64{-30, 30, 15, -15}},
+	}
+
+	ctx := context.Background()
+	market := types.MarketData{Symbol: "BTCUSDT", Price: 45000, Timestamp: time.Now()}
+	for i := 0; i < 7; i++ {
+		_ = cs.Execute(ctx, market)
+	}
+	if cs.weights[0] != 0.5 {
+		t.Errorf("Expected equal weights before the lookback fills, got %v", cs.weights)
+	}
+
+	_ = cs.Execute(ctx, market)
+	if math.Abs(cs.weights[0]-0.75) > 1e-6 || math.Abs(cs.weights[1]-0.25) > 1e-6 {
+		t.Errorf("Expected weights [0.75 0.25], got %v", cs.weights)
+	}
+	accounts := cs.GetCapitalAccounts()
+	if math.Abs(accounts[0].Allocated-1500) > 1e-6 || math.Abs(accounts[1].Allocated-500) > 1e-6 {
+		t.Errorf("Expected allocations 1500/500, got %.2f/%.2f", accounts[0].Allocated, accounts[1].Allocated)
+	}
+
+	// Bounds and a turnover cap hold the first strategy back
+	config.Strategies[0].MaxWeight = 0.6
+	config.MaxTurnover = 0.05
+	cs, err = NewComboStrategy(config, &MockExchangeClient{}, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatalf("Failed to create constrained Combo strategy: %v", err)
+	}
+	cs.strategies = []Strategy{
+		&pnlStrategy{steps: []float64{10, -10, 5, -5}},
+		&pnlStrategy{steps: []float64{-30, 30, 15, -15}},
+	}
+	for i := 0; i < 8; i++ {
+		_ = cs.Execute(ctx, market)
+	}
+	if math.Abs(cs.weights[0]-0.55) > 1e-6 || math.Abs(cs.weights[1]-0.45) > 1e-6 {
+		t.Errorf("Expected weights [0.55 0.45] towards the 0.6 cap, got %v", cs.weights)
+	}
+	accounts = cs.GetCapitalAccounts()
+	if math.Abs(accounts[0].Allocated-1100) > 1e-6 || math.Abs(accounts[1].Allocated-900) > 1e-6 {
+		t.Errorf("Expected allocations 1100/900, got %.2f/%.2f", accounts[0].Allocated, accounts[1].Allocated)
+	}
+	config.Strategies[0].MinWeight, config.Strategies[1].MinWeight = 0.6, 0.6
+	if _, err := NewComboStrategy(config, &MockExchangeClient{}, logger.New(logger.LevelError)); err == nil {
+		t.Error("Expected error when the minimum weights sum to more than 1")
+	}
+	config.Weighting = WeightingEqual
+	if _, err := NewComboStrategy(config, &MockExchangeClient{}, logger.New(logger.LevelError)); err == nil {
+		t.Error("Expected error for weight bounds without risk parity")
+	}
+	config.Weighting = WeightingRiskParity
+	config.Strategies[0].MinWeight, config.Strategies[1].MinWeight = 0, 0
+	config.Strategies[0].MaxWeight, config.MaxTurnover = 0, 0
+
+	config.Strategies[1].Allocation = 0
+	if _, err := NewComboStrategy(config, &MockExchangeClient{}, logger.New(logger.LevelError)); err == nil {
+		t.Error("Expected error when only some strategies have allocations")
+	}
+	config.Weighting = "markowitz"
+	if _, err := NewComboStrategy(config, &MockExchangeClient{}, logger.New(logger.LevelError)); err == nil {
+		t.Error("Expected error for unknown weighting")
+	}
+}
+
+func BenchmarkComboStrategy_GetSignal(b *testing.B) {
+	strategies := make([]types.StrategyConfig, 0, 8)
+	for i := 0; i < 4; i++ {
+		strategies = append(strategies,
+			types.StrategyConfig{Type: "dca", Config: map[string]interface{}{
+				"symbol": "BTCUSDT", "investment_amount": 100.0, "interval": "1h", "max_investments": 100.0, "enabled": true,
+			}},
+			types.StrategyConfig{Type: "grid", Config: map[string]interface{}{
+				"symbol": "BTCUSDT", "upper_price": 50000.0, "lower_price": 40000.0, "grid_levels": 50.0, "investment_per_level": 100.0, "enabled": true,
+			}},
+		)
+	}
+	combo, err := NewComboStrategy(types.ComboConfig{Strategies: strategies, Enabled: true}, &MockExchangeClient{}, logger.New(logger.LevelError))
+	if err != nil {
+		b.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		price := 45000 + 4000*math.Sin(float64(i)/100)
+		combo.GetSignal(types.MarketData{Symbol: "BTCUSDT", Price: price, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+}
+
+// buyStrategy buys a fixed notional on every Execute with a scripted signal
+type buyStrategy struct {
+	Identity
+	exchange types.ExchangeClient
+	strength float64
+	notional float64
+	err      error
+}
+
+func (s *buyStrategy) Execute(ctx context.Context, market types.MarketData) error {
+	s.err = s.exchange.PlaceOrder(ctx, types.Order{
+		Symbol: market.Symbol, Side: types.OrderSideBuy, Type: types.OrderTypeMarket,
+		Quantity: s.notional / market.Price, Price: market.Price,
+	})
+	return s.err
+}
+
+func (s *buyStrategy) GetSignal(market types.MarketData) types.Signal {
+	return types.Signal{Type: types.SignalTypeBuy, Symbol: market.Symbol, Strength: s.strength}
+}
+
+func (s *buyStrategy) ValidateConfig() error { return nil }
+
+func (s *buyStrategy) GetMetrics() types.StrategyMetrics { return types.StrategyMetrics{} }
+
+func (s *buyStrategy) Shutdown(ctx context.Context) error { return nil }
+
+func (s *buyStrategy) RequiredHistory() []HistoryRequirement { return nil }
+
+func TestComboStrategy_Arbitration(t *testing.T) {
+	dca := map[string]interface{}{"symbol": "BTCUSDT"}
+	config := types.ComboConfig{
+		Strategies: []types.StrategyConfig{
+			{Type: "dca", ID: "weak", Config: dca},
+			{Type: "dca", ID: "strong", Config: dca},
+			{Type: "dca", ID: "edge", Config: dca, Edge: 0.5},
+		},
+		Enabled:     true,
+		Arbitration: &types.ArbitrationConfig{Reserve: 4000},
+	}
+
+	exchange := &MockExchangeClient{}
+	cs, err := NewComboStrategy(config, exchange, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatalf("Failed to create Combo strategy: %v", err)
+	}
+	// 10000 free less the 4000 reserve fits two of the three 2500 buys
+	buys := []*buyStrategy{{strength: 0.4}, {strength: 0.9}, {strength: 0.6}}
+	cs.strategies = make([]Strategy, len(buys))
+	for i, buy := range buys {
+		buy.notional = 2500
+		buy.exchange = cs.arbiter.Client(config.Strategies[i].ID, exchange)
+		cs.strategies[i] = buy
+	}
+
+	market := types.MarketData{Symbol: "BTCUSDT", Price: 50000, Timestamp: time.Now()}
+	_ = cs.Execute(context.Background(), market)
+	if len(exchange.orders) != 2 {
+		t.Fatalf("Expected 2 buys within the budget, got %d", len(exchange.orders))
+	}
+	if !errors.Is(buys[0].err, ErrArbitrationBudget) || buys[1].err != nil || buys[2].err != nil {
+		t.Errorf("Expected only the weakest signal turned down, got %v, %v, %v", buys[0].err, buys[1].err, buys[2].err)
+	}
+	status := cs.GetStatus().Combo.Arbitration
+	if status == nil || !slices.Equal(status.Ranking, []string{"strong", "edge", "weak"}) {
+		t.Fatalf("Expected ranking strong, edge, weak, got %+v", status)
+	}
+	if math.Abs(status.Budget-1000) > 1e-6 || status.Rejected["weak"] != 1 {
+		t.Errorf("Expected 1000 left and one rejection of weak, got %.2f and %v", status.Budget, status.Rejected)
+	}
+
+	// A combo quoted in BTC is budgeted from the BTC balance, which is empty
+	exchange.orders = nil
+	_ = cs.Execute(context.Background(), types.MarketData{Symbol: "ETHBTC", Price: 0.05, Timestamp: time.Now()})
+	if len(exchange.orders) != 0 {
+		t.Fatalf("Expected no buys without a BTC balance, got %d", len(exchange.orders))
+	}
+	if status := cs.GetStatus().Combo.Arbitration; status.Budget != 0 || status.Rejected["weak"] != 2 {
+		t.Errorf("Expected no budget and every buy turned down, got %.2f and %v", status.Budget, status.Rejected)
+	}
+
+	// Weighing the expected edge puts the edge strategy first
+	config.Arbitration = &types.ArbitrationConfig{Strength: 1, Edge: 2}
+	cs, err = NewComboStrategy(config, exchange, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatalf("Failed to create Combo strategy: %v", err)
+	}
+	signals := []types.Signal{
+		{Type: types.SignalTypeBuy, Strength: 0.4},
+		{Type: types.SignalTypeBuy, Strength: 0.9},
+		{Type: types.SignalTypeBuy, Strength: 0.6},
+	}
+	order := cs.arbiter.begin(context.Background(), "BTCUSDT", []string{"weak", "strong", "edge"}, signals, []float64{0, 0, 0.5}, cs.weights)
+	if !slices.Equal(order, []int{2, 1, 0}) {
+		t.Errorf("Expected order [2 1 0], got %v", order)
+	}
+
+	config.Arbitration = &types.ArbitrationConfig{Reserve: -1}
+	if _, err := NewComboStrategy(config, exchange, logger.New(logger.LevelError)); err == nil {
+		t.Error("Expected error for a negative reserve")
 	}
 }
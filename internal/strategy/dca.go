@@ -2,71 +2,157 @@ package strategy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
 // DCAStrategy implements a basic Dollar-Cost Averaging strategy
 type DCAStrategy struct {
-	config   types.DCAConfig
-	exchange types.ExchangeClient
-	logger   *logger.Logger
-	metrics  *types.StrategyMetrics
-	lastBuy  time.Time
-	buyCount int
-	mu       sync.RWMutex
-	ctx      context.Context
-	cancel   context.CancelFunc
+	Identity
+	config    types.DCAConfig
+	exchange  types.ExchangeClient
+	logger    *logger.Logger
+	metrics   *types.StrategyMetrics
+	filter    SignalFilter
+	volTgt    *VolatilityTarget
+	exit      *ExitManager
+	throttle  *risk.Throttle
+	lastBuy   time.Time
+	buyCount  int
+	sold      float64 // withdrawn quantity
+	proceeds  float64 // quote received for withdrawals
+	rules     symbolRules
+	funding   fundingTotals
+	savings   savingsTotals
+	chase     *limitChase // buy being worked at the bid
+	execution executionTotals
+	skips     skipLog // buys skipped for want of cash
+	trace     *Tracer
+	mu        sync.RWMutex
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
 // NewDCAStrategy creates a new DCA strategy instance
 func NewDCAStrategy(config types.DCAConfig, exchange types.ExchangeClient, logger *logger.Logger) *DCAStrategy {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &DCAStrategy{
+	d := &DCAStrategy{
+		Identity: NewIdentity("dca", config.ID, config.Name),
 		config:   config,
 		exchange: exchange,
 		logger:   logger,
 		metrics: &types.StrategyMetrics{
 			LastUpdate: time.Now(),
 		},
+		trace:  NewTracer("dca"),
 		ctx:    ctx,
 		cancel: cancel,
 	}
+	if config.VolTarget != nil {
+		d.volTgt = NewVolatilityTarget(*config.VolTarget)
+	}
+	if config.Exit != nil {
+		d.exit = NewExitManager(*config.Exit)
+	}
+	return d
 }
 
 // Execute runs the DCA logic
-func (d *DCAStrategy) Execute(ctx context.Context, market types.MarketData) error {
+func (d *DCAStrategy) Execute(ctx context.Context, market types.MarketData) (err error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	d.trace.begin(market)
+	defer func() { d.trace.end(RuleNothingDue, err) }()
+
 	// Check if strategy is enabled
 	if !d.config.Enabled {
+		d.trace.blocked(RuleDisabled, "strategy is disabled")
+		return nil
+	}
+
+	if d.filter != nil {
+		d.filter.Observe(market)
+	}
+	if d.volTgt != nil {
+		d.volTgt.ObserveMarket(market)
+	}
+
+	if d.withdrawing() {
+		if err := d.executeWithdrawal(ctx, market); err != nil {
+			d.logger.Error("Error executing withdrawal: %v", err)
+			return err
+		}
 		return nil
 	}
 
+	// Exits are risk actions and bypass the signal filter
+	if d.exit != nil {
+		if exited, err := d.executeExit(ctx, market); err != nil || exited {
+			return err
+		}
+	}
+
+	// A chased buy runs until it fills or crosses the spread
+	if d.chase != nil {
+		if err := d.continueChase(ctx, market); err != nil {
+			if errors.Is(err, ErrArbitrationBudget) {
+				d.skips.skipped(d.logger.Info, "DCA buy delayed until the arbitration budget fits it: %v", err)
+				return nil
+			}
+			if errors.Is(err, risk.ErrThrottled) {
+				d.logger.Info("DCA buy delayed: %v", err)
+				return nil
+			}
+			d.logger.Error("Error chasing buy: %v", err)
+			return err
+		}
+		return nil
+	}
+
+	// Idle budget earns in savings until just before a buy is due
+	if d.config.Savings != nil {
+		d.manageSavings(ctx, market)
+	}
+
 	// Enforce interval between buys
-	if time.Since(d.lastBuy) < d.config.Interval {
+	if marketTime(market).Sub(d.lastBuy) < d.config.Interval {
+		d.trace.blocked(RuleInterval, "next buy at %s", d.lastBuy.Add(d.config.Interval).Format(time.RFC3339))
 		return nil
 	}
 
 	// Respect max number of investments
 	if d.buyCount >= d.config.MaxInvestments {
 		d.logger.Info("Maximum number of investments reached for %s", d.config.Symbol)
+		d.trace.blocked(RuleMaxInvestments, "%d of %d buys made", d.buyCount, d.config.MaxInvestments)
 		return nil
 	}
 
 	// Optional price threshold
 	if d.config.PriceThreshold > 0 && market.Price > d.config.PriceThreshold {
+		d.trace.blocked(RulePriceThreshold, "price %.2f above threshold %.2f", market.Price, d.config.PriceThreshold)
 		return nil
 	}
 
 	// Execute buy
 	if err := d.executeBuy(ctx, market); err != nil {
+		// A throttled buy, or one the combo's arbitration had no cash left
+		// for, is retried next tick like a vetoed one
+		if errors.Is(err, ErrArbitrationBudget) {
+			d.skips.skipped(d.logger.Info, "DCA buys skipped until the arbitration budget fits them: %v", err)
+			return nil
+		}
+		if errors.Is(err, risk.ErrThrottled) {
+			d.logger.Info("DCA buy skipped: %v", err)
+			return nil
+		}
 		d.logger.Error("Error executing buy: %v", err)
 		return err
 	}
@@ -79,6 +165,28 @@ func (d *DCAStrategy) GetSignal(market types.MarketData) types.Signal {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
+	if d.withdrawing() {
+		return d.withdrawalSignal(market)
+	}
+
+	if d.exit != nil {
+		if exit, ok := d.exit.CheckAt(market.Price, marketTime(market)); ok {
+			signal := types.Signal{
+				Type:      types.SignalTypeSell,
+				Symbol:    market.Symbol,
+				Price:     market.Price,
+				Quantity:  exit.Quantity,
+				Strength:  1.0,
+				Timestamp: market.Timestamp,
+				Metadata:  map[string]interface{}{"exit": exit.Reason, "target": exit.Target},
+			}
+			if exit.Tax != nil {
+				signal.Metadata["tax"] = exit.Tax
+			}
+			return signal
+		}
+	}
+
 	// Check threshold
 	if d.config.PriceThreshold > 0 && market.Price > d.config.PriceThreshold {
 		return types.Signal{
@@ -90,7 +198,7 @@ func (d *DCAStrategy) GetSignal(market types.MarketData) types.Signal {
 	}
 
 	// Check interval
-	if time.Since(d.lastBuy) < d.config.Interval {
+	if marketTime(market).Sub(d.lastBuy) < d.config.Interval {
 		return types.Signal{
 			Type:      types.SignalTypeHold,
 			Symbol:    market.Symbol,
@@ -109,6 +217,12 @@ func (d *DCAStrategy) GetSignal(market types.MarketData) types.Signal {
 		}
 	}
 
+	metadata := map[string]interface{}{
+		"buy_count": d.buyCount + 1,
+		"interval":  d.config.Interval.String(),
+	}
+	d.addVolMetadata(metadata)
+
 	return types.Signal{
 		Type:      types.SignalTypeBuy,
 		Symbol:    market.Symbol,
@@ -116,10 +230,7 @@ func (d *DCAStrategy) GetSignal(market types.MarketData) types.Signal {
 		Quantity:  d.calculateQuantity(market.Price),
 		Strength:  1.0,
 		Timestamp: market.Timestamp,
-		Metadata: map[string]interface{}{
-			"buy_count": d.buyCount + 1,
-			"interval":  d.config.Interval.String(),
-		},
+		Metadata:  metadata,
 	}
 }
 
@@ -141,7 +252,35 @@ func (d *DCAStrategy) ValidateConfig() error {
 		return fmt.Errorf("max investments must be positive")
 	}
 
-	return nil
+	if err := validateBudget(d.config); err != nil {
+		return err
+	}
+
+	if d.config.VolTarget != nil {
+		if err := ValidateVolTarget(*d.config.VolTarget); err != nil {
+			return err
+		}
+	}
+
+	if d.config.Exit != nil {
+		if err := ValidateExit(*d.config.Exit); err != nil {
+			return err
+		}
+	}
+
+	if d.config.Throttle != nil {
+		if err := risk.ValidateThrottle(*d.config.Throttle); err != nil {
+			return err
+		}
+	}
+
+	if d.config.Execution != nil {
+		if err := ValidateExecution(*d.config.Execution); err != nil {
+			return err
+		}
+	}
+
+	return validateWithdrawal(d.config)
 }
 
 // GetMetrics returns strategy metrics snapshot
@@ -152,17 +291,79 @@ func (d *DCAStrategy) GetMetrics() types.StrategyMetrics {
 	return *d.metrics
 }
 
-// Shutdown gracefully stops the strategy
+// Shutdown gracefully stops the strategy, canceling a chased limit buy
 func (d *DCAStrategy) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if d.chase != nil && d.chase.working {
+		if _, err := d.cancelChase(ctx, d.chase.order); err != nil {
+			d.logger.Warn("DCA limit buy left open: %v", err)
+		}
+	}
+	d.mu.Unlock()
 	d.cancel()
 	d.logger.Info("DCA strategy stopped")
 	return nil
 }
 
-// executeBuy places a market buy and updates metrics
+// RequiredHistory returns nil: buys are timed by the interval, and the
+// volatility target learns from the ticks it sees
+func (d *DCAStrategy) RequiredHistory() []HistoryRequirement {
+	return nil
+}
+
+// SetSignalFilter installs a filter consulted before each buy
+func (d *DCAStrategy) SetSignalFilter(filter SignalFilter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.filter = filter
+}
+
+// SetThrottle routes orders through throttle and reports it in status
+func (d *DCAStrategy) SetThrottle(throttle *risk.Throttle) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.throttle = throttle
+	d.exchange = throttle.Client(d.exchange)
+}
+
+// Throttle returns the installed throttle, or nil
+func (d *DCAStrategy) Throttle() *risk.Throttle {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.throttle
+}
+
+// executeBuy places a market buy and updates metrics, or starts a limit
+// chase for the buy
 func (d *DCAStrategy) executeBuy(ctx context.Context, market types.MarketData) error {
 	quantity := d.calculateQuantity(market.Price)
 
+	// A vetoed buy does not consume the interval and is retried next tick
+	signal := types.Signal{
+		Type:      types.SignalTypeBuy,
+		Symbol:    d.config.Symbol,
+		Price:     market.Price,
+		Quantity:  quantity,
+		Strength:  1.0,
+		Timestamp: market.Timestamp,
+	}
+	if d.volTgt != nil {
+		signal.Metadata = map[string]interface{}{}
+		d.addVolMetadata(signal.Metadata)
+	}
+	signal, ok := applyFilter(d.filter, d.logger, signal, market)
+	if !ok {
+		d.trace.blocked(RuleFilterVeto, "buy %.8f vetoed", quantity)
+		return nil
+	}
+	quantity, err := d.budget(ctx, signal.Quantity, market.Price)
+	if err != nil || quantity == 0 {
+		return err
+	}
+	if d.limitChasing() {
+		return d.startChase(ctx, market, quantity)
+	}
+
 	order := types.Order{
 		Symbol:    d.config.Symbol,
 		Side:      types.OrderSideBuy,
@@ -171,19 +372,30 @@ func (d *DCAStrategy) executeBuy(ctx context.Context, market types.MarketData) e
 		Price:     market.Price,
 		Status:    types.OrderStatusNew,
 		Timestamp: time.Now(),
+		Decision:  &types.Decision{Strategy: "dca", StrategyID: d.ID(), Action: types.DecisionBuy},
 	}
 
 	d.logger.Info("Placing DCA order: %s %.8f @ %.2f",
 		order.Symbol, order.Quantity, order.Price)
 
 	if err := d.exchange.PlaceOrder(ctx, order); err != nil {
+		d.trace.orderError(err, 0)
 		return fmt.Errorf("failed to place order: %w", err)
 	}
+	d.trace.triggered(RuleBuy, order, 0)
+	d.skips.resumed(d.logger.Info, "DCA buys fit the budget again")
+	if d.config.Funding != nil {
+		d.funding.fees += order.Quantity * order.Price * d.takerFee(ctx, d.config.Symbol)
+	}
+	d.execution.record(order.Type, order.Quantity*order.Price)
 
 	// Update metrics
-	d.lastBuy = time.Now()
+	d.lastBuy = marketTime(market)
 	d.buyCount++
 	d.updateMetrics(order, market.Price)
+	if d.exit != nil {
+		d.exit.OnBuyAt(order.Quantity, order.Price, d.lastBuy)
+	}
 
 	d.logger.Info("DCA buy executed: %s %.8f @ %.2f (buy #%d)",
 		order.Symbol, order.Quantity, order.Price, d.buyCount)
@@ -191,9 +403,127 @@ func (d *DCAStrategy) executeBuy(ctx context.Context, market types.MarketData) e
 	return nil
 }
 
-// calculateQuantity computes buy quantity by fixed investment amount
+// executeExit sells the part of the position due for exit, reporting whether it sold
+func (d *DCAStrategy) executeExit(ctx context.Context, market types.MarketData) (bool, error) {
+	exit, ok := d.exit.CheckAt(market.Price, marketTime(market))
+	if !ok {
+		return false, nil
+	}
+	_, entry := d.exit.Position()
+	if exit.Tax != nil {
+		d.logger.Info("DCA %s exit tax impact: %.8f from %d %s lot(s), gain %.2f short-term %.2f long-term, tax %.2f",
+			exit.Reason, exit.Tax.Quantity, len(exit.Tax.Lots), exit.Tax.Method, exit.Tax.ShortTermGain, exit.Tax.LongTermGain, exit.Tax.Tax)
+	}
+
+	order := types.Order{
+		Symbol:    d.config.Symbol,
+		Side:      types.OrderSideSell,
+		Type:      types.OrderTypeMarket,
+		Quantity:  exit.Quantity,
+		Price:     market.Price,
+		Status:    types.OrderStatusNew,
+		Timestamp: time.Now(),
+		Decision:  &types.Decision{Strategy: "dca", StrategyID: d.ID(), Action: types.DecisionExit, Exit: exit.Reason, Target: exit.Target, Tax: exit.Tax},
+	}
+	if err := d.exchange.PlaceOrder(ctx, order); err != nil {
+		d.trace.orderError(err, 0)
+		return false, fmt.Errorf("failed to place exit order: %w", err)
+	}
+	d.trace.triggered(RuleExit, order, 0)
+	d.exit.Filled(exit)
+
+	realized := (market.Price - entry) * exit.Quantity
+	d.updateMetrics(order, market.Price)
+	recordRealized(d.metrics, realized)
+
+	d.logger.Info("DCA %s exit: %s %.8f @ %.2f pnl=%.2f",
+		exit.Reason, order.Symbol, order.Quantity, order.Price, realized)
+	return true, nil
+}
+
+// Recover rebuilds the buy count, schedule, exit ladder and metrics from
+// fills placed before a restart
+func (d *DCAStrategy) Recover(fills []types.Order) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, fill := range fills {
+		if fill.Symbol != d.config.Symbol || fill.Decision == nil || fill.Decision.Strategy != "dca" {
+			continue
+		}
+		quantity, price := filledAt(fill)
+		order := fill
+		order.Quantity = quantity
+
+		switch fill.Decision.Action {
+		case types.DecisionSell:
+			d.buyCount++
+			d.lastBuy = fill.Timestamp
+			d.recordSell(order, price)
+		case types.DecisionBuy:
+			// The orders of a chased buy count as one buy
+			if fill.Decision.Buy == 0 {
+				d.buyCount++
+			} else if fill.Decision.Buy > d.buyCount {
+				d.buyCount = fill.Decision.Buy
+			}
+			d.lastBuy = fill.Timestamp
+			d.execution.record(fill.Type, quantity*price)
+			d.updateMetrics(order, price)
+			if d.exit != nil {
+				d.exit.OnBuyAt(quantity, price, fill.Timestamp)
+			}
+		case types.DecisionExit:
+			if d.withdrawing() {
+				d.recordSell(order, price)
+				continue
+			}
+			if d.exit == nil {
+				continue
+			}
+			_, entry := d.exit.Position()
+			d.exit.Filled(Exit{Quantity: quantity, Reason: fill.Decision.Exit, Target: fill.Decision.Target, Tax: fill.Decision.Tax})
+			d.updateMetrics(order, price)
+			recordRealized(d.metrics, (price-entry)*quantity)
+		}
+	}
+
+	if d.withdrawing() {
+		d.logger.Info("DCA withdrawal recovered: %d sells, %.8f sold", d.buyCount, d.sold)
+		return nil
+	}
+	d.logger.Info("DCA state recovered: %d buys, last at %s", d.buyCount, d.lastBuy.Format(time.RFC3339))
+	return nil
+}
+
+// marketTime returns the market snapshot time, falling back to wall clock.
+// Using the snapshot time keeps intervals correct when replaying history.
+func marketTime(market types.MarketData) time.Time {
+	if market.Timestamp.IsZero() {
+		return time.Now()
+	}
+	return market.Timestamp
+}
+
+// calculateQuantity computes buy quantity from the investment amount,
+// scaled by the volatility target if configured
 func (d *DCAStrategy) calculateQuantity(price float64) float64 {
-	return d.config.InvestmentAmount / price
+	amount := d.config.InvestmentAmount
+	if d.volTgt != nil {
+		scale, _ := d.volTgt.Scale()
+		amount *= scale
+	}
+	return amount / price
+}
+
+// addVolMetadata reports the volatility scaling applied to the next buy
+func (d *DCAStrategy) addVolMetadata(metadata map[string]interface{}) {
+	if d.volTgt == nil {
+		return
+	}
+	scale, vol := d.volTgt.Scale()
+	metadata["vol_scale"] = scale
+	metadata["realized_vol"] = vol
 }
 
 // updateMetrics updates strategy metrics counters
@@ -212,6 +542,13 @@ func (d *DCAStrategy) GetConfig() types.DCAConfig {
 	return d.config
 }
 
+// Markets returns the symbol the strategy buys
+func (d *DCAStrategy) Markets() []Market {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return []Market{{Symbol: d.config.Symbol, Exchange: d.exchange}}
+}
+
 // UpdateConfig updates strategy config with validation
 func (d *DCAStrategy) UpdateConfig(config types.DCAConfig) error {
 	d.mu.Lock()
@@ -221,6 +558,24 @@ func (d *DCAStrategy) UpdateConfig(config types.DCAConfig) error {
 		return err
 	}
 
+	switch {
+	case config.VolTarget == nil:
+		d.volTgt = nil
+	case d.volTgt == nil:
+		d.volTgt = NewVolatilityTarget(*config.VolTarget)
+	default:
+		d.volTgt.SetConfig(*config.VolTarget)
+	}
+
+	switch {
+	case config.Exit == nil:
+		d.exit = nil
+	case d.exit == nil:
+		d.exit = NewExitManager(*config.Exit)
+	default:
+		d.exit.SetConfig(*config.Exit)
+	}
+
 	d.config = config
 	d.logger.Info("DCA strategy configuration updated")
 	return nil
@@ -244,22 +599,83 @@ func (d *DCAStrategy) validateConfig(config types.DCAConfig) error {
 		return fmt.Errorf("max investments must be positive")
 	}
 
-	return nil
+	if err := validateBudget(config); err != nil {
+		return err
+	}
+
+	if config.VolTarget != nil {
+		if err := ValidateVolTarget(*config.VolTarget); err != nil {
+			return err
+		}
+	}
+
+	if config.Exit != nil {
+		if err := ValidateExit(*config.Exit); err != nil {
+			return err
+		}
+	}
+
+	if config.Throttle != nil {
+		if err := risk.ValidateThrottle(*config.Throttle); err != nil {
+			return err
+		}
+	}
+
+	if config.Execution != nil {
+		if err := ValidateExecution(*config.Execution); err != nil {
+			return err
+		}
+	}
+
+	return validateWithdrawal(config)
 }
 
-// GetStatus returns strategy status map for API
-func (d *DCAStrategy) GetStatus() map[string]interface{} {
+// GetStatus returns the strategy's schedule and progress
+func (d *DCAStrategy) GetStatus() types.StrategyStatus {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	return map[string]interface{}{
-		"enabled":           d.config.Enabled,
-		"symbol":            d.config.Symbol,
-		"buy_count":         d.buyCount,
-		"max_buys":          d.config.MaxInvestments,
-		"last_buy":          d.lastBuy,
-		"next_buy":          d.lastBuy.Add(d.config.Interval),
-		"interval":          d.config.Interval.String(),
-		"investment_amount": d.config.InvestmentAmount,
+	dca := &types.DCAStatus{
+		Mode:             types.DCAModeAccumulate,
+		Orders:           d.buyCount,
+		MaxOrders:        d.config.MaxInvestments,
+		LastOrder:        d.lastBuy,
+		NextOrder:        d.lastBuy.Add(d.config.Interval),
+		Interval:         d.config.Interval.String(),
+		InvestmentAmount: d.config.InvestmentAmount,
+	}
+	if d.volTgt != nil {
+		dca.VolScale, dca.RealizedVol = d.volTgt.Scale()
+	}
+	if d.exit != nil {
+		dca.ExitTargetsHit = d.exit.TargetsHit()
+		dca.StopPrice = d.exit.Stop()
+		if d.config.Exit.Tax != nil {
+			dca.TaxLots = d.exit.Lots()
+		}
+	}
+	if d.withdrawing() {
+		dca.Mode = types.DCAModeWithdraw
+		dca.Withdrawal = d.withdrawalStatus()
+	}
+	if d.config.Funding != nil {
+		dca.Funding = d.fundingStatus()
+	}
+	if d.config.Execution != nil {
+		dca.Execution = d.executionStatus()
+	}
+	if d.config.Savings != nil {
+		dca.Savings = d.savingsStatus()
+	}
+
+	status := types.StrategyStatus{ID: d.ID(), Name: d.Name(), Type: "dca", Symbol: d.config.Symbol, Enabled: d.config.Enabled, DCA: dca}
+	if d.throttle != nil {
+		status.Throttle = d.throttle.Status()
 	}
+	return status
+}
+
+// Trace returns the strategy's recent decisions, oldest first
+func (d *DCAStrategy) Trace() []TraceEntry {
+	return d.trace.Entries()
 }
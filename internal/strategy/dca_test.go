@@ -2,9 +2,13 @@ package strategy
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/sim"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
@@ -27,6 +31,10 @@ func (m *MockExchangeClient) GetOrder(ctx context.Context, orderID string) (*typ
 	return nil, nil
 }
 
+func (m *MockExchangeClient) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	return nil, types.ErrNotSupported
+}
+
 func (m *MockExchangeClient) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
 	return nil, nil
 }
@@ -46,6 +54,10 @@ func (m *MockExchangeClient) GetTicker(ctx context.Context, symbol string) (*typ
 	}, nil
 }
 
+func (m *MockExchangeClient) GetTickers(ctx context.Context, symbols []string) ([]types.Ticker, error) {
+	return types.FetchTickers(ctx, symbols, m.GetTicker)
+}
+
 func (m *MockExchangeClient) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
 	return nil, nil
 }
@@ -64,6 +76,11 @@ func (m *MockExchangeClient) GetBalance(ctx context.Context) (*types.Balance, er
 	}, nil
 }
 
+func (m *MockExchangeClient) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	balance, _ := m.GetBalance(ctx)
+	return []types.Balance{*balance}, nil
+}
+
 func (m *MockExchangeClient) GetTradingFees(ctx context.Context, symbol string) (*types.TradingFees, error) {
 	return &types.TradingFees{
 		Symbol:    symbol,
@@ -73,6 +90,42 @@ func (m *MockExchangeClient) GetTradingFees(ctx context.Context, symbol string)
 	}, nil
 }
 
+func (m *MockExchangeClient) GetFundingRate(ctx context.Context, symbol string) (*types.FundingRate, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (m *MockExchangeClient) GetOrderHistory(ctx context.Context, query types.OrderHistoryQuery) (*types.OrderHistoryPage, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (m *MockExchangeClient) GetSymbolRules(ctx context.Context, symbol string) (*types.SymbolRules, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (m *MockExchangeClient) ConvertDust(ctx context.Context, assets []string) (*types.DustConversion, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (m *MockExchangeClient) GetBorrowRates(ctx context.Context, assets []string) ([]types.BorrowRate, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (m *MockExchangeClient) GetSavings(ctx context.Context, asset string) (*types.SavingsBalance, error) {
+	return nil, types.ErrNotSupported
+}
+
+func (m *MockExchangeClient) SubscribeSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+func (m *MockExchangeClient) RedeemSavings(ctx context.Context, asset string, amount float64) error {
+	return types.ErrNotSupported
+}
+
+func (m *MockExchangeClient) GetSystemStatus(ctx context.Context) (*types.SystemStatus, error) {
+	return &types.SystemStatus{State: types.SystemNormal, Timestamp: time.Now()}, nil
+}
+
 func (m *MockExchangeClient) Ping(ctx context.Context) error {
 	return nil
 }
@@ -154,6 +207,29 @@ func TestDCAStrategy_ValidateConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown mode",
+			config: types.DCAConfig{
+				Symbol:           "BTCUSDT",
+				InvestmentAmount: 100.0,
+				Interval:         24 * time.Hour,
+				MaxInvestments:   100,
+				Mode:             "hodl",
+			},
+			wantErr: true,
+		},
+		{
+			name: "withdrawal take profit without cost basis",
+			config: types.DCAConfig{
+				Symbol:           "BTCUSDT",
+				InvestmentAmount: 100.0,
+				Interval:         24 * time.Hour,
+				MaxInvestments:   100,
+				Mode:             types.DCAModeWithdraw,
+				TakeProfit:       0.5,
+			},
+			wantErr: true,
+		},
 		{
 			name: "zero max investments",
 			config: types.DCAConfig{
@@ -324,19 +400,334 @@ func TestDCAStrategy_GetStatus(t *testing.T) {
 
 	status := strategy.GetStatus()
 
-	if status["enabled"] != true {
-		t.Errorf("Expected enabled true, got %v", status["enabled"])
+	if !status.Enabled {
+		t.Errorf("Expected enabled true, got %v", status.Enabled)
+	}
+
+	if status.Type != "dca" || status.Symbol != "BTCUSDT" {
+		t.Errorf("Expected a dca status for BTCUSDT, got %s %s", status.Type, status.Symbol)
+	}
+
+	if status.DCA.Orders != 0 {
+		t.Errorf("Expected buy count 0, got %v", status.DCA.Orders)
+	}
+
+	if status.DCA.MaxOrders != 100 {
+		t.Errorf("Expected max buys 100, got %v", status.DCA.MaxOrders)
+	}
+}
+
+func TestDCAStrategy_Withdraw(t *testing.T) {
+	config := types.DCAConfig{
+		Symbol:           "BTCUSDT",
+		InvestmentAmount: 1000.0,
+		Interval:         time.Hour,
+		MaxInvestments:   10,
+		Enabled:          true,
+		Mode:             types.DCAModeWithdraw,
+		Holdings:         0.06,
+		CostBasis:        30000,
+		TakeProfit:       0.5,
+	}
+	exchange := &MockExchangeClient{}
+	strategy := NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+	if err := strategy.ValidateConfig(); err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tick := range []struct {
+		offset time.Duration
+		price  float64
+	}{
+		{0, 40000},                // sells 0.025
+		{30 * time.Minute, 40000}, // not due
+		{time.Hour, 40000},        // sells 0.025
+		{90 * time.Minute, 46000}, // take profit sells the remaining 0.01
+		{3 * time.Hour, 40000},    // nothing left
+	} {
+		if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: tick.price, Timestamp: start.Add(tick.offset)}); err != nil {
+			t.Fatalf("Execute() at %s error = %v", tick.offset, err)
+		}
+	}
+
+	if len(exchange.orders) != 3 {
+		t.Fatalf("Expected 3 sells, got %d", len(exchange.orders))
+	}
+	for i, want := range []float64{0.025, 0.025, 0.01} {
+		order := exchange.orders[i]
+		if order.Side != types.OrderSideSell || math.Abs(order.Quantity-want) > 1e-9 {
+			t.Errorf("order %d = %s %.8f, want SELL %.8f", i, order.Side, order.Quantity, want)
+		}
+	}
+	if exit := exchange.orders[2].Decision; exit.Action != types.DecisionExit || exit.Exit != ExitTakeProfit {
+		t.Errorf("Expected a take-profit exit, got %+v", exit)
+	}
+
+	status := strategy.GetStatus().DCA
+	if status.Orders != 2 || status.Withdrawal.Remaining != 0 {
+		t.Errorf("status = %+v", status.Withdrawal)
+	}
+	if pnl := status.Withdrawal.RealizedPnL; math.Abs(pnl-(0.05*10000+0.01*16000)) > 1e-6 {
+		t.Errorf("realized pnl = %v", pnl)
+	}
+	if m := strategy.GetMetrics(); m.TotalTrades != 3 || m.WinningTrades != 3 {
+		t.Errorf("metrics = %+v", m)
 	}
 
-	if status["symbol"] != "BTCUSDT" {
-		t.Errorf("Expected symbol BTCUSDT, got %v", status["symbol"])
+	// A restart rebuilds the totals from the fills
+	restarted := NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+	if err := restarted.Recover(exchange.orders); err != nil {
+		t.Fatal(err)
+	}
+	if status := restarted.GetStatus().DCA; status.Orders != 2 || *status.Withdrawal != *strategy.GetStatus().DCA.Withdrawal {
+		t.Errorf("recovered status = %+v", status.Withdrawal)
+	}
+}
+
+func TestDCAStrategy_WithdrawBooksFills(t *testing.T) {
+	config := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 1000, Interval: time.Hour, MaxInvestments: 10, Enabled: true,
+		Mode: types.DCAModeWithdraw, Holdings: 0.06, CostBasis: 30000}
+	exchange, _ := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{40000}, Spread: 20, FillRatio: 0.5, BaseBalance: 1})
+	strategy := NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+	if err := strategy.Execute(context.Background(), exchange.Market()); err != nil {
+		t.Fatal(err)
 	}
 
-	if status["buy_count"] != 0 {
-		t.Errorf("Expected buy count 0, got %v", status["buy_count"])
+	// Half of the 0.025 sold, at the bid
+	status := strategy.GetStatus().DCA.Withdrawal
+	if math.Abs(status.SoldQuantity-0.0125) > 1e-9 || status.AvgSellPrice != 39990 || math.Abs(status.Remaining-0.0475) > 1e-9 {
+		t.Errorf("withdrawal = %+v, want 0.0125 sold at 39990", status)
+	}
+}
+
+func TestDCAStrategy_ArbitrationBudget(t *testing.T) {
+	config := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 5, Enabled: true}
+	exchange := &MockExchangeClient{}
+	arbiter := newArbiter(types.ArbitrationConfig{}, exchange, logger.New(logger.LevelError))
+	arbiter.limited, arbiter.budget = true, 50
+	strategy := NewDCAStrategy(config, arbiter.Client("dca", exchange), logger.New(logger.LevelError))
+
+	// A buy the combo has no cash left for waits for the next round
+	if err := strategy.Execute(context.Background(), types.MarketData{Symbol: "BTCUSDT", Price: 45000, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Execute() error = %v, want the buy skipped", err)
+	}
+	if len(exchange.orders) != 0 || arbiter.rejected["dca"] != 1 {
+		t.Errorf("orders = %d, rejected = %v; want the buy turned down", len(exchange.orders), arbiter.rejected)
+	}
+}
+
+// fundedExchange spends a quote balance on buys
+type fundedExchange struct {
+	*MockExchangeClient
+	free float64
+}
+
+func (f *fundedExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	f.free -= order.Quantity * order.Price
+	return f.MockExchangeClient.PlaceOrder(ctx, order)
+}
+
+func (f *fundedExchange) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	return []types.Balance{{Asset: "USDT", Free: f.free, Total: f.free}}, nil
+}
+
+func TestDCAStrategy_Budget(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := func(reduce bool) *fundedExchange {
+		t.Helper()
+		config := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 10, Enabled: true,
+			Reserve: 100, ReduceLastBuy: reduce}
+		exchange := &fundedExchange{MockExchangeClient: &MockExchangeClient{}, free: 250}
+		strategy := NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+		for i := 0; i < 3; i++ {
+			if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 100, Timestamp: start.Add(time.Duration(i) * time.Hour)}); err != nil {
+				t.Fatalf("Execute() #%d error = %v", i, err)
+			}
+		}
+		if trace := strategy.Trace(); trace[len(trace)-1].Rule != RuleInsufficient {
+			t.Errorf("last trace = %+v, want %s", trace[len(trace)-1], RuleInsufficient)
+		}
+		return exchange
+	}
+
+	// 150 above the reserve pays for one full buy; the rest is too little
+	if exchange := run(false); len(exchange.orders) != 1 || exchange.free != 150 {
+		t.Fatalf("without reduction: %d buys, %.2f left, want 1 buy and 150", len(exchange.orders), exchange.free)
+	}
+	// The last buy shrinks to the 50 left above the reserve
+	exchange := run(true)
+	if len(exchange.orders) != 2 || math.Abs(exchange.orders[1].Quantity-0.5) > 1e-12 || math.Abs(exchange.free-100) > 1e-9 {
+		t.Fatalf("with reduction: orders %+v, %.2f left, want a 0.5 last buy and the 100 reserve", exchange.orders, exchange.free)
+	}
+}
+
+func TestDCAStrategy_BudgetSkipLoggedOnce(t *testing.T) {
+	var logged []string
+	logf := func(format string, args ...interface{}) { logged = append(logged, fmt.Sprintf(format, args...)) }
+	var skips skipLog
+	for i := 0; i < 3; i++ {
+		skips.skipped(logf, "skipped %d", i)
+	}
+	skips.resumed(logf, "resumed")
+	skips.resumed(logf, "resumed")
+	skips.skipped(logf, "skipped %d", 3)
+	if want := []string{"skipped 0", "resumed", "skipped 3"}; !slices.Equal(logged, want) {
+		t.Fatalf("logged %v, want %v", logged, want)
+	}
+
+	// The DCA skips while the balance is short and resumes once it covers a buy
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 10, Enabled: true}
+	exchange := &fundedExchange{MockExchangeClient: &MockExchangeClient{}, free: 50}
+	strategy := NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+	if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 100, Timestamp: start}); err != nil {
+		t.Fatal(err)
+	}
+	if !strategy.skips.skipping {
+		t.Fatal("want the short balance to start skipping")
+	}
+	exchange.free = 1000
+	if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 100, Timestamp: start.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(exchange.orders) != 1 || strategy.skips.skipping {
+		t.Fatalf("%d buys, skipping %v after the top-up, want one buy and no skipping", len(exchange.orders), strategy.skips.skipping)
+	}
+}
+
+// convertingExchange also holds EURI, sold through an EURIUSDT book
+type convertingExchange struct {
+	*fundedExchange
+	euri float64
+	book *types.OrderBook
+}
+
+func (c *convertingExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	if order.Symbol != "EURIUSDT" {
+		return c.fundedExchange.PlaceOrder(ctx, order)
+	}
+	c.euri -= order.Quantity
+	c.free += order.Quantity * order.Price * 0.999
+	return c.MockExchangeClient.PlaceOrder(ctx, order)
+}
+
+func (c *convertingExchange) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	return []types.Balance{{Asset: "USDT", Free: c.free}, {Asset: "EURI", Free: c.euri}}, nil
+}
+
+func (c *convertingExchange) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	return c.book, nil
+}
+
+func TestDCAStrategy_Funding(t *testing.T) {
+	ctx := context.Background()
+	config := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: time.Hour, MaxInvestments: 10, Enabled: true,
+		Funding: &types.FundingConfig{Asset: "EURI", Symbol: "EURIUSDT", MaxSlippage: 0.01}}
+	newExchange := func(bids ...types.OrderBookEntry) *convertingExchange {
+		return &convertingExchange{fundedExchange: &fundedExchange{MockExchangeClient: &MockExchangeClient{}}, euri: 1000,
+			book: &types.OrderBook{Bids: bids, Asks: []types.OrderBookEntry{{Price: 1.081, Amount: 1000}}}}
+	}
+	// The USDT shortfall is converted from EURI, fees included, then bought
+	exchange := newExchange(types.OrderBookEntry{Price: 1.08, Amount: 1000})
+	strategy := NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+	if err := strategy.ValidateConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if len(exchange.orders) != 2 || exchange.orders[0].Decision.Action != types.DecisionConvert || exchange.orders[1].Symbol != "BTCUSDT" {
+		t.Fatalf("orders = %+v, want a conversion then the buy", exchange.orders)
+	}
+	if math.Abs(exchange.free) > 1e-9 || math.Abs(exchange.orders[1].Quantity-1) > 1e-12 {
+		t.Fatalf("%.8f USDT left after buying %.8f, want exactly 1 bought", exchange.free, exchange.orders[1].Quantity)
+	}
+	funding := strategy.GetStatus().DCA.Funding
+	// 0.1% on the conversion and on the buy
+	if funding.Conversions != 1 || math.Abs(funding.Fees-(100/0.999-100+0.1)) > 1e-9 {
+		t.Fatalf("funding status = %+v", funding)
+	}
+
+	// A thin book would convert too far from the mid price
+	exchange = newExchange(types.OrderBookEntry{Price: 1.08, Amount: 10}, types.OrderBookEntry{Price: 1.0, Amount: 1000})
+	strategy = NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+	if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if trace := strategy.Trace(); len(exchange.orders) != 0 || trace[len(trace)-1].Rule != RuleSlippage {
+		t.Fatalf("orders = %+v, last trace = %+v, want the conversion blocked", exchange.orders, trace[len(trace)-1])
+	}
+
+	config.Funding.Symbol = "EURIBTC"
+	if err := NewDCAStrategy(config, exchange, logger.New(logger.LevelError)).ValidateConfig(); err == nil {
+		t.Fatal("expected an error for a funding pair without the quote asset")
+	}
+}
+
+func TestDCAStrategy_Savings(t *testing.T) {
+	ctx := context.Background()
+	config := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 3 * time.Hour, MaxInvestments: 2, Enabled: true,
+		Savings: &types.SavingsConfig{Lead: time.Hour}}
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{100, 100, 100, 100}, QuoteBalance: 300, SavingsRate: 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	strategy := NewDCAStrategy(config, ex, logger.New(logger.LevelError))
+	if err := strategy.ValidateConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first buy is due at once; the second buy's budget is parked
+	if err := strategy.Execute(ctx, ex.Market()); err != nil {
+		t.Fatal(err)
+	}
+	if quote, _ := ex.Balances(); ex.Savings() != 100 || quote != 100 {
+		t.Fatalf("savings %.2f, balance %.2f, want 100 parked and 100 left after the buy", ex.Savings(), quote)
+	}
+	market, _ := ex.Step()
+	if err := strategy.Execute(ctx, market); err != nil {
+		t.Fatal(err)
+	}
+	if ex.Savings() < 100 {
+		t.Fatalf("savings %.2f redeemed before the lead", ex.Savings())
+	}
+
+	// An hour before the second buy its amount is redeemed, then bought
+	market, _ = ex.Step()
+	if err := strategy.Execute(ctx, market); err != nil {
+		t.Fatal(err)
+	}
+	if quote, _ := ex.Balances(); quote < 200 || ex.Savings() > 0.01 {
+		t.Fatalf("savings %.4f, balance %.4f, want the parked 100 redeemed with interest left", ex.Savings(), quote)
+	}
+	market, _ = ex.Step()
+	if err := strategy.Execute(ctx, market); err != nil {
+		t.Fatal(err)
+	}
+	if orders := ex.Orders(); len(orders) != 2 {
+		t.Fatalf("orders = %d, want both buys", len(orders))
+	}
+	savings := strategy.GetStatus().DCA.Savings
+	if savings.Parked != 0 || savings.Subscribed != 100 || savings.Redeemed != 100 || savings.Interest <= 0 || savings.APR != 0.05 {
+		t.Errorf("savings status = %+v", savings)
+	}
+
+	// Without savings on the exchange the buys go on as usual
+	ex, _ = sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{100}, QuoteBalance: 300})
+	strategy = NewDCAStrategy(config, ex, logger.New(logger.LevelError))
+	if err := strategy.Execute(ctx, ex.Market()); err != nil {
+		t.Fatal(err)
+	}
+	if len(ex.Orders()) != 1 || !strategy.GetStatus().DCA.Savings.Unavailable {
+		t.Errorf("orders = %d, savings = %+v, want the buy made and savings unavailable", len(ex.Orders()), strategy.GetStatus().DCA.Savings)
 	}
 
-	if status["max_buys"] != 100 {
-		t.Errorf("Expected max buys 100, got %v", status["max_buys"])
+	config.Savings.Lead = config.Interval
+	if err := NewDCAStrategy(config, ex, logger.New(logger.LevelError)).ValidateConfig(); err == nil {
+		t.Error("a lead as long as the interval was accepted")
 	}
 }
@@ -0,0 +1,271 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/compliance"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Exit reasons
+const (
+	ExitTakeProfit = "take_profit"
+	ExitStopLoss   = "stop_loss"
+	ExitBreakeven  = "breakeven_stop"
+)
+
+// dust is the position size treated as flat
+const dust = 1e-12
+
+// Exit is a sell proposed by an ExitManager
+type Exit struct {
+	Quantity float64
+	Reason   string
+	Target   int // index of the take-profit target, -1 for stops
+
+	// Tax is the estimated tax and the lots sold, with a tax config
+	Tax *types.TaxImpact
+}
+
+// ExitManager tracks one long position and proposes partial exits from a
+// take-profit ladder and a stop that can move to breakeven. Strategies report
+// fills with OnBuy/OnSell, call Check on every price and confirm executed
+// exits with Filled, so a failed order is simply proposed again. Buys are
+// also kept as tax lots; exits sell the lots the tax config picks, other
+// sells the oldest.
+type ExitManager struct {
+	cfg       types.ExitConfig
+	quantity  float64
+	entry     float64 // average entry price
+	peak      float64 // largest quantity since flat, the base for target fractions
+	hit       int     // targets taken
+	breakeven bool
+
+	lots   []types.TaxLot // open lots, oldest first
+	lotSeq int
+}
+
+// NewExitManager creates an exit manager for cfg
+func NewExitManager(cfg types.ExitConfig) *ExitManager {
+	return &ExitManager{cfg: cfg}
+}
+
+// ValidateExit checks an exit config
+func ValidateExit(cfg types.ExitConfig) error {
+	if len(cfg.Targets) == 0 && cfg.StopLoss <= 0 {
+		return fmt.Errorf("exit needs take-profit targets or a stop loss")
+	}
+	var total float64
+	for i, target := range cfg.Targets {
+		if target.Profit <= 0 {
+			return fmt.Errorf("exit target %d: profit must be positive", i)
+		}
+		if i > 0 && target.Profit <= cfg.Targets[i-1].Profit {
+			return fmt.Errorf("exit target %d: profits must be ascending", i)
+		}
+		if target.Fraction <= 0 || target.Fraction > 1 {
+			return fmt.Errorf("exit target %d: fraction must be in (0, 1]", i)
+		}
+		total += target.Fraction
+	}
+	if total > 1+1e-9 {
+		return fmt.Errorf("exit target fractions sum to %.2f, more than 1", total)
+	}
+	if cfg.StopLoss < 0 || cfg.StopLoss >= 1 {
+		return fmt.Errorf("exit stop loss must be in [0, 1)")
+	}
+	if cfg.BreakevenAfter < 0 || cfg.BreakevenAfter > len(cfg.Targets) {
+		return fmt.Errorf("exit breakeven_after must be between 0 and the number of targets")
+	}
+	if tax := cfg.Tax; tax != nil {
+		switch tax.Method {
+		case "", types.LotFIFO, types.LotHIFO, types.LotSpecific:
+		default:
+			return fmt.Errorf("unknown exit tax lot method %q (fifo, hifo, specific)", tax.Method)
+		}
+		if tax.ShortTermRate < 0 || tax.ShortTermRate >= 1 || tax.LongTermRate < 0 || tax.LongTermRate >= 1 {
+			return fmt.Errorf("exit tax rates must be in [0, 1)")
+		}
+		if tax.LongTermAfter < 0 {
+			return fmt.Errorf("exit tax long_term_after must not be negative")
+		}
+	}
+	return nil
+}
+
+// ParseExitConfig reads an "exit" object from a generic strategy config map
+func ParseExitConfig(config map[string]interface{}) (*types.ExitConfig, error) {
+	exit := &types.ExitConfig{}
+	if ok, err := decodeConfigObject(config, "exit", exit); !ok || err != nil {
+		return nil, err
+	}
+	return exit, nil
+}
+
+// SetConfig replaces the config, keeping the tracked position
+func (m *ExitManager) SetConfig(cfg types.ExitConfig) {
+	m.cfg = cfg
+	m.hit = min(m.hit, len(cfg.Targets))
+	m.breakeven = cfg.BreakevenAfter > 0 && m.hit >= cfg.BreakevenAfter
+}
+
+// OnBuy adds a fill made now to the position, updating the average entry
+func (m *ExitManager) OnBuy(quantity, price float64) {
+	m.OnBuyAt(quantity, price, time.Now())
+}
+
+// OnBuyAt adds a fill made at to the position as a new lot
+func (m *ExitManager) OnBuyAt(quantity, price float64, at time.Time) {
+	if quantity <= 0 {
+		return
+	}
+	m.entry = (m.entry*m.quantity + price*quantity) / (m.quantity + quantity)
+	m.quantity += quantity
+	m.peak = math.Max(m.peak, m.quantity)
+	m.lotSeq++
+	m.lots = append(m.lots, types.TaxLot{ID: strconv.Itoa(m.lotSeq), Quantity: quantity, Price: price, Acquired: at})
+}
+
+// OnSell removes quantity sold outside the exit manager, oldest lots first
+func (m *ExitManager) OnSell(quantity float64) {
+	m.sell(nil, quantity)
+}
+
+// Filled confirms that exit was executed
+func (m *ExitManager) Filled(exit Exit) {
+	if exit.Reason == ExitTakeProfit && exit.Target == m.hit {
+		m.hit++
+		if m.cfg.BreakevenAfter > 0 && m.hit >= m.cfg.BreakevenAfter {
+			m.breakeven = true
+		}
+	}
+	var sales []types.LotSale
+	if exit.Tax != nil {
+		sales = exit.Tax.Lots
+	}
+	m.sell(sales, exit.Quantity)
+}
+
+// sell removes quantity from the position, taking it from the lots in sales
+// and any rest from the oldest lots
+func (m *ExitManager) sell(sales []types.LotSale, quantity float64) {
+	m.quantity -= quantity
+	if m.quantity <= dust {
+		m.reset()
+		return
+	}
+
+	left := quantity
+	for _, sale := range sales {
+		for i := range m.lots {
+			if m.lots[i].ID == sale.Lot {
+				taken := math.Min(math.Min(sale.Quantity, m.lots[i].Quantity), left)
+				m.lots[i].Quantity -= taken
+				left -= taken
+			}
+		}
+	}
+	for i := range m.lots {
+		if left <= dust {
+			break
+		}
+		taken := math.Min(m.lots[i].Quantity, left)
+		m.lots[i].Quantity -= taken
+		left -= taken
+	}
+	open := m.lots[:0]
+	for _, lot := range m.lots {
+		if lot.Quantity > dust {
+			open = append(open, lot)
+		}
+	}
+	m.lots = open
+}
+
+// Check returns the exit due at price now, if any
+func (m *ExitManager) Check(price float64) (Exit, bool) {
+	return m.CheckAt(price, time.Now())
+}
+
+// CheckAt returns the exit due at price and time at, if any, with its tax
+// impact when the config is tax-aware. Stops take precedence over targets.
+func (m *ExitManager) CheckAt(price float64, at time.Time) (Exit, bool) {
+	exit, ok := m.check(price)
+	if ok && m.cfg.Tax != nil {
+		exit.Tax = compliance.SelectLots(m.lots, exit.Quantity, price, at, *m.cfg.Tax)
+	}
+	return exit, ok
+}
+
+func (m *ExitManager) check(price float64) (Exit, bool) {
+	if m.quantity <= dust || price <= 0 {
+		return Exit{}, false
+	}
+
+	if stop := m.Stop(); stop > 0 && price <= stop {
+		reason := ExitStopLoss
+		if m.breakeven {
+			reason = ExitBreakeven
+		}
+		return Exit{Quantity: m.quantity, Reason: reason, Target: -1}, true
+	}
+
+	if m.hit < len(m.cfg.Targets) {
+		target := m.cfg.Targets[m.hit]
+		if price >= m.entry*(1+target.Profit) {
+			quantity := math.Min(m.quantity, m.peak*target.Fraction)
+			if m.hit == len(m.cfg.Targets)-1 {
+				quantity = m.quantity
+			}
+			return Exit{Quantity: quantity, Reason: ExitTakeProfit, Target: m.hit}, true
+		}
+	}
+	return Exit{}, false
+}
+
+// Stop returns the current stop price, or 0 if there is none
+func (m *ExitManager) Stop() float64 {
+	switch {
+	case m.quantity <= dust:
+		return 0
+	case m.breakeven:
+		return m.entry
+	case m.cfg.StopLoss > 0:
+		return m.entry * (1 - m.cfg.StopLoss)
+	}
+	return 0
+}
+
+// Position returns the tracked quantity and average entry price
+func (m *ExitManager) Position() (quantity, entry float64) {
+	return m.quantity, m.entry
+}
+
+// TargetsHit returns how many take-profit targets were taken since flat
+func (m *ExitManager) TargetsHit() int {
+	return m.hit
+}
+
+// Lots returns the open tax lots, oldest first
+func (m *ExitManager) Lots() []types.TaxLot {
+	return append([]types.TaxLot(nil), m.lots...)
+}
+
+func (m *ExitManager) reset() {
+	m.quantity, m.entry, m.peak, m.hit, m.breakeven = 0, 0, 0, 0, false
+	m.lots = nil
+}
+
+// recordRealized adds a closed trade's PnL to metrics
+func recordRealized(metrics *types.StrategyMetrics, realized float64) {
+	if realized >= 0 {
+		metrics.WinningTrades++
+		metrics.TotalProfit += realized
+	} else {
+		metrics.LosingTrades++
+		metrics.TotalLoss += -realized
+	}
+}
@@ -0,0 +1,223 @@
+package strategy
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/sim"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ladder sells a third at +5%, a third at +10% and the rest at +20%,
+// moving the stop to breakeven after the first target
+var ladder = types.ExitConfig{
+	Targets: []types.ExitTarget{
+		{Profit: 0.05, Fraction: 1.0 / 3},
+		{Profit: 0.10, Fraction: 1.0 / 3},
+		{Profit: 0.20, Fraction: 1.0 / 3},
+	},
+	StopLoss:       0.1,
+	BreakevenAfter: 1,
+}
+
+func TestExitManagerLadder(t *testing.T) {
+	m := NewExitManager(ladder)
+	m.OnBuy(3, 100)
+
+	if _, ok := m.Check(104); ok {
+		t.Fatalf("no exit expected below the first target")
+	}
+	if stop := m.Stop(); math.Abs(stop-90) > 1e-9 {
+		t.Fatalf("initial stop = %v, want 90", stop)
+	}
+
+	exit, ok := m.Check(105)
+	if !ok || exit.Reason != ExitTakeProfit || exit.Target != 0 || math.Abs(exit.Quantity-1) > 1e-9 {
+		t.Fatalf("first target = %+v, %v", exit, ok)
+	}
+	// Unconfirmed exits (e.g. a rejected order) are proposed again
+	if again, _ := m.Check(105); again != exit {
+		t.Fatalf("unconfirmed exit changed: %+v", again)
+	}
+	m.Filled(exit)
+	if m.Stop() != 100 {
+		t.Fatalf("stop should move to breakeven, got %v", m.Stop())
+	}
+
+	exit, _ = m.Check(111)
+	m.Filled(exit)
+	exit, ok = m.Check(121)
+	if !ok || exit.Target != 2 || math.Abs(exit.Quantity-1) > 1e-9 {
+		t.Fatalf("last target = %+v, %v", exit, ok)
+	}
+	m.Filled(exit)
+	if qty, _ := m.Position(); qty != 0 || m.TargetsHit() != 0 || m.Stop() != 0 {
+		t.Fatalf("position should be flat and reset, got qty %v hits %d", qty, m.TargetsHit())
+	}
+}
+
+func TestExitManagerStops(t *testing.T) {
+	m := NewExitManager(ladder)
+	m.OnBuy(1, 100)
+	m.OnBuy(1, 80) // averages entry down to 90
+
+	exit, ok := m.Check(81)
+	if !ok || exit.Reason != ExitStopLoss || exit.Quantity != 2 {
+		t.Fatalf("stop loss = %+v, %v", exit, ok)
+	}
+
+	m = NewExitManager(ladder)
+	m.OnBuy(3, 100)
+	first, _ := m.Check(106)
+	m.Filled(first)
+	exit, ok = m.Check(100)
+	if !ok || exit.Reason != ExitBreakeven || math.Abs(exit.Quantity-2) > 1e-9 {
+		t.Fatalf("breakeven stop = %+v, %v", exit, ok)
+	}
+}
+
+func TestValidateExit(t *testing.T) {
+	invalid := []types.ExitConfig{
+		{},
+		{Targets: []types.ExitTarget{{Profit: 0.1, Fraction: 0.5}, {Profit: 0.05, Fraction: 0.5}}},
+		{Targets: []types.ExitTarget{{Profit: 0.1, Fraction: 0.7}, {Profit: 0.2, Fraction: 0.7}}},
+		{Targets: []types.ExitTarget{{Profit: 0.1, Fraction: 0}}},
+		{StopLoss: 1.5},
+		{Targets: []types.ExitTarget{{Profit: 0.1, Fraction: 1}}, BreakevenAfter: 2},
+		{StopLoss: 0.1, Tax: &types.TaxLotConfig{Method: "lifo"}},
+		{StopLoss: 0.1, Tax: &types.TaxLotConfig{ShortTermRate: 1.2}},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateExit(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+	if err := ValidateExit(ladder); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExitManagerTaxLots(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tax := types.TaxLotConfig{ShortTermRate: 0.3, LongTermRate: 0.15}
+	cfg := types.ExitConfig{Targets: []types.ExitTarget{{Profit: 0.05, Fraction: 2.0 / 3}, {Profit: 0.2, Fraction: 1.0 / 3}}}
+
+	// A long-term lot at 115 and short-term lots at 125 and 150; selling two
+	// at 140 owes 3.75 and 4.5 on the gains, -3 on the loss
+	for _, tc := range []struct {
+		method string
+		lots   []string
+		tax    float64
+	}{
+		{types.LotFIFO, []string{"1", "2"}, 3.75 + 4.5},
+		{types.LotHIFO, []string{"3", "2"}, -3 + 4.5},
+		{types.LotSpecific, []string{"3", "1"}, -3 + 3.75},
+	} {
+		tax.Method = tc.method
+		cfg.Tax = &tax
+		m := NewExitManager(cfg)
+		m.OnBuyAt(1, 115, now.AddDate(-2, 0, 0))
+		m.OnBuyAt(1, 125, now.AddDate(0, -6, 0))
+		m.OnBuyAt(1, 150, now.AddDate(0, -1, 0))
+
+		exit, ok := m.CheckAt(140, now)
+		if !ok || exit.Tax == nil {
+			t.Fatalf("%s: exit = %+v, %v, want a target with its tax impact", tc.method, exit, ok)
+		}
+		var lots []string
+		for _, sale := range exit.Tax.Lots {
+			lots = append(lots, sale.Lot)
+		}
+		if !reflect.DeepEqual(lots, tc.lots) || math.Abs(exit.Tax.Tax-tc.tax) > 1e-6 || math.Abs(exit.Tax.Quantity-2) > 1e-9 {
+			t.Errorf("%s: sells lots %v for tax %.4f, want %v for %.4f", tc.method, lots, exit.Tax.Tax, tc.lots, tc.tax)
+		}
+
+		// The lots sold leave the position; the average entry is unchanged
+		m.Filled(exit)
+		left := m.Lots()
+		sold := map[string]bool{tc.lots[0]: true, tc.lots[1]: true}
+		if len(left) != 1 || sold[left[0].ID] {
+			t.Errorf("%s: lots left = %+v", tc.method, left)
+		}
+		if _, entry := m.Position(); math.Abs(entry-130) > 1e-9 {
+			t.Errorf("%s: entry = %.2f, want 130", tc.method, entry)
+		}
+	}
+}
+
+func TestParseExitConfig(t *testing.T) {
+	cfg, err := ParseExitConfig(map[string]interface{}{
+		"exit": map[string]interface{}{
+			"targets":         []interface{}{map[string]interface{}{"profit": 0.05, "fraction": 0.5}},
+			"stop_loss":       0.02,
+			"breakeven_after": 1.0,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &types.ExitConfig{Targets: []types.ExitTarget{{Profit: 0.05, Fraction: 0.5}}, StopLoss: 0.02, BreakevenAfter: 1}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("ParseExitConfig = %+v, want %+v", cfg, want)
+	}
+	if cfg, err := ParseExitConfig(map[string]interface{}{}); cfg != nil || err != nil {
+		t.Fatalf("missing exit = %+v, %v", cfg, err)
+	}
+}
+
+func TestDCAExitLadder(t *testing.T) {
+	script := sim.Script{Symbol: "BTCUSDT", QuoteBalance: 1000, Prices: []float64{100, 106, 111, 95, 97}}
+	ex, orders, errSteps := runScenario(t, script, func(ex types.ExchangeClient) Strategy {
+		exit := types.ExitConfig{
+			Targets:        []types.ExitTarget{{Profit: 0.05, Fraction: 0.5}, {Profit: 0.1, Fraction: 0.5}},
+			BreakevenAfter: 1,
+		}
+		return NewDCAStrategy(types.DCAConfig{
+			Symbol:           "BTCUSDT",
+			InvestmentAmount: 100,
+			Interval:         3 * time.Hour,
+			MaxInvestments:   2,
+			Enabled:          true,
+			Exit:             &exit,
+		}, ex, logger.New(logger.LevelError))
+	})
+
+	// Buy at 100, half at +5%, rest at +10%, buy again on the next interval
+	want := []scenarioStep{{0, types.OrderSideBuy}, {1, types.OrderSideSell}, {2, types.OrderSideSell}, {3, types.OrderSideBuy}}
+	if !reflect.DeepEqual(orders, want) || errSteps != nil {
+		t.Fatalf("orders = %v (errors at %v), want %v", orders, errSteps, want)
+	}
+	if _, base := ex.Balances(); math.Abs(base-100/95.0) > 1e-9 {
+		t.Fatalf("BTC balance = %v, want %v", base, 100/95.0)
+	}
+}
+
+func TestGridExitStopBlocksRebuy(t *testing.T) {
+	// Levels 100/110/120 with a 5% stop per level
+	script := sim.Script{Symbol: "BTCUSDT", QuoteBalance: 1000, Prices: []float64{125, 119, 112, 113, 121, 119}}
+	_, orders, _ := runScenario(t, script, func(ex types.ExchangeClient) Strategy {
+		grid, err := NewGridStrategy(types.GridConfig{
+			Symbol:             "BTCUSDT",
+			LowerPrice:         100,
+			UpperPrice:         120,
+			GridLevels:         3,
+			InvestmentPerLevel: 100,
+			Enabled:            true,
+			Exit:               &types.ExitConfig{StopLoss: 0.05},
+		}, ex, logger.New(logger.LevelError))
+		if err != nil {
+			t.Fatalf("NewGridStrategy: %v", err)
+		}
+		return grid
+	})
+
+	// 120 level buys at 119 and stops out at 112; it stays blocked at 113
+	// and only rebuys after trading back above 120
+	want := []scenarioStep{{1, types.OrderSideBuy}, {2, types.OrderSideSell}, {5, types.OrderSideBuy}}
+	if !reflect.DeepEqual(orders, want) {
+		t.Fatalf("orders = %v, want %v", orders, want)
+	}
+}
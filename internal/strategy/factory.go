@@ -4,28 +4,64 @@ import (
 	"fmt"
 
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/scripting"
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
 // Factory is a strategy factory
 type Factory struct {
-	logger *logger.Logger
+	logger   *logger.Logger
+	registry *Registry
+	accounts map[string]types.ExchangeClient
+
+	// venueQuoter combines the accounts of a multi-venue grid into a quoter
+	venueQuoter func(venues map[string]types.ExchangeClient) VenueQuoter
 }
 
-// NewFactory creates a new strategy factory
+// NewFactory creates a new strategy factory backed by the default registry
 func NewFactory(logger *logger.Logger) *Factory {
 	return &Factory{
-		logger: logger,
+		logger:   logger,
+		registry: DefaultRegistry(),
 	}
 }
 
+// Registry returns the registry consulted for external strategy types
+func (f *Factory) Registry() *Registry {
+	return f.registry
+}
+
+// SetAccounts makes exchange accounts available by name to combo
+// sub-strategies bound to an account
+func (f *Factory) SetAccounts(accounts map[string]types.ExchangeClient) {
+	f.accounts = accounts
+}
+
+// SetVenueQuoter sets how multi-venue grids quote their accounts; grids
+// with venues cannot be created without one
+func (f *Factory) SetVenueQuoter(quoter func(venues map[string]types.ExchangeClient) VenueQuoter) {
+	f.venueQuoter = quoter
+}
+
 // CreateDCA creates a DCA strategy
 func (f *Factory) CreateDCA(config types.DCAConfig, exchange types.ExchangeClient) (Strategy, error) {
 	if err := f.validateDCAConfig(config); err != nil {
 		return nil, fmt.Errorf("invalid DCA config: %w", err)
 	}
 
-	strategy := NewDCAStrategy(config, exchange, f.logger)
+	log := f.named("dca", config.ID, config.Symbol)
+	strategy := NewDCAStrategy(config, exchange, log)
+	if config.Filter != nil {
+		filter, err := scripting.LoadFilter(*config.Filter, log)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DCA filter: %w", err)
+		}
+		strategy.SetSignalFilter(filter)
+	}
+	if config.Throttle != nil {
+		strategy.SetThrottle(risk.NewThrottle(*config.Throttle))
+	}
 	return strategy, nil
 }
 
@@ -34,24 +70,122 @@ func (f *Factory) CreateGrid(config types.GridConfig, exchange types.ExchangeCli
 	if err := f.validateGridConfig(config); err != nil {
 		return nil, fmt.Errorf("invalid Grid config: %w", err)
 	}
-	gs, err := NewGridStrategy(config, exchange, f.logger)
+	log := f.named("grid", config.ID, config.Symbol)
+	var router *venueRouter
+	if config.Venues != nil {
+		var err error
+		router, err = newVenueRouter(config.Symbol, *config.Venues, f.accounts, f.venueQuoter, log)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Grid venues: %w", err)
+		}
+		exchange = router
+	}
+	gs, err := NewGridStrategy(config, exchange, log)
 	if err != nil {
 		return nil, err
 	}
+	gs.venues = router
+	if config.Filter != nil {
+		filter, err := scripting.LoadFilter(*config.Filter, log)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Grid filter: %w", err)
+		}
+		gs.SetSignalFilter(filter)
+	}
+	if config.Throttle != nil {
+		gs.SetThrottle(risk.NewThrottle(*config.Throttle))
+	}
 	return gs, nil
 }
 
+// CreateBreakout creates a channel breakout strategy
+func (f *Factory) CreateBreakout(config types.BreakoutConfig, exchange types.ExchangeClient) (Strategy, error) {
+	if err := ValidateID(config.ID); err != nil {
+		return nil, fmt.Errorf("invalid Breakout config: %w", err)
+	}
+	if err := ValidateBreakout(config); err != nil {
+		return nil, fmt.Errorf("invalid Breakout config: %w", err)
+	}
+
+	log := f.named("breakout", config.ID, config.Symbol)
+	strategy := NewBreakoutStrategy(config, exchange, log)
+	if config.Filter != nil {
+		filter, err := scripting.LoadFilter(*config.Filter, log)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Breakout filter: %w", err)
+		}
+		strategy.SetSignalFilter(filter)
+	}
+	if config.Throttle != nil {
+		strategy.SetThrottle(risk.NewThrottle(*config.Throttle))
+	}
+	return strategy, nil
+}
+
 // CreateCombo creates a combined strategy
 func (f *Factory) CreateCombo(config types.ComboConfig, exchange types.ExchangeClient) (Strategy, error) {
 	if err := f.validateComboConfig(config); err != nil {
 		return nil, fmt.Errorf("invalid Combo config: %w", err)
 	}
 
-	return NewComboStrategy(config, exchange, f.logger)
+	return newComboStrategy(config, exchange, f.accounts, f.named("combo", config.ID, ""))
+}
+
+// CreateRegistered creates a strategy of an externally registered type (e.g. from a plugin)
+func (f *Factory) CreateRegistered(strategyType string, config map[string]interface{}, exchange types.ExchangeClient) (Strategy, error) {
+	ctor, ok := f.registry.Lookup(strategyType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported strategy type: %s", strategyType)
+	}
+
+	// Registered strategies get the same guardrails through their exchange client
+	throttle, err := ParseThrottleConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if throttle != nil {
+		if err := risk.ValidateThrottle(*throttle); err != nil {
+			return nil, fmt.Errorf("invalid %s throttle: %w", strategyType, err)
+		}
+		exchange = risk.NewThrottle(*throttle).Client(exchange)
+	}
+
+	id, _ := config["id"].(string)
+	if err := ValidateID(id); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", strategyType, err)
+	}
+
+	symbol, _ := config["symbol"].(string)
+	strategy, err := ctor(config, exchange, f.named(strategyType, id, symbol))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s strategy: %w", strategyType, err)
+	}
+	return strategy, nil
+}
+
+// named returns the logger of a strategy: a child named after its type,
+// tagged with its configured id and its symbol if any
+func (f *Factory) named(strategyType, id, symbol string) *logger.Logger {
+	fields := make(map[string]interface{})
+	if id != "" && id != strategyType {
+		fields["id"] = id
+	}
+	if symbol != "" {
+		fields["symbol"] = symbol
+	}
+	log := f.logger.Named(strategyType)
+	if len(fields) == 0 {
+		return log
+	}
+	return log.WithFields(fields)
 }
 
 // validateDCAConfig validates DCA configuration
 func (f *Factory) validateDCAConfig(config types.DCAConfig) error {
+	if err := ValidateID(config.ID); err != nil {
+		return err
+	}
+
 	if config.Symbol == "" {
 		return fmt.Errorf("symbol is required")
 	}
@@ -68,11 +202,33 @@ func (f *Factory) validateDCAConfig(config types.DCAConfig) error {
 		return fmt.Errorf("max investments must be positive")
 	}
 
-	return nil
+	if config.VolTarget != nil {
+		if err := ValidateVolTarget(*config.VolTarget); err != nil {
+			return err
+		}
+	}
+
+	if config.Exit != nil {
+		if err := ValidateExit(*config.Exit); err != nil {
+			return err
+		}
+	}
+
+	if config.Throttle != nil {
+		if err := risk.ValidateThrottle(*config.Throttle); err != nil {
+			return err
+		}
+	}
+
+	return validateWithdrawal(config)
 }
 
 // validateGridConfig validates Grid configuration
 func (f *Factory) validateGridConfig(config types.GridConfig) error {
+	if err := ValidateID(config.ID); err != nil {
+		return err
+	}
+
 	if config.Symbol == "" {
 		return fmt.Errorf("symbol is required")
 	}
@@ -89,15 +245,43 @@ func (f *Factory) validateGridConfig(config types.GridConfig) error {
 		return fmt.Errorf("investment per level must be positive")
 	}
 
+	if config.Exit != nil {
+		if err := ValidateExit(*config.Exit); err != nil {
+			return err
+		}
+	}
+
+	if config.Throttle != nil {
+		if err := risk.ValidateThrottle(*config.Throttle); err != nil {
+			return err
+		}
+	}
+
+	if config.Venues != nil {
+		if err := validateVenues(*config.Venues); err != nil {
+			return err
+		}
+		for _, account := range config.Venues.Accounts {
+			if _, ok := f.accounts[account]; !ok {
+				return fmt.Errorf("unknown venue account %q", account)
+			}
+		}
+	}
+
 	return nil
 }
 
 // validateComboConfig validates combined strategy configuration
 func (f *Factory) validateComboConfig(config types.ComboConfig) error {
+	if err := ValidateID(config.ID); err != nil {
+		return err
+	}
+
 	if len(config.Strategies) == 0 {
 		return fmt.Errorf("at least one strategy is required")
 	}
 
+	ids := make(map[string]bool)
 	for i, strategy := range config.Strategies {
 		if strategy.Type == "" {
 			return fmt.Errorf("strategy type is required for strategy %d", i)
@@ -106,7 +290,16 @@ func (f *Factory) validateComboConfig(config types.ComboConfig) error {
 		if strategy.Config == nil {
 			return fmt.Errorf("strategy config is required for strategy %d", i)
 		}
+
+		if err := ValidateID(strategy.ID); err != nil {
+			return fmt.Errorf("strategy %d: %w", i, err)
+		}
+		id := subStrategyID(i, strategy)
+		if ids[id] {
+			return fmt.Errorf("strategy %d: duplicate id %s", i, id)
+		}
+		ids[id] = true
 	}
 
-	return nil
+	return validateWeighting(config)
 }
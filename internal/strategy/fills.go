@@ -0,0 +1,118 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// orderSeq disambiguates client order ids tagged within one nanosecond
+var orderSeq atomic.Int64
+
+// tagOrder gives order a client order id to find its execution report by,
+// prefixed with the id of the strategy placing it. Ids already set, e.g. by
+// a caller, are kept.
+func tagOrder(order *types.Order, strategyID string) {
+	if order.ExchangeOrder != nil && order.ExchangeOrder.ClientOrderID != "" {
+		return
+	}
+	exchangeOrder := types.ExchangeOrder{}
+	if order.ExchangeOrder != nil {
+		exchangeOrder = *order.ExchangeOrder
+	}
+	exchangeOrder.ClientOrderID = types.NewClientOrderID(strategyID, "s", orderSeq.Add(1))
+	order.ExchangeOrder = &exchangeOrder
+}
+
+// executionReport returns a tagged order as the exchange executed it, with
+// FilledAmount and FilledPrice set, and whether it is still working. A final
+// report may fill less than the order, or nothing at all; an order the
+// exchange does not know filled nothing. Exchanges that keep no history of
+// the order are taken to have filled it in full at its price.
+//
+// The order is queried by its client order id; exchanges that cannot look
+// one order up are searched through their recent fills instead.
+func executionReport(ctx context.Context, exchange types.ExchangeClient, order types.Order) (types.Order, bool, error) {
+	assumed := order
+	assumed.Status, assumed.FilledAmount, assumed.FilledPrice = types.OrderStatusFilled, order.Quantity, order.Price
+
+	report, err := exchange.GetOrderByClientID(ctx, order.Symbol, order.ExchangeOrder.ClientOrderID)
+	switch {
+	case errors.Is(err, types.ErrNotSupported):
+		return reportFromHistory(ctx, exchange, order, assumed)
+	case errors.Is(err, types.ErrOrderNotFound):
+		unfilled := order
+		unfilled.Status, unfilled.FilledAmount, unfilled.FilledPrice = types.OrderStatusRejected, 0, 0
+		return unfilled, false, nil
+	case err != nil:
+		return order, true, fmt.Errorf("failed to get order: %w", err)
+	}
+	switch report.Status {
+	case types.OrderStatusNew:
+		return order, true, nil
+	case types.OrderStatusPartiallyFilled:
+		// Some exchanges leave a final partial fill in this state; it is
+		// still working only while open
+		active, err := exchange.GetActiveOrders(ctx, order.Symbol)
+		if err != nil && !errors.Is(err, types.ErrNotSupported) {
+			return order, true, fmt.Errorf("failed to get open orders: %w", err)
+		}
+		if _, open := findOrder(active, order.ExchangeOrder.ClientOrderID); open {
+			return order, true, nil
+		}
+	}
+	return withFill(order, *report), false, nil
+}
+
+// reportFromHistory is executionReport for exchanges that cannot look an
+// order up by client order id
+func reportFromHistory(ctx context.Context, exchange types.ExchangeClient, order, assumed types.Order) (types.Order, bool, error) {
+	filled, err := exchange.GetFilledOrders(ctx, order.Symbol)
+	if errors.Is(err, types.ErrNotSupported) {
+		return assumed, false, nil
+	}
+	if err != nil {
+		return order, true, fmt.Errorf("failed to get order history: %w", err)
+	}
+	report, found := findOrder(filled, order.ExchangeOrder.ClientOrderID)
+	if found && report.Status == types.OrderStatusFilled {
+		return withFill(order, report), false, nil
+	}
+
+	// A partial fill is final unless the order is still open
+	active, err := exchange.GetActiveOrders(ctx, order.Symbol)
+	if err != nil && !errors.Is(err, types.ErrNotSupported) {
+		return order, true, fmt.Errorf("failed to get open orders: %w", err)
+	}
+	if _, open := findOrder(active, order.ExchangeOrder.ClientOrderID); open {
+		return order, true, nil
+	}
+	if found {
+		return withFill(order, report), false, nil
+	}
+	return assumed, false, nil
+}
+
+// withFill copies the exchange's fill onto the order as placed, which keeps
+// the Decision exchanges do not report back
+func withFill(order, report types.Order) types.Order {
+	order.ID, order.Status = report.ID, report.Status
+	order.FilledAmount, order.FilledPrice = report.FilledAmount, report.FilledPrice
+	if order.FilledPrice <= 0 {
+		order.FilledPrice = order.Price
+	}
+	return order
+}
+
+// findOrder returns the order with clientOrderID, searching newest first
+func findOrder(orders []types.Order, clientOrderID string) (types.Order, bool) {
+	for i := len(orders) - 1; i >= 0; i-- {
+		if o := orders[i]; o.ExchangeOrder != nil && o.ExchangeOrder.ClientOrderID == clientOrderID {
+			return o, true
+		}
+	}
+	return types.Order{}, false
+}
@@ -0,0 +1,35 @@
+package strategy
+
+import (
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// SignalFilter can veto or resize order signals before a strategy places them
+type SignalFilter interface {
+	// Observe records market data on every Execute call
+	Observe(market types.MarketData)
+	// Filter returns the possibly adjusted signal, and false to veto it
+	Filter(signal types.Signal, market types.MarketData) (types.Signal, bool, error)
+}
+
+// applyFilter runs filter on signal; filter errors veto the order (fail closed)
+func applyFilter(filter SignalFilter, log *logger.Logger, signal types.Signal, market types.MarketData) (types.Signal, bool) {
+	if filter == nil {
+		return signal, true
+	}
+
+	filtered, ok, err := filter.Filter(signal, market)
+	if err != nil {
+		log.Error("Signal filter error, skipping %s %s: %v", signal.Type, signal.Symbol, err)
+		return signal, false
+	}
+	if !ok {
+		log.Info("Signal filter vetoed %s %s %.8f @ %.2f", signal.Type, signal.Symbol, signal.Quantity, signal.Price)
+		return signal, false
+	}
+	if filtered.Quantity != signal.Quantity {
+		log.Info("Signal filter resized %s %s %.8f -> %.8f", signal.Type, signal.Symbol, signal.Quantity, filtered.Quantity)
+	}
+	return filtered, true
+}
@@ -0,0 +1,90 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestDCAStrategy_FilterVetoesWeekendBuys(t *testing.T) {
+	config := types.DCAConfig{
+		Symbol:           "BTCUSDT",
+		InvestmentAmount: 100.0,
+		Interval:         time.Hour,
+		MaxInvestments:   10,
+		Enabled:          true,
+		Filter: &types.SignalFilterConfig{Script: `
+def filter(signal, market):
+    return market.weekday not in ("Saturday", "Sunday")
+`},
+	}
+
+	exchange := &MockExchangeClient{}
+	strategy, err := NewFactory(logger.New(logger.LevelError)).CreateDCA(config, exchange)
+	if err != nil {
+		t.Fatalf("CreateDCA() error = %v", err)
+	}
+
+	ctx := context.Background()
+	saturday := time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC)
+	if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 45000, Timestamp: saturday}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(exchange.orders) != 0 {
+		t.Fatalf("Expected weekend buy to be vetoed, got %d orders", len(exchange.orders))
+	}
+
+	// The vetoed buy did not consume the interval
+	monday := saturday.Add(48 * time.Hour)
+	if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: 45000, Timestamp: monday}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(exchange.orders) != 1 {
+		t.Fatalf("Expected 1 weekday buy, got %d orders", len(exchange.orders))
+	}
+}
+
+func TestGridStrategy_FilterResizesSells(t *testing.T) {
+	config := types.GridConfig{
+		Symbol:             "BTCUSDT",
+		LowerPrice:         100,
+		UpperPrice:         110,
+		GridLevels:         3, // 100, 105, 110
+		InvestmentPerLevel: 100,
+		Enabled:            true,
+		Filter: &types.SignalFilterConfig{Script: `
+def filter(signal, market):
+    if signal.side == "SELL":
+        return 0.5
+    return True
+`},
+	}
+
+	exchange := &MockExchangeClient{}
+	strategy, err := NewFactory(logger.New(logger.LevelError)).CreateGrid(config, exchange)
+	if err != nil {
+		t.Fatalf("CreateGrid() error = %v", err)
+	}
+
+	ctx := context.Background()
+	// 104 buys the 105 and 110 levels; each 110 tick sells half of the 105 position
+	for _, price := range []float64{104, 110, 110} {
+		if err := strategy.Execute(ctx, types.MarketData{Symbol: "BTCUSDT", Price: price, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Execute(%v) error = %v", price, err)
+		}
+	}
+
+	if len(exchange.orders) != 4 {
+		t.Fatalf("Expected two buys and two half sells, got %d orders", len(exchange.orders))
+	}
+	bought := exchange.orders[0].Quantity
+	if got := exchange.orders[2].Quantity; got != bought/2 {
+		t.Errorf("Expected first sell of %v, got %v", bought/2, got)
+	}
+	if got := exchange.orders[3].Quantity; got != bought/4 {
+		t.Errorf("Expected second sell to halve the remainder (%v), got %v", bought/4, got)
+	}
+}
@@ -2,24 +2,41 @@ package strategy
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
 
 // GridStrategy is a simple grid trading implementation with evenly spaced levels
 type GridStrategy struct {
+	Identity
 	config   types.GridConfig
 	exchange types.ExchangeClient
 	logger   *logger.Logger
+	filter   SignalFilter
+	throttle *risk.Throttle
 
 	mu        sync.RWMutex
 	levels    []float64                // sorted levels (low -> high)
 	positions map[float64]gridPosition // position size per level
+	rules     symbolRules
+	skips     skipLog // buys turned down by the combo's arbitration
+	trace     *Tracer
+
+	// Level positions written off as too small to sell; held, but not traded
+	dustQuantity float64
+	dustCost     float64
+
+	stats map[float64]*types.GridLevelStats // per level, once it traded
+
+	venues *venueRouter // the exchange of a multi-venue grid, nil otherwise
 
 	metrics types.StrategyMetrics
 }
@@ -27,6 +44,9 @@ type GridStrategy struct {
 type gridPosition struct {
 	quantity float64
 	avgPrice float64
+	exit     *ExitManager // nil without an exit config
+	stopped  bool         // stopped out; no rebuy until price trades above the level
+	pending  *types.Order // placed for the level, fill not reported yet
 }
 
 func NewGridStrategy(config types.GridConfig, exchange types.ExchangeClient, logger *logger.Logger) (*GridStrategy, error) {
@@ -34,10 +54,13 @@ func NewGridStrategy(config types.GridConfig, exchange types.ExchangeClient, log
 		return nil, fmt.Errorf("grid levels must be >= 2")
 	}
 	gs := &GridStrategy{
+		Identity:  NewIdentity("grid", config.ID, config.Name),
 		config:    config,
 		exchange:  exchange,
 		logger:    logger,
 		positions: make(map[float64]gridPosition),
+		stats:     make(map[float64]*types.GridLevelStats),
+		trace:     NewTracer("grid"),
 	}
 	gs.buildLevels()
 	return gs, nil
@@ -66,57 +89,288 @@ func (g *GridStrategy) ValidateConfig() error {
 	if g.config.InvestmentPerLevel <= 0 {
 		return fmt.Errorf("investment per level must be positive")
 	}
+	if g.config.Exit != nil {
+		if err := ValidateExit(*g.config.Exit); err != nil {
+			return err
+		}
+	}
+	if g.config.Throttle != nil {
+		if err := risk.ValidateThrottle(*g.config.Throttle); err != nil {
+			return err
+		}
+	}
+	if g.config.SeedMaxQuantity < 0 {
+		return fmt.Errorf("seed max quantity must not be negative")
+	}
+	if g.config.Venues != nil {
+		if err := validateVenues(*g.config.Venues); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (g *GridStrategy) Execute(ctx context.Context, market types.MarketData) error {
+func (g *GridStrategy) Execute(ctx context.Context, market types.MarketData) (err error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	g.trace.begin(market)
+	defer func() { g.trace.end(RuleNoLevelCrossed, err) }()
 	if !g.config.Enabled {
+		g.trace.blocked(RuleDisabled, "strategy is disabled")
 		return nil
 	}
+	if g.filter != nil {
+		g.filter.Observe(market)
+	}
+	g.settle(ctx)
+
+	rules, err := g.rules.get(ctx, g.exchange, g.config.Symbol)
+	if err != nil {
+		g.logger.Warn("Grid trading without order size rules: %v", err)
+	}
 
 	price := market.Price
 	// BUY when price crosses down to or below a level with empty position
 	for i, level := range g.levels {
 		pos := g.positions[level]
-		if price <= level && pos.quantity == 0 {
-			qty := g.config.InvestmentPerLevel / price
-			order := types.Order{Symbol: g.config.Symbol, Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: qty, Price: price, Status: types.OrderStatusNew, Timestamp: time.Now()}
-			if err := g.exchange.PlaceOrder(ctx, order); err != nil {
+		if pos.pending != nil {
+			// The level trades again once its order reports the fill
+			g.trace.blockedAt(level, RulePending, "%s %.8f not reported filled", pos.pending.Side, pos.pending.Quantity)
+			continue
+		}
+		if pos.stopped && price > level {
+			pos.stopped = false
+			g.positions[level] = pos
+		}
+		// A level too small to sell would block its sells; it is set aside
+		// and the level trades afresh
+		if rules.IsDust(pos.quantity, price) {
+			g.writeOffDust(level, pos)
+			pos = g.positions[level]
+		}
+		if price <= level && pos.quantity == 0 && !pos.stopped {
+			signal, ok := applyFilter(g.filter, g.logger, types.Signal{Type: types.SignalTypeBuy, Symbol: g.config.Symbol, Price: price, Quantity: g.config.InvestmentPerLevel / price, Timestamp: market.Timestamp}, market)
+			if !ok {
+				g.trace.blockedAt(level, RuleFilterVeto, "buy vetoed")
+				continue
+			}
+			if !rules.Tradable(signal.Quantity, price) {
+				g.logger.Warn("Grid BUY @ level %.2f skipped: %.8f is below the exchange minimum", level, signal.Quantity)
+				g.trace.blockedAt(level, RuleBelowMinimum, "buy %.8f", signal.Quantity)
+				continue
+			}
+			order := types.Order{Symbol: g.config.Symbol, Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: signal.Quantity, Price: price, Status: types.OrderStatusNew, Timestamp: time.Now(),
+				Decision: &types.Decision{Strategy: "grid", StrategyID: g.ID(), Action: types.DecisionBuy, Level: level}}
+			if err := g.place(ctx, order); err != nil {
+				if errors.Is(err, ErrArbitrationBudget) {
+					g.skips.skipped(g.logger.Info, "Grid buys skipped until the arbitration budget fits them: %v", err)
+					continue
+				}
+				if errors.Is(err, risk.ErrThrottled) || errors.Is(err, types.ErrBelowMinimum) || errors.Is(err, ErrNoVenue) {
+					g.logger.Info("Grid BUY @ level %.2f skipped: %v", level, err)
+					continue
+				}
 				return fmt.Errorf("grid buy failed: %w", err)
 			}
-			g.positions[level] = gridPosition{quantity: qty, avgPrice: price}
-			g.metrics.TotalTrades++
-			g.metrics.TotalVolume += qty * price
-			g.logger.Info("Grid BUY @ level %.2f qty=%.8f price=%.2f", level, qty, price)
+			g.skips.resumed(g.logger.Info, "Grid buys fit the arbitration budget again")
+		}
+
+		// Ladder exits and stops run before the next-level sell and bypass the filter
+		if pos.exit != nil && pos.quantity > 0 {
+			sold, err := g.executeExit(ctx, level, pos, price, rules)
+			if err != nil {
+				return err
+			}
+			if sold {
+				continue
+			}
 		}
 
 		// SELL when price reaches next higher level and we have a position at current level
 		if pos.quantity > 0 && i+1 < len(g.levels) {
 			nextLevel := g.levels[i+1]
 			if price >= nextLevel {
-				qty := pos.quantity
-				order := types.Order{Symbol: g.config.Symbol, Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: qty, Price: price, Status: types.OrderStatusNew, Timestamp: time.Now()}
-				if err := g.exchange.PlaceOrder(ctx, order); err != nil {
-					return fmt.Errorf("grid sell failed: %w", err)
+				signal, ok := applyFilter(g.filter, g.logger, types.Signal{Type: types.SignalTypeSell, Symbol: g.config.Symbol, Price: price, Quantity: pos.quantity, Timestamp: market.Timestamp}, market)
+				if !ok {
+					g.trace.blockedAt(level, RuleFilterVeto, "sell vetoed")
+					continue
+				}
+				// A filter may shrink the sell; the remainder stays at this level
+				// unless it is too small to sell later
+				quantity := sellQuantity(rules, signal.Quantity, pos.quantity, price)
+				if quantity <= 0 {
+					g.logger.Debug("Grid SELL from level %.2f skipped: %.8f is below the exchange minimum", level, signal.Quantity)
+					g.trace.blockedAt(level, RuleBelowMinimum, "sell %.8f", signal.Quantity)
+					continue
 				}
-				realized := (price - pos.avgPrice) * qty
-				g.metrics.TotalTrades++
-				g.metrics.TotalVolume += qty * price
-				if realized >= 0 {
-					g.metrics.WinningTrades++
-					g.metrics.TotalProfit += realized
-				} else {
-					g.metrics.LosingTrades++
-					g.metrics.TotalLoss += -realized
+				order := types.Order{Symbol: g.config.Symbol, Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: quantity, Price: price, Status: types.OrderStatusNew, Timestamp: time.Now(),
+					Decision: &types.Decision{Strategy: "grid", StrategyID: g.ID(), Action: types.DecisionSell, Level: level}}
+				if err := g.place(ctx, order); err != nil {
+					if errors.Is(err, types.ErrBelowMinimum) {
+						g.writeOffDust(level, pos)
+						continue
+					}
+					if errors.Is(err, ErrNoVenue) {
+						g.logger.Info("Grid SELL from level %.2f skipped: %v", level, err)
+						continue
+					}
+					return fmt.Errorf("grid sell failed: %w", err)
 				}
-				g.positions[level] = gridPosition{}
-				g.logger.Info("Grid SELL from level %.2f qty=%.8f price=%.2f pnl=%.2f", level, qty, price, realized)
 			}
 		}
 	}
 
+	g.updateRates()
+	return nil
+}
+
+// place sends a level's order and books what the exchange reports as filled.
+// An order still working holds its level until settle books it.
+func (g *GridStrategy) place(ctx context.Context, order types.Order) error {
+	tagOrder(&order, g.ID())
+	if err := g.exchange.PlaceOrder(ctx, order); err != nil {
+		g.trace.orderError(err, order.Decision.Level)
+		return err
+	}
+	g.trace.triggered(string(order.Decision.Action), order, order.Decision.Level)
+
+	report, working, err := executionReport(ctx, g.exchange, order)
+	if err != nil {
+		g.logger.Warn("Grid fill at level %.2f not reported yet: %v", order.Decision.Level, err)
+	}
+	if working {
+		pos := g.positions[order.Decision.Level]
+		pos.pending = &order
+		g.positions[order.Decision.Level] = pos
+		return nil
+	}
+	g.book(report)
+	return nil
+}
+
+// settle books the fills of orders that were still working on earlier ticks
+func (g *GridStrategy) settle(ctx context.Context) {
+	for _, level := range g.levels {
+		pos := g.positions[level]
+		if pos.pending == nil {
+			continue
+		}
+		report, working, err := executionReport(ctx, g.exchange, *pos.pending)
+		if err != nil {
+			g.logger.Warn("Grid fill at level %.2f not reported yet: %v", level, err)
+		}
+		if working {
+			continue
+		}
+		pos.pending = nil
+		g.positions[level] = pos
+		g.book(report)
+	}
+}
+
+// book applies a final execution report to its level
+func (g *GridStrategy) book(report types.Order) {
+	level := report.Decision.Level
+	if report.FilledAmount <= dust {
+		g.logger.Warn("Grid %s @ level %.2f did not fill (%s)", report.Side, level, report.Status)
+		return
+	}
+	qty, price, realized := g.applyFill(report)
+	switch report.Decision.Action {
+	case types.DecisionBuy:
+		g.logger.Info("Grid BUY @ level %.2f qty=%.8f price=%.2f", level, qty, price)
+	case types.DecisionSell:
+		g.logger.Info("Grid SELL from level %.2f qty=%.8f price=%.2f pnl=%.2f", level, qty, price, realized)
+	default:
+		g.logger.Info("Grid %s exit from level %.2f qty=%.8f price=%.2f pnl=%.2f", report.Decision.Exit, level, qty, price, realized)
+	}
+	if qty < report.Quantity-dust {
+		g.logger.Warn("Grid %s @ level %.2f filled %.8f of %.8f", report.Side, level, qty, report.Quantity)
+	}
+}
+
+// applyFill books a fill into its level's position and the metrics and
+// returns the quantity, price and realized PnL booked
+func (g *GridStrategy) applyFill(fill types.Order) (qty, price, realized float64) {
+	decision := fill.Decision
+	level := decision.Level
+	qty, price = filledAt(fill)
+	if qty <= dust {
+		return 0, price, 0
+	}
+	pos := g.positions[level]
+
+	switch decision.Action {
+	case types.DecisionBuy:
+		stats := g.levelStats(level)
+		stats.Buys++
+		stats.Volume += qty * price
+		pos.avgPrice = (pos.avgPrice*pos.quantity + price*qty) / (pos.quantity + qty)
+		pos.quantity += qty
+		pos.stopped = false
+		if g.config.Exit != nil {
+			if pos.exit == nil {
+				pos.exit = NewExitManager(*g.config.Exit)
+			}
+			pos.exit.OnBuy(qty, price)
+		}
+		g.metrics.TotalTrades++
+		g.metrics.TotalVolume += qty * price
+	case types.DecisionSell, types.DecisionExit:
+		qty = math.Min(qty, pos.quantity)
+		if qty <= dust {
+			return 0, price, 0
+		}
+		realized = (price - pos.avgPrice) * qty
+		recordRealized(&g.metrics, realized)
+		stats := g.levelStats(level)
+		stats.Sells++
+		stats.Volume += qty * price
+		stats.Profit += realized
+		g.metrics.TotalTrades++
+		g.metrics.TotalVolume += qty * price
+		if pos.exit != nil {
+			// A partially filled exit leaves its target due
+			if decision.Action == types.DecisionExit && qty >= fill.Quantity-dust {
+				pos.exit.Filled(Exit{Quantity: qty, Reason: decision.Exit, Target: decision.Target})
+			} else {
+				pos.exit.OnSell(qty)
+			}
+		}
+		pos.quantity -= qty
+		if pos.quantity <= dust {
+			stats.RoundTrips++
+			stopped := decision.Action == types.DecisionExit && decision.Exit != ExitTakeProfit
+			pos = gridPosition{stopped: stopped}
+		}
+	}
+	g.positions[level] = pos
+	return qty, price, realized
+}
+
+// levelStats returns the statistics of level, creating them on its first fill
+func (g *GridStrategy) levelStats(level float64) *types.GridLevelStats {
+	stats, ok := g.stats[level]
+	if !ok {
+		stats = &types.GridLevelStats{Level: level}
+		g.stats[level] = stats
+	}
+	return stats
+}
+
+// levelStatsList returns the statistics of the levels that traded, lowest first
+func (g *GridStrategy) levelStatsList() []types.GridLevelStats {
+	var list []types.GridLevelStats
+	for _, level := range g.levels {
+		if stats, ok := g.stats[level]; ok {
+			list = append(list, *stats)
+		}
+	}
+	return list
+}
+
+// updateRates refreshes win rate and profit factor
+func (g *GridStrategy) updateRates() {
 	g.metrics.LastUpdate = time.Now()
 	if g.metrics.TotalTrades > 0 {
 		totalWins := float64(g.metrics.WinningTrades)
@@ -126,9 +380,224 @@ func (g *GridStrategy) Execute(ctx context.Context, market types.MarketData) err
 			g.metrics.ProfitFactor = g.metrics.TotalProfit / g.metrics.TotalLoss
 		}
 	}
+}
+
+// Recover rebuilds level positions, exit ladders and metrics from fills
+// placed before a restart. Fills for levels no longer in the grid are skipped.
+func (g *GridStrategy) Recover(fills []types.Order) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, fill := range fills {
+		decision := fill.Decision
+		if fill.Symbol != g.config.Symbol || decision == nil || decision.Strategy != "grid" {
+			continue
+		}
+		level := decision.Level
+		if i := sort.SearchFloat64s(g.levels, level); i == len(g.levels) || g.levels[i] != level {
+			g.logger.Warn("Grid recovery: skipping fill for unknown level %.2f", level)
+			continue
+		}
+		g.applyFill(fill)
+	}
+
+	g.updateRates()
+	g.logger.Info("Grid state recovered: %d levels held", g.heldLevels())
+	return nil
+}
+
+// Seed maps base asset already held onto the grid when SeedFromHoldings is
+// set. Holdings the recovered levels do not account for fill the empty levels
+// at or above the price, nearest first, each with what a buy there would get;
+// they are booked at the current price. Seeded levels are not journaled, so
+// after a restart the inventory they still hold is seeded again.
+func (g *GridStrategy) Seed(ctx context.Context) error {
+	if !g.config.SeedFromHoldings {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	base, _, ok := types.SplitSymbol(g.config.Symbol)
+	if !ok {
+		return fmt.Errorf("cannot tell the base asset of %s", g.config.Symbol)
+	}
+	balances, err := g.exchange.GetBalances(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get balances: %w", err)
+	}
+	var available float64
+	for _, balance := range balances {
+		if balance.Asset == base {
+			available = balance.Free
+		}
+	}
+	available -= g.dustQuantity
+	for _, pos := range g.positions {
+		available -= pos.quantity
+	}
+	if g.config.SeedMaxQuantity > 0 {
+		available = math.Min(available, g.config.SeedMaxQuantity)
+	}
+	if available <= dust {
+		g.logger.Info("Grid seeding: no %s held beyond the grid's levels", base)
+		return nil
+	}
+
+	ticker, err := g.exchange.GetTicker(ctx, g.config.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get price: %w", err)
+	}
+	price := ticker.Price
+	if price <= 0 {
+		return fmt.Errorf("no price for %s", g.config.Symbol)
+	}
+
+	var seeded int
+	var quantity float64
+	for _, level := range g.levels {
+		pos := g.positions[level]
+		if level < price || pos.quantity > 0 || pos.pending != nil {
+			continue
+		}
+		qty := math.Min(g.config.InvestmentPerLevel/price, available)
+		if qty <= dust {
+			break
+		}
+		pos = gridPosition{quantity: qty, avgPrice: price}
+		if g.config.Exit != nil {
+			pos.exit = NewExitManager(*g.config.Exit)
+			pos.exit.OnBuy(qty, price)
+		}
+		g.positions[level] = pos
+		available -= qty
+		quantity += qty
+		seeded++
+	}
+	g.logger.Info("Grid seeded %d level(s) with %.8f %s held, booked at %.2f", seeded, quantity, base, price)
 	return nil
 }
 
+// executeExit sells the part of a level's position due for exit, reporting
+// whether it sold. Exits too small to order wait for the level's next sell.
+func (g *GridStrategy) executeExit(ctx context.Context, level float64, pos gridPosition, price float64, rules types.SymbolRules) (bool, error) {
+	exit, ok := pos.exit.Check(price)
+	if !ok {
+		return false, nil
+	}
+	quantity := sellQuantity(rules, exit.Quantity, pos.quantity, price)
+	if quantity <= 0 {
+		return false, nil
+	}
+
+	order := types.Order{Symbol: g.config.Symbol, Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: quantity, Price: price, Status: types.OrderStatusNew, Timestamp: time.Now(),
+		Decision: &types.Decision{Strategy: "grid", StrategyID: g.ID(), Action: types.DecisionExit, Level: level, Exit: exit.Reason, Target: exit.Target}}
+	if err := g.place(ctx, order); err != nil {
+		if errors.Is(err, types.ErrBelowMinimum) {
+			g.writeOffDust(level, pos)
+			return true, nil
+		}
+		return false, fmt.Errorf("grid exit failed: %w", err)
+	}
+	return true, nil
+}
+
+// writeOffDust sets aside a level's position that is too small to sell. The
+// coins stay in the account, e.g. for dust conversion, but leave the grid's
+// inventory so the level can buy again.
+func (g *GridStrategy) writeOffDust(level float64, pos gridPosition) {
+	g.dustQuantity += pos.quantity
+	g.dustCost += pos.quantity * pos.avgPrice
+	g.positions[level] = gridPosition{stopped: pos.stopped}
+	g.logger.Info("Grid level %.2f: %.8f %s is below the exchange minimum, set aside as dust", level, pos.quantity, g.config.Symbol)
+}
+
+// SetSignalFilter installs a filter consulted before each grid order
+func (g *GridStrategy) SetSignalFilter(filter SignalFilter) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.filter = filter
+}
+
+// SetThrottle routes orders through throttle and reports it in status
+func (g *GridStrategy) SetThrottle(throttle *risk.Throttle) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.throttle = throttle
+	g.exchange = throttle.Client(g.exchange)
+}
+
+// Throttle returns the installed throttle, or nil
+func (g *GridStrategy) Throttle() *risk.Throttle {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.throttle
+}
+
+// GetStatus returns the grid's levels and the positions they hold
+func (g *GridStrategy) GetStatus() types.StrategyStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	status := types.StrategyStatus{
+		ID:      g.ID(),
+		Name:    g.Name(),
+		Type:    "grid",
+		Symbol:  g.config.Symbol,
+		Enabled: g.config.Enabled,
+		Grid: &types.GridStatus{
+			Levels:        len(g.levels),
+			Prices:        append([]float64(nil), g.levels...),
+			LevelsHeld:    g.heldLevels(),
+			OpenLevels:    g.openLevels(),
+			PendingOrders: g.pendingOrders(),
+			DustQuantity:  g.dustQuantity,
+			DustCost:      g.dustCost,
+			LevelStats:    g.levelStatsList(),
+		},
+	}
+	if g.venues != nil {
+		status.Grid.Venues, status.Grid.Transfers = g.venues.status()
+	}
+	if g.throttle != nil {
+		status.Throttle = g.throttle.Status()
+	}
+	return status
+}
+
+// openLevels lists the levels holding a position, lowest first
+func (g *GridStrategy) openLevels() []types.GridLevel {
+	open := make([]types.GridLevel, 0)
+	for _, level := range g.levels {
+		if pos := g.positions[level]; pos.quantity > 0 {
+			open = append(open, types.GridLevel{Level: level, Quantity: pos.quantity, AvgPrice: pos.avgPrice})
+		}
+	}
+	return open
+}
+
+// heldLevels counts levels with an open position
+func (g *GridStrategy) heldLevels() int {
+	held := 0
+	for _, pos := range g.positions {
+		if pos.quantity > 0 {
+			held++
+		}
+	}
+	return held
+}
+
+// pendingOrders counts levels waiting for an order's fill to be reported
+func (g *GridStrategy) pendingOrders() int {
+	pending := 0
+	for _, pos := range g.positions {
+		if pos.pending != nil {
+			pending++
+		}
+	}
+	return pending
+}
+
 func (g *GridStrategy) GetSignal(market types.MarketData) types.Signal {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -146,3 +615,18 @@ func (g *GridStrategy) Shutdown(ctx context.Context) error {
 	g.logger.Info("Grid strategy stopped")
 	return nil
 }
+
+// RequiredHistory returns nil: the grid trades its levels from the first price
+func (g *GridStrategy) RequiredHistory() []HistoryRequirement {
+	return nil
+}
+
+// Markets returns the grid's symbol and price range
+func (g *GridStrategy) Markets() []Market {
+	return []Market{{Symbol: g.config.Symbol, Exchange: g.exchange, Lower: g.config.LowerPrice, Upper: g.config.UpperPrice}}
+}
+
+// Trace returns the strategy's recent decisions, oldest first
+func (g *GridStrategy) Trace() []TraceEntry {
+	return g.trace.Entries()
+}
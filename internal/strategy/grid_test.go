@@ -2,9 +2,12 @@ package strategy
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/sim"
 	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
 	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
 )
@@ -165,6 +168,240 @@ func TestGridStrategy_Execute(t *testing.T) {
 	}
 }
 
+func TestGridStrategy_PartialFills(t *testing.T) {
+	config := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: 100, UpperPrice: 120, GridLevels: 3, InvestmentPerLevel: 108, Enabled: true}
+	level := func(g *GridStrategy, price float64) *types.GridLevel {
+		for _, l := range g.GetStatus().Grid.OpenLevels {
+			if l.Level == price {
+				return &l
+			}
+		}
+		return nil
+	}
+	ctx := context.Background()
+
+	// Half of every order fills; levels book what filled, not what was asked
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{125, 108, 121}, FillRatio: 0.5, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, err := NewGridStrategy(config, ex, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := func() {
+		t.Helper()
+		market, _ := ex.Step()
+		if err := grid.Execute(ctx, market); err != nil {
+			t.Fatal(err)
+		}
+	}
+	step()
+	if l := level(grid, 110); l == nil || math.Abs(l.Quantity-0.5) > 1e-9 || l.AvgPrice != 108.0 {
+		t.Fatalf("level 110 after a half-filled buy = %v, want 0.5 @ 108", l)
+	}
+
+	// The half-filled sell leaves a quarter at the level
+	step()
+	if l := level(grid, 110); l == nil || math.Abs(l.Quantity-0.25) > 1e-9 {
+		t.Fatalf("level 110 after a half-filled sell = %v, want 0.25 left", l)
+	}
+	if m := grid.GetMetrics(); math.Abs(m.TotalProfit-0.25*13) > 1e-9 {
+		t.Errorf("profit = %v, want %v on the filled quarter", m.TotalProfit, 0.25*13)
+	}
+
+	// A delayed fill holds its level until the exchange reports it
+	ex, err = sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{108, 108}, FillDelay: 1, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, _ = NewGridStrategy(config, ex, logger.New(logger.LevelError))
+	if err := grid.Execute(ctx, ex.Market()); err != nil {
+		t.Fatal(err)
+	}
+	if status := grid.GetStatus().Grid; status.PendingOrders != 2 || status.LevelsHeld != 0 {
+		t.Fatalf("status before the fill = %v, want 2 pending orders", status)
+	}
+	step()
+	if status := grid.GetStatus().Grid; status.PendingOrders != 0 || status.LevelsHeld != 2 || len(ex.Orders()) != 2 {
+		t.Fatalf("status after the fill = %v with %d orders, want 2 levels held", status, len(ex.Orders()))
+	}
+}
+
+func TestGridStrategy_Dust(t *testing.T) {
+	config := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: 100, UpperPrice: 120, GridLevels: 3, InvestmentPerLevel: 108, Enabled: true}
+	rules := &types.SymbolRules{StepSize: 0.01, MinQty: 0.01, MinNotional: 10}
+	ctx := context.Background()
+
+	// 95% of every order fills, rounded down to the step size
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{125, 108, 121, 108}, FillRatio: 0.95, Rules: rules, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, err := NewGridStrategy(config, ex, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := grid.Execute(ctx, ex.Market()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		market, _ := ex.Step()
+		if err := grid.Execute(ctx, market); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The 0.95 bought at 110 sold 0.9, leaving 0.05 worth 6.05 under the 10 minimum
+	if status := grid.GetStatus().Grid; status.LevelsHeld != 2 || status.DustQuantity != 0 {
+		t.Fatalf("status after the partial sell = %v", status)
+	}
+
+	// The leftover is set aside instead of blocking the level, which buys again
+	market, _ := ex.Step()
+	if err := grid.Execute(ctx, market); err != nil {
+		t.Fatal(err)
+	}
+	status := grid.GetStatus().Grid
+	if math.Abs(status.DustQuantity-0.05) > 1e-9 {
+		t.Fatalf("dust_quantity = %v, want 0.05", status.DustQuantity)
+	}
+	for _, l := range status.OpenLevels {
+		if l.Level == 110.0 && math.Abs(l.Quantity-0.95) > 1e-9 {
+			t.Errorf("level 110 = %v, want a fresh 0.95", l)
+		}
+	}
+	if n := len(ex.Orders()); n != 4 {
+		t.Errorf("orders = %d, want 2 buys, 1 sell and the rebuy", n)
+	}
+
+	// Buys under the minimum are skipped rather than failing every tick
+	config.InvestmentPerLevel = 5
+	ex, _ = sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{108}, Rules: rules, QuoteBalance: 1000})
+	grid, _ = NewGridStrategy(config, ex, logger.New(logger.LevelError))
+	if err := grid.Execute(ctx, ex.Market()); err != nil {
+		t.Fatalf("Execute below the minimum: %v", err)
+	}
+	if n := len(ex.Orders()); n != 0 {
+		t.Errorf("orders = %d, want none below the minimum", n)
+	}
+}
+
+// lostOrders is a sim exchange that reports no order it was sent
+type lostOrders struct {
+	*sim.Exchange
+}
+
+func (l lostOrders) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	return nil, fmt.Errorf("%w: client id %s", types.ErrOrderNotFound, clientOrderID)
+}
+
+func TestGridStrategy_OrderNotFound(t *testing.T) {
+	config := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: 100, UpperPrice: 120, GridLevels: 3, InvestmentPerLevel: 100, Enabled: true}
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{108}, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, err := NewGridStrategy(config, lostOrders{ex}, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := grid.Execute(context.Background(), ex.Market()); err != nil {
+		t.Fatal(err)
+	}
+
+	// An order the exchange does not know is not booked as filled
+	if status := grid.GetStatus().Grid; status.LevelsHeld != 0 {
+		t.Fatalf("levels_held = %v, want none for orders the exchange does not know", status.LevelsHeld)
+	}
+}
+
+func TestGridStrategy_Seed(t *testing.T) {
+	config := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: 100, UpperPrice: 120, GridLevels: 3, InvestmentPerLevel: 108, Enabled: true, SeedFromHoldings: true}
+	ctx := context.Background()
+	held := func(g *GridStrategy) map[float64]float64 {
+		out := make(map[float64]float64)
+		for _, l := range g.GetStatus().Grid.OpenLevels {
+			out[l.Level] = l.Quantity
+		}
+		return out
+	}
+
+	// 2.5 BTC held at 108 fill the levels the grid would buy: 110 and 120
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{108, 121}, BaseBalance: 2.5, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, _ := NewGridStrategy(config, ex, logger.New(logger.LevelError))
+	if err := grid.Seed(ctx); err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+	if got := held(grid); len(got) != 2 || got[110] != 1 || got[120] != 1 {
+		t.Fatalf("seeded levels = %v, want 1 at 110 and 120", got)
+	}
+	if err := grid.Execute(ctx, ex.Market()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ex.Orders()); n != 0 {
+		t.Fatalf("orders = %d, want no buys of seeded levels", n)
+	}
+	// Seeded levels sell like bought ones
+	market, _ := ex.Step()
+	if err := grid.Execute(ctx, market); err != nil {
+		t.Fatal(err)
+	}
+	if orders := ex.Orders(); len(orders) != 1 || orders[0].Side != types.OrderSideSell || orders[0].Decision.Level != 110 {
+		t.Fatalf("orders = %v, want the sell from level 110", orders)
+	}
+
+	// Recovered levels and the cap limit what is seeded
+	config.SeedMaxQuantity = 0.4
+	ex, _ = sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{108}, BaseBalance: 1.5, QuoteBalance: 1000})
+	grid, _ = NewGridStrategy(config, ex, logger.New(logger.LevelError))
+	fill := types.Order{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1, Price: 105, Status: types.OrderStatusFilled,
+		Decision: &types.Decision{Strategy: "grid", Action: types.DecisionBuy, Level: 110}}
+	if err := grid.Recover([]types.Order{fill}); err != nil {
+		t.Fatal(err)
+	}
+	if err := grid.Seed(ctx); err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+	if got := held(grid); got[110] != 1 || math.Abs(got[120]-0.4) > 1e-9 {
+		t.Errorf("levels = %v, want the recovered 1 at 110 and 0.4 seeded at 120", got)
+	}
+}
+
+func TestGridStrategy_LevelStats(t *testing.T) {
+	config := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: 100, UpperPrice: 120, GridLevels: 3, InvestmentPerLevel: 108, Enabled: true}
+	ctx := context.Background()
+
+	// Level 110 buys at 108 and sells at 121 twice; 120 buys once and holds
+	ex, err := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{108, 121, 108, 121}, QuoteBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, _ := NewGridStrategy(config, ex, logger.New(logger.LevelError))
+	if err := grid.Execute(ctx, ex.Market()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		market, _ := ex.Step()
+		if err := grid.Execute(ctx, market); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := grid.GetStatus().Grid.LevelStats
+	if len(stats) != 2 {
+		t.Fatalf("level stats = %+v, want levels 110 and 120", stats)
+	}
+	if s := stats[0]; s.Level != 110 || s.Buys != 2 || s.Sells != 2 || s.RoundTrips != 2 || math.Abs(s.Profit-26) > 1e-9 {
+		t.Errorf("level 110 = %+v, want 2 round trips earning 26", s)
+	}
+	if s := stats[1]; s.Level != 120 || s.Buys != 1 || s.Sells != 0 || s.RoundTrips != 0 || s.Profit != 0 {
+		t.Errorf("level 120 = %+v, want one buy held", s)
+	}
+}
+
 func TestGridStrategy_GetSignal(t *testing.T) {
 	config := types.GridConfig{
 		Symbol:             "BTCUSDT",
@@ -247,3 +484,87 @@ func TestGridStrategy_Shutdown(t *testing.T) {
 		t.Errorf("GridStrategy.Shutdown() error = %v", err)
 	}
 }
+
+func TestGridStrategy_Venues(t *testing.T) {
+	config := types.GridConfig{Symbol: "BTCUSDT", LowerPrice: 100, UpperPrice: 120, GridLevels: 3, InvestmentPerLevel: 108, Enabled: true,
+		Venues: &types.GridVenuesConfig{Accounts: []string{"a", "b"}, TransferFee: map[string]float64{"USDT": 1}, MinTransfer: map[string]float64{"USDT": 10}}}
+	ctx := context.Background()
+
+	// a asks less, b bids more; only a can pay for buys, only b holds base
+	a, _ := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{108, 121}, QuoteBalance: 1000})
+	b, _ := sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{109, 122}, BaseBalance: 1})
+	factory := NewFactory(logger.New(logger.LevelError))
+	factory.SetAccounts(map[string]types.ExchangeClient{"a": a, "b": b})
+	factory.SetVenueQuoter(func(venues map[string]types.ExchangeClient) VenueQuoter { return tickerQuoter(venues) })
+	created, err := factory.CreateGrid(config, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strat := created.(*GridStrategy)
+	if err := strat.Execute(ctx, a.Market()); err != nil {
+		t.Fatal(err)
+	}
+	b.Step()
+	market, _ := a.Step()
+	if err := strat.Execute(ctx, market); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(a.Orders()); got != 2 {
+		t.Errorf("orders on a = %d, want both buys", got)
+	}
+	if orders := b.Orders(); len(orders) != 1 || orders[0].Side != types.OrderSideSell {
+		t.Errorf("orders on b = %+v, want the sell at the better bid", orders)
+	}
+	status := strat.GetStatus().Grid
+	if len(status.Venues) != 2 || status.Venues[0].Buys != 2 || status.Venues[1].Sells != 1 {
+		t.Errorf("venues = %+v, want 2 buys on a and 1 sell on b", status.Venues)
+	}
+	if stats := status.LevelStats; len(stats) == 0 || stats[0].RoundTrips != 1 || math.Abs(stats[0].Profit-14) > 1e-9 {
+		t.Errorf("level stats = %+v, want a round trip bought at 108 and sold at 122", stats)
+	}
+
+	// Neither venue can pay for a buy: quote is suggested moved to the cheaper one
+	a, _ = sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{108}, QuoteBalance: 50})
+	b, _ = sim.NewExchange(sim.Script{Symbol: "BTCUSDT", Prices: []float64{109}, QuoteBalance: 100})
+	factory.SetAccounts(map[string]types.ExchangeClient{"a": a, "b": b})
+	created, err = factory.CreateGrid(config, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strat = created.(*GridStrategy)
+	if err := strat.Execute(ctx, a.Market()); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Orders())+len(b.Orders()) != 0 {
+		t.Error("buys placed without a venue able to pay for them")
+	}
+	want := types.VenueTransfer{Asset: "USDT", From: "b", To: "a", Quantity: 59, Fee: 1}
+	if transfers := strat.GetStatus().Grid.Transfers; len(transfers) != 1 || transfers[0] != want {
+		t.Errorf("transfers = %+v, want %+v", transfers, want)
+	}
+
+	config.Venues.Accounts = []string{"a", "c"}
+	if _, err := factory.CreateGrid(config, a); err == nil {
+		t.Error("grid over an unknown account was created")
+	}
+	config.Venues.Accounts = []string{"a", "b"}
+	if _, err := NewFactory(logger.New(logger.LevelError)).CreateGrid(config, a); err == nil {
+		t.Error("grid over venues was created without a quoter")
+	}
+}
+
+// tickerQuoter quotes each venue's ticker
+type tickerQuoter map[string]types.ExchangeClient
+
+func (q tickerQuoter) Quotes(ctx context.Context, symbol string) ([]types.VenueQuote, error) {
+	var quotes []types.VenueQuote
+	for _, name := range []string{"a", "b"} {
+		ticker, err := q[name].GetTicker(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, types.VenueQuote{Venue: name, Bid: ticker.Price, Ask: ticker.Price})
+	}
+	return quotes, nil
+}
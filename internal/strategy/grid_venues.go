@@ -0,0 +1,371 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ErrNoVenue is returned when no venue holds the inventory an order needs
+var ErrNoVenue = errors.New("no venue holds enough inventory")
+
+// VenueQuoter quotes a symbol on several venues at once, sorted by venue.
+// Venues failing to quote are left out; it errors only when none quotes.
+type VenueQuoter interface {
+	Quotes(ctx context.Context, symbol string) ([]types.VenueQuote, error)
+}
+
+// validateVenues checks a multi-venue grid's settings
+func validateVenues(config types.GridVenuesConfig) error {
+	if len(config.Accounts) < 2 {
+		return fmt.Errorf("venues need at least two accounts")
+	}
+	seen := make(map[string]bool, len(config.Accounts))
+	for _, account := range config.Accounts {
+		if account == "" {
+			return fmt.Errorf("venue account name is required")
+		}
+		if seen[account] {
+			return fmt.Errorf("venue account %s listed twice", account)
+		}
+		seen[account] = true
+	}
+	for asset, fee := range config.TransferFee {
+		if fee < 0 {
+			return fmt.Errorf("transfer fee of %s must not be negative", asset)
+		}
+	}
+	for asset, min := range config.MinTransfer {
+		if min < 0 {
+			return fmt.Errorf("min transfer of %s must not be negative", asset)
+		}
+	}
+	return nil
+}
+
+// venueRouter is the exchange client of a multi-venue grid. It sends each
+// order to the venue quoting the best price that holds the inventory to trade
+// it, and answers for the orders of all venues. Market data, order size rules
+// and fees come from the first venue.
+type venueRouter struct {
+	types.ExchangeClient
+	venues map[string]types.ExchangeClient
+	quoter VenueQuoter
+	config types.GridVenuesConfig
+	symbol string
+	base   string
+	quote  string
+	logger *logger.Logger
+
+	mu        sync.Mutex
+	inventory map[string]*types.GridVenue
+	transfers map[string]pendingTransfer // by asset and destination
+}
+
+// pendingTransfer is a suggested transfer and the balance it is meant to reach
+type pendingTransfer struct {
+	types.VenueTransfer
+	need float64
+}
+
+// newVenueRouter routes symbol's orders over the accounts config names,
+// quoted by the quoter newQuoter combines them into
+func newVenueRouter(symbol string, config types.GridVenuesConfig, accounts map[string]types.ExchangeClient, newQuoter func(map[string]types.ExchangeClient) VenueQuoter, logger *logger.Logger) (*venueRouter, error) {
+	base, quote, ok := types.SplitSymbol(symbol)
+	if !ok {
+		return nil, fmt.Errorf("cannot tell the assets of %s", symbol)
+	}
+	if newQuoter == nil {
+		return nil, fmt.Errorf("no venue quoter configured")
+	}
+	venues := make(map[string]types.ExchangeClient, len(config.Accounts))
+	for _, account := range config.Accounts {
+		client, ok := accounts[account]
+		if !ok {
+			return nil, fmt.Errorf("unknown venue account %q", account)
+		}
+		venues[account] = client
+	}
+	r := &venueRouter{
+		ExchangeClient: venues[config.Accounts[0]],
+		venues:         venues,
+		quoter:         newQuoter(venues),
+		config:         config,
+		symbol:         symbol,
+		base:           base,
+		quote:          quote,
+		logger:         logger,
+		inventory:      make(map[string]*types.GridVenue, len(config.Accounts)),
+		transfers:      make(map[string]pendingTransfer),
+	}
+	for _, name := range config.Accounts {
+		r.inventory[name] = &types.GridVenue{Venue: name}
+	}
+	return r, nil
+}
+
+// PlaceOrder routes a buy to the lowest ask among the venues able to pay for
+// it and a sell to the highest bid among the venues holding the quantity. It
+// returns ErrNoVenue, suggesting a transfer, when no venue qualifies.
+func (r *venueRouter) PlaceOrder(ctx context.Context, order types.Order) error {
+	quotes, err := r.quoter.Quotes(ctx, order.Symbol)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refresh(ctx, quotes)
+
+	var best *types.VenueQuote
+	buy := order.Side == types.OrderSideBuy
+	for i, quote := range quotes {
+		venue := r.inventory[quote.Venue]
+		switch {
+		case buy && venue.Quote >= order.Quantity*quote.Ask && (best == nil || quote.Ask < best.Ask):
+			best = &quotes[i]
+		case !buy && venue.Base >= order.Quantity-dust && (best == nil || quote.Bid > best.Bid):
+			best = &quotes[i]
+		}
+	}
+	if best == nil {
+		return r.noVenue(order, quotes)
+	}
+
+	client := r.venues[best.Venue]
+	venue := r.inventory[best.Venue]
+	if buy {
+		order.Price = best.Ask
+	} else {
+		order.Price = best.Bid
+	}
+	if err := client.PlaceOrder(ctx, order); err != nil {
+		return fmt.Errorf("%s: %w", best.Venue, err)
+	}
+	if buy {
+		venue.Buys++
+		venue.Quote -= order.Quantity * order.Price
+		venue.Base += order.Quantity
+	} else {
+		venue.Sells++
+		venue.Base -= order.Quantity
+		venue.Quote += order.Quantity * order.Price
+	}
+	r.logger.Info("Grid %s %.8f routed to %s at %.2f", order.Side, order.Quantity, best.Venue, order.Price)
+	return nil
+}
+
+// refresh updates the venues' quotes and free balances. A venue whose
+// balances cannot be read keeps the ones last seen.
+func (r *venueRouter) refresh(ctx context.Context, quotes []types.VenueQuote) {
+	for _, quote := range quotes {
+		venue, ok := r.inventory[quote.Venue]
+		if !ok {
+			continue
+		}
+		venue.Bid, venue.Ask = quote.Bid, quote.Ask
+		client := r.venues[quote.Venue]
+		balances, err := client.GetBalances(ctx)
+		if err != nil {
+			r.logger.Warn("Venue %s balances unavailable: %v", quote.Venue, err)
+			continue
+		}
+		venue.Base, venue.Quote = 0, 0
+		for _, balance := range balances {
+			switch balance.Asset {
+			case r.base:
+				venue.Base = balance.Free
+			case r.quote:
+				venue.Quote = balance.Free
+			}
+		}
+	}
+	// Transfers are settled once their destination holds what it lacked
+	for key, transfer := range r.transfers {
+		if r.free(transfer.To, transfer.Asset) >= transfer.need {
+			delete(r.transfers, key)
+		}
+	}
+}
+
+// noVenue suggests moving inventory to the venue quoting the best price for
+// order and returns ErrNoVenue
+func (r *venueRouter) noVenue(order types.Order, quotes []types.VenueQuote) error {
+	to := quotes[0]
+	for _, quote := range quotes[1:] {
+		if (order.Side == types.OrderSideBuy && quote.Ask < to.Ask) || (order.Side == types.OrderSideSell && quote.Bid > to.Bid) {
+			to = quote
+		}
+	}
+	asset, need := r.base, order.Quantity
+	if order.Side == types.OrderSideBuy {
+		asset, need = r.quote, order.Quantity*to.Ask
+	}
+	err := fmt.Errorf("%w: %s %.8f %s", ErrNoVenue, order.Side, order.Quantity, r.symbol)
+
+	// Withdraw from the venue holding the most, at least the minimum and
+	// enough to cover the shortfall after the fee
+	var from string
+	for _, name := range r.config.Accounts {
+		if name != to.Venue && (from == "" || r.free(name, asset) > r.free(from, asset)) {
+			from = name
+		}
+	}
+	fee := r.config.TransferFee[asset]
+	quantity := math.Max(need-r.free(to.Venue, asset)+fee, r.config.MinTransfer[asset])
+	if from == "" || r.free(from, asset) < quantity {
+		r.logger.Warn("Grid %s %.8f: no venue can trade it and no transfer covers it", order.Side, order.Quantity)
+		return err
+	}
+	transfer := types.VenueTransfer{Asset: asset, From: from, To: to.Venue, Quantity: quantity, Fee: fee}
+	key := asset + "/" + to.Venue
+	if _, ok := r.transfers[key]; !ok {
+		r.logger.Warn("Grid needs %.8f %s moved from %s to %s (fee %.8f)", quantity, asset, from, to.Venue, fee)
+	}
+	r.transfers[key] = pendingTransfer{VenueTransfer: transfer, need: need}
+	return err
+}
+
+// free returns the free balance of asset last seen on venue
+func (r *venueRouter) free(venue, asset string) float64 {
+	inventory, ok := r.inventory[venue]
+	if !ok {
+		return 0
+	}
+	if asset == r.base {
+		return inventory.Base
+	}
+	return inventory.Quote
+}
+
+// GetOrder looks the order up on every venue
+func (r *venueRouter) GetOrder(ctx context.Context, orderID string) (*types.Order, error) {
+	var errs []error
+	for _, name := range r.config.Accounts {
+		client := r.venues[name]
+		order, err := client.GetOrder(ctx, orderID)
+		if err == nil {
+			return order, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// GetOrderByClientID looks the order up on every venue. It is not found
+// only when no venue failed to answer.
+func (r *venueRouter) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*types.Order, error) {
+	var errs []error
+	for _, name := range r.config.Accounts {
+		order, err := r.venues[name].GetOrderByClientID(ctx, symbol, clientOrderID)
+		switch {
+		case err == nil:
+			return order, nil
+		case !errors.Is(err, types.ErrOrderNotFound):
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, fmt.Errorf("%w: client id %s", types.ErrOrderNotFound, clientOrderID)
+}
+
+// CancelOrder cancels the order on the venue that has it
+func (r *venueRouter) CancelOrder(ctx context.Context, orderID string) error {
+	var errs []error
+	for _, name := range r.config.Accounts {
+		client := r.venues[name]
+		err := client.CancelOrder(ctx, orderID)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// GetActiveOrders lists the open orders of every venue
+func (r *venueRouter) GetActiveOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return r.collect(func(client types.ExchangeClient) ([]types.Order, error) {
+		return client.GetActiveOrders(ctx, symbol)
+	})
+}
+
+// GetFilledOrders lists the recent fills of every venue
+func (r *venueRouter) GetFilledOrders(ctx context.Context, symbol string) ([]types.Order, error) {
+	return r.collect(func(client types.ExchangeClient) ([]types.Order, error) {
+		return client.GetFilledOrders(ctx, symbol)
+	})
+}
+
+// collect concatenates the orders list returns for each venue; venues that
+// do not keep them are skipped
+func (r *venueRouter) collect(list func(types.ExchangeClient) ([]types.Order, error)) ([]types.Order, error) {
+	var all []types.Order
+	supported := false
+	for _, name := range r.config.Accounts {
+		client := r.venues[name]
+		orders, err := list(client)
+		if errors.Is(err, types.ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		supported = true
+		all = append(all, orders...)
+	}
+	if !supported {
+		return nil, types.ErrNotSupported
+	}
+	return all, nil
+}
+
+// GetBalances adds up the balances of every venue
+func (r *venueRouter) GetBalances(ctx context.Context) ([]types.Balance, error) {
+	var balances []types.Balance
+	index := make(map[string]int)
+	for _, name := range r.config.Accounts {
+		client := r.venues[name]
+		venueBalances, err := client.GetBalances(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, balance := range venueBalances {
+			i, ok := index[balance.Asset]
+			if !ok {
+				index[balance.Asset] = len(balances)
+				balances = append(balances, balance)
+				continue
+			}
+			balances[i].Free += balance.Free
+			balances[i].Locked += balance.Locked
+			balances[i].Total += balance.Total
+		}
+	}
+	return balances, nil
+}
+
+// status reports the venues in configured order and the transfers needed
+func (r *venueRouter) status() ([]types.GridVenue, []types.VenueTransfer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	venues := make([]types.GridVenue, 0, len(r.config.Accounts))
+	for _, name := range r.config.Accounts {
+		venues = append(venues, *r.inventory[name])
+	}
+	var transfers []types.VenueTransfer
+	for _, name := range r.config.Accounts {
+		for _, asset := range []string{r.base, r.quote} {
+			if transfer, ok := r.transfers[asset+"/"+name]; ok {
+				transfers = append(transfers, transfer.VenueTransfer)
+			}
+		}
+	}
+	return venues, transfers
+}
@@ -0,0 +1,48 @@
+package strategy
+
+import "fmt"
+
+// Identity tells instances of a strategy apart in logs, metrics, client
+// order ids and the order journal. Strategies embed it to implement ID and
+// Name.
+type Identity struct {
+	id   string
+	name string
+}
+
+// NewIdentity returns the identity of a strategy of strategyType; id
+// defaults to the type and name to the id
+func NewIdentity(strategyType, id, name string) Identity {
+	if id == "" {
+		id = strategyType
+	}
+	if name == "" {
+		name = id
+	}
+	return Identity{id: id, name: name}
+}
+
+// IdentityFromConfig reads the identity of a registered strategy from the
+// "id" and "name" keys of its config
+func IdentityFromConfig(strategyType string, config map[string]interface{}) Identity {
+	id, _ := config["id"].(string)
+	name, _ := config["name"].(string)
+	return NewIdentity(strategyType, id, name)
+}
+
+// ID returns the instance id, e.g. "dca-eth"
+func (i Identity) ID() string { return i.id }
+
+// Name returns the display name
+func (i Identity) Name() string { return i.name }
+
+// ValidateID checks a configured strategy id: letters, digits, '-' and '_'
+// only, so it can prefix client order ids. An empty id takes the default.
+func ValidateID(id string) error {
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("id %q may only contain letters, digits, '-' and '_'", id)
+		}
+	}
+	return nil
+}
@@ -7,11 +7,61 @@ import (
 )
 
 type Strategy interface {
+	// ID tells instances apart in logs, metrics, client order ids and the
+	// order journal; Name is for display. Embed Identity to implement both.
+	ID() string
+	Name() string
+
 	Execute(ctx context.Context, market types.MarketData) error
 	GetSignal(market types.MarketData) types.Signal
 	ValidateConfig() error
 	GetMetrics() types.StrategyMetrics
 	Shutdown(ctx context.Context) error
+
+	// RequiredHistory lists the candles the strategy needs before its first
+	// decision; the bot preloads them before starting the trading loop.
+	// Strategies deciding on the latest price alone return nil.
+	RequiredHistory() []HistoryRequirement
+}
+
+// HistoryRequirement is the number of candles of one symbol and interval a
+// strategy needs to decide, counting the one still open. Exchange is the
+// account the strategy trades the symbol on; nil for the bot's exchange.
+type HistoryRequirement struct {
+	Symbol   string
+	Interval string // exchange kline interval, e.g. "1h"
+	Candles  int
+	Exchange types.ExchangeClient
+}
+
+// HistoryLoader is implemented by strategies that take the preloaded candles
+// rather than waiting for their first Execute to fetch them. Candles are
+// oldest first, the last one possibly still open, and may fall short of the
+// requirement.
+type HistoryLoader interface {
+	LoadHistory(req HistoryRequirement, candles []types.Candle)
+}
+
+// StatusReporter is implemented by strategies that report their state for
+// the API and bot snapshots
+type StatusReporter interface {
+	GetStatus() types.StrategyStatus
+}
+
+// Market is a symbol a strategy trades and the exchange it trades it on.
+// Lower and Upper bound the prices the strategy trades at; zero when any
+// price works.
+type Market struct {
+	Symbol   string
+	Exchange types.ExchangeClient
+	Lower    float64
+	Upper    float64
+}
+
+// MarketLister is implemented by strategies that report the markets they
+// trade, so they can be checked before the bot starts
+type MarketLister interface {
+	Markets() []Market
 }
 
 type StrategyFactory interface {
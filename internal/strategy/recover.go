@@ -0,0 +1,33 @@
+package strategy
+
+import (
+	"context"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Recoverable is implemented by strategies that can resume after a crash.
+// Recover receives the filled orders the strategy placed before the restart,
+// in placement order, each carrying the Decision that produced it.
+type Recoverable interface {
+	Recover(fills []types.Order) error
+}
+
+// Seedable is implemented by strategies that can take over holdings the
+// account already has. Seed runs at startup, after Recover.
+type Seedable interface {
+	Seed(ctx context.Context) error
+}
+
+// filledAt returns the executed quantity and price of an order, falling back
+// to the requested ones when the exchange did not report the fill
+func filledAt(order types.Order) (quantity, price float64) {
+	quantity, price = order.Quantity, order.Price
+	if order.FilledAmount > 0 {
+		quantity = order.FilledAmount
+	}
+	if order.FilledPrice > 0 {
+		price = order.FilledPrice
+	}
+	return quantity, price
+}
@@ -0,0 +1,83 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Constructor builds a strategy from the "config" object of a strategy envelope
+type Constructor func(config map[string]interface{}, exchange types.ExchangeClient, logger *logger.Logger) (Strategy, error)
+
+// Registry maps external strategy types to their constructors
+type Registry struct {
+	mu           sync.RWMutex
+	constructors map[string]Constructor
+}
+
+// NewRegistry creates an empty strategy registry
+func NewRegistry() *Registry {
+	return &Registry{constructors: make(map[string]Constructor)}
+}
+
+// builtinTypes are handled by the Factory and cannot be overridden
+var builtinTypes = map[string]bool{"dca": true, "grid": true, "combo": true, "breakout": true}
+
+// Register adds a strategy type; names must be unique and not shadow built-ins
+func (r *Registry) Register(name string, ctor Constructor) error {
+	if name == "" {
+		return fmt.Errorf("strategy type is required")
+	}
+	if ctor == nil {
+		return fmt.Errorf("constructor for %s is nil", name)
+	}
+	if builtinTypes[name] {
+		return fmt.Errorf("strategy type %s is built in", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.constructors[name]; exists {
+		return fmt.Errorf("strategy type %s is already registered", name)
+	}
+	r.constructors[name] = ctor
+	return nil
+}
+
+// Lookup returns the constructor for a strategy type
+func (r *Registry) Lookup(name string) (Constructor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctor, ok := r.constructors[name]
+	return ctor, ok
+}
+
+// Names returns registered strategy types in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.constructors))
+	for name := range r.constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the process-wide registry used by factories and plugins
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a strategy type to the default registry
+func Register(name string, ctor Constructor) error {
+	return defaultRegistry.Register(name, ctor)
+}
@@ -0,0 +1,66 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func newTestDCA(config map[string]interface{}, exchange types.ExchangeClient, log *logger.Logger) (Strategy, error) {
+	symbol, _ := config["symbol"].(string)
+	return NewDCAStrategy(types.DCAConfig{Symbol: symbol, InvestmentAmount: 10, Interval: 1, MaxInvestments: 1, Enabled: true}, exchange, log), nil
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register("custom", newTestDCA); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register("custom", newTestDCA); err == nil {
+		t.Error("Expected error for duplicate registration")
+	}
+	if err := r.Register("grid", newTestDCA); err == nil {
+		t.Error("Expected error when shadowing a built-in type")
+	}
+	if err := r.Register("", newTestDCA); err == nil {
+		t.Error("Expected error for empty name")
+	}
+	if err := r.Register("nil_ctor", nil); err == nil {
+		t.Error("Expected error for nil constructor")
+	}
+
+	if _, ok := r.Lookup("custom"); !ok {
+		t.Error("Expected custom to be registered")
+	}
+	if names := r.Names(); len(names) != 1 || names[0] != "custom" {
+		t.Errorf("Names() = %v, want [custom]", names)
+	}
+}
+
+func TestComboStrategy_RegisteredType(t *testing.T) {
+	if err := Register("test_registered", newTestDCA); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	config := types.ComboConfig{
+		Strategies: []types.StrategyConfig{
+			{Type: "test_registered", Config: map[string]interface{}{"symbol": "ETHUSDT"}},
+		},
+		Enabled: true,
+	}
+
+	combo, err := NewComboStrategy(config, &MockExchangeClient{}, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatalf("Failed to create Combo strategy with registered type: %v", err)
+	}
+	if _, ok := combo.strategies[0].(*DCAStrategy); !ok {
+		t.Errorf("Expected registered constructor to build the sub-strategy, got %T", combo.strategies[0])
+	}
+
+	config.Strategies[0].Type = "unknown_type"
+	if _, err := NewComboStrategy(config, &MockExchangeClient{}, logger.New(logger.LevelError)); err == nil {
+		t.Error("Expected error for unregistered strategy type")
+	}
+}
@@ -0,0 +1,45 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// symbolRules loads a symbol's order size rules once. Exchanges without
+// rules impose none.
+type symbolRules struct {
+	rules *types.SymbolRules
+}
+
+// get returns the rules, fetching them until the exchange answers
+func (s *symbolRules) get(ctx context.Context, exchange types.ExchangeClient, symbol string) (types.SymbolRules, error) {
+	if s.rules != nil {
+		return *s.rules, nil
+	}
+	rules, err := exchange.GetSymbolRules(ctx, symbol)
+	if errors.Is(err, types.ErrNotSupported) {
+		rules, err = &types.SymbolRules{Symbol: symbol}, nil
+	}
+	if err != nil {
+		return types.SymbolRules{Symbol: symbol}, fmt.Errorf("failed to get order size rules: %w", err)
+	}
+	s.rules = rules
+	return *rules, nil
+}
+
+// sellQuantity sizes a sell of quantity out of held so that it leaves no
+// dust behind: a remainder too small to sell is sold with it. It returns 0
+// when the sell is too small to order.
+func sellQuantity(rules types.SymbolRules, quantity, held, price float64) float64 {
+	quantity = min(quantity, held)
+	if rules.IsDust(held-quantity, price) {
+		quantity = held
+	}
+	if !rules.Tradable(quantity, price) {
+		return 0
+	}
+	return quantity
+}
@@ -0,0 +1,142 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// validateSavings checks the savings settings of a DCA
+func validateSavings(config types.DCAConfig) error {
+	s := config.Savings
+	if s == nil {
+		return nil
+	}
+	if config.Mode == types.DCAModeWithdraw {
+		return fmt.Errorf("withdrawals cannot park cash in savings")
+	}
+	if _, _, ok := types.SplitSymbol(config.Symbol); !ok {
+		return fmt.Errorf("cannot tell the quote asset of %s to park", config.Symbol)
+	}
+	if s.Lead < 0 || s.MinAmount < 0 || s.Rate < 0 {
+		return fmt.Errorf("savings lead, min amount and rate must not be negative")
+	}
+	if s.Lead >= config.Interval {
+		return fmt.Errorf("savings lead must be shorter than the interval")
+	}
+	return nil
+}
+
+// savingsTotals track the quote asset a DCA keeps in flexible savings
+type savingsTotals struct {
+	parked      float64 // principal in savings
+	subscribed  float64
+	redeemed    float64
+	apr         float64 // of the account's savings position, as last read
+	interest    float64
+	adopted     bool // the position was read since the strategy started
+	unavailable bool // the exchange has no savings for the quote asset
+}
+
+func (d *DCAStrategy) savingsStatus() *types.SavingsStatus {
+	_, quote, _ := types.SplitSymbol(d.config.Symbol)
+	return &types.SavingsStatus{
+		Asset:       quote,
+		Parked:      d.savings.parked,
+		Subscribed:  d.savings.subscribed,
+		Redeemed:    d.savings.redeemed,
+		APR:         d.savings.apr,
+		Interest:    d.savings.interest,
+		Unavailable: d.savings.unavailable,
+	}
+}
+
+// manageSavings keeps the budget still to invest in savings, except for the
+// next buy, which is redeemed Lead before it is due. Once the last buy is made
+// everything parked is redeemed. Savings never hold up a buy: failures are
+// logged and the buy is left to the liquid balance.
+func (d *DCAStrategy) manageSavings(ctx context.Context, market types.MarketData) {
+	if d.savings.unavailable {
+		return
+	}
+	_, quote, _ := types.SplitSymbol(d.config.Symbol)
+
+	remaining := d.config.InvestmentAmount * float64(max(d.config.MaxInvestments-d.buyCount, 0))
+	if !d.savings.adopted {
+		// After a restart what the strategy parked is still in savings; it
+		// takes over as much of the position as it has left to invest
+		position, err := d.exchange.GetSavings(ctx, quote)
+		if errors.Is(err, types.ErrNotSupported) {
+			d.logger.Warn("DCA savings unavailable: the exchange has no %s savings", quote)
+			d.savings.unavailable = true
+			return
+		}
+		if err != nil {
+			d.logger.Warn("DCA savings skipped: %v", err)
+			return
+		}
+		d.savings.parked = math.Min(position.Amount, remaining)
+		d.savings.apr, d.savings.interest = position.APR, position.Interest
+		d.savings.adopted = true
+	}
+
+	target := remaining
+	due := !marketTime(market).Before(d.lastBuy.Add(d.config.Interval - d.config.Savings.Lead))
+	if due && remaining > 0 && market.Price > 0 {
+		target -= math.Min(d.calculateQuantity(market.Price)*market.Price, remaining)
+	}
+	target = math.Max(target, 0)
+	minAmount := math.Max(d.config.Savings.MinAmount, dust)
+
+	switch {
+	case d.savings.parked > target+dust:
+		amount := math.Min(math.Max(d.savings.parked-target, minAmount), d.savings.parked)
+		if err := d.exchange.RedeemSavings(ctx, quote, amount); err != nil {
+			d.logger.Warn("DCA savings redemption of %.2f %s failed: %v", amount, quote, err)
+			return
+		}
+		d.savings.parked -= amount
+		d.savings.redeemed += amount
+		d.logger.Info("DCA redeemed %.2f %s from savings for the next buy", amount, quote)
+	case target-d.savings.parked >= minAmount:
+		free, err := d.freeQuote(ctx, quote)
+		if err != nil {
+			d.logger.Warn("DCA savings skipped: %v", err)
+			return
+		}
+		amount := math.Min(target-d.savings.parked, free-d.config.Reserve)
+		if amount < minAmount {
+			return
+		}
+		if err := d.exchange.SubscribeSavings(ctx, quote, amount); err != nil {
+			d.logger.Warn("DCA savings subscription of %.2f %s failed: %v", amount, quote, err)
+			return
+		}
+		d.savings.parked += amount
+		d.savings.subscribed += amount
+		d.logger.Info("DCA parked %.2f %s in savings until it is needed", amount, quote)
+	default:
+		return
+	}
+
+	if position, err := d.exchange.GetSavings(ctx, quote); err == nil {
+		d.savings.apr, d.savings.interest = position.APR, position.Interest
+	}
+}
+
+// freeQuote returns the free balance of the quote asset
+func (d *DCAStrategy) freeQuote(ctx context.Context, quote string) (float64, error) {
+	balances, err := d.exchange.GetBalances(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balances: %w", err)
+	}
+	for _, balance := range balances {
+		if balance.Asset == quote {
+			return balance.Free, nil
+		}
+	}
+	return 0, nil
+}
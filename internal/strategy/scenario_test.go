@@ -0,0 +1,289 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/sim"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// scenarioStep is the part of a placed order a scenario asserts on
+type scenarioStep struct {
+	Step int
+	Side types.OrderSide
+}
+
+// runScenario builds a strategy on a scripted exchange, feeds it every price
+// and returns the accepted orders and the steps at which Execute failed
+func runScenario(t *testing.T, script sim.Script, build func(types.ExchangeClient) Strategy) (*sim.Exchange, []scenarioStep, []int) {
+	t.Helper()
+
+	script.Start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	script.Interval = time.Hour
+	ex, err := sim.NewExchange(script)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+	strat := build(ex)
+
+	var errSteps []int
+	err = ex.Run(func(step int, market types.MarketData) error {
+		if err := strat.Execute(context.Background(), market); err != nil {
+			errSteps = append(errSteps, step)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var orders []scenarioStep
+	for _, order := range ex.Orders() {
+		step := int(order.Timestamp.Sub(script.Start) / script.Interval)
+		orders = append(orders, scenarioStep{Step: step, Side: order.Side})
+	}
+	return ex, orders, errSteps
+}
+
+func TestGridScenarios(t *testing.T) {
+	const (
+		buy  = types.OrderSideBuy
+		sell = types.OrderSideSell
+	)
+	apiErr := errors.New("503 service unavailable")
+
+	// Levels 100/110/120; a position sells once price reaches the next level up
+	tests := []struct {
+		name     string
+		script   sim.Script
+		want     []scenarioStep
+		errSteps []int
+	}{
+		{
+			name:   "buys below levels then sells and rebuys",
+			script: sim.Script{Prices: []float64{125, 108, 121, 108, 121}},
+			want: []scenarioStep{
+				{1, buy}, {1, buy}, // 110 and 120 levels
+				{2, sell}, // 110 position exits at 121
+				{3, buy},  // 110 level rebuys; 120 is still held
+				{4, sell},
+			},
+		},
+		{
+			name:   "no orders while price stays between levels above",
+			script: sim.Script{Prices: []float64{125, 124, 130, 126}},
+		},
+		{
+			name:   "falling through every level buys each once",
+			script: sim.Script{Prices: []float64{125, 119, 109, 99, 95}},
+			want:   []scenarioStep{{1, buy}, {2, buy}, {3, buy}},
+		},
+		{
+			name: "rejected buy is retried on the next touch",
+			script: sim.Script{
+				Prices:  []float64{125, 108, 121, 108},
+				Rejects: map[int]error{2: apiErr},
+			},
+			want:     []scenarioStep{{1, buy}, {2, sell}, {3, buy}, {3, buy}},
+			errSteps: []int{1},
+		},
+		{
+			name: "rejected sell keeps the position for the next rally",
+			script: sim.Script{
+				Prices:  []float64{125, 115, 108, 121, 122},
+				Rejects: map[int]error{3: apiErr},
+			},
+			want:     []scenarioStep{{1, buy}, {2, buy}, {4, sell}},
+			errSteps: []int{3},
+		},
+		{
+			name: "delayed fills hold their levels until reported",
+			script: sim.Script{
+				Prices:    []float64{125, 108, 121, 108, 121},
+				FillDelay: 2,
+			},
+			// Filled at step 3, too late for the rally at step 2
+			want: []scenarioStep{{1, buy}, {1, buy}, {4, sell}},
+		},
+		{
+			name: "partially filled sells keep selling the remainder",
+			script: sim.Script{
+				Prices:    []float64{125, 108, 121, 122},
+				FillRatio: 0.5,
+			},
+			want: []scenarioStep{{1, buy}, {1, buy}, {2, sell}, {3, sell}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.script.Symbol = "BTCUSDT"
+			tt.script.QuoteBalance = 1000
+			_, orders, errSteps := runScenario(t, tt.script, func(ex types.ExchangeClient) Strategy {
+				grid, err := NewGridStrategy(types.GridConfig{
+					Symbol:             "BTCUSDT",
+					LowerPrice:         100,
+					UpperPrice:         120,
+					GridLevels:         3,
+					InvestmentPerLevel: 100,
+					Enabled:            true,
+				}, ex, logger.New(logger.LevelError))
+				if err != nil {
+					t.Fatalf("NewGridStrategy: %v", err)
+				}
+				return grid
+			})
+
+			if !reflect.DeepEqual(orders, tt.want) {
+				t.Errorf("orders = %v, want %v", orders, tt.want)
+			}
+			if !reflect.DeepEqual(errSteps, tt.errSteps) {
+				t.Errorf("error steps = %v, want %v", errSteps, tt.errSteps)
+			}
+		})
+	}
+}
+
+func TestDCAScenarios(t *testing.T) {
+	apiErr := errors.New("429 too many requests")
+	prices := []float64{100, 90, 80, 70, 60, 50}
+
+	// Prices arrive hourly; DCA buys 100 USDT every 2h, at most 3 times
+	tests := []struct {
+		name     string
+		script   sim.Script
+		buySteps []int
+		errSteps []int
+		wantBase float64 // filled base asset once the script ends
+	}{
+		{
+			name:     "buys every interval up to max investments",
+			script:   sim.Script{Prices: prices},
+			buySteps: []int{0, 2, 4},
+			wantBase: 100/100.0 + 100/80.0 + 100/60.0,
+		},
+		{
+			name:     "partial fills still consume the interval",
+			script:   sim.Script{Prices: prices, FillRatio: 0.5},
+			buySteps: []int{0, 2, 4},
+			wantBase: 0.5 * (100/100.0 + 100/80.0 + 100/60.0),
+		},
+		{
+			name:     "API error does not consume the interval",
+			script:   sim.Script{Prices: prices, Rejects: map[int]error{1: apiErr}},
+			buySteps: []int{1, 3, 5},
+			errSteps: []int{0},
+			wantBase: 100/90.0 + 100/70.0 + 100/50.0,
+		},
+		{
+			name:     "repeated errors delay every later buy",
+			script:   sim.Script{Prices: prices, Rejects: map[int]error{2: apiErr, 3: apiErr}},
+			buySteps: []int{0, 4},
+			errSteps: []int{2, 3},
+			wantBase: 100/100.0 + 100/60.0,
+		},
+		{
+			name:     "delayed fills keep the placed quantity",
+			script:   sim.Script{Prices: prices, FillDelay: 1},
+			buySteps: []int{0, 2, 4},
+			wantBase: 100/100.0 + 100/80.0 + 100/60.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.script.Symbol = "BTCUSDT"
+			tt.script.QuoteBalance = 1000
+			ex, orders, errSteps := runScenario(t, tt.script, func(ex types.ExchangeClient) Strategy {
+				return NewDCAStrategy(types.DCAConfig{
+					Symbol:           "BTCUSDT",
+					InvestmentAmount: 100,
+					Interval:         2 * time.Hour,
+					MaxInvestments:   3,
+					Enabled:          true,
+				}, ex, logger.New(logger.LevelError))
+			})
+
+			var buySteps []int
+			for _, order := range orders {
+				if order.Side != types.OrderSideBuy {
+					t.Fatalf("unexpected %s order", order.Side)
+				}
+				buySteps = append(buySteps, order.Step)
+			}
+			if !reflect.DeepEqual(buySteps, tt.buySteps) {
+				t.Errorf("buy steps = %v, want %v", buySteps, tt.buySteps)
+			}
+			if !reflect.DeepEqual(errSteps, tt.errSteps) {
+				t.Errorf("error steps = %v, want %v", errSteps, tt.errSteps)
+			}
+			if _, base := ex.Balances(); math.Abs(base-tt.wantBase) > 1e-9 {
+				t.Errorf("base balance = %.8f, want %.8f", base, tt.wantBase)
+			}
+		})
+	}
+}
+
+func TestDCALimitChase(t *testing.T) {
+	config := types.DCAConfig{Symbol: "BTCUSDT", InvestmentAmount: 100, Interval: 3 * time.Hour, MaxInvestments: 2, Enabled: true,
+		Execution: &types.ExecutionConfig{Style: types.ExecutionLimitChase, ChaseTicks: 3}}
+	var dca *DCAStrategy
+	// The first buy rests at the 99.9 bid until the price dips through it;
+	// the second is re-pegged to the rising bid twice, then bought at market
+	ex, orders, errSteps := runScenario(t, sim.Script{
+		Symbol:        "BTCUSDT",
+		Prices:        []float64{100, 100, 99, 101, 102, 103, 104, 104},
+		Spread:        0.2,
+		RestingLimits: true,
+		QuoteBalance:  1000,
+	}, func(exchange types.ExchangeClient) Strategy {
+		dca = NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+		return dca
+	})
+	if len(errSteps) > 0 {
+		t.Fatalf("Execute failed at steps %v", errSteps)
+	}
+	wantSteps := []int{0, 3, 4, 5, 6}
+	if len(orders) != len(wantSteps) {
+		t.Fatalf("orders = %+v, want them at steps %v", orders, wantSteps)
+	}
+	placed := ex.Orders()
+	for i, order := range placed {
+		wantBuy := 2
+		if i == 0 {
+			wantBuy = 1
+		}
+		if orders[i].Step != wantSteps[i] || order.Decision.Buy != wantBuy {
+			t.Errorf("order %d = %+v at step %d, want step %d of buy %d", i, order, orders[i].Step, wantSteps[i], wantBuy)
+		}
+	}
+	if last := placed[len(placed)-1]; last.Type != types.OrderTypeMarket || last.FilledPrice != 104.1 {
+		t.Errorf("last order = %+v, want a market buy at the 104.1 ask", last)
+	}
+
+	status := dca.GetStatus().DCA
+	taker := 100.0 / 101 * 104.1
+	if status.Orders != 2 || status.Execution.Chasing || math.Abs(status.Execution.MakerVolume-99.9) > 1e-9 ||
+		math.Abs(status.Execution.MakerRatio-99.9/(99.9+taker)) > 1e-9 {
+		t.Fatalf("status = %+v, execution = %+v", status, status.Execution)
+	}
+	if m := dca.GetMetrics(); m.TotalTrades != 2 {
+		t.Errorf("trades = %d, want one per chased buy", m.TotalTrades)
+	}
+
+	// The orders of a chased buy recover as one buy
+	filled, _ := ex.GetFilledOrders(context.Background(), "BTCUSDT")
+	recovered := NewDCAStrategy(config, ex, logger.New(logger.LevelError))
+	if err := recovered.Recover(filled); err != nil {
+		t.Fatal(err)
+	}
+	if got := recovered.GetStatus().DCA; got.Orders != 2 || math.Abs(got.Execution.MakerRatio-status.Execution.MakerRatio) > 1e-9 {
+		t.Errorf("recovered status = %+v, execution = %+v", got, got.Execution)
+	}
+}
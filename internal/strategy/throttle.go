@@ -0,0 +1,14 @@
+package strategy
+
+import (
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// ParseThrottleConfig reads a "throttle" object from a generic strategy config map
+func ParseThrottleConfig(config map[string]interface{}) (*types.ThrottleConfig, error) {
+	throttle := &types.ThrottleConfig{}
+	if ok, err := decodeConfigObject(config, "throttle", throttle); !ok || err != nil {
+		return nil, err
+	}
+	return throttle, nil
+}
@@ -0,0 +1,30 @@
+package strategy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func TestParseThrottleConfig(t *testing.T) {
+	cfg, err := ParseThrottleConfig(map[string]interface{}{
+		"throttle": map[string]interface{}{
+			"min_interval":  "5m",
+			"max_per_day":   20.0,
+			"loss_streak":   3.0,
+			"loss_cooldown": "6h",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &types.ThrottleConfig{MinInterval: 5 * time.Minute, MaxPerDay: 20, LossStreak: 3, LossCooldown: 6 * time.Hour}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("ParseThrottleConfig = %+v, want %+v", cfg, want)
+	}
+	if _, err := ParseThrottleConfig(map[string]interface{}{"throttle": map[string]interface{}{"min_interval": "soon"}}); err == nil {
+		t.Fatal("expected error for a bad duration")
+	}
+}
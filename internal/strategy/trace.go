@@ -0,0 +1,193 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/calendar"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/portfolio"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// traceCapacity is how many decisions a strategy keeps
+const traceCapacity = 500
+
+// Trace outcomes
+const (
+	TraceTriggered = "triggered" // an order was placed
+	TraceBlocked   = "blocked"   // a rule stopped an order
+	TraceFailed    = "failed"    // the order was sent and failed
+	TraceIdle      = "idle"      // nothing was due
+)
+
+// Trace rules
+const (
+	RuleDisabled       = "disabled"
+	RuleBuy            = "buy"
+	RuleSell           = "sell"
+	RuleExit           = "exit"
+	RuleInterval       = "interval_not_elapsed"
+	RuleMaxInvestments = "max_investments_reached"
+	RulePriceThreshold = "price_threshold"
+	RuleFilterVeto     = "filter_vetoed"
+	RuleThrottled      = "throttled"
+	RuleRiskRejected   = "risk_rejected"
+	RuleBelowMinimum   = "below_exchange_minimum"
+	RuleInsufficient   = "insufficient_balance"
+	RuleSlippage       = "conversion_slippage"
+	RuleOrderFailed    = "order_failed"
+	RulePending        = "order_pending"
+	RuleNothingToSell  = "nothing_to_sell"
+	RuleNoLevelCrossed = "no_level_crossed"
+	RuleNothingDue     = "nothing_due"
+	RuleWarmingUp      = "warming_up"
+	RuleNoData         = "market_data_unavailable"
+	RuleNoBreakout     = "no_breakout"
+	RuleError          = "error"
+)
+
+// TraceEntry is one decision of an Execute run: the rule that triggered or
+// blocked an order
+type TraceEntry struct {
+	Run      uint64    `json:"run"` // Execute run the decision belongs to
+	Seq      uint64    `json:"seq"` // order of the decision among all runs
+	Time     time.Time `json:"time"`
+	Strategy string    `json:"strategy"`
+	Symbol   string    `json:"symbol"`
+	Price    float64   `json:"price"`
+	Outcome  string    `json:"outcome"`
+	Rule     string    `json:"rule"`
+	Detail   string    `json:"detail,omitempty"`
+	Level    float64   `json:"level,omitempty"` // grid level
+}
+
+// Traceable is implemented by strategies that record their decisions
+type Traceable interface {
+	Trace() []TraceEntry
+}
+
+// Tracer keeps a strategy's most recent decisions. Runs are recorded from
+// Execute, which strategies serialize.
+type Tracer struct {
+	strategy string
+
+	mu       sync.Mutex
+	entries  []TraceEntry // ring buffer once full
+	next     int
+	run      uint64
+	seq      uint64
+	market   types.MarketData
+	recorded bool
+}
+
+// NewTracer creates a tracer for the named strategy
+func NewTracer(strategy string) *Tracer {
+	return &Tracer{strategy: strategy}
+}
+
+// begin starts the decisions of a new Execute run
+func (t *Tracer) begin(market types.MarketData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.run++
+	t.market = market
+	t.recorded = false
+}
+
+// add records a decision of the current run
+func (t *Tracer) add(entry TraceEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seq++
+	entry.Run, entry.Seq, entry.Time, entry.Strategy = t.run, t.seq, marketTime(t.market), t.strategy
+	if entry.Symbol == "" {
+		entry.Symbol = t.market.Symbol
+	}
+	if entry.Price == 0 {
+		entry.Price = t.market.Price
+	}
+	t.recorded = true
+
+	if len(t.entries) < traceCapacity {
+		t.entries = append(t.entries, entry)
+		return
+	}
+	t.entries[t.next] = entry
+	t.next = (t.next + 1) % traceCapacity
+}
+
+// blocked records a rule that stopped an order
+func (t *Tracer) blocked(rule, format string, args ...interface{}) {
+	t.blockedAt(0, rule, format, args...)
+}
+
+// blockedAt records a rule that stopped an order for a grid level
+func (t *Tracer) blockedAt(level float64, rule, format string, args ...interface{}) {
+	t.add(TraceEntry{Outcome: TraceBlocked, Rule: rule, Detail: fmt.Sprintf(format, args...), Level: level})
+}
+
+// triggered records an order placed under rule, at a grid level if any
+func (t *Tracer) triggered(rule string, order types.Order, level float64) {
+	t.add(TraceEntry{Outcome: TraceTriggered, Rule: rule, Detail: orderDetail(order), Level: level})
+}
+
+// end closes the run, recording its error or idle rule when the run made
+// no other decision
+func (t *Tracer) end(idle string, err error) {
+	t.mu.Lock()
+	recorded := t.recorded
+	t.mu.Unlock()
+	switch {
+	case recorded:
+	case err != nil:
+		t.add(TraceEntry{Outcome: TraceFailed, Rule: RuleError, Detail: err.Error()})
+	default:
+		t.add(TraceEntry{Outcome: TraceIdle, Rule: idle})
+	}
+}
+
+// orderError records an order that failed or that a risk control rejected
+func (t *Tracer) orderError(err error, level float64) {
+	entry := TraceEntry{Outcome: TraceBlocked, Rule: RuleRiskRejected, Detail: err.Error(), Level: level}
+	switch {
+	case errors.Is(err, risk.ErrThrottled):
+		entry.Rule = RuleThrottled
+	case errors.Is(err, types.ErrBelowMinimum):
+		entry.Rule = RuleBelowMinimum
+	case errors.Is(err, ErrNoVenue), errors.Is(err, ErrArbitrationBudget):
+		entry.Rule = RuleInsufficient
+	case errors.Is(err, risk.ErrCircuitOpen), errors.Is(err, risk.ErrDeleveraged), errors.Is(err, risk.ErrRestricted),
+		errors.Is(err, calendar.ErrBlackout), errors.Is(err, portfolio.ErrAllocationExceeded):
+	default:
+		entry.Outcome, entry.Rule = TraceFailed, RuleOrderFailed
+	}
+	t.add(entry)
+}
+
+// orderDetail describes an order for the trace
+func orderDetail(order types.Order) string {
+	return fmt.Sprintf("%s %.8f @ %.2f", order.Side, order.Quantity, order.Price)
+}
+
+// Entries returns the recorded decisions, oldest first
+func (t *Tracer) Entries() []TraceEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := make([]TraceEntry, 0, len(t.entries))
+	entries = append(entries, t.entries[t.next:]...)
+	return append(entries, t.entries[:t.next]...)
+}
+
+// mergeTraces interleaves the traces of several strategies by time
+func mergeTraces(traces ...[]TraceEntry) []TraceEntry {
+	var merged []TraceEntry
+	for _, trace := range traces {
+		merged = append(merged, trace...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	return merged
+}
@@ -0,0 +1,107 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// rejectingExchange fails every order with err
+type rejectingExchange struct {
+	MockExchangeClient
+	err error
+}
+
+func (r *rejectingExchange) PlaceOrder(ctx context.Context, order types.Order) error {
+	return r.err
+}
+
+func TestDCAStrategy_Trace(t *testing.T) {
+	config := types.DCAConfig{
+		Symbol:           "BTCUSDT",
+		InvestmentAmount: 100,
+		Interval:         time.Hour,
+		MaxInvestments:   2,
+		PriceThreshold:   50000,
+		Enabled:          true,
+	}
+	exchange := &rejectingExchange{}
+	dca := NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	steps := []struct {
+		offset  time.Duration
+		price   float64
+		err     error
+		outcome string
+		rule    string
+	}{
+		{0, 45000, nil, TraceTriggered, RuleBuy},
+		{time.Minute, 45000, nil, TraceBlocked, RuleInterval},
+		{2 * time.Hour, 55000, nil, TraceBlocked, RulePriceThreshold},
+		{2 * time.Hour, 45000, fmt.Errorf("wrapped: %w", risk.ErrThrottled), TraceBlocked, RuleThrottled},
+		{2 * time.Hour, 45000, risk.ErrCircuitOpen, TraceBlocked, RuleRiskRejected},
+		{2 * time.Hour, 45000, fmt.Errorf("timeout"), TraceFailed, RuleOrderFailed},
+		{3 * time.Hour, 45000, nil, TraceTriggered, RuleBuy},
+		{5 * time.Hour, 45000, nil, TraceBlocked, RuleMaxInvestments},
+	}
+	for _, step := range steps {
+		exchange.err = step.err
+		_ = dca.Execute(context.Background(), types.MarketData{Symbol: "BTCUSDT", Price: step.price, Timestamp: start.Add(step.offset)})
+	}
+
+	trace := dca.Trace()
+	if len(trace) != len(steps) {
+		t.Fatalf("trace has %d entries, want %d: %+v", len(trace), len(steps), trace)
+	}
+	for i, step := range steps {
+		entry := trace[i]
+		if entry.Outcome != step.outcome || entry.Rule != step.rule {
+			t.Errorf("run %d: %s/%s, want %s/%s (%s)", i+1, entry.Outcome, entry.Rule, step.outcome, step.rule, entry.Detail)
+		}
+		if entry.Run != uint64(i+1) || entry.Strategy != "dca" || entry.Symbol != "BTCUSDT" || entry.Price != step.price {
+			t.Errorf("run %d: unexpected entry %+v", i+1, entry)
+		}
+	}
+}
+
+func TestGridStrategy_TraceIdle(t *testing.T) {
+	grid, err := NewGridStrategy(types.GridConfig{
+		Symbol:             "BTCUSDT",
+		LowerPrice:         40000,
+		UpperPrice:         50000,
+		GridLevels:         5,
+		InvestmentPerLevel: 100,
+		Enabled:            true,
+	}, &MockExchangeClient{}, logger.New(logger.LevelError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := grid.Execute(context.Background(), types.MarketData{Symbol: "BTCUSDT", Price: 60000}); err != nil {
+		t.Fatal(err)
+	}
+	trace := grid.Trace()
+	if len(trace) != 1 || trace[0].Outcome != TraceIdle || trace[0].Rule != RuleNoLevelCrossed {
+		t.Fatalf("trace = %+v, want one idle no_level_crossed entry", trace)
+	}
+}
+
+func TestTracer_KeepsLatest(t *testing.T) {
+	tracer := NewTracer("dca")
+	for i := 0; i < traceCapacity+10; i++ {
+		tracer.begin(types.MarketData{Symbol: "BTCUSDT", Price: float64(i + 1)})
+		tracer.end(RuleNothingDue, nil)
+	}
+	entries := tracer.Entries()
+	if len(entries) != traceCapacity {
+		t.Fatalf("kept %d entries, want %d", len(entries), traceCapacity)
+	}
+	if entries[0].Run != 11 || entries[len(entries)-1].Run != traceCapacity+10 {
+		t.Fatalf("kept runs %d..%d, want 11..%d", entries[0].Run, entries[len(entries)-1].Run, traceCapacity+10)
+	}
+}
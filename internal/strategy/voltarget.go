@@ -0,0 +1,135 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/indicators"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Volatility targeting methods
+const (
+	VolMethodStd = "std"
+	VolMethodATR = "atr"
+)
+
+// VolatilityTarget scales investment sizes inversely with realized volatility:
+// buy more in calm markets, less in violent ones. It keeps the last Window+1
+// observations.
+type VolatilityTarget struct {
+	cfg                 types.VolatilityTargetConfig
+	highs, lows, closes []float64
+}
+
+// NewVolatilityTarget creates a volatility target, filling zero config fields with defaults
+func NewVolatilityTarget(cfg types.VolatilityTargetConfig) *VolatilityTarget {
+	return &VolatilityTarget{cfg: volTargetDefaults(cfg)}
+}
+
+// ValidateVolTarget checks a volatility target config
+func ValidateVolTarget(cfg types.VolatilityTargetConfig) error {
+	if cfg.Target <= 0 {
+		return fmt.Errorf("vol target must be positive")
+	}
+	if cfg.Window < 0 {
+		return fmt.Errorf("vol target window must not be negative")
+	}
+	if cfg.Method != "" && cfg.Method != VolMethodStd && cfg.Method != VolMethodATR {
+		return fmt.Errorf("unknown vol target method %q", cfg.Method)
+	}
+	cfg = volTargetDefaults(cfg)
+	if cfg.MinScale > cfg.MaxScale {
+		return fmt.Errorf("vol target min scale %.2f exceeds max scale %.2f", cfg.MinScale, cfg.MaxScale)
+	}
+	return nil
+}
+
+func volTargetDefaults(cfg types.VolatilityTargetConfig) types.VolatilityTargetConfig {
+	if cfg.Window <= 0 {
+		cfg.Window = 20
+	}
+	if cfg.Method == "" {
+		cfg.Method = VolMethodStd
+	}
+	if cfg.MinScale <= 0 {
+		cfg.MinScale = 0.25
+	}
+	if cfg.MaxScale <= 0 {
+		cfg.MaxScale = 2
+	}
+	return cfg
+}
+
+// SetConfig replaces the config, keeping the observed history
+func (v *VolatilityTarget) SetConfig(cfg types.VolatilityTargetConfig) {
+	v.cfg = volTargetDefaults(cfg)
+	v.trim()
+}
+
+// Observe records one bar; with only a price pass it as high, low and close
+func (v *VolatilityTarget) Observe(high, low, close float64) {
+	if close <= 0 {
+		return
+	}
+	v.highs = append(v.highs, high)
+	v.lows = append(v.lows, low)
+	v.closes = append(v.closes, close)
+	v.trim()
+}
+
+// ObserveMarket records the latest candle of market, or its price
+func (v *VolatilityTarget) ObserveMarket(market types.MarketData) {
+	if n := len(market.Candles); n > 0 {
+		c := market.Candles[n-1]
+		v.Observe(c.High, c.Low, c.Close)
+		return
+	}
+	v.Observe(market.Price, market.Price, market.Price)
+}
+
+func (v *VolatilityTarget) trim() {
+	if extra := len(v.closes) - (v.cfg.Window + 1); extra > 0 {
+		v.highs = v.highs[extra:]
+		v.lows = v.lows[extra:]
+		v.closes = v.closes[extra:]
+	}
+}
+
+// Volatility returns realized volatility, or false until Window+1 observations
+func (v *VolatilityTarget) Volatility() (float64, bool) {
+	if len(v.closes) < v.cfg.Window+1 {
+		return 0, false
+	}
+
+	if v.cfg.Method == VolMethodATR {
+		atr := indicators.ATR(v.highs, v.lows, v.closes, v.cfg.Window)
+		return atr[len(atr)-1] / v.closes[len(v.closes)-1], true
+	}
+
+	returns := make([]float64, len(v.closes)-1)
+	var mean float64
+	for i := 1; i < len(v.closes); i++ {
+		returns[i-1] = v.closes[i]/v.closes[i-1] - 1
+		mean += returns[i-1]
+	}
+	mean /= float64(len(returns))
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns))), true
+}
+
+// Scale returns the investment multiplier and the volatility it is based on.
+// The scale is 1 until enough observations are collected.
+func (v *VolatilityTarget) Scale() (scale, vol float64) {
+	vol, ok := v.Volatility()
+	if !ok {
+		return 1, 0
+	}
+	if vol == 0 {
+		return v.cfg.MaxScale, 0
+	}
+	return math.Min(v.cfg.MaxScale, math.Max(v.cfg.MinScale, v.cfg.Target/vol)), vol
+}
@@ -0,0 +1,129 @@
+package strategy
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/logger"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// alternating returns prices moving ±move around 100
+func alternating(n int, move float64) []float64 {
+	prices := make([]float64, n)
+	for i := range prices {
+		prices[i] = 100
+		if i%2 == 1 {
+			prices[i] = 100 * (1 + move)
+		}
+	}
+	return prices
+}
+
+func TestVolatilityTargetScale(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    types.VolatilityTargetConfig
+		prices []float64
+		want   float64
+	}{
+		{"warming up", types.VolatilityTargetConfig{Window: 10, Target: 0.01}, alternating(5, 0.02), 1},
+		{"flat market hits max", types.VolatilityTargetConfig{Window: 4, Target: 0.01}, []float64{100, 100, 100, 100, 100}, 2},
+		{"violent market hits min", types.VolatilityTargetConfig{Window: 4, Target: 0.001, MinScale: 0.5}, alternating(5, 0.1), 0.5},
+	}
+	for _, tt := range tests {
+		v := NewVolatilityTarget(tt.cfg)
+		for _, p := range tt.prices {
+			v.Observe(p, p, p)
+		}
+		if scale, _ := v.Scale(); scale != tt.want {
+			t.Errorf("%s: scale = %v, want %v", tt.name, scale, tt.want)
+		}
+	}
+
+	// Calm markets buy more than violent ones
+	cfg := types.VolatilityTargetConfig{Target: 0.015}
+	calm, violent := NewVolatilityTarget(cfg), NewVolatilityTarget(cfg)
+	for _, p := range alternating(40, 0.01) {
+		calm.Observe(p, p, p)
+	}
+	for _, p := range alternating(40, 0.04) {
+		violent.Observe(p, p, p)
+	}
+	calmScale, calmVol := calm.Scale()
+	violentScale, violentVol := violent.Scale()
+	if calmScale <= violentScale || calmVol >= violentVol {
+		t.Fatalf("calm scale %v (vol %v) should exceed violent scale %v (vol %v)", calmScale, calmVol, violentScale, violentVol)
+	}
+	if math.Abs(calmScale*calmVol-0.015) > 1e-9 {
+		t.Fatalf("unclamped scale should be target/vol, got %v * %v", calmScale, calmVol)
+	}
+}
+
+func TestVolatilityTargetATR(t *testing.T) {
+	v := NewVolatilityTarget(types.VolatilityTargetConfig{Window: 2, Method: VolMethodATR, Target: 0.01})
+	v.Observe(101, 99, 100)
+	v.Observe(102, 98, 100)
+	v.Observe(103, 99, 100)
+
+	vol, ok := v.Volatility()
+	if !ok || math.Abs(vol-0.04) > 1e-12 {
+		t.Fatalf("ATR volatility = %v, %v; want 0.04", vol, ok)
+	}
+	if scale, _ := v.Scale(); math.Abs(scale-0.25) > 1e-12 {
+		t.Fatalf("scale = %v, want 0.25", scale)
+	}
+}
+
+func TestValidateVolTarget(t *testing.T) {
+	invalid := []types.VolatilityTargetConfig{
+		{},
+		{Target: 0.01, Method: "garch"},
+		{Target: 0.01, Window: -1},
+		{Target: 0.01, MinScale: 3},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateVolTarget(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+	if err := ValidateVolTarget(types.VolatilityTargetConfig{Target: 0.01, Method: VolMethodATR}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDCAVolatilityScaling(t *testing.T) {
+	config := types.DCAConfig{
+		Symbol:           "BTCUSDT",
+		InvestmentAmount: 100,
+		Interval:         time.Hour,
+		MaxInvestments:   10,
+		Enabled:          true,
+		VolTarget:        &types.VolatilityTargetConfig{Window: 4, Target: 0.01},
+	}
+	exchange := &MockExchangeClient{}
+	dca := NewDCAStrategy(config, exchange, logger.New(logger.LevelError))
+
+	// Warm up with a flat market inside the buy interval; the first tick buys unscaled
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		market := types.MarketData{Symbol: "BTCUSDT", Price: 100, Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		if err := dca.Execute(context.Background(), market); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	market := types.MarketData{Symbol: "BTCUSDT", Price: 100, Timestamp: base.Add(2 * time.Hour)}
+	signal := dca.GetSignal(market)
+	if signal.Metadata["vol_scale"] != 2.0 || signal.Quantity != 2 {
+		t.Fatalf("expected doubled buy in a flat market, got quantity %v metadata %v", signal.Quantity, signal.Metadata)
+	}
+	if err := dca.Execute(context.Background(), market); err != nil {
+		t.Fatal(err)
+	}
+	if len(exchange.orders) != 2 || exchange.orders[0].Quantity != 1 || exchange.orders[1].Quantity != 2 {
+		t.Fatalf("unexpected orders %+v", exchange.orders)
+	}
+}
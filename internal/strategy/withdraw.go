@@ -0,0 +1,251 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/risk"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Withdrawal (reverse DCA) shares the DCA schedule: lastBuy and buyCount
+// track the last scheduled sell and the number of sells.
+
+// withdrawing reports whether the strategy sells on its schedule
+func (d *DCAStrategy) withdrawing() bool {
+	return d.config.Mode == types.DCAModeWithdraw
+}
+
+// validateWithdrawal checks the mode and the withdrawal settings
+func validateWithdrawal(config types.DCAConfig) error {
+	switch config.Mode {
+	case "", types.DCAModeAccumulate:
+		return nil
+	case types.DCAModeWithdraw:
+	default:
+		return fmt.Errorf("unknown DCA mode %q", config.Mode)
+	}
+
+	if config.Holdings < 0 || config.CostBasis < 0 {
+		return fmt.Errorf("holdings and cost basis must not be negative")
+	}
+	if config.StopLoss < 0 || config.StopLoss >= 1 || config.TakeProfit < 0 {
+		return fmt.Errorf("stop loss must be within [0, 1) and take profit not negative")
+	}
+	if (config.StopLoss > 0 || config.TakeProfit > 0) && config.CostBasis <= 0 {
+		return fmt.Errorf("take profit and stop loss need a cost basis")
+	}
+	if config.Exit != nil {
+		return fmt.Errorf("withdrawals use take_profit and stop_loss instead of an exit ladder")
+	}
+	return nil
+}
+
+// executeWithdrawal sells the scheduled amount, or everything left once
+// price crosses the take-profit or stop-loss bound
+func (d *DCAStrategy) executeWithdrawal(ctx context.Context, market types.MarketData) error {
+	remaining, err := d.remaining(ctx)
+	if err != nil {
+		return err
+	}
+	if remaining <= dust {
+		d.trace.blocked(RuleNothingToSell, "holdings withdrawn")
+		return nil
+	}
+	rules, err := d.rules.get(ctx, d.exchange, d.config.Symbol)
+	if err != nil {
+		d.logger.Warn("DCA withdrawal without order size rules: %v", err)
+	}
+	// A remainder too small to sell is dust, not something left to withdraw
+	if rules.IsDust(remaining, market.Price) {
+		d.trace.blocked(RuleNothingToSell, "%.8f left is dust", remaining)
+		return nil
+	}
+
+	if reason := d.withdrawalExit(market.Price); reason != "" {
+		return d.executeSell(ctx, market, remaining, &types.Decision{Strategy: "dca", StrategyID: d.ID(), Action: types.DecisionExit, Exit: reason})
+	}
+
+	if rule, detail := d.sellBlock(market); rule != "" {
+		d.trace.blocked(rule, "%s", detail)
+		return nil
+	}
+
+	quantity := math.Min(d.calculateQuantity(market.Price), remaining)
+	signal, ok := applyFilter(d.filter, d.logger, types.Signal{
+		Type:      types.SignalTypeSell,
+		Symbol:    d.config.Symbol,
+		Price:     market.Price,
+		Quantity:  quantity,
+		Strength:  1.0,
+		Timestamp: market.Timestamp,
+	}, market)
+	if !ok {
+		d.trace.blocked(RuleFilterVeto, "sell %.8f vetoed", quantity)
+		return nil
+	}
+	quantity = sellQuantity(rules, signal.Quantity, remaining, market.Price)
+	if quantity <= 0 {
+		d.logger.Warn("DCA sell skipped: %.8f is below the exchange minimum", signal.Quantity)
+		d.trace.blocked(RuleBelowMinimum, "sell %.8f", signal.Quantity)
+		return nil
+	}
+
+	err = d.executeSell(ctx, market, quantity, &types.Decision{Strategy: "dca", StrategyID: d.ID(), Action: types.DecisionSell})
+	if errors.Is(err, risk.ErrThrottled) {
+		d.logger.Info("DCA sell skipped: %v", err)
+		return nil
+	}
+	return err
+}
+
+// sellDue applies the schedule, sell cap and price floor to the next sell
+func (d *DCAStrategy) sellDue(market types.MarketData) bool {
+	rule, _ := d.sellBlock(market)
+	return rule == ""
+}
+
+// sellBlock returns the trace rule and detail holding back the next sell,
+// or "" when it is due
+func (d *DCAStrategy) sellBlock(market types.MarketData) (string, string) {
+	if marketTime(market).Sub(d.lastBuy) < d.config.Interval {
+		return RuleInterval, "next sell at " + d.lastBuy.Add(d.config.Interval).Format(time.RFC3339)
+	}
+	if d.buyCount >= d.config.MaxInvestments {
+		return RuleMaxInvestments, fmt.Sprintf("%d of %d sells made", d.buyCount, d.config.MaxInvestments)
+	}
+	// PriceThreshold is a floor when selling
+	if d.config.PriceThreshold > 0 && market.Price < d.config.PriceThreshold {
+		return RulePriceThreshold, fmt.Sprintf("price %.2f below floor %.2f", market.Price, d.config.PriceThreshold)
+	}
+	return "", ""
+}
+
+// withdrawalExit returns the exit reason once price reaches a bound, or ""
+func (d *DCAStrategy) withdrawalExit(price float64) string {
+	basis := d.config.CostBasis
+	switch {
+	case basis <= 0:
+		return ""
+	case d.config.TakeProfit > 0 && price >= basis*(1+d.config.TakeProfit):
+		return ExitTakeProfit
+	case d.config.StopLoss > 0 && price <= basis*(1-d.config.StopLoss):
+		return ExitStopLoss
+	}
+	return ""
+}
+
+// remaining returns what is left to withdraw: the rest of Holdings, or the
+// free base asset balance without one
+func (d *DCAStrategy) remaining(ctx context.Context) (float64, error) {
+	if d.config.Holdings > 0 {
+		return math.Max(d.config.Holdings-d.sold, 0), nil
+	}
+
+	base, _, ok := types.SplitSymbol(d.config.Symbol)
+	if !ok {
+		return 0, fmt.Errorf("cannot tell the base asset of %s, set holdings", d.config.Symbol)
+	}
+	balances, err := d.exchange.GetBalances(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balances: %w", err)
+	}
+	for _, balance := range balances {
+		if balance.Asset == base {
+			return balance.Free, nil
+		}
+	}
+	return 0, nil
+}
+
+// executeSell places a market sell and books what the exchange reports as
+// filled
+func (d *DCAStrategy) executeSell(ctx context.Context, market types.MarketData, quantity float64, decision *types.Decision) error {
+	order := types.Order{
+		Symbol:    d.config.Symbol,
+		Side:      types.OrderSideSell,
+		Type:      types.OrderTypeMarket,
+		Quantity:  quantity,
+		Price:     market.Price,
+		Status:    types.OrderStatusNew,
+		Timestamp: time.Now(),
+		Decision:  decision,
+	}
+	tagOrder(&order, d.ID())
+	if err := d.exchange.PlaceOrder(ctx, order); err != nil {
+		d.trace.orderError(err, 0)
+		return fmt.Errorf("failed to place sell order: %w", err)
+	}
+	d.trace.triggered(string(decision.Action), order, 0)
+
+	if decision.Action == types.DecisionSell {
+		d.lastBuy = marketTime(market)
+		d.buyCount++
+	}
+	report, working, err := executionReport(ctx, d.exchange, order)
+	if err != nil || working {
+		d.logger.Warn("DCA withdrawal sell not reported yet, booked at %.2f: %v", order.Price, err)
+		report = order
+		report.FilledAmount, report.FilledPrice = order.Quantity, order.Price
+	}
+	if report.FilledAmount <= dust {
+		d.logger.Warn("DCA withdrawal %s of %.8f did not fill (%s)", decision.Action, quantity, report.Status)
+		return nil
+	}
+	sold, price := filledAt(report)
+	report.Quantity = sold
+	d.recordSell(report, price)
+	d.logger.Info("DCA withdrawal %s: %s %.8f @ %.2f (sold %.8f in total)",
+		decision.Action, order.Symbol, sold, price, d.sold)
+	if sold < quantity-dust {
+		d.logger.Warn("DCA withdrawal %s filled %.8f of %.8f", decision.Action, sold, quantity)
+	}
+	return nil
+}
+
+// recordSell books a withdrawal fill into the sell totals and metrics
+func (d *DCAStrategy) recordSell(order types.Order, price float64) {
+	d.sold += order.Quantity
+	d.proceeds += order.Quantity * price
+	d.updateMetrics(order, price)
+	if d.config.CostBasis > 0 {
+		recordRealized(d.metrics, (price-d.config.CostBasis)*order.Quantity)
+	}
+}
+
+// withdrawalSignal reports the next withdrawal for observability
+func (d *DCAStrategy) withdrawalSignal(market types.MarketData) types.Signal {
+	signal := types.Signal{Type: types.SignalTypeHold, Symbol: market.Symbol, Price: market.Price, Timestamp: market.Timestamp}
+	if d.config.Holdings > 0 && d.config.Holdings-d.sold <= dust {
+		return signal
+	}
+	if reason := d.withdrawalExit(market.Price); reason != "" {
+		signal.Type, signal.Strength = types.SignalTypeSell, 1.0
+		signal.Metadata = map[string]interface{}{"exit": reason}
+		return signal
+	}
+	if d.sellDue(market) {
+		signal.Type, signal.Strength = types.SignalTypeSell, 1.0
+		signal.Quantity = d.calculateQuantity(market.Price)
+		signal.Metadata = map[string]interface{}{"sell_count": d.buyCount + 1, "interval": d.config.Interval.String()}
+	}
+	return signal
+}
+
+// withdrawalStatus reports the sales of withdraw mode
+func (d *DCAStrategy) withdrawalStatus() *types.WithdrawalStatus {
+	status := &types.WithdrawalStatus{SoldQuantity: d.sold, Proceeds: d.proceeds}
+	if d.sold > 0 {
+		status.AvgSellPrice = d.proceeds / d.sold
+	}
+	if d.config.Holdings > 0 {
+		status.Remaining = math.Max(d.config.Holdings-d.sold, 0)
+	}
+	if d.config.CostBasis > 0 {
+		status.RealizedPnL = d.proceeds - d.sold*d.config.CostBasis
+	}
+	return status
+}
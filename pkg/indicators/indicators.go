@@ -148,6 +148,20 @@ func BollingerBands(prices []float64, period int, stdDev float64) ([]float64, []
 	return upper, sma, lower
 }
 
+// ATR calculates Average True Range (simple average of true ranges)
+func ATR(highs, lows, closes []float64, period int) []float64 {
+	if len(closes) < period+1 {
+		return []float64{}
+	}
+
+	ranges := make([]float64, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		ranges[i-1] = math.Max(highs[i]-lows[i], math.Max(math.Abs(highs[i]-closes[i-1]), math.Abs(lows[i]-closes[i-1])))
+	}
+
+	return SMA(ranges, period)
+}
+
 // Stochastic calculates Stochastic Oscillator
 func Stochastic(highs, lows, closes []float64, kPeriod, dPeriod int) ([]float64, []float64) {
 	if len(closes) < kPeriod {
@@ -0,0 +1,66 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// benchSeries is a year of hourly prices with highs and lows around them
+func benchSeries() (highs, lows, closes []float64) {
+	n := 24 * 365
+	highs, lows, closes = make([]float64, n), make([]float64, n), make([]float64, n)
+	for i := range closes {
+		closes[i] = 40000 + 2000*math.Sin(float64(i)/50) + 300*math.Sin(float64(i)/3)
+		highs[i], lows[i] = closes[i]*1.002, closes[i]*0.998
+	}
+	return highs, lows, closes
+}
+
+func BenchmarkSMA(b *testing.B) {
+	_, _, closes := benchSeries()
+	for i := 0; i < b.N; i++ {
+		SMA(closes, 200)
+	}
+}
+
+func BenchmarkEMA(b *testing.B) {
+	_, _, closes := benchSeries()
+	for i := 0; i < b.N; i++ {
+		EMA(closes, 200)
+	}
+}
+
+func BenchmarkRSI(b *testing.B) {
+	_, _, closes := benchSeries()
+	for i := 0; i < b.N; i++ {
+		RSI(closes, 14)
+	}
+}
+
+func BenchmarkMACD(b *testing.B) {
+	_, _, closes := benchSeries()
+	for i := 0; i < b.N; i++ {
+		MACD(closes, 12, 26, 9)
+	}
+}
+
+func BenchmarkBollingerBands(b *testing.B) {
+	_, _, closes := benchSeries()
+	for i := 0; i < b.N; i++ {
+		BollingerBands(closes, 20, 2)
+	}
+}
+
+func BenchmarkATR(b *testing.B) {
+	highs, lows, closes := benchSeries()
+	for i := 0; i < b.N; i++ {
+		ATR(highs, lows, closes, 14)
+	}
+}
+
+func BenchmarkStochastic(b *testing.B) {
+	highs, lows, closes := benchSeries()
+	for i := 0; i < b.N; i++ {
+		Stochastic(highs, lows, closes, 14, 3)
+	}
+}
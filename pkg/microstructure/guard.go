@@ -0,0 +1,49 @@
+package microstructure
+
+import (
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Guard vetoes strategy orders that trade against the book. It satisfies
+// strategy.SignalFilter, so it can be installed with SetSignalFilter on
+// grid and DCA strategies:
+//
+//	tracker := microstructure.NewTracker(microstructure.Config{MaxToxicity: 0.8})
+//	go tracker.Watch(ctx, exchange, "BTCUSDT", 5*time.Second, nil)
+//	grid.SetSignalFilter(microstructure.NewGuard(tracker, 30*time.Second))
+type Guard struct {
+	tracker *Tracker
+	maxAge  time.Duration
+	now     func() time.Time
+}
+
+// NewGuard creates a guard over tracker; snapshots older than maxAge are
+// ignored (0 accepts any age)
+func NewGuard(tracker *Tracker, maxAge time.Duration) *Guard {
+	return &Guard{tracker: tracker, maxAge: maxAge, now: time.Now}
+}
+
+// Observe is a no-op; the tracker is fed from order book snapshots
+func (g *Guard) Observe(market types.MarketData) {}
+
+// Filter vetoes buys on a SELL book signal, sells on a BUY book signal and
+// any order while flow toxicity is above the tracker's MaxToxicity
+func (g *Guard) Filter(signal types.Signal, market types.MarketData) (types.Signal, bool, error) {
+	snap, ok := g.tracker.Last()
+	if !ok || (g.maxAge > 0 && g.now().Sub(snap.Time) > g.maxAge) {
+		return signal, true, nil
+	}
+
+	if max := g.tracker.cfg.MaxToxicity; max > 0 && snap.Toxicity > max {
+		return signal, false, nil
+	}
+	switch {
+	case signal.Type == types.SignalTypeBuy && snap.Signal.Type == types.SignalTypeSell:
+		return signal, false, nil
+	case signal.Type == types.SignalTypeSell && snap.Signal.Type == types.SignalTypeBuy:
+		return signal, false, nil
+	}
+	return signal, true, nil
+}
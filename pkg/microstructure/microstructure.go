@@ -0,0 +1,130 @@
+// Package microstructure derives short-horizon signals from order book snapshots:
+// imbalance, spread, depth-weighted mid and order-flow toxicity.
+package microstructure
+
+import (
+	"math"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Mid returns the midpoint of the best bid and ask, or 0 if either side is empty
+func Mid(book *types.OrderBook) float64 {
+	bid, ask, ok := bestQuotes(book)
+	if !ok {
+		return 0
+	}
+	return (bid.Price + ask.Price) / 2
+}
+
+// Spread returns the best ask minus the best bid, or 0 if either side is empty
+func Spread(book *types.OrderBook) float64 {
+	bid, ask, ok := bestQuotes(book)
+	if !ok {
+		return 0
+	}
+	return ask.Price - bid.Price
+}
+
+// SpreadBps returns the spread in basis points of the mid
+func SpreadBps(book *types.OrderBook) float64 {
+	mid := Mid(book)
+	if mid == 0 {
+		return 0
+	}
+	return Spread(book) / mid * 1e4
+}
+
+// Imbalance returns (bid depth - ask depth) / total depth over the top levels,
+// in [-1, 1]. Positive values mean more resting buy interest.
+// levels <= 0 uses the whole book.
+func Imbalance(book *types.OrderBook, levels int) float64 {
+	bidDepth, _ := depth(book.Bids, levels)
+	askDepth, _ := depth(book.Asks, levels)
+	total := bidDepth + askDepth
+	if total == 0 {
+		return 0
+	}
+	return (bidDepth - askDepth) / total
+}
+
+// DepthWeightedMid returns the mid of the bid and ask VWAPs over the top levels,
+// each weighted by the opposite side's depth, so it leans toward the thinner
+// side (where price is more likely to move). With one level this is the microprice.
+func DepthWeightedMid(book *types.OrderBook, levels int) float64 {
+	bidDepth, bidNotional := depth(book.Bids, levels)
+	askDepth, askNotional := depth(book.Asks, levels)
+	if bidDepth == 0 || askDepth == 0 {
+		return Mid(book)
+	}
+	bidVWAP := bidNotional / bidDepth
+	askVWAP := askNotional / askDepth
+	return (bidVWAP*askDepth + askVWAP*bidDepth) / (bidDepth + askDepth)
+}
+
+// OrderFlowImbalance returns the best-level order flow between two snapshots
+// (Cont, Kukanov and Stoikov): bid queue growth or an improving bid counts as
+// buy pressure, ask queue growth or an improving ask as sell pressure.
+func OrderFlowImbalance(prev, curr *types.OrderBook) float64 {
+	pb, pa, ok := bestQuotes(prev)
+	if !ok {
+		return 0
+	}
+	cb, ca, ok := bestQuotes(curr)
+	if !ok {
+		return 0
+	}
+
+	var flow float64
+	switch {
+	case cb.Price > pb.Price:
+		flow += cb.Amount
+	case cb.Price == pb.Price:
+		flow += cb.Amount - pb.Amount
+	default:
+		flow -= pb.Amount
+	}
+	switch {
+	case ca.Price < pa.Price:
+		flow -= ca.Amount
+	case ca.Price == pa.Price:
+		flow -= ca.Amount - pa.Amount
+	default:
+		flow += pa.Amount
+	}
+	return flow
+}
+
+// Toxicity returns |sum(flows)| / sum(|flows|) in [0, 1]: the share of order
+// flow that is one-sided. High values mean informed flow that tends to run
+// over resting quotes (a snapshot-based analogue of VPIN).
+func Toxicity(flows []float64) float64 {
+	var net, gross float64
+	for _, f := range flows {
+		net += f
+		gross += math.Abs(f)
+	}
+	if gross == 0 {
+		return 0
+	}
+	return math.Abs(net) / gross
+}
+
+func bestQuotes(book *types.OrderBook) (types.OrderBookEntry, types.OrderBookEntry, bool) {
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return types.OrderBookEntry{}, types.OrderBookEntry{}, false
+	}
+	return book.Bids[0], book.Asks[0], true
+}
+
+// depth sums amount and notional over the top levels
+func depth(entries []types.OrderBookEntry, levels int) (amount, notional float64) {
+	if levels <= 0 || levels > len(entries) {
+		levels = len(entries)
+	}
+	for _, e := range entries[:levels] {
+		amount += e.Amount
+		notional += e.Amount * e.Price
+	}
+	return amount, notional
+}
@@ -0,0 +1,169 @@
+package microstructure
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/internal/exchange/mock"
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+func book(bids, asks [][2]float64) *types.OrderBook {
+	b := &types.OrderBook{Symbol: "BTCUSDT"}
+	for _, l := range bids {
+		b.Bids = append(b.Bids, types.OrderBookEntry{Price: l[0], Amount: l[1]})
+	}
+	for _, l := range asks {
+		b.Asks = append(b.Asks, types.OrderBookEntry{Price: l[0], Amount: l[1]})
+	}
+	return b
+}
+
+func approx(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestBookMetrics(t *testing.T) {
+	b := book([][2]float64{{99, 3}, {98, 1}}, [][2]float64{{101, 1}, {102, 1}})
+
+	approx(t, "Mid", Mid(b), 100)
+	approx(t, "Spread", Spread(b), 2)
+	approx(t, "SpreadBps", SpreadBps(b), 200)
+	approx(t, "Imbalance top", Imbalance(b, 1), 0.5)
+	approx(t, "Imbalance all", Imbalance(b, 0), (4.0-2.0)/6.0)
+
+	// Microprice leans toward the thin ask side
+	approx(t, "DepthWeightedMid top", DepthWeightedMid(b, 1), (99*1+101*3)/4.0)
+	bidVWAP, askVWAP := (99*3+98*1)/4.0, (101+102)/2.0
+	approx(t, "DepthWeightedMid", DepthWeightedMid(b, 2), (bidVWAP*2+askVWAP*4)/6)
+
+	empty := book(nil, [][2]float64{{101, 1}})
+	if Mid(empty) != 0 || Spread(empty) != 0 || Imbalance(empty, 5) != -1 {
+		t.Errorf("unexpected metrics for one-sided book")
+	}
+}
+
+func TestOrderFlowAndToxicity(t *testing.T) {
+	prev := book([][2]float64{{99, 2}}, [][2]float64{{101, 2}})
+
+	tests := []struct {
+		name string
+		curr *types.OrderBook
+		want float64
+	}{
+		{"bid queue grows", book([][2]float64{{99, 5}}, [][2]float64{{101, 2}}), 3},
+		{"bid improves", book([][2]float64{{100, 1}}, [][2]float64{{101, 2}}), 1},
+		{"ask lifted", book([][2]float64{{99, 2}}, [][2]float64{{102, 4}}), 2},
+		{"ask improves", book([][2]float64{{99, 2}}, [][2]float64{{100, 1}}), -1},
+		{"bid dropped", book([][2]float64{{98, 7}}, [][2]float64{{101, 2}}), -2},
+	}
+	for _, tt := range tests {
+		approx(t, tt.name, OrderFlowImbalance(prev, tt.curr), tt.want)
+	}
+
+	approx(t, "one-sided", Toxicity([]float64{1, 2, 3}), 1)
+	approx(t, "balanced", Toxicity([]float64{1, -1, 2, -2}), 0)
+	approx(t, "empty", Toxicity(nil), 0)
+}
+
+func TestTrackerSignalsAndSubscribers(t *testing.T) {
+	tracker := NewTracker(Config{Depth: 1, Window: 3, ImbalanceThreshold: 0.4, MaxToxicity: 0.9})
+
+	var got []Snapshot
+	unsubscribe := tracker.Subscribe(func(s Snapshot) { got = append(got, s) })
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	snap := tracker.Update(book([][2]float64{{99, 4}}, [][2]float64{{101, 1}}), base)
+	if snap.Signal.Type != types.SignalTypeBuy || snap.Signal.Strength != 0.6 {
+		t.Fatalf("expected BUY with strength 0.6, got %+v", snap.Signal)
+	}
+
+	// Bid keeps growing: all flow one-sided, so toxicity forces HOLD
+	snap = tracker.Update(book([][2]float64{{99, 6}}, [][2]float64{{101, 1}}), base.Add(time.Second))
+	if snap.Toxicity != 1 || snap.Signal.Type != types.SignalTypeHold {
+		t.Fatalf("expected toxic HOLD, got toxicity %v signal %s", snap.Toxicity, snap.Signal.Type)
+	}
+
+	unsubscribe()
+	snap = tracker.Update(book([][2]float64{{99, 1}}, [][2]float64{{101, 4}}), base.Add(2*time.Second))
+	if len(got) != 2 {
+		t.Fatalf("subscriber got %d snapshots, want 2", len(got))
+	}
+	if snap.Signal.Type != types.SignalTypeSell || snap.Toxicity >= 0.9 {
+		t.Fatalf("expected SELL after balanced flow, got %+v (toxicity %v)", snap.Signal, snap.Toxicity)
+	}
+	if last, ok := tracker.Last(); !ok || !last.Time.Equal(snap.Time) {
+		t.Fatalf("Last = %+v, %v", last, ok)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	tracker := NewTracker(Config{Depth: 1, ImbalanceThreshold: 0.4})
+	guard := NewGuard(tracker, time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+
+	buy := types.Signal{Type: types.SignalTypeBuy, Quantity: 1}
+	sell := types.Signal{Type: types.SignalTypeSell, Quantity: 1}
+	if _, ok, _ := guard.Filter(buy, types.MarketData{}); !ok {
+		t.Fatalf("guard without snapshots should pass orders")
+	}
+
+	// Ask-heavy book: SELL signal
+	tracker.Update(book([][2]float64{{99, 1}}, [][2]float64{{101, 5}}), now)
+	if _, ok, _ := guard.Filter(buy, types.MarketData{}); ok {
+		t.Errorf("buy into a SELL book should be vetoed")
+	}
+	if _, ok, _ := guard.Filter(sell, types.MarketData{}); !ok {
+		t.Errorf("sell with the book should pass")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := guard.Filter(buy, types.MarketData{}); !ok {
+		t.Errorf("stale snapshot should be ignored")
+	}
+}
+
+// failingBook returns an error from GetOrderBook
+type failingBook struct{ *mock.MockClient }
+
+func (failingBook) GetOrderBook(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	return nil, errors.New("boom")
+}
+
+func TestWatch(t *testing.T) {
+	tracker := NewTracker(Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan Snapshot, 1)
+	tracker.Subscribe(func(s Snapshot) {
+		select {
+		case updates <- s:
+		default:
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- tracker.Watch(ctx, mock.NewMockClient(), "BTCUSDT", time.Millisecond, nil) }()
+	snap := <-updates
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Watch = %v, want context.Canceled", err)
+	}
+	if snap.Symbol != "BTCUSDT" || snap.Mid != 45000 {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+
+	var errs []error
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_ = NewTracker(Config{}).Watch(ctx, failingBook{mock.NewMockClient()}, "BTCUSDT", 5*time.Millisecond, func(err error) { errs = append(errs, err) })
+	if len(errs) == 0 {
+		t.Fatalf("expected fetch errors to be reported")
+	}
+}
@@ -0,0 +1,215 @@
+package microstructure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zmey56/crypto-arbitrage-trader/pkg/types"
+)
+
+// Config controls how a Tracker turns snapshots into signals
+type Config struct {
+	Depth              int     // book levels used for imbalance and depth-weighted mid (default 10)
+	Window             int     // snapshots in the toxicity window (default 50)
+	ImbalanceThreshold float64 // |imbalance| needed for a BUY/SELL signal (default 0.3)
+	MaxToxicity        float64 // toxicity above this forces HOLD; 0 disables
+}
+
+// DefaultConfig returns the defaults used for zero Config fields
+func DefaultConfig() Config {
+	return Config{Depth: 10, Window: 50, ImbalanceThreshold: 0.3}
+}
+
+// Snapshot holds the metrics computed from one order book update
+type Snapshot struct {
+	Symbol           string
+	Time             time.Time
+	Mid              float64
+	Spread           float64
+	SpreadBps        float64
+	Imbalance        float64
+	DepthWeightedMid float64
+	OrderFlow        float64 // flow since the previous snapshot
+	Toxicity         float64 // over the last Window snapshots
+	Signal           types.Signal
+}
+
+// Tracker maintains rolling microstructure state for one symbol and fans
+// snapshots out to subscribers
+type Tracker struct {
+	mu          sync.Mutex
+	cfg         Config
+	prev        *types.OrderBook
+	flows       []float64 // ring buffer of order flow
+	next        int
+	filled      bool
+	last        *Snapshot
+	subscribers map[int]func(Snapshot)
+	nextID      int
+}
+
+// NewTracker creates a tracker, filling zero config fields with defaults
+func NewTracker(cfg Config) *Tracker {
+	def := DefaultConfig()
+	if cfg.Depth <= 0 {
+		cfg.Depth = def.Depth
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = def.Window
+	}
+	if cfg.ImbalanceThreshold <= 0 {
+		cfg.ImbalanceThreshold = def.ImbalanceThreshold
+	}
+	return &Tracker{
+		cfg:         cfg,
+		flows:       make([]float64, cfg.Window),
+		subscribers: make(map[int]func(Snapshot)),
+	}
+}
+
+// Subscribe registers fn for every snapshot and returns a function that removes it.
+// fn runs synchronously in Update and must not call back into the tracker.
+func (t *Tracker) Subscribe(fn func(Snapshot)) (unsubscribe func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := t.nextID
+	t.nextID++
+	t.subscribers[id] = fn
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.subscribers, id)
+	}
+}
+
+// Last returns the most recent snapshot
+func (t *Tracker) Last() (Snapshot, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.last == nil {
+		return Snapshot{}, false
+	}
+	return *t.last, true
+}
+
+// Update computes a snapshot from book, notifies subscribers and returns it
+func (t *Tracker) Update(book *types.OrderBook, at time.Time) Snapshot {
+	t.mu.Lock()
+
+	snap := Snapshot{
+		Symbol:           book.Symbol,
+		Time:             at,
+		Mid:              Mid(book),
+		Spread:           Spread(book),
+		SpreadBps:        SpreadBps(book),
+		Imbalance:        Imbalance(book, t.cfg.Depth),
+		DepthWeightedMid: DepthWeightedMid(book, t.cfg.Depth),
+	}
+	if t.prev != nil {
+		snap.OrderFlow = OrderFlowImbalance(t.prev, book)
+		t.flows[t.next] = snap.OrderFlow
+		t.next = (t.next + 1) % len(t.flows)
+		if t.next == 0 {
+			t.filled = true
+		}
+	}
+	if t.filled {
+		snap.Toxicity = Toxicity(t.flows)
+	} else {
+		snap.Toxicity = Toxicity(t.flows[:t.next])
+	}
+	snap.Signal = t.signal(snap)
+
+	t.prev = topOfBook(book)
+	t.last = &snap
+
+	subscribers := make([]func(Snapshot), 0, len(t.subscribers))
+	for _, fn := range t.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	t.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(snap)
+	}
+	return snap
+}
+
+// signal maps a snapshot to BUY/SELL on strong imbalance, HOLD otherwise
+func (t *Tracker) signal(s Snapshot) types.Signal {
+	signal := types.Signal{
+		Type:      types.SignalTypeHold,
+		Symbol:    s.Symbol,
+		Price:     s.DepthWeightedMid,
+		Timestamp: s.Time,
+		Metadata: map[string]interface{}{
+			"mid":                s.Mid,
+			"spread_bps":         s.SpreadBps,
+			"imbalance":          s.Imbalance,
+			"depth_weighted_mid": s.DepthWeightedMid,
+			"toxicity":           s.Toxicity,
+		},
+	}
+
+	if t.cfg.MaxToxicity > 0 && s.Toxicity > t.cfg.MaxToxicity {
+		return signal
+	}
+	switch {
+	case s.Imbalance >= t.cfg.ImbalanceThreshold:
+		signal.Type = types.SignalTypeBuy
+	case s.Imbalance <= -t.cfg.ImbalanceThreshold:
+		signal.Type = types.SignalTypeSell
+	default:
+		return signal
+	}
+	if s.Imbalance < 0 {
+		signal.Strength = -s.Imbalance
+	} else {
+		signal.Strength = s.Imbalance
+	}
+	return signal
+}
+
+// Watch polls the order book every interval and feeds the tracker until ctx is done.
+// Fetch errors are reported to onError (if set) and polling continues.
+func (t *Tracker) Watch(ctx context.Context, exchange types.ExchangeClient, symbol string, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		book, err := exchange.GetOrderBook(ctx, symbol, t.cfg.Depth)
+		switch {
+		case err != nil:
+			if onError != nil && ctx.Err() == nil {
+				onError(fmt.Errorf("failed to get order book for %s: %w", symbol, err))
+			}
+		case book != nil:
+			if book.Symbol == "" {
+				book.Symbol = symbol
+			}
+			t.Update(book, time.Now())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// topOfBook copies the best levels, all OrderFlowImbalance needs from the previous book
+func topOfBook(book *types.OrderBook) *types.OrderBook {
+	top := &types.OrderBook{Symbol: book.Symbol}
+	if len(book.Bids) > 0 {
+		top.Bids = []types.OrderBookEntry{book.Bids[0]}
+	}
+	if len(book.Asks) > 0 {
+		top.Asks = []types.OrderBookEntry{book.Asks[0]}
+	}
+	return top
+}
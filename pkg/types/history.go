@@ -0,0 +1,106 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// OrderHistoryQuery selects a page of filled orders
+type OrderHistoryQuery struct {
+	Symbol string
+	Start  time.Time // orders placed at or after this; zero from the start of history
+	End    time.Time // orders placed at or before this; zero up to now
+	Cursor string    // Next of the previous page; "" for the first page
+	Limit  int       // page size; 0 for the exchange's maximum
+}
+
+// Contains reports whether t falls within the query's time range
+func (q OrderHistoryQuery) Contains(t time.Time) bool {
+	return (q.Start.IsZero() || !t.Before(q.Start)) && (q.End.IsZero() || !t.After(q.End))
+}
+
+// OrderHistoryPage is one page of filled orders, oldest first. A page may
+// be empty and still have a next page.
+type OrderHistoryPage struct {
+	Orders []Order
+	Next   string // cursor of the next page; "" after the last
+}
+
+// PageOrders pages an in-memory order history, oldest first, for exchanges
+// that keep their orders in memory. Its cursors are offsets into orders.
+func PageOrders(orders []Order, query OrderHistoryQuery) (*OrderHistoryPage, error) {
+	offset := 0
+	if query.Cursor != "" {
+		n, err := strconv.Atoi(query.Cursor)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid order history cursor %q", query.Cursor)
+		}
+		offset = n
+	}
+
+	page := &OrderHistoryPage{}
+	for i := offset; i < len(orders); i++ {
+		if query.Limit > 0 && len(page.Orders) == query.Limit {
+			page.Next = strconv.Itoa(i)
+			break
+		}
+		order := orders[i]
+		if (query.Symbol == "" || order.Symbol == query.Symbol) && query.Contains(order.Timestamp) {
+			page.Orders = append(page.Orders, order)
+		}
+	}
+	return page, nil
+}
+
+// OrderByClientID finds the newest of orders placed with clientOrderID, for
+// exchanges that keep their orders in memory
+func OrderByClientID(orders []Order, clientOrderID string) (*Order, error) {
+	for i := len(orders) - 1; i >= 0; i-- {
+		if o := orders[i]; o.ExchangeOrder != nil && o.ExchangeOrder.ClientOrderID == clientOrderID {
+			return &o, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: client id %s", ErrOrderNotFound, clientOrderID)
+}
+
+// FilledOrders returns every filled order matching query, oldest first, by
+// following the history's pages from query.Cursor. Exchanges without paged
+// history fall back to their recent filled orders within the time range.
+func FilledOrders(ctx context.Context, client ExchangeClient, query OrderHistoryQuery) ([]Order, error) {
+	var orders []Order
+	for {
+		page, err := client.GetOrderHistory(ctx, query)
+		if errors.Is(err, ErrNotSupported) && orders == nil {
+			return recentFilledOrders(ctx, client, query)
+		}
+		if err != nil {
+			return orders, fmt.Errorf("failed to get order history: %w", err)
+		}
+		orders = append(orders, page.Orders...)
+		if page.Next == "" {
+			return orders, nil
+		}
+		if page.Next == query.Cursor {
+			return orders, fmt.Errorf("order history stuck at cursor %q", page.Next)
+		}
+		query.Cursor = page.Next
+	}
+}
+
+// recentFilledOrders filters GetFilledOrders to the query's time range
+func recentFilledOrders(ctx context.Context, client ExchangeClient, query OrderHistoryQuery) ([]Order, error) {
+	recent, err := client.GetFilledOrders(ctx, query.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	var orders []Order
+	for _, order := range recent {
+		if query.Contains(order.Timestamp) {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
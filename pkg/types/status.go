@@ -0,0 +1,222 @@
+package types
+
+import "time"
+
+// StrategyStatus is a strategy's current state, as served by
+// /strategy/status and saved in bot snapshots. Every strategy sets the
+// common fields; the extension of its type carries the rest.
+type StrategyStatus struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type"` // dca, grid, combo, breakout or a registered type
+	Symbol  string `json:"symbol,omitempty"`
+	Enabled bool   `json:"enabled"`
+	Account string `json:"account,omitempty"` // exchange account of a combo sub-strategy; "" for the main one
+
+	// Throttle reports order counts and cooldowns when a throttle is set
+	Throttle map[string]interface{} `json:"throttle,omitempty"`
+
+	// Data reports the candles of strategies deciding on them
+	Data *DataHealth `json:"data,omitempty"`
+
+	DCA      *DCAStatus      `json:"dca,omitempty"`
+	Grid     *GridStatus     `json:"grid,omitempty"`
+	Combo    *ComboStatus    `json:"combo,omitempty"`
+	Breakout *BreakoutStatus `json:"breakout,omitempty"`
+
+	// Extra is the free-form status of registered strategy types
+	Extra map[string]interface{} `json:"extra,omitempty"`
+}
+
+// DCAStatus is the schedule of a DCA strategy. In withdraw mode its orders
+// are sells.
+type DCAStatus struct {
+	Mode             string    `json:"mode"` // DCAModeAccumulate or DCAModeWithdraw
+	Orders           int       `json:"orders"`
+	MaxOrders        int       `json:"max_orders"`
+	LastOrder        time.Time `json:"last_order"`
+	NextOrder        time.Time `json:"next_order"`
+	Interval         string    `json:"interval"`
+	InvestmentAmount float64   `json:"investment_amount"`
+
+	// Volatility targeting, when configured
+	VolScale    float64 `json:"vol_scale,omitempty"`
+	RealizedVol float64 `json:"realized_vol,omitempty"`
+
+	// Exit ladder, when configured
+	ExitTargetsHit int     `json:"exit_targets_hit,omitempty"`
+	StopPrice      float64 `json:"stop_price,omitempty"`
+
+	// Open lots, when exits are tax-aware
+	TaxLots []TaxLot `json:"tax_lots,omitempty"`
+
+	Withdrawal *WithdrawalStatus `json:"withdrawal,omitempty"`
+	Funding    *FundingStatus    `json:"funding,omitempty"`
+	Execution  *ExecutionStatus  `json:"execution,omitempty"`
+	Savings    *SavingsStatus    `json:"savings,omitempty"`
+}
+
+// SavingsStatus is what a DCA keeps in flexible savings between buys
+type SavingsStatus struct {
+	Asset       string  `json:"asset"`
+	Parked      float64 `json:"parked"`     // principal the strategy has in savings
+	Subscribed  float64 `json:"subscribed"` // total moved into savings
+	Redeemed    float64 `json:"redeemed"`   // total taken out for buys
+	APR         float64 `json:"apr,omitempty"`
+	Interest    float64 `json:"interest,omitempty"` // credited to the account's savings position
+	Unavailable bool    `json:"unavailable,omitempty"`
+}
+
+// ExecutionStatus is how a DCA's buys filled, as maker or taker
+type ExecutionStatus struct {
+	Style       string  `json:"style"`
+	Chasing     bool    `json:"chasing"`      // a limit buy is working
+	MakerVolume float64 `json:"maker_volume"` // quote filled by resting limit orders
+	TakerVolume float64 `json:"taker_volume"` // quote filled crossing the spread
+	MakerRatio  float64 `json:"maker_ratio"`  // maker share of the volume
+}
+
+// WithdrawalStatus is the progress of a withdraw mode DCA
+type WithdrawalStatus struct {
+	SoldQuantity float64 `json:"sold_quantity"`
+	Proceeds     float64 `json:"proceeds"`
+	AvgSellPrice float64 `json:"avg_sell_price,omitempty"`
+	Remaining    float64 `json:"remaining,omitempty"`    // of the configured holdings
+	RealizedPnL  float64 `json:"realized_pnl,omitempty"` // against the configured cost basis
+}
+
+// FundingStatus totals the conversions that funded DCA buys from another asset
+type FundingStatus struct {
+	Asset        string  `json:"asset"`
+	Conversions  int     `json:"conversions"`
+	Spent        float64 `json:"spent"`    // of the funding asset
+	Received     float64 `json:"received"` // of the quote asset
+	Fees         float64 `json:"fees"`
+	SlippageCost float64 `json:"slippage_cost"`
+}
+
+// GridStatus is the positions of a grid strategy
+type GridStatus struct {
+	Levels        int         `json:"levels"`
+	LevelsHeld    int         `json:"levels_held"`
+	Prices        []float64   `json:"prices,omitempty"` // every level, lowest first
+	OpenLevels    []GridLevel `json:"open_levels"`      // lowest first
+	PendingOrders int         `json:"pending_orders,omitempty"`
+	DustQuantity  float64     `json:"dust_quantity,omitempty"`
+	DustCost      float64     `json:"dust_cost,omitempty"`
+
+	LevelStats []GridLevelStats `json:"level_stats,omitempty"` // levels that traded, lowest first
+
+	// Multi-venue grids only
+	Venues    []GridVenue     `json:"venues,omitempty"`
+	Transfers []VenueTransfer `json:"transfers,omitempty"` // needed for a side to trade again
+}
+
+// GridVenue is a venue of a multi-venue grid as last seen when routing an order
+type GridVenue struct {
+	Venue string  `json:"venue"`
+	Base  float64 `json:"base"`  // free base asset
+	Quote float64 `json:"quote"` // free quote asset
+	Bid   float64 `json:"bid"`
+	Ask   float64 `json:"ask"`
+	Buys  int     `json:"buys"` // orders routed to the venue
+	Sells int     `json:"sells"`
+}
+
+// VenueTransfer moves inventory between venues so a side can trade again
+type VenueTransfer struct {
+	Asset    string  `json:"asset"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Quantity float64 `json:"quantity"` // withdrawn, fee included
+	Fee      float64 `json:"fee"`
+}
+
+// GridLevelStats is what a grid level has traded since the strategy started,
+// including fills recovered after a restart
+type GridLevelStats struct {
+	Level      float64 `json:"level"`
+	Buys       int     `json:"buys"`        // buy fills
+	Sells      int     `json:"sells"`       // sell and exit fills
+	RoundTrips int     `json:"round_trips"` // positions bought and sold out completely
+	Volume     float64 `json:"volume"`      // quote traded
+	Profit     float64 `json:"profit"`      // realized PnL
+}
+
+// GridLevel is a grid level holding a position
+type GridLevel struct {
+	Level    float64 `json:"level"`
+	Quantity float64 `json:"quantity"`
+	AvgPrice float64 `json:"avg_price"`
+}
+
+// BreakoutStatus is the channel of a breakout strategy and the position it
+// holds. The channel is over completed bars; zero until enough have closed.
+type BreakoutStatus struct {
+	Interval    string  `json:"interval"`
+	EntryPeriod int     `json:"entry_period"`
+	ExitPeriod  int     `json:"exit_period"`
+	Upper       float64 `json:"upper"` // highest high of the entry channel
+	Lower       float64 `json:"lower"` // lowest low of the exit channel
+	ATR         float64 `json:"atr,omitempty"`
+	Quantity    float64 `json:"quantity"`
+	EntryPrice  float64 `json:"entry_price,omitempty"`
+	StopPrice   float64 `json:"stop_price,omitempty"` // trailing stop, when configured
+	Pending     bool    `json:"pending,omitempty"`    // an order's fill is not reported yet
+}
+
+// Market data health states
+const (
+	DataHealthy     = "healthy"     // candles are current
+	DataDegraded    = "degraded"    // the exchange failed; cached candles at most a bar behind are used
+	DataUnavailable = "unavailable" // no usable candles; the strategy holds
+)
+
+// Market data sources
+const (
+	DataSourceCache    = "cache"
+	DataSourceExchange = "exchange"
+)
+
+// DataHealth is the state of the candles a strategy decides on
+type DataHealth struct {
+	Symbol    string    `json:"symbol"`
+	Interval  string    `json:"interval"`
+	State     string    `json:"state"`
+	Source    string    `json:"source,omitempty"`     // where the last decision's candles came from
+	UpdatedAt time.Time `json:"updated_at,omitempty"` // last successful fetch
+	Failures  int       `json:"failures,omitempty"`   // fetches failed in a row
+	Error     string    `json:"error,omitempty"`      // of the last failed fetch
+}
+
+// ComboStatus is the sub-strategies of a combo strategy, in config order
+type ComboStatus struct {
+	Strategies      []StrategyStatus   `json:"strategies"`
+	Weights         []float64          `json:"weights"`
+	TotalTrades     int                `json:"total_trades"`
+	WinRate         float64            `json:"win_rate"`
+	LastUpdate      time.Time          `json:"last_update"`
+	CapitalAccounts []CapitalAccount   `json:"capital_accounts,omitempty"`
+	Arbitration     *ArbitrationStatus `json:"arbitration,omitempty"`
+}
+
+// ArbitrationStatus reports the last execution's ranking of sub-strategies
+// and the buys turned down for lack of budget
+type ArbitrationStatus struct {
+	Ranking  []string       `json:"ranking"` // sub-strategy ids in execution order
+	Scores   []float64      `json:"scores"`
+	Budget   float64        `json:"budget"` // quote balance left for buys
+	Rejected map[string]int `json:"rejected,omitempty"`
+}
+
+// CapitalAccount is the virtual balance of a sub-strategy with a dedicated
+// allocation
+type CapitalAccount struct {
+	StrategyID string             `json:"strategy_id"`
+	Allocated  float64            `json:"allocated"`
+	Available  float64            `json:"available"`
+	Holdings   map[string]float64 `json:"holdings"` // base quantity bought per symbol
+	Spent      float64            `json:"spent"`
+	Proceeds   float64            `json:"proceeds"`
+	LastUpdate time.Time          `json:"last_update"`
+}
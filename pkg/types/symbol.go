@@ -0,0 +1,59 @@
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// knownQuotes lists quote assets recognised when splitting symbols (longest first)
+var knownQuotes = []string{
+	"FDUSD", "USDT", "USDC", "BUSD", "TUSD",
+	"USD", "EUR", "GBP", "TRY", "BTC", "ETH", "BNB",
+}
+
+// SplitSymbol splits a concatenated symbol like BTCUSDT into base and quote assets
+func SplitSymbol(symbol string) (base, quote string, ok bool) {
+	symbol = strings.ToUpper(symbol)
+	for _, q := range knownQuotes {
+		if len(symbol) > len(q) && strings.HasSuffix(symbol, q) {
+			return symbol[:len(symbol)-len(q)], q, true
+		}
+	}
+	return "", "", false
+}
+
+// SymbolRules are an exchange's order size constraints for a symbol. Zero
+// fields impose no constraint.
+type SymbolRules struct {
+	Symbol      string
+	StepSize    float64 // quantities are multiples of this
+	MinQty      float64
+	MinNotional float64 // minimum quantity × price, in the quote asset
+	TickSize    float64
+	Halted      bool // listed but not open for trading, e.g. delisting or a break
+}
+
+// RoundQuantity rounds quantity down to the step size
+func (r SymbolRules) RoundQuantity(quantity float64) float64 {
+	if r.StepSize <= 0 || quantity <= 0 {
+		return quantity
+	}
+	// The epsilon absorbs float error such as 0.3/0.1 = 2.9999999999999996
+	steps := math.Floor(quantity/r.StepSize + 1e-9)
+	return math.Round(steps*r.StepSize*1e12) / 1e12
+}
+
+// Tradable reports whether quantity, rounded to the step size, can be
+// ordered at price
+func (r SymbolRules) Tradable(quantity, price float64) bool {
+	quantity = r.RoundQuantity(quantity)
+	if quantity <= 0 || quantity < r.MinQty {
+		return false
+	}
+	return r.MinNotional <= 0 || price <= 0 || quantity*price >= r.MinNotional
+}
+
+// IsDust reports whether a holding of quantity is too small to sell at price
+func (r SymbolRules) IsDust(quantity, price float64) bool {
+	return quantity > 0 && !r.Tradable(quantity, price)
+}
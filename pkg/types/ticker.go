@@ -0,0 +1,36 @@
+package types
+
+import (
+	"context"
+	"fmt"
+)
+
+// FetchTickers gets tickers one symbol at a time with get, for exchanges
+// without a bulk ticker endpoint. It stops at the first failure.
+func FetchTickers(ctx context.Context, symbols []string, get func(ctx context.Context, symbol string) (*Ticker, error)) ([]Ticker, error) {
+	tickers := make([]Ticker, 0, len(symbols))
+	for _, symbol := range symbols {
+		ticker, err := get(ctx, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		tickers = append(tickers, *ticker)
+	}
+	return tickers, nil
+}
+
+// TickersBySymbol indexes tickers by their symbol
+func TickersBySymbol(tickers []Ticker) map[string]Ticker {
+	bySymbol := make(map[string]Ticker, len(tickers))
+	for _, ticker := range tickers {
+		bySymbol[ticker.Symbol] = ticker
+	}
+	return bySymbol
+}
+
+// VenueQuote is the best bid and ask a venue quotes for a symbol
+type VenueQuote struct {
+	Venue string
+	Bid   float64
+	Ask   float64
+}
@@ -3,7 +3,10 @@ package types
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +19,52 @@ type MarketData struct {
 	Ticker    *Ticker
 	OrderBook *OrderBook
 	Candles   []Candle
+
+	// Context holds configured higher-timeframe data (nil when none is configured)
+	Context *MarketContext
+
+	// Sentiment is the symbol's recorded sentiment (nil when none is recorded)
+	Sentiment *Sentiment
+}
+
+// Sentiment is a symbol's aggregated sentiment score and how it moved
+type Sentiment struct {
+	Score      float64   // -1 (bearish) to 1 (bullish)
+	Confidence float64   // 0 to 1
+	Momentum   float64   // change in Score over the momentum window, 0 without enough history
+	Timestamp  time.Time // of the reading
+}
+
+// MarketContext holds higher-timeframe candles and indicators for a symbol
+type MarketContext struct {
+	Timeframes map[string]*Timeframe // keyed by interval ("1h", "4h")
+}
+
+// Timeframe is the recent candles of one interval and the latest values of
+// indicators computed over them
+type Timeframe struct {
+	Interval   string
+	Candles    []Candle           // oldest first; the last candle may still be forming
+	Indicators map[string]float64 // keyed like "sma_50", "rsi_14"
+	UpdatedAt  time.Time
+}
+
+// Timeframe returns the data for interval, or nil when it is not available
+func (c *MarketContext) Timeframe(interval string) *Timeframe {
+	if c == nil {
+		return nil
+	}
+	return c.Timeframes[interval]
+}
+
+// Indicator returns the latest value of the named indicator on interval
+func (c *MarketContext) Indicator(interval, name string) (float64, bool) {
+	tf := c.Timeframe(interval)
+	if tf == nil {
+		return 0, false
+	}
+	value, ok := tf.Indicators[name]
+	return value, ok
 }
 
 // Ticker represents current quote
@@ -65,8 +114,36 @@ type Order struct {
 	FilledPrice   float64
 	Timestamp     time.Time
 	ExchangeOrder *ExchangeOrder
+
+	// Decision records why a strategy placed the order; it is journaled so a
+	// restarted strategy can rebuild its state
+	Decision *Decision
 }
 
+// Decision describes the strategy decision behind an order
+type Decision struct {
+	Strategy   string  `json:"strategy"`              // strategy type, e.g. "dca"
+	StrategyID string  `json:"strategy_id,omitempty"` // instance, e.g. "dca-eth"
+	Action     string  `json:"action"`                // DecisionBuy, DecisionSell, DecisionExit or DecisionConvert
+	Level      float64 `json:"level,omitempty"`       // grid level
+	Exit       string  `json:"exit,omitempty"`        // exit reason
+	Target     int     `json:"target,omitempty"`      // take-profit target index
+	Buy        int     `json:"buy,omitempty"`         // DCA buy number; the orders of a chased buy share it
+
+	// Tax is the estimated tax of a tax-aware exit and the lots it sells
+	Tax *TaxImpact `json:"tax,omitempty"`
+}
+
+// Decision actions
+const (
+	DecisionBuy  = "buy"
+	DecisionSell = "sell"
+	DecisionExit = "exit"
+
+	// DecisionConvert converts a funding asset into the quote asset
+	DecisionConvert = "convert"
+)
+
 // OrderSide represents order side
 type OrderSide string
 
@@ -101,6 +178,34 @@ type ExchangeOrder struct {
 	ClientOrderID   string
 }
 
+// maxClientOrderPrefix keeps client order ids within Binance's 36 characters
+const maxClientOrderPrefix = 16
+
+// NewClientOrderID returns a client order id unique across restarts. source
+// marks what generated it ("s" a strategy, "j" the order journal); the
+// first 16 characters of a strategy id prefix it with a dot.
+func NewClientOrderID(strategyID, source string, seq int64) string {
+	return PrefixClientOrderID(strategyID, source+strconv.FormatInt(time.Now().UnixNano(), 36)+"-"+strconv.FormatInt(seq, 36))
+}
+
+// PrefixClientOrderID prefixes id with the first 16 characters of a
+// strategy id and a dot; id is returned as is without a strategy id
+func PrefixClientOrderID(strategyID, id string) string {
+	if strategyID == "" {
+		return id
+	}
+	return strategyID[:min(len(strategyID), maxClientOrderPrefix)] + "." + id
+}
+
+// SplitClientOrderID splits a client order id made by NewClientOrderID into
+// the strategy id, "" if it has none, and the generated part
+func SplitClientOrderID(clientOrderID string) (strategyID, id string) {
+	if i := strings.LastIndexByte(clientOrderID, '.'); i >= 0 {
+		return clientOrderID[:i], clientOrderID[i+1:]
+	}
+	return "", clientOrderID
+}
+
 // OrderUpdate represents an order update
 type OrderUpdate struct {
 	OrderID       string
@@ -128,6 +233,81 @@ type TradingFees struct {
 	Timestamp time.Time
 }
 
+// FundingRate represents a perpetual futures funding rate
+type FundingRate struct {
+	Symbol          string
+	Rate            float64       // paid by longs to shorts per interval when positive
+	Interval        time.Duration // time between fundings
+	MarkPrice       float64
+	NextFundingTime time.Time
+	Timestamp       time.Time
+}
+
+// BorrowRate represents a margin borrow rate for one asset
+type BorrowRate struct {
+	Asset      string
+	HourlyRate float64
+	Timestamp  time.Time
+}
+
+// DailyRate returns the borrow rate per day
+func (r BorrowRate) DailyRate() float64 {
+	return r.HourlyRate * 24
+}
+
+// Exchange system states
+const (
+	SystemNormal      = "normal"
+	SystemMaintenance = "maintenance"
+	SystemDegraded    = "degraded"
+)
+
+// SystemStatus is the operational state reported by an exchange
+type SystemStatus struct {
+	State     string // SystemNormal, SystemMaintenance or SystemDegraded
+	Message   string
+	Timestamp time.Time
+}
+
+// Operational reports whether the exchange accepts trading
+func (s SystemStatus) Operational() bool {
+	return s.State == SystemNormal
+}
+
+// SavingsBalance is an asset held in flexible savings, redeemable at any time
+type SavingsBalance struct {
+	Asset     string
+	Amount    float64 // principal and interest credited
+	APR       float64 // current annual rate, e.g. 0.05
+	Interest  float64 // interest credited so far
+	Timestamp time.Time
+}
+
+// DustConversion reports dust balances converted into the exchange's dust
+// asset, e.g. BNB on Binance
+type DustConversion struct {
+	Asset     string             // what the dust was converted into
+	Converted map[string]float64 // quantity converted by asset
+	Received  float64            // Asset received after fees
+	Fee       float64            // in Asset
+	Timestamp time.Time
+}
+
+// ErrNotSupported is returned by exchange clients for data the venue or mode
+// does not provide, e.g. funding rates on a spot-only exchange
+var ErrNotSupported = errors.New("not supported by exchange")
+
+// ErrBelowMinimum is returned for orders smaller than the symbol's minimum
+// quantity or notional
+var ErrBelowMinimum = errors.New("order below exchange minimum")
+
+// ErrUnknownSymbol is returned for symbols the exchange does not list
+var ErrUnknownSymbol = errors.New("symbol not listed on exchange")
+
+// ErrOrderNotFound is returned when the exchange has no order with the id
+// looked up
+var ErrOrderNotFound = errors.New("order not found")
+
 // Signal represents a trading signal
 type Signal struct {
 	Type      SignalType
@@ -159,8 +339,10 @@ type StrategyMetrics struct {
 	AverageWin    float64
 	AverageLoss   float64
 	ProfitFactor  float64
-	MaxDrawdown   float64
+	MaxDrawdown   float64 // percent
 	SharpeRatio   float64
+	SortinoRatio  float64
+	CalmarRatio   float64
 	TotalVolume   float64
 	LastUpdate    time.Time
 }
@@ -175,6 +357,152 @@ type DCAConfig struct {
 	StopLoss         float64       `json:"stop_loss"`
 	TakeProfit       float64       `json:"take_profit"`
 	Enabled          bool          `json:"enabled"`
+
+	// ID tells instances apart in logs, metrics, client order ids and the
+	// order journal (default "dca"); Name is its display name
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// Filter is an optional script that can veto or resize buys
+	Filter *SignalFilterConfig `json:"filter,omitempty"`
+
+	// VolTarget optionally scales each buy inversely with realized volatility
+	VolTarget *VolatilityTargetConfig `json:"vol_target,omitempty"`
+
+	// Exit optionally scales out of the accumulated position
+	Exit *ExitConfig `json:"exit,omitempty"`
+
+	// Throttle optionally limits how often the strategy trades
+	Throttle *ThrottleConfig `json:"throttle,omitempty"`
+
+	// Mode is DCAModeAccumulate (default) or DCAModeWithdraw, which sells
+	// InvestmentAmount worth of the asset every Interval instead of buying
+	Mode string `json:"mode,omitempty"`
+
+	// Holdings caps what a withdrawal sells in total (default: the free
+	// base asset balance). CostBasis is the holdings' average entry price;
+	// withdrawals book PnL against it and sell the rest at once when price
+	// reaches TakeProfit above or StopLoss below it.
+	Holdings  float64 `json:"holdings,omitempty"`
+	CostBasis float64 `json:"cost_basis,omitempty"`
+
+	// Reserve is quote asset balance buys never spend. A buy the free
+	// balance above it cannot cover is skipped, or with ReduceLastBuy
+	// shrunk to what is left.
+	Reserve       float64 `json:"reserve,omitempty"`
+	ReduceLastBuy bool    `json:"reduce_last_buy,omitempty"`
+
+	// Funding optionally pays for buys with another asset, converted into
+	// the quote asset whenever the quote balance falls short
+	Funding *FundingConfig `json:"funding,omitempty"`
+
+	// Execution sets how buys are placed (default: market orders)
+	Execution *ExecutionConfig `json:"execution,omitempty"`
+
+	// Savings optionally parks the quote asset between buys
+	Savings *SavingsConfig `json:"savings,omitempty"`
+}
+
+// SavingsConfig parks the part of a DCA's budget it has yet to invest in the
+// exchange's flexible savings, and redeems each buy's amount just before the
+// buy is due. Backtests have no savings product; they credit the idle cash
+// with Rate instead.
+type SavingsConfig struct {
+	Lead      time.Duration `json:"lead,omitempty"`       // redeem this long before a buy is due (0: on the tick it is due)
+	MinAmount float64       `json:"min_amount,omitempty"` // smallest amount subscribed or redeemed
+	Rate      float64       `json:"rate,omitempty"`       // annual yield of backtests, e.g. 0.04
+}
+
+// UnmarshalJSON implements custom parsing for durations ("1h")
+func (s *SavingsConfig) UnmarshalJSON(data []byte) error {
+	type Alias SavingsConfig
+	aux := &struct {
+		Lead string `json:"lead"`
+		*Alias
+	}{
+		Alias: (*Alias)(s),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Lead != "" {
+		duration, err := time.ParseDuration(aux.Lead)
+		if err != nil {
+			return fmt.Errorf("invalid lead format: %w", err)
+		}
+		s.Lead = duration
+	}
+	return nil
+}
+
+// ExecutionConfig sets how a DCA buy reaches the exchange. A limit chase
+// bids at the best bid, re-pegs the order to the bid every tick it has not
+// filled, and buys what is left at market after ChaseTicks ticks or
+// ChaseTimeout, whichever comes first.
+type ExecutionConfig struct {
+	Style        string        `json:"style"`                   // ExecutionMarket (default) or ExecutionLimitChase
+	ChaseTicks   int           `json:"chase_ticks,omitempty"`   // default 5
+	ChaseTimeout time.Duration `json:"chase_timeout,omitempty"` // 0 chases for ChaseTicks only
+}
+
+// Execution styles
+const (
+	ExecutionMarket     = "market"
+	ExecutionLimitChase = "limit_chase"
+)
+
+// UnmarshalJSON implements custom parsing for durations ("90s")
+func (e *ExecutionConfig) UnmarshalJSON(data []byte) error {
+	type Alias ExecutionConfig
+	aux := &struct {
+		ChaseTimeout string `json:"chase_timeout"`
+		*Alias
+	}{
+		Alias: (*Alias)(e),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.ChaseTimeout != "" {
+		duration, err := time.ParseDuration(aux.ChaseTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid chase_timeout format: %w", err)
+		}
+		e.ChaseTimeout = duration
+	}
+	return nil
+}
+
+// FundingConfig funds DCA buys from an asset other than the symbol's quote
+// asset, e.g. EURI for BTCUSDT buys, through a conversion pair
+type FundingConfig struct {
+	Asset  string `json:"asset"`  // asset held, e.g. "EURI"
+	Symbol string `json:"symbol"` // Asset+quote (sold) or quote+Asset (bought), e.g. "EURIUSDT"
+
+	// MaxSlippage is the largest conversion cost against the pair's mid
+	// price, e.g. 0.002 for 0.2%; default 0.5%
+	MaxSlippage float64 `json:"max_slippage,omitempty"`
+}
+
+// DCA modes
+const (
+	DCAModeAccumulate = "accumulate"
+	DCAModeWithdraw   = "withdraw" // reverse DCA: systematic selling
+)
+
+// VolatilityTargetConfig scales an investment by Target / realized volatility.
+// Volatility is per observation: the standard deviation of simple returns
+// ("std") or ATR divided by price ("atr").
+type VolatilityTargetConfig struct {
+	Window   int     `json:"window"`    // observations in the estimate (default 20)
+	Method   string  `json:"method"`    // "std" (default) or "atr"
+	Target   float64 `json:"target"`    // volatility at which the scale is 1
+	MinScale float64 `json:"min_scale"` // lower clamp (default 0.25)
+	MaxScale float64 `json:"max_scale"` // upper clamp (default 2)
 }
 
 // UnmarshalJSON implements custom parsing for interval
@@ -210,18 +538,263 @@ type GridConfig struct {
 	GridLevels         int     `json:"grid_levels"`
 	InvestmentPerLevel float64 `json:"investment_per_level"`
 	Enabled            bool    `json:"enabled"`
+
+	// ID and Name tell instances apart, as for DCAConfig (default "grid")
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// Filter is an optional script that can veto or resize grid orders
+	Filter *SignalFilterConfig `json:"filter,omitempty"`
+
+	// Exit optionally scales out of each level's position before the next level
+	Exit *ExitConfig `json:"exit,omitempty"`
+
+	// Throttle optionally limits how often the strategy trades
+	Throttle *ThrottleConfig `json:"throttle,omitempty"`
+
+	// SeedFromHoldings has the grid take over base asset the account already
+	// holds at startup: the empty levels at or above the price, which it would
+	// otherwise buy right away, are filled from the holdings instead
+	SeedFromHoldings bool    `json:"seed_from_holdings,omitempty"`
+	SeedMaxQuantity  float64 `json:"seed_max_quantity,omitempty"` // base asset seeding may take (0: all free holdings)
+
+	// Venues optionally spreads the grid's orders over several accounts
+	Venues *GridVenuesConfig `json:"venues,omitempty"`
+}
+
+// GridVenuesConfig has a grid trade one pair on several venues, each a
+// configured account on its own exchange or sub-account. A buy goes to the
+// venue with the lowest ask that holds the quote asset to pay for it, a sell
+// to the one with the highest bid that holds the base asset to deliver.
+// Inventory does not move between venues by itself: when a side has no venue
+// to trade on, the grid suggests a transfer worth making after fees.
+type GridVenuesConfig struct {
+	Accounts    []string           `json:"accounts"`               // account names, at least two
+	TransferFee map[string]float64 `json:"transfer_fee,omitempty"` // withdrawal fee per asset, in the asset
+	MinTransfer map[string]float64 `json:"min_transfer,omitempty"` // smallest withdrawal per asset, in the asset
+}
+
+// BreakoutConfig contains channel breakout parameters. The strategy buys
+// InvestmentAmount when price breaks above the highest high of the last
+// EntryPeriod bars and sells the position when price breaks below the lowest
+// low of the last ExitPeriod bars, or falls ATRMultiplier ATRs below the
+// highest price since entry.
+type BreakoutConfig struct {
+	Symbol           string  `json:"symbol"`
+	InvestmentAmount float64 `json:"investment_amount"`
+	Interval         string  `json:"interval"`       // bar interval, e.g. "1d" (default) or "4h"
+	EntryPeriod      int     `json:"entry_period"`   // bars in the entry channel (default 20)
+	ExitPeriod       int     `json:"exit_period"`    // bars in the exit channel (default 10)
+	ATRPeriod        int     `json:"atr_period"`     // bars in the ATR of the trailing stop (default 14)
+	ATRMultiplier    float64 `json:"atr_multiplier"` // trailing stop distance in ATRs (0 disables)
+	Enabled          bool    `json:"enabled"`
+
+	// ID and Name tell instances apart, as for DCAConfig (default "breakout")
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// Filter is an optional script that can veto or resize entries
+	Filter *SignalFilterConfig `json:"filter,omitempty"`
+
+	// Throttle optionally limits how often the strategy trades
+	Throttle *ThrottleConfig `json:"throttle,omitempty"`
+}
+
+// ThrottleConfig holds pre-trade guardrails; zero fields are disabled
+type ThrottleConfig struct {
+	MinInterval  time.Duration `json:"min_interval"`  // minimum time between orders
+	MaxPerHour   int           `json:"max_per_hour"`  // orders in any rolling hour
+	MaxPerDay    int           `json:"max_per_day"`   // orders in any rolling 24h
+	LossStreak   int           `json:"loss_streak"`   // consecutive losing sells that start a cooldown
+	LossCooldown time.Duration `json:"loss_cooldown"` // pause after a loss streak
+}
+
+// UnmarshalJSON implements custom parsing for durations ("30s", "4h")
+func (t *ThrottleConfig) UnmarshalJSON(data []byte) error {
+	type Alias ThrottleConfig
+	aux := &struct {
+		MinInterval  string `json:"min_interval"`
+		LossCooldown string `json:"loss_cooldown"`
+		*Alias
+	}{
+		Alias: (*Alias)(t),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.MinInterval != "" {
+		duration, err := time.ParseDuration(aux.MinInterval)
+		if err != nil {
+			return fmt.Errorf("invalid min_interval format: %w", err)
+		}
+		t.MinInterval = duration
+	}
+	if aux.LossCooldown != "" {
+		duration, err := time.ParseDuration(aux.LossCooldown)
+		if err != nil {
+			return fmt.Errorf("invalid loss_cooldown format: %w", err)
+		}
+		t.LossCooldown = duration
+	}
+
+	return nil
+}
+
+// ExitConfig configures a take-profit ladder with an optional stop that moves
+// to breakeven after the first targets are hit
+type ExitConfig struct {
+	Targets        []ExitTarget `json:"targets"`         // ascending profit targets
+	StopLoss       float64      `json:"stop_loss"`       // loss below entry that closes the position (0 disables)
+	BreakevenAfter int          `json:"breakeven_after"` // targets hit before the stop moves to entry (0 disables)
+
+	// Tax chooses the lots exits sell; nil sells the oldest first
+	Tax *TaxLotConfig `json:"tax,omitempty"`
+}
+
+// Lot selection methods
+const (
+	LotFIFO     = "fifo"     // oldest first
+	LotHIFO     = "hifo"     // highest cost first
+	LotSpecific = "specific" // the lots that realize the least tax
+)
+
+// TaxLotConfig simulates tax-aware selling: exits dispose of the lots Method
+// picks and report their estimated tax before they are placed. Rates are
+// fractions of the realized gain; losses offset at the same rates.
+type TaxLotConfig struct {
+	Method        string        `json:"method"`          // LotFIFO, LotHIFO or LotSpecific (default)
+	ShortTermRate float64       `json:"short_term_rate"` // e.g. 0.3
+	LongTermRate  float64       `json:"long_term_rate"`  // e.g. 0.15
+	LongTermAfter time.Duration `json:"-"`               // holding period taxed at the long-term rate (default 8760h)
+}
+
+// UnmarshalJSON implements custom parsing for long_term_after
+func (t *TaxLotConfig) UnmarshalJSON(data []byte) error {
+	type Alias TaxLotConfig
+	aux := &struct {
+		LongTermAfter string `json:"long_term_after"`
+		*Alias
+	}{
+		Alias: (*Alias)(t),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.LongTermAfter != "" {
+		duration, err := time.ParseDuration(aux.LongTermAfter)
+		if err != nil {
+			return fmt.Errorf("invalid long_term_after format: %w", err)
+		}
+		t.LongTermAfter = duration
+	}
+	return nil
+}
+
+// TaxLot is a quantity bought at one price and time
+type TaxLot struct {
+	ID       string    `json:"id"`
+	Quantity float64   `json:"quantity"`
+	Price    float64   `json:"price"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// LotSale is the part of a lot an exit sells
+type LotSale struct {
+	Lot       string  `json:"lot"`
+	Quantity  float64 `json:"quantity"`
+	CostBasis float64 `json:"cost_basis"`
+	Gain      float64 `json:"gain"`
+	Treatment string  `json:"treatment"` // short_term or long_term
+}
+
+// TaxImpact is the estimated tax of selling Quantity at Price
+type TaxImpact struct {
+	Method        string    `json:"method"`
+	Quantity      float64   `json:"quantity"`
+	Price         float64   `json:"price"`
+	Proceeds      float64   `json:"proceeds"`
+	CostBasis     float64   `json:"cost_basis"`
+	ShortTermGain float64   `json:"short_term_gain"`
+	LongTermGain  float64   `json:"long_term_gain"`
+	Tax           float64   `json:"tax"` // negative when the exit realizes a deductible loss
+	Lots          []LotSale `json:"lots"`
+}
+
+// ExitTarget sells Fraction of the position once price is Profit above entry.
+// Fractions refer to the largest position held since it was last flat; the
+// last target closes whatever is left.
+type ExitTarget struct {
+	Profit   float64 `json:"profit"`   // e.g. 0.05 for +5%
+	Fraction float64 `json:"fraction"` // e.g. 0.5 for half the position
+}
+
+// SignalFilterConfig configures a Starlark signal filter script
+type SignalFilterConfig struct {
+	Script string `json:"script,omitempty"` // inline source
+	File   string `json:"file,omitempty"`   // path to a .star file, used when Script is empty
 }
 
 // ComboConfig holds combined strategies configuration
 type ComboConfig struct {
 	Strategies []StrategyConfig `json:"strategies"`
 	Enabled    bool             `json:"enabled"`
+
+	// ID and Name tell instances apart, as for DCAConfig (default "combo")
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// Weighting is "equal" (default) or "risk_parity", which re-weights
+	// signals and resizes allocations so each strategy contributes equal PnL volatility
+	Weighting string `json:"weighting,omitempty"`
+	Lookback  int    `json:"lookback,omitempty"` // PnL observations used for risk parity (default 30)
+
+	// MaxTurnover caps the share of the weights a risk parity rebalance moves,
+	// e.g. 0.1 (default 0: unlimited)
+	MaxTurnover float64 `json:"max_turnover,omitempty"`
+
+	// Arbitration ranks sub-strategies on the main account each execution
+	// and limits their buys to the free balance (default: config order, unlimited)
+	Arbitration *ArbitrationConfig `json:"arbitration,omitempty"`
+}
+
+// ArbitrationConfig weighs the factors that rank competing buys; with all
+// factors zero, buys rank by signal strength alone
+type ArbitrationConfig struct {
+	Strength float64 `json:"strength,omitempty"` // weight of the buy signal's strength
+	Edge     float64 `json:"edge,omitempty"`     // weight of the strategy's expected edge
+	Weight   float64 `json:"weight,omitempty"`   // weight of the strategy's combo weight
+
+	// Reserve is quote balance buys may not spend
+	Reserve float64 `json:"reserve,omitempty"`
 }
 
 // StrategyConfig describes a strategy envelope
 type StrategyConfig struct {
 	Type   string                 `json:"type"`
 	Config map[string]interface{} `json:"config"`
+
+	// ID tells the strategy apart within the combo (default <type>_<index>)
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// Account binds the strategy to a configured exchange account (default: the main account)
+	Account string `json:"account,omitempty"`
+
+	// Allocation is the quote capital reserved for this strategy (0 = shared, unlimited)
+	Allocation float64 `json:"allocation,omitempty"`
+
+	// MinWeight and MaxWeight bound the strategy's risk parity weight
+	// (default 0 and 1)
+	MinWeight float64 `json:"min_weight,omitempty"`
+	MaxWeight float64 `json:"max_weight,omitempty"`
+
+	// Edge is the expected return per trade, e.g. 0.004 from a backtest,
+	// used to rank buys under arbitration
+	Edge float64 `json:"edge,omitempty"`
 }
 
 // Portfolio represents a portfolio snapshot
@@ -245,25 +818,52 @@ type Position struct {
 	Timestamp     time.Time
 }
 
+// Valuation represents a portfolio value expressed in a reporting currency
+type Valuation struct {
+	Currency   string
+	TotalValue float64
+	Cash       float64
+	Positions  map[string]float64
+	Timestamp  time.Time
+}
+
 // ExchangeClient is the exchange interface used by strategies
 type ExchangeClient interface {
 	// Order management
 	PlaceOrder(ctx context.Context, order Order) error
 	CancelOrder(ctx context.Context, orderID string) error
 	GetOrder(ctx context.Context, orderID string) (*Order, error)
+	GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*Order, error) // in any state; ErrOrderNotFound if never placed
 	GetActiveOrders(ctx context.Context, symbol string) ([]Order, error)
-	GetFilledOrders(ctx context.Context, symbol string) ([]Order, error)
+	GetFilledOrders(ctx context.Context, symbol string) ([]Order, error) // the most recent ones
+	GetOrderHistory(ctx context.Context, query OrderHistoryQuery) (*OrderHistoryPage, error)
 
 	// Market data
 	GetTicker(ctx context.Context, symbol string) (*Ticker, error)
+	GetTickers(ctx context.Context, symbols []string) ([]Ticker, error) // in one request where the exchange allows
 	GetOrderBook(ctx context.Context, symbol string, limit int) (*OrderBook, error)
 	GetCandles(ctx context.Context, symbol string, interval string, limit int) ([]Candle, error)
 
 	// Account information
 	GetBalance(ctx context.Context) (*Balance, error)
+	GetBalances(ctx context.Context) ([]Balance, error) // every asset with a non-zero balance
 	GetTradingFees(ctx context.Context, symbol string) (*TradingFees, error)
 
+	// Order size rules and dust conversion; ErrNotSupported where not applicable
+	GetSymbolRules(ctx context.Context, symbol string) (*SymbolRules, error)
+	ConvertDust(ctx context.Context, assets []string) (*DustConversion, error)
+
+	// Carry costs for perpetual and margin modes; ErrNotSupported where not applicable
+	GetFundingRate(ctx context.Context, symbol string) (*FundingRate, error)
+	GetBorrowRates(ctx context.Context, assets []string) ([]BorrowRate, error)
+
+	// Flexible savings for idle balances; ErrNotSupported where not applicable
+	GetSavings(ctx context.Context, asset string) (*SavingsBalance, error)
+	SubscribeSavings(ctx context.Context, asset string, amount float64) error
+	RedeemSavings(ctx context.Context, asset string, amount float64) error
+
 	// Connection management
+	GetSystemStatus(ctx context.Context) (*SystemStatus, error)
 	Ping(ctx context.Context) error
 	Close() error
 }